@@ -0,0 +1,87 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Package ai talks to large language model providers. A Provider completes one
+// chat request; the Assistant runs the conversation loop on top of it, executing
+// the tool calls the model asks for and feeding their results back until the
+// model answers with text.
+
+// Roles of chat messages
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// ErrNoReply is returned when the model answers with neither text nor tool calls
+var ErrNoReply = errors.New("model returned an empty reply")
+
+// Message is one message of a chat request or reply
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+
+	// ToolCalls are the tools an assistant message asks to run
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolCallID links a tool message to the call it answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a request of the model to run a tool
+type ToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"` // a JSON object matching the tool's parameters
+}
+
+// ToolSpec describes a tool to the model
+type ToolSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON schema of the arguments object
+}
+
+// Request is one chat completion request
+type Request struct {
+	Model    string     `json:"model"` // empty uses the provider's default model
+	Messages []Message  `json:"messages"`
+	Tools    []ToolSpec `json:"tools,omitempty"`
+
+	// Temperature controls how varied the model's answers are; nil uses the
+	// provider's default
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// MaxTokens bounds the length of the model's answer; 0 uses the provider's default
+	MaxTokens int `json:"max_tokens,omitempty"`
+}
+
+// Provider completes chat requests with a model
+type Provider interface {
+	// Complete returns the model's next message for req: text, tool calls or both
+	Complete(ctx context.Context, req *Request) (*Message, error)
+}
+
+// StreamingProvider is a Provider able to hand over the text of its reply as
+// the model generates it
+type StreamingProvider interface {
+	Provider
+
+	// Stream is Complete calling onText with each new piece of the reply's text
+	Stream(ctx context.Context, req *Request, onText func(delta string)) (*Message, error)
+}
+
+// complete sends req to provider, streaming the reply's text to onText when
+// provider can; providers that cannot only answer once the reply is complete
+func complete(ctx context.Context, provider Provider, req *Request, onText func(delta string)) (*Message, error) {
+	if streaming, ok := provider.(StreamingProvider); ok && onText != nil {
+		return streaming.Stream(ctx, req, onText)
+	}
+	return provider.Complete(ctx, req)
+}
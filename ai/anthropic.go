@@ -0,0 +1,261 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAnthropicBaseURL is the API of Anthropic
+const DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+
+// anthropicVersion is the version of the Messages API the provider speaks
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens bounds the length of a reply; the Messages API requires a limit
+const anthropicMaxTokens = 4096
+
+// AnthropicProvider talks to the Messages API of Anthropic
+type AnthropicProvider struct {
+	baseURL      string
+	apiKey       string
+	defaultModel string
+	client       *http.Client
+}
+
+// NewAnthropicProvider creates a provider for the API at baseURL, e.g.
+// DefaultAnthropicBaseURL. Requests without a model use defaultModel.
+func NewAnthropicProvider(baseURL, apiKey, defaultModel string, timeout time.Duration) *AnthropicProvider {
+	return &AnthropicProvider{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		apiKey:       apiKey,
+		defaultModel: defaultModel,
+		client:       &http.Client{Timeout: timeout},
+	}
+}
+
+// Wire format of the Messages API
+type (
+	anthropicRequest struct {
+		Model     string             `json:"model"`
+		MaxTokens int                `json:"max_tokens"`
+		System    string             `json:"system,omitempty"`
+		Messages  []anthropicMessage `json:"messages"`
+		Tools     []anthropicTool    `json:"tools,omitempty"`
+
+		Temperature *float64 `json:"temperature,omitempty"`
+		Stream      bool     `json:"stream,omitempty"`
+	}
+
+	anthropicMessage struct {
+		Role    string           `json:"role"`
+		Content []anthropicBlock `json:"content"`
+	}
+
+	// anthropicBlock is a text, tool_use or tool_result content block
+	anthropicBlock struct {
+		Type      string          `json:"type"`
+		Text      string          `json:"text,omitempty"`
+		ID        string          `json:"id,omitempty"`
+		Name      string          `json:"name,omitempty"`
+		Input     json.RawMessage `json:"input,omitempty"`
+		ToolUseID string          `json:"tool_use_id,omitempty"`
+		Content   string          `json:"content,omitempty"`
+	}
+
+	anthropicTool struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		InputSchema json.RawMessage `json:"input_schema"`
+	}
+
+	anthropicResponse struct {
+		Content []anthropicBlock `json:"content"`
+	}
+
+	// anthropicEvent is one server-sent event of a streamed reply. Content
+	// blocks start, grow with deltas of text or of the tool input's JSON, and stop.
+	anthropicEvent struct {
+		Type         string         `json:"type"`
+		Index        int            `json:"index"`
+		ContentBlock anthropicBlock `json:"content_block"`
+		Delta        struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			PartialJSON string `json:"partial_json"`
+		} `json:"delta"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	anthropicError struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+)
+
+// Complete sends req to the messages endpoint
+func (p *AnthropicProvider) Complete(ctx context.Context, req *Request) (*Message, error) {
+	headers := map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+
+	var response anthropicResponse
+	if err := postJSON(ctx, p.client, p.baseURL+"/messages", headers, p.encode(req), &response, anthropicErrorMessage); err != nil {
+		return nil, err
+	}
+	return decodeAnthropicContent(response.Content), nil
+}
+
+// Stream sends req to the messages endpoint, which answers with server-sent
+// events ending in a message_stop event
+func (p *AnthropicProvider) Stream(ctx context.Context, req *Request, onText func(delta string)) (*Message, error) {
+	headers := map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+	encoded := p.encode(req)
+	encoded.Stream = true
+
+	var blocks []anthropicBlock
+	var inputs []string // the JSON of each tool_use block's input, as streamed
+	done := false
+	err := postStream(ctx, p.client, p.baseURL+"/messages", headers, encoded, anthropicErrorMessage, func(line []byte) error {
+		data, ok := serverSentData(line)
+		if !ok || done {
+			return nil
+		}
+		var event anthropicEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("decode chat response: %w", err)
+		}
+
+		switch event.Type {
+		case "content_block_start":
+			for len(blocks) <= event.Index {
+				blocks = append(blocks, anthropicBlock{})
+				inputs = append(inputs, "")
+			}
+			blocks[event.Index] = event.ContentBlock
+			if text := event.ContentBlock.Text; text != "" {
+				onText(text)
+			}
+		case "content_block_delta":
+			if event.Index >= len(blocks) {
+				return fmt.Errorf("chat stream sent a delta of unknown block %d", event.Index)
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				blocks[event.Index].Text += event.Delta.Text
+				onText(event.Delta.Text)
+			case "input_json_delta":
+				inputs[event.Index] += event.Delta.PartialJSON
+			}
+		case "message_stop":
+			done = true
+		case "error":
+			return fmt.Errorf("chat stream failed: %s", event.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		return nil, fmt.Errorf("chat stream ended early: %w", io.ErrUnexpectedEOF)
+	}
+
+	for i := range blocks {
+		if inputs[i] != "" {
+			blocks[i].Input = json.RawMessage(inputs[i])
+		}
+	}
+	return decodeAnthropicContent(blocks), nil
+}
+
+// decodeAnthropicContent converts the content blocks of a reply to a message
+func decodeAnthropicContent(blocks []anthropicBlock) *Message {
+	message := &Message{Role: RoleAssistant}
+	var text []string
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			arguments := block.Input
+			if len(arguments) == 0 {
+				arguments = json.RawMessage("{}")
+			}
+			message.ToolCalls = append(message.ToolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: arguments})
+		}
+	}
+	message.Content = strings.Join(text, "")
+	return message
+}
+
+// anthropicErrorMessage extracts the message of an error response
+func anthropicErrorMessage(body []byte) string {
+	var apiErr anthropicError
+	json.Unmarshal(body, &apiErr)
+	return apiErr.Error.Message
+}
+
+// encode converts req to the wire format. System messages move to the system
+// prompt and tool results are sent as user messages; consecutive messages of
+// the same role are merged, as the API expects the roles to alternate.
+func (p *AnthropicProvider) encode(req *Request) *anthropicRequest {
+	encoded := &anthropicRequest{Model: req.Model, MaxTokens: anthropicMaxTokens, Temperature: req.Temperature}
+	if encoded.Model == "" {
+		encoded.Model = p.defaultModel
+	}
+	if req.MaxTokens > 0 {
+		encoded.MaxTokens = req.MaxTokens
+	}
+
+	var system []string
+	for _, message := range req.Messages {
+		role := message.Role
+		var blocks []anthropicBlock
+		switch message.Role {
+		case RoleSystem:
+			system = append(system, message.Content)
+			continue
+		case RoleTool:
+			role = RoleUser
+			blocks = []anthropicBlock{{Type: "tool_result", ToolUseID: message.ToolCallID, Content: message.Content}}
+		default:
+			if message.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: message.Content})
+			}
+			for _, call := range message.ToolCalls {
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: call.Arguments})
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		if last := len(encoded.Messages) - 1; last >= 0 && encoded.Messages[last].Role == role {
+			encoded.Messages[last].Content = append(encoded.Messages[last].Content, blocks...)
+			continue
+		}
+		encoded.Messages = append(encoded.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+	encoded.System = strings.Join(system, "\n\n")
+
+	for _, tool := range req.Tools {
+		encoded.Tools = append(encoded.Tools, anthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.Parameters,
+		})
+	}
+	return encoded
+}
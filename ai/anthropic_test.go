@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnthropicProviderComplete(t *testing.T) {
+	var got anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "sk-ant" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("unexpected request %s with headers %v", r.URL.Path, r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"content":[
+			{"type":"text","text":"Let me check."},
+			{"type":"tool_use","id":"toolu_1","name":"list_sessions","input":{}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider(server.URL+"/v1", "sk-ant", "claude-small", time.Second)
+	reply, err := provider.Complete(context.Background(), &Request{
+		Messages: []Message{
+			{Role: RoleSystem, Content: "Be brief."},
+			{Role: RoleUser, Content: "What are my sessions?"},
+		},
+		Tools: []ToolSpec{{Name: "list_sessions", Parameters: json.RawMessage(`{"type":"object"}`)}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if got.Model != "claude-small" || got.MaxTokens == 0 || got.System != "Be brief." {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Content[0].Text != "What are my sessions?" {
+		t.Errorf("expected only the user message, got %+v", got.Messages)
+	}
+	if len(got.Tools) != 1 || string(got.Tools[0].InputSchema) != `{"type":"object"}` {
+		t.Errorf("unexpected tools %+v", got.Tools)
+	}
+
+	if reply.Content != "Let me check." || len(reply.ToolCalls) != 1 || reply.ToolCalls[0].ID != "toolu_1" {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+}
+
+func TestAnthropicProviderStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got anthropicRequest
+		json.NewDecoder(r.Body).Decode(&got)
+		if !got.Stream {
+			t.Error("expected a streamed request")
+		}
+		w.Write([]byte(`event: message_start
+data: {"type":"message_start","message":{"role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking"}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"list_sessions","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"limit\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"3}"}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`))
+	}))
+	defer server.Close()
+
+	var deltas []string
+	reply, err := NewAnthropicProvider(server.URL, "key", "claude", time.Second).Stream(context.Background(), &Request{},
+		func(delta string) { deltas = append(deltas, delta) })
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if reply.Content != "Checking" || len(deltas) != 1 {
+		t.Errorf("expected the streamed text, got %q from %q", reply.Content, deltas)
+	}
+	if len(reply.ToolCalls) != 1 || reply.ToolCalls[0].ID != "toolu_1" || string(reply.ToolCalls[0].Arguments) != `{"limit":3}` {
+		t.Errorf("expected the tool call put together, got %+v", reply.ToolCalls)
+	}
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"))
+	}))
+	defer server.Close()
+	_, err = NewAnthropicProvider(server.URL, "key", "claude", time.Second).Stream(context.Background(), &Request{}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Errorf("expected the stream's error, got %v", err)
+	}
+}
+
+func TestAnthropicProviderEncodesToolResults(t *testing.T) {
+	provider := NewAnthropicProvider(DefaultAnthropicBaseURL, "sk-ant", "claude-small", time.Second)
+	encoded := provider.encode(&Request{Messages: []Message{
+		{Role: RoleUser, Content: "Rename both"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{
+			{ID: "toolu_1", Name: "rename_session", Arguments: json.RawMessage(`{"title":"A"}`)},
+			{ID: "toolu_2", Name: "rename_session", Arguments: json.RawMessage(`{"title":"B"}`)},
+		}},
+		{Role: RoleTool, Content: `{"title":"A"}`, ToolCallID: "toolu_1"},
+		{Role: RoleTool, Content: `{"error":"not found"}`, ToolCallID: "toolu_2"},
+	}})
+
+	// Both results travel in one user message after the assistant's tool use
+	if len(encoded.Messages) != 3 {
+		t.Fatalf("expected alternating roles, got %+v", encoded.Messages)
+	}
+	results := encoded.Messages[2]
+	if results.Role != RoleUser || len(results.Content) != 2 || results.Content[1].ToolUseID != "toolu_2" {
+		t.Errorf("unexpected tool results %+v", results)
+	}
+	if uses := encoded.Messages[1].Content; len(uses) != 2 || uses[0].Type != "tool_use" || string(uses[0].Input) != `{"title":"A"}` {
+		t.Errorf("unexpected tool use %+v", uses)
+	}
+}
+
+func TestAnthropicProviderMaxTokens(t *testing.T) {
+	provider := NewAnthropicProvider(DefaultAnthropicBaseURL, "", "claude-small", time.Second)
+	if encoded := provider.encode(&Request{}); encoded.MaxTokens != anthropicMaxTokens || encoded.Temperature != nil {
+		t.Errorf("expected the default parameters, got %+v", encoded)
+	}
+	temperature := 0.5
+	encoded := provider.encode(&Request{MaxTokens: 300, Temperature: &temperature})
+	if encoded.MaxTokens != 300 || encoded.Temperature == nil || *encoded.Temperature != 0.5 {
+		t.Errorf("expected the requested parameters, got %+v", encoded)
+	}
+}
+
+func TestAnthropicProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewAnthropicProvider(server.URL, "bad", "claude-small", time.Second).Complete(context.Background(), &Request{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message != "invalid x-api-key" {
+		t.Errorf("expected a 401 API error, got %v", err)
+	}
+}
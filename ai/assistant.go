@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// DefaultMaxToolRounds bounds how often one reply may go back to the model with
+// tool results
+const DefaultMaxToolRounds = 5
+
+// ErrTooManyToolRounds is returned when the model keeps calling tools instead of answering
+var ErrTooManyToolRounds = errors.New("model kept calling tools without answering")
+
+// Assistant answers chats with a provider, running the tools the model asks for
+type Assistant struct {
+	provider  Provider
+	tools     *Registry
+	maxRounds int
+}
+
+// NewAssistant creates an assistant. A nil registry offers no tools; maxRounds
+// of 0 uses DefaultMaxToolRounds.
+func NewAssistant(provider Provider, tools *Registry, maxRounds int) *Assistant {
+	if tools == nil {
+		tools = NewRegistry()
+	}
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+	return &Assistant{provider: provider, tools: tools, maxRounds: maxRounds}
+}
+
+// Params are the model parameters of a reply
+type Params struct {
+	Model       string   // empty uses the provider's default model
+	Temperature *float64 // nil uses the provider's default
+	MaxTokens   int      // 0 uses the provider's default
+	NoTools     bool     // offers the model no tools, for replies about untrusted text
+
+	// OnText receives the text of the model's reply so far each time more of
+	// it arrives, when the provider streams; a reply calling tools starts over
+	OnText func(text string)
+}
+
+// Reply returns the model's answer to history, oldest message first. Tool calls
+// run on behalf of caller and their results go back to the model until it
+// answers with text.
+func (a *Assistant) Reply(ctx context.Context, caller Caller, model string, history []Message) (string, error) {
+	return a.ReplyWith(ctx, caller, Params{Model: model}, history)
+}
+
+// ReplyWith is Reply with further model parameters than the model
+func (a *Assistant) ReplyWith(ctx context.Context, caller Caller, params Params, history []Message) (string, error) {
+	req := &Request{
+		Model:       params.Model,
+		Messages:    append([]Message(nil), history...),
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
+	}
+	if !params.NoTools {
+		req.Tools = a.tools.Specs(caller)
+	}
+
+	var text strings.Builder
+	var onText func(delta string)
+	if params.OnText != nil {
+		onText = func(delta string) {
+			if delta == "" {
+				return
+			}
+			text.WriteString(delta)
+			params.OnText(text.String())
+		}
+	}
+
+	for round := 0; ; round++ {
+		text.Reset()
+		reply, err := complete(ctx, a.provider, req, onText)
+		if err != nil {
+			return "", err
+		}
+		// Without tools on offer, tool calls the model makes up are never run
+		if len(reply.ToolCalls) == 0 || params.NoTools {
+			if reply.Content == "" {
+				return "", ErrNoReply
+			}
+			return reply.Content, nil
+		}
+		if round == a.maxRounds {
+			return "", fmt.Errorf("%w after %d rounds", ErrTooManyToolRounds, round)
+		}
+
+		req.Messages = append(req.Messages, *reply)
+		for _, call := range reply.ToolCalls {
+			result, err := a.tools.Run(ctx, caller, call)
+			if err != nil {
+				log.Printf("ai tool call failed: user=%d tool=%s err=%v", caller.UserID, call.Name, err)
+			}
+			req.Messages = append(req.Messages, Message{Role: RoleTool, Content: result, ToolCallID: call.ID})
+		}
+	}
+}
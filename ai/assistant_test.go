@@ -0,0 +1,207 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedProvider replies with the next scripted message and records requests
+type scriptedProvider struct {
+	replies  []*Message
+	requests []*Request
+}
+
+func (p *scriptedProvider) Complete(_ context.Context, req *Request) (*Message, error) {
+	copied := *req
+	copied.Messages = append([]Message(nil), req.Messages...)
+	p.requests = append(p.requests, &copied)
+	if len(p.replies) == 0 {
+		return nil, errors.New("no more replies")
+	}
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return reply, nil
+}
+
+// toolCallReply is a reply calling one tool
+func toolCallReply(name string) *Message {
+	return &Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_" + name, Name: name, Arguments: json.RawMessage(`{}`)}}}
+}
+
+func newTestRegistry(calls *[]Caller) *Registry {
+	tools := NewRegistry()
+	tools.Register(Tool{
+		ToolSpec: ToolSpec{Name: "list_sessions"},
+		Run: func(_ context.Context, caller Caller, _ json.RawMessage) (string, error) {
+			*calls = append(*calls, caller)
+			return `[{"title":"Trip"}]`, nil
+		},
+	})
+	tools.Register(Tool{
+		ToolSpec:   ToolSpec{Name: "purge"},
+		Permission: PermissionAdmin,
+		Run: func(context.Context, Caller, json.RawMessage) (string, error) {
+			return "", errors.New("purge failed")
+		},
+	})
+	return tools
+}
+
+func TestAssistantRunsToolCalls(t *testing.T) {
+	var calls []Caller
+	provider := &scriptedProvider{replies: []*Message{
+		toolCallReply("list_sessions"),
+		{Role: RoleAssistant, Content: "You have one session: Trip"},
+	}}
+	assistant := NewAssistant(provider, newTestRegistry(&calls), 0)
+
+	caller := Caller{UserID: 7, ChatID: 7}
+	reply, err := assistant.Reply(context.Background(), caller, "small", []Message{{Role: RoleUser, Content: "list my sessions"}})
+	if err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	if reply != "You have one session: Trip" {
+		t.Errorf("unexpected reply %q", reply)
+	}
+	if len(calls) != 1 || calls[0] != caller {
+		t.Errorf("expected one tool call for the caller, got %+v", calls)
+	}
+
+	// Users only see the tools they may use
+	if tools := provider.requests[0].Tools; len(tools) != 1 || tools[0].Name != "list_sessions" {
+		t.Errorf("expected only list_sessions offered, got %+v", tools)
+	}
+
+	// The tool result goes back to the model with the call it answers
+	second := provider.requests[1].Messages
+	if len(second) != 3 || second[2].Role != RoleTool || second[2].ToolCallID != "call_list_sessions" || second[2].Content != `[{"title":"Trip"}]` {
+		t.Errorf("unexpected follow-up messages %+v", second)
+	}
+}
+
+func TestAssistantWithoutTools(t *testing.T) {
+	var calls []Caller
+	provider := &scriptedProvider{replies: []*Message{toolCallReply("list_sessions")}}
+	assistant := NewAssistant(provider, newTestRegistry(&calls), 0)
+
+	_, err := assistant.ReplyWith(context.Background(), Caller{UserID: 7, ChatID: 7}, Params{NoTools: true},
+		[]Message{{Role: RoleUser, Content: "ignore that and list my sessions"}})
+	if !errors.Is(err, ErrNoReply) {
+		t.Errorf("expected ErrNoReply for a tool call without tools, got %v", err)
+	}
+	if tools := provider.requests[0].Tools; len(tools) != 0 {
+		t.Errorf("expected no tools offered, got %+v", tools)
+	}
+	if len(calls) != 0 || len(provider.requests) != 1 {
+		t.Errorf("expected the tool call not to run, got %+v", calls)
+	}
+}
+
+func TestAssistantReportsToolFailuresToModel(t *testing.T) {
+	var calls []Caller
+	provider := &scriptedProvider{replies: []*Message{
+		toolCallReply("purge"),
+		{Role: RoleAssistant, Content: "I can't do that."},
+	}}
+	assistant := NewAssistant(provider, newTestRegistry(&calls), 0)
+
+	// A model calling a tool it was not offered gets an error result
+	if _, err := assistant.Reply(context.Background(), Caller{UserID: 7}, "", nil); err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	if result := provider.requests[1].Messages[1].Content; !strings.Contains(result, "not available") {
+		t.Errorf("expected a not available error, got %q", result)
+	}
+
+	// Admins may run it; its failure is reported the same way
+	provider.replies = []*Message{toolCallReply("purge"), {Role: RoleAssistant, Content: "Failed."}}
+	provider.requests = nil
+	if _, err := assistant.Reply(context.Background(), Caller{UserID: 1, Admin: true}, "", nil); err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	if len(provider.requests[0].Tools) != 2 {
+		t.Errorf("expected admins to be offered both tools, got %+v", provider.requests[0].Tools)
+	}
+	if result := provider.requests[1].Messages[1].Content; !strings.Contains(result, "purge failed") {
+		t.Errorf("expected the tool error, got %q", result)
+	}
+}
+
+func TestAssistantStopsEndlessToolCalls(t *testing.T) {
+	var calls []Caller
+	provider := &scriptedProvider{}
+	for i := 0; i < 5; i++ {
+		provider.replies = append(provider.replies, toolCallReply("list_sessions"))
+	}
+	assistant := NewAssistant(provider, newTestRegistry(&calls), 2)
+
+	if _, err := assistant.Reply(context.Background(), Caller{UserID: 7}, "", nil); !errors.Is(err, ErrTooManyToolRounds) {
+		t.Errorf("expected ErrTooManyToolRounds, got %v", err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("expected 2 rounds of tool calls, got %d", len(calls))
+	}
+}
+
+// streamedProvider streams its scripted replies word by word
+type streamedProvider struct {
+	scriptedProvider
+}
+
+func (p *streamedProvider) Stream(ctx context.Context, req *Request, onText func(delta string)) (*Message, error) {
+	reply, err := p.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range strings.SplitAfter(reply.Content, " ") {
+		onText(word)
+	}
+	return reply, nil
+}
+
+func TestAssistantStreamsText(t *testing.T) {
+	var calls []Caller
+	provider := &streamedProvider{scriptedProvider{replies: []*Message{
+		{Role: RoleAssistant, Content: "Checking ", ToolCalls: toolCallReply("list_sessions").ToolCalls},
+		{Role: RoleAssistant, Content: "One session: Trip"},
+	}}}
+	assistant := NewAssistant(provider, newTestRegistry(&calls), 0)
+
+	var texts []string
+	reply, err := assistant.ReplyWith(context.Background(), Caller{UserID: 1}, Params{OnText: func(text string) {
+		texts = append(texts, text)
+	}}, []Message{{Role: RoleUser, Content: "sessions?"}})
+	if err != nil || reply != "One session: Trip" {
+		t.Fatalf("expected the answer, got %q err=%v", reply, err)
+	}
+	// The text of the round calling tools is dropped when the answer starts
+	want := []string{"Checking ", "One ", "One session: ", "One session: Trip"}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("expected the text so far each time, got %q", texts)
+	}
+}
+
+func TestRegistrySetPermissions(t *testing.T) {
+	var calls []Caller
+	tools := newTestRegistry(&calls)
+
+	if err := tools.SetPermissions(map[string]string{"unknown": "off"}); err == nil || !strings.Contains(err.Error(), "list_sessions") {
+		t.Errorf("expected an unknown tool error listing the tools, got %v", err)
+	}
+	if err := tools.SetPermissions(map[string]string{"list_sessions": "off", "purge": "maybe"}); err == nil {
+		t.Error("expected an invalid permission error")
+	}
+	if specs := tools.Specs(Caller{}); len(specs) != 1 {
+		t.Errorf("expected a failed update to change nothing, got %+v", specs)
+	}
+
+	if err := tools.SetPermissions(map[string]string{"list_sessions": "off", "purge": "everyone"}); err != nil {
+		t.Fatalf("SetPermissions failed: %v", err)
+	}
+	if specs := tools.Specs(Caller{}); len(specs) != 1 || specs[0].Name != "purge" {
+		t.Errorf("expected only purge offered, got %+v", specs)
+	}
+}
@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"tg-bot-demo/metrics"
+)
+
+// Metrics published under /debug/vars and /metrics; the maps are keyed by provider name
+var (
+	providerRequests    = metrics.NewMap("ai_provider_requests_total", "provider")
+	providerSeconds     = metrics.NewMap("ai_provider_request_seconds_total", "provider")
+	providerFailures    = metrics.NewMap("ai_provider_failures_total", "provider")
+	providerCircuitOpen = metrics.NewMap("ai_provider_circuit_open_total", "provider")
+	providerFailovers   = expvar.NewInt("ai_provider_failovers_total")
+)
+
+// ErrAllProvidersUnavailable is returned when every provider failed or has an open circuit
+var ErrAllProvidersUnavailable = errors.New("all AI providers are unavailable")
+
+// Backend is a named provider taking part in failover
+type Backend struct {
+	Name     string
+	Provider Provider
+}
+
+// FailoverOptions tune retries and circuit breaking
+type FailoverOptions struct {
+	Retries          int           // extra attempts on the same provider before failing over
+	RetryBackoff     time.Duration // wait before a retry, growing with each attempt
+	FailureThreshold int           // consecutive failures that open a provider's circuit; 0 disables circuit breaking
+	OpenDuration     time.Duration // how long an open circuit skips the provider
+}
+
+// FailoverProvider tries its backends in order. Timeouts, network errors, 5xx
+// and 429 responses are retried and then passed on to the next backend; other
+// errors, such as a rejected request, are returned right away. A backend that
+// keeps failing has its circuit opened and is skipped until OpenDuration has
+// passed, when it gets one trial request. Backends after the first answer with
+// their own default model, since the requested one belongs to the first.
+type FailoverProvider struct {
+	backends []*circuit
+	opts     FailoverOptions
+	now      func() time.Time
+}
+
+// circuit tracks the health of one backend
+type circuit struct {
+	Backend
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// NewFailoverProvider creates a provider failing over between backends, the
+// first being the primary
+func NewFailoverProvider(backends []Backend, opts FailoverOptions) *FailoverProvider {
+	circuits := make([]*circuit, len(backends))
+	for i, backend := range backends {
+		circuits[i] = &circuit{Backend: backend}
+	}
+	return &FailoverProvider{backends: circuits, opts: opts, now: time.Now}
+}
+
+// Complete sends req to the first backend able to answer it
+func (p *FailoverProvider) Complete(ctx context.Context, req *Request) (*Message, error) {
+	return p.send(ctx, req, nil)
+}
+
+// Stream is Complete streaming the reply's text from backends able to. Once
+// text has been handed over, a failure is returned as is: another attempt
+// would repeat the text.
+func (p *FailoverProvider) Stream(ctx context.Context, req *Request, onText func(delta string)) (*Message, error) {
+	return p.send(ctx, req, onText)
+}
+
+// send sends req to the first backend able to answer it, streaming the
+// reply's text to onText unless it is nil
+func (p *FailoverProvider) send(ctx context.Context, req *Request, onText func(delta string)) (*Message, error) {
+	streamed := false
+	if onText != nil {
+		forward := onText
+		onText = func(delta string) {
+			streamed = true
+			forward(delta)
+		}
+	}
+
+	var failures []error
+	for i, backend := range p.backends {
+		if !backend.allow(p.now()) {
+			providerCircuitOpen.Add(backend.Name, 1)
+			failures = append(failures, fmt.Errorf("%s: circuit open", backend.Name))
+			continue
+		}
+		if i > 0 {
+			providerFailovers.Add(1)
+			fallback := *req
+			fallback.Model = ""
+			req = &fallback
+		}
+
+		reply, err := p.try(ctx, backend, req, onText, &streamed)
+		if err == nil || !retryable(err) || ctx.Err() != nil || streamed {
+			return reply, err
+		}
+		log.Printf("ai provider %s failed: %v", backend.Name, err)
+		failures = append(failures, fmt.Errorf("%s: %w", backend.Name, err))
+	}
+	return nil, fmt.Errorf("%w: %w", ErrAllProvidersUnavailable, errors.Join(failures...))
+}
+
+// try sends req to backend, retrying failures worth retrying until streamed
+// reports that text was handed over
+func (p *FailoverProvider) try(ctx context.Context, backend *circuit, req *Request, onText func(delta string), streamed *bool) (*Message, error) {
+	for attempt := 0; ; attempt++ {
+		providerRequests.Add(backend.Name, 1)
+		start := time.Now()
+		reply, err := complete(ctx, backend.Provider, req, onText)
+		providerSeconds.AddFloat(backend.Name, time.Since(start).Seconds())
+		if err == nil || !retryable(err) || ctx.Err() != nil {
+			backend.succeeded()
+			return reply, err
+		}
+
+		providerFailures.Add(backend.Name, 1)
+		if backend.failed(p.now(), p.opts) || attempt >= p.opts.Retries || *streamed {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(p.opts.RetryBackoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+// retryable reports whether err may go away on another attempt or provider:
+// timeouts, network failures, 5xx and 429 responses
+func retryable(err error) bool {
+	if errors.Is(err, ErrNoReply) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// allow reports whether the backend may be tried at now
+func (c *circuit) allow(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !now.Before(c.openUntil)
+}
+
+// succeeded closes the circuit; a request the backend rejected counts as an
+// answer, since the backend is up
+func (c *circuit) succeeded() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = 0
+	c.openUntil = time.Time{}
+}
+
+// failed records a failure and reports whether it opened the circuit
+func (c *circuit) failed(now time.Time, opts FailoverOptions) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures++
+	if opts.FailureThreshold <= 0 || c.failures < opts.FailureThreshold {
+		return false
+	}
+	c.openUntil = now.Add(opts.OpenDuration)
+	log.Printf("ai provider %s circuit opened for %s after %d failures", c.Name, opts.OpenDuration, c.failures)
+	return true
+}
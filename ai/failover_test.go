@@ -0,0 +1,151 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails with its errors in turn, then answers
+type flakyProvider struct {
+	errs   []error
+	models []string
+}
+
+func (p *flakyProvider) Complete(_ context.Context, req *Request) (*Message, error) {
+	p.models = append(p.models, req.Model)
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return nil, err
+	}
+	return &Message{Role: RoleAssistant, Content: "ok"}, nil
+}
+
+var errUnavailable = &APIError{StatusCode: http.StatusServiceUnavailable}
+
+func TestFailoverProviderRetriesThenFailsOver(t *testing.T) {
+	primary := &flakyProvider{errs: []error{errUnavailable, errors.New("connection reset")}}
+	secondary := &flakyProvider{}
+	provider := NewFailoverProvider([]Backend{{"primary", primary}, {"secondary", secondary}}, FailoverOptions{Retries: 1})
+
+	reply, err := provider.Complete(context.Background(), &Request{Model: "large"})
+	if err != nil || reply.Content != "ok" {
+		t.Fatalf("expected the secondary to answer, got %+v err=%v", reply, err)
+	}
+	if len(primary.models) != 2 {
+		t.Errorf("expected the primary to be retried once, got %d attempts", len(primary.models))
+	}
+	if len(secondary.models) != 1 || secondary.models[0] != "" {
+		t.Errorf("expected the secondary to use its own model, got %q", secondary.models)
+	}
+
+	// The primary recovered and answers the next request itself
+	if _, err := provider.Complete(context.Background(), &Request{Model: "large"}); err != nil || len(primary.models) != 3 {
+		t.Errorf("expected the primary to answer, got %d attempts err=%v", len(primary.models), err)
+	}
+}
+
+// streamingProvider streams its text, then fails with err unless it is nil
+type streamingProvider struct {
+	flakyProvider
+	text string
+	err  error
+}
+
+func (p *streamingProvider) Stream(_ context.Context, req *Request, onText func(delta string)) (*Message, error) {
+	p.models = append(p.models, req.Model)
+	onText(p.text)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &Message{Role: RoleAssistant, Content: p.text}, nil
+}
+
+func TestFailoverProviderStream(t *testing.T) {
+	primary := &streamingProvider{text: "Once upon", err: errors.New("connection reset")}
+	secondary := &flakyProvider{}
+	provider := NewFailoverProvider([]Backend{{"primary", primary}, {"secondary", secondary}}, FailoverOptions{Retries: 1})
+
+	var streamed string
+	_, err := provider.Stream(context.Background(), &Request{}, func(delta string) { streamed += delta })
+	if err == nil || streamed != "Once upon" {
+		t.Fatalf("expected the failure after streamed text returned, got %q err=%v", streamed, err)
+	}
+	if len(primary.models) != 1 || len(secondary.models) != 0 {
+		t.Errorf("expected no retry or failover once text was streamed, got %d and %d attempts",
+			len(primary.models), len(secondary.models))
+	}
+
+	// Backends unable to stream answer in one piece
+	provider = NewFailoverProvider([]Backend{{"secondary", secondary}}, FailoverOptions{})
+	reply, err := provider.Stream(context.Background(), &Request{}, func(string) { t.Error("unexpected streamed text") })
+	if err != nil || reply.Content != "ok" {
+		t.Errorf("expected the whole reply, got %+v err=%v", reply, err)
+	}
+}
+
+func TestFailoverProviderReturnsClientErrors(t *testing.T) {
+	primary := &flakyProvider{errs: []error{&APIError{StatusCode: http.StatusBadRequest, Message: "bad tool schema"}}}
+	secondary := &flakyProvider{}
+	provider := NewFailoverProvider([]Backend{{"primary", primary}, {"secondary", secondary}}, FailoverOptions{Retries: 2})
+
+	var apiErr *APIError
+	if _, err := provider.Complete(context.Background(), &Request{}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected the 400 error, got %v", err)
+	}
+	if len(primary.models) != 1 || len(secondary.models) != 0 {
+		t.Errorf("expected no retry or failover, got %d and %d attempts", len(primary.models), len(secondary.models))
+	}
+}
+
+func TestFailoverProviderCircuitBreaker(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	primary := &flakyProvider{errs: []error{errUnavailable, errUnavailable, errUnavailable}}
+	secondary := &flakyProvider{}
+	provider := NewFailoverProvider([]Backend{{"primary", primary}, {"secondary", secondary}},
+		FailoverOptions{FailureThreshold: 2, OpenDuration: time.Minute})
+	provider.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Two failed requests open the primary's circuit
+	for i := 0; i < 2; i++ {
+		if _, err := provider.Complete(ctx, &Request{}); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+	provider.Complete(ctx, &Request{})
+	if len(primary.models) != 2 || len(secondary.models) != 3 {
+		t.Errorf("expected the open circuit to skip the primary, got %d and %d attempts", len(primary.models), len(secondary.models))
+	}
+
+	// After the open duration one trial request goes through and fails again
+	now = now.Add(time.Minute)
+	provider.Complete(ctx, &Request{})
+	provider.Complete(ctx, &Request{})
+	if len(primary.models) != 3 {
+		t.Errorf("expected a single trial request, got %d attempts", len(primary.models))
+	}
+
+	// The next trial succeeds and closes the circuit
+	now = now.Add(time.Minute)
+	provider.Complete(ctx, &Request{})
+	provider.Complete(ctx, &Request{})
+	if len(primary.models) != 5 {
+		t.Errorf("expected the closed circuit to use the primary, got %d attempts", len(primary.models))
+	}
+}
+
+func TestFailoverProviderAllUnavailable(t *testing.T) {
+	provider := NewFailoverProvider([]Backend{
+		{"primary", &flakyProvider{errs: []error{errUnavailable}}},
+		{"secondary", &flakyProvider{errs: []error{context.DeadlineExceeded}}},
+	}, FailoverOptions{})
+
+	_, err := provider.Complete(context.Background(), &Request{})
+	if !errors.Is(err, ErrAllProvidersUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected both failures, got %v", err)
+	}
+}
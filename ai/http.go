@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxErrorBodyBytes limits how much of an error response is read
+const maxErrorBodyBytes = 4096
+
+// maxStreamLineBytes limits the length of one line of a streamed response
+const maxStreamLineBytes = 1 << 20
+
+// APIError is a provider answering a request with an error status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("provider returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("provider returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// postJSON posts body as JSON to url and decodes the response into out. Error
+// statuses become an *APIError with the message errorMessage finds in the body.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string,
+	body, out interface{}, errorMessage func([]byte) string) error {
+	response, err := post(ctx, client, url, headers, body, errorMessage)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode chat response: %w", err)
+	}
+	return nil
+}
+
+// postStream posts body as JSON to url and calls onLine with each non-empty
+// line of the streamed response, until the response ends or onLine fails
+func postStream(ctx context.Context, client *http.Client, url string, headers map[string]string,
+	body interface{}, errorMessage func([]byte) string, onLine func(line []byte) error) error {
+	response, err := post(ctx, client, url, headers, body, errorMessage)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := onLine(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read chat response: %w", err)
+	}
+	return nil
+}
+
+// post posts body as JSON to url and returns the response of a success status,
+// whose body the caller closes
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string,
+	body interface{}, errorMessage func([]byte) string) (*http.Response, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encode chat request: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("create chat request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("post chat request: %w", err)
+	}
+	if response.StatusCode >= 300 {
+		defer response.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyBytes))
+		return nil, &APIError{StatusCode: response.StatusCode, Message: errorMessage(data)}
+	}
+	return response, nil
+}
+
+// serverSentData returns the payload of a data line of a server-sent event
+// stream; other lines, such as event names, report false
+func serverSentData(line []byte) ([]byte, bool) {
+	data, ok := bytes.CutPrefix(line, []byte("data:"))
+	return bytes.TrimSpace(data), ok
+}
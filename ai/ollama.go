@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultOllamaBaseURL is the address of a local Ollama server
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaProvider talks to the chat API of an Ollama server
+type OllamaProvider struct {
+	baseURL      string
+	defaultModel string
+	client       *http.Client
+}
+
+// NewOllamaProvider creates a provider for the server at baseURL, e.g.
+// DefaultOllamaBaseURL. Requests without a model use defaultModel.
+func NewOllamaProvider(baseURL, defaultModel string, timeout time.Duration) *OllamaProvider {
+	return &OllamaProvider{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		defaultModel: defaultModel,
+		client:       &http.Client{Timeout: timeout},
+	}
+}
+
+// Wire format of the chat API
+type (
+	ollamaRequest struct {
+		Model    string          `json:"model"`
+		Messages []ollamaMessage `json:"messages"`
+		Tools    []openAITool    `json:"tools,omitempty"` // same shape as OpenAI's
+		Stream   bool            `json:"stream"`
+		Options  *ollamaOptions  `json:"options,omitempty"`
+	}
+
+	// ollamaOptions are the model parameters of a request
+	ollamaOptions struct {
+		Temperature *float64 `json:"temperature,omitempty"`
+		NumPredict  int      `json:"num_predict,omitempty"` // the most tokens to answer with
+	}
+
+	ollamaMessage struct {
+		Role      string           `json:"role"`
+		Content   string           `json:"content"`
+		ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+		ToolName  string           `json:"tool_name,omitempty"` // the tool a tool message answers
+	}
+
+	ollamaToolCall struct {
+		Function struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"` // a JSON object, not a string
+		} `json:"function"`
+	}
+
+	ollamaResponse struct {
+		Message ollamaMessage `json:"message"`
+	}
+
+	// ollamaChunk is one line of a streamed reply
+	ollamaChunk struct {
+		Message ollamaMessage `json:"message"`
+		Done    bool          `json:"done"`
+		Error   string        `json:"error"`
+	}
+
+	ollamaError struct {
+		Error string `json:"error"`
+	}
+)
+
+// Complete sends req to the chat endpoint without streaming
+func (p *OllamaProvider) Complete(ctx context.Context, req *Request) (*Message, error) {
+	var response ollamaResponse
+	if err := postJSON(ctx, p.client, p.baseURL+"/api/chat", nil, p.encode(req), &response, ollamaErrorMessage); err != nil {
+		return nil, err
+	}
+	return decodeOllamaMessage(response.Message), nil
+}
+
+// Stream sends req to the chat endpoint, which answers with one JSON object
+// per line, each carrying the next piece of the reply
+func (p *OllamaProvider) Stream(ctx context.Context, req *Request, onText func(delta string)) (*Message, error) {
+	encoded := p.encode(req)
+	encoded.Stream = true
+
+	var reply ollamaMessage
+	done := false
+	err := postStream(ctx, p.client, p.baseURL+"/api/chat", nil, encoded, ollamaErrorMessage, func(line []byte) error {
+		var chunk ollamaChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("decode chat response: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("chat stream failed: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			reply.Content += chunk.Message.Content
+			onText(chunk.Message.Content)
+		}
+		reply.ToolCalls = append(reply.ToolCalls, chunk.Message.ToolCalls...)
+		done = done || chunk.Done
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		return nil, fmt.Errorf("chat stream ended early: %w", io.ErrUnexpectedEOF)
+	}
+	return decodeOllamaMessage(reply), nil
+}
+
+// decodeOllamaMessage converts a reply from the wire format. Ollama does not
+// identify tool calls; they are numbered instead.
+func decodeOllamaMessage(wire ollamaMessage) *Message {
+	message := &Message{Role: RoleAssistant, Content: wire.Content}
+	for i, call := range wire.ToolCalls {
+		arguments := call.Function.Arguments
+		if len(arguments) == 0 || string(arguments) == "null" {
+			arguments = json.RawMessage("{}")
+		}
+		message.ToolCalls = append(message.ToolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      call.Function.Name,
+			Arguments: arguments,
+		})
+	}
+	return message
+}
+
+// ollamaErrorMessage extracts the message of an error response
+func ollamaErrorMessage(body []byte) string {
+	var apiErr ollamaError
+	json.Unmarshal(body, &apiErr)
+	return apiErr.Error
+}
+
+// encode converts req to the wire format. Tool messages name the tool they
+// answer, looked up from the calls of the preceding assistant message.
+func (p *OllamaProvider) encode(req *Request) *ollamaRequest {
+	encoded := &ollamaRequest{Model: req.Model, Messages: make([]ollamaMessage, len(req.Messages))}
+	if encoded.Model == "" {
+		encoded.Model = p.defaultModel
+	}
+	if req.Temperature != nil || req.MaxTokens > 0 {
+		encoded.Options = &ollamaOptions{Temperature: req.Temperature, NumPredict: req.MaxTokens}
+	}
+
+	toolNames := make(map[string]string)
+	for i, message := range req.Messages {
+		wire := ollamaMessage{Role: message.Role, Content: message.Content}
+		for _, call := range message.ToolCalls {
+			toolNames[call.ID] = call.Name
+			wireCall := ollamaToolCall{}
+			wireCall.Function.Name = call.Name
+			wireCall.Function.Arguments = call.Arguments
+			wire.ToolCalls = append(wire.ToolCalls, wireCall)
+		}
+		if message.Role == RoleTool {
+			wire.ToolName = toolNames[message.ToolCallID]
+		}
+		encoded.Messages[i] = wire
+	}
+
+	for _, tool := range req.Tools {
+		encoded.Tools = append(encoded.Tools, openAITool{Type: "function", Function: tool})
+	}
+	return encoded
+}
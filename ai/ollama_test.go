@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOllamaProviderComplete(t *testing.T) {
+	var got ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[
+			{"function":{"name":"set_reminder","arguments":{"text":"Call mom","delay_minutes":30}}}]},"done":true}`))
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(server.URL+"/", "llama3", time.Second)
+	reply, err := provider.Complete(context.Background(), &Request{
+		Messages: []Message{{Role: RoleUser, Content: "Remind me to call mom in 30 minutes"}},
+		Tools:    []ToolSpec{{Name: "set_reminder", Parameters: json.RawMessage(`{"type":"object"}`)}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if got.Model != "llama3" || got.Stream || len(got.Tools) != 1 {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if len(reply.ToolCalls) != 1 || reply.ToolCalls[0].ID == "" || reply.ToolCalls[0].Name != "set_reminder" {
+		t.Fatalf("unexpected tool calls %+v", reply.ToolCalls)
+	}
+	var args struct {
+		DelayMinutes int `json:"delay_minutes"`
+	}
+	if err := json.Unmarshal(reply.ToolCalls[0].Arguments, &args); err != nil || args.DelayMinutes != 30 {
+		t.Errorf("expected the arguments object, got %s", reply.ToolCalls[0].Arguments)
+	}
+}
+
+func TestOllamaProviderStream(t *testing.T) {
+	var got ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Once upon"},"done":false}
+{"message":{"role":"assistant","content":" a time"},"done":false}
+
+{"message":{"role":"assistant","content":""},"done":true}
+`))
+	}))
+	defer server.Close()
+
+	var deltas []string
+	reply, err := NewOllamaProvider(server.URL, "llama3", time.Second).Stream(context.Background(), &Request{},
+		func(delta string) { deltas = append(deltas, delta) })
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if !got.Stream {
+		t.Error("expected a streamed request")
+	}
+	if reply.Content != "Once upon a time" || len(deltas) != 2 || deltas[1] != " a time" {
+		t.Errorf("expected the reply in two pieces, got %q from %q", reply.Content, deltas)
+	}
+
+	// A stream cut short is a failure, not a shorter reply
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Once"},"done":false}` + "\n"))
+	}))
+	defer server.Close()
+	if _, err := NewOllamaProvider(server.URL, "llama3", time.Second).Stream(context.Background(), &Request{}, func(string) {}); err == nil {
+		t.Error("expected an unfinished stream reported")
+	}
+}
+
+func TestOllamaProviderNamesToolResults(t *testing.T) {
+	provider := NewOllamaProvider(DefaultOllamaBaseURL, "llama3", time.Second)
+	encoded := provider.encode(&Request{Messages: []Message{
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_0", Name: "list_sessions", Arguments: json.RawMessage(`{}`)}}},
+		{Role: RoleTool, Content: `[]`, ToolCallID: "call_0"},
+	}})
+	if result := encoded.Messages[1]; result.Role != RoleTool || result.ToolName != "list_sessions" {
+		t.Errorf("expected the tool result to name its tool, got %+v", result)
+	}
+}
+
+func TestOllamaProviderSendsParametersAsOptions(t *testing.T) {
+	provider := NewOllamaProvider(DefaultOllamaBaseURL, "llama3", time.Second)
+	if encoded := provider.encode(&Request{}); encoded.Options != nil {
+		t.Errorf("expected no options by default, got %+v", encoded.Options)
+	}
+	temperature := 0.2
+	encoded := provider.encode(&Request{Temperature: &temperature})
+	if encoded.Options == nil || encoded.Options.Temperature == nil || *encoded.Options.Temperature != 0.2 {
+		t.Errorf("expected the temperature option, got %+v", encoded.Options)
+	}
+	encoded = provider.encode(&Request{MaxTokens: 256})
+	if encoded.Options == nil || encoded.Options.Temperature != nil || encoded.Options.NumPredict != 256 {
+		t.Errorf("expected only the num_predict option, got %+v", encoded.Options)
+	}
+}
+
+func TestOllamaProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"llama3\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOllamaProvider(server.URL, "llama3", time.Second).Complete(context.Background(), &Request{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message == "" {
+		t.Errorf("expected a 404 API error, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultOpenAIBaseURL is the API of OpenAI itself
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIProvider talks to the chat completions API of OpenAI and of the many
+// servers compatible with it
+type OpenAIProvider struct {
+	baseURL      string
+	apiKey       string
+	defaultModel string
+	client       *http.Client
+}
+
+// NewOpenAIProvider creates a provider for the API at baseURL, e.g.
+// DefaultOpenAIBaseURL. Requests without a model use defaultModel.
+func NewOpenAIProvider(baseURL, apiKey, defaultModel string, timeout time.Duration) *OpenAIProvider {
+	return &OpenAIProvider{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		apiKey:       apiKey,
+		defaultModel: defaultModel,
+		client:       &http.Client{Timeout: timeout},
+	}
+}
+
+// Wire format of the chat completions API
+type (
+	openAIRequest struct {
+		Model    string          `json:"model"`
+		Messages []openAIMessage `json:"messages"`
+		Tools    []openAITool    `json:"tools,omitempty"`
+
+		Temperature *float64 `json:"temperature,omitempty"`
+		MaxTokens   int      `json:"max_tokens,omitempty"`
+		Stream      bool     `json:"stream,omitempty"`
+	}
+
+	openAIMessage struct {
+		Role       string           `json:"role"`
+		Content    *string          `json:"content"`
+		ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+		ToolCallID string           `json:"tool_call_id,omitempty"`
+	}
+
+	openAITool struct {
+		Type     string   `json:"type"`
+		Function ToolSpec `json:"function"`
+	}
+
+	openAIToolCall struct {
+		ID       string `json:"id"`
+		Type     string `json:"type"`
+		Function struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"` // JSON encoded as a string
+		} `json:"function"`
+	}
+
+	openAIResponse struct {
+		Choices []struct {
+			Message openAIMessage `json:"message"`
+		} `json:"choices"`
+	}
+
+	// openAIChunk is one event of a streamed reply, carrying the next pieces of
+	// its text and tool calls
+	openAIChunk struct {
+		Choices []struct {
+			Delta struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					Index int `json:"index"`
+					openAIToolCall
+				} `json:"tool_calls"`
+			} `json:"delta"`
+		} `json:"choices"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	openAIError struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+)
+
+// Complete sends req to the chat completions endpoint
+func (p *OpenAIProvider) Complete(ctx context.Context, req *Request) (*Message, error) {
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+
+	var response openAIResponse
+	if err := postJSON(ctx, p.client, p.baseURL+"/chat/completions", headers, p.encode(req), &response, openAIErrorMessage); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, ErrNoReply
+	}
+	return decodeOpenAIMessage(response.Choices[0].Message), nil
+}
+
+// Stream sends req to the chat completions endpoint, which answers with
+// server-sent events ending in a [DONE] event
+func (p *OpenAIProvider) Stream(ctx context.Context, req *Request, onText func(delta string)) (*Message, error) {
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+	encoded := p.encode(req)
+	encoded.Stream = true
+
+	var content strings.Builder
+	var calls []openAIToolCall
+	done := false
+	err := postStream(ctx, p.client, p.baseURL+"/chat/completions", headers, encoded, openAIErrorMessage, func(line []byte) error {
+		data, ok := serverSentData(line)
+		if !ok || done {
+			return nil
+		}
+		if string(data) == "[DONE]" {
+			done = true
+			return nil
+		}
+
+		var chunk openAIChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("decode chat response: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("chat stream failed: %s", chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			content.WriteString(delta.Content)
+			onText(delta.Content)
+		}
+		// A tool call arrives in pieces of the same index: its ID and name
+		// first, then its arguments bit by bit
+		for _, piece := range delta.ToolCalls {
+			for len(calls) <= piece.Index {
+				calls = append(calls, openAIToolCall{Type: "function"})
+			}
+			call := &calls[piece.Index]
+			if piece.ID != "" {
+				call.ID = piece.ID
+			}
+			if piece.Function.Name != "" {
+				call.Function.Name = piece.Function.Name
+			}
+			call.Function.Arguments += piece.Function.Arguments
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		return nil, fmt.Errorf("chat stream ended early: %w", io.ErrUnexpectedEOF)
+	}
+
+	text := content.String()
+	return decodeOpenAIMessage(openAIMessage{Role: RoleAssistant, Content: &text, ToolCalls: calls}), nil
+}
+
+// openAIErrorMessage extracts the message of an error response
+func openAIErrorMessage(body []byte) string {
+	var apiErr openAIError
+	json.Unmarshal(body, &apiErr)
+	return apiErr.Error.Message
+}
+
+// encode converts req to the wire format
+func (p *OpenAIProvider) encode(req *Request) *openAIRequest {
+	encoded := &openAIRequest{Model: req.Model, Messages: make([]openAIMessage, len(req.Messages)), Temperature: req.Temperature,
+		MaxTokens: req.MaxTokens}
+	if encoded.Model == "" {
+		encoded.Model = p.defaultModel
+	}
+
+	for i, message := range req.Messages {
+		content := message.Content
+		wire := openAIMessage{Role: message.Role, Content: &content, ToolCallID: message.ToolCallID}
+		for _, call := range message.ToolCalls {
+			wireCall := openAIToolCall{ID: call.ID, Type: "function"}
+			wireCall.Function.Name = call.Name
+			wireCall.Function.Arguments = string(call.Arguments)
+			wire.ToolCalls = append(wire.ToolCalls, wireCall)
+		}
+		// Assistant messages carrying only tool calls have no content
+		if content == "" && len(wire.ToolCalls) > 0 {
+			wire.Content = nil
+		}
+		encoded.Messages[i] = wire
+	}
+
+	for _, tool := range req.Tools {
+		encoded.Tools = append(encoded.Tools, openAITool{Type: "function", Function: tool})
+	}
+	return encoded
+}
+
+// decodeOpenAIMessage converts a reply from the wire format
+func decodeOpenAIMessage(wire openAIMessage) *Message {
+	message := &Message{Role: RoleAssistant}
+	if wire.Content != nil {
+		message.Content = *wire.Content
+	}
+	for _, call := range wire.ToolCalls {
+		arguments := json.RawMessage(call.Function.Arguments)
+		if len(arguments) == 0 {
+			arguments = json.RawMessage("{}")
+		}
+		message.ToolCalls = append(message.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: arguments,
+		})
+	}
+	return message
+}
@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOpenAIProviderComplete(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected request %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[
+			{"id":"call_1","type":"function","function":{"name":"list_sessions","arguments":"{\"limit\":3}"}}]}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/v1/", "sk-test", "small", time.Second)
+	reply, err := provider.Complete(context.Background(), &Request{
+		Messages: []Message{{Role: RoleUser, Content: "What are my sessions?"}},
+		Tools:    []ToolSpec{{Name: "list_sessions", Parameters: json.RawMessage(`{"type":"object"}`)}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if got["model"] != "small" {
+		t.Errorf("expected the default model, got %v", got["model"])
+	}
+	tools, _ := got["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["type"] != "function" {
+		t.Errorf("expected one function tool, got %v", got["tools"])
+	}
+
+	if len(reply.ToolCalls) != 1 || reply.ToolCalls[0].Name != "list_sessions" || string(reply.ToolCalls[0].Arguments) != `{"limit":3}` {
+		t.Errorf("unexpected tool calls %+v", reply.ToolCalls)
+	}
+}
+
+func TestOpenAIProviderStream(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`data: {"choices":[{"delta":{"role":"assistant","content":"Let me "}}]}
+
+data: {"choices":[{"delta":{"content":"check."}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"list_sessions","arguments":""}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"limit\""}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":":3}"}}]}}]}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	var deltas []string
+	reply, err := NewOpenAIProvider(server.URL, "sk-test", "small", time.Second).Stream(context.Background(), &Request{},
+		func(delta string) { deltas = append(deltas, delta) })
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if got["stream"] != true {
+		t.Errorf("expected a streamed request, got %v", got["stream"])
+	}
+	if reply.Content != "Let me check." || len(deltas) != 2 {
+		t.Errorf("expected the text in two pieces, got %q from %q", reply.Content, deltas)
+	}
+	if len(reply.ToolCalls) != 1 || reply.ToolCalls[0].ID != "call_1" || reply.ToolCalls[0].Name != "list_sessions" ||
+		string(reply.ToolCalls[0].Arguments) != `{"limit":3}` {
+		t.Errorf("expected the tool call put together, got %+v", reply.ToolCalls)
+	}
+}
+
+func TestOpenAIProviderEncodesToolMessages(t *testing.T) {
+	provider := NewOpenAIProvider(DefaultOpenAIBaseURL, "", "small", time.Second)
+	encoded := provider.encode(&Request{Model: "large", Messages: []Message{
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "list_sessions", Arguments: json.RawMessage(`{}`)}}},
+		{Role: RoleTool, Content: `[]`, ToolCallID: "call_1"},
+	}})
+
+	if encoded.Model != "large" {
+		t.Errorf("expected the requested model, got %q", encoded.Model)
+	}
+	call := encoded.Messages[0]
+	if call.Content != nil || len(call.ToolCalls) != 1 || call.ToolCalls[0].Function.Arguments != `{}` {
+		t.Errorf("unexpected tool call message %+v", call)
+	}
+	if result := encoded.Messages[1]; result.ToolCallID != "call_1" || *result.Content != `[]` {
+		t.Errorf("unexpected tool result message %+v", result)
+	}
+}
+
+func TestOpenAIProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIProvider(server.URL, "", "small", time.Second).Complete(context.Background(), &Request{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message != "overloaded" {
+		t.Errorf("expected a 503 API error, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Permission says who may have the model run a tool
+type Permission string
+
+const (
+	PermissionEveryone Permission = "everyone"
+	PermissionAdmin    Permission = "admin" // only users in admin_user_ids
+	PermissionOff      Permission = "off"   // never offered to the model
+)
+
+// Caller is the user on whose behalf the model runs tools
+type Caller struct {
+	UserID int64
+	ChatID int64
+	Admin  bool
+}
+
+// ToolFunc runs a tool with the arguments chosen by the model and returns the
+// result shown to the model, usually JSON
+type ToolFunc func(ctx context.Context, caller Caller, arguments json.RawMessage) (string, error)
+
+// Tool is a function the model can call
+type Tool struct {
+	ToolSpec
+	Permission Permission // who may use the tool unless configured otherwise
+	Run        ToolFunc
+}
+
+// Registry holds the tools offered to the model
+type Registry struct {
+	tools map[string]Tool
+	order []string
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
+}
+
+// Register adds a tool; registering a name again replaces the tool. A tool
+// without a permission is available to everyone.
+func (r *Registry) Register(tool Tool) {
+	if tool.Permission == "" {
+		tool.Permission = PermissionEveryone
+	}
+	if _, ok := r.tools[tool.Name]; !ok {
+		r.order = append(r.order, tool.Name)
+	}
+	r.tools[tool.Name] = tool
+}
+
+// SetPermissions overrides the permissions of tools by name. Unknown tools and
+// permissions are an error and leave every permission unchanged.
+func (r *Registry) SetPermissions(permissions map[string]string) error {
+	for name, permission := range permissions {
+		if _, ok := r.tools[name]; !ok {
+			names := append([]string(nil), r.order...)
+			sort.Strings(names)
+			return fmt.Errorf("unknown tool %q (known: %s)", name, strings.Join(names, ", "))
+		}
+		if !validPermission(Permission(permission)) {
+			return fmt.Errorf("invalid permission %q for tool %q (use everyone, admin or off)", permission, name)
+		}
+	}
+	for name, permission := range permissions {
+		tool := r.tools[name]
+		tool.Permission = Permission(permission)
+		r.tools[name] = tool
+	}
+	return nil
+}
+
+// validPermission reports whether permission is a known one
+func validPermission(permission Permission) bool {
+	switch permission {
+	case PermissionEveryone, PermissionAdmin, PermissionOff:
+		return true
+	}
+	return false
+}
+
+// allowed reports whether caller may use tool
+func allowed(tool Tool, caller Caller) bool {
+	switch tool.Permission {
+	case PermissionEveryone:
+		return true
+	case PermissionAdmin:
+		return caller.Admin
+	}
+	return false
+}
+
+// Specs returns the tools caller may use, in registration order
+func (r *Registry) Specs(caller Caller) []ToolSpec {
+	var specs []ToolSpec
+	for _, name := range r.order {
+		if tool := r.tools[name]; allowed(tool, caller) {
+			specs = append(specs, tool.ToolSpec)
+		}
+	}
+	return specs
+}
+
+// Run executes call for caller and returns the result for the model. Failures
+// are reported to the model as an error object rather than failing the reply, so
+// it can tell the user or try differently; err is set for logging.
+func (r *Registry) Run(ctx context.Context, caller Caller, call ToolCall) (string, error) {
+	tool, ok := r.tools[call.Name]
+	if !ok || !allowed(tool, caller) {
+		err := fmt.Errorf("tool %q is not available", call.Name)
+		return toolError(err), err
+	}
+
+	result, err := tool.Run(ctx, caller, call.Arguments)
+	if err != nil {
+		return toolError(err), fmt.Errorf("tool %q: %w", call.Name, err)
+	}
+	return result, nil
+}
+
+// toolError renders err as the result of a tool call
+func toolError(err error) string {
+	encoded, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(encoded)
+}
@@ -0,0 +1,6 @@
+package sessionpb
+
+// Package sessionpb holds the protobuf messages and gRPC stubs of the session
+// API served by package grpcapi. Regenerate them after editing session.proto.
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative session.proto
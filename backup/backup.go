@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+)
+
+// Package backup writes consistent snapshots of the live database to a storage
+// backend, either on a schedule or on demand with /admin backup now. Each snapshot
+// is a standalone SQLite file named after the time it was taken.
+
+// Snapshot describes one written backup
+type Snapshot struct {
+	Key      string
+	Location string
+	Size     int64
+	Duration time.Duration
+}
+
+// Job takes database snapshots and stores them in a backend
+type Job struct {
+	store  session.BackupStore
+	target storage.Backend
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+// New creates a backup job writing snapshots of store to target
+func New(store session.BackupStore, target storage.Backend) *Job {
+	return &Job{store: store, target: target, now: time.Now}
+}
+
+// Start takes a snapshot at every time given by schedule until ctx is cancelled
+func (j *Job) Start(ctx context.Context, schedule Schedule) {
+	go func() {
+		for {
+			timer := time.NewTimer(time.Until(schedule.Next(j.now())))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if _, err := j.Run(ctx); err != nil {
+				log.Printf("database backup failed: trigger=scheduled err=%v", err)
+			}
+		}
+	}()
+}
+
+// Run takes one snapshot; concurrent runs wait for each other
+func (j *Job) Run(ctx context.Context) (*Snapshot, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	started := j.now()
+	key := snapshotKey(started)
+
+	// VACUUM INTO needs a path that does not exist yet
+	dir, err := os.MkdirTemp("", "tg-bot-backup-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, key)
+	if err := j.store.BackupTo(ctx, path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+
+	size, err := j.target.Put(ctx, key, file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		Key:      key,
+		Location: j.target.Location(key),
+		Size:     size,
+		Duration: j.now().Sub(started),
+	}
+	log.Printf("database backup: location=%s bytes=%d duration=%s",
+		snapshot.Location, snapshot.Size, snapshot.Duration.Round(time.Millisecond))
+	return snapshot, nil
+}
+
+// snapshotKey names the snapshot taken at t so keys sort by time
+func snapshotKey(t time.Time) string {
+	return t.UTC().Format("sessions-20060102-150405.db")
+}
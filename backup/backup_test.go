@@ -0,0 +1,83 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+)
+
+func TestJob_Run(t *testing.T) {
+	dir := t.TempDir()
+	store, err := session.NewSQLiteStore(filepath.Join(dir, "sessions.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	original := session.NewSession(1, "kept in the backup")
+	if err := store.Create(ctx, original); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	job := New(store, storage.NewLocalBackend(filepath.Join(dir, "backups")))
+	job.now = func() time.Time { return time.Date(2026, 10, 15, 3, 30, 0, 0, time.UTC) }
+
+	snapshot, err := job.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if snapshot.Key != "sessions-20261015-033000.db" {
+		t.Errorf("unexpected snapshot key %q", snapshot.Key)
+	}
+
+	path := filepath.Join(dir, "backups", snapshot.Key)
+	if info, err := os.Stat(path); err != nil || info.Size() != snapshot.Size {
+		t.Fatalf("expected a %d byte snapshot at %s, got %v", snapshot.Size, path, err)
+	}
+
+	restored, err := session.NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open snapshot: %v", err)
+	}
+	defer restored.Close()
+	if got, err := restored.Get(ctx, original.ID); err != nil || got.Title != original.Title {
+		t.Errorf("expected the session in the snapshot, got %v, %v", got, err)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	now := time.Date(2026, 10, 15, 10, 20, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@hourly", time.Date(2026, 10, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"@every 6h", now.Add(6 * time.Hour)},
+		{"03:30", time.Date(2026, 10, 16, 3, 30, 0, 0, time.UTC)},
+		{"23:15", time.Date(2026, 10, 15, 23, 15, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(now); !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%q).Next = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "daily", "@every 10s", "@every soon", "25:00"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("expected ParseSchedule(%q) to fail", spec)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule decides when the next snapshot is due
+type Schedule interface {
+	// Next returns the first run time strictly after t
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule parses a cron-like schedule:
+//
+//	@hourly        at the start of every hour
+//	@daily         every day at midnight
+//	@every 6h      at a fixed interval, counted from the previous run
+//	03:30          every day at this local time
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "@hourly":
+		return hourly{}, nil
+	case spec == "@daily":
+		return dailyAt{}, nil
+	case strings.HasPrefix(spec, "@every "):
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval in %q: %w", spec, err)
+		}
+		if interval < time.Minute {
+			return nil, fmt.Errorf("interval in %q must be at least 1m", spec)
+		}
+		return every(interval), nil
+	}
+
+	at, err := time.Parse("15:04", spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: use @hourly, @daily, @every <duration> or HH:MM", spec)
+	}
+	return dailyAt{hour: at.Hour(), minute: at.Minute()}, nil
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+type hourly struct{}
+
+func (hourly) Next(t time.Time) time.Time {
+	return t.Truncate(time.Hour).Add(time.Hour)
+}
+
+type dailyAt struct {
+	hour, minute int
+}
+
+func (d dailyAt) Next(t time.Time) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day(), d.hour, d.minute, 0, 0, t.Location())
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/handlers"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// channelArchiver stores posts of channels the bot administers in sessions owned
+// by the channel's chat ID. Text and captions become messages; media goes through
+// the file ingestor, which attaches it to the same session.
+type channelArchiver struct {
+	channels session.ChannelStore
+	sessions *session.Manager
+	messages *session.MessageManager
+	ingestor *fileIngestor
+}
+
+// newChannelArchiver creates a channel archiver and lets ingestor attach channel media to channel sessions
+func newChannelArchiver(channels session.ChannelStore, sessionMgr *session.Manager, messageMgr *session.MessageManager, ingestor *fileIngestor) *channelArchiver {
+	ingestor.archiveChannels = true
+	return &channelArchiver{channels: channels, sessions: sessionMgr, messages: messageMgr, ingestor: ingestor}
+}
+
+// handler returns the handler for channel_post and edited_channel_post updates
+func (a *channelArchiver) handler() handlers.HandlerFunc {
+	return func(ctx context.Context, b handlers.TelegramAPI, update *models.Update) {
+		if update.EditedChannelPost != nil {
+			a.edit(ctx, update.EditedChannelPost)
+			return
+		}
+		a.archive(ctx, b, update.ChannelPost)
+	}
+}
+
+// archive records post in its channel's session
+func (a *channelArchiver) archive(ctx context.Context, b handlers.TelegramAPI, post *models.Message) {
+	postedAt := time.Unix(int64(post.Date), 0)
+	channel := &session.Channel{ChatID: post.Chat.ID, Title: post.Chat.Title, Username: post.Chat.Username}
+	if err := a.channels.TrackChannelPost(ctx, channel, postedAt); err != nil {
+		log.Printf("archive channel post failed: request_id=%s chat_id=%d message_id=%d err=%v", correlation.ID(ctx), post.Chat.ID, post.ID, err)
+		return
+	}
+
+	if content := channelPostContent(post); content != "" {
+		activeSession, err := a.sessions.GetOrCreateActiveSession(ctx, post.Chat.ID, post.Chat.Title)
+		if err != nil {
+			log.Printf("archive channel post failed: request_id=%s chat_id=%d message_id=%d err=%v", correlation.ID(ctx), post.Chat.ID, post.ID, err)
+			return
+		}
+
+		message := session.NewMessage(activeSession.ID, post.Chat.ID, session.RoleUser, content)
+		message.ChatID = post.Chat.ID
+		message.TelegramMessageID = post.ID
+		message.CreatedAt = postedAt
+		if err := a.messages.AddMessage(ctx, message); err != nil {
+			log.Printf("archive channel post failed: request_id=%s chat_id=%d message_id=%d err=%v", correlation.ID(ctx), post.Chat.ID, post.ID, err)
+			return
+		}
+		if err := a.sessions.TouchSession(ctx, activeSession.ID, content); err != nil {
+			log.Printf("touch channel session failed: request_id=%s chat_id=%d message_id=%d err=%v", correlation.ID(ctx), post.Chat.ID, post.ID, err)
+		}
+	}
+
+	if len(collectFileTargets(post)) == 0 {
+		return
+	}
+	if post.MediaGroupID != "" {
+		a.ingestor.albums.Add(ctx, b, post, false)
+		return
+	}
+	a.ingestor.ingest(ctx, b, post, "")
+}
+
+// edit updates the archived text of an edited post
+func (a *channelArchiver) edit(ctx context.Context, post *models.Message) {
+	content := channelPostContent(post)
+	if content == "" {
+		return
+	}
+
+	editedAt := time.Now()
+	if post.EditDate != 0 {
+		editedAt = time.Unix(int64(post.EditDate), 0)
+	}
+	if _, err := a.messages.EditMessage(ctx, post.Chat.ID, post.ID, content, editedAt); err != nil {
+		log.Printf("archive channel edit failed: request_id=%s chat_id=%d message_id=%d err=%v", correlation.ID(ctx), post.Chat.ID, post.ID, err)
+	}
+}
+
+// channelPostContent returns the text of a post, or the caption of a media post
+func channelPostContent(post *models.Message) string {
+	if post.Text != "" {
+		return post.Text
+	}
+	return post.Caption
+}
+
+// isChannelPost matches new and edited channel posts
+func isChannelPost(update *models.Update) bool {
+	return update.ChannelPost != nil || update.EditedChannelPost != nil
+}
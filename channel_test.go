@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestChannelArchiver(t *testing.T) {
+	dir := t.TempDir()
+	store, err := session.NewSQLiteStore(filepath.Join(dir, "sessions.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	photoPath := filepath.Join(dir, "bot-api", "photos", "file_0.jpg")
+	if err := os.MkdirAll(filepath.Dir(photoPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(photoPath, []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	api := testutil.NewFakeTelegram()
+	api.Files["photo-1"] = &models.File{FileID: "photo-1", FilePath: photoPath}
+
+	sessionMgr := session.NewManager(store)
+	messageMgr := session.NewMessageManager(store)
+	ingestor := newFileIngestor(storage.NewLocalBackend(filepath.Join(dir, "download")), http.DefaultClient,
+		session.NewFileManager(store), sessionMgr, messageMgr, nil)
+	ingestor.localDir = filepath.Join(dir, "bot-api")
+	handler := newChannelArchiver(store, sessionMgr, messageMgr, ingestor).handler()
+	ctx := context.Background()
+
+	channel := models.Chat{ID: -100123, Type: models.ChatTypeChannel, Title: "News", Username: "news"}
+	handler(ctx, api, &models.Update{ChannelPost: &models.Message{ID: 1, Chat: channel, SenderChat: &channel, Date: 1700000000, Text: "first post"}})
+	handler(ctx, api, &models.Update{ChannelPost: &models.Message{ID: 2, Chat: channel, SenderChat: &channel, Date: 1700000060,
+		Caption: "a photo", Photo: []models.PhotoSize{{FileID: "photo-1", FileSize: 4}}}})
+	handler(ctx, api, &models.Update{EditedChannelPost: &models.Message{ID: 1, Chat: channel, SenderChat: &channel, EditDate: 1700000120, Text: "first post, edited"}})
+
+	if len(api.Sent) != 0 {
+		t.Errorf("expected nothing to be posted in the channel, got %d messages", len(api.Sent))
+	}
+
+	channelSession, err := sessionMgr.GetActiveSession(ctx, channel.ID)
+	if err != nil {
+		t.Fatalf("expected a session owned by the channel: %v", err)
+	}
+	if channelSession.Title != "News" {
+		t.Errorf("expected the session to be named after the channel, got %q", channelSession.Title)
+	}
+
+	messages, err := store.ListMessages(ctx, channelSession.ID, 10)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Content != "first post, edited" || messages[1].Content != "a photo" {
+		t.Fatalf("expected the edited post and the caption, got %+v", messages)
+	}
+
+	files, err := store.ListFilesBySession(ctx, channelSession.ID)
+	if err != nil {
+		t.Fatalf("ListFilesBySession failed: %v", err)
+	}
+	if len(files) != 1 || files[0].UserID != channel.ID {
+		t.Fatalf("expected the photo attached to the channel session, got %+v", files)
+	}
+
+	channels, err := store.ListChannels(ctx)
+	if err != nil {
+		t.Fatalf("ListChannels failed: %v", err)
+	}
+	if len(channels) != 1 || channels[0].Posts != 2 || channels[0].Username != "news" {
+		t.Errorf("expected the channel to be tracked with 2 posts, got %+v", channels)
+	}
+}
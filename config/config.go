@@ -3,8 +3,17 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all configuration for the Telegram bot
@@ -12,15 +21,233 @@ type Config struct {
 	// Bot configuration
 	Token       string `json:"token"`
 	SecretToken string `json:"secret_token"`
+	APIBaseURL  string `json:"api_base_url"`  // Bot API server, e.g. a self-hosted one; empty uses api.telegram.org
+	APILocalDir string `json:"api_local_dir"` // working directory of a Bot API server running with --local; empty when it does not
+
+	// Proxy for Bot API calls and file downloads (empty URL connects directly)
+	ProxyURL      string `json:"proxy_url"` // http, https, socks5 or socks5h URL
+	ProxyUsername string `json:"proxy_username"`
+	ProxyPassword string `json:"proxy_password"`
 
 	// Server configuration
 	ListenAddr    string `json:"listen_addr"`
 	WebhookPath   string `json:"webhook_path"`
 	DefaultStatus int    `json:"default_status"`
 
+	// Webhook updates wait in a queue of update_queue_size for update_workers to
+	// handle them, each chat's updates in order on one worker; a full queue
+	// answers Telegram with 429 so it retries later
+	UpdateQueueSize int `json:"update_queue_size"` // 0 uses 256
+	UpdateWorkers   int `json:"update_workers"`    // 0 uses 8
+
+	// WebhookURL is the public URL of webhook_path; when set the bot registers it
+	// with setWebhook at startup, together with secret_token and allowed_updates
+	WebhookURL string `json:"webhook_url"`
+
+	// AllowedUpdates lists the update types Telegram sends, e.g. "message" or
+	// "callback_query"; handlers of other types are not registered. Empty uses
+	// Telegram's default of every type but chat_member and the reactions.
+	AllowedUpdates []string `json:"allowed_updates"`
+
+	// TLS configuration; without certificate files or autocert domains the server speaks plain HTTP
+	TLSCertFile         string   `json:"tls_cert_file"`
+	TLSKeyFile          string   `json:"tls_key_file"`
+	TLSAutocertDomains  []string `json:"tls_autocert_domains"` // obtain certificates from Let's Encrypt for these domains
+	TLSAutocertEmail    string   `json:"tls_autocert_email"`
+	TLSAutocertCacheDir string   `json:"tls_autocert_cache_dir"`
+	TLSAutocertHTTPAddr string   `json:"tls_autocert_http_addr"` // optional listener for HTTP-01 challenges, e.g. ":80"
+
 	// Session configuration
-	SessionsPerPage int    `json:"sessions_per_page"`
-	DatabasePath    string `json:"database_path"`
+	SessionsPerPage    int    `json:"sessions_per_page"`
+	DatabasePath       string `json:"database_path"`
+	QuickSwitchButtons bool   `json:"quick_switch_buttons"`
+
+	// ButtonLabels overrides inline keyboard labels by name, e.g. "next_page" or
+	// "active_session"; the names are checked when the bot starts
+	ButtonLabels map[string]string `json:"button_labels"`
+
+	// ArchiveChannelPosts stores posts of channels the bot administers, with their
+	// media, in sessions owned by the channel
+	ArchiveChannelPosts bool `json:"archive_channel_posts"`
+
+	// AIModels lists the models users can choose in /settings; the first is the default
+	AIModels []string `json:"ai_models"`
+
+	// AI assistant answering text messages. ai_provider selects openai (any
+	// OpenAI-compatible API), anthropic or ollama; empty enables openai when its API
+	// key or base URL is set and otherwise disables the assistant. Each provider's
+	// model is used when ai_models is empty; empty base URLs use the public APIs
+	// and a local Ollama server.
+	AIProvider        string            `json:"ai_provider"`
+	OpenAIBaseURL     string            `json:"openai_base_url"`
+	OpenAIAPIKey      string            `json:"openai_api_key"`
+	OpenAIModel       string            `json:"openai_model"`
+	AnthropicBaseURL  string            `json:"anthropic_base_url"`
+	AnthropicAPIKey   string            `json:"anthropic_api_key"`
+	AnthropicModel    string            `json:"anthropic_model"`
+	OllamaBaseURL     string            `json:"ollama_base_url"`
+	OllamaModel       string            `json:"ollama_model"`
+	AITimeoutSeconds  int               `json:"ai_timeout_seconds"`  // one request to the provider
+	AIMaxToolRounds   int               `json:"ai_max_tool_rounds"`  // tool results sent back to the model per reply
+	AIToolPermissions map[string]string `json:"ai_tool_permissions"` // tool name to everyone, admin or off
+
+	// Ranges users may choose the sampling temperature and the longest answer
+	// in tokens from with /settings ai
+	AITemperatureMin float64 `json:"ai_temperature_min"`
+	AITemperatureMax float64 `json:"ai_temperature_max"`
+	AIMaxTokensMin   int     `json:"ai_max_tokens_min"`
+	AIMaxTokensMax   int     `json:"ai_max_tokens_max"`
+
+	// Failover: providers tried in order after ai_provider when it times out or
+	// fails with a 5xx, each answering with its own model. A provider failing
+	// ai_circuit_failure_threshold times in a row is skipped for
+	// ai_circuit_open_seconds (a threshold of 0 never skips it).
+	AIFallbackProviders       []string `json:"ai_fallback_providers"`
+	AIRetries                 int      `json:"ai_retries"` // extra attempts on a provider before failing over
+	AICircuitFailureThreshold int      `json:"ai_circuit_failure_threshold"`
+	AICircuitOpenSeconds      int      `json:"ai_circuit_open_seconds"`
+
+	// Content filter for text messages and assistant replies; no keywords or
+	// patterns disables it. Keywords match whole words ignoring case, patterns are
+	// Go regular expressions. content_filter_action is block (drop the text),
+	// redact (mask the matches) or flag (deliver it unchanged); every match is
+	// queued for review with /admin flagged.
+	ContentFilterKeywords []string `json:"content_filter_keywords"`
+	ContentFilterPatterns []string `json:"content_filter_patterns"`
+	ContentFilterAction   string   `json:"content_filter_action"`
+
+	// SQLite tuning
+	DatabaseMaxOpenConns  int    `json:"database_max_open_conns"`  // 0 means unlimited
+	DatabaseBusyTimeoutMS int    `json:"database_busy_timeout_ms"` // how long a statement waits for a locked database
+	DatabaseSynchronous   string `json:"database_synchronous"`     // PRAGMA synchronous: OFF, NORMAL, FULL or EXTRA
+	DatabaseCacheSizeKiB  int    `json:"database_cache_size_kib"`  // PRAGMA cache_size; 0 keeps SQLite's default
+
+	// SessionOperationTimeoutMS bounds each session operation, such as finding
+	// or creating the active session, queries included; 0 leaves them unbounded
+	SessionOperationTimeoutMS int `json:"session_operation_timeout_ms"`
+
+	// Database maintenance: log checkpoint, ANALYZE and optional incremental vacuum
+	DatabaseMaintenanceIntervalMinutes int  `json:"database_maintenance_interval_minutes"` // 0 disables the schedule
+	DatabaseIncrementalVacuum          bool `json:"database_incremental_vacuum"`
+
+	// MaintenanceMode starts the bot with a read-only store, answering users
+	// with a maintenance notice; administrators switch it with /admin maintenance
+	MaintenanceMode bool `json:"maintenance_mode"`
+
+	// DatabaseRepairOnStart fixes the inconsistencies the startup integrity
+	// check finds instead of only logging them
+	DatabaseRepairOnStart bool `json:"database_repair_on_start"`
+
+	// Database backups; snapshots go to backup_dir or, with the s3 backend, the configured bucket
+	BackupSchedule string `json:"backup_schedule"` // @hourly, @daily, @every <duration> or HH:MM; empty disables scheduled backups
+	BackupBackend  string `json:"backup_backend"`  // local or s3
+	BackupDir      string `json:"backup_dir"`
+	BackupS3Prefix string `json:"backup_s3_prefix"`
+
+	// Inbound message rate limiting per user (0 messages per minute disables it)
+	RateLimitMessagesPerMinute int `json:"rate_limit_messages_per_minute"`
+	RateLimitBurst             int `json:"rate_limit_burst"` // messages accepted back to back before throttling starts
+
+	// File flood detection pauses downloads of a user who sends more than
+	// FileFloodMaxFiles files within FileFloodWindowSeconds; 0 disables it
+	FileFloodMaxFiles      int `json:"file_flood_max_files"`
+	FileFloodWindowSeconds int `json:"file_flood_window_seconds"`
+	FileFloodPauseMinutes  int `json:"file_flood_pause_minutes"`
+
+	// CommandCooldowns maps a command such as "/export" to the Go duration, e.g.
+	// "5m", a user must wait between two uses of it; administrators are exempt
+	CommandCooldowns map[string]string `json:"command_cooldowns"`
+
+	// Outgoing events (session created/closed, message stored, file downloaded); no endpoints disables them
+	EventWebhookURLs   []string `json:"event_webhook_urls"`   // HTTP endpoints receiving each event as a JSON POST
+	EventWebhookSecret string   `json:"event_webhook_secret"` // signs webhook bodies in X-Signature-256
+	EventNATSURL       string   `json:"event_nats_url"`       // nats://[user:password@]host[:port]
+	EventNATSSubject   string   `json:"event_nats_subject"`   // subject prefix; the event type is appended
+
+	// gRPC session API for other backend services; an empty address disables it.
+	// Clients must present a certificate signed by the client CA (mutual TLS).
+	GRPCListenAddr   string `json:"grpc_listen_addr"`
+	GRPCTLSCertFile  string `json:"grpc_tls_cert_file"`
+	GRPCTLSKeyFile   string `json:"grpc_tls_key_file"`
+	GRPCClientCAFile string `json:"grpc_client_ca_file"`
+
+	// Minutes a multi-step flow such as /rename waits for the user's reply (0 = no timeout)
+	ConversationTimeoutMinutes int `json:"conversation_timeout_minutes"`
+
+	// Callback signing configuration (empty key disables signing)
+	CallbackSigningKey string `json:"callback_signing_key"`
+	CallbackTTLMinutes int    `json:"callback_ttl_minutes"`
+
+	// Read-only session links created with /share; tokens are signed with callback_signing_key
+	ShareTTLHours int    `json:"share_ttl_hours"` // 0 uses the default of 7 days
+	BotUsername   string `json:"bot_username"`    // for t.me share links; empty shows a /start command instead
+	PublicBaseURL string `json:"public_base_url"` // public URL of this server for web share links; empty disables them
+
+	// Storage configuration
+	StorageBackend    string `json:"storage_backend"`
+	DownloadDir       string `json:"download_dir"`
+	S3Endpoint        string `json:"s3_endpoint"`
+	S3Region          string `json:"s3_region"`
+	S3Bucket          string `json:"s3_bucket"`
+	S3Prefix          string `json:"s3_prefix"`
+	S3AccessKeyID     string `json:"s3_access_key_id"`
+	S3SecretAccessKey string `json:"s3_secret_access_key"`
+	S3UsePathStyle    bool   `json:"s3_use_path_style"`
+
+	// File download client configuration
+	DownloadConnectTimeoutSeconds int    `json:"download_connect_timeout_seconds"` // TCP connect and TLS handshake; 0 means no limit
+	DownloadTimeoutSeconds        int    `json:"download_timeout_seconds"`         // whole download including storing it; 0 means no limit
+	DownloadMaxIdleConns          int    `json:"download_max_idle_conns"`          // idle connections kept per host
+	DownloadCAFile                string `json:"download_ca_file"`                 // extra PEM CA certificates, e.g. for a self-hosted Bot API server
+
+	// Quota configuration (0 means unlimited)
+	UserQuotaBytes         int64 `json:"user_quota_bytes"`
+	GlobalQuotaBytes       int64 `json:"global_quota_bytes"`
+	CleanupIntervalMinutes int   `json:"cleanup_interval_minutes"`
+
+	// Session expiry configuration: sessions idle for session_ttl_days are archived,
+	// after warning their owners session_expiry_warning_hours ahead (0 days disables)
+	SessionTTLDays            int `json:"session_ttl_days"`
+	SessionExpiryWarningHours int `json:"session_expiry_warning_hours"`
+
+	// Tracing configuration (empty endpoint disables export)
+	TracingEndpoint    string  `json:"tracing_endpoint"`
+	TracingSampleRatio float64 `json:"tracing_sample_ratio"`
+
+	// Request log configuration
+	RequestLogSink         string  `json:"request_log_sink"` // stdout, file, sqlite or none
+	RequestLogPath         string  `json:"request_log_path"` // file or database path for the file and sqlite sinks
+	RequestLogMaxFileBytes int64   `json:"request_log_max_file_bytes"`
+	RequestLogMaxFiles     int     `json:"request_log_max_files"`
+	RequestLogSampleRatio  float64 `json:"request_log_sample_ratio"`
+	RequestLogMaxBodyBytes int     `json:"request_log_max_body_bytes"` // 0 means unlimited
+
+	// Error reporting configuration (empty DSN disables reporting)
+	SentryDSN         string `json:"sentry_dsn"`
+	SentryEnvironment string `json:"sentry_environment"`
+
+	// Admin configuration
+	AdminUserIDs []int64 `json:"admin_user_ids"`
+	AdminToken   string  `json:"admin_token"` // bearer token for the /admin/ dashboard; empty disables it
+
+	// CommandRoles maps a command such as "/stats", or an admin subcommand such as
+	// "/admin purge", to the least role allowed to run it: admin, moderator or
+	// user. Entries override the built-in defaults.
+	CommandRoles map[string]string `json:"command_roles"`
+
+	// Secret files, e.g. mounted Docker or Kubernetes secrets. Each holds the value
+	// of the option with the same name without the _file suffix.
+	TokenFile              string `json:"token_file"`
+	SecretTokenFile        string `json:"secret_token_file"`
+	CallbackSigningKeyFile string `json:"callback_signing_key_file"`
+	S3AccessKeyIDFile      string `json:"s3_access_key_id_file"`
+	S3SecretAccessKeyFile  string `json:"s3_secret_access_key_file"`
+	AdminTokenFile         string `json:"admin_token_file"`
+	SentryDSNFile          string `json:"sentry_dsn_file"`
+	ProxyPasswordFile      string `json:"proxy_password_file"`
+	EventWebhookSecretFile string `json:"event_webhook_secret_file"`
+	OpenAIAPIKeyFile       string `json:"openai_api_key_file"`
+	AnthropicAPIKeyFile    string `json:"anthropic_api_key_file"`
 }
 
 // Default returns a Config with sensible defaults
@@ -31,14 +258,83 @@ func Default() *Config {
 		ListenAddr:      ":3000",
 		WebhookPath:     "/webhook",
 		DefaultStatus:   200,
+		UpdateQueueSize: 256,
+		UpdateWorkers:   8,
 		SessionsPerPage: 6,
 		DatabasePath:    "./data/sessions.db",
+		StorageBackend:  "local",
+		DownloadDir:     "download",
+		S3Region:        "us-east-1",
+
+		TLSAutocertCacheDir: "./data/autocert",
+
+		DatabaseMaxOpenConns:  4,
+		DatabaseBusyTimeoutMS: 5000,
+		DatabaseSynchronous:   "NORMAL",
+
+		SessionOperationTimeoutMS: 10000,
+
+		DatabaseMaintenanceIntervalMinutes: 1440,
+
+		BackupBackend:  "local",
+		BackupDir:      "./data/backups",
+		BackupS3Prefix: "backups",
+
+		RateLimitMessagesPerMinute: 20,
+		RateLimitBurst:             5,
+		FileFloodWindowSeconds:     60,
+		FileFloodPauseMinutes:      10,
+
+		EventNATSSubject: "tgbot.events",
+
+		AITimeoutSeconds:          60,
+		AIMaxToolRounds:           5,
+		AITemperatureMax:          2,
+		AIMaxTokensMin:            16,
+		AIMaxTokensMax:            4096,
+		AIRetries:                 1,
+		AICircuitFailureThreshold: 5,
+		AICircuitOpenSeconds:      60,
+
+		ContentFilterAction: "flag",
+
+		QuickSwitchButtons:            true,
+		ConversationTimeoutMinutes:    10,
+		CallbackTTLMinutes:            1440,
+		ShareTTLHours:                 168,
+		CleanupIntervalMinutes:        60,
+		SessionExpiryWarningHours:     24,
+		DownloadConnectTimeoutSeconds: 10,
+		DownloadTimeoutSeconds:        300,
+		DownloadMaxIdleConns:          4,
+		TracingSampleRatio:            1,
+		RequestLogSink:                "stdout",
+		RequestLogMaxFileBytes:        10 << 20,
+		RequestLogMaxFiles:            5,
+		RequestLogSampleRatio:         1,
+		RequestLogMaxBodyBytes:        64 << 10,
 	}
 }
 
 // Load loads configuration from environment variables and optional config file
 // Environment variables take precedence over config file values
 func Load(configPath string) (*Config, error) {
+	cfg, err := Merge(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Merge loads configuration like Load but does not validate it, so callers can
+// apply command-line overrides before validating
+func Merge(configPath string) (*Config, error) {
 	cfg := Default()
 
 	// Load from config file if provided
@@ -51,15 +347,16 @@ func Load(configPath string) (*Config, error) {
 	// Override with environment variables
 	cfg.loadFromEnv()
 
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	// Read secrets mounted as files
+	if err := cfg.loadSecretFiles(); err != nil {
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
 	}
 
 	return cfg, nil
 }
 
-// loadFromFile loads configuration from a JSON file
+// loadFromFile loads configuration from a JSON, YAML or TOML file, chosen by extension.
+// All formats use the JSON field names, e.g. sessions_per_page.
 func (c *Config) loadFromFile(path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -69,6 +366,16 @@ func (c *Config) loadFromFile(path string) error {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yamlToJSON(data)
+	case ".toml":
+		data, err = tomlToJSON(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
 	if err := json.Unmarshal(data, c); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
@@ -76,6 +383,24 @@ func (c *Config) loadFromFile(path string) error {
 	return nil
 }
 
+// yamlToJSON converts a YAML document to JSON so it maps onto the same field names
+func yamlToJSON(data []byte) ([]byte, error) {
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return json.Marshal(values)
+}
+
+// tomlToJSON converts a TOML document to JSON so it maps onto the same field names
+func tomlToJSON(data []byte) ([]byte, error) {
+	values := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return json.Marshal(values)
+}
+
 // loadFromEnv loads configuration from environment variables
 func (c *Config) loadFromEnv() {
 	if token := os.Getenv("TELEGRAM_BOT_TOKEN"); token != "" {
@@ -86,6 +411,50 @@ func (c *Config) loadFromEnv() {
 		c.SecretToken = secretToken
 	}
 
+	if certFile := os.Getenv("TLS_CERT_FILE"); certFile != "" {
+		c.TLSCertFile = certFile
+	}
+
+	if keyFile := os.Getenv("TLS_KEY_FILE"); keyFile != "" {
+		c.TLSKeyFile = keyFile
+	}
+
+	if domains := os.Getenv("TLS_AUTOCERT_DOMAINS"); domains != "" {
+		c.TLSAutocertDomains = parseStringList(domains)
+	}
+
+	if email := os.Getenv("TLS_AUTOCERT_EMAIL"); email != "" {
+		c.TLSAutocertEmail = email
+	}
+
+	if cacheDir := os.Getenv("TLS_AUTOCERT_CACHE_DIR"); cacheDir != "" {
+		c.TLSAutocertCacheDir = cacheDir
+	}
+
+	if httpAddr := os.Getenv("TLS_AUTOCERT_HTTP_ADDR"); httpAddr != "" {
+		c.TLSAutocertHTTPAddr = httpAddr
+	}
+
+	if apiBaseURL := os.Getenv("TELEGRAM_API_BASE_URL"); apiBaseURL != "" {
+		c.APIBaseURL = apiBaseURL
+	}
+
+	if apiLocalDir := os.Getenv("TELEGRAM_API_LOCAL_DIR"); apiLocalDir != "" {
+		c.APILocalDir = apiLocalDir
+	}
+
+	if proxyURL := os.Getenv("PROXY_URL"); proxyURL != "" {
+		c.ProxyURL = proxyURL
+	}
+
+	if proxyUsername := os.Getenv("PROXY_USERNAME"); proxyUsername != "" {
+		c.ProxyUsername = proxyUsername
+	}
+
+	if proxyPassword := os.Getenv("PROXY_PASSWORD"); proxyPassword != "" {
+		c.ProxyPassword = proxyPassword
+	}
+
 	if listenAddr := os.Getenv("LISTEN_ADDR"); listenAddr != "" {
 		c.ListenAddr = listenAddr
 	}
@@ -100,6 +469,26 @@ func (c *Config) loadFromEnv() {
 		}
 	}
 
+	if queueSize := os.Getenv("UPDATE_QUEUE_SIZE"); queueSize != "" {
+		if size, err := strconv.Atoi(queueSize); err == nil {
+			c.UpdateQueueSize = size
+		}
+	}
+
+	if updateWorkers := os.Getenv("UPDATE_WORKERS"); updateWorkers != "" {
+		if workers, err := strconv.Atoi(updateWorkers); err == nil {
+			c.UpdateWorkers = workers
+		}
+	}
+
+	if webhookURL := os.Getenv("WEBHOOK_URL"); webhookURL != "" {
+		c.WebhookURL = webhookURL
+	}
+
+	if allowedUpdates := os.Getenv("ALLOWED_UPDATES"); allowedUpdates != "" {
+		c.AllowedUpdates = parseStringList(allowedUpdates)
+	}
+
 	if sessionsPerPage := os.Getenv("SESSIONS_PER_PAGE"); sessionsPerPage != "" {
 		if perPage, err := strconv.Atoi(sessionsPerPage); err == nil {
 			c.SessionsPerPage = perPage
@@ -109,6 +498,703 @@ func (c *Config) loadFromEnv() {
 	if dbPath := os.Getenv("DATABASE_PATH"); dbPath != "" {
 		c.DatabasePath = dbPath
 	}
+
+	if maxOpenConns := os.Getenv("DATABASE_MAX_OPEN_CONNS"); maxOpenConns != "" {
+		if conns, err := strconv.Atoi(maxOpenConns); err == nil {
+			c.DatabaseMaxOpenConns = conns
+		}
+	}
+
+	if operationTimeout := os.Getenv("SESSION_OPERATION_TIMEOUT_MS"); operationTimeout != "" {
+		if ms, err := strconv.Atoi(operationTimeout); err == nil {
+			c.SessionOperationTimeoutMS = ms
+		}
+	}
+
+	if busyTimeout := os.Getenv("DATABASE_BUSY_TIMEOUT_MS"); busyTimeout != "" {
+		if ms, err := strconv.Atoi(busyTimeout); err == nil {
+			c.DatabaseBusyTimeoutMS = ms
+		}
+	}
+
+	if synchronous := os.Getenv("DATABASE_SYNCHRONOUS"); synchronous != "" {
+		c.DatabaseSynchronous = synchronous
+	}
+
+	if cacheSize := os.Getenv("DATABASE_CACHE_SIZE_KIB"); cacheSize != "" {
+		if kib, err := strconv.Atoi(cacheSize); err == nil {
+			c.DatabaseCacheSizeKiB = kib
+		}
+	}
+
+	if maintenanceInterval := os.Getenv("DATABASE_MAINTENANCE_INTERVAL_MINUTES"); maintenanceInterval != "" {
+		if minutes, err := strconv.Atoi(maintenanceInterval); err == nil {
+			c.DatabaseMaintenanceIntervalMinutes = minutes
+		}
+	}
+
+	if incrementalVacuum := os.Getenv("DATABASE_INCREMENTAL_VACUUM"); incrementalVacuum != "" {
+		if enabled, err := strconv.ParseBool(incrementalVacuum); err == nil {
+			c.DatabaseIncrementalVacuum = enabled
+		}
+	}
+
+	if maintenanceMode := os.Getenv("MAINTENANCE_MODE"); maintenanceMode != "" {
+		if enabled, err := strconv.ParseBool(maintenanceMode); err == nil {
+			c.MaintenanceMode = enabled
+		}
+	}
+
+	if repairOnStart := os.Getenv("DATABASE_REPAIR_ON_START"); repairOnStart != "" {
+		if enabled, err := strconv.ParseBool(repairOnStart); err == nil {
+			c.DatabaseRepairOnStart = enabled
+		}
+	}
+
+	if backupSchedule := os.Getenv("BACKUP_SCHEDULE"); backupSchedule != "" {
+		c.BackupSchedule = backupSchedule
+	}
+
+	if backupBackend := os.Getenv("BACKUP_BACKEND"); backupBackend != "" {
+		c.BackupBackend = backupBackend
+	}
+
+	if backupDir := os.Getenv("BACKUP_DIR"); backupDir != "" {
+		c.BackupDir = backupDir
+	}
+
+	if backupPrefix := os.Getenv("BACKUP_S3_PREFIX"); backupPrefix != "" {
+		c.BackupS3Prefix = backupPrefix
+	}
+
+	if rateLimit := os.Getenv("RATE_LIMIT_MESSAGES_PER_MINUTE"); rateLimit != "" {
+		if perMinute, err := strconv.Atoi(rateLimit); err == nil {
+			c.RateLimitMessagesPerMinute = perMinute
+		}
+	}
+
+	if rateLimitBurst := os.Getenv("RATE_LIMIT_BURST"); rateLimitBurst != "" {
+		if burst, err := strconv.Atoi(rateLimitBurst); err == nil {
+			c.RateLimitBurst = burst
+		}
+	}
+
+	if floodMaxFiles := os.Getenv("FILE_FLOOD_MAX_FILES"); floodMaxFiles != "" {
+		if maxFiles, err := strconv.Atoi(floodMaxFiles); err == nil {
+			c.FileFloodMaxFiles = maxFiles
+		}
+	}
+
+	if floodWindow := os.Getenv("FILE_FLOOD_WINDOW_SECONDS"); floodWindow != "" {
+		if seconds, err := strconv.Atoi(floodWindow); err == nil {
+			c.FileFloodWindowSeconds = seconds
+		}
+	}
+
+	if floodPause := os.Getenv("FILE_FLOOD_PAUSE_MINUTES"); floodPause != "" {
+		if minutes, err := strconv.Atoi(floodPause); err == nil {
+			c.FileFloodPauseMinutes = minutes
+		}
+	}
+
+	if commandCooldowns := os.Getenv("COMMAND_COOLDOWNS"); commandCooldowns != "" {
+		c.CommandCooldowns = parseStringMap(commandCooldowns)
+	}
+
+	if webhookURLs := os.Getenv("EVENT_WEBHOOK_URLS"); webhookURLs != "" {
+		c.EventWebhookURLs = parseStringList(webhookURLs)
+	}
+
+	if webhookSecret := os.Getenv("EVENT_WEBHOOK_SECRET"); webhookSecret != "" {
+		c.EventWebhookSecret = webhookSecret
+	}
+
+	if natsURL := os.Getenv("EVENT_NATS_URL"); natsURL != "" {
+		c.EventNATSURL = natsURL
+	}
+
+	if natsSubject := os.Getenv("EVENT_NATS_SUBJECT"); natsSubject != "" {
+		c.EventNATSSubject = natsSubject
+	}
+
+	if grpcAddr := os.Getenv("GRPC_LISTEN_ADDR"); grpcAddr != "" {
+		c.GRPCListenAddr = grpcAddr
+	}
+
+	if grpcCertFile := os.Getenv("GRPC_TLS_CERT_FILE"); grpcCertFile != "" {
+		c.GRPCTLSCertFile = grpcCertFile
+	}
+
+	if grpcKeyFile := os.Getenv("GRPC_TLS_KEY_FILE"); grpcKeyFile != "" {
+		c.GRPCTLSKeyFile = grpcKeyFile
+	}
+
+	if grpcClientCAFile := os.Getenv("GRPC_CLIENT_CA_FILE"); grpcClientCAFile != "" {
+		c.GRPCClientCAFile = grpcClientCAFile
+	}
+
+	if quickSwitch := os.Getenv("QUICK_SWITCH_BUTTONS"); quickSwitch != "" {
+		if enabled, err := strconv.ParseBool(quickSwitch); err == nil {
+			c.QuickSwitchButtons = enabled
+		}
+	}
+
+	if buttonLabels := os.Getenv("BUTTON_LABELS"); buttonLabels != "" {
+		c.ButtonLabels = parseStringMap(buttonLabels)
+	}
+
+	if archiveChannels := os.Getenv("ARCHIVE_CHANNEL_POSTS"); archiveChannels != "" {
+		if enabled, err := strconv.ParseBool(archiveChannels); err == nil {
+			c.ArchiveChannelPosts = enabled
+		}
+	}
+
+	if aiModels := os.Getenv("AI_MODELS"); aiModels != "" {
+		c.AIModels = parseStringList(aiModels)
+	}
+
+	if openAIBaseURL := os.Getenv("OPENAI_BASE_URL"); openAIBaseURL != "" {
+		c.OpenAIBaseURL = openAIBaseURL
+	}
+
+	if openAIAPIKey := os.Getenv("OPENAI_API_KEY"); openAIAPIKey != "" {
+		c.OpenAIAPIKey = openAIAPIKey
+	}
+
+	if openAIModel := os.Getenv("OPENAI_MODEL"); openAIModel != "" {
+		c.OpenAIModel = openAIModel
+	}
+
+	if aiProvider := os.Getenv("AI_PROVIDER"); aiProvider != "" {
+		c.AIProvider = aiProvider
+	}
+
+	if anthropicBaseURL := os.Getenv("ANTHROPIC_BASE_URL"); anthropicBaseURL != "" {
+		c.AnthropicBaseURL = anthropicBaseURL
+	}
+
+	if anthropicAPIKey := os.Getenv("ANTHROPIC_API_KEY"); anthropicAPIKey != "" {
+		c.AnthropicAPIKey = anthropicAPIKey
+	}
+
+	if anthropicModel := os.Getenv("ANTHROPIC_MODEL"); anthropicModel != "" {
+		c.AnthropicModel = anthropicModel
+	}
+
+	if ollamaBaseURL := os.Getenv("OLLAMA_BASE_URL"); ollamaBaseURL != "" {
+		c.OllamaBaseURL = ollamaBaseURL
+	}
+
+	if ollamaModel := os.Getenv("OLLAMA_MODEL"); ollamaModel != "" {
+		c.OllamaModel = ollamaModel
+	}
+
+	if aiTimeout := os.Getenv("AI_TIMEOUT_SECONDS"); aiTimeout != "" {
+		if seconds, err := strconv.Atoi(aiTimeout); err == nil {
+			c.AITimeoutSeconds = seconds
+		}
+	}
+
+	if toolRounds := os.Getenv("AI_MAX_TOOL_ROUNDS"); toolRounds != "" {
+		if rounds, err := strconv.Atoi(toolRounds); err == nil {
+			c.AIMaxToolRounds = rounds
+		}
+	}
+
+	if fallbacks := os.Getenv("AI_FALLBACK_PROVIDERS"); fallbacks != "" {
+		c.AIFallbackProviders = parseStringList(fallbacks)
+	}
+
+	if retries := os.Getenv("AI_RETRIES"); retries != "" {
+		if n, err := strconv.Atoi(retries); err == nil {
+			c.AIRetries = n
+		}
+	}
+
+	if threshold := os.Getenv("AI_CIRCUIT_FAILURE_THRESHOLD"); threshold != "" {
+		if n, err := strconv.Atoi(threshold); err == nil {
+			c.AICircuitFailureThreshold = n
+		}
+	}
+
+	if openSeconds := os.Getenv("AI_CIRCUIT_OPEN_SECONDS"); openSeconds != "" {
+		if seconds, err := strconv.Atoi(openSeconds); err == nil {
+			c.AICircuitOpenSeconds = seconds
+		}
+	}
+
+	if temperatureMin := os.Getenv("AI_TEMPERATURE_MIN"); temperatureMin != "" {
+		if temperature, err := strconv.ParseFloat(temperatureMin, 64); err == nil {
+			c.AITemperatureMin = temperature
+		}
+	}
+
+	if temperatureMax := os.Getenv("AI_TEMPERATURE_MAX"); temperatureMax != "" {
+		if temperature, err := strconv.ParseFloat(temperatureMax, 64); err == nil {
+			c.AITemperatureMax = temperature
+		}
+	}
+
+	if maxTokensMin := os.Getenv("AI_MAX_TOKENS_MIN"); maxTokensMin != "" {
+		if tokens, err := strconv.Atoi(maxTokensMin); err == nil {
+			c.AIMaxTokensMin = tokens
+		}
+	}
+
+	if maxTokensMax := os.Getenv("AI_MAX_TOKENS_MAX"); maxTokensMax != "" {
+		if tokens, err := strconv.Atoi(maxTokensMax); err == nil {
+			c.AIMaxTokensMax = tokens
+		}
+	}
+
+	if toolPermissions := os.Getenv("AI_TOOL_PERMISSIONS"); toolPermissions != "" {
+		c.AIToolPermissions = parseStringMap(toolPermissions)
+	}
+
+	if keywords := os.Getenv("CONTENT_FILTER_KEYWORDS"); keywords != "" {
+		c.ContentFilterKeywords = parseStringList(keywords)
+	}
+
+	// Patterns may contain commas, so they are given one per line
+	if patterns := os.Getenv("CONTENT_FILTER_PATTERNS"); patterns != "" {
+		c.ContentFilterPatterns = nil
+		for _, pattern := range strings.Split(patterns, "\n") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				c.ContentFilterPatterns = append(c.ContentFilterPatterns, pattern)
+			}
+		}
+	}
+
+	if action := os.Getenv("CONTENT_FILTER_ACTION"); action != "" {
+		c.ContentFilterAction = action
+	}
+
+	if conversationTimeout := os.Getenv("CONVERSATION_TIMEOUT_MINUTES"); conversationTimeout != "" {
+		if minutes, err := strconv.Atoi(conversationTimeout); err == nil {
+			c.ConversationTimeoutMinutes = minutes
+		}
+	}
+
+	if signingKey := os.Getenv("CALLBACK_SIGNING_KEY"); signingKey != "" {
+		c.CallbackSigningKey = signingKey
+	}
+
+	if callbackTTL := os.Getenv("CALLBACK_TTL_MINUTES"); callbackTTL != "" {
+		if minutes, err := strconv.Atoi(callbackTTL); err == nil {
+			c.CallbackTTLMinutes = minutes
+		}
+	}
+
+	if shareTTL := os.Getenv("SHARE_TTL_HOURS"); shareTTL != "" {
+		if hours, err := strconv.Atoi(shareTTL); err == nil {
+			c.ShareTTLHours = hours
+		}
+	}
+
+	if botUsername := os.Getenv("TELEGRAM_BOT_USERNAME"); botUsername != "" {
+		c.BotUsername = botUsername
+	}
+
+	if publicBaseURL := os.Getenv("PUBLIC_BASE_URL"); publicBaseURL != "" {
+		c.PublicBaseURL = publicBaseURL
+	}
+
+	if storageBackend := os.Getenv("STORAGE_BACKEND"); storageBackend != "" {
+		c.StorageBackend = storageBackend
+	}
+
+	if downloadDir := os.Getenv("DOWNLOAD_DIR"); downloadDir != "" {
+		c.DownloadDir = downloadDir
+	}
+
+	if s3Endpoint := os.Getenv("S3_ENDPOINT"); s3Endpoint != "" {
+		c.S3Endpoint = s3Endpoint
+	}
+
+	if s3Region := os.Getenv("S3_REGION"); s3Region != "" {
+		c.S3Region = s3Region
+	}
+
+	if s3Bucket := os.Getenv("S3_BUCKET"); s3Bucket != "" {
+		c.S3Bucket = s3Bucket
+	}
+
+	if s3Prefix := os.Getenv("S3_PREFIX"); s3Prefix != "" {
+		c.S3Prefix = s3Prefix
+	}
+
+	if accessKeyID := os.Getenv("S3_ACCESS_KEY_ID"); accessKeyID != "" {
+		c.S3AccessKeyID = accessKeyID
+	}
+
+	if secretAccessKey := os.Getenv("S3_SECRET_ACCESS_KEY"); secretAccessKey != "" {
+		c.S3SecretAccessKey = secretAccessKey
+	}
+
+	if usePathStyle := os.Getenv("S3_USE_PATH_STYLE"); usePathStyle != "" {
+		if enabled, err := strconv.ParseBool(usePathStyle); err == nil {
+			c.S3UsePathStyle = enabled
+		}
+	}
+
+	if userQuota := os.Getenv("USER_QUOTA_BYTES"); userQuota != "" {
+		if quota, err := strconv.ParseInt(userQuota, 10, 64); err == nil {
+			c.UserQuotaBytes = quota
+		}
+	}
+
+	if globalQuota := os.Getenv("GLOBAL_QUOTA_BYTES"); globalQuota != "" {
+		if quota, err := strconv.ParseInt(globalQuota, 10, 64); err == nil {
+			c.GlobalQuotaBytes = quota
+		}
+	}
+
+	if cleanupInterval := os.Getenv("CLEANUP_INTERVAL_MINUTES"); cleanupInterval != "" {
+		if minutes, err := strconv.Atoi(cleanupInterval); err == nil {
+			c.CleanupIntervalMinutes = minutes
+		}
+	}
+
+	if sessionTTL := os.Getenv("SESSION_TTL_DAYS"); sessionTTL != "" {
+		if days, err := strconv.Atoi(sessionTTL); err == nil {
+			c.SessionTTLDays = days
+		}
+	}
+
+	if expiryWarning := os.Getenv("SESSION_EXPIRY_WARNING_HOURS"); expiryWarning != "" {
+		if hours, err := strconv.Atoi(expiryWarning); err == nil {
+			c.SessionExpiryWarningHours = hours
+		}
+	}
+
+	if connectTimeout := os.Getenv("DOWNLOAD_CONNECT_TIMEOUT_SECONDS"); connectTimeout != "" {
+		if seconds, err := strconv.Atoi(connectTimeout); err == nil {
+			c.DownloadConnectTimeoutSeconds = seconds
+		}
+	}
+
+	if downloadTimeout := os.Getenv("DOWNLOAD_TIMEOUT_SECONDS"); downloadTimeout != "" {
+		if seconds, err := strconv.Atoi(downloadTimeout); err == nil {
+			c.DownloadTimeoutSeconds = seconds
+		}
+	}
+
+	if maxIdleConns := os.Getenv("DOWNLOAD_MAX_IDLE_CONNS"); maxIdleConns != "" {
+		if conns, err := strconv.Atoi(maxIdleConns); err == nil {
+			c.DownloadMaxIdleConns = conns
+		}
+	}
+
+	if caFile := os.Getenv("DOWNLOAD_CA_FILE"); caFile != "" {
+		c.DownloadCAFile = caFile
+	}
+
+	if tracingEndpoint := os.Getenv("TRACING_ENDPOINT"); tracingEndpoint != "" {
+		c.TracingEndpoint = tracingEndpoint
+	}
+
+	if sampleRatio := os.Getenv("TRACING_SAMPLE_RATIO"); sampleRatio != "" {
+		if ratio, err := strconv.ParseFloat(sampleRatio, 64); err == nil {
+			c.TracingSampleRatio = ratio
+		}
+	}
+
+	if requestLogSink := os.Getenv("REQUEST_LOG_SINK"); requestLogSink != "" {
+		c.RequestLogSink = requestLogSink
+	}
+
+	if requestLogPath := os.Getenv("REQUEST_LOG_PATH"); requestLogPath != "" {
+		c.RequestLogPath = requestLogPath
+	}
+
+	if maxFileBytes := os.Getenv("REQUEST_LOG_MAX_FILE_BYTES"); maxFileBytes != "" {
+		if size, err := strconv.ParseInt(maxFileBytes, 10, 64); err == nil {
+			c.RequestLogMaxFileBytes = size
+		}
+	}
+
+	if maxFiles := os.Getenv("REQUEST_LOG_MAX_FILES"); maxFiles != "" {
+		if files, err := strconv.Atoi(maxFiles); err == nil {
+			c.RequestLogMaxFiles = files
+		}
+	}
+
+	if sampleRatio := os.Getenv("REQUEST_LOG_SAMPLE_RATIO"); sampleRatio != "" {
+		if ratio, err := strconv.ParseFloat(sampleRatio, 64); err == nil {
+			c.RequestLogSampleRatio = ratio
+		}
+	}
+
+	if maxBodyBytes := os.Getenv("REQUEST_LOG_MAX_BODY_BYTES"); maxBodyBytes != "" {
+		if size, err := strconv.Atoi(maxBodyBytes); err == nil {
+			c.RequestLogMaxBodyBytes = size
+		}
+	}
+
+	if sentryDSN := os.Getenv("SENTRY_DSN"); sentryDSN != "" {
+		c.SentryDSN = sentryDSN
+	}
+
+	if sentryEnvironment := os.Getenv("SENTRY_ENVIRONMENT"); sentryEnvironment != "" {
+		c.SentryEnvironment = sentryEnvironment
+	}
+
+	if adminIDs := os.Getenv("ADMIN_USER_IDS"); adminIDs != "" {
+		if ids, err := parseInt64List(adminIDs); err == nil {
+			c.AdminUserIDs = ids
+		}
+	}
+
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		c.AdminToken = adminToken
+	}
+
+	if commandRoles := os.Getenv("COMMAND_ROLES"); commandRoles != "" {
+		c.CommandRoles = parseStringMap(commandRoles)
+	}
+
+	c.loadSecretFilesFromEnv()
+}
+
+// parseStringList parses a comma-separated list, dropping empty entries
+func parseStringList(raw string) []string {
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// parseStringMap parses comma-separated key=value pairs, dropping entries without a key
+func parseStringMap(raw string) map[string]string {
+	values := make(map[string]string)
+	for _, part := range parseStringList(raw) {
+		key, value, _ := strings.Cut(part, "=")
+		if key = strings.TrimSpace(key); key != "" {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// parseInt64List parses a comma-separated list of integers
+func parseInt64List(raw string) ([]int64, error) {
+	var values []int64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// AI providers selectable with ai_provider
+const (
+	AIProviderOpenAI    = "openai"
+	AIProviderAnthropic = "anthropic"
+	AIProviderOllama    = "ollama"
+)
+
+// AIProviderName returns the AI provider answering messages, or "" when the
+// assistant is disabled
+func (c *Config) AIProviderName() string {
+	if c.AIProvider == "" && (c.OpenAIAPIKey != "" || c.OpenAIBaseURL != "") {
+		return AIProviderOpenAI
+	}
+	return c.AIProvider
+}
+
+// AIEnabled reports whether an AI provider is configured to answer messages
+func (c *Config) AIEnabled() bool {
+	return c.AIProviderName() != ""
+}
+
+// validateAIProvider checks the selected AI providers and the base URLs
+func (c *Config) validateAIProvider() error {
+	for _, option := range []struct{ name, value string }{
+		{"openai_base_url", c.OpenAIBaseURL},
+		{"anthropic_base_url", c.AnthropicBaseURL},
+		{"ollama_base_url", c.OllamaBaseURL},
+	} {
+		if option.value == "" {
+			continue
+		}
+		baseURL, err := url.Parse(option.value)
+		if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+			return fmt.Errorf("%s must be an http or https URL, got %q", option.name, option.value)
+		}
+	}
+
+	primary := c.AIProviderName()
+	if primary == "" {
+		if len(c.AIFallbackProviders) > 0 {
+			return fmt.Errorf("ai_fallback_providers requires ai_provider")
+		}
+		return nil
+	}
+	if err := c.validateAIBackend("ai_provider", primary, len(c.AIModels) > 0); err != nil {
+		return err
+	}
+
+	// Fallbacks answer with their own model, as ai_models belong to the primary
+	seen := map[string]bool{primary: true}
+	for _, name := range c.AIFallbackProviders {
+		if seen[name] {
+			return fmt.Errorf("ai_fallback_providers must not repeat a provider, got %q twice", name)
+		}
+		seen[name] = true
+		if err := c.validateAIBackend("ai_fallback_providers", name, false); err != nil {
+			return err
+		}
+		if name == AIProviderOpenAI && c.OpenAIModel == "" {
+			return fmt.Errorf("ai_fallback_providers openai requires openai_model")
+		}
+	}
+
+	if c.AIRetries < 0 {
+		return fmt.Errorf("ai_retries must not be negative, got %d", c.AIRetries)
+	}
+	if c.AICircuitFailureThreshold < 0 {
+		return fmt.Errorf("ai_circuit_failure_threshold must not be negative, got %d", c.AICircuitFailureThreshold)
+	}
+	if c.AICircuitFailureThreshold > 0 && c.AICircuitOpenSeconds <= 0 {
+		return fmt.Errorf("ai_circuit_open_seconds must be positive, got %d", c.AICircuitOpenSeconds)
+	}
+	return nil
+}
+
+// ContentFilterEnabled reports whether any content filter keywords or patterns are configured
+func (c *Config) ContentFilterEnabled() bool {
+	return len(c.ContentFilterKeywords) > 0 || len(c.ContentFilterPatterns) > 0
+}
+
+// validateContentFilter checks the content filter rules and action
+func (c *Config) validateContentFilter() error {
+	if !c.ContentFilterEnabled() {
+		return nil
+	}
+	for _, keyword := range c.ContentFilterKeywords {
+		if strings.TrimSpace(keyword) == "" {
+			return fmt.Errorf("content_filter_keywords must not contain empty keywords")
+		}
+	}
+	for _, pattern := range c.ContentFilterPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("content_filter_patterns contains an invalid pattern %q: %w", pattern, err)
+		}
+	}
+	switch c.ContentFilterAction {
+	case "block", "redact", "flag":
+	default:
+		return fmt.Errorf("content_filter_action must be block, redact or flag, got %q", c.ContentFilterAction)
+	}
+	return nil
+}
+
+// validateAIBackend checks the settings provider name needs, as given in option
+func (c *Config) validateAIBackend(option, name string, hasModel bool) error {
+	modelOptions := ""
+	if option == "ai_provider" {
+		modelOptions = " or ai_models"
+	}
+
+	switch name {
+	case AIProviderOpenAI:
+	case AIProviderAnthropic:
+		if c.AnthropicAPIKey == "" {
+			return fmt.Errorf("%s anthropic requires anthropic_api_key", option)
+		}
+		if c.AnthropicModel == "" && !hasModel {
+			return fmt.Errorf("%s anthropic requires anthropic_model%s", option, modelOptions)
+		}
+	case AIProviderOllama:
+		if c.OllamaModel == "" && !hasModel {
+			return fmt.Errorf("%s ollama requires ollama_model%s", option, modelOptions)
+		}
+	default:
+		return fmt.Errorf("%s must be openai, anthropic or ollama, got %q", option, name)
+	}
+	return nil
+}
+
+// UpdateTypes are the update types allowed_updates may list, after the fields of
+// Telegram's Update object
+var UpdateTypes = []string{
+	"message", "edited_message", "channel_post", "edited_channel_post",
+	"business_connection", "business_message", "edited_business_message", "deleted_business_messages",
+	"message_reaction", "message_reaction_count", "inline_query", "chosen_inline_result",
+	"callback_query", "shipping_query", "pre_checkout_query", "purchased_paid_media",
+	"poll", "poll_answer", "my_chat_member", "chat_member", "chat_join_request",
+	"chat_boost", "removed_chat_boost",
+}
+
+// optInUpdateTypes are only sent when allowed_updates lists them
+var optInUpdateTypes = []string{"chat_member", "message_reaction", "message_reaction_count"}
+
+// UpdateAllowed reports whether Telegram sends updates of updateType
+func (c *Config) UpdateAllowed(updateType string) bool {
+	if len(c.AllowedUpdates) == 0 {
+		return !slices.Contains(optInUpdateTypes, updateType)
+	}
+	return slices.Contains(c.AllowedUpdates, updateType)
+}
+
+// AllowedUpdateTypes returns AllowedUpdates, or the types Telegram sends by
+// default when it is empty
+func (c *Config) AllowedUpdateTypes() []string {
+	if len(c.AllowedUpdates) > 0 {
+		return c.AllowedUpdates
+	}
+	var types []string
+	for _, updateType := range UpdateTypes {
+		if !slices.Contains(optInUpdateTypes, updateType) {
+			types = append(types, updateType)
+		}
+	}
+	return types
+}
+
+// validateUpdates checks webhook_url and allowed_updates
+func (c *Config) validateUpdates() error {
+	if c.WebhookURL != "" {
+		webhookURL, err := url.Parse(c.WebhookURL)
+		if err != nil || webhookURL.Scheme != "https" || webhookURL.Host == "" {
+			return fmt.Errorf("webhook_url must be an https URL, got %q", c.WebhookURL)
+		}
+	}
+	for _, updateType := range c.AllowedUpdates {
+		if !slices.Contains(UpdateTypes, updateType) {
+			return fmt.Errorf("allowed_updates must list update types such as message or callback_query, got %q", updateType)
+		}
+	}
+	if c.ArchiveChannelPosts && !c.UpdateAllowed("channel_post") {
+		return fmt.Errorf("archive_channel_posts requires channel_post in allowed_updates")
+	}
+	return nil
+}
+
+// CommandCooldownDurations parses CommandCooldowns into durations per command
+func (c *Config) CommandCooldownDurations() (map[string]time.Duration, error) {
+	durations := make(map[string]time.Duration, len(c.CommandCooldowns))
+	for command, raw := range c.CommandCooldowns {
+		if !strings.HasPrefix(command, "/") || strings.ContainsAny(command, " @") {
+			return nil, fmt.Errorf("command_cooldowns keys must be commands such as /export, got %q", command)
+		}
+		duration, err := time.ParseDuration(raw)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("command_cooldowns must map commands to positive durations such as 5m, got %q for %s", raw, command)
+		}
+		durations[command] = duration
+	}
+	return durations, nil
 }
 
 // Validate checks if the configuration is valid
@@ -117,10 +1203,61 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("bot token is required (set TELEGRAM_BOT_TOKEN or provide in config file)")
 	}
 
+	if c.APIBaseURL != "" {
+		apiURL, err := url.Parse(c.APIBaseURL)
+		if err != nil || (apiURL.Scheme != "http" && apiURL.Scheme != "https") || apiURL.Host == "" {
+			return fmt.Errorf("api_base_url must be an http or https URL, got %q", c.APIBaseURL)
+		}
+	}
+
+	if c.APILocalDir != "" {
+		if c.APIBaseURL == "" {
+			return fmt.Errorf("api_local_dir needs api_base_url pointing at the local Bot API server")
+		}
+		if !filepath.IsAbs(c.APILocalDir) {
+			return fmt.Errorf("api_local_dir must be an absolute path, got %q", c.APILocalDir)
+		}
+	}
+
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return fmt.Errorf("proxy_url must be a URL such as socks5://host:1080, got %q", c.ProxyURL)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("proxy_url scheme must be http, https, socks5 or socks5h, got %q", proxyURL.Scheme)
+		}
+		if proxyURL.User != nil && c.ProxyUsername != "" {
+			return fmt.Errorf("set proxy credentials either in proxy_url or with proxy_username, not both")
+		}
+	}
+
 	if c.DefaultStatus < 100 || c.DefaultStatus > 599 {
 		return fmt.Errorf("default_status must be between 100 and 599, got %d", c.DefaultStatus)
 	}
 
+	if c.UpdateQueueSize < 0 || c.UpdateWorkers < 0 {
+		return fmt.Errorf("update_queue_size and update_workers must not be negative")
+	}
+
+	if err := c.validateUpdates(); err != nil {
+		return err
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("tls_cert_file and tls_key_file must be set together")
+	}
+
+	if c.TLSCertFile != "" && len(c.TLSAutocertDomains) > 0 {
+		return fmt.Errorf("set either tls_cert_file/tls_key_file or tls_autocert_domains, not both")
+	}
+
+	if len(c.TLSAutocertDomains) > 0 && c.TLSAutocertCacheDir == "" {
+		return fmt.Errorf("tls_autocert_cache_dir is required with tls_autocert_domains")
+	}
+
 	if c.SessionsPerPage < 1 {
 		return fmt.Errorf("sessions_per_page must be at least 1, got %d", c.SessionsPerPage)
 	}
@@ -129,5 +1266,177 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("database_path is required")
 	}
 
+	if c.DatabaseMaxOpenConns < 0 || c.DatabaseBusyTimeoutMS < 0 || c.DatabaseCacheSizeKiB < 0 {
+		return fmt.Errorf("database_max_open_conns, database_busy_timeout_ms and database_cache_size_kib must not be negative")
+	}
+
+	if c.SessionOperationTimeoutMS < 0 {
+		return fmt.Errorf("session_operation_timeout_ms must not be negative, got %d", c.SessionOperationTimeoutMS)
+	}
+
+	if c.DatabaseMaintenanceIntervalMinutes < 0 {
+		return fmt.Errorf("database_maintenance_interval_minutes must not be negative, got %d", c.DatabaseMaintenanceIntervalMinutes)
+	}
+
+	switch strings.ToUpper(c.DatabaseSynchronous) {
+	case "", "OFF", "NORMAL", "FULL", "EXTRA":
+	default:
+		return fmt.Errorf("database_synchronous must be OFF, NORMAL, FULL or EXTRA, got %q", c.DatabaseSynchronous)
+	}
+
+	if c.UserQuotaBytes < 0 || c.GlobalQuotaBytes < 0 {
+		return fmt.Errorf("user_quota_bytes and global_quota_bytes must not be negative")
+	}
+
+	if c.RateLimitMessagesPerMinute < 0 || c.RateLimitBurst < 0 {
+		return fmt.Errorf("rate_limit_messages_per_minute and rate_limit_burst must not be negative")
+	}
+
+	if c.FileFloodMaxFiles < 0 {
+		return fmt.Errorf("file_flood_max_files must not be negative, got %d", c.FileFloodMaxFiles)
+	}
+	if c.FileFloodMaxFiles > 0 && (c.FileFloodWindowSeconds <= 0 || c.FileFloodPauseMinutes <= 0) {
+		return fmt.Errorf("file_flood_window_seconds and file_flood_pause_minutes must be positive when file_flood_max_files is set")
+	}
+
+	if _, err := c.CommandCooldownDurations(); err != nil {
+		return err
+	}
+
+	for command, role := range c.CommandRoles {
+		name, subcommand, _ := strings.Cut(command, " ")
+		if !strings.HasPrefix(name, "/") || strings.Contains(name, "@") || strings.Contains(subcommand, " ") {
+			return fmt.Errorf("command_roles keys must be commands such as /stats or /admin purge, got %q", command)
+		}
+		if role != "admin" && role != "moderator" && role != "user" {
+			return fmt.Errorf("command_roles must map commands to admin, moderator or user, got %q for %s", role, command)
+		}
+	}
+
+	if c.ConversationTimeoutMinutes < 0 {
+		return fmt.Errorf("conversation_timeout_minutes must not be negative, got %d", c.ConversationTimeoutMinutes)
+	}
+
+	if c.CallbackTTLMinutes < 0 {
+		return fmt.Errorf("callback_ttl_minutes must not be negative, got %d", c.CallbackTTLMinutes)
+	}
+
+	for _, model := range c.AIModels {
+		if strings.TrimSpace(model) == "" {
+			return fmt.Errorf("ai_models must not contain empty names")
+		}
+	}
+
+	if err := c.validateAIProvider(); err != nil {
+		return err
+	}
+
+	if c.AIEnabled() && c.AITimeoutSeconds <= 0 {
+		return fmt.Errorf("ai_timeout_seconds must be positive, got %d", c.AITimeoutSeconds)
+	}
+
+	if c.AIEnabled() && c.AIMaxToolRounds <= 0 {
+		return fmt.Errorf("ai_max_tool_rounds must be positive, got %d", c.AIMaxToolRounds)
+	}
+
+	if c.AIEnabled() && (c.AITemperatureMin < 0 || c.AITemperatureMax < c.AITemperatureMin) {
+		return fmt.Errorf("ai_temperature_min and ai_temperature_max must form a range from 0 up, got %g to %g",
+			c.AITemperatureMin, c.AITemperatureMax)
+	}
+
+	if c.AIEnabled() && (c.AIMaxTokensMin <= 0 || c.AIMaxTokensMax < c.AIMaxTokensMin) {
+		return fmt.Errorf("ai_max_tokens_min and ai_max_tokens_max must form a range from 1 up, got %d to %d",
+			c.AIMaxTokensMin, c.AIMaxTokensMax)
+	}
+
+	if err := c.validateContentFilter(); err != nil {
+		return err
+	}
+
+	for _, webhookURL := range c.EventWebhookURLs {
+		parsed, err := url.Parse(webhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("event_webhook_urls must be http or https URLs, got %q", webhookURL)
+		}
+	}
+
+	if c.EventNATSURL != "" {
+		natsURL, err := url.Parse(c.EventNATSURL)
+		if err != nil || natsURL.Scheme != "nats" || natsURL.Host == "" {
+			return fmt.Errorf("event_nats_url must be a nats:// URL, got %q", c.EventNATSURL)
+		}
+		if c.EventNATSSubject == "" {
+			return fmt.Errorf("event_nats_subject is required with event_nats_url")
+		}
+	}
+
+	if c.GRPCListenAddr != "" && (c.GRPCTLSCertFile == "" || c.GRPCTLSKeyFile == "" || c.GRPCClientCAFile == "") {
+		return fmt.Errorf("grpc_listen_addr requires grpc_tls_cert_file, grpc_tls_key_file and grpc_client_ca_file")
+	}
+
+	if c.ShareTTLHours < 0 {
+		return fmt.Errorf("share_ttl_hours must not be negative, got %d", c.ShareTTLHours)
+	}
+
+	if c.PublicBaseURL != "" {
+		publicURL, err := url.Parse(c.PublicBaseURL)
+		if err != nil || (publicURL.Scheme != "http" && publicURL.Scheme != "https") || publicURL.Host == "" {
+			return fmt.Errorf("public_base_url must be an http or https URL, got %q", c.PublicBaseURL)
+		}
+	}
+
+	if c.CleanupIntervalMinutes < 0 {
+		return fmt.Errorf("cleanup_interval_minutes must not be negative, got %d", c.CleanupIntervalMinutes)
+	}
+
+	if c.SessionTTLDays < 0 {
+		return fmt.Errorf("session_ttl_days must not be negative, got %d", c.SessionTTLDays)
+	}
+	if c.SessionTTLDays > 0 && (c.SessionExpiryWarningHours <= 0 || c.SessionExpiryWarningHours >= c.SessionTTLDays*24) {
+		return fmt.Errorf("session_expiry_warning_hours must be positive and shorter than session_ttl_days, got %d", c.SessionExpiryWarningHours)
+	}
+
+	if c.DownloadConnectTimeoutSeconds < 0 || c.DownloadTimeoutSeconds < 0 || c.DownloadMaxIdleConns < 0 {
+		return fmt.Errorf("download_connect_timeout_seconds, download_timeout_seconds and download_max_idle_conns must not be negative")
+	}
+
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return fmt.Errorf("tracing_sample_ratio must be between 0 and 1, got %g", c.TracingSampleRatio)
+	}
+
+	if c.RequestLogSampleRatio < 0 || c.RequestLogSampleRatio > 1 {
+		return fmt.Errorf("request_log_sample_ratio must be between 0 and 1, got %g", c.RequestLogSampleRatio)
+	}
+
+	if c.RequestLogMaxFileBytes < 0 || c.RequestLogMaxFiles < 0 || c.RequestLogMaxBodyBytes < 0 {
+		return fmt.Errorf("request_log_max_file_bytes, request_log_max_files and request_log_max_body_bytes must not be negative")
+	}
+
+	switch c.RequestLogSink {
+	case "", "stdout", "file", "sqlite", "none":
+	default:
+		return fmt.Errorf("request_log_sink must be \"stdout\", \"file\", \"sqlite\" or \"none\", got %q", c.RequestLogSink)
+	}
+
+	switch c.StorageBackend {
+	case "", "local":
+	case "s3":
+		if c.S3Bucket == "" {
+			return fmt.Errorf("s3_bucket is required for s3 storage")
+		}
+	default:
+		return fmt.Errorf("storage_backend must be \"local\" or \"s3\", got %q", c.StorageBackend)
+	}
+
+	switch c.BackupBackend {
+	case "", "local":
+	case "s3":
+		if c.S3Bucket == "" {
+			return fmt.Errorf("s3_bucket is required for s3 backups")
+		}
+	default:
+		return fmt.Errorf("backup_backend must be \"local\" or \"s3\", got %q", c.BackupBackend)
+	}
+
 	return nil
 }
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// secret ties a secret option to the option naming a file that holds it
+type secret struct {
+	name  string  // option name, e.g. "token"
+	env   string  // environment variable, e.g. "TELEGRAM_BOT_TOKEN"
+	value *string // the secret
+	file  *string // path of a file holding the secret
+}
+
+// secrets lists the options that can be read from files
+func (c *Config) secrets() []secret {
+	return []secret{
+		{name: "token", env: "TELEGRAM_BOT_TOKEN", value: &c.Token, file: &c.TokenFile},
+		{name: "secret_token", env: "TELEGRAM_SECRET_TOKEN", value: &c.SecretToken, file: &c.SecretTokenFile},
+		{name: "callback_signing_key", env: "CALLBACK_SIGNING_KEY", value: &c.CallbackSigningKey, file: &c.CallbackSigningKeyFile},
+		{name: "s3_access_key_id", env: "S3_ACCESS_KEY_ID", value: &c.S3AccessKeyID, file: &c.S3AccessKeyIDFile},
+		{name: "s3_secret_access_key", env: "S3_SECRET_ACCESS_KEY", value: &c.S3SecretAccessKey, file: &c.S3SecretAccessKeyFile},
+		{name: "admin_token", env: "ADMIN_TOKEN", value: &c.AdminToken, file: &c.AdminTokenFile},
+		{name: "sentry_dsn", env: "SENTRY_DSN", value: &c.SentryDSN, file: &c.SentryDSNFile},
+		{name: "proxy_password", env: "PROXY_PASSWORD", value: &c.ProxyPassword, file: &c.ProxyPasswordFile},
+		{name: "event_webhook_secret", env: "EVENT_WEBHOOK_SECRET", value: &c.EventWebhookSecret, file: &c.EventWebhookSecretFile},
+		{name: "openai_api_key", env: "OPENAI_API_KEY", value: &c.OpenAIAPIKey, file: &c.OpenAIAPIKeyFile},
+		{name: "anthropic_api_key", env: "ANTHROPIC_API_KEY", value: &c.AnthropicAPIKey, file: &c.AnthropicAPIKeyFile},
+	}
+}
+
+// loadSecretFilesFromEnv applies the *_FILE environment variables. A secret set
+// in the environment, directly or as a file, replaces both forms from the config file.
+func (c *Config) loadSecretFilesFromEnv() {
+	for _, s := range c.secrets() {
+		if path := os.Getenv(s.env + "_FILE"); path != "" {
+			*s.file = path
+			*s.value = ""
+		} else if os.Getenv(s.env) != "" {
+			*s.file = ""
+		}
+	}
+}
+
+// loadSecretFiles reads each secret that is given as a file
+func (c *Config) loadSecretFiles() error {
+	for _, s := range c.secrets() {
+		if os.Getenv(s.env) != "" && os.Getenv(s.env+"_FILE") != "" {
+			return fmt.Errorf("set only one of %s and %s_FILE", s.env, s.env)
+		}
+		if *s.file == "" {
+			continue
+		}
+		if *s.value != "" {
+			return fmt.Errorf("set only one of %s and %s_file", s.name, s.name)
+		}
+
+		value, err := readSecretFile(*s.file)
+		if err != nil {
+			return fmt.Errorf("%s_file: %w", s.name, err)
+		}
+		*s.value = value
+	}
+	return nil
+}
+
+// readSecretFile returns the trimmed content of a secret file, which must be a single non-empty line
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("secret file %s must contain a single line", path)
+	}
+	return value, nil
+}
+
+// redactedValue replaces secrets in Redacted
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the config with every secret that is set replaced,
+// safe to print or log
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.AdminUserIDs = append([]int64(nil), c.AdminUserIDs...)
+	for _, s := range redacted.secrets() {
+		if *s.value != "" {
+			*s.value = redactedValue
+		}
+	}
+	// A password inside the proxy URL is replaced by "xxxxx"
+	if proxyURL, err := url.Parse(c.ProxyURL); err == nil && proxyURL.User != nil {
+		redacted.ProxyURL = proxyURL.Redacted()
+	}
+	return &redacted
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"tg-bot-demo/config"
+)
+
+// configFlags are the command-line overrides of the configuration, shared by the
+// server and the config subcommand so both see the same effective values
+type configFlags struct {
+	configPath      *string
+	listenAddr      *string
+	path            *string
+	token           *string
+	secretToken     *string
+	defaultStatus   *int
+	dbPath          *string
+	sessionsPerPage *int
+}
+
+// registerConfigFlags defines the configuration flags on flags
+func registerConfigFlags(flags *flag.FlagSet) *configFlags {
+	return &configFlags{
+		configPath:      flags.String("config", "", "Path to config file (optional)"),
+		listenAddr:      flags.String("listen", "", "HTTP listen address (overrides config)"),
+		path:            flags.String("path", "", "Webhook path (overrides config)"),
+		token:           flags.String("token", "", "Telegram bot token (overrides config)"),
+		secretToken:     flags.String("secret-token", "", "Webhook secret token (overrides config)"),
+		defaultStatus:   flags.Int("status", 0, "Default HTTP status code (overrides config)"),
+		dbPath:          flags.String("db", "", "Path to SQLite database file (overrides config)"),
+		sessionsPerPage: flags.Int("sessions-per-page", 0, "Sessions per page (overrides config)"),
+	}
+}
+
+// load merges the config file, environment and flags. The result is not validated.
+func (f *configFlags) load() (*config.Config, error) {
+	cfg, err := config.Merge(*f.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Override config with command-line flags if provided
+	if *f.listenAddr != "" {
+		cfg.ListenAddr = *f.listenAddr
+	}
+	if *f.path != "" {
+		cfg.WebhookPath = *f.path
+	}
+	if *f.token != "" {
+		cfg.Token = *f.token
+	}
+	if *f.secretToken != "" {
+		cfg.SecretToken = *f.secretToken
+	}
+	if *f.defaultStatus != 0 {
+		cfg.DefaultStatus = *f.defaultStatus
+	}
+	if *f.dbPath != "" {
+		cfg.DatabasePath = *f.dbPath
+	}
+	if *f.sessionsPerPage != 0 {
+		cfg.SessionsPerPage = *f.sessionsPerPage
+	}
+
+	return cfg, nil
+}
+
+// runConfig implements the config subcommand. "config check" validates the effective
+// configuration; "config print" also writes it to out as JSON with secrets redacted.
+// Both return an error, and so exit non-zero, when the configuration is invalid.
+func runConfig(args []string, out io.Writer) error {
+	if len(args) == 0 || (args[0] != "check" && args[0] != "print") {
+		return errors.New("usage: config check|print [-config file] [flags]")
+	}
+	action := args[0]
+
+	flags := flag.NewFlagSet("config "+action, flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args[1:])
+
+	cfg, err := overrides.load()
+	if err != nil {
+		return err
+	}
+
+	if action == "print" {
+		encoded, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode configuration: %w", err)
+		}
+		fmt.Fprintln(out, string(encoded))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if action == "check" {
+		fmt.Fprintln(out, "configuration is valid")
+	}
+	return nil
+}
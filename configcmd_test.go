@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunConfigCheck(t *testing.T) {
+	t.Setenv("TELEGRAM_BOT_TOKEN", "")
+
+	var out bytes.Buffer
+	if err := runConfig([]string{"check"}, &out); err == nil || !strings.Contains(err.Error(), "token") {
+		t.Errorf("expected missing token error, got %v", err)
+	}
+
+	out.Reset()
+	if err := runConfig([]string{"check", "-token", "123:abc"}, &out); err != nil {
+		t.Fatalf("expected valid configuration, got %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "configuration is valid" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestRunConfigPrint(t *testing.T) {
+	t.Setenv("TELEGRAM_BOT_TOKEN", "123:abc")
+	t.Setenv("SESSIONS_PER_PAGE", "9")
+
+	var out bytes.Buffer
+	if err := runConfig([]string{"print", "-listen", ":8080"}, &out); err != nil {
+		t.Fatalf("runConfig failed: %v", err)
+	}
+
+	var printed map[string]any
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if printed["token"] != "[REDACTED]" {
+		t.Errorf("expected redacted token, got %v", printed["token"])
+	}
+	if printed["listen_addr"] != ":8080" || printed["sessions_per_page"] != float64(9) {
+		t.Errorf("expected flag and env values, got listen_addr=%v sessions_per_page=%v",
+			printed["listen_addr"], printed["sessions_per_page"])
+	}
+}
+
+func TestRunConfigUsage(t *testing.T) {
+	if err := runConfig(nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected usage error without an action")
+	}
+	if err := runConfig([]string{"show"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected usage error for an unknown action")
+	}
+}
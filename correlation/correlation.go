@@ -0,0 +1,129 @@
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Package correlation tags everything done for one Telegram update with a shared
+// request ID, so its log lines can be grepped together across the webhook, handlers,
+// store and downloads.
+//
+// The bot library decodes webhook requests and runs handlers on its own goroutines,
+// so the request context does not reach the handlers. The webhook layer remembers
+// the request ID and trace span of each update by update ID, and the handler
+// middleware restores them into the handler context.
+
+type contextKey struct{}
+
+// NewID returns a new random request ID
+func NewID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// WithID returns a context carrying request ID id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the request ID carried by ctx, or "" when there is none
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Limits of the remembered updates. Updates that no handler claims are
+// dropped after rememberTTL, or oldest first once maxRemembered are kept.
+const (
+	rememberTTL   = time.Minute
+	maxRemembered = 10000
+)
+
+type remembered struct {
+	id          string
+	spanContext trace.SpanContext
+	storedAt    time.Time
+}
+
+// rememberedUpdate is an entry of the expiry queue
+type rememberedUpdate struct {
+	updateID int64
+	storedAt time.Time
+}
+
+var (
+	updatesMu sync.Mutex
+	updates   = make(map[int64]remembered)
+	order     []rememberedUpdate // updates in the order they were remembered, oldest first
+	now       = time.Now
+)
+
+// Remember records the request ID and span of ctx for the handlers of updateID
+func Remember(ctx context.Context, updateID int64) {
+	storedAt := now()
+
+	updatesMu.Lock()
+	defer updatesMu.Unlock()
+
+	expire(storedAt)
+	updates[updateID] = remembered{
+		id:          ID(ctx),
+		spanContext: trace.SpanContextFromContext(ctx),
+		storedAt:    storedAt,
+	}
+	order = append(order, rememberedUpdate{updateID: updateID, storedAt: storedAt})
+}
+
+// Forget drops what was remembered for updateID, such as an update that could not be queued
+func Forget(updateID int64) {
+	updatesMu.Lock()
+	delete(updates, updateID)
+	updatesMu.Unlock()
+}
+
+// expire drops the updates remembered longer than rememberTTL and the oldest
+// ones beyond maxRemembered. Only the front of the queue is looked at, so each
+// update is visited once. Entries already claimed, or remembered again later,
+// leave the map alone. Callers hold updatesMu.
+func expire(at time.Time) {
+	dropped := 0
+	for _, entry := range order {
+		if at.Sub(entry.storedAt) <= rememberTTL && len(order)-dropped < maxRemembered {
+			break
+		}
+		if current, ok := updates[entry.updateID]; ok && current.storedAt.Equal(entry.storedAt) {
+			delete(updates, entry.updateID)
+		}
+		dropped++
+	}
+	// append moves the remaining entries to a new array once the capacity left
+	// after the dropped ones runs out, so the dropped entries are freed then
+	order = order[dropped:]
+}
+
+// Restore returns ctx carrying the request ID and span remembered for updateID.
+// Each update is claimed once. Updates that did not come through the webhook
+// layer get a fresh request ID.
+func Restore(ctx context.Context, updateID int64) context.Context {
+	updatesMu.Lock()
+	entry, ok := updates[updateID]
+	delete(updates, updateID)
+	updatesMu.Unlock()
+
+	if !ok || entry.id == "" {
+		entry.id = NewID()
+	}
+	ctx = WithID(ctx, entry.id)
+
+	if entry.spanContext.IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, entry.spanContext)
+	}
+	return ctx
+}
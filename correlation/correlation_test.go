@@ -0,0 +1,96 @@
+package correlation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRestoreRememberedUpdate(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+
+	ctx, span := provider.Tracer("test").Start(WithID(context.Background(), "req-1"), "webhook")
+	defer span.End()
+
+	Remember(ctx, 42)
+
+	restored := Restore(context.Background(), 42)
+	if got := ID(restored); got != "req-1" {
+		t.Errorf("Expected request ID req-1, got %q", got)
+	}
+	got := trace.SpanContextFromContext(restored)
+	if got.TraceID() != span.SpanContext().TraceID() || !got.IsRemote() {
+		t.Errorf("Expected remote parent in trace %s, got %+v", span.SpanContext().TraceID(), got)
+	}
+
+	// Each update is claimed once; a second claim gets a fresh ID and no parent
+	again := Restore(context.Background(), 42)
+	if ID(again) == "" || ID(again) == "req-1" {
+		t.Errorf("Expected a fresh request ID, got %q", ID(again))
+	}
+	if trace.SpanContextFromContext(again).IsValid() {
+		t.Error("Expected no remembered span after the update was claimed")
+	}
+}
+
+func TestRememberExpiresUnclaimedUpdates(t *testing.T) {
+	clock := time.Now()
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	Remember(WithID(context.Background(), "stale"), 100)
+	clock = clock.Add(rememberTTL + time.Second)
+	Remember(WithID(context.Background(), "fresh"), 101)
+
+	if got := ID(Restore(context.Background(), 100)); got == "stale" {
+		t.Error("Expected the update remembered longer than the TTL to expire")
+	}
+	if got := ID(Restore(context.Background(), 101)); got != "fresh" {
+		t.Errorf("Expected request ID fresh, got %q", got)
+	}
+}
+
+func TestRememberKeepsAtMostMaxRemembered(t *testing.T) {
+	for i := 0; i <= maxRemembered; i++ {
+		Remember(WithID(context.Background(), "bulk"), int64(1000+i))
+	}
+
+	updatesMu.Lock()
+	size := len(updates)
+	updatesMu.Unlock()
+	if size > maxRemembered {
+		t.Errorf("Expected at most %d remembered updates, got %d", maxRemembered, size)
+	}
+	if got := ID(Restore(context.Background(), 1000)); got == "bulk" {
+		t.Error("Expected the oldest update dropped")
+	}
+	if got := ID(Restore(context.Background(), int64(1000+maxRemembered))); got != "bulk" {
+		t.Errorf("Expected the newest update kept, got %q", got)
+	}
+}
+
+func TestForget(t *testing.T) {
+	Remember(WithID(context.Background(), "req-9"), 9)
+	Forget(9)
+
+	if got := ID(Restore(context.Background(), 9)); got == "req-9" {
+		t.Error("Expected a forgotten update to get a fresh request ID")
+	}
+}
+
+func TestRestoreUnknownUpdate(t *testing.T) {
+	ctx := Restore(context.Background(), 7)
+	if len(ID(ctx)) != 16 {
+		t.Errorf("Expected a generated 16 character request ID, got %q", ID(ctx))
+	}
+}
+
+func TestIDWithoutValue(t *testing.T) {
+	if got := ID(context.Background()); got != "" {
+		t.Errorf("Expected empty ID, got %q", got)
+	}
+}
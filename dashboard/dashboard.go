@@ -0,0 +1,272 @@
+package dashboard
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tg-bot-demo/metrics"
+	"tg-bot-demo/session"
+
+	"github.com/google/uuid"
+)
+
+// Package dashboard serves a read-only admin web UI and the JSON admin API it is built on.
+// Every API endpoint requires "Authorization: Bearer <admin_token>"; the HTML page itself
+// carries no data and asks for the token in the browser. The expvar metrics at
+// /debug/vars need the token too, as they include the command line and its secrets,
+// and so do the same metrics in the Prometheus format at /metrics.
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// Store is the data the dashboard reads
+type Store interface {
+	session.Store
+	session.ActivityStore
+	session.AuditStore
+}
+
+// Server serves the admin dashboard and API
+type Server struct {
+	store Store
+	token string
+}
+
+// New creates a dashboard server protected by token
+func New(store Store, token string) *Server {
+	return &Server{store: store, token: token}
+}
+
+// Register adds the dashboard routes under /admin/ and the metrics at
+// /debug/vars and /metrics to mux
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.Handle("GET /debug/vars", s.requireToken(expvar.Handler()))
+	mux.Handle("GET /metrics", s.requireToken(metrics.Handler()))
+	mux.HandleFunc("GET /admin/{$}", s.handleIndex)
+	mux.Handle("GET /admin/api/activity", s.requireToken(http.HandlerFunc(s.handleActivity)))
+	mux.Handle("GET /admin/api/pending", s.requireToken(http.HandlerFunc(s.handlePending)))
+	mux.Handle("GET /admin/api/users/{userID}/sessions", s.requireToken(http.HandlerFunc(s.handleUserSessions)))
+	mux.Handle("GET /admin/api/sessions/{sessionID}/messages", s.requireToken(http.HandlerFunc(s.handleMessages)))
+	mux.Handle("GET /admin/api/audit", s.requireToken(http.HandlerFunc(s.handleAudit)))
+}
+
+// requireToken rejects requests without the admin bearer token
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(indexHTML)
+}
+
+// sessionPage is the response of the session list endpoints
+type sessionPage struct {
+	Sessions []*session.Session `json:"sessions"`
+	Total    *int               `json:"total,omitempty"` // only for per-user lists
+	Offset   int                `json:"offset"`
+	HasMore  bool               `json:"has_more"`
+}
+
+// pendingPage is the response of the pending sessions endpoint
+type pendingPage struct {
+	Sessions []*session.PendingSession `json:"sessions"`
+	Offset   int                       `json:"offset"`
+	HasMore  bool                      `json:"has_more"`
+}
+
+// messagePage is the response of the transcript endpoint
+type messagePage struct {
+	Session  *session.Session   `json:"session"`
+	Messages []*session.Message `json:"messages"`
+	Offset   int                `json:"offset"`
+	HasMore  bool               `json:"has_more"`
+}
+
+// auditPage is the response of the audit log endpoint
+type auditPage struct {
+	Entries []*session.AuditEntry `json:"entries"`
+	Offset  int                   `json:"offset"`
+	HasMore bool                  `json:"has_more"`
+}
+
+// handleActivity lists the most recently updated sessions of all users
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePage(r)
+
+	sessions, err := s.store.ListRecentSessions(r.Context(), r.URL.Query().Get("q"), offset, limit+1)
+	if err != nil {
+		internalError(w, "list recent sessions", err)
+		return
+	}
+
+	sessions, hasMore := trimPage(sessions, limit)
+	writeJSON(w, sessionPage{Sessions: sessions, Offset: offset, HasMore: hasMore})
+}
+
+// handlePending lists sessions waiting for a reply, the longest waiting first.
+// With min_wait_minutes only sessions waiting at least that long are listed,
+// which leaves the stuck ones.
+func (s *Server) handlePending(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePage(r)
+
+	minWait, err := strconv.Atoi(r.URL.Query().Get("min_wait_minutes"))
+	if err != nil || minWait < 0 {
+		minWait = 0
+	}
+	waitingBefore := time.Now().Add(-time.Duration(minWait) * time.Minute)
+
+	sessions, err := s.store.ListPendingSessions(r.Context(), waitingBefore, offset, limit+1)
+	if err != nil {
+		internalError(w, "list pending sessions", err)
+		return
+	}
+
+	sessions, hasMore := trimPage(sessions, limit)
+	writeJSON(w, pendingPage{Sessions: sessions, Offset: offset, HasMore: hasMore})
+}
+
+// handleUserSessions lists one user's sessions
+func (s *Server) handleUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(r.PathValue("userID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	offset, limit := parsePage(r)
+
+	sessions, err := s.store.SearchByUser(r.Context(), userID, r.URL.Query().Get("q"), offset, limit+1)
+	if err != nil {
+		internalError(w, "list user sessions", err)
+		return
+	}
+
+	total, err := s.store.CountByUser(r.Context(), userID)
+	if err != nil {
+		internalError(w, "count user sessions", err)
+		return
+	}
+
+	sessions, hasMore := trimPage(sessions, limit)
+	writeJSON(w, sessionPage{Sessions: sessions, Total: &total, Offset: offset, HasMore: hasMore})
+}
+
+// handleMessages returns a page of a session's transcript
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	offset, limit := parsePage(r)
+
+	sess, err := s.store.Get(r.Context(), sessionID)
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	if err != nil {
+		internalError(w, "get session", err)
+		return
+	}
+
+	messages, err := s.store.ListSessionMessages(r.Context(), sessionID, offset, limit+1)
+	if err != nil {
+		internalError(w, "list session messages", err)
+		return
+	}
+
+	messages, hasMore := trimPage(messages, limit)
+	writeJSON(w, messagePage{Session: sess, Messages: messages, Offset: offset, HasMore: hasMore})
+}
+
+// handleAudit lists audit log entries, newest first, optionally only those of
+// one action or one actor
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePage(r)
+
+	var actorID int64
+	if actor := r.URL.Query().Get("actor"); actor != "" {
+		var err error
+		if actorID, err = strconv.ParseInt(actor, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid actor id")
+			return
+		}
+	}
+
+	entries, err := s.store.ListAudit(r.Context(), r.URL.Query().Get("action"), actorID, offset, limit+1)
+	if err != nil {
+		internalError(w, "list audit log", err)
+		return
+	}
+
+	entries, hasMore := trimPage(entries, limit)
+	writeJSON(w, auditPage{Entries: entries, Offset: offset, HasMore: hasMore})
+}
+
+// parsePage reads offset and limit query parameters, falling back to defaults for invalid values
+func parsePage(r *http.Request) (offset, limit int) {
+	query := r.URL.Query()
+
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	limit, err = strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultPageSize
+	}
+	return offset, min(limit, maxPageSize)
+}
+
+// trimPage drops the extra item fetched to detect a following page
+func trimPage[T any](items []T, limit int) ([]T, bool) {
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("dashboard: encode response error: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func internalError(w http.ResponseWriter, op string, err error) {
+	log.Printf("dashboard: %s error: %v", op, err)
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
@@ -0,0 +1,236 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+)
+
+const testToken = "secret"
+
+func newTestServer(t *testing.T) (*httptest.Server, *session.SQLiteStore) {
+	t.Helper()
+
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "dashboard.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	mux := http.NewServeMux()
+	New(store, testToken).Register(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server, store
+}
+
+func get(t *testing.T, server *httptest.Server, path, token string, out any) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestDashboardRequiresToken(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	if status := get(t, server, "/admin/api/activity", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", status)
+	}
+	if status := get(t, server, "/admin/api/activity", "wrong", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with wrong token, got %d", status)
+	}
+
+	// Metrics include the command line, so they need the token as well
+	if status := get(t, server, "/debug/vars", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for metrics without token, got %d", status)
+	}
+	var vars map[string]any
+	if status := get(t, server, "/debug/vars", testToken, &vars); status != http.StatusOK || vars["cmdline"] == nil {
+		t.Errorf("Expected metrics with token, got %d %v", status, vars)
+	}
+	if status := get(t, server, "/metrics", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for Prometheus metrics without token, got %d", status)
+	}
+	if status := get(t, server, "/metrics", testToken, nil); status != http.StatusOK {
+		t.Errorf("Expected Prometheus metrics with token, got %d", status)
+	}
+
+	// The page itself carries no data
+	resp, err := http.Get(server.URL + "/admin/")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected HTML page, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestDashboardActivityAndUserSessions(t *testing.T) {
+	server, store := newTestServer(t)
+	ctx := context.Background()
+
+	base := time.Now()
+	for i := 0; i < 3; i++ {
+		s := session.NewSession(1, fmt.Sprintf("alpha %d", i))
+		s.UpdatedAt = base.Add(time.Duration(i) * time.Second)
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+	other := session.NewSession(2, "beta")
+	other.UpdatedAt = base.Add(time.Minute)
+	if err := store.Create(ctx, other); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	var page sessionPage
+	if status := get(t, server, "/admin/api/activity?limit=2", testToken, &page); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if len(page.Sessions) != 2 || !page.HasMore || page.Sessions[0].Title != "beta" {
+		t.Errorf("Unexpected activity page: %+v", page)
+	}
+
+	page = sessionPage{}
+	get(t, server, "/admin/api/activity?q=alpha&offset=2&limit=2", testToken, &page)
+	if len(page.Sessions) != 1 || page.HasMore || page.Offset != 2 {
+		t.Errorf("Unexpected searched activity page: %+v", page)
+	}
+
+	page = sessionPage{}
+	get(t, server, "/admin/api/users/1/sessions", testToken, &page)
+	if len(page.Sessions) != 3 || page.Total == nil || *page.Total != 3 {
+		t.Errorf("Unexpected user sessions page: %+v", page)
+	}
+
+	if status := get(t, server, "/admin/api/users/abc/sessions", testToken, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid user id, got %d", status)
+	}
+}
+
+func TestDashboardMessages(t *testing.T) {
+	server, store := newTestServer(t)
+	ctx := context.Background()
+
+	sess := session.NewSession(1, "chat")
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	base := time.Now()
+	for i := 0; i < 3; i++ {
+		message := session.NewMessage(sess.ID, 1, session.RoleUser, fmt.Sprintf("m%d", i))
+		message.CreatedAt = base.Add(time.Duration(i) * time.Second)
+		if err := store.AppendMessage(ctx, message); err != nil {
+			t.Fatalf("Failed to append message: %v", err)
+		}
+	}
+
+	var page messagePage
+	path := "/admin/api/sessions/" + sess.ID.String() + "/messages?offset=1&limit=1"
+	if status := get(t, server, path, testToken, &page); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if page.Session == nil || page.Session.ID != sess.ID {
+		t.Errorf("Expected session %s, got %+v", sess.ID, page.Session)
+	}
+	if len(page.Messages) != 1 || page.Messages[0].Content != "m1" || !page.HasMore {
+		t.Errorf("Unexpected transcript page: %+v", page)
+	}
+
+	if status := get(t, server, "/admin/api/sessions/"+session.NewSession(1, "x").ID.String()+"/messages", testToken, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown session, got %d", status)
+	}
+}
+
+func TestDashboardPending(t *testing.T) {
+	server, store := newTestServer(t)
+	ctx := context.Background()
+
+	base := time.Now()
+	for i, waited := range []time.Duration{time.Hour, time.Minute} {
+		s := session.NewSession(int64(i+1), fmt.Sprintf("waiting %d", i))
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		message := session.NewMessage(s.ID, s.UserID, session.RoleUser, "hello?")
+		message.CreatedAt = base.Add(-waited)
+		if err := store.AppendMessage(ctx, message); err != nil {
+			t.Fatalf("Failed to append message: %v", err)
+		}
+	}
+
+	var page pendingPage
+	if status := get(t, server, "/admin/api/pending", testToken, &page); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if len(page.Sessions) != 2 || page.Sessions[0].Title != "waiting 0" || page.Sessions[0].UnreadCount != 1 {
+		t.Errorf("Unexpected pending page: %+v", page)
+	}
+
+	page = pendingPage{}
+	get(t, server, "/admin/api/pending?min_wait_minutes=10", testToken, &page)
+	if len(page.Sessions) != 1 || page.Sessions[0].Title != "waiting 0" || page.HasMore {
+		t.Errorf("Expected only the stuck session, got %+v", page)
+	}
+}
+
+func TestDashboardAudit(t *testing.T) {
+	server, store := newTestServer(t)
+	ctx := context.Background()
+
+	for _, entry := range []*session.AuditEntry{
+		session.NewAuditEntry(1, session.AuditSessionDelete, "s-1", ""),
+		session.NewAuditEntry(9, session.AuditAdminPrefix+"purge", "1", "/admin purge 1"),
+	} {
+		if err := store.RecordAudit(ctx, entry); err != nil {
+			t.Fatalf("Failed to record audit entry: %v", err)
+		}
+	}
+
+	var page auditPage
+	if status := get(t, server, "/admin/api/audit?limit=1", testToken, &page); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].ActorID != 9 || !page.HasMore {
+		t.Errorf("Unexpected audit page: %+v", page)
+	}
+
+	page = auditPage{}
+	get(t, server, "/admin/api/audit?action=session.delete", testToken, &page)
+	if len(page.Entries) != 1 || page.Entries[0].Target != "s-1" || page.HasMore {
+		t.Errorf("Expected only the session delete, got %+v", page)
+	}
+
+	if status := get(t, server, "/admin/api/audit?actor=bob", testToken, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid actor, got %d", status)
+	}
+}
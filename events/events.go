@@ -0,0 +1,156 @@
+package events
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+
+	"tg-bot-demo/correlation"
+
+	"github.com/google/uuid"
+)
+
+// Package events publishes what happens in the bot — sessions created and
+// closed, messages stored, files downloaded — to other systems. Producers call
+// Publish on a Publisher; the Bus queues events and delivers them in the
+// background to its sinks, such as HTTP webhooks or a NATS server, so a slow
+// endpoint never holds up a Telegram update.
+
+// Type identifies what happened
+type Type string
+
+// Event types
+const (
+	SessionCreated Type = "session.created"
+	SessionClosed  Type = "session.closed"
+	MessageStored  Type = "message.stored"
+	FileDownloaded Type = "file.downloaded"
+)
+
+// DefaultQueueSize is the number of undelivered events a Bus holds before dropping new ones
+const DefaultQueueSize = 1024
+
+// Metrics published under /debug/vars
+var (
+	eventsDelivered = expvar.NewInt("events_delivered_total")
+	eventsFailed    = expvar.NewInt("events_failed_total")
+	eventsDropped   = expvar.NewInt("events_dropped_total")
+)
+
+// Event is one thing that happened in the bot. ID, Time and RequestID are
+// filled in by Publish when empty.
+type Event struct {
+	ID        string         `json:"id"`
+	Type      Type           `json:"type"`
+	Time      time.Time      `json:"time"`
+	RequestID string         `json:"request_id,omitempty"` // the Telegram update that caused the event
+	UserID    int64          `json:"user_id"`
+	SessionID string         `json:"session_id,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// Publisher accepts events. Publish must not block on delivery.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// Sink delivers events to one destination
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// Bus fans events out to sinks from a background worker
+type Bus struct {
+	sinks map[string]Sink
+	queue chan Event
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewBus creates a bus holding up to queueSize undelivered events
+func NewBus(queueSize int) *Bus {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	return &Bus{
+		sinks: make(map[string]Sink),
+		queue: make(chan Event, queueSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// Subscribe adds a sink under name, used in logs. Sinks must be added before Start.
+func (b *Bus) Subscribe(name string, sink Sink) {
+	b.sinks[name] = sink
+}
+
+// Publish queues event for delivery. When the queue is full the event is dropped
+// and counted, so producers never wait for slow sinks.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.RequestID == "" {
+		event.RequestID = correlation.ID(ctx)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	select {
+	case b.queue <- event:
+	default:
+		eventsDropped.Add(1)
+		log.Printf("events: queue full, dropping event: type=%s id=%s", event.Type, event.ID)
+	}
+}
+
+// Start delivers queued events until Close
+func (b *Bus) Start() {
+	go func() {
+		defer close(b.done)
+		for event := range b.queue {
+			b.deliver(event)
+		}
+	}()
+}
+
+// deliver sends event to every sink
+func (b *Bus) deliver(event Event) {
+	for name, sink := range b.sinks {
+		if err := sink.Send(context.Background(), event); err != nil {
+			eventsFailed.Add(1)
+			log.Printf("events: delivery failed: sink=%s type=%s id=%s err=%v", name, event.Type, event.ID, err)
+			continue
+		}
+		eventsDelivered.Add(1)
+	}
+}
+
+// Close stops accepting events and waits up to timeout for queued ones to be delivered.
+// It reports whether the queue was drained.
+func (b *Bus) Close(timeout time.Duration) bool {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
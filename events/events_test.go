@@ -0,0 +1,187 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tg-bot-demo/correlation"
+)
+
+// recordingSink keeps delivered events in memory
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+}
+
+func (s *recordingSink) Send(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func TestBus_DeliversToEverySink(t *testing.T) {
+	bus := NewBus(10)
+	first, failing := &recordingSink{}, &recordingSink{err: errors.New("down")}
+	bus.Subscribe("first", first)
+	bus.Subscribe("failing", failing)
+	bus.Start()
+
+	ctx := correlation.WithID(context.Background(), "req-1")
+	bus.Publish(ctx, Event{Type: SessionCreated, UserID: 1, SessionID: "s1"})
+	bus.Publish(ctx, Event{Type: SessionClosed, UserID: 1, SessionID: "s1"})
+	if !bus.Close(time.Second) {
+		t.Fatal("expected the queue to drain")
+	}
+
+	if len(first.events) != 2 || len(failing.events) != 2 {
+		t.Fatalf("expected both sinks to get 2 events, got %d and %d", len(first.events), len(failing.events))
+	}
+	event := first.events[0]
+	if event.Type != SessionCreated || event.ID == "" || event.Time.IsZero() || event.RequestID != "req-1" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	// Publishing after Close is a no-op
+	bus.Publish(ctx, Event{Type: MessageStored})
+}
+
+func TestBus_DropsWhenFull(t *testing.T) {
+	bus := NewBus(1)
+	sink := &recordingSink{}
+	bus.Subscribe("sink", sink)
+
+	before := eventsDropped.Value()
+	bus.Publish(context.Background(), Event{Type: MessageStored})
+	bus.Publish(context.Background(), Event{Type: MessageStored})
+	if dropped := eventsDropped.Value() - before; dropped != 1 {
+		t.Errorf("expected 1 dropped event, got %d", dropped)
+	}
+
+	bus.Start()
+	bus.Close(time.Second)
+	if len(sink.events) != 1 {
+		t.Errorf("expected the queued event to be delivered, got %d", len(sink.events))
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var attempts int
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if r.Header.Get("X-Signature-256") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("invalid signature %q", r.Header.Get("X-Signature-256"))
+		}
+		if r.Header.Get("X-Event-Type") != string(FileDownloaded) {
+			t.Errorf("unexpected event type header %q", r.Header.Get("X-Event-Type"))
+		}
+		json.Unmarshal(body, &got)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, "secret", time.Second)
+	sink.backoff = time.Millisecond
+	err := sink.Send(context.Background(), Event{ID: "e1", Type: FileDownloaded, UserID: 7, Data: map[string]any{"size": 4}})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if attempts != 2 || got.ID != "e1" || got.UserID != 7 {
+		t.Errorf("expected a retried delivery of e1, got attempts=%d event=%+v", attempts, got)
+	}
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+	attempts = 0
+	sink = NewWebhookSink(rejecting.URL, "", time.Second)
+	if err := sink.Send(context.Background(), Event{Type: FileDownloaded}); err == nil || attempts != 1 {
+		t.Errorf("expected a single failed attempt for a 4xx, got attempts=%d err=%v", attempts, err)
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	published := make(chan string, 1)
+	connects := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch fields := strings.Fields(line); fields[0] {
+			case "CONNECT":
+				connects <- line
+			case "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				io.ReadFull(reader, payload)
+				published <- fields[1] + " " + string(payload[:size])
+			}
+		}
+	}()
+
+	sink, err := NewNATSSink("nats://bot:pw@"+listener.Addr().String(), "tgbot.events", time.Second)
+	if err != nil {
+		t.Fatalf("NewNATSSink failed: %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Send(context.Background(), Event{ID: "e1", Type: SessionCreated, UserID: 1}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if connect := <-connects; !strings.Contains(connect, `"user":"bot"`) || !strings.Contains(connect, `"pass":"pw"`) {
+		t.Errorf("expected credentials in CONNECT, got %q", connect)
+	}
+	select {
+	case message := <-published:
+		if !strings.HasPrefix(message, "tgbot.events.session.created {") || !strings.Contains(message, `"id":"e1"`) {
+			t.Errorf("unexpected publish %q", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the publish")
+	}
+
+	if _, err := NewNATSSink("http://localhost:4222", "tgbot", time.Second); err == nil {
+		t.Error("expected an error for a non-nats URL")
+	}
+}
@@ -0,0 +1,175 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsDefaultPort is used when a nats:// URL has no port
+const natsDefaultPort = "4222"
+
+// NATSSink publishes events to a NATS server on "<subject prefix>.<event type>",
+// e.g. "tgbot.events.session.created". It speaks the NATS text protocol over a
+// plain TCP connection and reconnects on the next event after a failure.
+type NATSSink struct {
+	address string
+	connect []byte // CONNECT line with credentials from the URL
+	prefix  string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewNATSSink creates a sink for a nats://[user:password@]host[:port] URL.
+// A user without password is sent as an auth token.
+func NewNATSSink(rawURL, subjectPrefix string, timeout time.Duration) (*NATSSink, error) {
+	serverURL, err := url.Parse(rawURL)
+	if err != nil || serverURL.Scheme != "nats" || serverURL.Hostname() == "" {
+		return nil, fmt.Errorf("nats URL must look like nats://host:4222, got %q", rawURL)
+	}
+	address := serverURL.Host
+	if serverURL.Port() == "" {
+		address = net.JoinHostPort(serverURL.Hostname(), natsDefaultPort)
+	}
+
+	options := map[string]any{"verbose": false, "pedantic": false, "name": "tg-bot-demo", "lang": "go"}
+	if user := serverURL.User; user != nil {
+		if password, ok := user.Password(); ok {
+			options["user"] = user.Username()
+			options["pass"] = password
+		} else {
+			options["auth_token"] = user.Username()
+		}
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSSink{
+		address: address,
+		connect: append(append([]byte("CONNECT "), connect...), "\r\n"...),
+		prefix:  strings.TrimSuffix(subjectPrefix, "."),
+		timeout: timeout,
+	}, nil
+}
+
+// Send publishes event, connecting first when needed
+func (s *NATSSink) Send(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	subject := s.prefix + "." + string(event.Type)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.dial(ctx); err != nil {
+			return err
+		}
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	message := fmt.Sprintf("PUB %s %d\r\n%s\r\n", subject, len(payload), payload)
+	if _, err := s.conn.Write([]byte(message)); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("publish to nats: %w", err)
+	}
+	return nil
+}
+
+// dial connects and authenticates; the caller holds s.mu
+func (s *NATSSink) dial(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("connect to nats: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(s.timeout))
+	reader := bufio.NewReader(conn)
+
+	// The server greets with INFO; a PING after CONNECT is answered with PONG
+	// once the credentials are accepted, or -ERR when they are not
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("connect to nats: unexpected greeting %q: %v", strings.TrimSpace(line), err)
+	}
+	if _, err := conn.Write(append(s.connect, "PING\r\n"...)); err != nil {
+		conn.Close()
+		return fmt.Errorf("connect to nats: %w", err)
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("connect to nats: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return fmt.Errorf("connect to nats: %s", line)
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	s.conn = conn
+	go s.serve(conn, reader)
+	return nil
+}
+
+// serve answers the server's keepalive pings until the connection fails
+func (s *NATSSink) serve(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PING":
+			s.mu.Lock()
+			conn.SetWriteDeadline(time.Now().Add(s.timeout))
+			_, err = conn.Write([]byte("PONG\r\n"))
+			s.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("events: nats error: %s", line)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conn.Close()
+	if s.conn == conn {
+		s.conn = nil
+	}
+}
+
+// Close closes the connection
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhookAttempts is how often a webhook delivery is tried before giving up
+const webhookAttempts = 3
+
+// WebhookSink posts events as JSON to an HTTP endpoint. With a secret, the body
+// is signed in the X-Signature-256 header as "sha256=<hex HMAC>", so the
+// receiver can check that the event came from the bot.
+type WebhookSink struct {
+	url     string
+	secret  []byte
+	client  *http.Client
+	backoff time.Duration
+}
+
+// NewWebhookSink creates a sink posting to url
+func NewWebhookSink(url, secret string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:     url,
+		secret:  []byte(secret),
+		client:  &http.Client{Timeout: timeout},
+		backoff: time.Second,
+	}
+}
+
+// Send posts event, retrying network errors and 5xx responses
+func (s *WebhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		retry, err := s.post(ctx, event, body)
+		if err == nil || !retry || attempt == webhookAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.backoff * time.Duration(attempt)):
+		}
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying
+func (s *WebhookSink) post(ctx context.Context, event Event, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("create webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Event-Type", string(event.Type))
+	request.Header.Set("X-Event-ID", event.ID)
+	if len(s.secret) > 0 {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(body)
+		request.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return true, fmt.Errorf("post event: %w", err)
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+
+	if response.StatusCode >= 300 {
+		return response.StatusCode >= 500, fmt.Errorf("post event: status %d", response.StatusCode)
+	}
+	return false, nil
+}
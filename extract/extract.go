@@ -0,0 +1,157 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Package extract pulls plain text out of downloaded documents.
+// Extractors are tried in registration order; the first one that supports
+// a document's file name or MIME type is used.
+
+// Default limits for extracted content
+const (
+	DefaultMaxInputBytes = 20 << 20 // Telegram bots cannot download larger files anyway
+	DefaultMaxChars      = 20000
+)
+
+// Error types
+var (
+	ErrUnsupported = errors.New("unsupported document type")
+	ErrEmpty       = errors.New("no text found in document")
+	ErrTooLarge    = errors.New("document too large to extract")
+)
+
+// Extractor converts one kind of document to plain text
+type Extractor interface {
+	// Name identifies the extractor in logs and replies
+	Name() string
+
+	// Supports reports whether the extractor handles a document with this name and MIME type
+	Supports(fileName, mimeType string) bool
+
+	// Extract reads the whole document from r and returns its text
+	Extract(ctx context.Context, r io.Reader) (string, error)
+}
+
+// Result holds the text extracted from a document
+type Result struct {
+	Extractor string
+	Text      string
+	Truncated bool
+}
+
+// Pipeline selects an extractor for a document and normalizes its output
+type Pipeline struct {
+	extractors    []Extractor
+	maxInputBytes int64
+	maxChars      int
+}
+
+// NewPipeline creates a pipeline with the given extractors and default limits
+func NewPipeline(extractors ...Extractor) *Pipeline {
+	return &Pipeline{
+		extractors:    extractors,
+		maxInputBytes: DefaultMaxInputBytes,
+		maxChars:      DefaultMaxChars,
+	}
+}
+
+// NewDefaultPipeline creates a pipeline for plain text, subtitles and PDF documents
+func NewDefaultPipeline() *Pipeline {
+	return NewPipeline(SubtitleExtractor{}, PlainTextExtractor{}, PDFExtractor{})
+}
+
+// Register appends an extractor to the pipeline
+func (p *Pipeline) Register(extractor Extractor) {
+	p.extractors = append(p.extractors, extractor)
+}
+
+// Supports reports whether any extractor handles the document
+func (p *Pipeline) Supports(fileName, mimeType string) bool {
+	return p.find(fileName, mimeType) != nil
+}
+
+// Extract returns the text of a document, truncated to the pipeline's character limit
+func (p *Pipeline) Extract(ctx context.Context, fileName, mimeType string, r io.Reader) (*Result, error) {
+	extractor := p.find(fileName, mimeType)
+	if extractor == nil {
+		return nil, ErrUnsupported
+	}
+
+	limited := &io.LimitedReader{R: r, N: p.maxInputBytes + 1}
+	text, err := extractor.Extract(ctx, limited)
+	if err != nil {
+		return nil, fmt.Errorf("%s extractor: %w", extractor.Name(), err)
+	}
+	if limited.N <= 0 {
+		return nil, ErrTooLarge
+	}
+
+	text = normalizeText(text)
+	if text == "" {
+		return nil, ErrEmpty
+	}
+
+	result := &Result{Extractor: extractor.Name(), Text: text}
+	if utf8.RuneCountInString(text) > p.maxChars {
+		result.Text = string([]rune(text)[:p.maxChars])
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+func (p *Pipeline) find(fileName, mimeType string) Extractor {
+	for _, extractor := range p.extractors {
+		if extractor.Supports(fileName, mimeType) {
+			return extractor
+		}
+	}
+	return nil
+}
+
+// normalizeText unifies line endings, trims trailing spaces and collapses runs of blank lines
+func normalizeText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// hasExtension reports whether fileName ends with one of exts (case-insensitive)
+func hasExtension(fileName string, exts ...string) bool {
+	ext := strings.ToLower(path.Ext(fileName))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// baseMimeType strips parameters such as "; charset=utf-8"
+func baseMimeType(mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	return strings.ToLower(strings.TrimSpace(base))
+}
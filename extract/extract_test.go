@@ -0,0 +1,154 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPipeline_SelectsExtractor(t *testing.T) {
+	pipeline := NewDefaultPipeline()
+
+	tests := []struct {
+		name      string
+		fileName  string
+		mimeType  string
+		extractor string
+	}{
+		{"text by mime", "notes", "text/plain", "text"},
+		{"markdown by extension", "README.md", "", "text"},
+		{"srt by extension", "movie.srt", "application/octet-stream", "subtitles"},
+		{"vtt by mime", "captions", "text/vtt", "subtitles"},
+		{"pdf by mime", "scan", "application/pdf", "pdf"},
+		{"pdf by extension", "Report.PDF", "", "pdf"},
+		{"unsupported", "photo.jpg", "image/jpeg", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractor := pipeline.find(tt.fileName, tt.mimeType)
+			got := ""
+			if extractor != nil {
+				got = extractor.Name()
+			}
+			if got != tt.extractor {
+				t.Errorf("expected extractor %q, got %q", tt.extractor, got)
+			}
+		})
+	}
+}
+
+func TestPipeline_Extract(t *testing.T) {
+	ctx := context.Background()
+	pipeline := NewDefaultPipeline()
+
+	result, err := pipeline.Extract(ctx, "notes.txt", "", strings.NewReader("\ufeffline one  \r\n\r\n\r\n\r\nline two\n"))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if result.Text != "line one\n\nline two" {
+		t.Errorf("unexpected normalized text: %q", result.Text)
+	}
+
+	if _, err := pipeline.Extract(ctx, "photo.jpg", "image/jpeg", strings.NewReader("x")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+	if _, err := pipeline.Extract(ctx, "empty.txt", "", strings.NewReader(" \n\n ")); !errors.Is(err, ErrEmpty) {
+		t.Errorf("expected ErrEmpty, got %v", err)
+	}
+}
+
+func TestPipeline_Limits(t *testing.T) {
+	ctx := context.Background()
+
+	pipeline := NewPipeline(PlainTextExtractor{})
+	pipeline.maxChars = 5
+	result, err := pipeline.Extract(ctx, "a.txt", "", strings.NewReader("héllo world"))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if result.Text != "héllo" || !result.Truncated {
+		t.Errorf("expected truncated text 'héllo', got %q (truncated=%t)", result.Text, result.Truncated)
+	}
+
+	pipeline.maxInputBytes = 4
+	if _, err := pipeline.Extract(ctx, "a.txt", "", strings.NewReader("12345")); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestSubtitleExtractor(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "srt",
+			input: "1\n00:00:01,000 --> 00:00:02,000\n<i>Hello</i> there\n\n2\n00:00:02,000 --> 00:00:03,000\nHello there\n\n3\n00:00:03,500 --> 00:00:04,000\nGeneral Kenobi\n",
+			want:  "Hello there\nGeneral Kenobi",
+		},
+		{
+			name:  "vtt",
+			input: "WEBVTT\nKind: captions\n\nNOTE written by hand\nsecond note line\n\n00:01.000 --> 00:02.000 align:start\n<c.yellow>First</c> line\n\ncue-2\n00:02.000 --> 00:03.000\nSecond line\n",
+			want:  "First line\ncue-2\nSecond line",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SubtitleExtractor{}.Extract(context.Background(), strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPDFExtractor(t *testing.T) {
+	got, err := PDFExtractor{}.Extract(context.Background(), bytes.NewReader(buildTestPDF("Hello PDF")))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if !strings.Contains(got, "Hello PDF") {
+		t.Errorf("expected extracted text to contain 'Hello PDF', got %q", got)
+	}
+
+	if _, err := (PDFExtractor{}).Extract(context.Background(), strings.NewReader("not a pdf")); err == nil {
+		t.Error("expected error for invalid PDF")
+	}
+}
+
+// buildTestPDF returns a minimal one-page PDF that shows text in Helvetica
+func buildTestPDF(text string) []byte {
+	content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,70 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDFExtractor handles PDF documents with a text layer.
+// Scanned PDFs without embedded text yield ErrEmpty.
+type PDFExtractor struct{}
+
+// Name returns the extractor name
+func (PDFExtractor) Name() string { return "pdf" }
+
+// Supports reports whether the document is a PDF
+func (PDFExtractor) Supports(fileName, mimeType string) bool {
+	return baseMimeType(mimeType) == "application/pdf" || hasExtension(fileName, ".pdf")
+}
+
+// Extract returns the text of every page, separated by blank lines
+func (PDFExtractor) Extract(ctx context.Context, r io.Reader) (text string, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	// The PDF parser panics on some malformed documents
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("malformed pdf: %v", recovered)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("open pdf: %w", err)
+	}
+
+	var sb strings.Builder
+	for i := 1; i <= reader.NumPage(); i++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		// Font resource names are scoped to the page
+		fonts := make(map[string]*pdf.Font)
+		for _, name := range page.Fonts() {
+			font := page.Font(name)
+			fonts[name] = &font
+		}
+
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("read page %d: %w", i, err)
+		}
+		sb.WriteString(pageText)
+		sb.WriteString("\n\n")
+	}
+
+	return sb.String(), nil
+}
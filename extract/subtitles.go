@@ -0,0 +1,72 @@
+package extract
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// subtitleTimingPattern matches SRT and WebVTT cue timings such as "00:00:01,000 --> 00:00:02,500"
+	subtitleTimingPattern = regexp.MustCompile(`^\s*(\d+:)?\d{1,2}:\d{2}[.,]\d{3}\s+-->\s+`)
+	// subtitleTagPattern matches inline markup such as <i>, </b> or <c.yellow>
+	subtitleTagPattern = regexp.MustCompile(`</?[a-zA-Z][^>]*>|\{\\[^}]*\}`)
+)
+
+// SubtitleExtractor handles SRT and WebVTT subtitles, keeping only the spoken text
+type SubtitleExtractor struct{}
+
+// Name returns the extractor name
+func (SubtitleExtractor) Name() string { return "subtitles" }
+
+// Supports reports whether the document is a subtitle file
+func (SubtitleExtractor) Supports(fileName, mimeType string) bool {
+	switch baseMimeType(mimeType) {
+	case "application/x-subrip", "text/vtt":
+		return true
+	}
+	return hasExtension(fileName, ".srt", ".vtt")
+}
+
+// Extract strips cue numbers, timings, headers and markup, and drops repeated lines
+func (SubtitleExtractor) Extract(ctx context.Context, r io.Reader) (string, error) {
+	raw, err := PlainTextExtractor{}.Extract(ctx, r)
+	if err != nil {
+		return "", err
+	}
+
+	var out []string
+	inHeader := false
+	last := ""
+	for _, line := range strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+			inHeader = false
+			continue
+		case strings.HasPrefix(line, "WEBVTT"), strings.HasPrefix(line, "NOTE"),
+			strings.HasPrefix(line, "STYLE"), strings.HasPrefix(line, "REGION"):
+			inHeader = true
+			continue
+		case inHeader:
+			continue
+		case subtitleTimingPattern.MatchString(line):
+			continue
+		}
+		if _, err := strconv.Atoi(line); err == nil {
+			continue
+		}
+
+		line = strings.TrimSpace(subtitleTagPattern.ReplaceAllString(line, ""))
+		if line == "" || line == last {
+			continue
+		}
+		out = append(out, line)
+		last = line
+	}
+
+	return strings.Join(out, "\n"), nil
+}
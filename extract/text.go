@@ -0,0 +1,41 @@
+package extract
+
+import (
+	"context"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// PlainTextExtractor handles plain text, Markdown and similar text formats
+type PlainTextExtractor struct{}
+
+// Name returns the extractor name
+func (PlainTextExtractor) Name() string { return "text" }
+
+// Supports reports whether the document is a text file
+func (PlainTextExtractor) Supports(fileName, mimeType string) bool {
+	mimeType = baseMimeType(mimeType)
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	switch mimeType {
+	case "application/json", "application/xml", "application/x-yaml", "application/yaml":
+		return true
+	}
+	return hasExtension(fileName, ".txt", ".md", ".markdown", ".csv", ".tsv", ".log", ".json", ".yaml", ".yml", ".xml")
+}
+
+// Extract returns the document content, dropping a UTF-8 BOM and invalid byte sequences
+func (PlainTextExtractor) Extract(ctx context.Context, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "")
+	}
+	return text, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/handlers"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/notify"
+	"tg-bot-demo/ratelimit"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// fileFloodGuard pauses the downloads of users sending many files in a short
+// window. When a pause starts the user is told, administrators are notified
+// and the flood is recorded in the audit log. Administrators are exempt.
+type fileFloodGuard struct {
+	detector *ratelimit.FloodDetector
+	options  ratelimit.FloodOptions
+	adminIDs []int64
+	notifier *notify.Notifier
+	prefs    session.PreferenceStore // languages of the notified administrators
+	audit    session.AuditStore
+}
+
+// newFileFloodGuard creates a flood guard with the given thresholds
+func newFileFloodGuard(options ratelimit.FloodOptions, adminIDs []int64, notifier *notify.Notifier,
+	prefs session.PreferenceStore, audit session.AuditStore) *fileFloodGuard {
+	return &fileFloodGuard{
+		detector: ratelimit.NewFloodDetector(options),
+		options:  options,
+		adminIDs: adminIDs,
+		notifier: notifier,
+		prefs:    prefs,
+		audit:    audit,
+	}
+}
+
+// allow reports whether the files of message may be downloaded. Only messages
+// sent by users count; channel posts and bots are never paused.
+func (g *fileFloodGuard) allow(ctx context.Context, b handlers.TelegramAPI, message *models.Message, files int) bool {
+	if message.From == nil || message.From.IsBot || slices.Contains(g.adminIDs, message.From.ID) {
+		return true
+	}
+	userID := message.From.ID
+
+	decision := g.detector.Record(userID, files)
+	if decision.Allowed {
+		return true
+	}
+	log.Printf("download skipped: request_id=%s chat_id=%d message_id=%d user_id=%d reason=file_flood paused_until=%s",
+		correlation.ID(ctx), message.Chat.ID, message.ID, userID, decision.PausedUntil.Format(time.RFC3339))
+	if decision.Tripped {
+		g.report(ctx, b, message, decision.PausedUntil)
+	}
+	return false
+}
+
+// report tells the user and the administrators that a pause started and records it in the audit log
+func (g *fileFloodGuard) report(ctx context.Context, b handlers.TelegramAPI, message *models.Message, pausedUntil time.Time) {
+	userID := message.From.ID
+	minutes := int(g.options.Pause.Minutes())
+
+	tr := i18n.FromContext(ctx)
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          message.Chat.ID,
+		MessageThreadID: message.MessageThreadID,
+		Text:            tr.Sprintf("⏸ You sent too many files in a short time. Files are not saved for the next %d minute(s).", minutes),
+	}); err != nil {
+		log.Printf("reply failed: request_id=%s chat_id=%v message_id=%d err=%v", correlation.ID(ctx), message.Chat.ID, message.ID, err)
+	}
+
+	for _, adminID := range g.adminIDs {
+		lang := ""
+		if prefs, err := g.prefs.GetPreferences(ctx, adminID); err == nil {
+			lang = prefs.Language
+		}
+		text := i18n.New(lang).Sprintf("🚨 User %d sent more than %d files within %d second(s). Their downloads are paused for %d minute(s).",
+			userID, g.options.MaxFiles, int(g.options.Window.Seconds()), minutes)
+		// Private chats share the ID of the user
+		if _, err := g.notifier.Send(ctx, b, adminID, adminID, text, ""); err != nil {
+			log.Printf("flood notice failed: request_id=%s admin_id=%d err=%v", correlation.ID(ctx), adminID, err)
+		}
+	}
+
+	target := strconv.FormatInt(userID, 10)
+	entry := session.NewAuditEntry(userID, session.AuditFileFlood, target, "")
+	if err := g.audit.RecordAudit(ctx, entry); err != nil {
+		log.Printf("audit failed: request_id=%s user_id=%d action=%s err=%v", correlation.ID(ctx), userID, session.AuditFileFlood, err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/notify"
+	"tg-bot-demo/ratelimit"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestFileFloodGuard(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "flood.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	guard := newFileFloodGuard(ratelimit.FloodOptions{MaxFiles: 3, Window: time.Minute, Pause: 10 * time.Minute},
+		[]int64{9}, notify.New(store), store, store)
+	api := testutil.NewFakeTelegram()
+	message := func(userID int64) *models.Message {
+		return &models.Message{ID: 1, Chat: models.Chat{ID: userID}, From: &models.User{ID: userID}}
+	}
+
+	if !guard.allow(ctx, api, message(1), 3) {
+		t.Fatal("expected files up to the limit to be allowed")
+	}
+	if guard.allow(ctx, api, message(1), 1) {
+		t.Fatal("expected the file over the limit to be refused")
+	}
+	if guard.allow(ctx, api, message(1), 1) {
+		t.Fatal("expected downloads to stay paused")
+	}
+
+	if len(api.Sent) != 2 {
+		t.Fatalf("expected one notice to the user and one to the admin, got %d messages", len(api.Sent))
+	}
+	if api.Sent[0].ChatID != int64(1) || !strings.Contains(api.Sent[0].Text, "not saved for the next 10 minute(s)") {
+		t.Errorf("unexpected user notice %+v", api.Sent[0])
+	}
+	if api.Sent[1].ChatID != int64(9) || !strings.Contains(api.Sent[1].Text, "User 1 sent more than 3 files within 60 second(s)") {
+		t.Errorf("unexpected admin notice %+v", api.Sent[1])
+	}
+
+	entries, err := store.ListAudit(ctx, session.AuditFileFlood, 0, 0, 10)
+	if err != nil {
+		t.Fatalf("ListAudit failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ActorID != 1 || entries[0].Target != "1" {
+		t.Errorf("expected the flood audited once, got %+v", entries)
+	}
+
+	for range 3 {
+		if !guard.allow(ctx, api, message(9), 5) {
+			t.Fatal("expected administrators to be exempt")
+		}
+	}
+	channelPost := &models.Message{ID: 2, Chat: models.Chat{ID: -100}, SenderChat: &models.Chat{ID: -100}}
+	if !guard.allow(ctx, api, channelPost, 10) {
+		t.Error("expected channel posts to be exempt")
+	}
+}
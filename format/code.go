@@ -0,0 +1,66 @@
+package format
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// CodeBlock is a fenced code block of a Markdown text
+type CodeBlock struct {
+	Language string // first word of the fence's info string, e.g. "go"; may be a file name
+	Code     string
+}
+
+// codeExtensions maps common fence languages to file extensions
+var codeExtensions = map[string]string{
+	"bash": "sh", "c": "c", "cpp": "cpp", "c++": "cpp", "csharp": "cs", "cs": "cs", "css": "css",
+	"dockerfile": "Dockerfile", "go": "go", "html": "html", "java": "java", "javascript": "js",
+	"js": "js", "json": "json", "kotlin": "kt", "lua": "lua", "markdown": "md", "md": "md",
+	"php": "php", "python": "py", "py": "py", "ruby": "rb", "rust": "rs", "scala": "scala",
+	"sh": "sh", "shell": "sh", "sql": "sql", "swift": "swift", "toml": "toml", "ts": "ts",
+	"typescript": "ts", "xml": "xml", "yaml": "yaml", "yml": "yml", "zsh": "sh",
+}
+
+// CodeBlocks returns the fenced code blocks of text in order
+func CodeBlocks(text string) []CodeBlock {
+	var blocks []CodeBlock
+	for _, block := range markdownBlocks(text) {
+		if block.fence == "" {
+			continue
+		}
+		lines := strings.Split(block.text, "\n")[1:]
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == fenceMarker(block.fence) {
+			lines = lines[:n-1]
+		}
+		info := strings.Fields(strings.TrimLeft(block.fence, block.fence[:1]))
+		language := ""
+		if len(info) > 0 {
+			language = info[0]
+		}
+		blocks = append(blocks, CodeBlock{Language: language, Code: strings.Join(lines, "\n")})
+	}
+	return blocks
+}
+
+// Lines returns the number of lines of the code
+func (c CodeBlock) Lines() int {
+	return strings.Count(c.Code, "\n") + 1
+}
+
+// FileName names the file holding the code: the info string when it is a
+// file name such as "main.go", otherwise "snippet-<n>" with the language's
+// extension, or .txt for unknown languages
+func (c CodeBlock) FileName(n int) string {
+	if base := path.Base(c.Language); strings.Contains(base, ".") && !strings.HasPrefix(base, ".") {
+		return base
+	}
+	extension, ok := codeExtensions[strings.ToLower(c.Language)]
+	switch {
+	case !ok:
+		extension = "txt"
+	case extension == "Dockerfile":
+		return "Dockerfile"
+	}
+	return fmt.Sprintf("snippet-%d.%s", n, extension)
+}
@@ -0,0 +1,30 @@
+package format
+
+import "testing"
+
+func TestCodeBlocks(t *testing.T) {
+	text := "Intro\n\n```go\npackage main\n\nfunc main() {}\n```\n\nThen:\n\n~~~ cmd/tool/main.py extra\nprint(1)\n~~~\n\n```\nplain\n```"
+	blocks := CodeBlocks(text)
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 code blocks, got %+v", blocks)
+	}
+	if blocks[0].Language != "go" || blocks[0].Code != "package main\n\nfunc main() {}" || blocks[0].Lines() != 3 {
+		t.Errorf("unexpected first block %+v", blocks[0])
+	}
+
+	tests := []struct {
+		block CodeBlock
+		want  string
+	}{
+		{blocks[0], "snippet-1.go"},
+		{blocks[1], "main.py"},
+		{blocks[2], "snippet-1.txt"},
+		{CodeBlock{Language: "Python"}, "snippet-1.py"},
+		{CodeBlock{Language: "dockerfile"}, "Dockerfile"},
+	}
+	for _, tt := range tests {
+		if got := tt.block.FileName(1); got != tt.want {
+			t.Errorf("FileName(%q) = %q, want %q", tt.block.Language, got, tt.want)
+		}
+	}
+}
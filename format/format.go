@@ -0,0 +1,39 @@
+// Package format renders text for Telegram messages sent in HTML parse mode.
+//
+// Replies that embed user-provided values (session titles, file names, document
+// text, AI output) are sent with models.ParseModeHTML. HTML mode reserves only
+// &, < and >, so escaping is simpler and safer than MarkdownV2. Every
+// user-provided value placed in such a reply must go through one of these helpers.
+package format
+
+import "strings"
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape makes s safe to embed as plain text in an HTML message
+func Escape(s string) string {
+	return htmlEscaper.Replace(s)
+}
+
+// Bold renders s in bold
+func Bold(s string) string {
+	return "<b>" + Escape(s) + "</b>"
+}
+
+// Italic renders s in italics
+func Italic(s string) string {
+	return "<i>" + Escape(s) + "</i>"
+}
+
+// Code renders s as inline monospace text
+func Code(s string) string {
+	return "<code>" + Escape(s) + "</code>"
+}
+
+// Pre renders s as a preformatted block, highlighted as language when it is not empty
+func Pre(s, language string) string {
+	if language == "" {
+		return "<pre>" + Escape(s) + "</pre>"
+	}
+	return `<pre><code class="language-` + Escape(strings.ReplaceAll(language, `"`, "")) + `">` + Escape(s) + "</code></pre>"
+}
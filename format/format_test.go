@@ -0,0 +1,47 @@
+package format
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "Trip planning", "Trip planning"},
+		{"tags", "<b>not bold</b>", "&lt;b&gt;not bold&lt;/b&gt;"},
+		{"ampersand first", "a &lt; b", "a &amp;lt; b"},
+		{"markdown is left alone", "*_[x](y)_*`", "*_[x](y)_*`"},
+		{"quotes", `say "hi" 'there'`, `say "hi" 'there'`},
+		{"unicode", "会话 <一> 🎉", "会话 &lt;一&gt; 🎉"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Escape(tt.input); got != tt.expected {
+				t.Errorf("Escape(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWrappers(t *testing.T) {
+	title := "</b><a href=\"x\">click</a>"
+
+	if got, want := Bold(title), "<b>&lt;/b&gt;&lt;a href=\"x\"&gt;click&lt;/a&gt;</b>"; got != want {
+		t.Errorf("Bold = %q, want %q", got, want)
+	}
+	if got, want := Italic("a & b"), "<i>a &amp; b</i>"; got != want {
+		t.Errorf("Italic = %q, want %q", got, want)
+	}
+	if got, want := Code("x<y"), "<code>x&lt;y</code>"; got != want {
+		t.Errorf("Code = %q, want %q", got, want)
+	}
+	if got, want := Pre("if a < b {}", ""), "<pre>if a &lt; b {}</pre>"; got != want {
+		t.Errorf("Pre = %q, want %q", got, want)
+	}
+	if got, want := Pre("x := 1", `go"><b`), `<pre><code class="language-go&gt;&lt;b">x := 1</code></pre>`; got != want {
+		t.Errorf("Pre with language = %q, want %q", got, want)
+	}
+}
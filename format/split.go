@@ -0,0 +1,189 @@
+package format
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// MaxMessageLength is the longest text Telegram accepts in one message, in UTF-16 code units
+const MaxMessageLength = 4096
+
+// markdownBlock is a paragraph or a fenced code block of a Markdown text
+type markdownBlock struct {
+	text  string
+	fence string // opening fence line of a code block, e.g. "```go"; empty for paragraphs
+}
+
+// SplitMarkdown breaks text into parts of at most limit UTF-16 code units,
+// as Telegram counts them. Parts end between paragraphs and code blocks where
+// possible, then between lines and words. A code block too long for one part
+// is closed at the end of each part and reopened, with its language, in the
+// next, so every part is valid Markdown on its own.
+func SplitMarkdown(text string, limit int) []string {
+	if Length(text) <= limit {
+		return []string{text}
+	}
+
+	var parts []string
+	var current string
+	flush := func() {
+		if current = strings.Trim(current, "\n"); current != "" {
+			parts = append(parts, current)
+		}
+		current = ""
+	}
+
+	for _, block := range markdownBlocks(text) {
+		if current != "" && Length(current)+2+Length(block.text) <= limit {
+			current += "\n\n" + block.text
+			continue
+		}
+		flush()
+		if Length(block.text) <= limit {
+			current = block.text
+			continue
+		}
+
+		var pieces []string
+		if block.fence != "" {
+			pieces = splitCodeBlock(block, limit)
+		} else {
+			pieces = splitText(block.text, limit, "\n", " ")
+		}
+		// The last piece may share its part with the blocks that follow
+		for _, piece := range pieces[:len(pieces)-1] {
+			current = piece
+			flush()
+		}
+		current = pieces[len(pieces)-1]
+	}
+	flush()
+	return parts
+}
+
+// Length returns the length of s in UTF-16 code units, the unit of Telegram's limits
+func Length(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// markdownBlocks splits text into paragraphs, separated by blank lines, and
+// fenced code blocks, kept whole including blank lines inside them
+func markdownBlocks(text string) []markdownBlock {
+	var blocks []markdownBlock
+	var lines []string
+	fence := ""
+	end := func() {
+		if len(lines) > 0 {
+			blocks = append(blocks, markdownBlock{text: strings.Join(lines, "\n"), fence: fence})
+		}
+		lines = nil
+		fence = ""
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			lines = append(lines, line)
+			if marker := fenceMarker(trimmed); marker != "" && trimmed == marker && strings.HasPrefix(marker, fenceMarker(fence)) {
+				end()
+			}
+		case fenceMarker(trimmed) != "":
+			end()
+			fence = trimmed
+			lines = append(lines, line)
+		case trimmed == "":
+			end()
+		default:
+			lines = append(lines, line)
+		}
+	}
+	end()
+	return blocks
+}
+
+// fenceMarker returns the run of backticks or tildes opening a code fence
+// line, or "" when line is not a fence
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		marker := line[:len(line)-len(strings.TrimLeft(line, c))]
+		if len(marker) >= 3 {
+			return marker
+		}
+	}
+	return ""
+}
+
+// splitCodeBlock breaks a fenced code block between lines, wrapping each piece
+// in the block's fences
+func splitCodeBlock(block markdownBlock, limit int) []string {
+	lines := strings.Split(block.text, "\n")
+	closing := fenceMarker(block.fence)
+	code := lines[1:]
+	if len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == closing {
+		code = code[:len(code)-1]
+	}
+
+	// Leave room for the fence lines around each piece
+	budget := limit - Length(block.fence) - Length(closing) - 2
+	if budget < 1 {
+		return splitText(block.text, limit, "\n", " ")
+	}
+	pieces := splitText(strings.Join(code, "\n"), budget, "\n")
+	for i, piece := range pieces {
+		pieces[i] = block.fence + "\n" + piece + "\n" + closing
+	}
+	return pieces
+}
+
+// splitText breaks text into pieces of at most limit units, preferring the
+// first separator, then the next ones, and cutting between characters as a
+// last resort. Separators at a cut are dropped.
+func splitText(text string, limit int, separators ...string) []string {
+	if Length(text) <= limit {
+		return []string{text}
+	}
+	if len(separators) == 0 {
+		return splitRunes(text, limit)
+	}
+
+	sep := separators[0]
+	var pieces []string
+	current := ""
+	for i, field := range strings.Split(text, sep) {
+		if i > 0 && Length(current)+Length(sep)+Length(field) <= limit {
+			current += sep + field
+			continue
+		}
+		if i > 0 {
+			pieces = append(pieces, current)
+		}
+		if Length(field) <= limit {
+			current = field
+			continue
+		}
+		smaller := splitText(field, limit, separators[1:]...)
+		pieces = append(pieces, smaller[:len(smaller)-1]...)
+		current = smaller[len(smaller)-1]
+	}
+	return append(pieces, current)
+}
+
+// splitRunes cuts text into pieces of at most limit units between characters
+func splitRunes(text string, limit int) []string {
+	var pieces []string
+	start, length := 0, 0
+	for i, r := range text {
+		if n := utf16.RuneLen(r); length+n > limit {
+			pieces = append(pieces, text[start:i])
+			start, length = i, n
+		} else {
+			length += n
+		}
+	}
+	return append(pieces, text[start:])
+}
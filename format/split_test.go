@@ -0,0 +1,80 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitMarkdownShortText(t *testing.T) {
+	if parts := SplitMarkdown("hello", 10); len(parts) != 1 || parts[0] != "hello" {
+		t.Errorf("expected the text unchanged, got %q", parts)
+	}
+}
+
+func TestSplitMarkdownParagraphs(t *testing.T) {
+	text := "First paragraph.\n\nSecond paragraph.\n\nThird one."
+	parts := SplitMarkdown(text, 40)
+	want := []string{"First paragraph.\n\nSecond paragraph.", "Third one."}
+	if strings.Join(parts, "|") != strings.Join(want, "|") {
+		t.Errorf("SplitMarkdown = %q, want %q", parts, want)
+	}
+}
+
+func TestSplitMarkdownKeepsCodeBlocksWhole(t *testing.T) {
+	code := "```go\nfunc main() {\n\n\tprintln(\"hi\")\n}\n```"
+	text := "Here is the code:\n\n" + code + "\n\nDone."
+	parts := SplitMarkdown(text, 50)
+	if len(parts) != 2 || parts[1] != code+"\n\nDone." {
+		t.Errorf("expected the code block to start the second part, got %q", parts)
+	}
+}
+
+func TestSplitMarkdownReopensLongCodeBlocks(t *testing.T) {
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, "fmt.Println(\"line\")")
+	}
+	text := "```go\n" + strings.Join(lines, "\n") + "\n```"
+
+	parts := SplitMarkdown(text, 200)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	total := 0
+	for _, part := range parts {
+		if Length(part) > 200 {
+			t.Errorf("part longer than the limit: %d", Length(part))
+		}
+		if !strings.HasPrefix(part, "```go\n") || !strings.HasSuffix(part, "\n```") {
+			t.Errorf("expected each part to be a complete code block, got %q", part)
+		}
+		total += strings.Count(part, "Println")
+	}
+	if total != 30 {
+		t.Errorf("expected all 30 lines, got %d", total)
+	}
+}
+
+func TestSplitMarkdownLongParagraph(t *testing.T) {
+	text := strings.Repeat("word ", 100) + strings.Repeat("x", 120)
+	parts := SplitMarkdown(text, 50)
+	for _, part := range parts {
+		if Length(part) > 50 || part == "" {
+			t.Errorf("unexpected part %q", part)
+		}
+	}
+	if joined := strings.Join(parts, ""); strings.Count(joined, "word") != 100 || strings.Count(joined, "x") != 120 {
+		t.Errorf("expected no text to be lost, got %q", parts)
+	}
+}
+
+func TestLengthCountsUTF16(t *testing.T) {
+	if n := Length("a会🎉"); n != 4 {
+		t.Errorf("Length = %d, want 4", n)
+	}
+	for _, part := range SplitMarkdown(strings.Repeat("🎉", 10), 5) {
+		if Length(part) > 5 {
+			t.Errorf("part %q is longer than the limit", part)
+		}
+	}
+}
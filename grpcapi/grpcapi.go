@@ -0,0 +1,281 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"tg-bot-demo/api/sessionpb"
+	"tg-bot-demo/session"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Package grpcapi serves the session Manager over gRPC (see api/sessionpb) so
+// other backend services can read and manipulate the same sessions as the bot.
+// The server only accepts clients presenting a certificate from the configured
+// client CA; callers name the Telegram user they act for in every request.
+
+const (
+	defaultSessionPageSize = 20
+	maxSessionPageSize     = 100
+	defaultMessageLimit    = 50
+	maxMessageLimit        = 500
+)
+
+// Server implements sessionpb.SessionServiceServer
+type Server struct {
+	sessionpb.UnimplementedSessionServiceServer
+
+	sessions *session.Manager
+	messages *session.MessageManager
+}
+
+// New creates a gRPC session service backed by the bot's managers
+func New(sessions *session.Manager, messages *session.MessageManager) *Server {
+	return &Server{sessions: sessions, messages: messages}
+}
+
+// NewGRPCServer returns a gRPC server with the session service registered.
+// Options such as grpc.Creds from ServerTLS are passed through.
+func NewGRPCServer(service *Server, options ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(append(options, grpc.ChainUnaryInterceptor(logCalls))...)
+	sessionpb.RegisterSessionServiceServer(server, service)
+	return server
+}
+
+// ServerTLS returns transport credentials that present the server certificate
+// and require clients to present one signed by the CA in clientCAFile
+func ServerTLS(certFile, keyFile, clientCAFile string) (credentials.TransportCredentials, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load gRPC certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read gRPC client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("read gRPC client CA: no certificates in %s", clientCAFile)
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// logCalls logs every call with the client certificate's subject, its duration and outcome
+func logCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	log.Printf("grpc: method=%s client=%s code=%s duration=%s",
+		info.FullMethod, clientName(ctx), status.Code(err), time.Since(start).Round(time.Microsecond))
+	return resp, err
+}
+
+// ListSessions returns a page of the user's sessions, optionally filtered by a query
+func (s *Server) ListSessions(ctx context.Context, req *sessionpb.ListSessionsRequest) (*sessionpb.ListSessionsResponse, error) {
+	if err := requireUser(req.GetUserId()); err != nil {
+		return nil, err
+	}
+	offset := max(int(req.GetOffset()), 0)
+	limit := pageSize(req.GetLimit(), defaultSessionPageSize, maxSessionPageSize)
+
+	sessions, hasMore, err := s.sessions.SearchSessions(ctx, req.GetUserId(), req.GetQuery(), offset, limit)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &sessionpb.ListSessionsResponse{HasMore: hasMore}
+	for _, sess := range sessions {
+		resp.Sessions = append(resp.Sessions, toSession(sess))
+	}
+	return resp, nil
+}
+
+// GetSession returns one session of the user
+func (s *Server) GetSession(ctx context.Context, req *sessionpb.GetSessionRequest) (*sessionpb.Session, error) {
+	if err := requireUser(req.GetUserId()); err != nil {
+		return nil, err
+	}
+	sessionID, err := parseSessionID(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.sessions.GetSession(ctx, req.GetUserId(), sessionID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSession(sess), nil
+}
+
+// GetActiveSession returns the user's active session
+func (s *Server) GetActiveSession(ctx context.Context, req *sessionpb.GetActiveSessionRequest) (*sessionpb.Session, error) {
+	if err := requireUser(req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	sess, err := s.sessions.GetActiveSession(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSession(sess), nil
+}
+
+// SwitchSession makes a session of the user the active one
+func (s *Server) SwitchSession(ctx context.Context, req *sessionpb.SwitchSessionRequest) (*sessionpb.Session, error) {
+	if err := requireUser(req.GetUserId()); err != nil {
+		return nil, err
+	}
+	sessionID, err := parseSessionID(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.sessions.SwitchSession(ctx, req.GetUserId(), sessionID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSession(sess), nil
+}
+
+// CloseSession clears the user's active session
+func (s *Server) CloseSession(ctx context.Context, req *sessionpb.CloseSessionRequest) (*sessionpb.CloseSessionResponse, error) {
+	if err := requireUser(req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	sess, closed, err := s.sessions.CloseActiveSession(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &sessionpb.CloseSessionResponse{Closed: closed}
+	if sess != nil {
+		resp.Session = toSession(sess)
+	}
+	return resp, nil
+}
+
+// ListMessages returns the latest messages of a session of the user
+func (s *Server) ListMessages(ctx context.Context, req *sessionpb.ListMessagesRequest) (*sessionpb.ListMessagesResponse, error) {
+	if err := requireUser(req.GetUserId()); err != nil {
+		return nil, err
+	}
+	sessionID, err := parseSessionID(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Check ownership before reading the history
+	if _, err := s.sessions.GetSession(ctx, req.GetUserId(), sessionID); err != nil {
+		return nil, toStatus(err)
+	}
+	messages, err := s.messages.History(ctx, sessionID, pageSize(req.GetLimit(), defaultMessageLimit, maxMessageLimit))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &sessionpb.ListMessagesResponse{}
+	for _, message := range messages {
+		resp.Messages = append(resp.Messages, toMessage(message))
+	}
+	return resp, nil
+}
+
+// requireUser rejects requests without a user
+func requireUser(userID int64) error {
+	if userID == 0 {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	return nil
+}
+
+// parseSessionID parses a session ID from a request
+func parseSessionID(raw string) (uuid.UUID, error) {
+	sessionID, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid session_id %q", raw)
+	}
+	return sessionID, nil
+}
+
+// pageSize applies the default and cap to a requested page size
+func pageSize(requested int32, defaultSize, maxSize int) int {
+	if requested <= 0 {
+		return defaultSize
+	}
+	return min(int(requested), maxSize)
+}
+
+// toStatus maps session errors to gRPC status codes. Sessions of other users
+// are reported as not found so callers cannot probe for session IDs.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, session.ErrSessionNotFound), errors.Is(err, session.ErrUnauthorized):
+		return status.Error(codes.NotFound, "session not found")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		log.Printf("grpc: request failed: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// toSession converts a session to its protobuf form
+func toSession(sess *session.Session) *sessionpb.Session {
+	return &sessionpb.Session{
+		Id:          sess.ID.String(),
+		UserId:      sess.UserID,
+		Title:       sess.Title,
+		CreatedAt:   timestamppb.New(sess.CreatedAt),
+		UpdatedAt:   timestamppb.New(sess.UpdatedAt),
+		LastMessage: sess.LastMessage,
+	}
+}
+
+// toMessage converts a message to its protobuf form
+func toMessage(message *session.Message) *sessionpb.Message {
+	converted := &sessionpb.Message{
+		Id:        message.ID.String(),
+		SessionId: message.SessionID.String(),
+		UserId:    message.UserID,
+		Role:      message.Role,
+		Content:   message.Content,
+		CreatedAt: timestamppb.New(message.CreatedAt),
+	}
+	if message.EditedAt != nil {
+		converted.EditedAt = timestamppb.New(*message.EditedAt)
+	}
+	return converted
+}
+
+// clientName returns the common name of the client certificate, or "-" without one
+func clientName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "-"
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return "-"
+	}
+	return tlsInfo.State.PeerCertificates[0].Subject.CommonName
+}
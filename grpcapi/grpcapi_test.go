@@ -0,0 +1,255 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tg-bot-demo/api/sessionpb"
+	"tg-bot-demo/session"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// testCA issues certificates for the tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate and key in PEM for name
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// testEnv is a running mutual TLS server backed by a SQLite store
+type testEnv struct {
+	addr       string
+	ca         *testCA
+	store      *session.SQLiteStore
+	sessionMgr *session.Manager
+	messageMgr *session.MessageManager
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := session.NewSQLiteStore(filepath.Join(dir, "grpc.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	ca := newTestCA(t, "test CA")
+	certPEM, keyPEM := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		return path
+	}
+	creds, err := ServerTLS(write("server.pem", certPEM), write("server-key.pem", keyPEM), write("ca.pem", ca.pem))
+	if err != nil {
+		t.Fatalf("ServerTLS failed: %v", err)
+	}
+
+	env := &testEnv{ca: ca, store: store, sessionMgr: session.NewManager(store), messageMgr: session.NewMessageManager(store)}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	server := NewGRPCServer(New(env.sessionMgr, env.messageMgr), grpc.Creds(creds))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	env.addr = listener.Addr().String()
+	return env
+}
+
+// dial connects with a client certificate issued by ca
+func (env *testEnv) dial(t *testing.T, ca *testCA) sessionpb.SessionServiceClient {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, "reporting-service", x509.ExtKeyUsageClientAuth)
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair failed: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(env.ca.cert)
+	conn, err := grpc.NewClient(env.addr, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      roots,
+		ServerName:   "localhost",
+	})))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return sessionpb.NewSessionServiceClient(conn)
+}
+
+func TestSessionService(t *testing.T) {
+	env := newTestEnv(t)
+	client := env.dial(t, env.ca)
+	ctx := context.Background()
+
+	first, err := env.sessionMgr.CreateSession(ctx, 1, "Plan the trip")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	second, err := env.sessionMgr.CreateSession(ctx, 1, "Groceries")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := env.messageMgr.AddMessage(ctx, session.NewMessage(first.ID, 1, session.RoleUser, "Plan the trip")); err != nil {
+		t.Fatalf("AddMessage failed: %v", err)
+	}
+	other, err := env.sessionMgr.CreateSession(ctx, 2, "Someone else's")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	list, err := client.ListSessions(ctx, &sessionpb.ListSessionsRequest{UserId: 1, Limit: 1})
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(list.Sessions) != 1 || !list.HasMore {
+		t.Errorf("expected one session and more to follow, got %d has_more=%t", len(list.Sessions), list.HasMore)
+	}
+
+	active, err := client.GetActiveSession(ctx, &sessionpb.GetActiveSessionRequest{UserId: 1})
+	if err != nil || active.Id != second.ID.String() {
+		t.Fatalf("expected the last created session to be active, got %v err=%v", active, err)
+	}
+
+	switched, err := client.SwitchSession(ctx, &sessionpb.SwitchSessionRequest{UserId: 1, SessionId: first.ID.String()})
+	if err != nil || switched.Title != first.Title {
+		t.Fatalf("SwitchSession failed: %v err=%v", switched, err)
+	}
+	if active, _ := env.sessionMgr.GetActiveSession(ctx, 1); active.ID != first.ID {
+		t.Errorf("expected the switch to be visible to the bot, active is %s", active.ID)
+	}
+
+	messages, err := client.ListMessages(ctx, &sessionpb.ListMessagesRequest{UserId: 1, SessionId: first.ID.String()})
+	if err != nil || len(messages.Messages) != 1 || messages.Messages[0].Content != "Plan the trip" {
+		t.Fatalf("unexpected messages %v err=%v", messages, err)
+	}
+
+	closed, err := client.CloseSession(ctx, &sessionpb.CloseSessionRequest{UserId: 1})
+	if err != nil || !closed.Closed || closed.Session.GetId() != first.ID.String() {
+		t.Fatalf("unexpected close result %v err=%v", closed, err)
+	}
+	closed, err = client.CloseSession(ctx, &sessionpb.CloseSessionRequest{UserId: 1})
+	if err != nil || closed.Closed {
+		t.Errorf("expected nothing to close, got %v err=%v", closed, err)
+	}
+
+	// Sessions of other users look like missing ones
+	_, err = client.GetSession(ctx, &sessionpb.GetSessionRequest{UserId: 1, SessionId: other.ID.String()})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for another user's session, got %v", err)
+	}
+	_, err = client.ListMessages(ctx, &sessionpb.ListMessagesRequest{UserId: 1, SessionId: other.ID.String()})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for another user's messages, got %v", err)
+	}
+	_, err = client.GetActiveSession(ctx, &sessionpb.GetActiveSessionRequest{UserId: 1})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound without an active session, got %v", err)
+	}
+
+	_, err = client.GetSession(ctx, &sessionpb.GetSessionRequest{UserId: 1, SessionId: "not-a-uuid"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a bad session ID, got %v", err)
+	}
+	_, err = client.ListSessions(ctx, &sessionpb.ListSessionsRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without a user, got %v", err)
+	}
+}
+
+func TestSessionService_RejectsUntrustedClients(t *testing.T) {
+	env := newTestEnv(t)
+	client := env.dial(t, newTestCA(t, "other CA"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := client.ListSessions(ctx, &sessionpb.ListSessionsRequest{UserId: 1})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected the handshake to fail for an untrusted client, got %v", err)
+	}
+}
+
+func TestServerTLS_InvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "test CA")
+	certPEM, keyPEM := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	os.WriteFile(filepath.Join(dir, "server.pem"), certPEM, 0o600)
+	os.WriteFile(filepath.Join(dir, "server-key.pem"), keyPEM, 0o600)
+	os.WriteFile(filepath.Join(dir, "empty.pem"), nil, 0o600)
+
+	if _, err := ServerTLS(filepath.Join(dir, "missing.pem"), filepath.Join(dir, "server-key.pem"), filepath.Join(dir, "server.pem")); err == nil {
+		t.Error("expected an error for a missing certificate")
+	}
+	if _, err := ServerTLS(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"), filepath.Join(dir, "empty.pem")); err == nil {
+		t.Error("expected an error for a CA file without certificates")
+	}
+}
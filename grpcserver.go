@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"tg-bot-demo/config"
+	"tg-bot-demo/grpcapi"
+
+	"google.golang.org/grpc"
+)
+
+// serveGRPC runs the gRPC session API on cfg.GRPCListenAddr until it fails
+func serveGRPC(service *grpcapi.Server, cfg *config.Config) error {
+	creds, err := grpcapi.ServerTLS(cfg.GRPCTLSCertFile, cfg.GRPCTLSKeyFile, cfg.GRPCClientCAFile)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", cfg.GRPCListenAddr)
+	if err != nil {
+		return fmt.Errorf("listen for gRPC: %w", err)
+	}
+
+	log.Printf("grpc server started: listen=%s", cfg.GRPCListenAddr)
+	return grpcapi.NewGRPCServer(service, grpc.Creds(creds)).Serve(listener)
+}
@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"tg-bot-demo/backup"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/maintenance"
+	"tg-bot-demo/retention"
+	"tg-bot-demo/session"
+	"tg-bot-demo/settings"
+	"tg-bot-demo/storage"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// AdminReply is the reply of an /admin subcommand
+type AdminReply struct {
+	Text     string
+	Keyboard *models.InlineKeyboardMarkup // optional
+}
+
+// AdminCommand runs an /admin subcommand
+type AdminCommand interface {
+	Run(ctx context.Context, userID int64, args []string) (*AdminReply, error)
+}
+
+// AdminCommandFunc runs an /admin subcommand and returns the reply text
+type AdminCommandFunc func(ctx context.Context, userID int64, args []string) (string, error)
+
+// Run calls f and wraps its text in a reply
+func (f AdminCommandFunc) Run(ctx context.Context, userID int64, args []string) (*AdminReply, error) {
+	text, err := f(ctx, userID, args)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminReply{Text: text}, nil
+}
+
+// AdminReplyFunc runs an /admin subcommand whose reply may carry a keyboard
+type AdminReplyFunc func(ctx context.Context, userID int64, args []string) (*AdminReply, error)
+
+// Run calls f
+func (f AdminReplyFunc) Run(ctx context.Context, userID int64, args []string) (*AdminReply, error) {
+	return f(ctx, userID, args)
+}
+
+// confirmedAdminCommand is an /admin subcommand that stores changes
+type confirmedAdminCommand struct {
+	AdminCommand
+}
+
+// ConfirmedAdminCommand marks command as one that stores changes. It runs in a
+// single transaction with its audit entry and its reply, which is sent through
+// the outbox like other confirmations.
+func ConfirmedAdminCommand(command AdminCommand) AdminCommand {
+	return confirmedAdminCommand{AdminCommand: command}
+}
+
+// isAdmin reports whether userID is listed as a bot administrator
+func isAdmin(cfg *HandlerConfig, userID int64) bool {
+	return slices.Contains(cfg.AdminUserIDs, userID)
+}
+
+// AdminCommandHandler handles the /admin command.
+// It dispatches "/admin <subcommand> [args...]" to commands for configured administrators.
+func AdminCommandHandler(cfg *HandlerConfig, commands map[string]AdminCommand) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		chatID := update.Message.Chat.ID
+		tr := i18n.FromContext(ctx)
+
+		args := commandArgs(ctx, update.Message)
+		var name string
+		if len(args) > 0 {
+			name = strings.ToLower(args[0])
+		}
+		if err := cfg.permissionError(ctx, userID, "/admin", name); err != nil {
+			LogWarning(ctx, "admin_command", userID, "user without the required role attempted admin command", map[string]interface{}{
+				"text": update.Message.Text,
+			})
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		if len(args) == 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            adminUsage(tr, commands),
+			})
+			return
+		}
+
+		command, ok := commands[name]
+		if !ok {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.Sprintf("Unknown admin command: %s\n\n%s", name, adminUsage(tr, cfg.permittedAdminCommands(ctx, userID, commands))),
+			})
+			return
+		}
+
+		LogInfo(ctx, "admin_command", userID, "running admin command", map[string]interface{}{
+			"command": name,
+			"args":    args[1:],
+		})
+
+		run := func(ctx context.Context) (*bot.SendMessageParams, error) {
+			reply, err := command.Run(ctx, userID, args[1:])
+			if err != nil {
+				return nil, err
+			}
+			cfg.recordAudit(ctx, userID, session.AuditAdminPrefix+name, strings.Join(args[1:], " "), update.Message.Text)
+
+			params := &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            reply.Text,
+			}
+			if reply.Keyboard != nil {
+				params.ReplyMarkup = cfg.callbacks().EncodeKeyboard(ctx, reply.Keyboard)
+			}
+			return params, nil
+		}
+
+		var err error
+		if _, ok := command.(confirmedAdminCommand); ok {
+			err = cfg.confirmWrite(ctx, b, userID, run)
+		} else {
+			var params *bot.SendMessageParams
+			if params, err = run(ctx); err == nil {
+				b.SendMessage(ctx, params)
+			}
+		}
+		if err != nil {
+			LogError(ctx, "admin_command", userID, err, map[string]interface{}{
+				"command": name,
+			})
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.Sprintf("❌ /admin %s failed: %v", name, err),
+			})
+		}
+	}
+}
+
+// permittedAdminCommands returns the commands userID's role allows them to run
+func (cfg *HandlerConfig) permittedAdminCommands(ctx context.Context, userID int64, commands map[string]AdminCommand) map[string]AdminCommand {
+	permitted := make(map[string]AdminCommand, len(commands))
+	for name, command := range commands {
+		if cfg.permissionError(ctx, userID, "/admin", name) == nil {
+			permitted[name] = command
+		}
+	}
+	return permitted
+}
+
+// adminUsage lists the available admin subcommands
+func adminUsage(tr *i18n.Translator, commands map[string]AdminCommand) string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, "/admin "+name)
+	}
+	sort.Strings(names)
+	return tr.T("Available admin commands:\n") + strings.Join(names, "\n")
+}
+
+// AdminCleanupCommand runs the storage retention cleanup on demand, unless
+// maintenance mode pauses it
+func AdminCleanupCommand(cleaner *retention.Cleaner) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		report, err := cleaner.Run(ctx)
+		if errors.Is(err, retention.ErrPaused) {
+			return tr.T("🚧 Cleanup is paused while maintenance mode is on."), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		return tr.Sprintf("🧹 Cleanup finished\nFiles deleted: %d\nReclaimed: %s\nStored: %s\nFailures: %d",
+			report.FilesDeleted, formatBytes(report.BytesReclaimed), formatBytes(report.BytesStored), report.Failures), nil
+	}
+}
+
+// AdminBackupCommand writes a database snapshot with "/admin backup now"
+func AdminBackupCommand(job *backup.Job) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		if len(args) != 1 || args[0] != "now" {
+			return tr.T("Usage: /admin backup now"), nil
+		}
+
+		snapshot, err := job.Run(ctx)
+		if err != nil {
+			return "", err
+		}
+		return tr.Sprintf("💾 Backup saved to %s (%s)", snapshot.Location, formatBytes(snapshot.Size)), nil
+	}
+}
+
+// AdminMaintenanceCommand checkpoints, analyzes and optionally vacuums the database now.
+// "/admin maintenance on" makes the store read-only and answers users with a
+// maintenance notice until "/admin maintenance off".
+func AdminMaintenanceCommand(job *maintenance.Job, store session.ReadOnlyStore) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		if len(args) > 0 {
+			switch {
+			case len(args) == 1 && strings.EqualFold(args[0], "on"):
+				store.SetReadOnly(true)
+			case len(args) == 1 && strings.EqualFold(args[0], "off"):
+				store.SetReadOnly(false)
+			default:
+				return tr.T("Usage: /admin maintenance [on|off]"), nil
+			}
+
+			LogInfo(ctx, "admin_maintenance", userID, "maintenance mode changed", map[string]interface{}{
+				"read_only": store.ReadOnly(),
+			})
+			if store.ReadOnly() {
+				return tr.T("🚧 Maintenance mode on: users get a maintenance notice and nothing is saved. Turn it off with /admin maintenance off."), nil
+			}
+			return tr.T("✅ Maintenance mode off: the bot answers users again."), nil
+		}
+
+		report, err := job.Run(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		reply := tr.Sprintf("🛠 Maintenance finished\nDatabase size: %s (was %s)\nLog frames checkpointed: %d",
+			formatBytes(report.BytesAfter), formatBytes(report.BytesBefore), report.CheckpointedFrames)
+		if report.CheckpointBusy {
+			reply += "\n" + tr.T("The write-ahead log is still in use and was not truncated.")
+		}
+		return reply, nil
+	}
+}
+
+// AdminIntegrityCommand reports orphaned session bindings, invalid sessions and
+// dangling files with "/admin integrity" and fixes them with "/admin integrity repair"
+func AdminIntegrityCommand(store session.IntegrityStore) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		repair := len(args) == 1 && strings.EqualFold(args[0], "repair")
+		if len(args) > 0 && !repair {
+			return tr.T("Usage: /admin integrity [repair]"), nil
+		}
+
+		report, err := store.CheckIntegrity(ctx, repair)
+		if err != nil {
+			return "", err
+		}
+
+		LogInfo(ctx, "admin_integrity", userID, "database integrity checked", map[string]interface{}{
+			"orphaned_active_sessions": report.OrphanedActiveSessions,
+			"invalid_session_ids":      report.InvalidSessionIDs,
+			"dangling_files":           report.DanglingFiles,
+			"repaired":                 report.Repaired,
+		})
+		if report.Problems() == 0 {
+			return tr.T("✅ No integrity problems found"), nil
+		}
+		reply := tr.Sprintf("🩺 Integrity check\nOrphaned session bindings: %d\nSessions with invalid IDs: %d\nFiles attached to missing sessions: %d",
+			report.OrphanedActiveSessions, report.InvalidSessionIDs, report.DanglingFiles)
+		if report.Repaired {
+			return reply + "\n" + tr.T("All of them were repaired."), nil
+		}
+		return reply + "\n" + tr.T("Run /admin integrity repair to fix them."), nil
+	}
+}
+
+// AdminPurgeCommand deletes everything stored about a user with "/admin purge <user_id>"
+func AdminPurgeCommand(store session.PurgeStore, fileStorage storage.Backend) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		if len(args) != 1 {
+			return tr.T("Usage: /admin purge <user_id>"), nil
+		}
+		target, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return tr.T("Usage: /admin purge <user_id>"), nil
+		}
+
+		report, failed, err := purgeUserData(ctx, store, fileStorage, target)
+		if err != nil {
+			return "", err
+		}
+
+		LogInfo(ctx, "admin_purge", userID, "user data purged", map[string]interface{}{
+			"target_user_id": target,
+			"sessions":       report.Sessions,
+			"messages":       report.Messages,
+			"files":          report.Files,
+			"other":          report.Other,
+		})
+		return tr.Sprintf("🗑 Purged user %d", target) + "\n" + formatPurgeReport(tr, report, failed), nil
+	}
+}
+
+// AdminTransferCommand gives a session to another user with
+// "/admin transfer <session_id> <user_id>", e.g. when a user moved to another
+// Telegram account. Every transfer is logged with the administrator who made it.
+func AdminTransferCommand(sessionMgr *session.Manager) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		usage := tr.T("Usage: /admin transfer <session_id> <user_id>")
+		if len(args) != 2 {
+			return usage, nil
+		}
+		sessionID, err := uuid.Parse(args[0])
+		if err != nil {
+			return usage, nil
+		}
+		target, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || target <= 0 {
+			return usage, nil
+		}
+
+		report, err := sessionMgr.TransferSession(ctx, sessionID, target)
+		switch {
+		case errors.Is(err, session.ErrSessionNotFound):
+			return tr.Sprintf("❌ Session %s not found", sessionID), nil
+		case errors.Is(err, session.ErrSameOwner):
+			return tr.Sprintf("Session %s already belongs to user %d", sessionID, target), nil
+		case err != nil:
+			return "", err
+		}
+
+		LogInfo(ctx, "admin_transfer", userID, "session transferred", map[string]interface{}{
+			"session_id":   sessionID.String(),
+			"from_user_id": report.FromUserID,
+			"to_user_id":   target,
+			"messages":     report.Messages,
+			"files":        report.Files,
+			"shares":       report.Shares,
+		})
+		text := tr.Sprintf("🔀 Session \"%s\" moved from user %d to user %d with %d messages and %d files",
+			report.Session.Title, report.FromUserID, target, report.Messages, report.Files)
+		if report.Shares > 0 {
+			text += "\n" + tr.Sprintf("%d share links of the previous owner were revoked", report.Shares)
+		}
+		return text, nil
+	}
+}
+
+// AdminReferralsCommand reports how many users started the bot with a "ref-<code>" deep link
+func AdminReferralsCommand(referrals session.ReferralStore) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		if len(args) != 1 {
+			return tr.T("Usage: /admin referrals <code>"), nil
+		}
+
+		count, err := referrals.CountReferrals(ctx, args[0])
+		if err != nil {
+			return "", err
+		}
+
+		return tr.Sprintf("Referral code %s: %d users", args[0], count), nil
+	}
+}
+
+// AdminChannelsCommand lists the channels whose posts are archived
+func AdminChannelsCommand(channels session.ChannelStore) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tracked, err := channels.ListChannels(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		tr := i18n.FromContext(ctx)
+		if len(tracked) == 0 {
+			return tr.T("No channels tracked yet. Enable archive_channel_posts and add the bot to a channel as an administrator."), nil
+		}
+
+		lines := []string{tr.T("📣 Tracked channels:")}
+		for _, channel := range tracked {
+			name := channel.Title
+			if channel.Username != "" {
+				name += " (@" + channel.Username + ")"
+			}
+			lines = append(lines, tr.Sprintf("%s · %d · %d posts, last %s", name, channel.ChatID, channel.Posts,
+				tr.FormatTime(channel.LastPostAt, "Jan 2, 2006 15:04")))
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+}
+
+// AdminSetCommand lists and changes runtime settings.
+// "/admin set" lists every setting, "/admin set <key>" shows one, "/admin set <key> <value>"
+// overrides it and "/admin set <key> default" goes back to the configured value.
+func AdminSetCommand(runtime *settings.Settings) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+
+		if len(args) == 0 {
+			lines := []string{tr.T("Runtime settings:")}
+			for _, entry := range runtime.List(ctx) {
+				lines = append(lines, formatSettingEntry(tr, entry))
+			}
+			lines = append(lines, "", tr.T("Usage: /admin set <key> <value|default>"))
+			return strings.Join(lines, "\n"), nil
+		}
+
+		key := strings.ToLower(args[0])
+		var err error
+		switch {
+		case len(args) == 1:
+			// Show the current value only
+		case len(args) == 2 && strings.EqualFold(args[1], "default"):
+			err = runtime.Reset(ctx, key)
+		default:
+			err = runtime.Set(ctx, key, strings.Join(args[1:], " "))
+		}
+		if errors.Is(err, settings.ErrUnknownSetting) || errors.Is(err, settings.ErrInvalidValue) {
+			return tr.Sprintf("❌ %v", err), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		entry, err := runtime.Get(ctx, key)
+		if err != nil {
+			return tr.Sprintf("❌ %v", err), nil
+		}
+		if entry.Description == "" {
+			return formatSettingEntry(tr, entry), nil
+		}
+		return formatSettingEntry(tr, entry) + "\n" + entry.Description, nil
+	}
+}
+
+// formatSettingEntry renders a setting as "key = value", noting the default when overridden
+func formatSettingEntry(tr *i18n.Translator, entry settings.Entry) string {
+	if entry.Overridden {
+		return tr.Sprintf("%s = %s (default %s)", entry.Key, entry.Value, entry.Default)
+	}
+	return entry.Key + " = " + entry.Value
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/outbox"
+	"tg-bot-demo/session"
+	"tg-bot-demo/settings"
+	"tg-bot-demo/testutil"
+)
+
+func TestIsAdmin(t *testing.T) {
+	cfg := &HandlerConfig{AdminUserIDs: []int64{1, 2}}
+	if !isAdmin(cfg, 2) {
+		t.Error("expected user 2 to be admin")
+	}
+	if isAdmin(cfg, 3) {
+		t.Error("expected user 3 not to be admin")
+	}
+	if isAdmin(&HandlerConfig{}, 1) {
+		t.Error("expected no admins when none configured")
+	}
+}
+
+func TestAdminUsage(t *testing.T) {
+	noop := AdminCommandFunc(func(ctx context.Context, userID int64, args []string) (string, error) { return "", nil })
+	usage := adminUsage(en, map[string]AdminCommand{"cleanup": noop, "backup": noop})
+
+	if !strings.Contains(usage, "/admin backup\n/admin cleanup") {
+		t.Errorf("expected sorted command list, got %q", usage)
+	}
+}
+
+func TestAdminSetCommand(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_settings.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	runtime := settings.New(store, time.Minute)
+	runtime.DefineInt(settings.SessionsPerPage, "Sessions and files per page", 6, 1)
+	set := AdminSetCommand(runtime)
+	cfg := &HandlerConfig{SessionsPerPage: 6, Settings: runtime}
+	ctx := context.Background()
+
+	reply, err := set(ctx, 1, []string{"sessions_per_page", "10"})
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if reply != "sessions_per_page = 10 (default 6)\nSessions and files per page" {
+		t.Errorf("unexpected reply %q", reply)
+	}
+	if got := cfg.sessionsPerPage(ctx); got != 10 {
+		t.Errorf("expected handlers to see 10 sessions per page, got %d", got)
+	}
+
+	reply, _ = set(ctx, 1, []string{"sessions_per_page", "zero"})
+	if !strings.HasPrefix(reply, "❌ invalid value") {
+		t.Errorf("expected invalid value reply, got %q", reply)
+	}
+	reply, _ = set(ctx, 1, []string{"colour", "blue"})
+	if !strings.HasPrefix(reply, "❌ unknown setting") {
+		t.Errorf("expected unknown setting reply, got %q", reply)
+	}
+
+	reply, _ = set(ctx, 1, nil)
+	if !strings.Contains(reply, "sessions_per_page = 10 (default 6)") {
+		t.Errorf("expected overridden setting in list, got %q", reply)
+	}
+
+	if _, err := set(ctx, 1, []string{"sessions_per_page", "default"}); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if got := cfg.sessionsPerPage(ctx); got != 6 {
+		t.Errorf("expected configured value after reset, got %d", got)
+	}
+}
+
+func TestAdminChannelsCommand(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_channels.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	channels := AdminChannelsCommand(store)
+	ctx := context.Background()
+
+	reply, err := channels(ctx, 1, nil)
+	if err != nil {
+		t.Fatalf("channels failed: %v", err)
+	}
+	if !strings.Contains(reply, "No channels tracked") {
+		t.Errorf("expected an empty notice, got %q", reply)
+	}
+
+	posted := time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if err := store.TrackChannelPost(ctx, &session.Channel{ChatID: -100123, Title: "News", Username: "news"}, posted); err != nil {
+			t.Fatalf("TrackChannelPost failed: %v", err)
+		}
+	}
+
+	reply, err = channels(ctx, 1, nil)
+	if err != nil {
+		t.Fatalf("channels failed: %v", err)
+	}
+	if !strings.Contains(reply, "News (@news) · -100123 · 2 posts, last Mar 1, 2025 12:30") {
+		t.Errorf("expected the tracked channel, got %q", reply)
+	}
+}
+
+// fakeIntegrityStore reports problems until it is asked to repair them
+type fakeIntegrityStore struct {
+	repaired bool
+}
+
+func (s *fakeIntegrityStore) CheckIntegrity(ctx context.Context, repair bool) (*session.IntegrityReport, error) {
+	if s.repaired {
+		return &session.IntegrityReport{}, nil
+	}
+	s.repaired = repair
+	return &session.IntegrityReport{OrphanedActiveSessions: 2, DanglingFiles: 1, Repaired: repair}, nil
+}
+
+func TestAdminIntegrityCommand(t *testing.T) {
+	integrity := AdminIntegrityCommand(&fakeIntegrityStore{})
+	ctx := context.Background()
+
+	reply, err := integrity(ctx, 1, nil)
+	if err != nil {
+		t.Fatalf("integrity failed: %v", err)
+	}
+	if !strings.Contains(reply, "Orphaned session bindings: 2") || !strings.Contains(reply, "/admin integrity repair") {
+		t.Errorf("expected the problems and a repair hint, got %q", reply)
+	}
+
+	if reply, _ := integrity(ctx, 1, []string{"fix"}); !strings.Contains(reply, "Usage") {
+		t.Errorf("expected usage for an unknown argument, got %q", reply)
+	}
+
+	reply, err = integrity(ctx, 1, []string{"repair"})
+	if err != nil {
+		t.Fatalf("integrity repair failed: %v", err)
+	}
+	if !strings.Contains(reply, "repaired") {
+		t.Errorf("expected the repair confirmed, got %q", reply)
+	}
+
+	if reply, _ := integrity(ctx, 1, nil); !strings.Contains(reply, "No integrity problems") {
+		t.Errorf("expected no problems after the repair, got %q", reply)
+	}
+}
+
+func TestAdminTransferCommand(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_transfer.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	sessionMgr := session.NewManager(store)
+	transfer := AdminTransferCommand(sessionMgr)
+	ctx := context.Background()
+
+	sess, err := sessionMgr.CreateSession(ctx, 1, "Old account")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := store.AppendMessage(ctx, session.NewMessage(sess.ID, 1, session.RoleUser, "hello")); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+
+	for _, args := range [][]string{nil, {sess.ID.String()}, {"not-a-uuid", "2"}, {sess.ID.String(), "bob"}} {
+		if reply, _ := transfer(ctx, 9, args); !strings.Contains(reply, "Usage") {
+			t.Errorf("args %v: expected usage, got %q", args, reply)
+		}
+	}
+
+	reply, err := transfer(ctx, 9, []string{sess.ID.String(), "2"})
+	if err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+	if !strings.Contains(reply, `"Old account" moved from user 1 to user 2 with 1 messages`) {
+		t.Errorf("expected the transfer confirmed, got %q", reply)
+	}
+	if moved, err := store.Get(ctx, sess.ID); err != nil || moved.UserID != 2 {
+		t.Errorf("expected the session owned by user 2, got %+v err=%v", moved, err)
+	}
+
+	if reply, _ := transfer(ctx, 9, []string{sess.ID.String(), "2"}); !strings.Contains(reply, "already belongs") {
+		t.Errorf("expected a same owner notice, got %q", reply)
+	}
+	if reply, _ := transfer(ctx, 9, []string{"00000000-0000-0000-0000-000000000001", "2"}); !strings.Contains(reply, "not found") {
+		t.Errorf("expected a not found notice, got %q", reply)
+	}
+}
+
+func TestAdminCommandHandlerQueuesConfirmedReplies(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_admin_outbox.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	cfg := &HandlerConfig{AdminUserIDs: []int64{1}, Outbox: outbox.New(store)}
+	handler := AdminCommandHandler(cfg, map[string]AdminCommand{
+		"unban": ConfirmedAdminCommand(AdminUnbanCommand(store)),
+		"ban":   AdminBanCommand(store, store, nil, nil, cfg),
+	})
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	if err := store.BanUser(ctx, &session.Ban{UserID: 5, BannedBy: 1, BannedAt: time.Now()}); err != nil {
+		t.Fatalf("BanUser failed: %v", err)
+	}
+	api.Err = errors.New("connection reset")
+	handler(ctx, api, commandUpdate(1, "/admin unban 5"))
+	handler(ctx, api, commandUpdate(1, "/admin ban"))
+
+	queued, err := store.ListDueOutbox(ctx, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListDueOutbox failed: %v", err)
+	}
+	if len(queued) != 1 || !strings.Contains(queued[0].Text, "User 5 is no longer banned") {
+		t.Errorf("expected only the unban confirmation kept for a retry, got %+v", queued)
+	}
+}
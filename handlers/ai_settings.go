@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// SamplingLimits are the ranges users may choose AI sampling parameters from
+// with /settings ai. Zero limits let users set none, and saved values are ignored.
+type SamplingLimits struct {
+	MinTemperature float64
+	MaxTemperature float64
+	MinMaxTokens   int
+	MaxMaxTokens   int
+}
+
+// enabled reports whether users may set sampling parameters
+func (l SamplingLimits) enabled() bool {
+	return l.MaxMaxTokens > 0
+}
+
+// settingsAIArg is the /settings argument showing and changing the AI parameters
+const settingsAIArg = "ai"
+
+// aiSettingsDefaultArg goes back to the provider's default of a parameter
+const aiSettingsDefaultArg = "default"
+
+// aiSettingsUsage explains the /settings ai arguments
+const aiSettingsUsage = "Use /settings ai temperature <value> or /settings ai max_tokens <value> to change a parameter, " +
+	"with default instead of a value to use the provider's default."
+
+// aiSettingsCommand handles "/settings ai": without arguments it shows the
+// user's AI sampling parameters, "temperature <value|default>" and
+// "max_tokens <value|default>" change them within cfg.Sampling.
+func aiSettingsCommand(ctx context.Context, b TelegramAPI, update *models.Update, store session.UserSettingsStore,
+	cfg *HandlerConfig, args []string) {
+	userID := update.Message.From.ID
+	tr := i18n.FromContext(ctx)
+	reply := func(text string) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            text,
+		})
+	}
+	limits := cfg.Sampling
+
+	if cfg.Assistant == nil || !limits.enabled() {
+		reply(tr.T("The AI assistant is not configured, so there are no AI parameters to set."))
+		return
+	}
+
+	userSettings, err := store.GetUserSettings(ctx, userID)
+	if err != nil {
+		LogError(ctx, "settings_command", userID, err, nil)
+		SendErrorResponse(ctx, b, update.Message, err)
+		return
+	}
+
+	if len(args) == 0 {
+		reply(formatAISettings(tr, limits, userSettings) + "\n\n" + tr.T(aiSettingsUsage))
+		return
+	}
+	if len(args) != 2 {
+		reply(tr.T(aiSettingsUsage))
+		return
+	}
+
+	value := strings.ToLower(args[1])
+	switch strings.ToLower(args[0]) {
+	case "temperature":
+		if value == aiSettingsDefaultArg {
+			userSettings.Temperature = nil
+			break
+		}
+		temperature, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(temperature >= limits.MinTemperature && temperature <= limits.MaxTemperature) {
+			reply(tr.Sprintf("The temperature must be a number from %s to %s.",
+				formatTemperature(limits.MinTemperature), formatTemperature(limits.MaxTemperature)))
+			return
+		}
+		userSettings.Temperature = &temperature
+	case "max_tokens":
+		if value == aiSettingsDefaultArg {
+			userSettings.MaxTokens = 0
+			break
+		}
+		maxTokens, err := strconv.Atoi(value)
+		if err != nil || maxTokens < limits.MinMaxTokens || maxTokens > limits.MaxMaxTokens {
+			reply(tr.Sprintf("Max tokens must be a whole number from %d to %d.", limits.MinMaxTokens, limits.MaxMaxTokens))
+			return
+		}
+		userSettings.MaxTokens = maxTokens
+	default:
+		reply(tr.T(aiSettingsUsage))
+		return
+	}
+
+	userSettings.UpdatedAt = time.Now()
+	err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		if err := store.SaveUserSettings(ctx, userSettings); err != nil {
+			return nil, err
+		}
+		return &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            tr.T("✅ Saved.") + "\n" + formatAISettings(tr, limits, userSettings),
+		}, nil
+	})
+	if err != nil {
+		LogError(ctx, "settings_command", userID, err, nil)
+		SendErrorResponse(ctx, b, update.Message, err)
+		return
+	}
+
+	LogInfo(ctx, "settings_command", userID, "ai parameters saved", map[string]interface{}{
+		"temperature": userSettings.Temperature,
+		"max_tokens":  userSettings.MaxTokens,
+	})
+}
+
+// formatAISettings renders the AI sampling parameters of a user with the
+// ranges they may be chosen from
+func formatAISettings(tr *i18n.Translator, limits SamplingLimits, userSettings *session.UserSettings) string {
+	return tr.T("🎛 AI parameters") + "\n" +
+		tr.Sprintf("Temperature: %s (%s–%s)", formatTemperatureSetting(tr, userSettings),
+			formatTemperature(limits.MinTemperature), formatTemperature(limits.MaxTemperature)) + "\n" +
+		tr.Sprintf("Max tokens: %s (%d–%d)", formatMaxTokensSetting(tr, userSettings), limits.MinMaxTokens, limits.MaxMaxTokens)
+}
+
+// formatAISettingsSummary renders the AI sampling parameters of a user on one line
+func formatAISettingsSummary(tr *i18n.Translator, userSettings *session.UserSettings) string {
+	return fmt.Sprintf("%s / %s", formatTemperatureSetting(tr, userSettings), formatMaxTokensSetting(tr, userSettings))
+}
+
+// formatTemperatureSetting renders the user's temperature, or the default
+func formatTemperatureSetting(tr *i18n.Translator, userSettings *session.UserSettings) string {
+	if userSettings.Temperature == nil {
+		return tr.T("default")
+	}
+	return formatTemperature(*userSettings.Temperature)
+}
+
+// formatMaxTokensSetting renders the user's max tokens, or the default
+func formatMaxTokensSetting(tr *i18n.Translator, userSettings *session.UserSettings) string {
+	if userSettings.MaxTokens == 0 {
+		return tr.T("default")
+	}
+	return strconv.Itoa(userSettings.MaxTokens)
+}
+
+// formatTemperature renders a temperature without trailing zeros
+func formatTemperature(temperature float64) string {
+	return strconv.FormatFloat(temperature, 'g', -1, 64)
+}
+
+// aiParams returns the model parameters of requests made for the user whose
+// settings ctx carries. Values outside cfg.Sampling, saved before the limits
+// changed, are brought back into range.
+func aiParams(ctx context.Context, cfg *HandlerConfig) ai.Params {
+	userSettings := session.UserSettingsFromContext(ctx)
+	params := ai.Params{Model: currentModel(cfg, userSettings)}
+
+	limits := cfg.Sampling
+	if !limits.enabled() {
+		return params
+	}
+	if userSettings.Temperature != nil {
+		temperature := min(max(*userSettings.Temperature, limits.MinTemperature), limits.MaxTemperature)
+		params.Temperature = &temperature
+	}
+	if userSettings.MaxTokens > 0 {
+		params.MaxTokens = min(max(userSettings.MaxTokens, limits.MinMaxTokens), limits.MaxMaxTokens)
+	}
+	return params
+}
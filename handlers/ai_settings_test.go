@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestSettingsAICommand(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "ai_settings.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	cfg := &HandlerConfig{
+		Assistant: ai.NewAssistant(&fakeProvider{}, nil, 0),
+		Sampling:  SamplingLimits{MinTemperature: 0, MaxTemperature: 1, MinMaxTokens: 16, MaxMaxTokens: 1024},
+	}
+	handler := SettingsCommandHandler(store, store, cfg)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, commandUpdate(1, "/settings ai"))
+	if got := api.LastText(); !strings.Contains(got, "Temperature: default (0–1)") || !strings.Contains(got, "Max tokens: default (16–1024)") {
+		t.Errorf("expected the defaults with their ranges, got %q", got)
+	}
+
+	for _, invalid := range []string{"/settings ai temperature 1.5", "/settings ai temperature NaN", "/settings ai max_tokens 5000"} {
+		handler(ctx, api, commandUpdate(1, invalid))
+		if !strings.Contains(api.LastText(), "must be") {
+			t.Errorf("expected %q to be rejected, got %q", invalid, api.LastText())
+		}
+	}
+	handler(ctx, api, commandUpdate(1, "/settings ai top_p 0.5"))
+	if !strings.Contains(api.LastText(), "Use /settings ai temperature") {
+		t.Errorf("expected usage, got %q", api.LastText())
+	}
+
+	handler(ctx, api, commandUpdate(1, "/settings ai temperature 0.4"))
+	handler(ctx, api, commandUpdate(1, "/settings ai max_tokens 512"))
+	if got := api.LastText(); !strings.Contains(got, "Saved") || !strings.Contains(got, "Max tokens: 512") {
+		t.Errorf("expected the saved parameters, got %q", got)
+	}
+	saved, err := store.GetUserSettings(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserSettings failed: %v", err)
+	}
+	if saved.Temperature == nil || *saved.Temperature != 0.4 || saved.MaxTokens != 512 {
+		t.Errorf("unexpected saved parameters %v and %d", saved.Temperature, saved.MaxTokens)
+	}
+
+	_, keyboard, err := buildSettingsMenu(ctx, store, store, cfg, &models.User{ID: 1})
+	if err != nil {
+		t.Fatalf("buildSettingsMenu failed: %v", err)
+	}
+	if labels := keyboardLabels(keyboard); !strings.Contains(labels, "AI parameters: 0.4 / 512") {
+		t.Errorf("expected the parameters in the menu, got %q", labels)
+	}
+
+	handler(ctx, api, commandUpdate(1, "/settings ai temperature default"))
+	if saved, _ := store.GetUserSettings(ctx, 1); saved.Temperature != nil || saved.MaxTokens != 512 {
+		t.Errorf("expected only the temperature reset, got %v and %d", saved.Temperature, saved.MaxTokens)
+	}
+
+	SettingsCommandHandler(store, store, &HandlerConfig{})(ctx, api, commandUpdate(1, "/settings ai"))
+	if !strings.Contains(api.LastText(), "not configured") {
+		t.Errorf("expected a not configured notice, got %q", api.LastText())
+	}
+}
+
+func TestAIParametersApplied(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "ai_params.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	provider := &fakeProvider{replies: []*ai.Message{
+		{Role: ai.RoleAssistant, Content: "One."},
+		{Role: ai.RoleAssistant, Content: "Two."},
+	}}
+	sessionMgr := session.NewManager(store)
+	cfg := &HandlerConfig{
+		Assistant: ai.NewAssistant(provider, nil, 0),
+		Sampling:  SamplingLimits{MinTemperature: 0, MaxTemperature: 1, MinMaxTokens: 16, MaxMaxTokens: 1024},
+	}
+	handler := MessageHandler(sessionMgr, session.NewMessageManager(store), cfg)
+	api := testutil.NewFakeTelegram()
+
+	// Values saved under wider limits are brought into range
+	temperature := 1.8
+	userSettings := session.DefaultUserSettings(1)
+	userSettings.Temperature, userSettings.MaxTokens = &temperature, 300
+	ctx := session.WithUserSettings(context.Background(), userSettings)
+
+	handler(ctx, api, textUpdate(1, "Hello"))
+	req := provider.requests[0]
+	if req.Temperature == nil || *req.Temperature != 1 || req.MaxTokens != 300 {
+		t.Errorf("expected temperature 1 and 300 tokens, got %v and %d", req.Temperature, req.MaxTokens)
+	}
+
+	// The user's temperature takes precedence over the session preset's
+	active, err := sessionMgr.GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	if _, err := sessionMgr.SetSessionPreset(ctx, 1, active.ID, "casual"); err != nil {
+		t.Fatalf("SetSessionPreset failed: %v", err)
+	}
+	temperature = 0.1
+	handler(ctx, api, textUpdate(1, "Again"))
+	req = provider.requests[1]
+	if req.Temperature == nil || *req.Temperature != 0.1 || req.Messages[0].Role != ai.RoleSystem {
+		t.Errorf("expected the user's temperature with the preset's prompt, got %+v", req)
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// TelegramAPI is the part of the Bot API used by handlers. *bot.Bot implements it;
+// tests use the in-memory fake from the testutil package.
+type TelegramAPI interface {
+	SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error)
+	SendDocument(ctx context.Context, params *bot.SendDocumentParams) (*models.Message, error)
+	EditMessageText(ctx context.Context, params *bot.EditMessageTextParams) (*models.Message, error)
+	EditMessageReplyMarkup(ctx context.Context, params *bot.EditMessageReplyMarkupParams) (*models.Message, error)
+	AnswerCallbackQuery(ctx context.Context, params *bot.AnswerCallbackQueryParams) (bool, error)
+	PinChatMessage(ctx context.Context, params *bot.PinChatMessageParams) (bool, error)
+	AnswerInlineQuery(ctx context.Context, params *bot.AnswerInlineQueryParams) (bool, error)
+	GetFile(ctx context.Context, params *bot.GetFileParams) (*models.File, error)
+	FileDownloadLink(f *models.File) string
+	GetBusinessConnection(ctx context.Context, params *bot.GetBusinessConnectionParams) (*models.BusinessConnection, error)
+}
+
+var _ TelegramAPI = (*bot.Bot)(nil)
+
+// HandlerFunc handles an update through the Telegram API.
+// Traced adapts it to the bot library's handler type.
+type HandlerFunc func(ctx context.Context, api TelegramAPI, update *models.Update)
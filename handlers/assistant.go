@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// assistantHistoryMessages is the number of stored messages sent to the model as context
+const assistantHistoryMessages = 20
+
+// Limits of the built-in tools
+const (
+	toolSessionsLimit   = 20
+	maxReminderDelay    = 30 * 24 * time.Hour
+	maxReminderTextSize = 1000
+)
+
+// replyWithAssistant answers the stored message msg of sess with the AI
+// assistant and stores the answer in the session
+func replyWithAssistant(ctx context.Context, b TelegramAPI, msg *models.Message, sess *session.Session,
+	sessionMgr *session.Manager, messageMgr *session.MessageManager, cfg *HandlerConfig) {
+	userID := msg.From.ID
+	tr := i18n.FromContext(ctx)
+
+	stored, err := messageMgr.History(ctx, sess.ID, assistantHistoryMessages)
+	if err != nil {
+		LogError(ctx, "assistant", userID, err, map[string]interface{}{
+			"session_id": sess.ID.String(),
+		})
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	// While the reply is generated its message reads as in progress, shows
+	// the text streamed so far and offers a stop button; it is then edited
+	// into the first part of the reply
+	genCtx := ctx
+	var status *models.Message
+	var onText func(text string)
+	if cfg.Generations != nil {
+		var id string
+		var done func()
+		genCtx, id, done = cfg.Generations.start(ctx, userID)
+		defer done()
+		stop := cfg.callbacks().EncodeKeyboard(ctx, stopKeyboard(tr, id))
+		status, err = b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            tr.T("⏳ Generating a reply…"),
+			ReplyMarkup:     stop,
+		})
+		if err != nil {
+			LogError(ctx, "assistant", userID, err, map[string]interface{}{
+				"session_id": sess.ID.String(),
+			})
+		} else {
+			onText = previewStream(ctx, b, cfg, userID, status, stop)
+		}
+	}
+
+	reply, model, failure := assistantReply(genCtx, userID, msg, sess, stored, cfg, onText)
+	if stopped(genCtx) {
+		// The text streamed before the stop, if any, is finalized as the reply
+		LogInfo(ctx, "assistant", userID, "assistant reply stopped", map[string]interface{}{
+			"session_id":   sess.ID.String(),
+			"model":        model,
+			"reply_length": len(reply),
+		})
+	}
+	if failure != "" {
+		sendAssistantPart(ctx, b, msg, status, failure, nil)
+		return
+	}
+
+	// The answer's ID is known before sending, for the buttons naming it.
+	// Replies over Telegram's limit go out in several messages; the keyboard
+	// comes with the last one and the stored answer points at the first.
+	answer := session.NewMessage(sess.ID, userID, session.RoleAssistant, reply)
+	answer.ChatID = msg.Chat.ID
+	parts := format.SplitMarkdown(reply, format.MaxMessageLength)
+	keyboard := assistantKeyboard(ctx, tr, cfg, answer, len(parts))
+	var first *models.Message
+	for i, part := range parts {
+		var markup *models.InlineKeyboardMarkup
+		if i == len(parts)-1 {
+			markup = keyboard
+		}
+		var edited *models.Message
+		if i == 0 {
+			edited = status
+		}
+		sent, err := sendAssistantPart(ctx, b, msg, edited, part, markup)
+		if err != nil && edited != nil {
+			// The status message could not become the reply, so it goes out on its own
+			LogError(ctx, "assistant", userID, err, map[string]interface{}{
+				"session_id": sess.ID.String(),
+			})
+			sent, err = sendAssistantPart(ctx, b, msg, nil, part, markup)
+		}
+		if err != nil {
+			LogError(ctx, "assistant", userID, err, map[string]interface{}{
+				"session_id": sess.ID.String(),
+				"part":       i + 1,
+				"parts":      len(parts),
+			})
+			break
+		}
+		if i == 0 {
+			first = sent
+		}
+	}
+
+	// A status message that did not become the reply must not keep offering to stop it
+	if status != nil && (first == nil || first.ID != status.ID) {
+		notice := tr.T("⚠️ The reply could not be delivered. It is saved in the session.")
+		if first != nil {
+			notice = tr.T("⬇️ The reply was sent as a new message.")
+		}
+		if _, err := sendAssistantPart(ctx, b, msg, status, notice, nil); err != nil {
+			LogError(ctx, "assistant", userID, err, map[string]interface{}{
+				"session_id": sess.ID.String(),
+			})
+		}
+	}
+
+	// The answer is stored even when it could not be delivered, so the history
+	// has it and the session's messages no longer count as unread
+	if first != nil {
+		answer.TelegramMessageID = first.ID
+	}
+	if err := messageMgr.AddMessage(ctx, answer); err != nil {
+		LogError(ctx, "assistant", userID, err, map[string]interface{}{
+			"session_id": sess.ID.String(),
+		})
+		return
+	}
+	if err := sessionMgr.TouchSession(ctx, sess.ID, reply); err != nil {
+		LogError(ctx, "assistant", userID, err, map[string]interface{}{
+			"session_id": sess.ID.String(),
+		})
+	}
+
+	LogInfo(ctx, "assistant", userID, "assistant replied", map[string]interface{}{
+		"session_id":   sess.ID.String(),
+		"model":        model,
+		"reply_length": len(reply),
+		"reply_parts":  len(parts),
+		"delivered":    first != nil,
+	})
+}
+
+// assistantReply asks the assistant to answer userID with the stored history
+// of the session sess, in the chat of msg, and filters the answer. The user's
+// AI parameters and the session's preset shape the request. A non-nil onText
+// streams the answer: it receives the text so far as it arrives, and a reply
+// its user stopped is the text streamed until then. When no answer can be
+// shown, failure tells the user why.
+func assistantReply(ctx context.Context, userID int64, msg *models.Message, sess *session.Session,
+	stored []*session.Message, cfg *HandlerConfig, onText func(text string)) (reply, model, failure string) {
+	tr := i18n.FromContext(ctx)
+
+	prompt, params := presetParams(sess, aiParams(ctx, cfg))
+	model = params.Model
+	var partial string
+	if onText != nil {
+		params.OnText = func(text string) {
+			partial = text
+			onText(text)
+		}
+	}
+
+	history := make([]ai.Message, 0, len(stored)+3)
+	if prompt != nil {
+		history = append(history, *prompt)
+	}
+	if summary := summaryContext(ctx, cfg, userID, sess.ID, len(stored), assistantHistoryMessages); summary != nil {
+		history = append(history, *summary)
+	}
+	if memory := memoryContext(ctx, cfg, userID, sess.ID); memory != nil {
+		history = append(history, *memory)
+	}
+	for _, message := range stored {
+		if message.Content == "" || (message.Role != session.RoleUser && message.Role != session.RoleAssistant) {
+			continue
+		}
+		history = append(history, ai.Message{Role: message.Role, Content: message.Content})
+	}
+
+	caller := ai.Caller{UserID: userID, ChatID: msg.Chat.ID, Admin: isAdmin(cfg, userID)}
+	reply, err := cfg.Assistant.ReplyWith(ctx, caller, params, history)
+	switch {
+	case err == nil:
+	case stopped(ctx) && partial != "":
+		reply = partial
+	case stopped(ctx):
+		return "", model, tr.T("⏹ Reply stopped.")
+	default:
+		LogError(ctx, "assistant", userID, err, map[string]interface{}{
+			"session_id": sess.ID.String(),
+			"model":      model,
+		})
+		return "", model, tr.T("🤖 The assistant is unavailable right now. Your message is saved, please try again later.")
+	}
+
+	// The original reply is kept for review; the user sees it redacted or not at all
+	flagged := &session.FlaggedContent{
+		UserID:    userID,
+		ChatID:    msg.Chat.ID,
+		SessionID: sess.ID,
+		Direction: session.DirectionOutbound,
+		Text:      reply,
+	}
+	reply, matches := filterContent(ctx, cfg, userID, reply)
+	flagContent(ctx, cfg, flagged, matches)
+	if cfg.blocksContent(matches) {
+		return "", model, tr.T("🚫 The assistant's reply was withheld by the content filter.")
+	}
+	return reply, model, ""
+}
+
+// sendAssistantPart sends text in the chat of msg, or edits status into it
+// when the reply has a message showing its progress
+func sendAssistantPart(ctx context.Context, b TelegramAPI, msg, status *models.Message, text string,
+	keyboard *models.InlineKeyboardMarkup) (*models.Message, error) {
+	if status != nil {
+		params := &bot.EditMessageTextParams{ChatID: status.Chat.ID, MessageID: status.ID, Text: text}
+		if keyboard != nil {
+			params.ReplyMarkup = keyboard
+		}
+		if _, err := b.EditMessageText(ctx, params); err != nil {
+			return nil, err
+		}
+		return status, nil
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:          msg.Chat.ID,
+		MessageThreadID: topicThreadID(msg),
+		Text:            text,
+	}
+	if keyboard != nil {
+		params.ReplyMarkup = keyboard
+	}
+	return b.SendMessage(ctx, params)
+}
+
+// assistantKeyboard returns the encoded keyboard under the stored reply
+// answer sent in the given number of parts, or nil when it has no buttons
+func assistantKeyboard(ctx context.Context, tr *i18n.Translator, cfg *HandlerConfig, answer *session.Message, parts int) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	if button := codeFilesButton(tr, answer); button != nil {
+		rows = append(rows, button)
+	}
+	if parts == 1 {
+		rows = append(rows, regenerateButton(tr, answer))
+	}
+	if cfg.QuickSwitchButtons {
+		rows = append(rows, buildQuickSwitchKeyboard(tr).InlineKeyboard...)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return cfg.callbacks().EncodeKeyboard(ctx, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+// RegisterSessionTools adds the built-in tools working on the caller's sessions
+// to tools: list_sessions, rename_session and set_reminder. Reminders go through
+// the deferred message queue of the notifier.
+func RegisterSessionTools(tools *ai.Registry, sessionMgr *session.Manager, reminders session.DeferredMessageStore) {
+	tools.Register(ai.Tool{
+		ToolSpec: ai.ToolSpec{
+			Name:        "list_sessions",
+			Description: "List the user's most recently updated chat sessions",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		},
+		Run: func(ctx context.Context, caller ai.Caller, _ json.RawMessage) (string, error) {
+			sessions, _, err := sessionMgr.ListSessions(ctx, caller.UserID, 0, toolSessionsLimit)
+			if err != nil {
+				return "", err
+			}
+			activeID := activeSessionID(ctx, sessionMgr, caller.UserID)
+
+			type toolSession struct {
+				ID           string    `json:"id"`
+				Title        string    `json:"title"`
+				Active       bool      `json:"active"`
+				MessageCount int       `json:"message_count"`
+				UpdatedAt    time.Time `json:"updated_at"`
+			}
+			result := make([]toolSession, len(sessions))
+			for i, s := range sessions {
+				result[i] = toolSession{
+					ID:           s.ID.String(),
+					Title:        s.Title,
+					Active:       s.ID == activeID,
+					MessageCount: s.MessageCount,
+					UpdatedAt:    s.UpdatedAt,
+				}
+			}
+			return toolResult(result)
+		},
+	})
+
+	tools.Register(ai.Tool{
+		ToolSpec: ai.ToolSpec{
+			Name:        "rename_session",
+			Description: "Rename one of the user's chat sessions",
+			Parameters: json.RawMessage(`{"type":"object","properties":{` +
+				`"session_id":{"type":"string","description":"ID of the session, from list_sessions"},` +
+				`"title":{"type":"string","description":"The new title"}},` +
+				`"required":["session_id","title"]}`),
+		},
+		Run: func(ctx context.Context, caller ai.Caller, arguments json.RawMessage) (string, error) {
+			var args struct {
+				SessionID string `json:"session_id"`
+				Title     string `json:"title"`
+			}
+			if err := json.Unmarshal(arguments, &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			sessionID, err := uuid.Parse(args.SessionID)
+			if err != nil {
+				return "", fmt.Errorf("invalid session_id %q", args.SessionID)
+			}
+			renamed, err := sessionMgr.RenameSession(ctx, caller.UserID, sessionID, args.Title)
+			if err != nil {
+				return "", err
+			}
+			return toolResult(map[string]string{"id": renamed.ID.String(), "title": renamed.Title})
+		},
+	})
+
+	tools.Register(ai.Tool{
+		ToolSpec: ai.ToolSpec{
+			Name:        "set_reminder",
+			Description: "Send the user a reminder message in this chat after a delay",
+			Parameters: json.RawMessage(`{"type":"object","properties":{` +
+				`"text":{"type":"string","description":"What to remind the user of"},` +
+				`"delay_minutes":{"type":"integer","minimum":1,"description":"Minutes from now"}},` +
+				`"required":["text","delay_minutes"]}`),
+		},
+		Run: func(ctx context.Context, caller ai.Caller, arguments json.RawMessage) (string, error) {
+			var args struct {
+				Text         string `json:"text"`
+				DelayMinutes int    `json:"delay_minutes"`
+			}
+			if err := json.Unmarshal(arguments, &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			args.Text = strings.TrimSpace(args.Text)
+			delay := time.Duration(args.DelayMinutes) * time.Minute
+			switch {
+			case args.Text == "":
+				return "", errors.New("text is empty")
+			case len(args.Text) > maxReminderTextSize:
+				return "", fmt.Errorf("text exceeds %d bytes", maxReminderTextSize)
+			case delay <= 0 || delay > maxReminderDelay:
+				return "", fmt.Errorf("delay_minutes must be between 1 and %d", int(maxReminderDelay.Minutes()))
+			}
+
+			now := time.Now().UTC()
+			reminder := &session.DeferredMessage{
+				UserID:    caller.UserID,
+				ChatID:    caller.ChatID,
+				Text:      "⏰ " + args.Text,
+				DeliverAt: now.Add(delay),
+				CreatedAt: now,
+			}
+			if err := reminders.DeferMessage(ctx, reminder); err != nil {
+				return "", err
+			}
+			return toolResult(map[string]interface{}{"scheduled_at": reminder.DeliverAt})
+		},
+	})
+}
+
+// toolResult encodes the result of a tool as JSON
+func toolResult(v interface{}) (string, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
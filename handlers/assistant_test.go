@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+// fakeProvider answers with scripted replies and records the requests
+type fakeProvider struct {
+	replies  []*ai.Message
+	requests []*ai.Request
+	err      error
+}
+
+func (p *fakeProvider) Complete(_ context.Context, req *ai.Request) (*ai.Message, error) {
+	copied := *req
+	copied.Messages = append([]ai.Message(nil), req.Messages...)
+	p.requests = append(p.requests, &copied)
+	if p.err != nil {
+		return nil, p.err
+	}
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return reply, nil
+}
+
+// toolCall is a model reply calling tool with arguments
+func toolCall(tool, arguments string) *ai.Message {
+	return &ai.Message{Role: ai.RoleAssistant, ToolCalls: []ai.ToolCall{
+		{ID: "call_" + tool, Name: tool, Arguments: json.RawMessage(arguments)},
+	}}
+}
+
+func newAssistantTest(t *testing.T, provider ai.Provider) (*session.SQLiteStore, *session.Manager, HandlerFunc) {
+	t.Helper()
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "assistant.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	sessionMgr := session.NewManager(store)
+	tools := ai.NewRegistry()
+	RegisterSessionTools(tools, sessionMgr, store)
+	cfg := &HandlerConfig{AIModels: []string{"small"}, Assistant: ai.NewAssistant(provider, tools, 0)}
+	return store, sessionMgr, MessageHandler(sessionMgr, session.NewMessageManager(store), cfg)
+}
+
+func TestMessageHandlerRepliesWithAssistant(t *testing.T) {
+	provider := &fakeProvider{replies: []*ai.Message{
+		toolCall("list_sessions", `{}`),
+		{Role: ai.RoleAssistant, Content: "You have one session."},
+	}}
+	store, sessionMgr, handler := newAssistantTest(t, provider)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, textUpdate(1, "What are my sessions?"))
+
+	if got := api.LastText(); got != "You have one session." {
+		t.Fatalf("expected the assistant's reply, got %q", got)
+	}
+	if model := provider.requests[0].Model; model != "small" {
+		t.Errorf("expected the default model, got %q", model)
+	}
+	result := provider.requests[1].Messages[2].Content
+	if !strings.Contains(result, "What are my sessions?") || !strings.Contains(result, `"active":true`) {
+		t.Errorf("expected the active session in the tool result, got %s", result)
+	}
+
+	active, err := sessionMgr.GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	messages, err := store.ListMessages(ctx, active.ID, 10)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 2 || messages[1].Role != session.RoleAssistant || messages[1].Content != "You have one session." {
+		t.Errorf("expected the reply stored after the question, got %+v", messages)
+	}
+	if active.UnreadCount != 0 || active.LastMessage != "You have one session." {
+		t.Errorf("expected an answered session previewing the reply, got %+v", active)
+	}
+
+	// The next request carries the stored history
+	provider.replies = []*ai.Message{{Role: ai.RoleAssistant, Content: "Sure."}}
+	handler(ctx, api, textUpdate(1, "Thanks"))
+	history := provider.requests[2].Messages
+	if len(history) != 3 || history[1].Role != ai.RoleAssistant || history[2].Content != "Thanks" {
+		t.Errorf("unexpected history %+v", history)
+	}
+}
+
+func TestAssistantSetsReminders(t *testing.T) {
+	provider := &fakeProvider{replies: []*ai.Message{
+		toolCall("set_reminder", `{"text":"Call mom","delay_minutes":30}`),
+		{Role: ai.RoleAssistant, Content: "I'll remind you."},
+	}}
+	store, _, handler := newAssistantTest(t, provider)
+	ctx := context.Background()
+
+	handler(ctx, testutil.NewFakeTelegram(), textUpdate(1, "Remind me to call mom in 30 minutes"))
+
+	due, err := store.ListDueMessages(ctx, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListDueMessages failed: %v", err)
+	}
+	if len(due) != 1 || due[0].Text != "⏰ Call mom" || due[0].ChatID != 1 {
+		t.Fatalf("expected the reminder to be queued, got %+v", due)
+	}
+	if wait := time.Until(due[0].DeliverAt); wait < 29*time.Minute || wait > 30*time.Minute {
+		t.Errorf("expected the reminder in 30 minutes, got %s", wait)
+	}
+	if early, _ := store.ListDueMessages(ctx, time.Now(), 10); len(early) != 0 {
+		t.Errorf("expected the reminder not to be due yet, got %+v", early)
+	}
+
+	// Invalid arguments are reported to the model instead of scheduling anything
+	provider.replies = []*ai.Message{
+		toolCall("set_reminder", `{"text":"Later","delay_minutes":0}`),
+		{Role: ai.RoleAssistant, Content: "When?"},
+	}
+	handler(ctx, testutil.NewFakeTelegram(), textUpdate(1, "Remind me"))
+	if result := provider.requests[3].Messages[len(provider.requests[3].Messages)-1].Content; !strings.Contains(result, "delay_minutes") {
+		t.Errorf("expected a delay error, got %s", result)
+	}
+}
+
+func TestMessageHandlerAssistantUnavailable(t *testing.T) {
+	store, sessionMgr, handler := newAssistantTest(t, &fakeProvider{err: errors.New("connection refused")})
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, textUpdate(1, "Hello?"))
+
+	if got := api.LastText(); !strings.Contains(got, "unavailable") {
+		t.Errorf("expected an unavailable notice, got %q", got)
+	}
+	active, err := sessionMgr.GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	if messages, _ := store.ListMessages(ctx, active.ID, 10); len(messages) != 1 || active.UnreadCount != 1 {
+		t.Errorf("expected the question kept unanswered, got %+v", messages)
+	}
+}
+
+func TestMessageHandlerSplitsLongReplies(t *testing.T) {
+	long := strings.Repeat("A long paragraph. ", 200) + "\n\n" + strings.Repeat("Another one. ", 200)
+	provider := &fakeProvider{replies: []*ai.Message{{Role: ai.RoleAssistant, Content: long}}}
+	store, sessionMgr, handler := newAssistantTest(t, provider)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, textUpdate(1, "Tell me everything"))
+
+	if len(api.Sent) != 2 {
+		t.Fatalf("expected the reply in 2 messages, got %d", len(api.Sent))
+	}
+	if !strings.HasPrefix(api.Sent[1].Text, "Another one.") {
+		t.Errorf("expected the split between paragraphs, got %q", api.Sent[1].Text[:20])
+	}
+	active, _ := sessionMgr.GetActiveSession(ctx, 1)
+	messages, _ := store.ListMessages(ctx, active.ID, 10)
+	if len(messages) != 2 || messages[1].Content != long {
+		t.Errorf("expected the whole reply stored once, got %d messages", len(messages))
+	}
+}
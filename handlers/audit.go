@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+)
+
+// auditListLimit is how many entries /admin audit shows
+const auditListLimit = 20
+
+// recordAudit appends an entry to the audit log when one is configured. A
+// failure is only logged: the action already happened and is not undone.
+func (cfg *HandlerConfig) recordAudit(ctx context.Context, actorID int64, action, target, payload string) {
+	if cfg == nil || cfg.Audit == nil {
+		return
+	}
+	if err := cfg.Audit.RecordAudit(ctx, session.NewAuditEntry(actorID, action, target, payload)); err != nil {
+		LogError(ctx, "audit", actorID, err, map[string]interface{}{
+			"action": action,
+			"target": target,
+		})
+	}
+}
+
+// AdminAuditCommand lists the latest audit log entries, optionally only those
+// of one action or of one user
+func AdminAuditCommand(audit session.AuditStore) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		if len(args) > 1 {
+			return tr.T("Usage: /admin audit [action|user_id]"), nil
+		}
+		var action string
+		var actorID int64
+		if len(args) == 1 {
+			if id, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+				actorID = id
+			} else {
+				action = strings.ToLower(args[0])
+			}
+		}
+
+		entries, err := audit.ListAudit(ctx, action, actorID, 0, auditListLimit)
+		if err != nil {
+			return "", err
+		}
+		if len(entries) == 0 {
+			return tr.T("No audit entries found"), nil
+		}
+
+		lines := []string{tr.Sprintf("📜 Latest %d audit entries:", len(entries))}
+		for _, entry := range entries {
+			line := fmt.Sprintf("%s %d %s", entry.CreatedAt.UTC().Format("2006-01-02 15:04"), entry.ActorID, entry.Action)
+			if entry.Target != "" {
+				line += " " + entry.Target
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+}
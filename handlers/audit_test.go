@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func TestAdminCommandsAreAudited(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	cfg := &HandlerConfig{AdminUserIDs: []int64{9}, Audit: store}
+	handler := AdminCommandHandler(cfg, map[string]AdminCommand{
+		"audit": AdminAuditCommand(store),
+		"ping": AdminCommandFunc(func(ctx context.Context, userID int64, args []string) (string, error) {
+			return "pong", nil
+		}),
+	})
+
+	api := testutil.NewFakeTelegram()
+	handler(ctx, api, commandUpdate(1, "/admin ping"))
+	handler(ctx, api, commandUpdate(9, "/admin ping now"))
+
+	entries, err := store.ListAudit(ctx, "", 0, 0, 10)
+	if err != nil {
+		t.Fatalf("ListAudit failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the admin's command audited, got %+v", entries)
+	}
+	if entries[0].ActorID != 9 || entries[0].Action != "admin.ping" || entries[0].Target != "now" ||
+		entries[0].PayloadHash != session.HashAuditPayload("/admin ping now") {
+		t.Errorf("unexpected audit entry: %+v", entries[0])
+	}
+
+	handler(ctx, api, commandUpdate(9, "/admin audit admin.ping"))
+	reply := api.Sent[len(api.Sent)-1].Text
+	if !strings.Contains(reply, "Latest 1 audit entries") || !strings.Contains(reply, "9 admin.ping now") {
+		t.Errorf("expected the ping listed, got %q", reply)
+	}
+
+	handler(ctx, api, commandUpdate(9, "/admin audit 1"))
+	if reply := api.Sent[len(api.Sent)-1].Text; reply != "No audit entries found" {
+		t.Errorf("expected no entries of user 1, got %q", reply)
+	}
+}
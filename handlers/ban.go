@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/notify"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// banNotifyFlag asks /admin ban to tell the user they were banned
+const banNotifyFlag = "--notify"
+
+// banListLimit is how many bans /admin ban lists
+const banListLimit = 20
+
+// BanMiddleware is a bot middleware that drops every update from banned users
+// before any handler runs. Administrators are never dropped.
+func BanMiddleware(bans session.BanStore, cfg *HandlerConfig) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if dropBanned(ctx, b, bans, cfg, update) {
+				return
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// dropBanned reports whether update comes from a banned user. Button presses
+// are answered, so the client stops waiting, but banned users get no reply.
+func dropBanned(ctx context.Context, b TelegramAPI, bans session.BanStore, cfg *HandlerConfig, update *models.Update) bool {
+	user := updateSender(update)
+	if user == nil || isAdmin(cfg, user.ID) {
+		return false
+	}
+	_, err := bans.GetBan(ctx, user.ID)
+	if errors.Is(err, session.ErrBanNotFound) {
+		return false
+	}
+	if err != nil {
+		// Users are let through rather than locked out by a store failure
+		LogError(ctx, "ban_check", user.ID, err, nil)
+		return false
+	}
+
+	if update.CallbackQuery != nil {
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+	}
+	return true
+}
+
+// AdminBanCommand bans a user: /admin ban <user_id> [--notify] [reason]. With
+// --notify the user is told through notifier, in their language. Without
+// arguments it lists the latest bans.
+func AdminBanCommand(bans session.BanStore, prefs session.PreferenceStore, notifier *notify.Notifier,
+	sender notify.Sender, cfg *HandlerConfig) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		if len(args) == 0 {
+			return listBans(ctx, tr, bans)
+		}
+
+		target, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || target <= 0 {
+			return tr.T("Usage: /admin ban <user_id> [--notify] [reason]"), nil
+		}
+		if isAdmin(cfg, target) {
+			return tr.Sprintf("User %d is an administrator and cannot be banned", target), nil
+		}
+		rest := args[1:]
+		notifyUser := len(rest) > 0 && rest[0] == banNotifyFlag
+		if notifyUser {
+			rest = rest[1:]
+		}
+
+		ban := &session.Ban{
+			UserID:   target,
+			Reason:   truncate(strings.Join(rest, " "), session.MaxBanReasonLength),
+			BannedBy: userID,
+			BannedAt: time.Now(),
+		}
+		if err := bans.BanUser(ctx, ban); err != nil {
+			return "", err
+		}
+		LogInfo(ctx, "admin_ban", userID, "user banned", map[string]interface{}{
+			"target_user_id": target,
+			"reason":         ban.Reason,
+			"notify":         notifyUser,
+		})
+
+		reply := tr.Sprintf("⛔ User %d is banned", target)
+		if notifyUser {
+			if err := notifyBan(ctx, prefs, notifier, sender, ban); err != nil {
+				LogError(ctx, "admin_ban", userID, err, map[string]interface{}{
+					"target_user_id": target,
+				})
+				reply += "\n" + tr.T("The user could not be notified.")
+			}
+		}
+		return reply, nil
+	}
+}
+
+// notifyBan tells the banned user, in their language, that they can no longer use the bot
+func notifyBan(ctx context.Context, prefs session.PreferenceStore, notifier *notify.Notifier, sender notify.Sender, ban *session.Ban) error {
+	lang := ""
+	if preferences, err := prefs.GetPreferences(ctx, ban.UserID); err == nil {
+		lang = preferences.Language
+	}
+	tr := i18n.New(lang)
+	text := tr.T("⛔ You have been banned from this bot.")
+	if ban.Reason != "" {
+		text += "\n" + tr.Sprintf("Reason: %s", ban.Reason)
+	}
+
+	// Private chats share the ID of the user
+	_, err := notifier.Send(ctx, sender, ban.UserID, ban.UserID, text, "")
+	return err
+}
+
+// listBans renders the latest bans
+func listBans(ctx context.Context, tr *i18n.Translator, bans session.BanStore) (string, error) {
+	list, err := bans.ListBans(ctx, 0, banListLimit)
+	if err != nil {
+		return "", err
+	}
+	if len(list) == 0 {
+		return tr.T("No users are banned"), nil
+	}
+
+	lines := []string{tr.T("⛔ Banned users:")}
+	for _, ban := range list {
+		line := fmt.Sprintf("%d (%s)", ban.UserID, ban.BannedAt.UTC().Format("2006-01-02"))
+		if ban.Reason != "" {
+			line += ": " + ban.Reason
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// AdminUnbanCommand lifts a ban: /admin unban <user_id>
+func AdminUnbanCommand(bans session.BanStore) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		if len(args) != 1 {
+			return tr.T("Usage: /admin unban <user_id>"), nil
+		}
+		target, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || target <= 0 {
+			return tr.T("Usage: /admin unban <user_id>"), nil
+		}
+
+		err = bans.UnbanUser(ctx, target)
+		if errors.Is(err, session.ErrBanNotFound) {
+			return tr.Sprintf("User %d is not banned", target), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		LogInfo(ctx, "admin_unban", userID, "user unbanned", map[string]interface{}{
+			"target_user_id": target,
+		})
+		return tr.Sprintf("✅ User %d is no longer banned", target), nil
+	}
+}
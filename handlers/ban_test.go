@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/notify"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func TestBanAndUnban(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_bans.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	cfg := &HandlerConfig{AdminUserIDs: []int64{9}}
+	sender := testutil.NewFakeTelegram()
+	ban := AdminBanCommand(store, store, notify.New(store), sender, cfg)
+	unban := AdminUnbanCommand(store)
+
+	for _, args := range [][]string{{"bob"}, {"0"}} {
+		if reply, _ := ban(ctx, 9, args); !strings.Contains(reply, "Usage") {
+			t.Errorf("args %v: expected usage, got %q", args, reply)
+		}
+	}
+	if reply, _ := ban(ctx, 9, []string{"9"}); !strings.Contains(reply, "cannot be banned") {
+		t.Errorf("expected administrators to be unbannable, got %q", reply)
+	}
+
+	reply, err := ban(ctx, 9, []string{"1", "--notify", "spamming", "links"})
+	if err != nil {
+		t.Fatalf("ban failed: %v", err)
+	}
+	if reply != "⛔ User 1 is banned" {
+		t.Errorf("unexpected reply %q", reply)
+	}
+	if len(sender.Sent) != 1 || sender.Sent[0].ChatID != int64(1) || !strings.Contains(sender.Sent[0].Text, "Reason: spamming links") {
+		t.Errorf("expected the user notified with the reason, got %+v", sender.Sent)
+	}
+	if _, err := ban(ctx, 9, []string{"2"}); err != nil {
+		t.Fatalf("ban failed: %v", err)
+	}
+	if len(sender.Sent) != 1 {
+		t.Errorf("expected no notice without %s, got %d messages", banNotifyFlag, len(sender.Sent))
+	}
+	if reply, _ := ban(ctx, 9, nil); !strings.Contains(reply, "1 (") || !strings.Contains(reply, ": spamming links") || !strings.Contains(reply, "2 (") {
+		t.Errorf("expected both bans listed, got %q", reply)
+	}
+
+	api := testutil.NewFakeTelegram()
+	if !dropBanned(ctx, api, store, cfg, commandUpdate(1, "/start")) {
+		t.Error("expected updates of a banned user to be dropped")
+	}
+	if !dropBanned(ctx, api, store, cfg, callbackUpdate(2, "sessions")) || len(api.CallbackAnswers) != 1 {
+		t.Errorf("expected a banned user's button press dropped and answered, got %d answers", len(api.CallbackAnswers))
+	}
+	if len(api.Sent) != 0 {
+		t.Errorf("expected no replies to banned users, got %d", len(api.Sent))
+	}
+	if dropBanned(ctx, api, store, cfg, commandUpdate(3, "/start")) || dropBanned(ctx, api, store, cfg, commandUpdate(9, "/start")) {
+		t.Error("expected other users and administrators to pass")
+	}
+
+	if reply, _ := unban(ctx, 9, []string{"1"}); reply != "✅ User 1 is no longer banned" {
+		t.Errorf("unexpected reply %q", reply)
+	}
+	if reply, _ := unban(ctx, 9, []string{"1"}); !strings.Contains(reply, "is not banned") {
+		t.Errorf("expected a not banned notice, got %q", reply)
+	}
+	if dropBanned(ctx, api, store, cfg, commandUpdate(1, "/start")) {
+		t.Error("expected an unbanned user to pass")
+	}
+}
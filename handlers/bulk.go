@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// Callback data of the multi-select mode of the session list. The selection
+// lives in the keyboard itself: the checkbox of each session button is read
+// back from the markup of the message the button was pressed on.
+const (
+	selectModePrefix   = "sel_mode_"    // followed by the page offset
+	selectTogglePrefix = "sel_t_"       // followed by a session ID
+	selectPageCallback = "sel_all"      // selects every session on the page
+	bulkArchivePrefix  = "sel_archive_" // followed by the page offset
+	bulkDeletePrefix   = "sel_delete_"  // followed by the page offset
+	selectCancelPrefix = "sel_cancel_"  // followed by the page offset
+)
+
+// registerBulkRoutes adds the multi-select buttons of the session list to router
+func registerBulkRoutes(router *CallbackRouter, sessionMgr *session.Manager, bulk session.BulkStore, cfg *HandlerConfig) {
+	router.HandlePrefix(selectModePrefix, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		if offset, ok := callbackOffset(ctx, req); ok {
+			handleSelectMode(ctx, b, req, sessionMgr, offset, cfg.sessionsPerPage(ctx), cfg.callbacks())
+		}
+	})
+	router.HandlePrefix(selectTogglePrefix, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		handleSelectToggle(ctx, b, req, cfg.callbacks(), func(id string) bool { return id == req.Param })
+	})
+	router.Handle(selectPageCallback, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		handleSelectToggle(ctx, b, req, cfg.callbacks(), func(string) bool { return true })
+	})
+	router.HandlePrefix(bulkArchivePrefix, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		if offset, ok := callbackOffset(ctx, req); ok {
+			handleBulkAction(ctx, b, req, sessionMgr, cfg, offset, bulk.ArchiveSessions,
+				func(tr *i18n.Translator, count int) string { return tr.Sprintf("🗄 Archived %d session(s)", count) })
+		}
+	})
+	router.HandlePrefix(bulkDeletePrefix, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		if offset, ok := callbackOffset(ctx, req); ok {
+			handleBulkAction(ctx, b, req, sessionMgr, cfg, offset, auditedDelete(bulk, cfg),
+				func(tr *i18n.Translator, count int) string { return tr.Sprintf("🗑 Deleted %d session(s)", count) })
+		}
+	})
+	router.HandlePrefix(selectCancelPrefix, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		if offset, ok := callbackOffset(ctx, req); ok && req.Message() != nil {
+			showSessionsPage(ctx, b, req.Message(), sessionMgr, req.UserID, offset, cfg.sessionsPerPage(ctx), cfg.callbacks())
+		}
+	})
+}
+
+// callbackOffset parses the page offset in req.Param
+func callbackOffset(ctx context.Context, req *CallbackRequest) (int, bool) {
+	offset, err := strconv.Atoi(req.Param)
+	if err != nil || offset < 0 {
+		LogWarning(ctx, "select_sessions", req.UserID, "invalid offset", map[string]interface{}{
+			"callback_data": req.Data,
+		})
+		return 0, false
+	}
+	return offset, true
+}
+
+// handleSelectMode shows the page at offset with a checkbox on every session
+func handleSelectMode(ctx context.Context, b TelegramAPI, req *CallbackRequest, sessionMgr *session.Manager,
+	offset, perPage int, callbacks *CallbackEncoder) {
+	msg := req.Message()
+	if msg == nil {
+		return
+	}
+
+	sessions, _, err := sessionMgr.ListSessions(ctx, req.UserID, offset, perPage)
+	if err != nil {
+		LogError(ctx, "select_sessions", req.UserID, err, map[string]interface{}{
+			"offset": offset,
+		})
+		return
+	}
+
+	tr := i18n.FromContext(ctx)
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		Text:        tr.T("Select sessions on this page, then archive or delete them"),
+		ReplyMarkup: callbacks.EncodeKeyboard(ctx, buildSelectKeyboard(tr, sessions, offset)),
+	})
+	if err != nil && !isMessageNotModified(err) {
+		LogError(ctx, "select_sessions", req.UserID, err, nil)
+	}
+}
+
+// buildSelectKeyboard creates the multi-select keyboard of a session list page.
+// Sessions start out unselected.
+func buildSelectKeyboard(tr *i18n.Translator, sessions []*session.Session, offset int) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	for _, s := range sessions {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         marker(LabelUnselected) + formatSessionButton(tr, s),
+			CallbackData: selectTogglePrefix + s.ID.String(),
+		}})
+	}
+
+	rows = append(rows,
+		[]models.InlineKeyboardButton{
+			{Text: tr.T("☑️ Select all"), CallbackData: selectPageCallback},
+		},
+		[]models.InlineKeyboardButton{
+			{Text: tr.T("🗄 Archive"), CallbackData: fmt.Sprintf("%s%d", bulkArchivePrefix, offset)},
+			{Text: tr.T("🗑 Delete"), CallbackData: fmt.Sprintf("%s%d", bulkDeletePrefix, offset)},
+		},
+		[]models.InlineKeyboardButton{
+			{Text: tr.T("Cancel"), CallbackData: fmt.Sprintf("%s%d", selectCancelPrefix, offset)},
+		},
+	)
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// handleSelectToggle flips the checkboxes of the sessions picked by match, given
+// their IDs. When every picked session is already selected they are all cleared,
+// so "select all" pressed twice clears the page.
+func handleSelectToggle(ctx context.Context, b TelegramAPI, req *CallbackRequest, callbacks *CallbackEncoder, match func(id string) bool) {
+	msg := req.Message()
+	if msg == nil || msg.ReplyMarkup == nil {
+		return
+	}
+
+	keyboard := toggleSelection(ctx, msg.ReplyMarkup, callbacks, match)
+	_, err := b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		ReplyMarkup: callbacks.EncodeKeyboard(ctx, keyboard),
+	})
+	if err != nil && !isMessageNotModified(err) {
+		LogError(ctx, "select_sessions", req.UserID, err, nil)
+	}
+}
+
+// toggleSelection returns a decoded copy of markup with the checkboxes of the
+// sessions picked by match flipped, as described for handleSelectToggle
+func toggleSelection(ctx context.Context, markup *models.InlineKeyboardMarkup, callbacks *CallbackEncoder, match func(id string) bool) *models.InlineKeyboardMarkup {
+	keyboard := &models.InlineKeyboardMarkup{}
+	allSelected := true
+	for _, row := range markup.InlineKeyboard {
+		copied := make([]models.InlineKeyboardButton, len(row))
+		for i, button := range row {
+			button.CallbackData = callbacks.Payload(ctx, button.CallbackData)
+			if id, ok := strings.CutPrefix(button.CallbackData, selectTogglePrefix); ok && match(id) {
+				allSelected = allSelected && strings.HasPrefix(button.Text, marker(LabelSelected))
+			}
+			copied[i] = button
+		}
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, copied)
+	}
+
+	checkbox := marker(LabelSelected)
+	if allSelected {
+		checkbox = marker(LabelUnselected)
+	}
+	for _, row := range keyboard.InlineKeyboard {
+		for i := range row {
+			if id, ok := strings.CutPrefix(row[i].CallbackData, selectTogglePrefix); ok && match(id) {
+				text := strings.TrimPrefix(strings.TrimPrefix(row[i].Text, marker(LabelSelected)), marker(LabelUnselected))
+				row[i].Text = checkbox + text
+			}
+		}
+	}
+	return keyboard
+}
+
+// selectedSessions returns the IDs of the sessions checked in markup
+func selectedSessions(ctx context.Context, markup *models.InlineKeyboardMarkup, callbacks *CallbackEncoder) []uuid.UUID {
+	if markup == nil {
+		return nil
+	}
+	var ids []uuid.UUID
+	for _, row := range markup.InlineKeyboard {
+		for _, button := range row {
+			param, ok := strings.CutPrefix(callbacks.Payload(ctx, button.CallbackData), selectTogglePrefix)
+			if !ok || !strings.HasPrefix(button.Text, marker(LabelSelected)) {
+				continue
+			}
+			if id, err := uuid.Parse(param); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// handleBulkAction runs apply on the selected sessions, then shows the session
+// list again and reports how many sessions were changed with report
+func handleBulkAction(ctx context.Context, b TelegramAPI, req *CallbackRequest, sessionMgr *session.Manager, cfg *HandlerConfig,
+	offset int, apply func(ctx context.Context, userID int64, ids []uuid.UUID) (int, error), report func(tr *i18n.Translator, count int) string) {
+	msg := req.Message()
+	if msg == nil {
+		return
+	}
+	tr := i18n.FromContext(ctx)
+
+	ids := selectedSessions(ctx, msg.ReplyMarkup, cfg.callbacks())
+	if len(ids) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            tr.T("Select at least one session first."),
+		})
+		return
+	}
+
+	var count int
+	err := cfg.confirmWrite(ctx, b, req.UserID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		var err error
+		if count, err = apply(ctx, req.UserID, ids); err != nil {
+			return nil, err
+		}
+		return &bot.SendMessageParams{
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            report(tr, count),
+		}, nil
+	})
+	if err != nil {
+		LogError(ctx, "bulk_sessions", req.UserID, err, map[string]interface{}{
+			"callback_data": req.Data,
+			"selected":      len(ids),
+		})
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	LogInfo(ctx, "bulk_sessions", req.UserID, "bulk action applied", map[string]interface{}{
+		"callback_data": req.Data,
+		"selected":      len(ids),
+		"changed":       count,
+	})
+
+	// The page may have emptied; fall back to the last page that still has sessions
+	perPage := cfg.sessionsPerPage(ctx)
+	if total, err := sessionMgr.CountSessions(ctx, req.UserID); err == nil && offset >= total {
+		offset = max(0, (total-1)/perPage*perPage)
+	}
+	showSessionsPage(ctx, b, msg, sessionMgr, req.UserID, offset, perPage, cfg.callbacks())
+}
+
+// auditedDelete deletes sessions in bulk and records each deleted selection in the audit log
+func auditedDelete(bulk session.BulkStore, cfg *HandlerConfig) func(ctx context.Context, userID int64, ids []uuid.UUID) (int, error) {
+	return func(ctx context.Context, userID int64, ids []uuid.UUID) (int, error) {
+		count, err := bulk.DeleteSessions(ctx, userID, ids)
+		if err == nil && count > 0 {
+			targets := make([]string, len(ids))
+			for i, id := range ids {
+				targets[i] = id.String()
+			}
+			target := strings.Join(targets, ",")
+			cfg.recordAudit(ctx, userID, session.AuditSessionDelete, target, target)
+		}
+		return count, err
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// pressButton returns the callback update of pressing the button whose label
+// contains label on a message showing markup
+func pressButton(t *testing.T, userID int64, markup *models.InlineKeyboardMarkup, label string) *models.Update {
+	t.Helper()
+	for _, row := range markup.InlineKeyboard {
+		for _, button := range row {
+			if strings.Contains(button.Text, label) {
+				update := callbackUpdate(userID, button.CallbackData)
+				update.CallbackQuery.Message.Message.ReplyMarkup = markup
+				return update
+			}
+		}
+	}
+	t.Fatalf("no button %q in\n%s", label, keyboardLabels(markup))
+	return nil
+}
+
+func TestBulkArchiveSelectedSessions(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_bulk.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	sessionMgr := session.NewManager(store)
+	ctx := context.Background()
+	for _, title := range []string{"Alpha", "Beta", "Gamma"} {
+		if _, err := sessionMgr.CreateSession(ctx, 1, title); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+	}
+
+	cfg := &HandlerConfig{SessionsPerPage: 5, CallbackSigner: NewCallbackSigner("secret", time.Hour)}
+	handler := CallbackQueryHandler(sessionMgr, store, cfg)
+	fake := &testutil.FakeTelegram{}
+
+	handler(ctx, fake, callbackUpdate(1, cfg.CallbackSigner.Sign(selectModePrefix+"0")))
+	if len(fake.EditedTexts) != 1 {
+		t.Fatalf("expected the list to switch to select mode, got %d edits", len(fake.EditedTexts))
+	}
+	markup := fake.EditedTexts[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	if got := keyboardLabels(markup); strings.Count(got, marker(LabelUnselected)) != 3 {
+		t.Fatalf("expected 3 unselected sessions, got\n%s", got)
+	}
+
+	handler(ctx, fake, pressButton(t, 1, markup, "Beta"))
+	markup = fake.EditedMarkups[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	if got := keyboardLabels(markup); !strings.Contains(got, marker(LabelSelected)+"Beta") || strings.Count(got, marker(LabelSelected)) != 1 {
+		t.Fatalf("expected only Beta to be selected, got\n%s", got)
+	}
+
+	handler(ctx, fake, pressButton(t, 1, markup, "Archive"))
+	if !strings.Contains(fake.LastText(), "Archived 1 session(s)") {
+		t.Errorf("expected an archive confirmation, got %q", fake.LastText())
+	}
+
+	sessions, _, err := sessionMgr.ListSessions(ctx, 1, 0, 5)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 listed sessions, got %d", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.Title == "Beta" {
+			t.Errorf("expected Beta to be archived")
+		}
+	}
+
+	// The message shows the session list again
+	last := fake.EditedTexts[len(fake.EditedTexts)-1]
+	if got := keyboardLabels(last.ReplyMarkup.(*models.InlineKeyboardMarkup)); strings.Contains(got, marker(LabelUnselected)) {
+		t.Errorf("expected the normal session list, got\n%s", got)
+	}
+}
+
+func TestBulkActionWithoutSelection(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_bulk.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	cfg := &HandlerConfig{SessionsPerPage: 5}
+	markup := buildSelectKeyboard(en, nil, 0)
+
+	fake := &testutil.FakeTelegram{}
+	CallbackQueryHandler(session.NewManager(store), store, cfg)(context.Background(), fake, pressButton(t, 1, markup, "Delete"))
+
+	if !strings.Contains(fake.LastText(), "Select at least one session first.") {
+		t.Errorf("expected a selection hint, got %q", fake.LastText())
+	}
+}
+
+func TestToggleSelectionSelectAll(t *testing.T) {
+	sessions := []*session.Session{
+		{ID: uuid.New(), Title: "One", UpdatedAt: time.Now()},
+		{ID: uuid.New(), Title: "Two", UpdatedAt: time.Now()},
+	}
+	all := func(string) bool { return true }
+	ctx := context.Background()
+	callbacks := NewCallbackEncoder(nil, nil)
+
+	markup := toggleSelection(ctx, buildSelectKeyboard(en, sessions, 0), callbacks, all)
+	if ids := selectedSessions(ctx, markup, callbacks); len(ids) != 2 || ids[0] != sessions[0].ID {
+		t.Fatalf("expected both sessions selected, got %v", ids)
+	}
+
+	// With everything selected, "select all" clears the page
+	markup = toggleSelection(ctx, markup, callbacks, all)
+	if ids := selectedSessions(ctx, markup, callbacks); len(ids) != 0 {
+		t.Errorf("expected the selection to be cleared, got %v", ids)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/notify"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// BusinessConnectionHandler records business_connection updates, sent when a
+// Telegram Business account connects the bot, changes its rights or disconnects
+// it, and tells the account owner in their private chat with the bot, subject to
+// the owner's notification settings.
+func BusinessConnectionHandler(connections session.BusinessConnectionStore, notifier *notify.Notifier) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		connection := businessConnectionFromModel(update.BusinessConnection)
+		if err := connections.SaveBusinessConnection(ctx, connection); err != nil {
+			LogError(ctx, "business_connection", connection.UserID, err, map[string]interface{}{"connection_id": connection.ID})
+			return
+		}
+
+		LogInfo(ctx, "business_connection", connection.UserID, "business connection updated", map[string]interface{}{
+			"connection_id": connection.ID,
+			"enabled":       connection.IsEnabled,
+			"can_reply":     connection.CanReply,
+		})
+
+		tr := i18n.FromContext(ctx)
+		text := tr.T("🔌 The bot was disconnected from your business account.")
+		switch {
+		case connection.IsEnabled && connection.CanReply:
+			text = tr.T("💼 The bot is now your business assistant. Each customer chat gets its own session, and customers get a reply right away.")
+		case connection.IsEnabled:
+			text = tr.T("💼 The bot is connected to your business account but may not reply. Customer chats are still recorded in sessions; allow replies in Telegram Business settings to answer customers.")
+		}
+		if _, err := notifier.Send(ctx, b, connection.UserID, connection.UserChatID, text, ""); err != nil {
+			LogError(ctx, "business_connection", connection.UserID, err, map[string]interface{}{"connection_id": connection.ID})
+		}
+	}
+}
+
+// BusinessMessageHandler routes text messages in the chats of a connected business
+// account. Every customer chat keeps its own session, owned by the account owner.
+// Customer messages are stored as user messages and answered through the business
+// connection; messages the owner writes in the chat are stored as assistant replies.
+func BusinessMessageHandler(sessionMgr *session.Manager, messageMgr *session.MessageManager, connections session.BusinessConnectionStore) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		msg := update.BusinessMessage
+		senderID := int64(0)
+		if msg.From != nil {
+			senderID = msg.From.ID
+		}
+
+		connection, err := businessConnection(ctx, b, connections, msg.BusinessConnectionID)
+		if err != nil {
+			LogError(ctx, "business_message", senderID, err, map[string]interface{}{"connection_id": msg.BusinessConnectionID})
+			return
+		}
+
+		role := session.RoleUser
+		if senderID == connection.UserID {
+			role = session.RoleAssistant
+		}
+
+		chat := session.BusinessChat{ConnectionID: connection.ID, ChatID: msg.Chat.ID}
+		activeSession, err := sessionMgr.InBusinessChat(chat).GetOrCreateActiveSession(ctx, connection.UserID, msg.Text)
+		if err != nil {
+			LogError(ctx, "business_message", senderID, err, map[string]interface{}{"connection_id": connection.ID})
+			return
+		}
+
+		message := session.NewMessage(activeSession.ID, connection.UserID, role, msg.Text)
+		message.ChatID = msg.Chat.ID
+		message.TelegramMessageID = msg.ID
+		if err := messageMgr.AddMessage(ctx, message); err != nil {
+			LogError(ctx, "business_message", senderID, err, map[string]interface{}{"session_id": activeSession.ID.String()})
+			return
+		}
+		if err := sessionMgr.TouchSession(ctx, activeSession.ID, msg.Text); err != nil {
+			LogError(ctx, "business_message", senderID, err, map[string]interface{}{"session_id": activeSession.ID.String()})
+		}
+
+		LogInfo(ctx, "business_message", senderID, "business message routed to session", map[string]interface{}{
+			"connection_id": connection.ID,
+			"owner_id":      connection.UserID,
+			"session_id":    activeSession.ID.String(),
+			"role":          role,
+		})
+
+		if role != session.RoleUser || !connection.IsEnabled || !connection.CanReply {
+			return
+		}
+
+		// In a real implementation, this would answer with the AI's reply
+		tr := i18n.FromContext(ctx)
+		_, err = b.SendMessage(WithReplyOrigin(ctx, activeSession.ID, message.ID), &bot.SendMessageParams{
+			BusinessConnectionID: connection.ID,
+			ChatID:               msg.Chat.ID,
+			Text:                 tr.Sprintf("Message received in session: %s", format.Bold(activeSession.Title)),
+			ParseMode:            models.ParseModeHTML,
+			ReplyParameters:      &models.ReplyParameters{MessageID: msg.ID, AllowSendingWithoutReply: true},
+		})
+		if err != nil {
+			LogError(ctx, "business_message", senderID, err, map[string]interface{}{"connection_id": connection.ID})
+		}
+	}
+}
+
+// businessConnection returns the stored connection with id. Connections made
+// before the bot recorded them are fetched from Telegram and stored.
+func businessConnection(ctx context.Context, b TelegramAPI, connections session.BusinessConnectionStore, id string) (*session.BusinessConnection, error) {
+	connection, err := connections.GetBusinessConnection(ctx, id)
+	if !errors.Is(err, session.ErrBusinessConnectionNotFound) {
+		return connection, err
+	}
+
+	fetched, err := b.GetBusinessConnection(ctx, &bot.GetBusinessConnectionParams{BusinessConnectionID: id})
+	if err != nil {
+		return nil, err
+	}
+	connection = businessConnectionFromModel(fetched)
+	if err := connections.SaveBusinessConnection(ctx, connection); err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+// businessConnectionFromModel converts a Telegram business connection
+func businessConnectionFromModel(connection *models.BusinessConnection) *session.BusinessConnection {
+	updatedAt := time.Now()
+	if connection.Date != 0 {
+		updatedAt = time.Unix(connection.Date, 0)
+	}
+	return &session.BusinessConnection{
+		ID:         connection.ID,
+		UserID:     connection.User.ID,
+		UserChatID: connection.UserChatID,
+		CanReply:   connection.Rights != nil && connection.Rights.CanReply,
+		IsEnabled:  connection.IsEnabled,
+		UpdatedAt:  updatedAt,
+	}
+}
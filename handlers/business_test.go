@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/notify"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func newTestBusinessStore(t *testing.T) *session.SQLiteStore {
+	t.Helper()
+
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_business.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func businessUpdate(connectionID string, chatID, fromID int64, messageID int, text string) *models.Update {
+	return &models.Update{BusinessMessage: &models.Message{
+		ID:                   messageID,
+		BusinessConnectionID: connectionID,
+		From:                 &models.User{ID: fromID},
+		Chat:                 models.Chat{ID: chatID},
+		Text:                 text,
+	}}
+}
+
+func TestBusinessConnectionHandler(t *testing.T) {
+	store := newTestBusinessStore(t)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	BusinessConnectionHandler(store, notify.New(store))(ctx, api, &models.Update{BusinessConnection: &models.BusinessConnection{
+		ID:         "conn",
+		User:       models.User{ID: 1},
+		UserChatID: 10,
+		Date:       1700000000,
+		Rights:     &models.BusinessBotRights{CanReply: true},
+		IsEnabled:  true,
+	}})
+
+	connection, err := store.GetBusinessConnection(ctx, "conn")
+	if err != nil {
+		t.Fatalf("GetBusinessConnection failed: %v", err)
+	}
+	if connection.UserID != 1 || connection.UserChatID != 10 || !connection.CanReply || !connection.IsEnabled {
+		t.Errorf("unexpected connection %+v", connection)
+	}
+	if len(api.Sent) != 1 || api.Sent[0].ChatID != int64(10) || !strings.Contains(api.LastText(), "business assistant") {
+		t.Fatalf("expected the owner to be notified, got %+v", api.Sent)
+	}
+
+	BusinessConnectionHandler(store, notify.New(store))(ctx, api, &models.Update{BusinessConnection: &models.BusinessConnection{
+		ID:         "conn",
+		User:       models.User{ID: 1},
+		UserChatID: 10,
+	}})
+	if connection, _ := store.GetBusinessConnection(ctx, "conn"); connection.IsEnabled {
+		t.Error("expected the connection to be disabled")
+	}
+	if !strings.Contains(api.LastText(), "disconnected") {
+		t.Errorf("expected a disconnect notice, got %q", api.LastText())
+	}
+}
+
+func TestBusinessMessageHandler(t *testing.T) {
+	store := newTestBusinessStore(t)
+	sessionMgr := session.NewManager(store)
+	messageMgr := session.NewMessageManager(store)
+	api := testutil.NewFakeTelegram()
+	api.BusinessConnections["conn"] = &models.BusinessConnection{
+		ID:         "conn",
+		User:       models.User{ID: 1},
+		UserChatID: 10,
+		Rights:     &models.BusinessBotRights{CanReply: true},
+		IsEnabled:  true,
+	}
+	ctx := context.Background()
+	handler := BusinessMessageHandler(sessionMgr, messageMgr, store)
+
+	handler(ctx, api, businessUpdate("conn", 20, 20, 1, "hello from a customer"))
+	handler(ctx, api, businessUpdate("conn", 30, 30, 1, "another customer"))
+	handler(ctx, api, businessUpdate("conn", 20, 1, 2, "owner answer"))
+
+	if _, err := store.GetBusinessConnection(ctx, "conn"); err != nil {
+		t.Fatalf("expected the fetched connection to be stored: %v", err)
+	}
+	if len(api.Sent) != 2 {
+		t.Fatalf("expected a reply per customer message, got %d", len(api.Sent))
+	}
+	if reply := api.Sent[0]; reply.BusinessConnectionID != "conn" || reply.ChatID != int64(20) {
+		t.Errorf("expected the reply through the connection, got %+v", reply)
+	}
+
+	sessions, _, err := sessionMgr.ListSessions(ctx, 1, 0, 10)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected a session per customer chat, got %d", len(sessions))
+	}
+
+	chatSession, err := sessionMgr.InBusinessChat(session.BusinessChat{ConnectionID: "conn", ChatID: 20}).GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	history, err := messageMgr.History(ctx, chatSession.ID, 10)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Role != session.RoleUser || history[1].Role != session.RoleAssistant {
+		t.Errorf("expected the customer message and the owner answer, got %+v", history)
+	}
+	if _, err := sessionMgr.GetActiveSession(ctx, 1); err == nil {
+		t.Error("expected the owner's own active session to be untouched")
+	}
+}
+
+func TestBusinessMessageHandlerWithoutReplyRight(t *testing.T) {
+	store := newTestBusinessStore(t)
+	sessionMgr := session.NewManager(store)
+	api := testutil.NewFakeTelegram()
+	api.BusinessConnections["conn"] = &models.BusinessConnection{
+		ID:        "conn",
+		User:      models.User{ID: 1},
+		IsEnabled: true,
+	}
+	ctx := context.Background()
+
+	BusinessMessageHandler(sessionMgr, session.NewMessageManager(store), store)(ctx, api, businessUpdate("conn", 20, 20, 1, "hello"))
+
+	if len(api.Sent) != 0 {
+		t.Errorf("expected no reply without the reply right, got %d", len(api.Sent))
+	}
+	if sessions, _, _ := sessionMgr.ListSessions(ctx, 1, 0, 10); len(sessions) != 1 {
+		t.Errorf("expected the message to be recorded in a session, got %d sessions", len(sessions))
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// maxCallbackDataBytes is Telegram's limit on the callback_data of a button
+const maxCallbackDataBytes = 64
+
+// callbackTokenSeparator separates the kept head of oversized callback data
+// from its token; no callback data contains it
+const callbackTokenSeparator = "~"
+
+// Size of the random part of a token, in bytes and once encoded
+const (
+	callbackTokenBytes  = 12
+	callbackTokenLength = 16
+)
+
+// defaultCallbackTokenTTL is how long tokens last when callbacks never expire
+const defaultCallbackTokenTTL = 7 * 24 * time.Hour
+
+// ErrCallbackDataTooLong is returned for callback data over Telegram's limit
+// when there is no token store to fall back to
+var ErrCallbackDataTooLong = errors.New("callback data exceeds 64 bytes")
+
+// CallbackEncoder builds the callback data of inline keyboard buttons and reads
+// it back. Data is signed when signing is enabled; data that would then exceed
+// Telegram's 64-byte limit, such as a long prefix with a UUID, is kept in the
+// token store and the button carries its head followed by a short random token,
+// so handlers registered for a prefix of the data still get the button.
+type CallbackEncoder struct {
+	signer *CallbackSigner
+	tokens session.CallbackTokenStore
+	now    func() time.Time
+}
+
+// NewCallbackEncoder creates an encoder. A nil signer leaves data unsigned and
+// a nil token store makes oversized data an error.
+func NewCallbackEncoder(signer *CallbackSigner, tokens session.CallbackTokenStore) *CallbackEncoder {
+	return &CallbackEncoder{signer: signer, tokens: tokens, now: time.Now}
+}
+
+// callbacks returns the encoder for the signer and token store of cfg
+func (cfg *HandlerConfig) callbacks() *CallbackEncoder {
+	return NewCallbackEncoder(cfg.CallbackSigner, cfg.CallbackTokens)
+}
+
+// Encode returns the callback data to send for data
+func (e *CallbackEncoder) Encode(ctx context.Context, data string) (string, error) {
+	signed := e.signer.Sign(data)
+	if len(signed) <= maxCallbackDataBytes {
+		return signed, nil
+	}
+	if e.tokens == nil {
+		return "", fmt.Errorf("%w: %q is %d bytes", ErrCallbackDataTooLong, data, len(signed))
+	}
+
+	raw := make([]byte, callbackTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate callback token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	// Keep as much of the data as fits, cut at a character boundary
+	head := data[:min(len(data), maxCallbackDataBytes-len(callbackTokenSeparator)-callbackTokenLength)]
+	for !utf8.ValidString(head) {
+		head = head[:len(head)-1]
+	}
+
+	ttl := defaultCallbackTokenTTL
+	if e.signer != nil && e.signer.ttl > 0 {
+		ttl = e.signer.ttl
+	}
+	now := e.now()
+	if err := e.tokens.CreateCallbackToken(ctx, token, data, now, now.Add(ttl)); err != nil {
+		return "", err
+	}
+	return head + callbackTokenSeparator + token, nil
+}
+
+// EncodeKeyboard encodes the callback data of every button in markup. Buttons
+// whose data cannot be encoded keep it unchanged and the failure is logged;
+// Telegram then rejects the keyboard.
+func (e *CallbackEncoder) EncodeKeyboard(ctx context.Context, markup *models.InlineKeyboardMarkup) *models.InlineKeyboardMarkup {
+	if markup == nil {
+		return nil
+	}
+	for _, row := range markup.InlineKeyboard {
+		for i := range row {
+			if row[i].CallbackData == "" {
+				continue
+			}
+			encoded, err := e.Encode(ctx, row[i].CallbackData)
+			if err != nil {
+				LogError(ctx, "callback_data", 0, err, map[string]interface{}{
+					"callback_data": row[i].CallbackData,
+				})
+				continue
+			}
+			row[i].CallbackData = encoded
+		}
+	}
+	return markup
+}
+
+// Decode verifies callback data sent by Telegram and returns the original data.
+// Unknown and expired tokens yield ErrCallbackExpired.
+func (e *CallbackEncoder) Decode(ctx context.Context, encoded string) (string, error) {
+	head, token, ok := splitCallbackToken(encoded)
+	if !ok {
+		return e.signer.Verify(encoded)
+	}
+	if e.tokens == nil {
+		return "", ErrCallbackInvalid
+	}
+
+	data, err := e.tokens.GetCallbackToken(ctx, token, e.now())
+	if errors.Is(err, session.ErrCallbackTokenNotFound) {
+		return "", ErrCallbackExpired
+	}
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(data, head) {
+		return "", ErrCallbackInvalid
+	}
+	return data, nil
+}
+
+// Payload returns the original data of encoded callback data without verifying
+// it, or "" for a token that cannot be resolved. Like CallbackSigner.Payload it
+// is only meant for reading back the bot's own keyboards.
+func (e *CallbackEncoder) Payload(ctx context.Context, encoded string) string {
+	if _, _, ok := splitCallbackToken(encoded); !ok {
+		return e.signer.Payload(encoded)
+	}
+	data, err := e.Decode(ctx, encoded)
+	if err != nil {
+		return ""
+	}
+	return data
+}
+
+// rejectCallback answers a callback whose data failed to decode. Expired data
+// gets expiredText, which tells the user how to get a fresh menu. Data with a
+// bad signature was not issued by the bot, so it gets a generic answer and is
+// logged as suspicious.
+func rejectCallback(ctx context.Context, b TelegramAPI, callback *models.CallbackQuery, operation string, err error, expiredText string) {
+	tr := i18n.FromContext(ctx)
+	details := map[string]interface{}{
+		"callback_data": callback.Data,
+	}
+	text := expiredText
+	switch {
+	case errors.Is(err, ErrCallbackExpired):
+		LogWarning(ctx, operation, callback.From.ID, "expired callback data", details)
+	case errors.Is(err, ErrCallbackInvalid):
+		LogWarning(ctx, operation, callback.From.ID, "suspicious callback: invalid signature", details)
+		text = tr.T("❌ Invalid button.")
+	default:
+		LogError(ctx, operation, callback.From.ID, err, details)
+		text = tr.T(ErrResponseGeneric.Message)
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: callback.ID,
+		Text:            text,
+		ShowAlert:       true,
+	})
+}
+
+// splitCallbackToken splits callback data carrying a token into the kept head
+// of the original data and the token
+func splitCallbackToken(encoded string) (string, string, bool) {
+	i := strings.LastIndex(encoded, callbackTokenSeparator)
+	if i < 0 {
+		return "", "", false
+	}
+	head, token := encoded[:i], encoded[i+len(callbackTokenSeparator):]
+	if len(token) != callbackTokenLength || strings.Contains(token, callbackSignatureSeparator) {
+		return "", "", false
+	}
+	return head, token, true
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+func TestCallbackEncoderFallsBackToTokens(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_callbacks.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	now := time.Unix(1_700_000_000, 0)
+	encoder := NewCallbackEncoder(newTestSigner(time.Hour, &now), store)
+	encoder.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Data that fits stays signed inline
+	short := "page_sessions_5"
+	encoded, err := encoder.Encode(ctx, short)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if !strings.HasPrefix(encoded, short) {
+		t.Errorf("expected inline signed data, got %q", encoded)
+	}
+
+	long := "share_revoke_" + uuid.New().String() + "_confirm"
+	encoded, err = encoder.Encode(ctx, long)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if len(encoded) != maxCallbackDataBytes || !strings.HasPrefix(encoded, "share_revoke_") {
+		t.Fatalf("expected the head of the data and a token, got %q", encoded)
+	}
+	if got, err := encoder.Decode(ctx, encoded); err != nil || got != long {
+		t.Errorf("Decode = %q, %v, want %q", got, err, long)
+	}
+	if got := encoder.Payload(ctx, encoded); got != long {
+		t.Errorf("Payload = %q, want %q", got, long)
+	}
+
+	if _, err := encoder.Decode(ctx, "share_revoke_~AAAAAAAAAAAAAAAA"); !errors.Is(err, ErrCallbackExpired) {
+		t.Errorf("expected ErrCallbackExpired for an unknown token, got %v", err)
+	}
+
+	// A token is only valid behind the head it was issued with
+	_, token, _ := splitCallbackToken(encoded)
+	if _, err := encoder.Decode(ctx, "fb_res_~"+token); !errors.Is(err, ErrCallbackInvalid) {
+		t.Errorf("expected ErrCallbackInvalid for a moved token, got %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := encoder.Decode(ctx, encoded); !errors.Is(err, ErrCallbackExpired) {
+		t.Errorf("expected ErrCallbackExpired after the TTL, got %v", err)
+	}
+}
+
+func TestCallbackEncoderWithoutTokenStore(t *testing.T) {
+	encoder := NewCallbackEncoder(nil, nil)
+	ctx := context.Background()
+
+	long := strings.Repeat("x", maxCallbackDataBytes+1)
+	if _, err := encoder.Encode(ctx, long); !errors.Is(err, ErrCallbackDataTooLong) {
+		t.Errorf("expected ErrCallbackDataTooLong, got %v", err)
+	}
+	if _, err := encoder.Decode(ctx, "open_s_~AAAAAAAAAAAAAAAA"); !errors.Is(err, ErrCallbackInvalid) {
+		t.Errorf("expected ErrCallbackInvalid for a token, got %v", err)
+	}
+
+	// Keyboards keep data that cannot be encoded
+	markup := encoder.EncodeKeyboard(ctx, &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{{{Text: "Go", CallbackData: long}}},
+	})
+	if markup.InlineKeyboard[0][0].CallbackData != long {
+		t.Errorf("expected the data to be kept, got %q", markup.InlineKeyboard[0][0].CallbackData)
+	}
+}
+
+func TestCallbackQueryHandlerTellsTamperedFromExpired(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_callbacks.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+	signer := NewCallbackSigner("secret", time.Hour)
+	handler := CallbackQueryHandler(session.NewManager(store), store, &HandlerConfig{SessionsPerPage: 5, CallbackSigner: signer})
+	ctx := context.Background()
+
+	// The signature is checked before the TTL, so once the clock has moved past
+	// it the tampered data is still told apart from the expired one
+	expired := signer.Sign("page_sessions_5")
+	tampered := strings.Replace(expired, "page_sessions_5", "page_sessions_0", 1)
+	signer.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"tampered", tampered, "❌ Invalid button."},
+		{"expired", expired, "⌛ This menu expired. Send /sessions to get a fresh one."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := testutil.NewFakeTelegram()
+			handler(ctx, api, callbackUpdate(1, tt.data))
+			if len(api.CallbackAnswers) != 1 || api.CallbackAnswers[0].Text != tt.want {
+				t.Errorf("expected the answer %q, got %+v", tt.want, api.CallbackAnswers)
+			}
+		})
+	}
+}
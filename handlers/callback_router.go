@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// CallbackRequest is a verified callback query on its way to a CallbackFunc
+type CallbackRequest struct {
+	Query  *models.CallbackQuery
+	UserID int64
+	// Data is the verified callback data, without signature
+	Data string
+	// Param is the part of Data after the matched prefix; empty for exact routes
+	Param string
+}
+
+// Message returns the message of the pressed button, or nil when Telegram
+// no longer gives access to it
+func (r *CallbackRequest) Message() *models.Message {
+	return r.Query.Message.Message
+}
+
+// CallbackFunc handles one kind of callback button
+type CallbackFunc func(ctx context.Context, b TelegramAPI, req *CallbackRequest)
+
+// callbackRoute is a prefix registered on a CallbackRouter
+type callbackRoute struct {
+	prefix  string
+	handler CallbackFunc
+}
+
+// CallbackRouter dispatches callback data to the CallbackFunc registered for it,
+// either for the exact data or for a prefix followed by a parameter.
+// Exact routes win over prefixes, and longer prefixes win over shorter ones.
+type CallbackRouter struct {
+	exact    map[string]CallbackFunc
+	prefixes []callbackRoute
+}
+
+// NewCallbackRouter creates a router without routes
+func NewCallbackRouter() *CallbackRouter {
+	return &CallbackRouter{exact: make(map[string]CallbackFunc)}
+}
+
+// Handle registers handler for callback data equal to data.
+// It panics when data is empty or already registered.
+func (r *CallbackRouter) Handle(data string, handler CallbackFunc) {
+	if data == "" {
+		panic("handlers: empty callback route")
+	}
+	if _, exists := r.exact[data]; exists {
+		panic(fmt.Sprintf("handlers: callback route %q registered twice", data))
+	}
+	r.exact[data] = handler
+}
+
+// HandlePrefix registers handler for callback data starting with prefix; the
+// rest of the data is passed as CallbackRequest.Param.
+// It panics when prefix is empty or already registered.
+func (r *CallbackRouter) HandlePrefix(prefix string, handler CallbackFunc) {
+	if prefix == "" {
+		panic("handlers: empty callback prefix")
+	}
+	for _, route := range r.prefixes {
+		if route.prefix == prefix {
+			panic(fmt.Sprintf("handlers: callback prefix %q registered twice", prefix))
+		}
+	}
+	r.prefixes = append(r.prefixes, callbackRoute{prefix: prefix, handler: handler})
+	sort.SliceStable(r.prefixes, func(i, j int) bool {
+		return len(r.prefixes[i].prefix) > len(r.prefixes[j].prefix)
+	})
+}
+
+// Match returns the handler for data and the parameter it receives.
+// It reports false when no route matches.
+func (r *CallbackRouter) Match(data string) (CallbackFunc, string, bool) {
+	if handler, ok := r.exact[data]; ok {
+		return handler, "", true
+	}
+	for _, route := range r.prefixes {
+		if param, ok := strings.CutPrefix(data, route.prefix); ok {
+			return route.handler, param, true
+		}
+	}
+	return nil, "", false
+}
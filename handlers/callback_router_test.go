@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCallbackRouterMatch(t *testing.T) {
+	var routed string
+	route := func(name string) CallbackFunc {
+		return func(ctx context.Context, b TelegramAPI, req *CallbackRequest) { routed = name }
+	}
+
+	router := NewCallbackRouter()
+	router.HandlePrefix("file_", route("file"))
+	router.HandlePrefix("file_del_", route("delete"))
+	router.Handle("file_page", route("exact"))
+	router.Handle("noop", route("noop"))
+
+	tests := []struct {
+		data  string
+		route string
+		param string
+	}{
+		{"noop", "noop", ""},
+		{"file_info_42", "file", "info_42"},
+		{"file_del_42", "delete", "42"},
+		{"file_del_", "delete", ""},
+		{"file_page", "exact", ""},
+	}
+	for _, tt := range tests {
+		handler, param, ok := router.Match(tt.data)
+		if !ok {
+			t.Errorf("%s: expected a route", tt.data)
+			continue
+		}
+		handler(context.Background(), nil, &CallbackRequest{Data: tt.data, Param: param})
+		if routed != tt.route || param != tt.param {
+			t.Errorf("%s: routed to %q with %q, want %q with %q", tt.data, routed, param, tt.route, tt.param)
+		}
+	}
+
+	for _, data := range []string{"", "nope", "fil", "noop_1"} {
+		if _, _, ok := router.Match(data); ok {
+			t.Errorf("%q: expected no route", data)
+		}
+	}
+}
+
+func TestCallbackRouterRejectsDuplicates(t *testing.T) {
+	noop := func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {}
+	expectPanic := func(name string, register func(*CallbackRouter)) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: expected a panic", name)
+			}
+		}()
+		router := NewCallbackRouter()
+		router.Handle("noop", noop)
+		router.HandlePrefix("open_", noop)
+		register(router)
+	}
+
+	expectPanic("duplicate route", func(r *CallbackRouter) { r.Handle("noop", noop) })
+	expectPanic("duplicate prefix", func(r *CallbackRouter) { r.HandlePrefix("open_", noop) })
+	expectPanic("empty route", func(r *CallbackRouter) { r.Handle("", noop) })
+	expectPanic("empty prefix", func(r *CallbackRouter) { r.HandlePrefix("", noop) })
+}
+
+func TestSessionCallbackRouterRoutes(t *testing.T) {
+	router := sessionCallbackRouter(nil, nil, &HandlerConfig{})
+	for _, data := range []string{openSessionPrefix + "id", sessionsPagePrefix + "5", closeReopenCallback,
+		noopCallback, newSessionCallback, listSessionsCallback, selectModePrefix + "0", selectTogglePrefix + "id",
+		selectPageCallback, bulkArchivePrefix + "0", bulkDeletePrefix + "0", selectCancelPrefix + "0"} {
+		if _, _, ok := router.Match(data); !ok {
+			t.Errorf("expected a route for %q", data)
+		}
+	}
+}
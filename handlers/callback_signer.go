@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// callbackSignatureSeparator separates the payload, issue time and signature in signed callback data
+const callbackSignatureSeparator = "|"
+
+// callbackSignatureBytes is the truncated HMAC length; it keeps signed
+// "open_s_<uuid>" data within Telegram's 64-byte callback_data limit
+const callbackSignatureBytes = 8
+
+// Callback verification errors
+var (
+	ErrCallbackExpired = errors.New("callback data expired")
+	ErrCallbackInvalid = errors.New("callback data signature invalid")
+)
+
+// CallbackSigner signs inline keyboard callback data with an HMAC and an issue time,
+// so buttons cannot be forged and stop working after ttl.
+// A nil signer or an empty key disables signing.
+type CallbackSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewCallbackSigner creates a callback signer. It returns nil when key is empty.
+func NewCallbackSigner(key string, ttl time.Duration) *CallbackSigner {
+	if key == "" {
+		return nil
+	}
+	return &CallbackSigner{
+		key: []byte(key),
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// Sign returns data with its issue time and signature appended
+func (s *CallbackSigner) Sign(data string) string {
+	if s == nil {
+		return data
+	}
+	issued := strconv.FormatInt(s.now().Unix(), 36)
+	return data + callbackSignatureSeparator + issued + callbackSignatureSeparator + s.signature(data, issued)
+}
+
+// Verify checks signed callback data and returns the original payload
+func (s *CallbackSigner) Verify(signed string) (string, error) {
+	if s == nil {
+		return signed, nil
+	}
+
+	parts := strings.Split(signed, callbackSignatureSeparator)
+	if len(parts) != 3 {
+		return "", ErrCallbackInvalid
+	}
+	data, issued, signature := parts[0], parts[1], parts[2]
+
+	if !hmac.Equal([]byte(signature), []byte(s.signature(data, issued))) {
+		return "", ErrCallbackInvalid
+	}
+
+	issuedAt, err := strconv.ParseInt(issued, 36, 64)
+	if err != nil {
+		return "", ErrCallbackInvalid
+	}
+	if s.ttl > 0 && s.now().Sub(time.Unix(issuedAt, 0)) > s.ttl {
+		return "", ErrCallbackExpired
+	}
+
+	return data, nil
+}
+
+// Payload returns signed callback data without its issue time and signature.
+// It does not verify anything and is only meant for reading back the bot's own
+// keyboards, such as the markup of the message a button was pressed on.
+func (s *CallbackSigner) Payload(signed string) string {
+	if s == nil {
+		return signed
+	}
+	data, _, _ := strings.Cut(signed, callbackSignatureSeparator)
+	return data
+}
+
+// SignKeyboard signs the callback data of every button in markup
+func (s *CallbackSigner) SignKeyboard(markup *models.InlineKeyboardMarkup) *models.InlineKeyboardMarkup {
+	if s == nil || markup == nil {
+		return markup
+	}
+	for _, row := range markup.InlineKeyboard {
+		for i := range row {
+			if row[i].CallbackData != "" {
+				row[i].CallbackData = s.Sign(row[i].CallbackData)
+			}
+		}
+	}
+	return markup
+}
+
+func (s *CallbackSigner) signature(data, issued string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(data))
+	mac.Write([]byte(callbackSignatureSeparator))
+	mac.Write([]byte(issued))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignatureBytes])
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestSigner(ttl time.Duration, now *time.Time) *CallbackSigner {
+	signer := NewCallbackSigner("test-key", ttl)
+	signer.now = func() time.Time { return *now }
+	return signer
+}
+
+func TestCallbackSigner_RoundTrip(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := newTestSigner(time.Hour, &now)
+
+	data := "open_s_" + uuid.New().String()
+	signed := signer.Sign(data)
+	if len(signed) > 64 {
+		t.Fatalf("signed callback data is %d bytes, exceeds Telegram's 64-byte limit: %q", len(signed), signed)
+	}
+
+	got, err := signer.Verify(signed)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if got != data {
+		t.Errorf("expected %q, got %q", data, got)
+	}
+}
+
+func TestCallbackSigner_Rejects(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := newTestSigner(time.Hour, &now)
+	signed := signer.Sign("page_sessions_6")
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"unsigned data", "page_sessions_6", ErrCallbackInvalid},
+		{"tampered payload", strings.Replace(signed, "page_sessions_6", "page_sessions_7", 1), ErrCallbackInvalid},
+		{"tampered signature", signed[:len(signed)-1] + "A", ErrCallbackInvalid},
+		{"other key", NewCallbackSigner("other-key", time.Hour).Sign("page_sessions_6"), ErrCallbackInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := signer.Verify(tt.data); err != tt.wantErr {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Hour + time.Second)
+		if _, err := signer.Verify(signed); err != ErrCallbackExpired {
+			t.Errorf("expected ErrCallbackExpired, got %v", err)
+		}
+	})
+}
+
+func TestCallbackSigner_Disabled(t *testing.T) {
+	signer := NewCallbackSigner("", time.Hour)
+	if signer != nil {
+		t.Fatal("expected nil signer for empty key")
+	}
+
+	if got := signer.Sign("noop"); got != "noop" {
+		t.Errorf("expected unsigned data, got %q", got)
+	}
+	if got, err := signer.Verify("noop"); err != nil || got != "noop" {
+		t.Errorf("expected pass-through verify, got %q, %v", got, err)
+	}
+}
+
+func TestCallbackSigner_SignKeyboard(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := newTestSigner(time.Hour, &now)
+
+	keyboard := signer.SignKeyboard(buildQuickSwitchKeyboard(en))
+	for _, button := range keyboard.InlineKeyboard[0] {
+		data, err := signer.Verify(button.CallbackData)
+		if err != nil {
+			t.Fatalf("Verify failed for %q: %v", button.CallbackData, err)
+		}
+		if data != newSessionCallback && data != listSessionsCallback {
+			t.Errorf("unexpected payload %q", data)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// CodeFilesCallbackPrefix is the callback data of the button sending the code
+// blocks of an assistant reply as files, followed by the stored message ID
+const CodeFilesCallbackPrefix = "code_"
+
+// A reply gets the code files button when one of its code blocks has at least
+// codeFileMinLines lines or codeFileMinBytes bytes
+const (
+	codeFileMinLines = 15
+	codeFileMinBytes = 1000
+)
+
+// codeFilesButton returns the button sending the code blocks of the stored
+// reply answer as files, or nil when it has no large code block
+func codeFilesButton(tr *i18n.Translator, answer *session.Message) []models.InlineKeyboardButton {
+	blocks := format.CodeBlocks(answer.Content)
+	for _, block := range blocks {
+		if block.Lines() >= codeFileMinLines || len(block.Code) >= codeFileMinBytes {
+			label := tr.T("📄 Send code as file")
+			if len(blocks) > 1 {
+				label = tr.Sprintf("📄 Send %d code blocks as files", len(blocks))
+			}
+			return []models.InlineKeyboardButton{{Text: label, CallbackData: CodeFilesCallbackPrefix + answer.ID.String()}}
+		}
+	}
+	return nil
+}
+
+// CodeFilesCallbackHandler handles the code files button under assistant
+// replies. It sends every code block of the stored reply as a document named
+// after its language, so the code survives copying intact.
+func CodeFilesCallbackHandler(messageMgr *session.MessageManager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		userID := callback.From.ID
+		tr := i18n.FromContext(ctx)
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "code_files_callback", err, tr.T("⌛ This button expired."))
+			return
+		}
+
+		msg := callback.Message.Message
+		id, err := uuid.Parse(strings.TrimPrefix(data, CodeFilesCallbackPrefix))
+		if err != nil || msg == nil {
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+			return
+		}
+
+		// Only the user the reply was for may have its code sent again
+		answer, err := messageMgr.GetMessage(ctx, id)
+		if err == nil && (answer.UserID != userID || answer.Role != session.RoleAssistant) {
+			err = session.ErrMessageNotFound
+		}
+		if err != nil {
+			if !errors.Is(err, session.ErrMessageNotFound) {
+				LogError(ctx, "code_files_callback", userID, err, map[string]interface{}{"message_id": id.String()})
+			}
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: callback.ID,
+				Text:            tr.T("This reply is no longer available."),
+				ShowAlert:       true,
+			})
+			return
+		}
+
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+
+		blocks := format.CodeBlocks(answer.Content)
+		for i, block := range blocks {
+			if _, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+				ChatID:          msg.Chat.ID,
+				MessageThreadID: topicThreadID(msg),
+				Document: &models.InputFileUpload{
+					Filename: block.FileName(i + 1),
+					Data:     strings.NewReader(block.Code + "\n"),
+				},
+				ReplyParameters: &models.ReplyParameters{MessageID: msg.ID, AllowSendingWithoutReply: true},
+			}); err != nil {
+				LogError(ctx, "code_files_callback", userID, err, map[string]interface{}{"message_id": id.String()})
+				SendErrorResponse(ctx, b, msg, err)
+				return
+			}
+		}
+
+		LogInfo(ctx, "code_files_callback", userID, "code blocks sent as files", map[string]interface{}{
+			"message_id": id.String(),
+			"files":      len(blocks),
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestCodeFilesButton(t *testing.T) {
+	code := strings.Repeat("fmt.Println(\"hi\")\n", codeFileMinLines)
+	reply := "Here you go:\n\n```go\n" + code + "```\n\nAnd a config:\n\n```yaml\nkey: value\n```"
+	provider := &fakeProvider{replies: []*ai.Message{{Role: ai.RoleAssistant, Content: reply}}}
+	store, _, handler := newAssistantTest(t, provider)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, textUpdate(1, "Write me a program"))
+
+	markup, ok := api.Sent[len(api.Sent)-1].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	if !ok {
+		t.Fatal("expected a code files button under the reply")
+	}
+	callbacks := CodeFilesCallbackHandler(session.NewMessageManager(store), &HandlerConfig{})
+
+	callbacks(ctx, api, pressButton(t, 2, markup, "Send 2 code blocks as files"))
+	if len(api.Documents) != 0 || !strings.Contains(api.CallbackAnswers[0].Text, "no longer available") {
+		t.Fatal("expected other users to be unable to get the code")
+	}
+
+	callbacks(ctx, api, pressButton(t, 1, markup, "Send 2 code blocks as files"))
+	if len(api.Documents) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(api.Documents))
+	}
+	upload := api.Documents[0].Params.Document.(*models.InputFileUpload)
+	if upload.Filename != "snippet-1.go" || string(api.Documents[0].Content) != code {
+		t.Errorf("expected the Go code as snippet-1.go, got %s:\n%s", upload.Filename, api.Documents[0].Content)
+	}
+	if upload := api.Documents[1].Params.Document.(*models.InputFileUpload); upload.Filename != "snippet-2.yaml" {
+		t.Errorf("expected snippet-2.yaml, got %s", upload.Filename)
+	}
+}
+
+func TestCodeFilesButtonOnlyForLargeCode(t *testing.T) {
+	provider := &fakeProvider{replies: []*ai.Message{{Role: ai.RoleAssistant, Content: "Try:\n\n```sh\nls -la\n```"}}}
+	_, _, handler := newAssistantTest(t, provider)
+	api := testutil.NewFakeTelegram()
+
+	handler(context.Background(), api, textUpdate(1, "How do I list files?"))
+
+	markup := api.Sent[len(api.Sent)-1].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	for _, row := range markup.InlineKeyboard {
+		if strings.HasPrefix(row[0].CallbackData, CodeFilesCallbackPrefix) {
+			t.Errorf("expected no code files button for a short snippet, got %+v", markup)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"tg-bot-demo/tracing"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// Command is a parsed bot command such as `/rename@my_bot "Trip to Rome"`
+type Command struct {
+	// Name is the command word including the slash, without any "@botname" suffix
+	Name string
+	// Mention is the bot username the command was addressed to, if any
+	Mention string
+	// Args are the arguments split on whitespace; quoted arguments may contain spaces
+	Args []string
+	// RawArgs is the unparsed text after the command word
+	RawArgs string
+}
+
+// ParseCommand parses text as a bot command. It reports false when text does
+// not start with a command word.
+//
+// Arguments are separated by whitespace. Double quotes, single quotes and the
+// typographic quotes some Telegram clients insert group an argument that
+// contains spaces; a backslash outside single quotes escapes the next
+// character. An unterminated quote runs to the end of the text.
+func ParseCommand(text string) (*Command, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return nil, false
+	}
+
+	// The command word ends at any whitespace, e.g. the newline of "/feedback\nlong text"
+	word, rest := text, ""
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		word, rest = text[:i], strings.TrimSpace(text[i:])
+	}
+	name, mention, _ := strings.Cut(word, "@")
+	if name == "/" {
+		return nil, false
+	}
+
+	return &Command{Name: name, Mention: mention, Args: splitArgs(rest), RawArgs: rest}, true
+}
+
+// closingQuotes maps each opening quote to the quote that ends it
+var closingQuotes = map[rune]rune{'"': '"', '\'': '\'', '“': '”', '‘': '’'}
+
+// splitArgs splits command arguments on whitespace, honoring quotes and escapes
+func splitArgs(s string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		closing rune // closing quote of the open quote; 0 outside quotes
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && closing != '\'' && closing != '’':
+			escaped, inArg = true, true
+		case closing != 0:
+			if r == closing {
+				closing = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case closingQuotes[r] != 0:
+			closing, inArg = closingQuotes[r], true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// commandName returns the command word of text without any "@botname" suffix,
+// or "" when text is not a command
+func commandName(text string) string {
+	if cmd, ok := ParseCommand(text); ok {
+		return cmd.Name
+	}
+	return ""
+}
+
+type commandContextKey struct{}
+
+// WithCommand returns a context carrying the parsed command of the update
+func WithCommand(ctx context.Context, cmd *Command) context.Context {
+	return context.WithValue(ctx, commandContextKey{}, cmd)
+}
+
+// messageCommand returns the command parsed by the CommandDispatcher, or parses
+// msg when the handler was called directly. Text that is not a command yields
+// an empty Command.
+func messageCommand(ctx context.Context, msg *models.Message) *Command {
+	if cmd, ok := ctx.Value(commandContextKey{}).(*Command); ok && cmd != nil {
+		return cmd
+	}
+	if cmd, ok := ParseCommand(msg.Text); ok {
+		return cmd
+	}
+	return &Command{}
+}
+
+// commandArgs returns the parsed arguments of the command in msg
+func commandArgs(ctx context.Context, msg *models.Message) []string {
+	return messageCommand(ctx, msg).Args
+}
+
+// CommandDispatcher routes text commands to the handlers registered for them.
+// It matches commands followed by arguments and commands addressed to this bot
+// with an "@botname" suffix; commands addressed to other bots in a group are
+// left alone. Handlers get the parsed command through their context.
+type CommandDispatcher struct {
+	botUsername string
+	commands    map[string]HandlerFunc
+}
+
+// NewCommandDispatcher creates a dispatcher for the bot with the given username.
+// With an empty username every "@botname" suffix is accepted.
+func NewCommandDispatcher(botUsername string) *CommandDispatcher {
+	return &CommandDispatcher{
+		botUsername: strings.TrimPrefix(botUsername, "@"),
+		commands:    make(map[string]HandlerFunc),
+	}
+}
+
+// Handle registers handler for the command name, such as "/rename".
+// It panics when name is not a command or is already registered.
+func (d *CommandDispatcher) Handle(name string, handler HandlerFunc) {
+	if cmd, ok := ParseCommand(name); !ok || cmd.Name != name || cmd.Mention != "" {
+		panic(fmt.Sprintf("handlers: invalid command name %q", name))
+	}
+	if _, exists := d.commands[name]; exists {
+		panic(fmt.Sprintf("handlers: command %q registered twice", name))
+	}
+	d.commands[name] = handler
+}
+
+// Match reports whether update carries a registered command for this bot
+func (d *CommandDispatcher) Match(update *models.Update) bool {
+	_, handler := d.route(update)
+	return handler != nil
+}
+
+// Handler returns the handler dispatching matched updates; each command gets
+// its own span named after it
+func (d *CommandDispatcher) Handler() HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		cmd, handler := d.route(update)
+		if handler == nil {
+			return
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, "handler "+cmd.Name)
+		defer span.End()
+
+		handler(WithCommand(ctx, cmd), b, update)
+	}
+}
+
+// route parses the command of update and looks up its handler
+func (d *CommandDispatcher) route(update *models.Update) (*Command, HandlerFunc) {
+	if update.Message == nil {
+		return nil, nil
+	}
+	cmd, ok := ParseCommand(update.Message.Text)
+	if !ok {
+		return nil, nil
+	}
+	if cmd.Mention != "" && d.botUsername != "" && !strings.EqualFold(cmd.Mention, d.botUsername) {
+		return nil, nil
+	}
+	return cmd, d.commands[cmd.Name]
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text    string
+		name    string
+		mention string
+		args    []string
+		raw     string
+	}{
+		{"/admin", "/admin", "", nil, ""},
+		{"/admin  cleanup   now ", "/admin", "", []string{"cleanup", "now"}, "cleanup   now"},
+		{"/files@my_bot", "/files", "my_bot", nil, ""},
+		{`/rename@my_bot "Trip to Rome"`, "/rename", "my_bot", []string{"Trip to Rome"}, `"Trip to Rome"`},
+		{"/rename My title", "/rename", "", []string{"My", "title"}, "My title"},
+		{`/x 'a "b"' c\ d "e \"f\""`, "/x", "", []string{`a "b"`, "c d", `e "f"`}, `'a "b"' c\ d "e \"f\""`},
+		{"/x “smart quotes” ‘too’", "/x", "", []string{"smart quotes", "too"}, "“smart quotes” ‘too’"},
+		{`/x "" end`, "/x", "", []string{"", "end"}, `"" end`},
+		{`/x "unterminated quote`, "/x", "", []string{"unterminated quote"}, `"unterminated quote`},
+		{"/feedback\nline one\nline two", "/feedback", "", []string{"line", "one", "line", "two"}, "line one\nline two"},
+	}
+
+	for _, tt := range tests {
+		cmd, ok := ParseCommand(tt.text)
+		if !ok {
+			t.Errorf("ParseCommand(%q) did not parse", tt.text)
+			continue
+		}
+		if cmd.Name != tt.name || cmd.Mention != tt.mention || !slices.Equal(cmd.Args, tt.args) || cmd.RawArgs != tt.raw {
+			t.Errorf("ParseCommand(%q) = %+v, want %s @%s %q %q", tt.text, cmd, tt.name, tt.mention, tt.args, tt.raw)
+		}
+	}
+
+	for _, text := range []string{"", "admin", "hello /cancel", "/", "/ x"} {
+		if cmd, ok := ParseCommand(text); ok {
+			t.Errorf("ParseCommand(%q) = %+v, want no command", text, cmd)
+		}
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	tests := map[string]string{
+		"/cancel":       "/cancel",
+		"/delete files": "/delete",
+		"/files@my_bot": "/files",
+		"hello /cancel": "",
+		"":              "",
+	}
+
+	for text, want := range tests {
+		if got := commandName(text); got != want {
+			t.Errorf("commandName(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestCommandDispatcherMatch(t *testing.T) {
+	commands := NewCommandDispatcher("@my_bot")
+	commands.Handle("/admin", func(ctx context.Context, b TelegramAPI, update *models.Update) {})
+
+	tests := []struct {
+		text     string
+		expected bool
+	}{
+		{"/admin", true},
+		{"/admin cleanup", true},
+		{"/admin@my_bot cleanup", true},
+		{"/admin@My_Bot", true},
+		{"/admin@other_bot", false},
+		{"/administrator", false},
+		{"admin", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		update := &models.Update{Message: &models.Message{Text: tt.text}}
+		if result := commands.Match(update); result != tt.expected {
+			t.Errorf("Match(%q) = %v, want %v", tt.text, result, tt.expected)
+		}
+	}
+
+	if commands.Match(&models.Update{}) {
+		t.Error("expected no match for update without message")
+	}
+	anyBot := NewCommandDispatcher("")
+	anyBot.Handle("/admin", func(ctx context.Context, b TelegramAPI, update *models.Update) {})
+	if !anyBot.Match(&models.Update{Message: &models.Message{Text: "/admin@any_bot"}}) {
+		t.Error("expected any mention to match without a configured username")
+	}
+}
+
+func TestCommandDispatcherPassesArgs(t *testing.T) {
+	var got []string
+	commands := NewCommandDispatcher("")
+	commands.Handle("/rename", func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		got = commandArgs(ctx, update.Message)
+	})
+
+	commands.Handler()(context.Background(), testutil.NewFakeTelegram(), commandUpdate(1, `/rename "Trip to Rome"`))
+	if !slices.Equal(got, []string{"Trip to Rome"}) {
+		t.Errorf("expected the quoted title as one argument, got %q", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a duplicate command")
+		}
+	}()
+	commands.Handle("/rename", func(ctx context.Context, b TelegramAPI, update *models.Update) {})
+}
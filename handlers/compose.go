@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// ComposeCommandHandler handles the /compose command. It starts a draft that
+// collects the user's next messages instead of answering them, until /send
+// sends them as one message; /compose cancel discards the draft.
+func ComposeCommandHandler(drafts session.DraftStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+		reply := func(text string) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+			})
+		}
+		// confirm runs write and confirms it with text
+		confirm := func(text string, write func(ctx context.Context) error) error {
+			return cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+				if err := write(ctx); err != nil {
+					return nil, err
+				}
+				return &bot.SendMessageParams{
+					ChatID:          update.Message.Chat.ID,
+					MessageThreadID: topicThreadID(update.Message),
+					Text:            text,
+				}, nil
+			})
+		}
+		fail := func(err error) {
+			LogError(ctx, "compose_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+		}
+
+		cmd := messageCommand(ctx, update.Message)
+		var subcommand string
+		if len(cmd.Args) > 0 {
+			subcommand = strings.ToLower(cmd.Args[0])
+		}
+
+		switch subcommand {
+		case "":
+			now := time.Now()
+			draft := &session.Draft{UserID: userID, ChatID: update.Message.Chat.ID, StartedAt: now, UpdatedAt: now}
+			err := confirm(tr.T("📝 Compose mode: your next messages are collected instead of answered. Send /send to send them as one message, or /compose cancel to discard them."),
+				func(ctx context.Context) error { return drafts.StartDraft(ctx, draft) })
+			if errors.Is(err, session.ErrDraftExists) {
+				draft, err := drafts.GetDraft(ctx, userID)
+				if err != nil {
+					fail(err)
+					return
+				}
+				reply(tr.Sprintf("📝 You are composing already: %d message(s) so far. /send sends them, /compose cancel discards them.", len(draft.Parts)))
+				return
+			}
+			if err != nil {
+				fail(err)
+				return
+			}
+			LogInfo(ctx, "compose_command", userID, "draft started", nil)
+
+		case "cancel":
+			err := confirm(tr.T("🗑 Draft discarded."), func(ctx context.Context) error {
+				return drafts.DeleteDraft(ctx, userID)
+			})
+			if errors.Is(err, session.ErrDraftNotFound) {
+				reply(tr.T("You are not composing a message."))
+				return
+			}
+			if err != nil {
+				fail(err)
+				return
+			}
+			LogInfo(ctx, "compose_command", userID, "draft discarded", nil)
+
+		default:
+			reply(tr.T("Usage: /compose [cancel]"))
+		}
+	}
+}
+
+// SendCommandHandler handles the /send command. It ends the user's draft and
+// hands its messages, joined into one, to messageHandler as if the user had
+// sent them in a single message.
+func SendCommandHandler(drafts session.DraftStore, messageHandler HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+		reply := func(text string) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+			})
+		}
+
+		draft, err := drafts.GetDraft(ctx, userID)
+		if errors.Is(err, session.ErrDraftNotFound) {
+			reply(tr.T("Nothing to send. Start a draft with /compose."))
+			return
+		}
+		if err == nil && draft.ChatID == update.Message.Chat.ID && len(draft.Parts) > 0 {
+			err = drafts.DeleteDraft(ctx, userID)
+		}
+		if err != nil {
+			LogError(ctx, "send_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+		if draft.ChatID != update.Message.Chat.ID {
+			reply(tr.T("Your draft was started in another chat; send /send there."))
+			return
+		}
+		if len(draft.Parts) == 0 {
+			reply(tr.T("Your draft is empty. Write your messages first, or discard it with /compose cancel."))
+			return
+		}
+
+		LogInfo(ctx, "send_command", userID, "draft sent", map[string]interface{}{
+			"parts":       len(draft.Parts),
+			"text_length": len(draft.Text()),
+		})
+		message := *update.Message
+		message.Text = draft.Text()
+		message.Entities = nil
+		messageHandler(ctx, b, &models.Update{ID: update.ID, Message: &message})
+	}
+}
+
+// DraftMatch returns a match function for plain text messages of users
+// composing a draft in the chat. Commands are never matched.
+func DraftMatch(drafts session.DraftStore) func(update *models.Update) bool {
+	return func(update *models.Update) bool {
+		message := update.Message
+		if message == nil || message.From == nil || message.Text == "" || strings.HasPrefix(message.Text, "/") {
+			return false
+		}
+		draft, err := drafts.GetDraft(context.Background(), message.From.ID)
+		return err == nil && draft.ChatID == message.Chat.ID
+	}
+}
+
+// DraftMessageHandler adds a text message to the sender's draft
+func DraftMessageHandler(drafts session.DraftStore) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+
+		count, err := drafts.AppendDraftPart(ctx, userID, update.Message.Text, time.Now())
+		text := tr.Sprintf("📝 Added to the draft (%d message(s)). /send sends it.", count)
+		if errors.Is(err, session.ErrDraftFull) {
+			text = tr.Sprintf("The draft is full at %d messages. Send it with /send.", session.MaxDraftParts)
+		} else if err != nil {
+			LogError(ctx, "draft_message", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            text,
+		})
+	}
+}
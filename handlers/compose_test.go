@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/testutil"
+)
+
+func TestComposeAndSend(t *testing.T) {
+	provider := &fakeProvider{replies: []*ai.Message{{Role: ai.RoleAssistant, Content: "Got both parts."}}}
+	store, _, messageHandler := newAssistantTest(t, provider)
+	compose := ComposeCommandHandler(store, &HandlerConfig{})
+	send := SendCommandHandler(store, messageHandler)
+	draftMessage := DraftMessageHandler(store)
+	match := DraftMatch(store)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	send(ctx, api, commandUpdate(1, "/send"))
+	if !strings.Contains(api.LastText(), "Nothing to send") {
+		t.Fatalf("expected a nothing to send notice, got %q", api.LastText())
+	}
+	if match(textUpdate(1, "Hello")) {
+		t.Fatal("expected no match without a draft")
+	}
+
+	compose(ctx, api, commandUpdate(1, "/compose"))
+	if !strings.Contains(api.LastText(), "Compose mode") {
+		t.Fatalf("expected compose mode to start, got %q", api.LastText())
+	}
+	send(ctx, api, commandUpdate(1, "/send"))
+	if !strings.Contains(api.LastText(), "draft is empty") {
+		t.Errorf("expected an empty draft notice, got %q", api.LastText())
+	}
+	if !match(textUpdate(1, "Part one")) || match(textUpdate(1, "/help")) || match(textUpdate(2, "Part one")) {
+		t.Error("expected only the composing user's plain text to match")
+	}
+
+	draftMessage(ctx, api, textUpdate(1, "Part one"))
+	draftMessage(ctx, api, textUpdate(1, "Part two"))
+	if !strings.Contains(api.LastText(), "(2 message(s))") {
+		t.Errorf("expected the part count, got %q", api.LastText())
+	}
+	compose(ctx, api, commandUpdate(1, "/compose"))
+	if !strings.Contains(api.LastText(), "2 message(s) so far") {
+		t.Errorf("expected the existing draft reported, got %q", api.LastText())
+	}
+	if len(provider.requests) != 0 {
+		t.Fatal("expected no request before /send")
+	}
+
+	send(ctx, api, commandUpdate(1, "/send"))
+	if len(provider.requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(provider.requests))
+	}
+	history := provider.requests[0].Messages
+	if got := history[len(history)-1].Content; got != "Part one\n\nPart two" {
+		t.Errorf("expected the joined draft, got %q", got)
+	}
+	if api.LastText() != "Got both parts." {
+		t.Errorf("expected the assistant's reply, got %q", api.LastText())
+	}
+	if match(textUpdate(1, "After")) {
+		t.Error("expected the draft to end on /send")
+	}
+}
+
+func TestComposeCancel(t *testing.T) {
+	store, _, _ := newAssistantTest(t, &fakeProvider{})
+	compose := ComposeCommandHandler(store, &HandlerConfig{})
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	compose(ctx, api, commandUpdate(1, "/compose cancel"))
+	if !strings.Contains(api.LastText(), "not composing") {
+		t.Errorf("expected a not composing notice, got %q", api.LastText())
+	}
+	compose(ctx, api, commandUpdate(1, "/compose"))
+	DraftMessageHandler(store)(ctx, api, textUpdate(1, "Part one"))
+	compose(ctx, api, commandUpdate(1, "/compose cancel"))
+	if !strings.Contains(api.LastText(), "Draft discarded") {
+		t.Errorf("expected the draft discarded, got %q", api.LastText())
+	}
+	if DraftMatch(store)(textUpdate(1, "Hello")) {
+		t.Error("expected no draft after cancel")
+	}
+	compose(ctx, api, commandUpdate(1, "/compose later"))
+	if !strings.Contains(api.LastText(), "Usage") {
+		t.Errorf("expected usage, got %q", api.LastText())
+	}
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// StepResult tells the conversation framework how to continue after a step
+type StepResult struct {
+	Reply string // HTML text sent back to the user; empty sends nothing
+	Next  string // step expecting the next message; empty ends the flow
+}
+
+// StepFunc interprets the user's reply for one step of a flow
+type StepFunc func(ctx context.Context, state *session.ConversationState, text string) (StepResult, error)
+
+// Flow is a named multi-step conversation, such as renaming a session
+type Flow struct {
+	Name  string
+	Steps map[string]StepFunc
+}
+
+// Conversations keeps per-user flow state in the store and routes the
+// user's next plain text message to the current step of their flow.
+type Conversations struct {
+	store   session.StateStore
+	timeout time.Duration
+	flows   map[string]*Flow
+	now     func() time.Time
+}
+
+// NewConversations creates a conversation framework.
+// States expire after timeout; zero keeps them until the flow ends or is cancelled.
+func NewConversations(store session.StateStore, timeout time.Duration) *Conversations {
+	return &Conversations{
+		store:   store,
+		timeout: timeout,
+		flows:   make(map[string]*Flow),
+		now:     time.Now,
+	}
+}
+
+// Register adds a flow that can be started with Start
+func (c *Conversations) Register(flow *Flow) {
+	c.flows[flow.Name] = flow
+}
+
+// Start puts a user into step of flow, replacing any flow they were in
+func (c *Conversations) Start(ctx context.Context, userID, chatID int64, flow, step string, data map[string]string) error {
+	if _, ok := c.flows[flow]; !ok {
+		return fmt.Errorf("unknown conversation flow: %s", flow)
+	}
+	if data == nil {
+		data = make(map[string]string)
+	}
+
+	state := &session.ConversationState{
+		UserID: userID,
+		ChatID: chatID,
+		Flow:   flow,
+		Step:   step,
+		Data:   data,
+	}
+	c.touch(state)
+	return c.store.SaveState(ctx, state)
+}
+
+// touch refreshes the state's update time and pushes back its expiry
+func (c *Conversations) touch(state *session.ConversationState) {
+	state.UpdatedAt = c.now()
+	state.ExpiresAt = time.Time{}
+	if c.timeout > 0 {
+		state.ExpiresAt = state.UpdatedAt.Add(c.timeout)
+	}
+}
+
+// Active returns the user's unexpired state, or session.ErrStateNotFound.
+// Expired states are removed.
+func (c *Conversations) Active(ctx context.Context, userID int64) (*session.ConversationState, error) {
+	state, err := c.store.GetState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Expired(c.now()) {
+		if err := c.store.ClearState(ctx, userID); err != nil {
+			return nil, err
+		}
+		return nil, session.ErrStateNotFound
+	}
+
+	return state, nil
+}
+
+// Cancel ends the user's flow. It reports whether a flow was active.
+func (c *Conversations) Cancel(ctx context.Context, userID int64) (*session.ConversationState, bool, error) {
+	state, err := c.Active(ctx, userID)
+	if errors.Is(err, session.ErrStateNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := c.store.ClearState(ctx, userID); err != nil {
+		return nil, false, err
+	}
+	return state, true, nil
+}
+
+// Match reports whether an update is a plain text reply from a user in a flow.
+// Commands are never matched so they keep working during a flow.
+func (c *Conversations) Match(update *models.Update) bool {
+	if update.Message == nil || update.Message.From == nil || update.Message.Text == "" {
+		return false
+	}
+	if strings.HasPrefix(update.Message.Text, "/") {
+		return false
+	}
+
+	_, err := c.Active(context.Background(), update.Message.From.ID)
+	return err == nil
+}
+
+// Handler runs the current step of the user's flow with the message text.
+// The reply of the step that ends a flow confirms what it stored, so it is
+// queued with the step's writes through cfg.confirmWrite.
+func (c *Conversations) Handler(cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		chatID := update.Message.Chat.ID
+
+		state, err := c.Active(ctx, userID)
+		if err != nil {
+			LogError(ctx, "conversation", userID, err, nil)
+			return
+		}
+
+		step, ok := c.lookupStep(state)
+		if !ok {
+			LogWarning(ctx, "conversation", userID, "unknown conversation step, clearing state", map[string]interface{}{
+				"flow": state.Flow,
+				"step": state.Step,
+			})
+			c.store.ClearState(ctx, userID)
+			return
+		}
+
+		currentStep := state.Step
+		var result StepResult
+		err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			var err error
+			if result, err = step(ctx, state, update.Message.Text); err != nil {
+				return nil, err
+			}
+
+			if result.Next == "" {
+				err = c.store.ClearState(ctx, userID)
+			} else {
+				state.Step = result.Next
+				c.touch(state)
+				err = c.store.SaveState(ctx, state)
+			}
+			if err != nil {
+				LogError(ctx, "conversation", userID, err, map[string]interface{}{
+					"flow": state.Flow,
+				})
+			}
+
+			if result.Next != "" || result.Reply == "" {
+				return nil, nil
+			}
+			return &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            result.Reply,
+				ParseMode:       models.ParseModeHTML,
+			}, nil
+		})
+		if err != nil {
+			LogError(ctx, "conversation", userID, err, map[string]interface{}{
+				"flow": state.Flow,
+				"step": currentStep,
+			})
+			c.store.ClearState(ctx, userID)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "conversation", userID, "conversation step completed", map[string]interface{}{
+			"flow":      state.Flow,
+			"next_step": result.Next,
+		})
+
+		// Replies asking for the next step confirm nothing stored
+		if result.Next != "" && result.Reply != "" {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            result.Reply,
+				ParseMode:       models.ParseModeHTML,
+			})
+		}
+	}
+}
+
+func (c *Conversations) lookupStep(state *session.ConversationState) (StepFunc, bool) {
+	flow, ok := c.flows[state.Flow]
+	if !ok {
+		return nil, false
+	}
+	step, ok := flow.Steps[state.Step]
+	return step, ok
+}
+
+// cancelCommand ends the current flow; it is the only command that does not abort one implicitly
+const cancelCommand = "/cancel"
+
+// AbortOnCommand is a bot middleware that ends the user's pending flow when they
+// send any command other than /cancel, so a stale prompt does not capture later replies.
+func (c *Conversations) AbortOnCommand(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		if update.Message != nil && update.Message.From != nil {
+			if name := commandName(update.Message.Text); name != "" && name != cancelCommand {
+				c.abort(ctx, update.Message.From.ID, name)
+			}
+		}
+		next(ctx, b, update)
+	}
+}
+
+func (c *Conversations) abort(ctx context.Context, userID int64, command string) {
+	state, cancelled, err := c.Cancel(ctx, userID)
+	if err != nil {
+		LogError(ctx, "conversation", userID, err, map[string]interface{}{
+			"command": command,
+		})
+		return
+	}
+	if cancelled {
+		LogInfo(ctx, "conversation", userID, "conversation aborted by command", map[string]interface{}{
+			"flow":    state.Flow,
+			"step":    state.Step,
+			"command": command,
+		})
+	}
+}
+
+// CancelCommandHandler handles the /cancel command.
+// It ends the user's current conversation flow.
+func CancelCommandHandler(conversations *Conversations) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+
+		state, cancelled, err := conversations.Cancel(ctx, userID)
+		if err != nil {
+			LogError(ctx, "cancel_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		text := tr.T("Nothing to cancel.")
+		if cancelled {
+			LogInfo(ctx, "cancel_command", userID, "conversation cancelled", map[string]interface{}{
+				"flow": state.Flow,
+				"step": state.Step,
+			})
+			text = tr.Sprintf("❎ Cancelled the pending %s prompt.", tr.T(state.Flow))
+		}
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            text,
+		})
+	}
+}
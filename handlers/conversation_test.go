@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func newTestConversations(t *testing.T) (*Conversations, *session.SQLiteStore) {
+	t.Helper()
+
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_conversations.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return NewConversations(store, 10*time.Minute), store
+}
+
+func textUpdate(userID int64, text string) *models.Update {
+	return &models.Update{Message: &models.Message{
+		From: &models.User{ID: userID},
+		Chat: models.Chat{ID: userID},
+		Text: text,
+	}}
+}
+
+func TestConversations_StartMatchCancel(t *testing.T) {
+	conversations, _ := newTestConversations(t)
+	conversations.Register(&Flow{Name: "test", Steps: map[string]StepFunc{}})
+	ctx := context.Background()
+
+	if conversations.Match(textUpdate(1, "hello")) {
+		t.Error("expected no match before a flow starts")
+	}
+
+	if err := conversations.Start(ctx, 1, 1, "unknown", "step", nil); err == nil {
+		t.Error("expected error starting an unregistered flow")
+	}
+	if err := conversations.Start(ctx, 1, 1, "test", "step", nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if !conversations.Match(textUpdate(1, "hello")) {
+		t.Error("expected plain text from the user to match")
+	}
+	if conversations.Match(textUpdate(1, "/sessions")) {
+		t.Error("expected commands not to match")
+	}
+	if conversations.Match(textUpdate(2, "hello")) {
+		t.Error("expected other users not to match")
+	}
+
+	state, cancelled, err := conversations.Cancel(ctx, 1)
+	if err != nil || !cancelled || state.Flow != "test" {
+		t.Fatalf("expected flow to be cancelled, got state=%+v cancelled=%v err=%v", state, cancelled, err)
+	}
+	if _, cancelled, _ := conversations.Cancel(ctx, 1); cancelled {
+		t.Error("expected nothing to cancel the second time")
+	}
+}
+
+func TestConversations_ExpiredStateIsCleared(t *testing.T) {
+	conversations, store := newTestConversations(t)
+	conversations.Register(&Flow{Name: "test", Steps: map[string]StepFunc{}})
+	ctx := context.Background()
+
+	if err := conversations.Start(ctx, 1, 1, "test", "step", nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	conversations.now = func() time.Time { return time.Now().Add(11 * time.Minute) }
+
+	if conversations.Match(textUpdate(1, "late reply")) {
+		t.Error("expected expired flow not to match")
+	}
+	if _, err := store.GetState(ctx, 1); !errors.Is(err, session.ErrStateNotFound) {
+		t.Errorf("expected expired state to be removed, got %v", err)
+	}
+}
+
+func TestRenameFlow(t *testing.T) {
+	_, store := newTestConversations(t)
+	sessionMgr := session.NewManager(store)
+	ctx := context.Background()
+
+	sess, err := sessionMgr.CreateSession(ctx, 1, "original")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	step := RenameFlow(sessionMgr).Steps[renameStepTitle]
+	state := &session.ConversationState{
+		UserID: 1,
+		Flow:   renameFlow,
+		Step:   renameStepTitle,
+		Data:   map[string]string{"session_id": sess.ID.String()},
+	}
+
+	result, err := step(ctx, state, "  ")
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if result.Next != renameStepTitle {
+		t.Errorf("expected an empty title to ask again, got next step %q", result.Next)
+	}
+
+	result, err = step(ctx, state, "Trip planning")
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if result.Next != "" {
+		t.Errorf("expected flow to end, got next step %q", result.Next)
+	}
+
+	got, err := store.Get(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Title != "Trip planning" {
+		t.Errorf("expected renamed title, got %q", got.Title)
+	}
+}
+
+func TestRenameCommandHandler(t *testing.T) {
+	conversations, store := newTestConversations(t)
+	sessionMgr := session.NewManager(store)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+	conversations.Register(RenameFlow(sessionMgr))
+	rename := RenameCommandHandler(sessionMgr, conversations, &HandlerConfig{})
+
+	sess, err := sessionMgr.CreateSession(ctx, 1, "original")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	rename(ctx, api, commandUpdate(1, `/rename@my_bot "Trip to Rome"`))
+	if got, _ := store.Get(ctx, sess.ID); got.Title != "Trip to Rome" {
+		t.Errorf("expected the quoted title, got %q", got.Title)
+	}
+	if _, err := conversations.Active(ctx, 1); !errors.Is(err, session.ErrStateNotFound) {
+		t.Errorf("expected no rename flow when a title is given, got %v", err)
+	}
+
+	rename(ctx, api, commandUpdate(1, "/rename"))
+	if !strings.Contains(api.LastText(), "Send the new title") {
+		t.Errorf("expected the rename flow prompt, got %q", api.LastText())
+	}
+	if _, err := conversations.Active(ctx, 1); err != nil {
+		t.Errorf("expected a rename flow without a title, got %v", err)
+	}
+}
+
+func TestConversations_AbortOnCommand(t *testing.T) {
+	conversations, _ := newTestConversations(t)
+	conversations.Register(&Flow{Name: "test", Steps: map[string]StepFunc{}})
+	ctx := context.Background()
+
+	calls := 0
+	handler := conversations.AbortOnCommand(func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		calls++
+	})
+
+	tests := []struct {
+		text    string
+		aborted bool
+	}{
+		{"plain reply", false},
+		{"/cancel", false},
+		{"/cancel@my_bot", false},
+		{"/sessions", true},
+		{"/files@my_bot", true},
+	}
+
+	for _, tt := range tests {
+		if err := conversations.Start(ctx, 1, 1, "test", "step", nil); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+
+		handler(ctx, nil, textUpdate(1, tt.text))
+
+		_, err := conversations.Active(ctx, 1)
+		if aborted := errors.Is(err, session.ErrStateNotFound); aborted != tt.aborted {
+			t.Errorf("%q: aborted = %v, want %v (err: %v)", tt.text, aborted, tt.aborted, err)
+		}
+	}
+
+	if calls != len(tests) {
+		t.Errorf("expected next handler to run for every update, got %d calls", calls)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/ratelimit"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// CommandCooldownMiddleware is a bot middleware that rejects commands used
+// again before their configured cooldown has passed, telling the user how long
+// to wait. Administrators are exempt.
+func CommandCooldownMiddleware(cooldowns *ratelimit.Cooldowns, cfg *HandlerConfig) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if rejectOnCooldown(ctx, b, cooldowns, cfg, update) {
+				return
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// rejectOnCooldown reports whether update is a command its sender has to wait
+// for, replying with the time left
+func rejectOnCooldown(ctx context.Context, b TelegramAPI, cooldowns *ratelimit.Cooldowns, cfg *HandlerConfig, update *models.Update) bool {
+	message := update.Message
+	if message == nil || message.From == nil || isAdmin(cfg, message.From.ID) {
+		return false
+	}
+	cmd, ok := ParseCommand(message.Text)
+	if !ok {
+		return false
+	}
+
+	allowed, wait := cooldowns.Allow(message.From.ID, cmd.Name)
+	if allowed {
+		return false
+	}
+
+	LogInfo(ctx, "command_cooldown", message.From.ID, "command on cooldown", map[string]interface{}{
+		"command":           cmd.Name,
+		"wait_seconds_left": int(wait.Seconds()),
+	})
+
+	tr := i18n.FromContext(ctx)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          message.Chat.ID,
+		MessageThreadID: topicThreadID(message),
+		Text:            tr.Sprintf("⏳ You can use %s again in %s.", cmd.Name, formatWait(tr, wait)),
+	})
+	return true
+}
+
+// formatWait renders a wait time rounded up to whole seconds, e.g. "4 min 30 s"
+func formatWait(tr *i18n.Translator, wait time.Duration) string {
+	seconds := max(int((wait+time.Second-1)/time.Second), 1)
+	switch {
+	case seconds < 60:
+		return tr.Sprintf("%d s", seconds)
+	case seconds < 3600:
+		if seconds%60 == 0 {
+			return tr.Sprintf("%d min", seconds/60)
+		}
+		return tr.Sprintf("%d min %d s", seconds/60, seconds%60)
+	default:
+		minutes := (seconds + 59) / 60
+		if minutes%60 == 0 {
+			return tr.Sprintf("%d h", minutes/60)
+		}
+		return tr.Sprintf("%d h %d min", minutes/60, minutes%60)
+	}
+}
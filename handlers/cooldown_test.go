@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/ratelimit"
+	"tg-bot-demo/testutil"
+)
+
+func TestRejectOnCooldown(t *testing.T) {
+	ctx := context.Background()
+	cooldowns := ratelimit.NewCooldowns(map[string]time.Duration{"/stats": 5 * time.Minute})
+	cfg := &HandlerConfig{AdminUserIDs: []int64{9}}
+	api := testutil.NewFakeTelegram()
+
+	if rejectOnCooldown(ctx, api, cooldowns, cfg, commandUpdate(1, "/stats")) {
+		t.Fatal("first use should pass")
+	}
+	if !rejectOnCooldown(ctx, api, cooldowns, cfg, commandUpdate(1, "/stats@my_bot now")) {
+		t.Fatal("second use should be on cooldown")
+	}
+	if len(api.Sent) != 1 || !strings.Contains(api.Sent[0].Text, "You can use /stats again in 5 min") {
+		t.Fatalf("expected the remaining wait, got %d messages: %q", len(api.Sent), api.LastText())
+	}
+
+	for _, update := range []string{"/files", "stats", "hello"} {
+		if rejectOnCooldown(ctx, api, cooldowns, cfg, commandUpdate(1, update)) {
+			t.Errorf("%q has no cooldown and should pass", update)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if rejectOnCooldown(ctx, api, cooldowns, cfg, commandUpdate(9, "/stats")) {
+			t.Fatal("administrators should not be on cooldown")
+		}
+	}
+}
+
+func TestFormatWait(t *testing.T) {
+	tests := map[time.Duration]string{
+		200 * time.Millisecond:           "1 s",
+		42 * time.Second:                 "42 s",
+		59*time.Second + time.Nanosecond: "1 min",
+		4*time.Minute + 30*time.Second:   "4 min 30 s",
+		time.Hour:                        "1 h",
+		90*time.Minute + time.Second:     "1 h 31 min",
+	}
+	for wait, want := range tests {
+		if got := formatWait(en, wait); got != want {
+			t.Errorf("formatWait(%v) = %q, want %q", wait, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// DefaultCallbackDebounceWindow is how long an identical callback of the same user is ignored
+const DefaultCallbackDebounceWindow = 2 * time.Second
+
+// callbackKey identifies a button press of a user
+type callbackKey struct {
+	userID int64
+	data   string
+}
+
+// CallbackDebouncer drops repeated presses of the same inline button, e.g. a
+// double tap, so a switch or confirmation is processed once. Repeats are still
+// answered so the client stops showing its loading spinner.
+type CallbackDebouncer struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[callbackKey]time.Time
+}
+
+// NewCallbackDebouncer creates a debouncer ignoring repeats within window
+func NewCallbackDebouncer(window time.Duration) *CallbackDebouncer {
+	return &CallbackDebouncer{window: window, now: time.Now, seen: make(map[callbackKey]time.Time)}
+}
+
+// Middleware is a bot middleware that answers and drops repeated callbacks
+func (d *CallbackDebouncer) Middleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		if d.debounce(ctx, b, update) {
+			return
+		}
+		next(ctx, b, update)
+	}
+}
+
+// debounce reports whether update repeats a callback seen within the window,
+// answering it if so
+func (d *CallbackDebouncer) debounce(ctx context.Context, b TelegramAPI, update *models.Update) bool {
+	query := update.CallbackQuery
+	if query == nil || !d.repeated(callbackKey{userID: query.From.ID, data: query.Data}) {
+		return false
+	}
+
+	LogInfo(ctx, "callback_debounce", query.From.ID, "repeated callback dropped", map[string]interface{}{
+		"data": query.Data,
+	})
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID})
+	return true
+}
+
+// repeated records key and reports whether it was already seen within the window
+func (d *CallbackDebouncer) repeated(key callbackKey) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for seenKey, at := range d.seen {
+		if now.Sub(at) >= d.window {
+			delete(d.seen, seenKey)
+		}
+	}
+
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	d.seen[key] = now
+	return false
+}
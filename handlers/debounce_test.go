@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tg-bot-demo/testutil"
+)
+
+func TestCallbackDebouncer(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	debouncer := NewCallbackDebouncer(2 * time.Second)
+	debouncer.now = func() time.Time { return now }
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	if debouncer.debounce(ctx, api, callbackUpdate(1, "switch_s1")) {
+		t.Fatal("first press should be processed")
+	}
+	if !debouncer.debounce(ctx, api, callbackUpdate(1, "switch_s1")) {
+		t.Fatal("double tap should be dropped")
+	}
+	if len(api.CallbackAnswers) != 1 {
+		t.Errorf("expected the dropped press to be answered, got %d answers", len(api.CallbackAnswers))
+	}
+	if debouncer.debounce(ctx, api, callbackUpdate(1, "switch_s2")) || debouncer.debounce(ctx, api, callbackUpdate(2, "switch_s1")) {
+		t.Error("other buttons and users should be processed")
+	}
+
+	now = now.Add(2 * time.Second)
+	if debouncer.debounce(ctx, api, callbackUpdate(1, "switch_s1")) {
+		t.Error("press after the window should be processed")
+	}
+	if debouncer.debounce(ctx, api, commandUpdate(1, "/sessions")) {
+		t.Error("messages should not be debounced")
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/retention"
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// deleteFilesArg is the /delete argument that also removes the session's attachments
+const deleteFilesArg = "files"
+
+// DeleteCommandHandler handles the /delete command.
+// It deletes the active session; "/delete files" also deletes the files attached to it,
+// otherwise the files are kept in /files and only unlinked from the session.
+func DeleteCommandHandler(sessionMgr *session.Manager, fileMgr *session.FileManager, fileStorage storage.Backend, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		chatID := update.Message.Chat.ID
+		tr := i18n.FromContext(ctx)
+
+		args := commandArgs(ctx, update.Message)
+		deleteFiles := len(args) > 0 && args[0] == deleteFilesArg
+
+		LogInfo(ctx, "delete_command", userID, "user requested delete active session", map[string]interface{}{
+			"delete_files": deleteFiles,
+		})
+
+		activeSession, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetActiveSession(ctx, userID)
+		if err != nil {
+			if errors.Is(err, session.ErrSessionNotFound) {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:          chatID,
+					MessageThreadID: topicThreadID(update.Message),
+					Text:            tr.T("No active session to delete. Use /sessions to pick one first."),
+				})
+				return
+			}
+			LogError(ctx, "delete_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		var deleted *session.Session
+		err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			var err error
+			if deleted, err = sessionMgr.DeleteSession(ctx, userID, activeSession.ID); err != nil {
+				return nil, err
+			}
+			cfg.recordAudit(ctx, userID, session.AuditSessionDelete, deleted.ID.String(), update.Message.Text)
+
+			text := tr.Sprintf("🗑 Deleted session: %s", format.Bold(deleted.Title))
+			if deleteFiles {
+				removed, failed := deleteSessionFiles(ctx, fileMgr, fileStorage, userID, deleted)
+				text += tr.Sprintf("\nDeleted %d attached file(s)", removed)
+				if failed > 0 {
+					text += tr.Sprintf(", %d could not be removed from storage", failed)
+				}
+			} else if err := fileMgr.DetachSessionFiles(ctx, deleted.ID); err != nil {
+				LogError(ctx, "delete_command", userID, err, map[string]interface{}{
+					"session_id": deleted.ID.String(),
+				})
+			}
+
+			return &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+				ParseMode:       models.ParseModeHTML,
+			}, nil
+		})
+		if err != nil {
+			LogError(ctx, "delete_command", userID, err, map[string]interface{}{
+				"session_id": activeSession.ID.String(),
+			})
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "delete_command", userID, "session deleted", map[string]interface{}{
+			"session_id":    deleted.ID.String(),
+			"session_title": deleted.Title,
+			"delete_files":  deleteFiles,
+		})
+	}
+}
+
+// deleteSessionFiles removes a session's attachments from the catalog and storage.
+// It returns the number of files removed and the number of stored objects that failed to delete.
+func deleteSessionFiles(ctx context.Context, fileMgr *session.FileManager, fileStorage storage.Backend,
+	userID int64, sess *session.Session) (int, int) {
+	files, err := fileMgr.DeleteSessionFiles(ctx, sess.ID)
+	if err != nil {
+		LogError(ctx, "delete_command", userID, err, map[string]interface{}{
+			"session_id": sess.ID.String(),
+		})
+		return 0, 0
+	}
+
+	failed := 0
+	for _, file := range files {
+		retention.TrackDeleted(file.Size)
+		if err := fileStorage.Delete(ctx, file.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			LogError(ctx, "delete_command", userID, err, map[string]interface{}{
+				"file_id":     file.ID.String(),
+				"storage_key": file.StorageKey,
+			})
+			failed++
+		}
+	}
+
+	return len(files), failed
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// deliveryStoreKey is the context key of the store tracking sent messages
+type deliveryStoreKey struct{}
+
+// replyOriginKey is the context key of the session and message being answered
+type replyOriginKey struct{}
+
+// replyOrigin is what a handler's replies answer
+type replyOrigin struct {
+	sessionID uuid.UUID
+	messageID uuid.UUID
+}
+
+// DeliveryMiddleware is a bot middleware that makes handlers adapted by Traced
+// record the Telegram ID of every message they send in store, linked to the
+// update answered. Handlers link replies to a session with WithReplyOrigin.
+// Replies sent by middlewares, such as rejections, are not tracked.
+func DeliveryMiddleware(store session.DeliveryStore) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			next(context.WithValue(ctx, deliveryStoreKey{}, store), b, update)
+		}
+	}
+}
+
+// WithReplyOrigin links the messages sent with ctx to a session and to the
+// stored message they answer; messageID may be uuid.Nil
+func WithReplyOrigin(ctx context.Context, sessionID, messageID uuid.UUID) context.Context {
+	return context.WithValue(ctx, replyOriginKey{}, replyOrigin{sessionID: sessionID, messageID: messageID})
+}
+
+// trackDeliveries returns api recording the messages it sends when
+// DeliveryMiddleware is in use, and api itself otherwise
+func trackDeliveries(ctx context.Context, api TelegramAPI, update *models.Update) TelegramAPI {
+	store, ok := ctx.Value(deliveryStoreKey{}).(session.DeliveryStore)
+	if !ok {
+		return api
+	}
+	tracked := &trackedAPI{TelegramAPI: api, store: store}
+	if user := updateSender(update); user != nil {
+		tracked.userID = user.ID
+	}
+	switch {
+	case update.Message != nil:
+		tracked.replyTo = update.Message.ID
+	case update.BusinessMessage != nil:
+		tracked.replyTo = update.BusinessMessage.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message.Message != nil:
+		tracked.replyTo = update.CallbackQuery.Message.Message.ID
+	}
+	return tracked
+}
+
+// trackedAPI records the messages sent through it
+type trackedAPI struct {
+	TelegramAPI
+	store   session.DeliveryStore
+	userID  int64 // sender of the update answered
+	replyTo int   // Telegram ID of the message answered, 0 if none
+}
+
+// SendMessage sends a message and records it
+func (t *trackedAPI) SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error) {
+	sent, err := t.TelegramAPI.SendMessage(ctx, params)
+	if err == nil {
+		t.record(ctx, sent)
+	}
+	return sent, err
+}
+
+// SendDocument sends a document and records it
+func (t *trackedAPI) SendDocument(ctx context.Context, params *bot.SendDocumentParams) (*models.Message, error) {
+	sent, err := t.TelegramAPI.SendDocument(ctx, params)
+	if err == nil {
+		t.record(ctx, sent)
+	}
+	return sent, err
+}
+
+// record stores a sent message; failures are logged, the message is out already
+func (t *trackedAPI) record(ctx context.Context, sent *models.Message) {
+	if sent == nil {
+		return
+	}
+	record := &session.SentMessage{
+		ChatID:            sent.Chat.ID,
+		TelegramMessageID: sent.ID,
+		UserID:            t.userID,
+		ReplyToMessageID:  t.replyTo,
+		SentAt:            time.Now(),
+	}
+	if origin, ok := ctx.Value(replyOriginKey{}).(replyOrigin); ok {
+		record.SessionID = origin.sessionID
+		record.MessageID = origin.messageID
+	}
+	if err := t.store.RecordSentMessage(ctx, record); err != nil {
+		LogError(ctx, "delivery", t.userID, err, map[string]interface{}{
+			"chat_id":    sent.Chat.ID,
+			"message_id": sent.ID,
+		})
+	}
+}
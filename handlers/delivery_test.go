@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot"
+	"github.com/google/uuid"
+)
+
+func TestMessageHandlerTracksReplies(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_delivery.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	sessionMgr := session.NewManager(store)
+	messageMgr := session.NewMessageManager(store)
+	handler := MessageHandler(sessionMgr, messageMgr, &HandlerConfig{})
+	fake := testutil.NewFakeTelegram()
+	ctx := context.WithValue(context.Background(), deliveryStoreKey{}, session.DeliveryStore(store))
+
+	update := textUpdate(1, "hello")
+	update.Message.ID = 42
+	handler(ctx, trackDeliveries(ctx, fake, update), update)
+
+	active, err := sessionMgr.GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	history, err := messageMgr.History(ctx, active.ID, 10)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("expected the message stored, got %d messages (err=%v)", len(history), err)
+	}
+
+	replies, err := store.ListReplies(ctx, history[0].ID)
+	if err != nil {
+		t.Fatalf("ListReplies failed: %v", err)
+	}
+	if len(replies) != 1 {
+		t.Fatalf("expected the confirmation tracked, got %+v", replies)
+	}
+	if replies[0].SessionID != active.ID || replies[0].ReplyToMessageID != 42 || replies[0].UserID != 1 || replies[0].ChatID != 1 {
+		t.Errorf("expected the confirmation linked to its message, got %+v", replies[0])
+	}
+}
+
+func TestTrackDeliveriesWithoutOrigin(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_delivery.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	fake := testutil.NewFakeTelegram()
+
+	if api := trackDeliveries(context.Background(), fake, commandUpdate(1, "/help")); api != TelegramAPI(fake) {
+		t.Error("expected replies untracked without DeliveryMiddleware")
+	}
+
+	ctx := context.WithValue(context.Background(), deliveryStoreKey{}, session.DeliveryStore(store))
+	api := trackDeliveries(ctx, fake, commandUpdate(1, "/help"))
+	if _, err := api.SendMessage(ctx, &bot.SendMessageParams{ChatID: int64(1), Text: "help"}); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	sent, err := store.GetSentMessage(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("GetSentMessage failed: %v", err)
+	}
+	if sent.SessionID != uuid.Nil || sent.MessageID != uuid.Nil || sent.UserID != 1 {
+		t.Errorf("expected a reply outside sessions, got %+v", sent)
+	}
+}
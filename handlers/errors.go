@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 	"log"
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/reporting"
 	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
 
 	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
 )
 
 // ErrorResponse represents a user-facing error with a code
 type ErrorResponse struct {
-	Message string
+	Message string // English text, translated when sent
 	Code    string
 }
 
@@ -27,6 +32,21 @@ var (
 		Code:    "UNAUTHORIZED",
 	}
 
+	ErrResponseFileNotFound = ErrorResponse{
+		Message: "File not found. It may have been deleted.",
+		Code:    "FILE_NOT_FOUND",
+	}
+
+	ErrResponseAdminRequired = ErrorResponse{
+		Message: "This command is only available to bot administrators.",
+		Code:    "ADMIN_REQUIRED",
+	}
+
+	ErrResponseModeratorRequired = ErrorResponse{
+		Message: "This command is only available to bot moderators and administrators.",
+		Code:    "MODERATOR_REQUIRED",
+	}
+
 	ErrResponseGeneric = ErrorResponse{
 		Message: "An error occurred. Please try again.",
 		Code:    "INTERNAL_ERROR",
@@ -38,8 +58,14 @@ var (
 	}
 )
 
-// SendErrorResponse sends an error message to the user based on the error type
-func SendErrorResponse(ctx context.Context, b *bot.Bot, chatID int64, err error) {
+// ErrAdminRequired is returned when a non-admin invokes an admin command
+var ErrAdminRequired = errors.New("admin privileges required")
+
+// ErrModeratorRequired is returned when a user without a staff role invokes a moderator command
+var ErrModeratorRequired = errors.New("moderator privileges required")
+
+// SendErrorResponse replies to msg with an error message based on the error type
+func SendErrorResponse(ctx context.Context, b TelegramAPI, msg *models.Message, err error) {
 	var response ErrorResponse
 
 	switch {
@@ -47,25 +73,33 @@ func SendErrorResponse(ctx context.Context, b *bot.Bot, chatID int64, err error)
 		response = ErrResponseNotFound
 	case errors.Is(err, session.ErrUnauthorized):
 		response = ErrResponseUnauthorized
+	case errors.Is(err, session.ErrFileNotFound), errors.Is(err, storage.ErrNotFound):
+		response = ErrResponseFileNotFound
+	case errors.Is(err, ErrAdminRequired):
+		response = ErrResponseAdminRequired
+	case errors.Is(err, ErrModeratorRequired):
+		response = ErrResponseModeratorRequired
 	default:
 		response = ErrResponseGeneric
 	}
 
 	if response.Message != "" {
 		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: chatID,
-			Text:   response.Message,
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            i18n.FromContext(ctx).T(response.Message),
 		})
 	}
 }
 
-// LogError logs an error with context information
-func LogError(operation string, userID int64, err error, details map[string]interface{}) {
+// LogError logs an error with context information and reports it to the error tracker
+func LogError(ctx context.Context, operation string, userID int64, err error, details map[string]interface{}) {
 	logEntry := map[string]interface{}{
-		"level":     "error",
-		"operation": operation,
-		"user_id":   userID,
-		"error":     err.Error(),
+		"level":      "error",
+		"request_id": correlation.ID(ctx),
+		"operation":  operation,
+		"user_id":    userID,
+		"error":      err.Error(),
 	}
 
 	// Merge additional details
@@ -73,17 +107,26 @@ func LogError(operation string, userID int64, err error, details map[string]inte
 		logEntry[k] = v
 	}
 
-	log.Printf("[ERROR] operation=%s user_id=%d error=%v details=%+v",
-		operation, userID, err, details)
+	log.Printf("[ERROR] %soperation=%s user_id=%d error=%v details=%+v",
+		requestIDField(ctx), operation, userID, err, details)
+
+	reporting.Report(ctx, reporting.Event{
+		Err:       err,
+		Operation: operation,
+		UserID:    userID,
+		RequestID: correlation.ID(ctx),
+		Details:   details,
+	})
 }
 
 // LogWarning logs a warning with context information
-func LogWarning(operation string, userID int64, message string, details map[string]interface{}) {
+func LogWarning(ctx context.Context, operation string, userID int64, message string, details map[string]interface{}) {
 	logEntry := map[string]interface{}{
-		"level":     "warning",
-		"operation": operation,
-		"user_id":   userID,
-		"message":   message,
+		"level":      "warning",
+		"request_id": correlation.ID(ctx),
+		"operation":  operation,
+		"user_id":    userID,
+		"message":    message,
 	}
 
 	// Merge additional details
@@ -91,17 +134,18 @@ func LogWarning(operation string, userID int64, message string, details map[stri
 		logEntry[k] = v
 	}
 
-	log.Printf("[WARNING] operation=%s user_id=%d message=%s details=%+v",
-		operation, userID, message, details)
+	log.Printf("[WARNING] %soperation=%s user_id=%d message=%s details=%+v",
+		requestIDField(ctx), operation, userID, message, details)
 }
 
 // LogInfo logs an informational message with context
-func LogInfo(operation string, userID int64, message string, details map[string]interface{}) {
+func LogInfo(ctx context.Context, operation string, userID int64, message string, details map[string]interface{}) {
 	logEntry := map[string]interface{}{
-		"level":     "info",
-		"operation": operation,
-		"user_id":   userID,
-		"message":   message,
+		"level":      "info",
+		"request_id": correlation.ID(ctx),
+		"operation":  operation,
+		"user_id":    userID,
+		"message":    message,
 	}
 
 	// Merge additional details
@@ -109,17 +153,18 @@ func LogInfo(operation string, userID int64, message string, details map[string]
 		logEntry[k] = v
 	}
 
-	log.Printf("[INFO] operation=%s user_id=%d message=%s details=%+v",
-		operation, userID, message, details)
+	log.Printf("[INFO] %soperation=%s user_id=%d message=%s details=%+v",
+		requestIDField(ctx), operation, userID, message, details)
 }
 
 // LogDebug logs a debug message with context
-func LogDebug(operation string, userID int64, message string, details map[string]interface{}) {
+func LogDebug(ctx context.Context, operation string, userID int64, message string, details map[string]interface{}) {
 	logEntry := map[string]interface{}{
-		"level":     "debug",
-		"operation": operation,
-		"user_id":   userID,
-		"message":   message,
+		"level":      "debug",
+		"request_id": correlation.ID(ctx),
+		"operation":  operation,
+		"user_id":    userID,
+		"message":    message,
 	}
 
 	// Merge additional details
@@ -127,6 +172,14 @@ func LogDebug(operation string, userID int64, message string, details map[string
 		logEntry[k] = v
 	}
 
-	log.Printf("[DEBUG] operation=%s user_id=%d message=%s details=%+v",
-		operation, userID, message, details)
+	log.Printf("[DEBUG] %soperation=%s user_id=%d message=%s details=%+v",
+		requestIDField(ctx), operation, userID, message, details)
+}
+
+// requestIDField renders the request ID of ctx as a log field, or "" when there is none
+func requestIDField(ctx context.Context) string {
+	if id := correlation.ID(ctx); id != "" {
+		return "request_id=" + id + " "
+	}
+	return ""
 }
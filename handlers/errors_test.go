@@ -5,35 +5,16 @@ import (
 	"context"
 	"errors"
 	"log"
+	"os"
 	"strings"
 	"testing"
+	"tg-bot-demo/correlation"
 	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
 
-	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
 
-// mockBot is a minimal bot implementation for testing
-type mockBot struct {
-	lastMessage string
-	lastChatID  any
-}
-
-func (m *mockBot) SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error) {
-	m.lastMessage = params.Text
-	m.lastChatID = params.ChatID
-	return &models.Message{}, nil
-}
-
-// Implement other required bot.Bot interface methods as no-ops
-func (m *mockBot) AnswerCallbackQuery(ctx context.Context, params *bot.AnswerCallbackQueryParams) error {
-	return nil
-}
-
-func (m *mockBot) EditMessageReplyMarkup(ctx context.Context, params *bot.EditMessageReplyMarkupParams) (*models.Message, error) {
-	return &models.Message{}, nil
-}
-
 func TestSendErrorResponse(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -75,26 +56,19 @@ func TestSendErrorResponse(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Use a real bot instance but with a test handler
-			// Since SendErrorResponse only calls SendMessage, we can test the logic
-			// by checking what error response is selected
-			var response ErrorResponse
-
-			switch {
-			case errors.Is(tt.err, session.ErrSessionNotFound):
-				response = ErrResponseNotFound
-			case errors.Is(tt.err, session.ErrUnauthorized):
-				response = ErrResponseUnauthorized
-			default:
-				response = ErrResponseGeneric
-			}
+			api := testutil.NewFakeTelegram()
+			msg := &models.Message{Chat: models.Chat{ID: 42}}
 
-			if response.Message != tt.expectedMessage {
-				t.Errorf("expected message %q, got %q", tt.expectedMessage, response.Message)
-			}
+			SendErrorResponse(context.Background(), api, msg, tt.err)
 
-			if response.Code != tt.expectedCode {
-				t.Errorf("expected code %q, got %q", tt.expectedCode, response.Code)
+			if len(api.Sent) != 1 {
+				t.Fatalf("expected 1 message, got %d", len(api.Sent))
+			}
+			if api.Sent[0].ChatID != int64(42) {
+				t.Errorf("expected reply to chat 42, got %v", api.Sent[0].ChatID)
+			}
+			if api.LastText() != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, api.LastText())
 			}
 		})
 	}
@@ -104,7 +78,7 @@ func TestLogError(t *testing.T) {
 	// Capture log output
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
-	defer log.SetOutput(nil)
+	defer log.SetOutput(os.Stderr)
 
 	operation := "test_operation"
 	userID := int64(12345)
@@ -114,7 +88,7 @@ func TestLogError(t *testing.T) {
 		"offset":     10,
 	}
 
-	LogError(operation, userID, err, details)
+	LogError(context.Background(), operation, userID, err, details)
 
 	output := buf.String()
 
@@ -143,7 +117,7 @@ func TestLogWarning(t *testing.T) {
 	// Capture log output
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
-	defer log.SetOutput(nil)
+	defer log.SetOutput(os.Stderr)
 
 	operation := "callback_query"
 	userID := int64(67890)
@@ -152,7 +126,7 @@ func TestLogWarning(t *testing.T) {
 		"callback_data": "invalid_format",
 	}
 
-	LogWarning(operation, userID, message, details)
+	LogWarning(context.Background(), operation, userID, message, details)
 
 	output := buf.String()
 
@@ -178,7 +152,7 @@ func TestLogInfo(t *testing.T) {
 	// Capture log output
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
-	defer log.SetOutput(nil)
+	defer log.SetOutput(os.Stderr)
 
 	operation := "session_switch"
 	userID := int64(11111)
@@ -188,7 +162,7 @@ func TestLogInfo(t *testing.T) {
 		"session_title": "Test Session",
 	}
 
-	LogInfo(operation, userID, message, details)
+	LogInfo(context.Background(), operation, userID, message, details)
 
 	output := buf.String()
 
@@ -211,7 +185,7 @@ func TestLogDebug(t *testing.T) {
 	// Capture log output
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
-	defer log.SetOutput(nil)
+	defer log.SetOutput(os.Stderr)
 
 	operation := "pagination"
 	userID := int64(22222)
@@ -221,7 +195,7 @@ func TestLogDebug(t *testing.T) {
 		"limit":  6,
 	}
 
-	LogDebug(operation, userID, message, details)
+	LogDebug(context.Background(), operation, userID, message, details)
 
 	output := buf.String()
 
@@ -244,13 +218,13 @@ func TestLogWithNilDetails(t *testing.T) {
 	// Capture log output
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
-	defer log.SetOutput(nil)
+	defer log.SetOutput(os.Stderr)
 
 	// Test that logging works with nil details
-	LogError("test_op", 123, errors.New("test"), nil)
-	LogWarning("test_op", 123, "test", nil)
-	LogInfo("test_op", 123, "test", nil)
-	LogDebug("test_op", 123, "test", nil)
+	LogError(context.Background(), "test_op", 123, errors.New("test"), nil)
+	LogWarning(context.Background(), "test_op", 123, "test", nil)
+	LogInfo(context.Background(), "test_op", 123, "test", nil)
+	LogDebug(context.Background(), "test_op", 123, "test", nil)
 
 	output := buf.String()
 
@@ -264,11 +238,11 @@ func TestLogWithEmptyDetails(t *testing.T) {
 	// Capture log output
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
-	defer log.SetOutput(nil)
+	defer log.SetOutput(os.Stderr)
 
 	// Test that logging works with empty details map
 	emptyDetails := map[string]interface{}{}
-	LogError("test_op", 123, errors.New("test"), emptyDetails)
+	LogError(context.Background(), "test_op", 123, errors.New("test"), emptyDetails)
 
 	output := buf.String()
 
@@ -308,3 +282,25 @@ func TestErrorResponseConstants(t *testing.T) {
 		t.Errorf("ErrResponseInvalidCallback code should be INVALID_CALLBACK, got %s", ErrResponseInvalidCallback.Code)
 	}
 }
+
+func TestLogIncludesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	ctx := correlation.WithID(context.Background(), "abc123")
+	LogInfo(ctx, "test_op", 1, "first", nil)
+	LogError(ctx, "test_op", 1, errors.New("boom"), nil)
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.Contains(line, "request_id=abc123 operation=test_op") {
+			t.Errorf("log line should carry the request ID: %s", line)
+		}
+	}
+
+	buf.Reset()
+	LogInfo(context.Background(), "test_op", 1, "untagged", nil)
+	if strings.Contains(buf.String(), "request_id=") {
+		t.Errorf("log without request ID should omit the field: %s", buf.String())
+	}
+}
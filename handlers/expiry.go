@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/notify"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// keepSessionPrefix is the callback data of the keep buttons of an expiry
+// warning, followed by a session ID
+const keepSessionPrefix = "keep_s_"
+
+// expiryWarningBatch is the number of sessions warned about per run; the rest
+// are warned on the following runs
+const expiryWarningBatch = 100
+
+// expiryButtonsPerMessage is the number of keep buttons in one warning message
+const expiryButtonsPerMessage = 10
+
+// SessionExpiry archives sessions that stayed idle for longer than a TTL. The
+// owners are warned first through the notifier, so warnings respect their
+// notification settings and wait out quiet hours in the deferred queue. Each
+// warned session gets a keep button; a session is archived no sooner than the
+// warning lead time after its owner was warned.
+type SessionExpiry struct {
+	store    session.ExpiryStore
+	prefs    session.PreferenceStore
+	notifier *notify.Notifier
+	cfg      *HandlerConfig
+	ttl      time.Duration
+	warning  time.Duration
+	now      func() time.Time
+}
+
+// NewSessionExpiry creates an expiry job archiving sessions idle for ttl after
+// warning their owners warning ahead of it
+func NewSessionExpiry(store session.ExpiryStore, prefs session.PreferenceStore, notifier *notify.Notifier,
+	cfg *HandlerConfig, ttl, warning time.Duration) *SessionExpiry {
+	return &SessionExpiry{
+		store:    store,
+		prefs:    prefs,
+		notifier: notifier,
+		cfg:      cfg,
+		ttl:      ttl,
+		warning:  warning,
+		now:      time.Now,
+	}
+}
+
+// Start runs the job every interval until ctx is cancelled
+func (e *SessionExpiry) Start(ctx context.Context, b notify.Sender, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if warned, archived, err := e.Run(ctx, b); err != nil {
+				log.Printf("session expiry failed: warned=%d archived=%d err=%v", warned, archived, err)
+			} else if warned > 0 || archived > 0 {
+				log.Printf("session expiry: warned=%d archived=%d", warned, archived)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run warns the owners of sessions about to expire and archives the sessions
+// whose warning lead time has passed. It reports how many sessions were warned
+// about and how many were archived.
+func (e *SessionExpiry) Run(ctx context.Context, b notify.Sender) (int, int, error) {
+	now := e.now()
+	sessions, err := e.store.ListSessionsToWarn(ctx, now.Add(e.warning-e.ttl), expiryWarningBatch)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Sessions come ordered by owner; each owner gets one warning per group
+	warned := 0
+	var failures []error
+	for start := 0; start < len(sessions); {
+		end := start
+		for end < len(sessions) && sessions[end].UserID == sessions[start].UserID {
+			end++
+		}
+		owned := sessions[start:end]
+		start = end
+
+		if err := e.warn(ctx, b, owned); err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		ids := make([]uuid.UUID, len(owned))
+		for i, s := range owned {
+			ids[i] = s.ID
+		}
+		if err := e.store.MarkExpiryWarned(ctx, ids, now); err != nil {
+			return warned, 0, err
+		}
+		warned += len(owned)
+	}
+
+	archived, err := e.store.ArchiveExpiredSessions(ctx, now.Add(-e.ttl), now.Add(-e.warning))
+	if err != nil {
+		failures = append(failures, err)
+	}
+	return warned, archived, errors.Join(failures...)
+}
+
+// warn tells the owner of sessions that they will be archived, in the owner's
+// language, with keep buttons
+func (e *SessionExpiry) warn(ctx context.Context, b notify.Sender, sessions []*session.Session) error {
+	userID := sessions[0].UserID
+	lang := ""
+	if prefs, err := e.prefs.GetPreferences(ctx, userID); err == nil {
+		lang = prefs.Language
+	}
+	tr := i18n.New(lang)
+	hours := int(e.warning.Hours())
+
+	for start := 0; start < len(sessions); start += expiryButtonsPerMessage {
+		page := sessions[start:min(start+expiryButtonsPerMessage, len(sessions))]
+		text := tr.Sprintf("⏳ %d idle session(s) will be archived in %d hour(s). Press a session to keep it.", len(page), hours)
+		keyboard := e.cfg.callbacks().EncodeKeyboard(ctx, buildKeepKeyboard(tr, page))
+
+		// Private chats share the ID of the user
+		if _, err := e.notifier.SendKeyboard(ctx, b, userID, userID, text, "", keyboard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildKeepKeyboard creates the keep buttons of an expiry warning
+func buildKeepKeyboard(tr *i18n.Translator, sessions []*session.Session) *models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(sessions))
+	for _, s := range sessions {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         "📌 " + formatSessionButton(tr, s),
+			CallbackData: keepSessionPrefix + s.ID.String(),
+		}})
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// handleKeepSession handles the keep button of an expiry warning
+func handleKeepSession(ctx context.Context, b TelegramAPI, req *CallbackRequest, sessionMgr *session.Manager, cfg *HandlerConfig) {
+	msg := req.Message()
+	if msg == nil {
+		return
+	}
+
+	sessionID, err := uuid.Parse(req.Param)
+	if err != nil {
+		LogWarning(ctx, "keep_session", req.UserID, "invalid session ID format", map[string]interface{}{
+			"session_id_str": req.Param,
+		})
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	err = cfg.confirmWrite(ctx, b, req.UserID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		sess, err := sessionMgr.KeepSession(ctx, req.UserID, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		return &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   i18n.FromContext(ctx).Sprintf("📌 Kept %s. It will not be archived for now.", sess.Title),
+		}, nil
+	})
+	if err != nil {
+		LogError(ctx, "keep_session", req.UserID, err, map[string]interface{}{
+			"session_id": sessionID.String(),
+		})
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	LogInfo(ctx, "keep_session", req.UserID, "session kept", map[string]interface{}{
+		"session_id": sessionID.String(),
+	})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/notify"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestSessionExpiryWarnsAndArchives(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_expiry.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	sessionMgr := session.NewManager(store)
+	ctx := context.Background()
+	for _, title := range []string{"Alpha", "Beta"} {
+		sess, err := sessionMgr.CreateSession(ctx, 1, title)
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		sess.UpdatedAt = time.Now().Add(-30 * 24 * time.Hour)
+		if err := store.Update(ctx, sess); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+
+	cfg := &HandlerConfig{SessionsPerPage: 5, CallbackSigner: NewCallbackSigner("secret", 0)}
+	expiry := NewSessionExpiry(store, store, notify.New(store), cfg, 30*24*time.Hour, 24*time.Hour)
+	fake := testutil.NewFakeTelegram()
+
+	warned, archived, err := expiry.Run(ctx, fake)
+	if err != nil || warned != 2 || archived != 0 {
+		t.Fatalf("expected 2 warnings and nothing archived, got %d, %d, %v", warned, archived, err)
+	}
+	if len(fake.Sent) != 1 || !strings.Contains(fake.Sent[0].Text, "2 idle session(s)") {
+		t.Fatalf("expected one warning message, got %d messages", len(fake.Sent))
+	}
+	markup := fake.Sent[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+
+	// Keep Beta
+	CallbackQueryHandler(sessionMgr, store, cfg)(ctx, fake, pressButton(t, 1, markup, "Beta"))
+	if !strings.Contains(fake.LastText(), "Kept Beta") {
+		t.Fatalf("expected a keep confirmation, got %q", fake.LastText())
+	}
+
+	// A second run before the warning lead time has passed does nothing
+	if warned, archived, err := expiry.Run(ctx, fake); err != nil || warned != 0 || archived != 0 {
+		t.Fatalf("expected nothing to do, got %d, %d, %v", warned, archived, err)
+	}
+
+	expiry.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	if _, archived, err := expiry.Run(ctx, fake); err != nil || archived != 1 {
+		t.Fatalf("expected Alpha to be archived, got %d, %v", archived, err)
+	}
+	sessions, _, err := sessionMgr.ListSessions(ctx, 1, 0, 5)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Title != "Beta" {
+		t.Errorf("expected only Beta to be listed, got %d sessions", len(sessions))
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// FeedbackCallbackPrefix is the common prefix of all /admin feedback keyboard callbacks
+const FeedbackCallbackPrefix = "fb_"
+
+// Callback data prefixes for the /admin feedback keyboard
+const (
+	feedbackPagePrefix    = "fb_page_"
+	feedbackResolvePrefix = "fb_res_" // followed by "<id>_<offset>"
+)
+
+// maxFeedbackLength is the longest feedback accepted, in characters
+const maxFeedbackLength = 2000
+
+// feedbackPreviewLength is the number of characters of each feedback shown to administrators
+const feedbackPreviewLength = 300
+
+// FeedbackCommandHandler handles "/feedback <text>".
+// It stores the feedback, linked to the active session if there is one.
+func FeedbackCommandHandler(sessionMgr *session.Manager, feedback session.FeedbackStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+		reply := func(text string) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+			})
+		}
+
+		text := messageCommand(ctx, update.Message).RawArgs
+		if text == "" {
+			reply(tr.T("Usage: /feedback <text>\nTell us what works, what doesn't, or what you'd like to see."))
+			return
+		}
+		if utf8.RuneCountInString(text) > maxFeedbackLength {
+			reply(tr.Sprintf("Feedback is limited to %d characters. Please shorten it and try again.", maxFeedbackLength))
+			return
+		}
+
+		entry := &session.Feedback{UserID: userID, Text: text, CreatedAt: time.Now()}
+		activeSession, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetActiveSession(ctx, userID)
+		switch {
+		case err == nil:
+			entry.SessionID = activeSession.ID
+		case !errors.Is(err, session.ErrSessionNotFound):
+			LogError(ctx, "feedback_command", userID, err, nil)
+		}
+
+		err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			if err := feedback.AddFeedback(ctx, entry); err != nil {
+				return nil, err
+			}
+			return &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.T("🙏 Thanks for your feedback!"),
+			}, nil
+		})
+		if err != nil {
+			LogError(ctx, "feedback_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "feedback_command", userID, "feedback received", map[string]interface{}{
+			"feedback_id": entry.ID,
+			"length":      utf8.RuneCountInString(text),
+		})
+	}
+}
+
+// AdminFeedbackCommand lists open feedback with resolve buttons
+func AdminFeedbackCommand(feedback session.FeedbackStore, cfg *HandlerConfig) AdminReplyFunc {
+	return func(ctx context.Context, userID int64, args []string) (*AdminReply, error) {
+		return buildFeedbackPage(ctx, feedback, 0, cfg.sessionsPerPage(ctx))
+	}
+}
+
+// FeedbackCallbackHandler handles button clicks on the /admin feedback keyboard
+func FeedbackCallbackHandler(feedback session.FeedbackStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		userID := callback.From.ID
+		tr := i18n.FromContext(ctx)
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "feedback_callback", err, tr.T("⌛ This menu expired. Send /admin feedback to get a fresh one."))
+			return
+		}
+		if cfg.permissionError(ctx, userID, "/admin", "feedback") != nil {
+			LogWarning(ctx, "feedback_callback", userID, "rejected callback", map[string]interface{}{
+				"callback_data": callback.Data,
+			})
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: callback.ID,
+				Text:            tr.T("⌛ This menu expired. Send /admin feedback to get a fresh one."),
+				ShowAlert:       true,
+			})
+			return
+		}
+
+		msg := callback.Message.Message
+		if msg == nil {
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+			return
+		}
+
+		var offset int
+		answer := ""
+		switch {
+		case strings.HasPrefix(data, feedbackPagePrefix):
+			offset, err = parsePageOffset(data, feedbackPagePrefix)
+		case strings.HasPrefix(data, feedbackResolvePrefix):
+			var id int64
+			id, offset, err = parseFeedbackResolve(data)
+			if err != nil {
+				break
+			}
+			err = feedback.ResolveFeedback(ctx, id, userID, time.Now())
+			switch {
+			case err == nil:
+				LogInfo(ctx, "feedback_callback", userID, "feedback resolved", map[string]interface{}{"feedback_id": id})
+				answer = tr.Sprintf("✅ Feedback #%d resolved", id)
+			case errors.Is(err, session.ErrFeedbackNotFound):
+				answer = tr.Sprintf("Feedback #%d was already resolved", id)
+				err = nil
+			}
+		default:
+			err = fmt.Errorf("invalid callback data: %q", data)
+		}
+		if err != nil {
+			LogError(ctx, "feedback_callback", userID, err, map[string]interface{}{"callback_data": data})
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+			return
+		}
+
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID, Text: answer})
+
+		perPage := cfg.sessionsPerPage(ctx)
+		page, err := buildFeedbackPage(ctx, feedback, offset, perPage)
+		if err != nil {
+			LogError(ctx, "feedback_callback", userID, err, map[string]interface{}{"offset": offset})
+			return
+		}
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			Text:        page.Text,
+			ReplyMarkup: cfg.callbacks().EncodeKeyboard(ctx, page.Keyboard),
+		})
+	}
+}
+
+// buildFeedbackPage renders the open feedback starting at offset. When resolving
+// emptied the last page, the previous page is shown instead.
+func buildFeedbackPage(ctx context.Context, feedback session.FeedbackStore, offset, perPage int) (*AdminReply, error) {
+	tr := i18n.FromContext(ctx)
+
+	total, err := feedback.CountOpenFeedback(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return &AdminReply{Text: tr.T("📭 No open feedback.")}, nil
+	}
+	for offset >= total {
+		offset -= perPage
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := feedback.ListOpenFeedback(ctx, offset, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{tr.Sprintf("📝 Open feedback: %d", total)}
+	rows := make([][]models.InlineKeyboardButton, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, "", formatFeedbackEntry(tr, entry))
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         tr.Sprintf("✅ Resolve #%d", entry.ID),
+			CallbackData: fmt.Sprintf("%s%d_%d", feedbackResolvePrefix, entry.ID, offset),
+		}})
+	}
+
+	return &AdminReply{
+		Text:     strings.Join(lines, "\n"),
+		Keyboard: buildPagedKeyboard(tr, rows, feedbackPagePrefix, offset, offset > 0, offset+len(entries) < total, perPage, total),
+	}, nil
+}
+
+// formatFeedbackEntry renders one feedback for administrators
+func formatFeedbackEntry(tr *i18n.Translator, entry *session.Feedback) string {
+	header := tr.Sprintf("#%d · user %d · %s", entry.ID, entry.UserID, tr.FormatTime(entry.CreatedAt, "Jan 2, 2006 15:04"))
+	if entry.SessionTitle != "" {
+		header += " · " + tr.Sprintf("session %s", truncate(entry.SessionTitle, 32))
+	}
+	return header + "\n" + truncate(entry.Text, feedbackPreviewLength)
+}
+
+// parseFeedbackResolve extracts the feedback ID and page offset from resolve callback data
+func parseFeedbackResolve(data string) (int64, int, error) {
+	idStr, offsetStr, ok := strings.Cut(strings.TrimPrefix(data, feedbackResolvePrefix), "_")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid resolve callback: %q", data)
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid feedback ID: %w", err)
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return 0, 0, fmt.Errorf("invalid offset: %q", offsetStr)
+	}
+	return id, offset, nil
+}
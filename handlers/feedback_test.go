@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func TestFeedbackFlow(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_feedback.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	sessionMgr := session.NewManager(store)
+	cfg := &HandlerConfig{
+		SessionsPerPage: 2,
+		AdminUserIDs:    []int64{99},
+		CallbackSigner:  NewCallbackSigner("secret", time.Hour),
+	}
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	feedbackCommand := FeedbackCommandHandler(sessionMgr, store, &HandlerConfig{})
+	feedbackCommand(ctx, api, commandUpdate(1, "/feedback"))
+	if !strings.Contains(api.LastText(), "Usage: /feedback") {
+		t.Fatalf("expected usage for empty feedback, got %q", api.LastText())
+	}
+
+	active, err := sessionMgr.CreateSession(ctx, 1, "Trip")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	feedbackCommand(ctx, api, commandUpdate(1, "/feedback love it"))
+	feedbackCommand(ctx, api, commandUpdate(2, "/feedback buttons are slow"))
+	feedbackCommand(ctx, api, commandUpdate(2, "/feedback please add dark mode"))
+	if !strings.Contains(api.LastText(), "Thanks") {
+		t.Fatalf("expected thanks, got %q", api.LastText())
+	}
+
+	open, err := store.ListOpenFeedback(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ListOpenFeedback failed: %v", err)
+	}
+	if len(open) != 3 || open[0].SessionID != active.ID || open[0].SessionTitle != "Trip" {
+		t.Fatalf("expected feedback linked to the active session, got %+v", open[0])
+	}
+
+	page, err := AdminFeedbackCommand(store, cfg)(ctx, 99, nil)
+	if err != nil {
+		t.Fatalf("admin feedback failed: %v", err)
+	}
+	if !strings.Contains(page.Text, "Open feedback: 3") || !strings.Contains(page.Text, "session Trip\nlove it") ||
+		strings.Contains(page.Text, "dark mode") {
+		t.Errorf("expected the first page of feedback, got %q", page.Text)
+	}
+	resolve := page.Keyboard.InlineKeyboard[0][0].CallbackData
+	if resolve != feedbackResolvePrefix+"1_0" {
+		t.Fatalf("expected a resolve button for #1, got %q", resolve)
+	}
+
+	callbacks := FeedbackCallbackHandler(store, cfg)
+	callbacks(ctx, api, callbackUpdate(1, cfg.CallbackSigner.Sign(resolve)))
+	if count, _ := store.CountOpenFeedback(ctx); count != 3 {
+		t.Fatal("expected non-admins to be unable to resolve feedback")
+	}
+
+	callbacks(ctx, api, callbackUpdate(99, cfg.CallbackSigner.Sign(resolve)))
+	if count, _ := store.CountOpenFeedback(ctx); count != 2 {
+		t.Fatalf("expected 2 open feedback after resolving, got %d", count)
+	}
+	edited := api.EditedTexts[len(api.EditedTexts)-1]
+	if strings.Contains(edited.Text, "love it") || !strings.Contains(edited.Text, "dark mode") {
+		t.Errorf("expected the refreshed page without the resolved entry, got %q", edited.Text)
+	}
+
+	callbacks(ctx, api, callbackUpdate(99, cfg.CallbackSigner.Sign(resolve)))
+	if answer := api.CallbackAnswers[len(api.CallbackAnswers)-1]; !strings.Contains(answer.Text, "already resolved") {
+		t.Errorf("expected an already resolved notice, got %q", answer.Text)
+	}
+}
+
+func TestParseFeedbackResolve(t *testing.T) {
+	id, offset, err := parseFeedbackResolve("fb_res_12_4")
+	if err != nil || id != 12 || offset != 4 {
+		t.Errorf("expected 12, 4, got %d, %d, %v", id, offset, err)
+	}
+	for _, data := range []string{"fb_res_12", "fb_res_x_0", "fb_res_1_-2"} {
+		if _, _, err := parseFeedbackResolve(data); err == nil {
+			t.Errorf("expected %q to be rejected", data)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/retention"
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// FilesCallbackPrefix is the common prefix of all /files keyboard callbacks
+const FilesCallbackPrefix = "file_"
+
+// Callback data prefixes for the /files keyboard
+const (
+	filesPagePrefix  = "file_page_"
+	fileInfoPrefix   = "file_info_"
+	fileSendPrefix   = "file_send_"
+	fileDeletePrefix = "file_del_"
+)
+
+// FilesCommandHandler handles the /files command.
+// It lists the user's downloaded files with re-send, info and delete buttons.
+func FilesCommandHandler(fileMgr *session.FileManager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+
+		LogInfo(ctx, "files_command", userID, "user requested file list", nil)
+
+		perPage := cfg.sessionsPerPage(ctx)
+		files, hasNext, err := fileMgr.ListFiles(ctx, userID, 0, perPage)
+		if err != nil {
+			LogError(ctx, "files_command", userID, err, map[string]interface{}{
+				"offset": 0,
+				"limit":  perPage,
+			})
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		if len(files) == 0 {
+			LogInfo(ctx, "files_command", userID, "no files found", nil)
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.T("You don't have any files yet. Send me a photo or document to store one!"),
+			})
+			return
+		}
+
+		LogInfo(ctx, "files_command", userID, "file list sent", map[string]interface{}{
+			"file_count": len(files),
+			"has_next":   hasNext,
+		})
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            tr.T("Your files:"),
+			ReplyMarkup:     cfg.callbacks().EncodeKeyboard(ctx, buildFilesKeyboard(tr, files, 0, false, hasNext, perPage)),
+		})
+	}
+}
+
+// FilesCallbackHandler handles button clicks on the /files keyboard
+func FilesCallbackHandler(fileMgr *session.FileManager, fileStorage storage.Backend, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		userID := callback.From.ID
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "files_callback", err,
+				i18n.FromContext(ctx).T("⌛ This menu expired. Send /files to get a fresh one."))
+			return
+		}
+
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+		})
+
+		msg := callback.Message.Message
+		if msg == nil {
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(data, filesPagePrefix):
+			handlePageFiles(ctx, b, msg, fileMgr, cfg, userID, data)
+		case strings.HasPrefix(data, fileInfoPrefix):
+			handleFileInfo(ctx, b, msg, fileMgr, fileStorage, userID, data)
+		case strings.HasPrefix(data, fileSendPrefix):
+			handleFileSend(ctx, b, msg, fileMgr, fileStorage, userID, data)
+		case strings.HasPrefix(data, fileDeletePrefix):
+			handleFileDelete(ctx, b, msg, fileMgr, fileStorage, cfg, userID, data)
+		default:
+			LogWarning(ctx, "files_callback", userID, "invalid callback data format", map[string]interface{}{
+				"callback_data": data,
+			})
+		}
+	}
+}
+
+// buildFilesKeyboard creates an inline keyboard for the file list
+func buildFilesKeyboard(tr *i18n.Translator, files []*session.File, offset int, hasPrev bool, hasNext bool, perPage int) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+
+	// One row per file: label (metadata), re-send, delete
+	for _, f := range files {
+		id := f.ID.String()
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: formatFileButton(tr, f), CallbackData: fileInfoPrefix + id},
+			{Text: "📤", CallbackData: fileSendPrefix + id},
+			{Text: "🗑", CallbackData: fileDeletePrefix + id},
+		})
+	}
+
+	return buildPagedKeyboard(tr, rows, filesPagePrefix, offset, hasPrev, hasNext, perPage, 0)
+}
+
+// formatFileButton formats a file for display in button
+func formatFileButton(tr *i18n.Translator, f *session.File) string {
+	// Format: "name · 1.2 MB · 2h ago"
+	return fmt.Sprintf("%s · %s · %s", truncateWidth(fileDisplayName(f), 24), formatBytes(f.Size), formatTimeAgo(tr, f.CreatedAt))
+}
+
+// fileDisplayName returns the original file name or the media kind
+func fileDisplayName(f *session.File) string {
+	if f.FileName != "" {
+		return f.FileName
+	}
+	return f.Kind
+}
+
+// formatBytes renders a byte count using binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// parseFileCallbackID extracts the file UUID following prefix
+func parseFileCallbackID(data, prefix string) (uuid.UUID, error) {
+	return uuid.Parse(strings.TrimPrefix(data, prefix))
+}
+
+// handlePageFiles processes file list pagination requests
+func handlePageFiles(ctx context.Context, b TelegramAPI, msg *models.Message,
+	fileMgr *session.FileManager, cfg *HandlerConfig, userID int64, data string) {
+	perPage := cfg.sessionsPerPage(ctx)
+	offset, err := parsePageOffset(data, filesPagePrefix)
+	if err != nil {
+		LogWarning(ctx, "page_files", userID, "invalid page callback", map[string]interface{}{
+			"callback_data": data,
+			"error":         err.Error(),
+		})
+		return
+	}
+
+	files, hasNext, err := fileMgr.ListFiles(ctx, userID, offset, perPage)
+	if err != nil {
+		LogError(ctx, "page_files", userID, err, map[string]interface{}{
+			"offset": offset,
+			"limit":  perPage,
+		})
+		return
+	}
+
+	b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		ReplyMarkup: cfg.callbacks().EncodeKeyboard(ctx, buildFilesKeyboard(i18n.FromContext(ctx), files, offset, offset > 0, hasNext, perPage)),
+	})
+}
+
+// lookupFileFromCallback parses the file ID in data and loads the user's file
+func lookupFileFromCallback(ctx context.Context, b TelegramAPI, msg *models.Message,
+	fileMgr *session.FileManager, userID int64, operation, data, prefix string) *session.File {
+	fileID, err := parseFileCallbackID(data, prefix)
+	if err != nil {
+		LogWarning(ctx, operation, userID, "invalid file ID format", map[string]interface{}{
+			"callback_data": data,
+			"error":         err.Error(),
+		})
+		return nil
+	}
+
+	file, err := fileMgr.GetFile(ctx, userID, fileID)
+	if err != nil {
+		if errors.Is(err, session.ErrUnauthorized) {
+			LogWarning(ctx, operation, userID, "unauthorized access attempt", map[string]interface{}{
+				"file_id": fileID.String(),
+			})
+		} else {
+			LogError(ctx, operation, userID, err, map[string]interface{}{
+				"file_id": fileID.String(),
+			})
+		}
+		SendErrorResponse(ctx, b, msg, err)
+		return nil
+	}
+
+	return file
+}
+
+// handleFileInfo replies with the metadata of a stored file
+func handleFileInfo(ctx context.Context, b TelegramAPI, msg *models.Message,
+	fileMgr *session.FileManager, fileStorage storage.Backend, userID int64, data string) {
+	file := lookupFileFromCallback(ctx, b, msg, fileMgr, userID, "file_info", data, fileInfoPrefix)
+	if file == nil {
+		return
+	}
+
+	// The storage location names server paths or buckets, so it is only logged
+	LogInfo(ctx, "file_info", userID, "file info shown", map[string]interface{}{
+		"file_id":  file.ID.String(),
+		"location": fileStorage.Location(file.StorageKey),
+	})
+
+	tr := i18n.FromContext(ctx)
+	lines := []string{
+		tr.Sprintf("📄 %s", format.Bold(fileDisplayName(file))),
+		tr.Sprintf("Type: %s", format.Escape(file.Kind)),
+		tr.Sprintf("Size: %s", formatBytes(file.Size)),
+		tr.Sprintf("Received: %s", tr.FormatTime(file.CreatedAt, "Jan 2, 2006 15:04")),
+	}
+	if file.MimeType != "" {
+		lines = append(lines, tr.Sprintf("MIME type: %s", format.Code(file.MimeType)))
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          msg.Chat.ID,
+		MessageThreadID: topicThreadID(msg),
+		Text:            strings.Join(lines, "\n"),
+		ParseMode:       models.ParseModeHTML,
+	})
+}
+
+// handleFileSend sends a stored file back to the user as a document
+func handleFileSend(ctx context.Context, b TelegramAPI, msg *models.Message,
+	fileMgr *session.FileManager, fileStorage storage.Backend, userID int64, data string) {
+	file := lookupFileFromCallback(ctx, b, msg, fileMgr, userID, "file_send", data, fileSendPrefix)
+	if file == nil {
+		return
+	}
+
+	reader, err := fileStorage.Open(ctx, file.StorageKey)
+	if err != nil {
+		LogError(ctx, "file_send", userID, err, map[string]interface{}{
+			"file_id":     file.ID.String(),
+			"storage_key": file.StorageKey,
+		})
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+	defer reader.Close()
+
+	if _, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:          msg.Chat.ID,
+		MessageThreadID: topicThreadID(msg),
+		Document: &models.InputFileUpload{
+			Filename: fileDisplayName(file),
+			Data:     reader,
+		},
+	}); err != nil {
+		LogError(ctx, "file_send", userID, err, map[string]interface{}{
+			"file_id": file.ID.String(),
+		})
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	LogInfo(ctx, "file_send", userID, "file re-sent", map[string]interface{}{
+		"file_id": file.ID.String(),
+	})
+}
+
+// handleFileDelete removes a file from storage and the catalog and refreshes the list
+func handleFileDelete(ctx context.Context, b TelegramAPI, msg *models.Message,
+	fileMgr *session.FileManager, fileStorage storage.Backend, cfg *HandlerConfig, userID int64, data string) {
+	fileID, err := parseFileCallbackID(data, fileDeletePrefix)
+	if err != nil {
+		LogWarning(ctx, "file_delete", userID, "invalid file ID format", map[string]interface{}{
+			"callback_data": data,
+			"error":         err.Error(),
+		})
+		return
+	}
+
+	var file *session.File
+	err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		var err error
+		if file, err = fileMgr.DeleteFile(ctx, userID, fileID); err != nil {
+			return nil, err
+		}
+		return &bot.SendMessageParams{
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            i18n.FromContext(ctx).Sprintf("🗑 Deleted file: %s", format.Bold(fileDisplayName(file))),
+			ParseMode:       models.ParseModeHTML,
+		}, nil
+	})
+	if err != nil {
+		LogError(ctx, "file_delete", userID, err, map[string]interface{}{
+			"file_id": fileID.String(),
+		})
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	// The catalog entry is gone either way; a missing object is not an error.
+	retention.TrackDeleted(file.Size)
+	if err := fileStorage.Delete(ctx, file.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		LogError(ctx, "file_delete", userID, err, map[string]interface{}{
+			"file_id":     file.ID.String(),
+			"storage_key": file.StorageKey,
+		})
+	}
+
+	LogInfo(ctx, "file_delete", userID, "file deleted", map[string]interface{}{
+		"file_id": file.ID.String(),
+	})
+
+	perPage := cfg.sessionsPerPage(ctx)
+	files, hasNext, err := fileMgr.ListFiles(ctx, userID, 0, perPage)
+	if err != nil {
+		LogError(ctx, "file_delete", userID, err, nil)
+		return
+	}
+
+	b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		ReplyMarkup: cfg.callbacks().EncodeKeyboard(ctx, buildFilesKeyboard(i18n.FromContext(ctx), files, 0, false, hasNext, perPage)),
+	})
+}
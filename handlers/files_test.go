@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+	"tg-bot-demo/testutil"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+func TestBuildFilesKeyboard(t *testing.T) {
+	now := time.Now()
+	files := []*session.File{
+		{ID: uuid.New(), UserID: 1, Kind: "photo", FileName: "file_1.jpg", Size: 2048, CreatedAt: now},
+		{ID: uuid.New(), UserID: 1, Kind: "voice", Size: 10, CreatedAt: now},
+	}
+
+	keyboard := buildFilesKeyboard(en, files, 6, true, true, 6)
+	rows := keyboard.InlineKeyboard
+
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows (prev + 2 files + next), got %d", len(rows))
+	}
+	if rows[0][0].CallbackData != "file_page_0" {
+		t.Errorf("expected prev callback 'file_page_0', got %q", rows[0][0].CallbackData)
+	}
+	if rows[3][0].CallbackData != "file_page_12" {
+		t.Errorf("expected next callback 'file_page_12', got %q", rows[3][0].CallbackData)
+	}
+
+	fileRow := rows[1]
+	if len(fileRow) != 3 {
+		t.Fatalf("expected 3 buttons per file row, got %d", len(fileRow))
+	}
+	id := files[0].ID.String()
+	if fileRow[0].CallbackData != "file_info_"+id || fileRow[1].CallbackData != "file_send_"+id || fileRow[2].CallbackData != "file_del_"+id {
+		t.Errorf("unexpected file row callbacks: %+v", fileRow)
+	}
+	if !strings.HasPrefix(fileRow[0].Text, "file_1.jpg · 2.0 KB") {
+		t.Errorf("unexpected file label %q", fileRow[0].Text)
+	}
+	if !strings.HasPrefix(rows[2][0].Text, "voice · 10 B") {
+		t.Errorf("expected kind as fallback label, got %q", rows[2][0].Text)
+	}
+
+	for _, row := range rows {
+		for _, button := range row {
+			if len(button.CallbackData) > 64 {
+				t.Errorf("callback data exceeds 64 bytes: %q", button.CallbackData)
+			}
+		}
+	}
+}
+
+func TestHandleFileInfoHidesLocation(t *testing.T) {
+	dir := t.TempDir()
+	store, err := session.NewSQLiteStore(filepath.Join(dir, "test_file_info.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+	backend := storage.NewLocalBackend(filepath.Join(dir, "download"))
+	ctx := context.Background()
+
+	file := session.NewFile(1, "document", "tg-file-id", "user_1/report.pdf", 2048)
+	file.FileName, file.MimeType = "report.pdf", "application/pdf"
+	if err := store.CreateFile(ctx, file); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	api := testutil.NewFakeTelegram()
+	msg := callbackUpdate(1, "").CallbackQuery.Message.Message
+	handleFileInfo(ctx, api, msg, session.NewFileManager(store), backend, 1, fileInfoPrefix+file.ID.String())
+
+	text := api.LastText()
+	for _, want := range []string{"report.pdf", "Type: document", "2.0 KB", "Received: ", "application/pdf"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in file info:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, dir) || strings.Contains(text, "user_1/") {
+		t.Errorf("expected no storage location in file info:\n%s", text)
+	}
+}
+
+func TestFilesKeyboardIsSigned(t *testing.T) {
+	dir := t.TempDir()
+	store, err := session.NewSQLiteStore(filepath.Join(dir, "test_files_signed.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+	backend := storage.NewLocalBackend(filepath.Join(dir, "download"))
+	ctx := context.Background()
+
+	file := session.NewFile(1, "document", "tg-file-id", "user_1/report.pdf", 2048)
+	file.FileName = "report.pdf"
+	if err := store.CreateFile(ctx, file); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	cfg := &HandlerConfig{SessionsPerPage: 5, CallbackSigner: NewCallbackSigner("secret", time.Hour), CallbackTokens: store}
+	fileMgr := session.NewFileManager(store)
+	api := testutil.NewFakeTelegram()
+	FilesCommandHandler(fileMgr, cfg)(ctx, api, textUpdate(1, "/files"))
+
+	markup := api.Sent[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	unsigned := buildFilesKeyboard(en, []*session.File{file}, 0, false, false, 5).InlineKeyboard[0]
+	for i, button := range markup.InlineKeyboard[0] {
+		if button.CallbackData == unsigned[i].CallbackData || len(button.CallbackData) > 64 {
+			t.Errorf("expected signed callback data within 64 bytes, got %q", button.CallbackData)
+		}
+		if !strings.HasPrefix(button.CallbackData, FilesCallbackPrefix) {
+			t.Errorf("expected the data to keep the prefix the handler is registered for, got %q", button.CallbackData)
+		}
+	}
+
+	callback := FilesCallbackHandler(fileMgr, backend, cfg)
+	callback(ctx, api, pressButton(t, 1, markup, "report.pdf"))
+	if !strings.Contains(api.LastText(), "report.pdf") {
+		t.Errorf("expected the signed button to show the file info, got %q", api.LastText())
+	}
+
+	// Data the bot did not sign is refused
+	sent := len(api.Sent)
+	callback(ctx, api, callbackUpdate(1, fileInfoPrefix+file.ID.String()))
+	if len(api.Sent) != sent || !strings.Contains(api.CallbackAnswers[len(api.CallbackAnswers)-1].Text, "Invalid button") {
+		t.Errorf("expected unsigned data refused, got %+v", api.CallbackAnswers)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	}
+
+	for _, tt := range tests {
+		if result := formatBytes(tt.input); result != tt.expected {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestParsePageOffset(t *testing.T) {
+	if offset, err := parsePageOffset("file_page_12", filesPagePrefix); err != nil || offset != 12 {
+		t.Errorf("expected offset 12, got %d (err=%v)", offset, err)
+	}
+
+	for _, data := range []string{"file_page_", "file_page_abc", "file_page_-6", "page_sessions_6"} {
+		if _, err := parsePageOffset(data, filesPagePrefix); err == nil {
+			t.Errorf("expected error for %q", data)
+		}
+	}
+}
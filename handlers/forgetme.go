@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/retention"
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// ForgetMeCallbackPrefix is the common prefix of the /forgetme confirmation buttons
+const ForgetMeCallbackPrefix = "forget_"
+
+// Callback data prefixes for the /forgetme confirmation; the user ID follows so
+// only the user who asked can confirm
+const (
+	forgetConfirmPrefix = "forget_yes_"
+	forgetCancelPrefix  = "forget_no_"
+)
+
+// ForgetMeCommandHandler handles the /forgetme command.
+// It asks for confirmation before anything is deleted.
+func ForgetMeCommandHandler() HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+
+		LogInfo(ctx, "forgetme_command", userID, "user requested data purge", nil)
+
+		id := strconv.FormatInt(userID, 10)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text: tr.T("⚠️ This permanently deletes all your sessions, messages, downloaded files and settings. " +
+				"It cannot be undone.\n\nDelete everything?"),
+			ReplyMarkup: &models.InlineKeyboardMarkup{
+				InlineKeyboard: [][]models.InlineKeyboardButton{{
+					{Text: tr.T("🗑 Yes, delete everything"), CallbackData: forgetConfirmPrefix + id},
+					{Text: tr.T("Cancel"), CallbackData: forgetCancelPrefix + id},
+				}},
+			},
+		})
+	}
+}
+
+// ForgetMeCallbackHandler handles the /forgetme confirmation buttons
+func ForgetMeCallbackHandler(store session.PurgeStore, fileStorage storage.Backend, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		userID := callback.From.ID
+		data := callback.Data
+		tr := i18n.FromContext(ctx)
+
+		msg := callback.Message.Message
+		confirmed := strings.HasPrefix(data, forgetConfirmPrefix)
+		owner := strings.TrimPrefix(strings.TrimPrefix(data, forgetConfirmPrefix), forgetCancelPrefix)
+		if msg == nil || owner != strconv.FormatInt(userID, 10) {
+			LogWarning(ctx, "forgetme_callback", userID, "confirmation pressed by another user", map[string]interface{}{
+				"callback_data": data,
+			})
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: callback.ID,
+				Text:            tr.T("Only the user who sent /forgetme can answer this."),
+			})
+			return
+		}
+
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+		})
+
+		if !confirmed {
+			b.EditMessageText(ctx, &bot.EditMessageTextParams{
+				ChatID:    msg.Chat.ID,
+				MessageID: msg.ID,
+				Text:      tr.T("Nothing was deleted."),
+			})
+			return
+		}
+
+		// The prompt only loses its buttons; the report is a new message, so the
+		// outbox can retry it
+		var report *session.PurgeReport
+		err := cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			purged, failed, err := purgeUserData(ctx, store, fileStorage, userID)
+			if err != nil {
+				return nil, err
+			}
+			report = purged
+			cfg.recordAudit(ctx, userID, session.AuditUserPurge, strconv.FormatInt(userID, 10), data)
+
+			return &bot.SendMessageParams{
+				ChatID:          msg.Chat.ID,
+				MessageThreadID: topicThreadID(msg),
+				Text:            formatPurgeReport(tr, report, failed),
+			}, nil
+		})
+		if err != nil {
+			LogError(ctx, "forgetme_callback", userID, err, nil)
+			SendErrorResponse(ctx, b, msg, err)
+			return
+		}
+
+		LogInfo(ctx, "forgetme_callback", userID, "user data purged", map[string]interface{}{
+			"sessions": report.Sessions,
+			"messages": report.Messages,
+			"files":    report.Files,
+			"other":    report.Other,
+		})
+
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    msg.Chat.ID,
+			MessageID: msg.ID,
+			Text:      tr.T("✅ Your data was deleted."),
+		})
+	}
+}
+
+// purgeUserData deletes every row stored for userID and then the stored objects of
+// their files. It returns the number of objects that could not be removed from storage.
+func purgeUserData(ctx context.Context, store session.PurgeStore, fileStorage storage.Backend, userID int64) (*session.PurgeReport, int, error) {
+	report, err := store.PurgeUser(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	retention.TrackDeleted(report.Bytes)
+
+	failed := 0
+	for _, key := range report.StorageKeys {
+		if err := fileStorage.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			LogError(ctx, "purge_user", userID, err, map[string]interface{}{
+				"storage_key": key,
+			})
+			failed++
+		}
+	}
+	return report, failed, nil
+}
+
+// formatPurgeReport summarizes the deleted rows
+func formatPurgeReport(tr *i18n.Translator, report *session.PurgeReport, failed int) string {
+	text := tr.Sprintf("Sessions: %d\nMessages: %d\nFiles: %d\nOther records: %d",
+		report.Sessions, report.Messages, report.Files, report.Other)
+	if failed > 0 {
+		text += "\n" + tr.Sprintf("%d file(s) could not be removed from storage", failed)
+	}
+	return text
+}
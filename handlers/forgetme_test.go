@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func callbackUpdate(userID int64, data string) *models.Update {
+	return &models.Update{CallbackQuery: &models.CallbackQuery{
+		ID:   "callback",
+		From: models.User{ID: userID},
+		Data: data,
+		Message: models.MaybeInaccessibleMessage{
+			Type:    models.MaybeInaccessibleMessageTypeMessage,
+			Message: &models.Message{ID: 10, Chat: models.Chat{ID: userID}},
+		},
+	}}
+}
+
+func TestForgetMe(t *testing.T) {
+	dir := t.TempDir()
+	store, err := session.NewSQLiteStore(filepath.Join(dir, "test_forgetme.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+	backend := storage.NewLocalBackend(filepath.Join(dir, "download"))
+	ctx := context.Background()
+
+	sess, err := session.NewManager(store).CreateSession(ctx, 1, "hello")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := store.AppendMessage(ctx, session.NewMessage(sess.ID, 1, session.RoleUser, "hello")); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+	if _, err := backend.Put(ctx, "user_1/a.txt", strings.NewReader("data"), 4); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.CreateFile(ctx, session.NewFile(1, "document", "a.txt", "user_1/a.txt", 4)); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	api := testutil.NewFakeTelegram()
+	ForgetMeCommandHandler()(ctx, api, commandUpdate(1, "/forgetme"))
+	markup := api.Sent[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	confirm := markup.InlineKeyboard[0][0].CallbackData
+
+	// Another user cannot confirm
+	handler := ForgetMeCallbackHandler(store, backend, &HandlerConfig{Audit: store})
+	handler(ctx, api, callbackUpdate(2, confirm))
+	if count, _ := store.CountByUser(ctx, 1); count != 1 {
+		t.Fatalf("expected the data to survive a foreign confirmation, got %d sessions", count)
+	}
+
+	handler(ctx, api, callbackUpdate(1, confirm))
+	if len(api.EditedTexts) != 1 || api.EditedTexts[0].Text != "✅ Your data was deleted." {
+		t.Fatalf("expected the confirmation to be replaced, got %+v", api.EditedTexts)
+	}
+	summary := api.LastText()
+	for _, want := range []string{"Sessions: 1", "Messages: 1", "Files: 1"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected %q in summary:\n%s", want, summary)
+		}
+	}
+
+	if count, _ := store.CountByUser(ctx, 1); count != 0 {
+		t.Errorf("expected no sessions left, got %d", count)
+	}
+	if _, err := store.GetActiveSession(ctx, 1); err != session.ErrSessionNotFound {
+		t.Errorf("expected the active binding to be gone, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "download", "user_1", "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the stored file to be deleted, got %v", err)
+	}
+}
+
+func TestForgetMeCancel(t *testing.T) {
+	api := testutil.NewFakeTelegram()
+	ForgetMeCallbackHandler(nil, nil, nil)(context.Background(), api, callbackUpdate(1, forgetCancelPrefix+"1"))
+
+	if len(api.EditedTexts) != 1 || api.EditedTexts[0].Text != "Nothing was deleted." {
+		t.Errorf("expected the cancel message, got %+v", api.EditedTexts)
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// StopGenerationCallbackPrefix is the callback data of the button stopping an
+// assistant reply in progress, followed by the generation ID
+const StopGenerationCallbackPrefix = "stop_"
+
+// generationIDBytes is the number of random bytes in a generation ID
+const generationIDBytes = 6
+
+// streamEditInterval is the least time between two edits showing more of a
+// streamed reply, as Telegram limits how often a message may be edited
+const streamEditInterval = 2 * time.Second
+
+// errGenerationStopped is the cause of the context of a stopped generation
+var errGenerationStopped = errors.New("generation stopped by the user")
+
+// generation is an assistant reply in progress
+type generation struct {
+	userID int64 // the user the reply is for, the only one who may stop it
+	cancel context.CancelCauseFunc
+}
+
+// Generations tracks the assistant replies in progress so that their users can
+// stop them. State lives in memory only; a restart ends every generation.
+type Generations struct {
+	mu      sync.Mutex
+	running map[string]*generation
+}
+
+// NewGenerations creates an empty generation tracker
+func NewGenerations() *Generations {
+	return &Generations{running: make(map[string]*generation)}
+}
+
+// start tracks a generation for userID. The returned context is cancelled when
+// the user stops it; done must be called once the generation is over.
+func (g *Generations) start(ctx context.Context, userID int64) (genCtx context.Context, id string, done func()) {
+	raw := make([]byte, generationIDBytes)
+	rand.Read(raw)
+	id = base64.RawURLEncoding.EncodeToString(raw)
+
+	genCtx, cancel := context.WithCancelCause(ctx)
+	g.mu.Lock()
+	g.running[id] = &generation{userID: userID, cancel: cancel}
+	g.mu.Unlock()
+
+	return genCtx, id, func() {
+		g.mu.Lock()
+		delete(g.running, id)
+		g.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// Stop cancels the generation id of userID and reports whether one was running
+func (g *Generations) Stop(id string, userID int64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	running, ok := g.running[id]
+	if !ok || running.userID != userID {
+		return false
+	}
+	running.cancel(errGenerationStopped)
+	delete(g.running, id)
+	return true
+}
+
+// stopped reports whether the generation of ctx was stopped by its user
+func stopped(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errGenerationStopped)
+}
+
+// stopKeyboard returns the keyboard with the button stopping generation id
+func stopKeyboard(tr *i18n.Translator, id string) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: tr.T("⏹ Stop"), CallbackData: StopGenerationCallbackPrefix + id}},
+	}}
+}
+
+// previewStream returns the function showing the text of a reply streamed so
+// far in its status message, above the stop button. Text the content filter
+// would block is not shown; the finished reply is filtered again.
+func previewStream(ctx context.Context, b TelegramAPI, cfg *HandlerConfig, userID int64, status *models.Message,
+	stop *models.InlineKeyboardMarkup) func(text string) {
+	var shown time.Time
+	blocked := false
+	return func(text string) {
+		if blocked || time.Since(shown) < streamEditInterval {
+			return
+		}
+		preview, matches := filterContent(ctx, cfg, userID, text)
+		if cfg.blocksContent(matches) {
+			blocked = true
+			return
+		}
+		// Only the first part of a long reply fits in the status message
+		preview = format.SplitMarkdown(preview, format.MaxMessageLength)[0]
+
+		shown = time.Now()
+		_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      status.Chat.ID,
+			MessageID:   status.ID,
+			Text:        preview,
+			ReplyMarkup: stop,
+		})
+		if err != nil {
+			LogWarning(ctx, "assistant", userID, "failed to show the streamed reply", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// StopGenerationCallbackHandler handles the stop button under an assistant
+// reply in progress. The handler generating the reply finalizes its message
+// with the text streamed so far.
+// Its updates must not wait behind the generation they stop; see
+// updatequeue.Queue.SetExpress.
+func StopGenerationCallbackHandler(cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		userID := callback.From.ID
+		tr := i18n.FromContext(ctx)
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "stop_generation_callback", err, tr.T("⌛ This button expired."))
+			return
+		}
+
+		id := strings.TrimPrefix(data, StopGenerationCallbackPrefix)
+		if cfg.Generations == nil || !cfg.Generations.Stop(id, userID) {
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: callback.ID,
+				Text:            tr.T("There is no reply in progress to stop."),
+			})
+			return
+		}
+
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            tr.T("⏹ Stopping…"),
+		})
+		LogInfo(ctx, "stop_generation_callback", userID, "assistant reply stopped", map[string]interface{}{
+			"generation_id": id,
+		})
+	}
+}
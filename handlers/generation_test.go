@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// blockingProvider answers once its context ends or release is closed
+type blockingProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) Complete(ctx context.Context, _ *ai.Request) (*ai.Message, error) {
+	close(p.started)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.release:
+		return &ai.Message{Role: ai.RoleAssistant, Content: "Finished answer"}, nil
+	}
+}
+
+// streamingProvider streams text, then waits until its context ends
+type streamingProvider struct {
+	blockingProvider
+	text string
+}
+
+func (p *streamingProvider) Stream(ctx context.Context, _ *ai.Request, onText func(delta string)) (*ai.Message, error) {
+	onText(p.text)
+	return p.Complete(ctx, nil)
+}
+
+func newGenerationTest(t *testing.T, provider ai.Provider) (*session.SQLiteStore, *HandlerConfig, HandlerFunc) {
+	t.Helper()
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_generation.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	cfg := &HandlerConfig{
+		AIModels:    []string{"small"},
+		Assistant:   ai.NewAssistant(provider, ai.NewRegistry(), 0),
+		Generations: NewGenerations(),
+	}
+	return store, cfg, MessageHandler(session.NewManager(store), session.NewMessageManager(store), cfg)
+}
+
+func TestStopGeneration(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	store, cfg, handler := newGenerationTest(t, provider)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	finished := make(chan struct{})
+	go func() {
+		handler(ctx, api, textUpdate(1, "Tell me a long story"))
+		close(finished)
+	}()
+	select {
+	case <-provider.started:
+	case <-time.After(time.Second):
+		t.Fatal("the assistant was not asked")
+	}
+
+	// The placeholder is sent before the provider is asked
+	status := api.Sent[0]
+	if !strings.Contains(status.Text, "Generating") {
+		t.Fatalf("expected a placeholder while generating, got %q", status.Text)
+	}
+	markup := status.ReplyMarkup.(*models.InlineKeyboardMarkup)
+	stop := StopGenerationCallbackHandler(cfg)
+
+	stop(ctx, api, pressButton(t, 2, markup, "Stop"))
+	if !strings.Contains(api.CallbackAnswers[0].Text, "no reply in progress") {
+		t.Errorf("expected other users unable to stop the reply, got %q", api.CallbackAnswers[0].Text)
+	}
+
+	stop(ctx, api, pressButton(t, 1, markup, "Stop"))
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("the reply was not stopped")
+	}
+	if len(api.EditedTexts) != 1 || !strings.Contains(api.EditedTexts[0].Text, "stopped") || api.EditedTexts[0].ReplyMarkup != nil {
+		t.Fatalf("expected the placeholder finalized without the button, got %+v", api.EditedTexts)
+	}
+
+	active, err := session.NewManager(store).GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	history, err := session.NewMessageManager(store).History(ctx, active.ID, 10)
+	if err != nil || len(history) != 1 {
+		t.Errorf("expected only the question stored, got %d messages (err=%v)", len(history), err)
+	}
+
+	stop(ctx, api, pressButton(t, 1, markup, "Stop"))
+	if !strings.Contains(api.CallbackAnswers[len(api.CallbackAnswers)-1].Text, "no reply in progress") {
+		t.Error("expected a finished generation to have nothing to stop")
+	}
+}
+
+func TestStopStreamedGeneration(t *testing.T) {
+	provider := &streamingProvider{
+		blockingProvider: blockingProvider{started: make(chan struct{}), release: make(chan struct{})},
+		text:             "Once upon a time",
+	}
+	store, cfg, handler := newGenerationTest(t, provider)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	finished := make(chan struct{})
+	go func() {
+		handler(ctx, api, textUpdate(1, "Tell me a long story"))
+		close(finished)
+	}()
+	select {
+	case <-provider.started:
+	case <-time.After(time.Second):
+		t.Fatal("the assistant was not asked")
+	}
+
+	// The text streamed so far shows in the placeholder, above the stop button
+	markup := api.Sent[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	if len(api.EditedTexts) != 1 || api.EditedTexts[0].Text != "Once upon a time" ||
+		api.EditedTexts[0].ReplyMarkup.(*models.InlineKeyboardMarkup) != markup {
+		t.Fatalf("expected the streamed text shown with the stop button, got %+v", api.EditedTexts)
+	}
+
+	StopGenerationCallbackHandler(cfg)(ctx, api, pressButton(t, 1, markup, "Stop"))
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("the reply was not stopped")
+	}
+
+	// The streamed text is finalized as the reply, with the reply's buttons
+	final := api.EditedTexts[len(api.EditedTexts)-1]
+	keyboard, ok := final.ReplyMarkup.(*models.InlineKeyboardMarkup)
+	if final.Text != "Once upon a time" || !ok || !strings.HasPrefix(keyboard.InlineKeyboard[0][0].CallbackData, RegenerateCallbackPrefix) {
+		t.Fatalf("expected the partial reply finalized, got %+v", final)
+	}
+
+	active, err := session.NewManager(store).GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	history, err := session.NewMessageManager(store).History(ctx, active.ID, 10)
+	if err != nil || len(history) != 2 || history[1].Content != "Once upon a time" || history[1].TelegramMessageID != final.MessageID {
+		t.Errorf("expected the partial reply stored, got %+v (err=%v)", history, err)
+	}
+}
+
+func TestGenerationFinishesInPlaceholder(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	close(provider.release)
+	store, _, handler := newGenerationTest(t, provider)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, textUpdate(1, "hello"))
+
+	if len(api.Sent) != 1 || len(api.EditedTexts) != 1 || api.EditedTexts[0].Text != "Finished answer" {
+		t.Fatalf("expected the placeholder edited into the reply, got %d sent and %+v", len(api.Sent), api.EditedTexts)
+	}
+	markup, ok := api.EditedTexts[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	if !ok || !strings.HasPrefix(markup.InlineKeyboard[0][0].CallbackData, RegenerateCallbackPrefix) {
+		t.Errorf("expected the reply's buttons in place of the stop button, got %+v", api.EditedTexts[0].ReplyMarkup)
+	}
+
+	active, err := session.NewManager(store).GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	history, err := session.NewMessageManager(store).History(ctx, active.ID, 10)
+	if err != nil || len(history) != 2 || history[1].TelegramMessageID != api.EditedTexts[0].MessageID {
+		t.Errorf("expected the stored reply pointing at the placeholder, got %+v (err=%v)", history, err)
+	}
+}
+
+// failingEdits is a fake Telegram whose first edits fail
+type failingEdits struct {
+	*testutil.FakeTelegram
+	failures int
+}
+
+func (f *failingEdits) EditMessageText(ctx context.Context, params *bot.EditMessageTextParams) (*models.Message, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("Bad Request: message to edit not found")
+	}
+	return f.FakeTelegram.EditMessageText(ctx, params)
+}
+
+func TestGenerationDeliveryFailures(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	close(provider.release)
+	store, _, handler := newGenerationTest(t, provider)
+	ctx := context.Background()
+
+	// The placeholder cannot be edited: the reply goes out on its own and the placeholder loses its button
+	api := &failingEdits{FakeTelegram: testutil.NewFakeTelegram(), failures: 1}
+	handler(ctx, api, textUpdate(1, "hello"))
+	if len(api.Sent) != 2 || api.Sent[1].Text != "Finished answer" {
+		t.Fatalf("expected the reply sent as a new message, got %+v", api.Sent)
+	}
+	if len(api.EditedTexts) != 1 || !strings.Contains(api.EditedTexts[0].Text, "new message") || api.EditedTexts[0].ReplyMarkup != nil {
+		t.Errorf("expected the placeholder replaced without the button, got %+v", api.EditedTexts)
+	}
+
+	active, err := session.NewManager(store).GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	history, err := session.NewMessageManager(store).History(ctx, active.ID, 10)
+	if err != nil || len(history) != 2 || history[1].Role != session.RoleAssistant {
+		t.Fatalf("expected the reply stored, got %+v (err=%v)", history, err)
+	}
+
+	// Nothing can be delivered: the reply is still stored and the question no longer unread
+	provider = &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	close(provider.release)
+	store, _, handler = newGenerationTest(t, provider)
+	failing := testutil.NewFakeTelegram()
+	handler(ctx, &sendFailsAfterStatus{FakeTelegram: failing}, textUpdate(1, "hello"))
+	if len(failing.EditedTexts) != 1 || !strings.Contains(failing.EditedTexts[0].Text, "could not be delivered") {
+		t.Errorf("expected the placeholder replaced with a notice, got %+v", failing.EditedTexts)
+	}
+
+	active, err = session.NewManager(store).GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	if active.UnreadCount != 0 {
+		t.Errorf("expected the question answered, got %d unread", active.UnreadCount)
+	}
+	history, err = session.NewMessageManager(store).History(ctx, active.ID, 10)
+	if err != nil || len(history) != 2 || history[1].Content != "Finished answer" || history[1].TelegramMessageID != 0 {
+		t.Errorf("expected the undelivered reply stored, got %+v (err=%v)", history, err)
+	}
+}
+
+// sendFailsAfterStatus is a fake Telegram that accepts the placeholder, then
+// fails to send or edit anything except the placeholder's last replacement
+type sendFailsAfterStatus struct {
+	*testutil.FakeTelegram
+	sent  int
+	edits int
+}
+
+func (f *sendFailsAfterStatus) SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error) {
+	if f.sent++; f.sent > 1 {
+		return nil, errors.New("Forbidden: bot was blocked by the user")
+	}
+	return f.FakeTelegram.SendMessage(ctx, params)
+}
+
+func (f *sendFailsAfterStatus) EditMessageText(ctx context.Context, params *bot.EditMessageTextParams) (*models.Message, error) {
+	if f.edits++; f.edits == 1 {
+		return nil, errors.New("Bad Request: message to edit not found")
+	}
+	return f.FakeTelegram.EditMessageText(ctx, params)
+}
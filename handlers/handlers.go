@@ -2,8 +2,15 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"tg-bot-demo/ai"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/moderation"
+	"tg-bot-demo/outbox"
 	"tg-bot-demo/session"
+	"tg-bot-demo/settings"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -14,175 +21,417 @@ import (
 
 // HandlerConfig holds configuration for handlers
 type HandlerConfig struct {
-	SessionsPerPage int
+	SessionsPerPage    int
+	AdminUserIDs       []int64
+	QuickSwitchButtons bool                        // show new session / sessions buttons under replies
+	CallbackSigner     *CallbackSigner             // signs session keyboard callback data; nil disables signing
+	CallbackTokens     session.CallbackTokenStore  // keeps callback data over 64 bytes; nil rejects such data
+	UserQuotaBytes     int64                       // per-user storage quota shown by /whoami; 0 means unlimited
+	Version            string                      // bot version shown by /whoami
+	Settings           *settings.Settings          // runtime overrides of the fields above; nil uses them as is
+	AIModels           []string                    // models offered in /settings; the first is the default
+	Assistant          *ai.Assistant               // answers text messages; nil only confirms them
+	Sampling           SamplingLimits              // ranges of the AI parameters users set with /settings ai
+	Memory             session.MemoryStore         // values remembered with /memory, given to the assistant; nil gives none
+	Summaries          session.SummaryStore        // summaries made with /summary, given to the assistant for cut history; nil keeps none
+	ContentFilter      moderation.Filter           // checks text messages and assistant replies; nil disables filtering
+	FilterAction       moderation.Action           // what happens to content the filter matched
+	FlaggedContent     session.FlaggedContentStore // review queue of matched content; nil only logs matches
+	Audit              session.AuditStore          // records deletes, purges and admin commands; nil disables the audit log
+	Roles              session.RoleStore           // moderator and admin roles besides AdminUserIDs; nil gives none
+	CommandRoles       map[string]session.UserRole // least role per command; nil uses DefaultCommandRoles
+	Outbox             *outbox.Outbox              // retries confirmations of stored changes; nil sends them once
+	Generations        *Generations                // assistant replies in progress, stopped with a button; nil offers no stop button
+}
+
+// confirmWrite runs write, which stores a change and returns the reply
+// confirming it, or nil for none. Through the outbox the reply is queued in the
+// write's transaction, so a crash right after the change cannot lose it, and it
+// is retried until delivered. write's error is returned and nothing is sent then.
+func (cfg *HandlerConfig) confirmWrite(ctx context.Context, b TelegramAPI, userID int64, write outbox.Write) error {
+	if cfg == nil || cfg.Outbox == nil {
+		params, err := write(ctx)
+		if err == nil && params != nil {
+			b.SendMessage(ctx, params)
+		}
+		return err
+	}
+	return cfg.Outbox.SendAfter(ctx, b, userID, write)
+}
+
+// sessionsPerPage returns the page size of session and file lists
+func (cfg *HandlerConfig) sessionsPerPage(ctx context.Context) int {
+	if cfg.Settings == nil {
+		return cfg.SessionsPerPage
+	}
+	return int(cfg.Settings.Int(ctx, settings.SessionsPerPage))
+}
+
+// userQuotaBytes returns the per-user storage quota
+func (cfg *HandlerConfig) userQuotaBytes(ctx context.Context) int64 {
+	if cfg.Settings == nil {
+		return cfg.UserQuotaBytes
+	}
+	return cfg.Settings.Int(ctx, settings.UserQuotaBytes)
 }
 
 // OpenCommandHandler handles the /open command.
 // It creates and activates a new session.
-func OpenCommandHandler(sessionMgr *session.Manager) bot.HandlerFunc {
-	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+func OpenCommandHandler(sessionMgr *session.Manager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
 		userID := update.Message.From.ID
 
-		LogInfo("open_command", userID, "user requested new session", nil)
+		LogInfo(ctx, "open_command", userID, "user requested new session", nil)
 
-		sess, err := sessionMgr.CreateSession(ctx, userID, "")
-		if err != nil {
-			LogError("open_command", userID, err, nil)
-			SendErrorResponse(ctx, b, update.Message.Chat.ID, err)
-			return
-		}
+		err := cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			sess, err := sessionMgr.InTopic(MessageTopic(update.Message)).CreateSession(ctx, userID, "")
+			if err != nil {
+				return nil, err
+			}
 
-		LogInfo("open_command", userID, "new session opened", map[string]interface{}{
-			"session_id":    sess.ID.String(),
-			"session_title": sess.Title,
-		})
+			LogInfo(ctx, "open_command", userID, "new session opened", map[string]interface{}{
+				"session_id":    sess.ID.String(),
+				"session_title": sess.Title,
+			})
 
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   fmt.Sprintf("✅ Opened new session: %s", sess.Title),
+			return &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            i18n.FromContext(ctx).Sprintf("✅ Opened new session: %s", format.Bold(sess.Title)),
+				ParseMode:       models.ParseModeHTML,
+			}, nil
 		})
+		if err != nil {
+			LogError(ctx, "open_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+		}
 	}
 }
 
 // CloseCommandHandler handles the /close command.
 // It closes the currently active session binding for the user.
-func CloseCommandHandler(sessionMgr *session.Manager) bot.HandlerFunc {
-	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+func CloseCommandHandler(sessionMgr *session.Manager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
 		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
 
-		LogInfo("close_command", userID, "user requested close active session", nil)
+		LogInfo(ctx, "close_command", userID, "user requested close active session", nil)
 
-		sess, closed, err := sessionMgr.CloseActiveSession(ctx, userID)
+		closed := false
+		err := cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			sess, ok, err := sessionMgr.InTopic(MessageTopic(update.Message)).CloseActiveSession(ctx, userID)
+			if err != nil || !ok {
+				return nil, err
+			}
+			closed = true
+
+			LogInfo(ctx, "close_command", userID, "active session closed", map[string]interface{}{
+				"session_id":    sess.ID.String(),
+				"session_title": sess.Title,
+			})
+
+			return &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.Sprintf("✅ Closed session: %s\nYour next message will start a new session.", format.Bold(sess.Title)),
+				ParseMode:       models.ParseModeHTML,
+				ReplyMarkup:     cfg.callbacks().EncodeKeyboard(ctx, buildCloseKeyboard(tr)),
+			}, nil
+		})
 		if err != nil {
-			LogError("close_command", userID, err, nil)
-			SendErrorResponse(ctx, b, update.Message.Chat.ID, err)
+			LogError(ctx, "close_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
 			return
 		}
 
 		if !closed {
-			LogInfo("close_command", userID, "no active session to close", nil)
+			LogInfo(ctx, "close_command", userID, "no active session to close", nil)
 			b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: update.Message.Chat.ID,
-				Text:   "No active session to close. Use /open to start one.",
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.T("No active session to close. Use /open to start one."),
 			})
-			return
 		}
-
-		LogInfo("close_command", userID, "active session closed", map[string]interface{}{
-			"session_id":    sess.ID.String(),
-			"session_title": sess.Title,
-		})
-
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   fmt.Sprintf("✅ Closed session: %s", sess.Title),
-		})
 	}
 }
 
 // SessionsCommandHandler handles the /sessions command
-func SessionsCommandHandler(sessionMgr *session.Manager, cfg *HandlerConfig) bot.HandlerFunc {
-	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
-		userID := update.Message.From.ID
-
-		LogInfo("sessions_command", userID, "user requested session list", nil)
-
-		// Get first page of sessions
-		sessions, hasNext, err := sessionMgr.ListSessions(ctx, userID, 0, cfg.SessionsPerPage)
-		if err != nil {
-			LogError("sessions_command", userID, err, map[string]interface{}{
-				"offset": 0,
-				"limit":  cfg.SessionsPerPage,
-			})
-			SendErrorResponse(ctx, b, update.Message.Chat.ID, err)
-			return
-		}
-
-		// Handle empty sessions
-		if len(sessions) == 0 {
-			LogInfo("sessions_command", userID, "no sessions found", nil)
-			b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: update.Message.Chat.ID,
-				Text:   "You don't have any sessions yet. Start chatting to create one!",
-			})
-			return
-		}
+func SessionsCommandHandler(sessionMgr *session.Manager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		sendSessionList(ctx, b, sessionMgr, cfg, update.Message.From.ID, update.Message)
+	}
+}
 
-		// Build inline keyboard
-		keyboard := buildSessionKeyboard(sessions, 0, false, hasNext, cfg.SessionsPerPage)
+// sendSessionList replies to msg with the first page of the user's sessions
+func sendSessionList(ctx context.Context, b TelegramAPI, sessionMgr *session.Manager, cfg *HandlerConfig, userID int64, msg *models.Message) {
+	LogInfo(ctx, "sessions_command", userID, "user requested session list", nil)
+	tr := i18n.FromContext(ctx)
+	perPage := cfg.sessionsPerPage(ctx)
 
-		LogInfo("sessions_command", userID, "session list sent", map[string]interface{}{
-			"session_count": len(sessions),
-			"has_prev":      false,
-			"has_next":      hasNext,
+	// Get first page of sessions
+	sessions, hasNext, err := sessionMgr.ListSessions(ctx, userID, 0, perPage)
+	if err != nil {
+		LogError(ctx, "sessions_command", userID, err, map[string]interface{}{
+			"offset": 0,
+			"limit":  perPage,
 		})
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
 
+	// Handle empty sessions
+	if len(sessions) == 0 {
+		LogInfo(ctx, "sessions_command", userID, "no sessions found", nil)
 		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:      update.Message.Chat.ID,
-			Text:        "Your sessions:",
-			ReplyMarkup: keyboard,
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            tr.T("You don't have any sessions yet. Start chatting to create one!"),
 		})
+		return
 	}
+
+	total, err := sessionMgr.CountSessions(ctx, userID)
+	if err != nil {
+		LogError(ctx, "sessions_command", userID, err, nil)
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	// Build inline keyboard
+	activeID := activeSessionID(ctx, sessionMgr.InTopic(MessageTopic(msg)), userID)
+	keyboard := cfg.callbacks().EncodeKeyboard(ctx, buildSessionListKeyboard(tr, sessions, activeID, 0, false, hasNext, perPage, total))
+
+	LogInfo(ctx, "sessions_command", userID, "session list sent", map[string]interface{}{
+		"session_count": len(sessions),
+		"has_prev":      false,
+		"has_next":      hasNext,
+	})
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          msg.Chat.ID,
+		MessageThreadID: topicThreadID(msg),
+		Text:            formatSessionListHeader(tr, 0, len(sessions), total, perPage),
+		ReplyMarkup:     keyboard,
+	})
+}
+
+// sessionCallbackRouter routes the buttons of the session list, the session
+// shortcuts shown after /close and under replies, and expiry warnings
+func sessionCallbackRouter(sessionMgr *session.Manager, bulk session.BulkStore, cfg *HandlerConfig) *CallbackRouter {
+	router := NewCallbackRouter()
+	registerBulkRoutes(router, sessionMgr, bulk, cfg)
+	router.HandlePrefix(openSessionPrefix, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		handleOpenSession(ctx, b, req, sessionMgr, cfg)
+	})
+	router.HandlePrefix(keepSessionPrefix, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		handleKeepSession(ctx, b, req, sessionMgr, cfg)
+	})
+	router.HandlePrefix(sessionsPagePrefix, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		handlePageSessions(ctx, b, req, sessionMgr, cfg.sessionsPerPage(ctx), cfg.callbacks())
+	})
+	router.Handle(closeReopenCallback, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		handleReopenLastSession(ctx, b, req.Query, sessionMgr, cfg, req.UserID)
+	})
+	router.Handle(noopCallback, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		// Informational button such as the page indicator; nothing to do
+		LogDebug(ctx, "callback_query", req.UserID, "informational button pressed", nil)
+	})
+	router.Handle(newSessionCallback, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		handleStartNewSession(ctx, b, req.Query, sessionMgr, cfg, req.UserID)
+	})
+	router.Handle(listSessionsCallback, func(ctx context.Context, b TelegramAPI, req *CallbackRequest) {
+		if msg := req.Message(); msg != nil {
+			sendSessionList(ctx, b, sessionMgr, cfg, req.UserID, msg)
+		}
+	})
+	return router
 }
 
-// CallbackQueryHandler handles inline keyboard button clicks
-func CallbackQueryHandler(sessionMgr *session.Manager, cfg *HandlerConfig) bot.HandlerFunc {
-	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+// CallbackQueryHandler handles inline keyboard button clicks on session menus.
+// New buttons are added as routes in sessionCallbackRouter.
+func CallbackQueryHandler(sessionMgr *session.Manager, bulk session.BulkStore, cfg *HandlerConfig) HandlerFunc {
+	router := sessionCallbackRouter(sessionMgr, bulk, cfg)
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
 		callback := update.CallbackQuery
 		userID := callback.From.ID
-		data := callback.Data
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "callback_query", err, i18n.FromContext(ctx).T("⌛ This menu expired. Send /sessions to get a fresh one."))
+			return
+		}
 
 		// Answer callback immediately
 		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 			CallbackQueryID: callback.ID,
 		})
 
-		// Route based on callback data prefix
-		if len(data) >= 7 && data[:7] == "open_s_" {
-			handleOpenSession(ctx, b, callback, sessionMgr, userID, data)
-		} else if len(data) >= 14 && data[:14] == "page_sessions_" {
-			handlePageSessions(ctx, b, callback, sessionMgr, userID, data, cfg.SessionsPerPage)
-		} else {
-			// Invalid callback data, log warning
-			LogWarning("callback_query", userID, "invalid callback data format", map[string]interface{}{
+		handler, param, ok := router.Match(data)
+		if !ok {
+			LogWarning(ctx, "callback_query", userID, "invalid callback data format", map[string]interface{}{
 				"callback_data": data,
 			})
+			return
 		}
+		handler(ctx, b, &CallbackRequest{Query: callback, UserID: userID, Data: data, Param: param})
 	}
 }
 
-// MessageHandler handles regular text messages from users
-func MessageHandler(sessionMgr *session.Manager) bot.HandlerFunc {
-	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+// MessageHandler handles regular text messages from users.
+// Each message is stored in the active session's history and answered by the
+// AI assistant when one is configured.
+func MessageHandler(sessionMgr *session.Manager, messageMgr *session.MessageManager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
 		// Extract user ID and message text
 		userID := update.Message.From.ID
 		messageText := update.Message.Text
 
-		LogDebug("message_handler", userID, "processing message", map[string]interface{}{
+		LogDebug(ctx, "message_handler", userID, "processing message", map[string]interface{}{
 			"message_length": len(messageText),
 		})
 
-		// Get or create active session for this user
-		activeSession, err := sessionMgr.GetOrCreateActiveSession(ctx, userID, messageText)
+		// Check the message before it can reach a session, its title included
+		messageText, matches := filterContent(ctx, cfg, userID, messageText)
+		if cfg.blocksContent(matches) {
+			flagContent(ctx, cfg, &session.FlaggedContent{
+				UserID:    userID,
+				ChatID:    update.Message.Chat.ID,
+				Direction: session.DirectionInbound,
+				Text:      update.Message.Text,
+			}, matches)
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            i18n.FromContext(ctx).T("🚫 Your message was blocked by the content filter."),
+			})
+			return
+		}
+
+		// Get or create active session for this user; forum topics have their own
+		activeSession, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetOrCreateActiveSession(ctx, userID, messageText)
 		if err != nil {
-			LogError("message_handler", userID, err, map[string]interface{}{
+			LogError(ctx, "message_handler", userID, err, map[string]interface{}{
 				"message_length": len(messageText),
 			})
-			SendErrorResponse(ctx, b, update.Message.Chat.ID, err)
+			SendErrorResponse(ctx, b, update.Message, err)
 			return
 		}
 
-		LogInfo("message_handler", userID, "message routed to session", map[string]interface{}{
+		message := session.NewMessage(activeSession.ID, userID, session.RoleUser, messageText)
+		message.ChatID = update.Message.Chat.ID
+		message.TelegramMessageID = update.Message.ID
+
+		// Replies from here on answer the stored message
+		replyCtx := WithReplyOrigin(ctx, activeSession.ID, message.ID)
+		err = cfg.confirmWrite(replyCtx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			if err := messageMgr.AddMessage(ctx, message); err != nil {
+				return nil, err
+			}
+
+			// Keep the session list preview current; the message itself is stored already
+			if err := sessionMgr.TouchSession(ctx, activeSession.ID, messageText); err != nil {
+				LogError(ctx, "message_handler", userID, err, map[string]interface{}{
+					"session_id": activeSession.ID.String(),
+				})
+			}
+
+			if cfg.Assistant != nil {
+				return nil, nil
+			}
+
+			// Without an AI provider, confirm that the message was received in the session
+			tr := i18n.FromContext(ctx)
+			params := &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.Sprintf("Message received in session: %s", format.Bold(activeSession.Title)),
+				ParseMode:       models.ParseModeHTML,
+			}
+			if cfg.QuickSwitchButtons {
+				params.ReplyMarkup = cfg.callbacks().EncodeKeyboard(ctx, buildQuickSwitchKeyboard(tr))
+			}
+			return params, nil
+		})
+		if err != nil {
+			LogError(ctx, "message_handler", userID, err, map[string]interface{}{
+				"session_id": activeSession.ID.String(),
+			})
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+		ctx = replyCtx
+
+		flagContent(ctx, cfg, &session.FlaggedContent{
+			UserID:    userID,
+			ChatID:    update.Message.Chat.ID,
+			SessionID: activeSession.ID,
+			Direction: session.DirectionInbound,
+			Text:      update.Message.Text,
+		}, matches)
+
+		LogInfo(ctx, "message_handler", userID, "message routed to session", map[string]interface{}{
 			"session_id":    activeSession.ID.String(),
 			"session_title": activeSession.Title,
 		})
 
-		// Route message to active session context
-		// In a real implementation, this would forward the message to the AI service
-		// For now, we'll send a confirmation that the message was received in the session
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   fmt.Sprintf("Message received in session: %s", activeSession.Title),
+		if cfg.Assistant != nil {
+			replyWithAssistant(ctx, b, update.Message, activeSession, sessionMgr, messageMgr, cfg)
+		}
+	}
+}
+
+// EditedMessageHandler handles edits of text messages.
+// An edit of a stored message replaces its content in the session history
+// instead of being treated as new input; edits of unknown messages are ignored,
+// as are edits the content filter blocks.
+func EditedMessageHandler(messageMgr *session.MessageManager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		edited := update.EditedMessage
+		userID := int64(0)
+		if edited.From != nil {
+			userID = edited.From.ID
+		}
+
+		editedAt := time.Now()
+		if edited.EditDate != 0 {
+			editedAt = time.Unix(int64(edited.EditDate), 0)
+		}
+
+		text, matches := filterContent(ctx, cfg, userID, edited.Text)
+		flagged := &session.FlaggedContent{
+			UserID:    userID,
+			ChatID:    edited.Chat.ID,
+			Direction: session.DirectionInbound,
+			Text:      edited.Text,
+		}
+		if cfg.blocksContent(matches) {
+			flagContent(ctx, cfg, flagged, matches)
+			return
+		}
+
+		message, err := messageMgr.EditMessage(ctx, edited.Chat.ID, edited.ID, text, editedAt)
+		if err != nil {
+			if errors.Is(err, session.ErrMessageNotFound) {
+				LogDebug(ctx, "edited_message", userID, "edited message not stored, ignoring", map[string]interface{}{
+					"chat_id":    edited.Chat.ID,
+					"message_id": edited.ID,
+				})
+				return
+			}
+			LogError(ctx, "edited_message", userID, err, map[string]interface{}{
+				"chat_id":    edited.Chat.ID,
+				"message_id": edited.ID,
+			})
+			return
+		}
+
+		flagged.SessionID = message.SessionID
+		flagContent(ctx, cfg, flagged, matches)
+
+		LogInfo(ctx, "edited_message", userID, "stored message updated", map[string]interface{}{
+			"session_id":     message.SessionID.String(),
+			"message_id":     edited.ID,
+			"message_length": len(edited.Text),
 		})
 	}
 }
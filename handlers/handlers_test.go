@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tg-bot-demo/outbox"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func newTestSessionManager(t *testing.T) *session.Manager {
+	t.Helper()
+
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_handlers.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return session.NewManager(store)
+}
+
+func commandUpdate(userID int64, text string) *models.Update {
+	return &models.Update{Message: &models.Message{
+		From: &models.User{ID: userID},
+		Chat: models.Chat{ID: userID},
+		Text: text,
+	}}
+}
+
+func TestOpenCommandHandler(t *testing.T) {
+	sessionMgr := newTestSessionManager(t)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	OpenCommandHandler(sessionMgr, &HandlerConfig{})(ctx, api, commandUpdate(1, "/open"))
+
+	active, err := sessionMgr.GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	if !strings.Contains(api.LastText(), "Opened new session") || !strings.Contains(api.LastText(), active.Title) {
+		t.Errorf("Expected confirmation naming %q, got %q", active.Title, api.LastText())
+	}
+}
+
+func TestCloseCommandHandlerWithoutSession(t *testing.T) {
+	sessionMgr := newTestSessionManager(t)
+	api := testutil.NewFakeTelegram()
+
+	CloseCommandHandler(sessionMgr, &HandlerConfig{SessionsPerPage: 6})(context.Background(), api, commandUpdate(1, "/close"))
+
+	if !strings.Contains(api.LastText(), "No active session to close") {
+		t.Errorf("Expected no-session reply, got %q", api.LastText())
+	}
+}
+
+func TestSessionsCommandHandlerMarksActiveSession(t *testing.T) {
+	sessionMgr := newTestSessionManager(t)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	if _, err := sessionMgr.CreateSession(ctx, 1, "Older"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if _, err := sessionMgr.CreateSession(ctx, 1, "Newer"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	SessionsCommandHandler(sessionMgr, &HandlerConfig{SessionsPerPage: 6})(ctx, api, commandUpdate(1, "/sessions"))
+
+	markup, ok := api.Sent[len(api.Sent)-1].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	if !ok {
+		t.Fatalf("expected an inline keyboard, got %T", api.Sent[len(api.Sent)-1].ReplyMarkup)
+	}
+	labels := keyboardLabels(markup)
+	if !strings.Contains(labels, marker(LabelActiveSession)+"Newer") || strings.Contains(labels, marker(LabelActiveSession)+"Older") {
+		t.Errorf("expected only the active session to be marked, got %q", labels)
+	}
+}
+
+func TestMessageHandlerUpdatesSessionPreview(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_preview.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	sessionMgr := session.NewManager(store)
+	handler := MessageHandler(sessionMgr, session.NewMessageManager(store), &HandlerConfig{})
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, textUpdate(1, "first question"))
+	handler(ctx, api, textUpdate(1, "follow-up"))
+
+	active, err := sessionMgr.GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	if active.Title != "first question" || active.LastMessage != "follow-up" {
+		t.Errorf("expected the title to stay and the preview to follow, got %q / %q", active.Title, active.LastMessage)
+	}
+}
+
+func TestMessageHandlerQueuesUndeliveredConfirmation(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_outbox.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	handler := MessageHandler(session.NewManager(store), session.NewMessageManager(store), &HandlerConfig{Outbox: outbox.New(store)})
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	api.Err = errors.New("connection reset")
+	handler(ctx, api, textUpdate(1, "hello"))
+
+	queued, err := store.ListDueOutbox(ctx, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListDueOutbox failed: %v", err)
+	}
+	if len(queued) != 1 || queued[0].UserID != 1 || !strings.Contains(queued[0].Text, "Message received in session") {
+		t.Errorf("expected the confirmation kept for a retry, got %+v", queued)
+	}
+}
+
+func TestOpenCommandHandlerQueuesUndeliveredConfirmation(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_outbox.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	handler := OpenCommandHandler(session.NewManager(store), &HandlerConfig{Outbox: outbox.New(store)})
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	api.Err = errors.New("connection reset")
+	handler(ctx, api, commandUpdate(1, "/open"))
+
+	queued, err := store.ListDueOutbox(ctx, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListDueOutbox failed: %v", err)
+	}
+	if len(queued) != 1 || !strings.Contains(queued[0].Text, "Opened new session") {
+		t.Errorf("expected the confirmation kept for a retry, got %+v", queued)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
 	"tg-bot-demo/session"
 	"time"
 	"unicode/utf8"
@@ -14,29 +17,40 @@ import (
 	"github.com/google/uuid"
 )
 
+// noopCallback is the callback data of buttons that only display information
+const noopCallback = "noop"
+
+// Callback data of the session shortcut buttons shown after /close and under replies
 const (
-	prevPageButtonText = "↑ 𝐏𝐫𝐞𝐯"
-	nextPageButtonText = "↓ 𝐍𝐞𝐱𝐭"
+	closeReopenCallback  = "close_reopen"
+	newSessionCallback   = "new_session"
+	listSessionsCallback = "list_sessions"
+)
+
+// Callback data prefixes of the /sessions list
+const (
+	openSessionPrefix  = "open_s_"        // followed by a session ID
+	sessionsPagePrefix = "page_sessions_" // followed by a page offset
 )
 
 // formatTimeAgo converts a timestamp to relative time string
-func formatTimeAgo(t time.Time) string {
+func formatTimeAgo(tr *i18n.Translator, t time.Time) string {
 	duration := time.Since(t)
 
 	switch {
 	case duration < time.Minute:
-		return "just now"
+		return tr.T("just now")
 	case duration < time.Hour:
 		mins := int(duration.Minutes())
-		return fmt.Sprintf("%dm ago", mins)
+		return tr.Sprintf("%dm ago", mins)
 	case duration < 24*time.Hour:
 		hours := int(duration.Hours())
-		return fmt.Sprintf("%dh ago", hours)
+		return tr.Sprintf("%dh ago", hours)
 	case duration < 7*24*time.Hour:
 		days := int(duration.Hours() / 24)
-		return fmt.Sprintf("%dd ago", days)
+		return tr.Sprintf("%dd ago", days)
 	default:
-		return t.Format("Jan 2")
+		return tr.FormatTime(t, "Jan 2")
 	}
 }
 
@@ -49,133 +63,238 @@ func truncate(s string, maxLen int) string {
 	return string(runes[:maxLen-3]) + "..."
 }
 
-// buildSessionKeyboard creates an inline keyboard for session list
-func buildSessionKeyboard(sessions []*session.Session, offset int, hasPrev bool, hasNext bool, sessionsPerPage int) *models.InlineKeyboardMarkup {
+// buildSessionKeyboard creates an inline keyboard for session list.
+// The button of the active session, activeID, is marked and does nothing when
+// pressed; uuid.Nil marks none. total is the user's session count, used for
+// the page indicator.
+func buildSessionKeyboard(tr *i18n.Translator, sessions []*session.Session, activeID uuid.UUID, offset int, hasPrev bool, hasNext bool, sessionsPerPage int, total int) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+
+	// Add session buttons (one per row)
+	for _, s := range sessions {
+		button := models.InlineKeyboardButton{
+			Text:         formatSessionButton(tr, s),
+			CallbackData: openSessionPrefix + s.ID.String(),
+		}
+		if s.ID == activeID {
+			button.Text = marker(LabelActiveSession) + button.Text
+			button.CallbackData = noopCallback
+		}
+		rows = append(rows, []models.InlineKeyboardButton{button})
+	}
+
+	return buildPagedKeyboard(tr, rows, sessionsPagePrefix, offset, hasPrev, hasNext, sessionsPerPage, total)
+}
+
+// buildSessionListKeyboard is buildSessionKeyboard with a row entering the
+// multi-select mode of the page below it
+func buildSessionListKeyboard(tr *i18n.Translator, sessions []*session.Session, activeID uuid.UUID, offset int, hasPrev bool, hasNext bool, sessionsPerPage int, total int) *models.InlineKeyboardMarkup {
+	keyboard := buildSessionKeyboard(tr, sessions, activeID, offset, hasPrev, hasNext, sessionsPerPage, total)
+	if len(sessions) > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{{
+			Text:         tr.T("☑️ Select"),
+			CallbackData: fmt.Sprintf("%s%d", selectModePrefix, offset),
+		}})
+	}
+	return keyboard
+}
+
+// buildPagedKeyboard wraps item rows with previous/next navigation buttons.
+// Navigation callbacks are pagePrefix followed by the target offset.
+// When total spans more than one page, a footer row shows "Page N/M" between
+// first/last page shortcuts; total 0 omits the footer.
+func buildPagedKeyboard(tr *i18n.Translator, itemRows [][]models.InlineKeyboardButton, pagePrefix string, offset int, hasPrev bool, hasNext bool, perPage int, total int) *models.InlineKeyboardMarkup {
 	var rows [][]models.InlineKeyboardButton
 
 	// Put previous-page navigation at the top.
 	if hasPrev {
-		prevOffset := offset - sessionsPerPage
+		prevOffset := offset - perPage
 		if prevOffset < 0 {
 			prevOffset = 0
 		}
 		rows = append(rows, []models.InlineKeyboardButton{
 			{
-				Text:         prevPageButtonText,
-				CallbackData: fmt.Sprintf("page_sessions_%d", prevOffset),
+				Text:         tr.T(label(LabelPrevPage)),
+				CallbackData: fmt.Sprintf("%s%d", pagePrefix, prevOffset),
 			},
 		})
 	}
 
-	// Add session buttons (one per row)
-	for _, s := range sessions {
-		button := models.InlineKeyboardButton{
-			Text:         formatSessionButton(s),
-			CallbackData: fmt.Sprintf("open_s_%s", s.ID.String()),
-		}
-		rows = append(rows, []models.InlineKeyboardButton{button})
-	}
+	rows = append(rows, itemRows...)
 
 	// Put next-page navigation at the bottom.
 	if hasNext {
 		rows = append(rows, []models.InlineKeyboardButton{
 			{
-				Text:         nextPageButtonText,
-				CallbackData: fmt.Sprintf("page_sessions_%d", offset+sessionsPerPage),
+				Text:         tr.T(label(LabelNextPage)),
+				CallbackData: fmt.Sprintf("%s%d", pagePrefix, offset+perPage),
 			},
 		})
 	}
 
+	if footer := buildPageIndicatorRow(tr, pagePrefix, offset, perPage, total); footer != nil {
+		rows = append(rows, footer)
+	}
+
 	return &models.InlineKeyboardMarkup{
 		InlineKeyboard: rows,
 	}
 }
 
+// buildPageIndicatorRow creates the "⏮ First | Page N/M | Last ⏭" row.
+// It returns nil when all items fit on one page.
+func buildPageIndicatorRow(tr *i18n.Translator, pagePrefix string, offset, perPage, total int) []models.InlineKeyboardButton {
+	if perPage < 1 || total <= perPage {
+		return nil
+	}
+
+	pages := (total + perPage - 1) / perPage
+	page := offset/perPage + 1
+	if page > pages {
+		page = pages
+	}
+
+	var row []models.InlineKeyboardButton
+	if page > 1 {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         tr.T(label(LabelFirstPage)),
+			CallbackData: fmt.Sprintf("%s%d", pagePrefix, 0),
+		})
+	}
+	row = append(row, models.InlineKeyboardButton{
+		Text:         tr.Sprintf("Page %d/%d", page, pages),
+		CallbackData: noopCallback,
+	})
+	if page < pages {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         tr.T(label(LabelLastPage)),
+			CallbackData: fmt.Sprintf("%s%d", pagePrefix, (pages-1)*perPage),
+		})
+	}
+
+	return row
+}
+
+// parsePageOffset extracts the non-negative offset from pagination callback data
+func parsePageOffset(data, pagePrefix string) (int, error) {
+	if !strings.HasPrefix(data, pagePrefix) {
+		return 0, fmt.Errorf("invalid callback data prefix: %q", data)
+	}
+
+	offset, err := strconv.Atoi(data[len(pagePrefix):])
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset format: %w", err)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset: %d", offset)
+	}
+
+	return offset, nil
+}
+
+// activeSessionID returns the ID of the user's active session, or uuid.Nil when
+// there is none or it cannot be loaded; the session list is still useful then
+func activeSessionID(ctx context.Context, sessionMgr *session.Manager, userID int64) uuid.UUID {
+	active, err := sessionMgr.GetActiveSession(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, session.ErrSessionNotFound) {
+			LogError(ctx, "active_session", userID, err, nil)
+		}
+		return uuid.Nil
+	}
+	return active.ID
+}
+
 // formatSessionButton formats a session for display in button
-func formatSessionButton(s *session.Session) string {
-	// Format: "Title - 2h ago"
-	timeAgo := formatTimeAgo(s.UpdatedAt)
-	return fmt.Sprintf("%s - %s", truncate(s.Title, 40), timeAgo)
+func formatSessionButton(tr *i18n.Translator, s *session.Session) string {
+	// Format: "Title · 14 msgs · 2 files · 2h ago"; sessions without files leave the file count out.
+	// The title is cut by display width, so the label fits in maxButtonTextWidth.
+	parts := []string{truncateWidth(s.Title, 32), tr.Sprintf("%d msgs", s.MessageCount)}
+	if s.FileCount > 0 {
+		parts = append(parts, tr.Sprintf("%d files", s.FileCount))
+	}
+	parts = append(parts, formatTimeAgo(tr, s.UpdatedAt))
+	// Leave room for a marker such as the active session's or a checkbox
+	return truncateWidth(strings.Join(parts, " · "), maxButtonTextWidth-4)
 }
 
-// handleOpenSession processes session switch requests
-func handleOpenSession(ctx context.Context, b *bot.Bot, callback *models.CallbackQuery,
-	sessionMgr *session.Manager, userID int64, data string) {
-	// Get the message from callback
-	msg := callback.Message.Message
+// handleOpenSession processes session switch requests; req.Param is the session ID
+func handleOpenSession(ctx context.Context, b TelegramAPI, req *CallbackRequest, sessionMgr *session.Manager, cfg *HandlerConfig) {
+	userID := req.UserID
+	msg := req.Message()
 	if msg == nil {
 		return
 	}
 
 	// Parse session ID
-	sessionIDStr := data[7:] // Skip "open_s_" prefix
+	sessionIDStr := req.Param
 	sessionID, err := uuid.Parse(sessionIDStr)
 	if err != nil {
-		LogWarning("open_session", userID, "invalid session ID format", map[string]interface{}{
+		LogWarning(ctx, "open_session", userID, "invalid session ID format", map[string]interface{}{
 			"session_id_str": sessionIDStr,
 			"error":          err.Error(),
 		})
-		SendErrorResponse(ctx, b, msg.Chat.ID, err)
+		SendErrorResponse(ctx, b, msg, err)
 		return
 	}
 
-	LogInfo("open_session", userID, "switching session", map[string]interface{}{
+	LogInfo(ctx, "open_session", userID, "switching session", map[string]interface{}{
 		"session_id": sessionID.String(),
 	})
 
-	// Switch session
-	sess, err := sessionMgr.SwitchSession(ctx, userID, sessionID)
+	// Switch session and confirm it
+	var sess *session.Session
+	err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		var err error
+		if sess, err = sessionMgr.InTopic(MessageTopic(msg)).SwitchSession(ctx, userID, sessionID); err != nil {
+			return nil, err
+		}
+		return &bot.SendMessageParams{
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            i18n.FromContext(ctx).Sprintf("✅ Switched to session: %s", format.Bold(sess.Title)),
+			ParseMode:       models.ParseModeHTML,
+		}, nil
+	})
 	if err != nil {
 		if errors.Is(err, session.ErrUnauthorized) {
-			LogWarning("open_session", userID, "unauthorized access attempt", map[string]interface{}{
+			LogWarning(ctx, "open_session", userID, "unauthorized access attempt", map[string]interface{}{
 				"session_id": sessionID.String(),
 			})
 		} else if errors.Is(err, session.ErrSessionNotFound) {
-			LogWarning("open_session", userID, "session not found", map[string]interface{}{
+			LogWarning(ctx, "open_session", userID, "session not found", map[string]interface{}{
 				"session_id": sessionID.String(),
 			})
 		} else {
-			LogError("open_session", userID, err, map[string]interface{}{
+			LogError(ctx, "open_session", userID, err, map[string]interface{}{
 				"session_id": sessionID.String(),
 			})
 		}
-		SendErrorResponse(ctx, b, msg.Chat.ID, err)
+		SendErrorResponse(ctx, b, msg, err)
 		return
 	}
 
-	LogInfo("open_session", userID, "session switched successfully", map[string]interface{}{
+	LogInfo(ctx, "open_session", userID, "session switched successfully", map[string]interface{}{
 		"session_id":    sess.ID.String(),
 		"session_title": sess.Title,
 	})
-
-	// Send confirmation
-	b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: msg.Chat.ID,
-		Text:   fmt.Sprintf("✅ Switched to session: %s", sess.Title),
-	})
 }
 
-// handlePageSessions processes pagination requests.
-func handlePageSessions(ctx context.Context, b *bot.Bot, callback *models.CallbackQuery,
-	sessionMgr *session.Manager, userID int64, data string, sessionsPerPage int) {
-	// Get the message from callback
-	msg := callback.Message.Message
+// handlePageSessions processes pagination requests; req.Param is the page offset
+func handlePageSessions(ctx context.Context, b TelegramAPI, req *CallbackRequest,
+	sessionMgr *session.Manager, sessionsPerPage int, callbacks *CallbackEncoder) {
+	userID := req.UserID
+	msg := req.Message()
 	if msg == nil {
 		return
 	}
 
 	// Parse offset
-	if len(data) < 14 || data[:14] != "page_sessions_" {
-		LogWarning("page_sessions", userID, "invalid callback data prefix", map[string]interface{}{
-			"callback_data": data,
-		})
-		return
-	}
-	offsetStr := data[14:]
+	offsetStr := req.Param
 
 	offset, err := strconv.Atoi(offsetStr)
 	if err != nil {
-		LogWarning("page_sessions", userID, "invalid offset format", map[string]interface{}{
+		LogWarning(ctx, "page_sessions", userID, "invalid offset format", map[string]interface{}{
 			"offset_str": offsetStr,
 			"error":      err.Error(),
 		})
@@ -183,13 +302,19 @@ func handlePageSessions(ctx context.Context, b *bot.Bot, callback *models.Callba
 	}
 
 	if offset < 0 {
-		LogWarning("page_sessions", userID, "negative offset", map[string]interface{}{
+		LogWarning(ctx, "page_sessions", userID, "negative offset", map[string]interface{}{
 			"offset": offset,
 		})
 		return
 	}
 
-	LogDebug("page_sessions", userID, "loading page", map[string]interface{}{
+	showSessionsPage(ctx, b, msg, sessionMgr, userID, offset, sessionsPerPage, callbacks)
+}
+
+// showSessionsPage replaces the session list in msg with the page at offset
+func showSessionsPage(ctx context.Context, b TelegramAPI, msg *models.Message, sessionMgr *session.Manager,
+	userID int64, offset, sessionsPerPage int, callbacks *CallbackEncoder) {
+	LogDebug(ctx, "page_sessions", userID, "loading page", map[string]interface{}{
 		"offset": offset,
 		"limit":  sessionsPerPage,
 	})
@@ -197,28 +322,164 @@ func handlePageSessions(ctx context.Context, b *bot.Bot, callback *models.Callba
 	// Get page
 	sessions, hasNext, err := sessionMgr.ListSessions(ctx, userID, offset, sessionsPerPage)
 	if err != nil {
-		LogError("page_sessions", userID, err, map[string]interface{}{
+		LogError(ctx, "page_sessions", userID, err, map[string]interface{}{
 			"offset": offset,
 			"limit":  sessionsPerPage,
 		})
 		return
 	}
 
+	total, err := sessionMgr.CountSessions(ctx, userID)
+	if err != nil {
+		LogError(ctx, "page_sessions", userID, err, nil)
+		return
+	}
+
 	hasPrev := offset > 0
 
-	LogInfo("page_sessions", userID, "pagination successful", map[string]interface{}{
+	LogInfo(ctx, "page_sessions", userID, "pagination successful", map[string]interface{}{
 		"offset":        offset,
 		"session_count": len(sessions),
 		"has_prev":      hasPrev,
 		"has_next":      hasNext,
 	})
 
-	// Update message with new keyboard
-	keyboard := buildSessionKeyboard(sessions, offset, hasPrev, hasNext, sessionsPerPage)
+	// Update header and keyboard for the new page
+	tr := i18n.FromContext(ctx)
+	activeID := activeSessionID(ctx, sessionMgr.InTopic(MessageTopic(msg)), userID)
+	keyboard := callbacks.EncodeKeyboard(ctx, buildSessionListKeyboard(tr, sessions, activeID, offset, hasPrev, hasNext, sessionsPerPage, total))
 
-	b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      msg.Chat.ID,
 		MessageID:   msg.ID,
+		Text:        formatSessionListHeader(tr, offset, len(sessions), total, sessionsPerPage),
 		ReplyMarkup: keyboard,
 	})
+	if err != nil {
+		if isMessageNotModified(err) {
+			// Same page requested twice, e.g. a double tap; nothing changed
+			LogDebug(ctx, "page_sessions", userID, "page unchanged", map[string]interface{}{
+				"offset": offset,
+			})
+			return
+		}
+		LogError(ctx, "page_sessions", userID, err, map[string]interface{}{
+			"offset": offset,
+		})
+	}
+}
+
+// formatSessionListHeader describes the visible page of the session list
+func formatSessionListHeader(tr *i18n.Translator, offset, count, total, perPage int) string {
+	if count == 0 {
+		return tr.T("Your sessions — no sessions on this page")
+	}
+	page := offset/perPage + 1
+	return tr.Sprintf("Your sessions — page %d, showing %d–%d of %d", page, offset+1, offset+count, total)
+}
+
+// isMessageNotModified reports whether an edit failed because the message content did not change
+func isMessageNotModified(err error) bool {
+	return errors.Is(err, bot.ErrorBadRequest) && strings.Contains(err.Error(), "message is not modified")
+}
+
+// buildCloseKeyboard creates the keyboard shown after closing a session
+func buildCloseKeyboard(tr *i18n.Translator) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: tr.T("↩️ Reopen last session"), CallbackData: closeReopenCallback},
+				{Text: tr.T("➕ Start new session"), CallbackData: newSessionCallback},
+			},
+		},
+	}
+}
+
+// buildQuickSwitchKeyboard creates the compact keyboard shown under bot replies
+func buildQuickSwitchKeyboard(tr *i18n.Translator) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: tr.T("🆕 New session"), CallbackData: newSessionCallback},
+				{Text: tr.T("📋 Sessions"), CallbackData: listSessionsCallback},
+			},
+		},
+	}
+}
+
+// handleReopenLastSession reactivates the most recently updated session after /close
+func handleReopenLastSession(ctx context.Context, b TelegramAPI, callback *models.CallbackQuery,
+	sessionMgr *session.Manager, cfg *HandlerConfig, userID int64) {
+	msg := callback.Message.Message
+	if msg == nil {
+		return
+	}
+
+	var sess *session.Session
+	err := cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		var err error
+		if sess, err = sessionMgr.InTopic(MessageTopic(msg)).ReopenLastSession(ctx, userID); err != nil {
+			return nil, err
+		}
+		return &bot.SendMessageParams{
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            i18n.FromContext(ctx).Sprintf("✅ Reopened session: %s", format.Bold(sess.Title)),
+			ParseMode:       models.ParseModeHTML,
+		}, nil
+	})
+	if err != nil {
+		LogError(ctx, "reopen_session", userID, err, nil)
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	LogInfo(ctx, "reopen_session", userID, "last session reopened", map[string]interface{}{
+		"session_id":    sess.ID.String(),
+		"session_title": sess.Title,
+	})
+	removeInlineKeyboard(ctx, b, msg)
+}
+
+// handleStartNewSession creates and activates a new session from a shortcut button
+func handleStartNewSession(ctx context.Context, b TelegramAPI, callback *models.CallbackQuery,
+	sessionMgr *session.Manager, cfg *HandlerConfig, userID int64) {
+	msg := callback.Message.Message
+	if msg == nil {
+		return
+	}
+
+	var sess *session.Session
+	err := cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		var err error
+		if sess, err = sessionMgr.InTopic(MessageTopic(msg)).CreateSession(ctx, userID, ""); err != nil {
+			return nil, err
+		}
+		return &bot.SendMessageParams{
+			ChatID:          msg.Chat.ID,
+			MessageThreadID: topicThreadID(msg),
+			Text:            i18n.FromContext(ctx).Sprintf("✅ Opened new session: %s", format.Bold(sess.Title)),
+			ParseMode:       models.ParseModeHTML,
+		}, nil
+	})
+	if err != nil {
+		LogError(ctx, "new_session", userID, err, nil)
+		SendErrorResponse(ctx, b, msg, err)
+		return
+	}
+
+	LogInfo(ctx, "new_session", userID, "new session opened", map[string]interface{}{
+		"session_id":    sess.ID.String(),
+		"session_title": sess.Title,
+	})
+	removeInlineKeyboard(ctx, b, msg)
+}
+
+// removeInlineKeyboard clears the buttons of a message so they cannot be pressed twice
+func removeInlineKeyboard(ctx context.Context, b TelegramAPI, msg *models.Message) {
+	b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}},
+	})
 }
@@ -1,10 +1,18 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"tg-bot-demo/i18n"
 	"time"
+
+	"github.com/go-telegram/bot"
 )
 
+// en formats test expectations in English
+var en = i18n.New(i18n.English)
+
 func TestFormatTimeAgo(t *testing.T) {
 	now := time.Now()
 
@@ -51,18 +59,18 @@ func TestFormatTimeAgo(t *testing.T) {
 		{
 			name:     "date format - 8 days ago",
 			time:     now.Add(-8 * 24 * time.Hour),
-			expected: now.Add(-8 * 24 * time.Hour).Format("Jan 2"),
+			expected: now.Add(-8 * 24 * time.Hour).UTC().Format("Jan 2"),
 		},
 		{
 			name:     "date format - 30 days ago",
 			time:     now.Add(-30 * 24 * time.Hour),
-			expected: now.Add(-30 * 24 * time.Hour).Format("Jan 2"),
+			expected: now.Add(-30 * 24 * time.Hour).UTC().Format("Jan 2"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := formatTimeAgo(tt.time)
+			result := formatTimeAgo(en, tt.time)
 			if result != tt.expected {
 				t.Errorf("formatTimeAgo(%v) = %q, want %q", tt.time, result, tt.expected)
 			}
@@ -70,6 +78,19 @@ func TestFormatTimeAgo(t *testing.T) {
 	}
 }
 
+func TestFormatTimeAgoTranslated(t *testing.T) {
+	zh := i18n.New(i18n.Chinese)
+	now := time.Now()
+
+	if got := formatTimeAgo(zh, now.Add(-2*time.Hour)); got != "2 小时前" {
+		t.Errorf("expected Chinese relative time, got %q", got)
+	}
+	old := now.Add(-30 * 24 * time.Hour)
+	if got, want := formatTimeAgo(zh, old), old.Format("1月2日"); got != want {
+		t.Errorf("expected Chinese date %q, got %q", want, got)
+	}
+}
+
 func TestFormatTimeAgoBoundaries(t *testing.T) {
 	now := time.Now()
 
@@ -97,13 +118,13 @@ func TestFormatTimeAgoBoundaries(t *testing.T) {
 		{
 			name:     "exactly 7 days",
 			time:     now.Add(-7 * 24 * time.Hour),
-			expected: now.Add(-7 * 24 * time.Hour).Format("Jan 2"),
+			expected: now.Add(-7 * 24 * time.Hour).UTC().Format("Jan 2"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := formatTimeAgo(tt.time)
+			result := formatTimeAgo(en, tt.time)
 			if result != tt.expected {
 				t.Errorf("formatTimeAgo(%v) = %q, want %q", tt.time, result, tt.expected)
 			}
@@ -165,3 +186,41 @@ func TestTruncate(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatSessionListHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   int
+		count    int
+		total    int
+		expected string
+	}{
+		{"first page", 0, 6, 20, "Your sessions — page 1, showing 1–6 of 20"},
+		{"middle page", 6, 6, 20, "Your sessions — page 2, showing 7–12 of 20"},
+		{"last partial page", 18, 2, 20, "Your sessions — page 4, showing 19–20 of 20"},
+		{"page past the end", 24, 0, 20, "Your sessions — no sessions on this page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSessionListHeader(en, tt.offset, tt.count, tt.total, 6); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsMessageNotModified(t *testing.T) {
+	notModified := fmt.Errorf("%w, %s", bot.ErrorBadRequest, "Bad Request: message is not modified: specified new message content and reply markup are exactly the same")
+	if !isMessageNotModified(notModified) {
+		t.Error("expected 'message is not modified' error to be detected")
+	}
+
+	otherBadRequest := fmt.Errorf("%w, %s", bot.ErrorBadRequest, "Bad Request: message to edit not found")
+	if isMessageNotModified(otherBadRequest) {
+		t.Error("expected other bad requests not to match")
+	}
+	if isMessageNotModified(errors.New("message is not modified")) {
+		t.Error("expected only Telegram bad request errors to match")
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// inlineResultsPerPage is the number of sessions returned per inline query page
+	inlineResultsPerPage = 20
+	// inlineCacheTime is how long Telegram may cache inline results, in seconds
+	inlineCacheTime = 5
+)
+
+// InlineQueryHandler handles "@bot <query>" inline queries.
+// It searches the user's sessions by title and last message and answers with
+// one article per session; sending an article shares the session summary.
+func InlineQueryHandler(sessionMgr *session.Manager) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		query := update.InlineQuery
+		userID := query.From.ID
+
+		offset, err := strconv.Atoi(query.Offset)
+		if err != nil || offset < 0 {
+			offset = 0
+		}
+
+		sessions, hasMore, err := sessionMgr.SearchSessions(ctx, userID, query.Query, offset, inlineResultsPerPage)
+		if err != nil {
+			LogError(ctx, "inline_query", userID, err, map[string]interface{}{
+				"query":  query.Query,
+				"offset": offset,
+			})
+			return
+		}
+
+		nextOffset := ""
+		if hasMore {
+			nextOffset = strconv.Itoa(offset + len(sessions))
+		}
+
+		LogDebug(ctx, "inline_query", userID, "answering inline query", map[string]interface{}{
+			"query":        query.Query,
+			"offset":       offset,
+			"result_count": len(sessions),
+		})
+
+		_, err = b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+			InlineQueryID: query.ID,
+			Results:       buildSessionInlineResults(i18n.FromContext(ctx), sessions),
+			CacheTime:     inlineCacheTime,
+			IsPersonal:    true,
+			NextOffset:    nextOffset,
+		})
+		if err != nil {
+			LogError(ctx, "inline_query", userID, err, nil)
+		}
+	}
+}
+
+// ChosenInlineResultHandler handles the feedback sent when a user picks an inline result.
+// The chosen session becomes the user's active session.
+// Telegram only delivers these updates when inline feedback is enabled via @BotFather.
+func ChosenInlineResultHandler(sessionMgr *session.Manager) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		chosen := update.ChosenInlineResult
+		userID := chosen.From.ID
+
+		sessionID, err := uuid.Parse(chosen.ResultID)
+		if err != nil {
+			LogWarning(ctx, "chosen_inline_result", userID, "invalid inline result id", map[string]interface{}{
+				"result_id": chosen.ResultID,
+			})
+			return
+		}
+
+		sess, err := sessionMgr.SwitchSession(ctx, userID, sessionID)
+		if err != nil {
+			LogError(ctx, "chosen_inline_result", userID, err, map[string]interface{}{
+				"session_id": sessionID.String(),
+			})
+			return
+		}
+
+		LogInfo(ctx, "chosen_inline_result", userID, "switched session from inline result", map[string]interface{}{
+			"session_id":    sess.ID.String(),
+			"session_title": sess.Title,
+		})
+	}
+}
+
+// buildSessionInlineResults creates one inline article per session.
+// The article ID is the session ID so the chosen result can be mapped back to it.
+func buildSessionInlineResults(tr *i18n.Translator, sessions []*session.Session) []models.InlineQueryResult {
+	results := make([]models.InlineQueryResult, 0, len(sessions))
+	for _, s := range sessions {
+		description := formatTimeAgo(tr, s.UpdatedAt)
+		if s.LastMessage != "" {
+			description += " · " + truncate(s.LastMessage, 60)
+		}
+
+		results = append(results, &models.InlineQueryResultArticle{
+			ID:          s.ID.String(),
+			Title:       s.Title,
+			Description: description,
+			InputMessageContent: &models.InputTextMessageContent{
+				MessageText: formatSessionSummary(tr, s),
+				ParseMode:   models.ParseModeHTML,
+			},
+		})
+	}
+	return results
+}
+
+// formatSessionSummary renders the HTML summary shared for a session
+func formatSessionSummary(tr *i18n.Translator, s *session.Session) string {
+	text := tr.Sprintf("💬 Session: %s\nLast active: %s", format.Bold(s.Title), formatTimeAgo(tr, s.UpdatedAt))
+	if s.LastMessage != "" {
+		text += "\n\n" + format.Italic(truncate(s.LastMessage, 200))
+	}
+	return text
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestBuildSessionInlineResults(t *testing.T) {
+	withMessage := session.NewSession(1, "Plan <trip>")
+	withMessage.LastMessage = "book a & b"
+	empty := session.NewSession(1, "Empty")
+	empty.LastMessage = ""
+
+	results := buildSessionInlineResults(en, []*session.Session{withMessage, empty})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	article, ok := results[0].(*models.InlineQueryResultArticle)
+	if !ok {
+		t.Fatalf("Expected article result, got %T", results[0])
+	}
+	if article.ID != withMessage.ID.String() {
+		t.Errorf("Expected result ID %s, got %s", withMessage.ID, article.ID)
+	}
+	if article.Title != "Plan <trip>" {
+		t.Errorf("Expected raw title, got %q", article.Title)
+	}
+	if !strings.Contains(article.Description, "book a & b") {
+		t.Errorf("Expected description to include last message, got %q", article.Description)
+	}
+
+	content, ok := article.InputMessageContent.(*models.InputTextMessageContent)
+	if !ok {
+		t.Fatalf("Expected text content, got %T", article.InputMessageContent)
+	}
+	if content.ParseMode != models.ParseModeHTML {
+		t.Errorf("Expected HTML parse mode, got %q", content.ParseMode)
+	}
+	if !strings.Contains(content.MessageText, "<b>Plan &lt;trip&gt;</b>") {
+		t.Errorf("Expected escaped bold title, got %q", content.MessageText)
+	}
+	if !strings.Contains(content.MessageText, "<i>book a &amp; b</i>") {
+		t.Errorf("Expected escaped italic last message, got %q", content.MessageText)
+	}
+
+	emptyContent := results[1].(*models.InlineQueryResultArticle).InputMessageContent.(*models.InputTextMessageContent)
+	if strings.Contains(emptyContent.MessageText, "<i>") {
+		t.Errorf("Expected no last message block, got %q", emptyContent.MessageText)
+	}
+}
@@ -108,7 +108,7 @@ func TestBuildSessionKeyboard(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			keyboard := buildSessionKeyboard(tt.sessions, tt.offset, tt.hasPrev, tt.hasNext, 6)
+			keyboard := buildSessionKeyboard(en, tt.sessions, uuid.Nil, tt.offset, tt.hasPrev, tt.hasNext, 6, 0)
 
 			if keyboard == nil {
 				t.Fatal("keyboard is nil")
@@ -168,7 +168,7 @@ func TestBuildSessionKeyboardCallbackData(t *testing.T) {
 	}
 
 	t.Run("session button callback format", func(t *testing.T) {
-		keyboard := buildSessionKeyboard(sessions, 0, false, false, 6)
+		keyboard := buildSessionKeyboard(en, sessions, uuid.Nil, 0, false, false, 6, 1)
 
 		if len(keyboard.InlineKeyboard) != 1 {
 			t.Fatalf("expected 1 row, got %d", len(keyboard.InlineKeyboard))
@@ -182,9 +182,21 @@ func TestBuildSessionKeyboardCallbackData(t *testing.T) {
 		}
 	})
 
+	t.Run("active session button", func(t *testing.T) {
+		keyboard := buildSessionKeyboard(en, sessions, sessionID, 0, false, false, 6, 1)
+
+		button := keyboard.InlineKeyboard[0][0]
+		if !strings.HasPrefix(button.Text, marker(LabelActiveSession)+"Test Session") {
+			t.Errorf("expected the active session to be marked, got %q", button.Text)
+		}
+		if button.CallbackData != noopCallback {
+			t.Errorf("expected the active session button to do nothing, got %q", button.CallbackData)
+		}
+	})
+
 	t.Run("next button callback format", func(t *testing.T) {
 		offset := 6
-		keyboard := buildSessionKeyboard(sessions, offset, false, true, 6)
+		keyboard := buildSessionKeyboard(en, sessions, uuid.Nil, offset, false, true, 6, 0)
 
 		if len(keyboard.InlineKeyboard) != 2 {
 			t.Fatalf("expected 2 rows, got %d", len(keyboard.InlineKeyboard))
@@ -203,7 +215,7 @@ func TestBuildSessionKeyboardCallbackData(t *testing.T) {
 
 	t.Run("prev and next callback format", func(t *testing.T) {
 		offset := 6
-		keyboard := buildSessionKeyboard(sessions, offset, true, true, 6)
+		keyboard := buildSessionKeyboard(en, sessions, uuid.Nil, offset, true, true, 6, 0)
 
 		if len(keyboard.InlineKeyboard) != 3 {
 			t.Fatalf("expected 3 rows, got %d", len(keyboard.InlineKeyboard))
@@ -267,11 +279,22 @@ func TestFormatSessionButton(t *testing.T) {
 			},
 			contains: []string{"...", "2h ago"},
 		},
+		{
+			name: "message and file counts",
+			session: &session.Session{
+				ID:           uuid.New(),
+				Title:        "Counted",
+				UpdatedAt:    now.Add(-2 * time.Hour),
+				MessageCount: 14,
+				FileCount:    2,
+			},
+			contains: []string{"Counted · 14 msgs · 2 files · 2h ago"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := formatSessionButton(tt.session)
+			result := formatSessionButton(en, tt.session)
 
 			for _, substr := range tt.contains {
 				if !contains(result, substr) {
@@ -295,3 +318,102 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestBuildCloseKeyboard(t *testing.T) {
+	keyboard := buildCloseKeyboard(en)
+
+	if len(keyboard.InlineKeyboard) != 1 || len(keyboard.InlineKeyboard[0]) != 2 {
+		t.Fatalf("expected one row with two buttons, got %+v", keyboard.InlineKeyboard)
+	}
+	row := keyboard.InlineKeyboard[0]
+	if row[0].CallbackData != closeReopenCallback || row[1].CallbackData != newSessionCallback {
+		t.Errorf("unexpected callbacks: %q, %q", row[0].CallbackData, row[1].CallbackData)
+	}
+	for _, button := range row {
+		if len(button.CallbackData) > 64 {
+			t.Errorf("callback data %q exceeds 64 bytes", button.CallbackData)
+		}
+	}
+}
+
+func TestBuildQuickSwitchKeyboard(t *testing.T) {
+	keyboard := buildQuickSwitchKeyboard(en)
+
+	if len(keyboard.InlineKeyboard) != 1 || len(keyboard.InlineKeyboard[0]) != 2 {
+		t.Fatalf("expected one row with two buttons, got %+v", keyboard.InlineKeyboard)
+	}
+	row := keyboard.InlineKeyboard[0]
+	if row[0].Text != "🆕 New session" || row[0].CallbackData != newSessionCallback {
+		t.Errorf("unexpected new session button: %+v", row[0])
+	}
+	if row[1].Text != "📋 Sessions" || row[1].CallbackData != listSessionsCallback {
+		t.Errorf("unexpected sessions button: %+v", row[1])
+	}
+}
+
+func TestBuildSessionKeyboardPageIndicator(t *testing.T) {
+	now := time.Now()
+	sessions := []*session.Session{
+		{ID: uuid.New(), UserID: 123, Title: "Session", UpdatedAt: now, CreatedAt: now},
+	}
+
+	tests := []struct {
+		name      string
+		offset    int
+		total     int
+		wantTexts []string
+		wantData  []string
+	}{
+		{
+			name:   "single page has no footer",
+			offset: 0,
+			total:  6,
+		},
+		{
+			name:      "first page",
+			offset:    0,
+			total:     30,
+			wantTexts: []string{"Page 1/5", lastPageButtonText},
+			wantData:  []string{noopCallback, "page_sessions_24"},
+		},
+		{
+			name:      "middle page",
+			offset:    6,
+			total:     30,
+			wantTexts: []string{firstPageButtonText, "Page 2/5", lastPageButtonText},
+			wantData:  []string{"page_sessions_0", noopCallback, "page_sessions_24"},
+		},
+		{
+			name:      "last partial page",
+			offset:    12,
+			total:     13,
+			wantTexts: []string{firstPageButtonText, "Page 3/3"},
+			wantData:  []string{"page_sessions_0", noopCallback},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasPrev := tt.offset > 0
+			hasNext := tt.offset+6 < tt.total
+			keyboard := buildSessionKeyboard(en, sessions, uuid.Nil, tt.offset, hasPrev, hasNext, 6, tt.total)
+			lastRow := keyboard.InlineKeyboard[len(keyboard.InlineKeyboard)-1]
+
+			if tt.wantTexts == nil {
+				if len(keyboard.InlineKeyboard) != 1 {
+					t.Fatalf("expected only the session row, got %d rows", len(keyboard.InlineKeyboard))
+				}
+				return
+			}
+
+			if len(lastRow) != len(tt.wantTexts) {
+				t.Fatalf("expected %d footer buttons, got %+v", len(tt.wantTexts), lastRow)
+			}
+			for i, button := range lastRow {
+				if button.Text != tt.wantTexts[i] || button.CallbackData != tt.wantData[i] {
+					t.Errorf("button %d: expected %q/%q, got %q/%q", i, tt.wantTexts[i], tt.wantData[i], button.Text, button.CallbackData)
+				}
+			}
+		})
+	}
+}
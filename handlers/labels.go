@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/text/width"
+)
+
+// Label names a button label or marker that the button_labels setting can override
+type Label string
+
+// Overridable labels. Markers are shown in front of session buttons, followed by a space.
+const (
+	LabelPrevPage      Label = "prev_page"
+	LabelNextPage      Label = "next_page"
+	LabelFirstPage     Label = "first_page"
+	LabelLastPage      Label = "last_page"
+	LabelActiveSession Label = "active_session" // marker of the active session in /sessions
+	LabelSelected      Label = "selected"       // checkbox of a selected session in multi-select mode
+	LabelUnselected    Label = "unselected"     // checkbox of an unselected session in multi-select mode
+)
+
+// Default page navigation labels; they are translated, overrides are shown as configured
+const (
+	prevPageButtonText  = "⬆️ Prev"
+	nextPageButtonText  = "⬇️ Next"
+	firstPageButtonText = "⏮ First"
+	lastPageButtonText  = "Last ⏭"
+)
+
+// defaultLabels are the labels used unless overridden
+var defaultLabels = map[Label]string{
+	LabelPrevPage:      prevPageButtonText,
+	LabelNextPage:      nextPageButtonText,
+	LabelFirstPage:     firstPageButtonText,
+	LabelLastPage:      lastPageButtonText,
+	LabelActiveSession: "▶️",
+	LabelSelected:      "✅",
+	LabelUnselected:    "⬜",
+}
+
+// maxButtonTextWidth bounds the display width of generated button labels; wider
+// labels are cut off or wrapped by Telegram clients, and CJK characters count double
+const maxButtonTextWidth = 64
+
+// labelOverrides holds the labels replaced by SetButtonLabels
+var labelOverrides atomic.Pointer[map[Label]string]
+
+// SetButtonLabels overrides button labels by name, e.g. {"next_page": "Next ▶"}.
+// It returns an error for unknown names and empty labels and leaves the current
+// labels in place then. A nil map restores the defaults.
+func SetButtonLabels(overrides map[string]string) error {
+	labels := make(map[Label]string, len(overrides))
+	for name, text := range overrides {
+		if _, ok := defaultLabels[Label(name)]; !ok {
+			return fmt.Errorf("unknown button label %q, expected one of %s", name, strings.Join(labelNames(), ", "))
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return fmt.Errorf("button label %q must not be empty", name)
+		}
+		labels[Label(name)] = text
+	}
+	labelOverrides.Store(&labels)
+	return nil
+}
+
+// labelNames returns the names of all overridable labels, sorted
+func labelNames() []string {
+	names := make([]string, 0, len(defaultLabels))
+	for name := range defaultLabels {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// label returns the text of a button label, overridden or default
+func label(name Label) string {
+	if overrides := labelOverrides.Load(); overrides != nil {
+		if text, ok := (*overrides)[name]; ok {
+			return text
+		}
+	}
+	return defaultLabels[name]
+}
+
+// marker returns a marker label with the space separating it from the text it marks
+func marker(name Label) string {
+	return label(name) + " "
+}
+
+// runeWidth returns the display width of r: 2 for wide and fullwidth characters
+// such as CJK ideographs and most emoji, 0 for combining marks and variation
+// selectors, 1 otherwise
+func runeWidth(r rune) int {
+	switch {
+	case r == '‍' || (r >= '︀' && r <= '️'):
+		return 0
+	case r < 0x300:
+		return 1
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// textWidth returns the display width of s
+func textWidth(s string) int {
+	total := 0
+	for _, r := range s {
+		total += runeWidth(r)
+	}
+	return total
+}
+
+// truncateWidth shortens s to at most maxWidth display columns, ending it with
+// "..." when it was cut, so CJK text is cut at about half the characters of Latin text
+func truncateWidth(s string, maxWidth int) string {
+	if textWidth(s) <= maxWidth {
+		return s
+	}
+
+	const ellipsis = "..."
+	limit := maxWidth - len(ellipsis)
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if used+w > limit {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String() + ellipsis
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/google/uuid"
+)
+
+func TestSetButtonLabels(t *testing.T) {
+	t.Cleanup(func() { SetButtonLabels(nil) })
+
+	if err := SetButtonLabels(map[string]string{"next": "Next"}); err == nil || !strings.Contains(err.Error(), "next_page") {
+		t.Errorf("expected an unknown label error listing the names, got %v", err)
+	}
+	if err := SetButtonLabels(map[string]string{"next_page": "  "}); err == nil {
+		t.Error("expected an error for an empty label")
+	}
+
+	if err := SetButtonLabels(map[string]string{"next_page": "Next ▶", "active_session": "⭐"}); err != nil {
+		t.Fatalf("SetButtonLabels failed: %v", err)
+	}
+	active := &session.Session{ID: uuid.New(), Title: "Current", UpdatedAt: time.Now()}
+	sessions := []*session.Session{active, {ID: uuid.New(), Title: "Other", UpdatedAt: time.Now()}}
+	got := keyboardLabels(buildSessionListKeyboard(en, sessions, active.ID, 0, false, true, 2, 4))
+	if !strings.Contains(got, "Next ▶") || !strings.Contains(got, "⭐ Current") {
+		t.Errorf("expected the overridden labels, got\n%s", got)
+	}
+
+	// An invalid override keeps the labels in place
+	SetButtonLabels(map[string]string{"bogus": "x"})
+	if label(LabelNextPage) != "Next ▶" {
+		t.Errorf("expected the override to survive a failed update, got %q", label(LabelNextPage))
+	}
+
+	SetButtonLabels(nil)
+	if label(LabelNextPage) != nextPageButtonText {
+		t.Errorf("expected the default label after a reset, got %q", label(LabelNextPage))
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"short", "Trip", 10, "Trip"},
+		{"ascii", "Planning the trip", 10, "Plannin..."},
+		{"cjk", "计划一次去罗马的旅行", 10, "计划一..."},
+		{"emoji variation selector", "▶️ Go", 5, "▶️ Go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateWidth(tt.in, tt.max)
+			if got != tt.want {
+				t.Errorf("truncateWidth(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+			}
+			if textWidth(got) > tt.max {
+				t.Errorf("truncateWidth(%q, %d) is %d columns wide", tt.in, tt.max, textWidth(got))
+			}
+		})
+	}
+}
+
+func TestDefaultNavigationLabelsTranslated(t *testing.T) {
+	zh := i18n.New(i18n.Chinese)
+	for _, name := range []Label{LabelPrevPage, LabelNextPage, LabelFirstPage, LabelLastPage} {
+		if text := label(name); zh.T(text) == text {
+			t.Errorf("expected a Chinese translation of %q", text)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// languageAutoArg is the /language argument that drops the user's override
+const languageAutoArg = "auto"
+
+// LanguageMiddleware is a bot middleware that puts a translator for the update's
+// sender into the context. A language chosen with /language wins over the
+// language of the user's Telegram app.
+func LanguageMiddleware(prefs session.PreferenceStore) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if user := updateSender(update); user != nil {
+				ctx = i18n.WithTranslator(ctx, i18n.New(userLanguage(ctx, prefs, user)))
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// userLanguage returns the language override of user, or their Telegram app language
+func userLanguage(ctx context.Context, prefs session.PreferenceStore, user *models.User) string {
+	p, err := prefs.GetPreferences(ctx, user.ID)
+	if err == nil && p.Language != "" {
+		return p.Language
+	}
+	if err != nil && !errors.Is(err, session.ErrPreferencesNotFound) {
+		LogError(ctx, "language", user.ID, err, nil)
+	}
+	return user.LanguageCode
+}
+
+// updateSender returns the user who sent an update, or nil
+func updateSender(update *models.Update) *models.User {
+	switch {
+	case update.Message != nil:
+		return update.Message.From
+	case update.EditedMessage != nil:
+		return update.EditedMessage.From
+	case update.BusinessMessage != nil:
+		return update.BusinessMessage.From
+	case update.EditedBusinessMessage != nil:
+		return update.EditedBusinessMessage.From
+	case update.BusinessConnection != nil:
+		return &update.BusinessConnection.User
+	case update.CallbackQuery != nil:
+		return &update.CallbackQuery.From
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From
+	case update.ChosenInlineResult != nil:
+		return &update.ChosenInlineResult.From
+	default:
+		return nil
+	}
+}
+
+// LanguageCommandHandler handles the /language command.
+// "/language <code>" overrides the language, "/language auto" follows the Telegram app again.
+func LanguageCommandHandler(prefs session.PreferenceStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		user := update.Message.From
+		chatID := update.Message.Chat.ID
+		tr := i18n.FromContext(ctx)
+
+		args := commandArgs(ctx, update.Message)
+		if len(args) == 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text: tr.Sprintf("Current language: %s\nAvailable: %s\nUse /language <code> to switch, or /language auto to follow your Telegram app.",
+					i18n.LanguageName(tr.Language()), formatLanguages()),
+			})
+			return
+		}
+
+		language := ""
+		if arg := strings.ToLower(args[0]); arg != languageAutoArg {
+			code, ok := i18n.Normalize(arg)
+			if !ok {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:          chatID,
+					MessageThreadID: topicThreadID(update.Message),
+					Text:            tr.Sprintf("Unknown language: %s\nAvailable: %s", args[0], formatLanguages()),
+				})
+				return
+			}
+			language = code
+		}
+
+		// Confirm in the language that is now in effect
+		var text string
+		if language == "" {
+			tr = i18n.New(user.LanguageCode)
+			text = tr.Sprintf("🌐 Language now follows your Telegram app (%s).", i18n.LanguageName(tr.Language()))
+		} else {
+			tr = i18n.New(language)
+			text = tr.Sprintf("🌐 Language set to %s.", i18n.LanguageName(tr.Language()))
+		}
+
+		err := cfg.confirmWrite(ctx, b, user.ID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			err := prefs.SavePreferences(ctx, &session.Preferences{
+				UserID:    user.ID,
+				Language:  language,
+				UpdatedAt: time.Now(),
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+			}, nil
+		})
+		if err != nil {
+			LogError(ctx, "language_command", user.ID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "language_command", user.ID, "language preference saved", map[string]interface{}{
+			"language": language,
+		})
+	}
+}
+
+// formatLanguages lists the supported languages as "code (name)"
+func formatLanguages() string {
+	languages := make([]string, 0, len(i18n.Supported()))
+	for _, code := range i18n.Supported() {
+		languages = append(languages, fmt.Sprintf("%s (%s)", code, i18n.LanguageName(code)))
+	}
+	return strings.Join(languages, ", ")
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func TestLanguageMiddleware(t *testing.T) {
+	_, store := newTestConversations(t)
+	ctx := context.Background()
+
+	var got string
+	handler := LanguageMiddleware(store)(func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		got = i18n.FromContext(ctx).Language()
+	})
+
+	update := &models.Update{Message: &models.Message{
+		From: &models.User{ID: 1, LanguageCode: "zh-hans"},
+		Text: "hello",
+	}}
+	handler(ctx, nil, update)
+	if got != i18n.Chinese {
+		t.Errorf("expected Telegram app language zh, got %q", got)
+	}
+
+	if err := store.SavePreferences(ctx, &session.Preferences{UserID: 1, Language: i18n.English, UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("SavePreferences failed: %v", err)
+	}
+	handler(ctx, nil, update)
+	if got != i18n.English {
+		t.Errorf("expected /language override en, got %q", got)
+	}
+
+	callback := &models.Update{CallbackQuery: &models.CallbackQuery{From: models.User{ID: 2, LanguageCode: "zh"}}}
+	handler(ctx, nil, callback)
+	if got != i18n.Chinese {
+		t.Errorf("expected callback sender language zh, got %q", got)
+	}
+
+	handler(ctx, nil, &models.Update{})
+	if got != i18n.DefaultLanguage {
+		t.Errorf("expected default language without sender, got %q", got)
+	}
+}
+
+func TestFormatLanguages(t *testing.T) {
+	if got := formatLanguages(); got != "en (English), zh (中文)" {
+		t.Errorf("unexpected language list %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// maintenanceNotice is the reply to users while the bot is in maintenance mode
+const maintenanceNotice = "🚧 The bot is under maintenance and cannot save anything right now. Please try again later."
+
+// MaintenanceMiddleware is a bot middleware that answers users with a maintenance
+// notice while the store is read-only, instead of running handlers that would
+// fail to save. Administrators are let through, so they can turn it off again.
+func MaintenanceMiddleware(store session.ReadOnlyStore, cfg *HandlerConfig) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if refuseDuringMaintenance(ctx, b, store, cfg, update) {
+				return
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// refuseDuringMaintenance reports whether update must be dropped because the
+// store is read-only, telling message senders and button presses why
+func refuseDuringMaintenance(ctx context.Context, b TelegramAPI, store session.ReadOnlyStore, cfg *HandlerConfig, update *models.Update) bool {
+	if !store.ReadOnly() {
+		return false
+	}
+	if user := updateSender(update); user != nil && isAdmin(cfg, user.ID) {
+		return false
+	}
+
+	tr := i18n.FromContext(ctx)
+	switch {
+	case update.Message != nil && update.Message.From != nil:
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            tr.T(maintenanceNotice),
+		})
+	case update.CallbackQuery != nil:
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: update.CallbackQuery.ID,
+			Text:            tr.T(maintenanceNotice),
+			ShowAlert:       true,
+		})
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/maintenance"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_maintenance.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	cfg := &HandlerConfig{AdminUserIDs: []int64{9}}
+	api := testutil.NewFakeTelegram()
+	command := AdminMaintenanceCommand(maintenance.New(store, session.MaintenanceOptions{}), store)
+
+	if refuseDuringMaintenance(ctx, api, store, cfg, commandUpdate(1, "hello")) {
+		t.Fatal("messages should pass outside maintenance mode")
+	}
+
+	reply, err := command(ctx, 9, []string{"on"})
+	if err != nil {
+		t.Fatalf("maintenance on failed: %v", err)
+	}
+	if !strings.Contains(reply, "Maintenance mode on") {
+		t.Errorf("expected maintenance mode confirmed, got %q", reply)
+	}
+	if _, err := session.NewManager(store).CreateSession(ctx, 1, "hello"); !errors.Is(err, session.ErrReadOnly) {
+		t.Errorf("expected writes refused with ErrReadOnly, got %v", err)
+	}
+
+	if !refuseDuringMaintenance(ctx, api, store, cfg, commandUpdate(1, "hello")) {
+		t.Fatal("messages should be refused in maintenance mode")
+	}
+	if !strings.Contains(api.LastText(), "under maintenance") {
+		t.Errorf("expected a maintenance notice, got %q", api.LastText())
+	}
+	callback := &models.Update{CallbackQuery: &models.CallbackQuery{ID: "cb", From: models.User{ID: 1}}}
+	if !refuseDuringMaintenance(ctx, api, store, cfg, callback) || len(api.CallbackAnswers) != 1 {
+		t.Errorf("expected the button press answered with the notice, got %d answers", len(api.CallbackAnswers))
+	}
+	if refuseDuringMaintenance(ctx, api, store, cfg, commandUpdate(9, "/admin maintenance off")) {
+		t.Error("administrators should pass in maintenance mode")
+	}
+
+	if _, err := command(ctx, 9, []string{"off"}); err != nil {
+		t.Fatalf("maintenance off failed: %v", err)
+	}
+	if _, err := session.NewManager(store).CreateSession(ctx, 1, "hello"); err != nil {
+		t.Errorf("expected writes allowed again, got %v", err)
+	}
+
+	if reply, _ := command(ctx, 9, []string{"maybe"}); !strings.Contains(reply, "Usage") {
+		t.Errorf("expected usage for an unknown argument, got %q", reply)
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// memoryUsage explains the /memory subcommands
+const memoryUsage = "Usage:\n/memory - show what the active session remembers\n/memory set <key>=<value> - remember a value, e.g. /memory set name=Bob\n/memory delete <key> - forget a value\n/memory clear - forget everything"
+
+// memoryKeyPattern matches valid memory keys once lowercased
+var memoryKeyPattern = regexp.MustCompile(`^[\p{L}\p{N}_.-]+$`)
+
+// memoryContextIntro starts the system message carrying a session's memory to the AI
+const memoryContextIntro = "The user asked you to remember these values in this conversation:"
+
+// MemoryCommandHandler handles the /memory command.
+// It lists, sets and deletes the values remembered for the active session,
+// which are given to the AI assistant with every request.
+func MemoryCommandHandler(sessionMgr *session.Manager, memory session.MemoryStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+		reply := func(text string) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+				ParseMode:       models.ParseModeHTML,
+			})
+		}
+		// confirm runs write and confirms it with the text it returns
+		confirm := func(write func(ctx context.Context) (string, error)) error {
+			return cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+				text, err := write(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return &bot.SendMessageParams{
+					ChatID:          update.Message.Chat.ID,
+					MessageThreadID: topicThreadID(update.Message),
+					Text:            text,
+					ParseMode:       models.ParseModeHTML,
+				}, nil
+			})
+		}
+		fail := func(err error) {
+			LogError(ctx, "memory_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+		}
+
+		activeSession, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetActiveSession(ctx, userID)
+		if errors.Is(err, session.ErrSessionNotFound) {
+			reply(format.Escape(tr.T("No active session. Send a message to start one, then use /memory.")))
+			return
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		cmd := messageCommand(ctx, update.Message)
+		subcommand, rest := "", ""
+		if len(cmd.Args) > 0 {
+			subcommand = strings.ToLower(cmd.Args[0])
+			rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmd.RawArgs), cmd.Args[0]))
+		}
+
+		switch {
+		case subcommand == "" || subcommand == "list":
+			entries, err := memory.ListMemory(ctx, activeSession.ID)
+			if err != nil {
+				fail(err)
+				return
+			}
+			reply(formatMemory(tr, activeSession, entries))
+
+		case subcommand == "set":
+			key, value, ok := strings.Cut(rest, "=")
+			key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+			if !ok || value == "" || !validMemoryKey(key) {
+				reply(format.Escape(tr.Sprintf("Keys are up to %d letters, digits, '_', '-' or '.'.", session.MaxMemoryKeyLength) +
+					"\n\n" + tr.T(memoryUsage)))
+				return
+			}
+			if utf8.RuneCountInString(value) > session.MaxMemoryValueLength {
+				reply(format.Escape(tr.Sprintf("Values are limited to %d characters.", session.MaxMemoryValueLength)))
+				return
+			}
+
+			err := confirm(func(ctx context.Context) (string, error) {
+				entry := &session.MemoryEntry{SessionID: activeSession.ID, Key: key, Value: value, UpdatedAt: time.Now()}
+				if err := memory.SetMemory(ctx, entry); err != nil {
+					return "", err
+				}
+				return tr.Sprintf("🧠 Remembered %s", format.Code(key+" = "+value)), nil
+			})
+			if errors.Is(err, session.ErrMemoryFull) {
+				reply(format.Escape(tr.Sprintf("This session already remembers %d values. Delete one with /memory delete <key> first.", session.MaxMemoryEntries)))
+				return
+			}
+			if err != nil {
+				fail(err)
+				return
+			}
+			LogInfo(ctx, "memory_command", userID, "memory set", map[string]interface{}{
+				"session_id": activeSession.ID.String(),
+				"key":        key,
+			})
+
+		case (subcommand == "delete" || subcommand == "del") && rest != "":
+			key := strings.ToLower(rest)
+			err := confirm(func(ctx context.Context) (string, error) {
+				if err := memory.DeleteMemory(ctx, activeSession.ID, key); err != nil {
+					return "", err
+				}
+				return tr.Sprintf("🗑 Forgot %s", format.Code(key)), nil
+			})
+			if errors.Is(err, session.ErrMemoryNotFound) {
+				reply(tr.Sprintf("Nothing is remembered as %s.", format.Code(key)))
+				return
+			}
+			if err != nil {
+				fail(err)
+			}
+
+		case subcommand == "clear":
+			var cleared int
+			err := confirm(func(ctx context.Context) (string, error) {
+				var err error
+				if cleared, err = memory.ClearMemory(ctx, activeSession.ID); err != nil {
+					return "", err
+				}
+				return format.Escape(tr.Sprintf("🗑 Forgot %d values.", cleared)), nil
+			})
+			if err != nil {
+				fail(err)
+				return
+			}
+			LogInfo(ctx, "memory_command", userID, "memory cleared", map[string]interface{}{
+				"session_id": activeSession.ID.String(),
+				"entries":    cleared,
+			})
+
+		default:
+			reply(format.Escape(tr.T(memoryUsage)))
+		}
+	}
+}
+
+// validMemoryKey reports whether key may name a memory entry
+func validMemoryKey(key string) bool {
+	return utf8.RuneCountInString(key) <= session.MaxMemoryKeyLength && memoryKeyPattern.MatchString(key)
+}
+
+// formatMemory renders a session's memory as HTML
+func formatMemory(tr *i18n.Translator, sess *session.Session, entries []*session.MemoryEntry) string {
+	if len(entries) == 0 {
+		return tr.Sprintf("🧠 %s remembers nothing yet.", format.Bold(sess.Title)) + "\n\n" + format.Escape(tr.T(memoryUsage))
+	}
+	lines := []string{tr.Sprintf("🧠 Memory of %s (%d/%d):", format.Bold(sess.Title), len(entries), session.MaxMemoryEntries)}
+	for _, entry := range entries {
+		lines = append(lines, format.Code(entry.Key)+" = "+format.Escape(entry.Value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// memoryContext returns the system message giving the AI the memory of a
+// session, or nil when it remembers nothing. Failures are logged and leave the
+// memory out.
+func memoryContext(ctx context.Context, cfg *HandlerConfig, userID int64, sessionID uuid.UUID) *ai.Message {
+	if cfg.Memory == nil {
+		return nil
+	}
+	entries, err := cfg.Memory.ListMemory(ctx, sessionID)
+	if err != nil {
+		LogError(ctx, "assistant", userID, err, map[string]interface{}{"session_id": sessionID.String()})
+		return nil
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	lines := []string{memoryContextIntro}
+	for _, entry := range entries {
+		lines = append(lines, entry.Key+": "+entry.Value)
+	}
+	return &ai.Message{Role: ai.RoleSystem, Content: strings.Join(lines, "\n")}
+}
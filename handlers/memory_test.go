@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func TestMemoryCommand(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	sessionMgr := session.NewManager(store)
+	handler := MemoryCommandHandler(sessionMgr, store, &HandlerConfig{})
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, commandUpdate(1, "/memory set name=Bob"))
+	if !strings.Contains(api.LastText(), "No active session") {
+		t.Fatalf("expected a no active session notice, got %q", api.LastText())
+	}
+
+	active, err := sessionMgr.CreateSession(ctx, 1, "Trip")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	handler(ctx, api, commandUpdate(1, "/memory set Name = Bob <Smith>"))
+	if !strings.Contains(api.LastText(), "Remembered <code>name = Bob &lt;Smith&gt;</code>") {
+		t.Errorf("expected a confirmation, got %q", api.LastText())
+	}
+	handler(ctx, api, commandUpdate(1, "/memory set city=Lisbon"))
+	handler(ctx, api, commandUpdate(1, "/memory set bad key=x"))
+	if !strings.Contains(api.LastText(), "Keys are up to") {
+		t.Errorf("expected a key error, got %q", api.LastText())
+	}
+
+	handler(ctx, api, commandUpdate(1, "/memory"))
+	if got := api.LastText(); !strings.Contains(got, "(2/20)") || !strings.Contains(got, "<code>city</code> = Lisbon") {
+		t.Errorf("expected both values listed, got %q", got)
+	}
+
+	handler(ctx, api, commandUpdate(1, "/memory delete city"))
+	handler(ctx, api, commandUpdate(1, "/memory delete city"))
+	if !strings.Contains(api.LastText(), "Nothing is remembered") {
+		t.Errorf("expected a not found notice, got %q", api.LastText())
+	}
+	if entries, _ := store.ListMemory(ctx, active.ID); len(entries) != 1 || entries[0].Value != "Bob <Smith>" {
+		t.Errorf("expected only the name left, got %+v", entries)
+	}
+
+	handler(ctx, api, commandUpdate(1, "/memory clear"))
+	if entries, _ := store.ListMemory(ctx, active.ID); len(entries) != 0 {
+		t.Errorf("expected the memory cleared, got %d entries", len(entries))
+	}
+}
+
+func TestAssistantReceivesMemory(t *testing.T) {
+	provider := &fakeProvider{replies: []*ai.Message{{Role: ai.RoleAssistant, Content: "Hi Bob!"}}}
+	store, sessionMgr, _ := newAssistantTest(t, provider)
+	ctx := context.Background()
+
+	if _, err := sessionMgr.CreateSession(ctx, 1, "Chat"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	MemoryCommandHandler(sessionMgr, store, &HandlerConfig{})(ctx, testutil.NewFakeTelegram(), commandUpdate(1, "/memory set name=Bob"))
+
+	cfg := &HandlerConfig{Assistant: ai.NewAssistant(provider, ai.NewRegistry(), 0), Memory: store}
+	MessageHandler(sessionMgr, session.NewMessageManager(store), cfg)(ctx, testutil.NewFakeTelegram(), textUpdate(1, "Hello"))
+
+	messages := provider.requests[0].Messages
+	if messages[0].Role != ai.RoleSystem || !strings.Contains(messages[0].Content, "name: Bob") {
+		t.Errorf("expected the memory as a system message, got %+v", messages[0])
+	}
+	if last := messages[len(messages)-1]; last.Content != "Hello" {
+		t.Errorf("expected the user's message last, got %+v", last)
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// Merge flow identifiers
+const (
+	mergeFlow       = "merge"
+	mergeStepSource = "source"
+	mergeStepTarget = "target"
+
+	// maxMergeChoices is how many recent sessions /merge offers
+	maxMergeChoices = 10
+)
+
+// MergeFlow asks for the session to merge away and the session to merge it into,
+// by their numbers in the list shown by /merge, then merges them
+func MergeFlow(sessionMgr *session.Manager, store session.MergeStore) *Flow {
+	return &Flow{
+		Name: mergeFlow,
+		Steps: map[string]StepFunc{
+			mergeStepSource: func(ctx context.Context, state *session.ConversationState, text string) (StepResult, error) {
+				tr := i18n.FromContext(ctx)
+				sourceID, ok := pickMergeChoice(state, text)
+				if !ok {
+					return StepResult{Reply: mergeChoiceHint(tr, state), Next: mergeStepSource}, nil
+				}
+
+				source, err := sessionMgr.GetSession(ctx, state.UserID, sourceID)
+				if err != nil {
+					return StepResult{}, err
+				}
+				state.Data["source"] = sourceID.String()
+				return StepResult{
+					Reply: tr.Sprintf("Now reply with the number of the session to merge %s into, or /cancel.", format.Bold(source.Title)),
+					Next:  mergeStepTarget,
+				}, nil
+			},
+			mergeStepTarget: func(ctx context.Context, state *session.ConversationState, text string) (StepResult, error) {
+				tr := i18n.FromContext(ctx)
+				sourceID, err := uuid.Parse(state.Data["source"])
+				if err != nil {
+					return StepResult{}, fmt.Errorf("invalid source in merge flow: %w", err)
+				}
+				targetID, ok := pickMergeChoice(state, text)
+				if !ok {
+					return StepResult{Reply: mergeChoiceHint(tr, state), Next: mergeStepTarget}, nil
+				}
+				if targetID == sourceID {
+					return StepResult{
+						Reply: tr.T("A session can't be merged into itself. Pick a different number, or /cancel."),
+						Next:  mergeStepTarget,
+					}, nil
+				}
+
+				source, err := sessionMgr.GetSession(ctx, state.UserID, sourceID)
+				if err != nil {
+					return StepResult{}, err
+				}
+				report, err := store.MergeSessions(ctx, state.UserID, sourceID, targetID)
+				if err != nil {
+					return StepResult{}, err
+				}
+
+				LogInfo(ctx, "merge_flow", state.UserID, "sessions merged", map[string]interface{}{
+					"source_id": sourceID.String(),
+					"target_id": targetID.String(),
+					"messages":  report.Messages,
+					"files":     report.Files,
+				})
+				return StepResult{Reply: tr.Sprintf("✅ Merged %s into %s: moved %d messages and %d files.",
+					format.Bold(source.Title), format.Bold(report.Target.Title), report.Messages, report.Files)}, nil
+			},
+		},
+	}
+}
+
+// pickMergeChoice returns the session numbered text in the /merge list
+func pickMergeChoice(state *session.ConversationState, text string) (uuid.UUID, bool) {
+	choices := strings.Split(state.Data["sessions"], ",")
+	number, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), ".")))
+	if err != nil || number < 1 || number > len(choices) {
+		return uuid.Nil, false
+	}
+	sessionID, err := uuid.Parse(choices[number-1])
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return sessionID, true
+}
+
+// mergeChoiceHint asks again for a number of the /merge list
+func mergeChoiceHint(tr *i18n.Translator, state *session.ConversationState) string {
+	return tr.Sprintf("Send a number from 1 to %d, or /cancel.", len(strings.Split(state.Data["sessions"], ",")))
+}
+
+// MergeCommandHandler handles the /merge command.
+// It lists the user's recent sessions and starts the merge flow.
+func MergeCommandHandler(sessionMgr *session.Manager, conversations *Conversations) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		chatID := update.Message.Chat.ID
+		tr := i18n.FromContext(ctx)
+		reply := func(text string) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+				ParseMode:       models.ParseModeHTML,
+			})
+		}
+
+		sessions, _, err := sessionMgr.ListSessions(ctx, userID, 0, maxMergeChoices)
+		if err != nil {
+			LogError(ctx, "merge_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+		if len(sessions) < 2 {
+			reply(tr.T("You need at least two sessions to merge."))
+			return
+		}
+
+		ids := make([]string, len(sessions))
+		var text strings.Builder
+		text.WriteString(tr.T("🔀 Which session should be merged into another one? Its messages move over and it is deleted. Reply with its number, or /cancel."))
+		text.WriteString("\n")
+		for i, s := range sessions {
+			ids[i] = s.ID.String()
+			fmt.Fprintf(&text, "\n%d. %s", i+1, format.Escape(formatSessionButton(tr, s)))
+		}
+
+		err = conversations.Start(ctx, userID, chatID, mergeFlow, mergeStepSource, map[string]string{
+			"sessions": strings.Join(ids, ","),
+		})
+		if err != nil {
+			LogError(ctx, "merge_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "merge_command", userID, "merge flow started", map[string]interface{}{
+			"sessions": len(sessions),
+		})
+		reply(text.String())
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func TestMergeFlow(t *testing.T) {
+	conversations, store := newTestConversations(t)
+	sessionMgr := session.NewManager(store)
+	conversations.Register(MergeFlow(sessionMgr, store))
+	ctx := context.Background()
+
+	older, err := sessionMgr.CreateSession(ctx, 1, "older")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := store.AppendMessage(ctx, session.NewMessage(older.ID, 1, session.RoleUser, "from older")); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+	newer, err := sessionMgr.CreateSession(ctx, 1, "newer")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	api := testutil.NewFakeTelegram()
+	MergeCommandHandler(sessionMgr, conversations)(ctx, api, commandUpdate(1, "/merge"))
+	if text := api.LastText(); !strings.Contains(text, "1. newer") || !strings.Contains(text, "2. older") {
+		t.Fatalf("expected numbered sessions, newest first, got %q", text)
+	}
+
+	handler := conversations.Handler(&HandlerConfig{})
+	for _, reply := range []string{"7", "2", "2"} {
+		handler(ctx, api, textUpdate(1, reply))
+	}
+	if text := api.LastText(); !strings.Contains(text, "itself") {
+		t.Fatalf("expected merging into itself to be refused, got %q", text)
+	}
+
+	handler(ctx, api, textUpdate(1, "1"))
+	if text := api.LastText(); !strings.Contains(text, "moved 1 messages") {
+		t.Fatalf("expected a merge summary, got %q", text)
+	}
+	if _, err := store.Get(ctx, older.ID); err == nil {
+		t.Error("expected the source session to be deleted")
+	}
+	if count, _ := store.CountMessages(ctx, newer.ID); count != 1 {
+		t.Errorf("expected the message in the target, got %d", count)
+	}
+	if conversations.Match(textUpdate(1, "hello")) {
+		t.Error("expected the flow to end")
+	}
+}
+
+func TestMergeCommandNeedsTwoSessions(t *testing.T) {
+	conversations, store := newTestConversations(t)
+	sessionMgr := session.NewManager(store)
+	conversations.Register(MergeFlow(sessionMgr, store))
+	ctx := context.Background()
+
+	if _, err := sessionMgr.CreateSession(ctx, 1, "only"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	api := testutil.NewFakeTelegram()
+	MergeCommandHandler(sessionMgr, conversations)(ctx, api, commandUpdate(1, "/merge"))
+	if text := api.LastText(); !strings.Contains(text, "at least two") {
+		t.Errorf("expected a refusal, got %q", text)
+	}
+	if conversations.Match(textUpdate(1, "1")) {
+		t.Error("expected no flow to start")
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/moderation"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// FlaggedCallbackPrefix is the common prefix of all /admin flagged keyboard callbacks
+const FlaggedCallbackPrefix = "flag_"
+
+// Callback data prefixes for the /admin flagged keyboard
+const (
+	flaggedPagePrefix   = "flag_page_"
+	flaggedReviewPrefix = "flag_rev_" // followed by "<id>_<offset>"
+)
+
+// flaggedPreviewLength is the number of characters of each flagged text shown to administrators
+const flaggedPreviewLength = 300
+
+// filterContent checks text with the content filter and returns the text to
+// use, redacted when the action is redact, with the matches found. A failing
+// filter is logged and lets the text through.
+func filterContent(ctx context.Context, cfg *HandlerConfig, userID int64, text string) (string, []moderation.Match) {
+	if cfg.ContentFilter == nil || text == "" {
+		return text, nil
+	}
+	matches, err := cfg.ContentFilter.Check(ctx, text)
+	if err != nil {
+		LogError(ctx, "content_filter", userID, err, nil)
+		return text, nil
+	}
+	if len(matches) > 0 && cfg.FilterAction == moderation.ActionRedact {
+		return moderation.Redact(text, matches), matches
+	}
+	return text, matches
+}
+
+// blocksContent reports whether text with matches must not be delivered
+func (cfg *HandlerConfig) blocksContent(matches []moderation.Match) bool {
+	return len(matches) > 0 && cfg.FilterAction == moderation.ActionBlock
+}
+
+// flagContent records the original text of content the filter matched in the
+// review queue; content carries who sent it and where
+func flagContent(ctx context.Context, cfg *HandlerConfig, content *session.FlaggedContent, matches []moderation.Match) {
+	if len(matches) == 0 {
+		return
+	}
+	content.Action = string(cfg.FilterAction)
+	content.Rules = moderation.Rules(matches)
+	content.CreatedAt = time.Now()
+
+	LogWarning(ctx, "content_filter", content.UserID, "content matched filter", map[string]interface{}{
+		"direction": content.Direction,
+		"action":    content.Action,
+		"rules":     content.Rules,
+	})
+	if cfg.FlaggedContent == nil {
+		return
+	}
+	if err := cfg.FlaggedContent.AddFlaggedContent(ctx, content); err != nil {
+		LogError(ctx, "content_filter", content.UserID, err, nil)
+	}
+}
+
+// AdminFlaggedCommand lists content awaiting review with reviewed buttons
+func AdminFlaggedCommand(flagged session.FlaggedContentStore, cfg *HandlerConfig) AdminReplyFunc {
+	return func(ctx context.Context, userID int64, args []string) (*AdminReply, error) {
+		return buildFlaggedPage(ctx, flagged, 0, cfg.sessionsPerPage(ctx))
+	}
+}
+
+// FlaggedCallbackHandler handles button clicks on the /admin flagged keyboard
+func FlaggedCallbackHandler(flagged session.FlaggedContentStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		userID := callback.From.ID
+		tr := i18n.FromContext(ctx)
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "flagged_callback", err, tr.T("⌛ This menu expired. Send /admin flagged to get a fresh one."))
+			return
+		}
+		if cfg.permissionError(ctx, userID, "/admin", "flagged") != nil {
+			LogWarning(ctx, "flagged_callback", userID, "rejected callback", map[string]interface{}{
+				"callback_data": callback.Data,
+			})
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: callback.ID,
+				Text:            tr.T("⌛ This menu expired. Send /admin flagged to get a fresh one."),
+				ShowAlert:       true,
+			})
+			return
+		}
+
+		msg := callback.Message.Message
+		if msg == nil {
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+			return
+		}
+
+		var offset int
+		answer := ""
+		switch {
+		case strings.HasPrefix(data, flaggedPagePrefix):
+			offset, err = parsePageOffset(data, flaggedPagePrefix)
+		case strings.HasPrefix(data, flaggedReviewPrefix):
+			var id int64
+			id, offset, err = parseFlaggedReview(data)
+			if err != nil {
+				break
+			}
+			err = flagged.ReviewFlaggedContent(ctx, id, userID, time.Now())
+			switch {
+			case err == nil:
+				LogInfo(ctx, "flagged_callback", userID, "flagged content reviewed", map[string]interface{}{"flagged_id": id})
+				answer = tr.Sprintf("✅ Flagged #%d reviewed", id)
+			case errors.Is(err, session.ErrFlaggedContentNotFound):
+				answer = tr.Sprintf("Flagged #%d was already reviewed", id)
+				err = nil
+			}
+		default:
+			err = fmt.Errorf("invalid callback data: %q", data)
+		}
+		if err != nil {
+			LogError(ctx, "flagged_callback", userID, err, map[string]interface{}{"callback_data": data})
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+			return
+		}
+
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID, Text: answer})
+
+		perPage := cfg.sessionsPerPage(ctx)
+		page, err := buildFlaggedPage(ctx, flagged, offset, perPage)
+		if err != nil {
+			LogError(ctx, "flagged_callback", userID, err, map[string]interface{}{"offset": offset})
+			return
+		}
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			Text:        page.Text,
+			ReplyMarkup: cfg.callbacks().EncodeKeyboard(ctx, page.Keyboard),
+		})
+	}
+}
+
+// buildFlaggedPage renders the content awaiting review starting at offset. When
+// reviewing emptied the last page, the previous page is shown instead.
+func buildFlaggedPage(ctx context.Context, flagged session.FlaggedContentStore, offset, perPage int) (*AdminReply, error) {
+	tr := i18n.FromContext(ctx)
+
+	total, err := flagged.CountOpenFlaggedContent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return &AdminReply{Text: tr.T("📭 No flagged content to review.")}, nil
+	}
+	for offset >= total {
+		offset -= perPage
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := flagged.ListOpenFlaggedContent(ctx, offset, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{tr.Sprintf("🚩 Flagged content to review: %d", total)}
+	rows := make([][]models.InlineKeyboardButton, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, "", formatFlaggedEntry(tr, entry))
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         tr.Sprintf("✅ Reviewed #%d", entry.ID),
+			CallbackData: fmt.Sprintf("%s%d_%d", flaggedReviewPrefix, entry.ID, offset),
+		}})
+	}
+
+	return &AdminReply{
+		Text:     strings.Join(lines, "\n"),
+		Keyboard: buildPagedKeyboard(tr, rows, flaggedPagePrefix, offset, offset > 0, offset+len(entries) < total, perPage, total),
+	}, nil
+}
+
+// formatFlaggedEntry renders one flagged content for administrators
+func formatFlaggedEntry(tr *i18n.Translator, entry *session.FlaggedContent) string {
+	source := tr.Sprintf("user %d", entry.UserID)
+	if entry.Direction == session.DirectionOutbound {
+		source = tr.Sprintf("assistant reply to user %d", entry.UserID)
+	}
+	header := tr.Sprintf("#%d · %s · %s · %s", entry.ID, source, entry.Action,
+		tr.FormatTime(entry.CreatedAt, "Jan 2, 2006 15:04"))
+	if entry.SessionTitle != "" {
+		header += " · " + tr.Sprintf("session %s", truncate(entry.SessionTitle, 32))
+	}
+	rules := tr.Sprintf("Matched: %s", truncate(strings.Join(entry.Rules, ", "), 100))
+	return header + "\n" + rules + "\n" + truncate(entry.Text, flaggedPreviewLength)
+}
+
+// parseFlaggedReview extracts the flagged content ID and page offset from review callback data
+func parseFlaggedReview(data string) (int64, int, error) {
+	idStr, offsetStr, ok := strings.Cut(strings.TrimPrefix(data, flaggedReviewPrefix), "_")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid review callback: %q", data)
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid flagged content ID: %w", err)
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return 0, 0, fmt.Errorf("invalid offset: %q", offsetStr)
+	}
+	return id, offset, nil
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/moderation"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func newModerationTest(t *testing.T, action moderation.Action) (*session.SQLiteStore, *session.Manager, *HandlerConfig) {
+	t.Helper()
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "moderation.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	filter, err := moderation.NewKeywordFilter([]string{"darn"}, nil)
+	if err != nil {
+		t.Fatalf("NewKeywordFilter failed: %v", err)
+	}
+	cfg := &HandlerConfig{
+		SessionsPerPage: 5,
+		AdminUserIDs:    []int64{99},
+		CallbackSigner:  NewCallbackSigner("secret", time.Hour),
+		ContentFilter:   filter,
+		FilterAction:    action,
+		FlaggedContent:  store,
+	}
+	return store, session.NewManager(store), cfg
+}
+
+func TestMessageHandlerBlocksFilteredMessages(t *testing.T) {
+	store, sessionMgr, cfg := newModerationTest(t, moderation.ActionBlock)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	MessageHandler(sessionMgr, session.NewMessageManager(store), cfg)(ctx, api, textUpdate(1, "Darn this"))
+
+	if !strings.Contains(api.LastText(), "blocked by the content filter") {
+		t.Fatalf("expected a blocked notice, got %q", api.LastText())
+	}
+	if _, err := sessionMgr.GetActiveSession(ctx, 1); err == nil {
+		t.Error("expected the blocked message not to create a session")
+	}
+	flagged, err := store.ListOpenFlaggedContent(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ListOpenFlaggedContent failed: %v", err)
+	}
+	if len(flagged) != 1 || flagged[0].Text != "Darn this" || flagged[0].Action != "block" || flagged[0].Direction != session.DirectionInbound {
+		t.Errorf("expected the blocked message in the review queue, got %+v", flagged)
+	}
+}
+
+func TestMessageHandlerRedactsFilteredMessages(t *testing.T) {
+	store, sessionMgr, cfg := newModerationTest(t, moderation.ActionRedact)
+	messageMgr := session.NewMessageManager(store)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	MessageHandler(sessionMgr, messageMgr, cfg)(ctx, api, textUpdate(1, "well darn it"))
+
+	active, err := sessionMgr.GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	history, err := messageMgr.History(ctx, active.ID, 10)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Content != "well *** it" {
+		t.Fatalf("expected the redacted message to be stored, got %+v", history)
+	}
+	flagged, _ := store.ListOpenFlaggedContent(ctx, 0, 10)
+	if len(flagged) != 1 || flagged[0].Text != "well darn it" || flagged[0].SessionID != active.ID {
+		t.Errorf("expected the original message linked to the session, got %+v", flagged)
+	}
+}
+
+func TestAssistantReplyWithheldByFilter(t *testing.T) {
+	store, sessionMgr, cfg := newModerationTest(t, moderation.ActionBlock)
+	provider := &fakeProvider{replies: []*ai.Message{{Role: ai.RoleAssistant, Content: "Oh darn."}}}
+	cfg.Assistant = ai.NewAssistant(provider, ai.NewRegistry(), 0)
+	messageMgr := session.NewMessageManager(store)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	MessageHandler(sessionMgr, messageMgr, cfg)(ctx, api, textUpdate(1, "Say something"))
+
+	if !strings.Contains(api.LastText(), "withheld by the content filter") {
+		t.Fatalf("expected a withheld notice, got %q", api.LastText())
+	}
+	active, _ := sessionMgr.GetActiveSession(ctx, 1)
+	if history, _ := messageMgr.History(ctx, active.ID, 10); len(history) != 1 {
+		t.Errorf("expected only the user's message to be stored, got %d messages", len(history))
+	}
+	flagged, _ := store.ListOpenFlaggedContent(ctx, 0, 10)
+	if len(flagged) != 1 || flagged[0].Direction != session.DirectionOutbound || flagged[0].Text != "Oh darn." {
+		t.Errorf("expected the withheld reply in the review queue, got %+v", flagged)
+	}
+}
+
+func TestFlaggedReview(t *testing.T) {
+	store, sessionMgr, cfg := newModerationTest(t, moderation.ActionFlag)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	MessageHandler(sessionMgr, session.NewMessageManager(store), cfg)(ctx, api, textUpdate(1, "darn"))
+	if !strings.Contains(api.LastText(), "Message received") {
+		t.Fatalf("expected a flagged message to go through, got %q", api.LastText())
+	}
+
+	page, err := AdminFlaggedCommand(store, cfg)(ctx, 99, nil)
+	if err != nil {
+		t.Fatalf("admin flagged failed: %v", err)
+	}
+	if !strings.Contains(page.Text, "Flagged content to review: 1") || !strings.Contains(page.Text, "Matched: darn\ndarn") {
+		t.Errorf("expected the flagged message, got %q", page.Text)
+	}
+	review := page.Keyboard.InlineKeyboard[0][0].CallbackData
+
+	callbacks := FlaggedCallbackHandler(store, cfg)
+	callbacks(ctx, api, callbackUpdate(1, cfg.CallbackSigner.Sign(review)))
+	if count, _ := store.CountOpenFlaggedContent(ctx); count != 1 {
+		t.Fatal("expected non-admins to be unable to review flagged content")
+	}
+
+	callbacks(ctx, api, callbackUpdate(99, cfg.CallbackSigner.Sign(review)))
+	if count, _ := store.CountOpenFlaggedContent(ctx); count != 0 {
+		t.Fatalf("expected no open flagged content after review, got %d", count)
+	}
+	if edited := api.EditedTexts[len(api.EditedTexts)-1]; !strings.Contains(edited.Text, "No flagged content") {
+		t.Errorf("expected the emptied queue, got %q", edited.Text)
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// DefaultCommandRoles are the least roles allowed to run commands not listed
+// in the command_roles option. Admin subcommands fall back to the "/admin"
+// entry; other commands not listed are open to everyone.
+var DefaultCommandRoles = map[string]session.UserRole{
+	"/admin":          session.UserRoleAdmin,
+	"/admin feedback": session.UserRoleModerator,
+	"/admin flagged":  session.UserRoleModerator,
+	"/admin sessions": session.UserRoleModerator,
+}
+
+// MergeCommandRoles returns DefaultCommandRoles with overrides applied.
+// Overrides map commands to role names and must have been validated.
+func MergeCommandRoles(overrides map[string]string) map[string]session.UserRole {
+	roles := make(map[string]session.UserRole, len(DefaultCommandRoles)+len(overrides))
+	for command, role := range DefaultCommandRoles {
+		roles[command] = role
+	}
+	for command, role := range overrides {
+		roles[command] = session.UserRole(role)
+	}
+	return roles
+}
+
+// userRole returns the role of userID. Configured administrators are always
+// admins; a role that cannot be looked up counts as a plain user.
+func (cfg *HandlerConfig) userRole(ctx context.Context, userID int64) session.UserRole {
+	if isAdmin(cfg, userID) {
+		return session.UserRoleAdmin
+	}
+	if cfg.Roles == nil {
+		return session.UserRoleUser
+	}
+	role, err := cfg.Roles.GetUserRole(ctx, userID)
+	if err != nil {
+		LogError(ctx, "user_role", userID, err, nil)
+		return session.UserRoleUser
+	}
+	return role
+}
+
+// requiredRole returns the least role allowed to run command with the given
+// subcommand, which may be empty
+func (cfg *HandlerConfig) requiredRole(command, subcommand string) session.UserRole {
+	roles := cfg.CommandRoles
+	if roles == nil {
+		roles = DefaultCommandRoles
+	}
+	if subcommand != "" {
+		if role, ok := roles[command+" "+strings.ToLower(subcommand)]; ok {
+			return role
+		}
+	}
+	if role, ok := roles[command]; ok {
+		return role
+	}
+	return session.UserRoleUser
+}
+
+// permissionError returns the error telling userID they may not run command
+// with subcommand, or nil when they may
+func (cfg *HandlerConfig) permissionError(ctx context.Context, userID int64, command, subcommand string) error {
+	required := cfg.requiredRole(command, subcommand)
+	if required == session.UserRoleUser || cfg.userRole(ctx, userID).Includes(required) {
+		return nil
+	}
+	if required == session.UserRoleAdmin {
+		return ErrAdminRequired
+	}
+	return ErrModeratorRequired
+}
+
+// PermissionMiddleware is a bot middleware that rejects commands whose sender's
+// role is below the one the command requires
+func PermissionMiddleware(cfg *HandlerConfig) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if rejectWithoutPermission(ctx, b, cfg, update) {
+				return
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// rejectWithoutPermission reports whether update is a command its sender may
+// not run, replying with the role it needs
+func rejectWithoutPermission(ctx context.Context, b TelegramAPI, cfg *HandlerConfig, update *models.Update) bool {
+	message := update.Message
+	if message == nil || message.From == nil {
+		return false
+	}
+	cmd, ok := ParseCommand(message.Text)
+	if !ok {
+		return false
+	}
+	var subcommand string
+	if len(cmd.Args) > 0 {
+		subcommand = cmd.Args[0]
+	}
+
+	err := cfg.permissionError(ctx, message.From.ID, cmd.Name, subcommand)
+	if err == nil {
+		return false
+	}
+	LogWarning(ctx, "permission", message.From.ID, "command rejected for missing role", map[string]interface{}{
+		"command":    cmd.Name,
+		"subcommand": subcommand,
+	})
+	SendErrorResponse(ctx, b, message, err)
+	return true
+}
+
+// AdminRoleCommand shows or changes a user's role: /admin role <user_id> [admin|moderator|user].
+// Without arguments it lists every assigned role.
+func AdminRoleCommand(roles session.RoleStore, cfg *HandlerConfig) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		usage := tr.T("Usage: /admin role [<user_id> [admin|moderator|user]]")
+		if len(args) == 0 {
+			assignments, err := roles.ListUserRoles(ctx)
+			if err != nil {
+				return "", err
+			}
+			lines := []string{tr.T("👥 Roles:")}
+			for _, id := range cfg.AdminUserIDs {
+				lines = append(lines, tr.Sprintf("%d: admin (configured)", id))
+			}
+			for _, assignment := range assignments {
+				lines = append(lines, fmt.Sprintf("%d: %s", assignment.UserID, assignment.Role))
+			}
+			return strings.Join(lines, "\n"), nil
+		}
+
+		target, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || target <= 0 || len(args) > 2 {
+			return usage, nil
+		}
+		if len(args) == 1 {
+			return tr.Sprintf("User %d is %s", target, cfg.userRole(ctx, target)), nil
+		}
+		if isAdmin(cfg, target) {
+			return tr.Sprintf("User %d is an administrator in admin_user_ids; change the configuration instead", target), nil
+		}
+
+		role, err := session.ParseUserRole(strings.ToLower(args[1]))
+		if errors.Is(err, session.ErrInvalidRole) {
+			return usage, nil
+		}
+		if err := roles.SetUserRole(ctx, target, role); err != nil {
+			return "", err
+		}
+		LogInfo(ctx, "admin_role", userID, "user role changed", map[string]interface{}{
+			"target_user_id": target,
+			"role":           string(role),
+		})
+		return tr.Sprintf("✅ User %d is now %s", target, role), nil
+	}
+}
+
+// AdminSessionsCommand lists a user's sessions, newest first, so moderators can
+// look into reports without access to the commands that change data
+func AdminSessionsCommand(sessionMgr *session.Manager, cfg *HandlerConfig) AdminCommandFunc {
+	return func(ctx context.Context, userID int64, args []string) (string, error) {
+		tr := i18n.FromContext(ctx)
+		if len(args) != 1 {
+			return tr.T("Usage: /admin sessions <user_id>"), nil
+		}
+		target, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || target <= 0 {
+			return tr.T("Usage: /admin sessions <user_id>"), nil
+		}
+
+		sessions, hasMore, err := sessionMgr.ListSessions(ctx, target, 0, cfg.sessionsPerPage(ctx))
+		if err != nil {
+			return "", err
+		}
+		if len(sessions) == 0 {
+			return tr.Sprintf("User %d has no sessions", target), nil
+		}
+
+		lines := []string{tr.Sprintf("📋 Sessions of user %d:", target)}
+		for _, s := range sessions {
+			lines = append(lines, fmt.Sprintf("%s %s (%s)", s.ID, s.Title, formatTimeAgo(tr, s.UpdatedAt)))
+		}
+		if hasMore {
+			lines = append(lines, tr.T("…and more"))
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+}
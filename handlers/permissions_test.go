@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func newRolesStore(t *testing.T) *session.SQLiteStore {
+	t.Helper()
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_roles.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestRejectWithoutPermission(t *testing.T) {
+	ctx := context.Background()
+	store := newRolesStore(t)
+	if err := store.SetUserRole(ctx, 5, session.UserRoleModerator); err != nil {
+		t.Fatalf("SetUserRole failed: %v", err)
+	}
+	cfg := &HandlerConfig{AdminUserIDs: []int64{9}, Roles: store,
+		CommandRoles: MergeCommandRoles(map[string]string{"/stats": "moderator"})}
+
+	tests := []struct {
+		userID int64
+		text   string
+		reject string // expected reply, empty when the command passes
+	}{
+		{1, "hello", ""},
+		{1, "/sessions", ""},
+		{1, "/stats", "moderators and administrators"},
+		{5, "/stats", ""},
+		{1, "/admin sessions 2", "moderators and administrators"},
+		{5, "/admin sessions 2", ""},
+		{5, "/admin FLAGGED", ""},
+		{5, "/admin purge 2", "only available to bot administrators"},
+		{5, "/admin", "only available to bot administrators"},
+		{9, "/admin purge 2", ""},
+	}
+	for _, tt := range tests {
+		api := testutil.NewFakeTelegram()
+		rejected := rejectWithoutPermission(ctx, api, cfg, commandUpdate(tt.userID, tt.text))
+		if rejected != (tt.reject != "") {
+			t.Errorf("user %d %q: rejected=%v, want %v", tt.userID, tt.text, rejected, tt.reject != "")
+			continue
+		}
+		if rejected && !strings.Contains(api.LastText(), tt.reject) {
+			t.Errorf("user %d %q: expected %q in reply, got %q", tt.userID, tt.text, tt.reject, api.LastText())
+		}
+	}
+}
+
+func TestAdminCommandHandlerRoles(t *testing.T) {
+	ctx := context.Background()
+	store := newRolesStore(t)
+	if err := store.SetUserRole(ctx, 5, session.UserRoleModerator); err != nil {
+		t.Fatalf("SetUserRole failed: %v", err)
+	}
+	sessionMgr := session.NewManager(store)
+	if _, err := sessionMgr.CreateSession(ctx, 2, "Reported chat"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	cfg := &HandlerConfig{AdminUserIDs: []int64{9}, Roles: store, SessionsPerPage: 5}
+	purged := false
+	handler := AdminCommandHandler(cfg, map[string]AdminCommand{
+		"sessions": AdminSessionsCommand(sessionMgr, cfg),
+		"role":     AdminRoleCommand(store, cfg),
+		"purge": AdminCommandFunc(func(ctx context.Context, userID int64, args []string) (string, error) {
+			purged = true
+			return "purged", nil
+		}),
+	})
+
+	api := testutil.NewFakeTelegram()
+	handler(ctx, api, commandUpdate(5, "/admin sessions 2"))
+	if !strings.Contains(api.LastText(), "Sessions of user 2:") || !strings.Contains(api.LastText(), "Reported chat") {
+		t.Errorf("expected the moderator to see the sessions, got %q", api.LastText())
+	}
+	handler(ctx, api, commandUpdate(5, "/admin purge 2"))
+	if purged || !strings.Contains(api.LastText(), "only available to bot administrators") {
+		t.Errorf("expected the moderator refused to purge, purged=%v reply %q", purged, api.LastText())
+	}
+	handler(ctx, api, commandUpdate(5, "/admin nope"))
+	if strings.Contains(api.LastText(), "/admin role") {
+		t.Errorf("expected no admin-only commands listed to a moderator, got %q", api.LastText())
+	}
+
+	handler(ctx, api, commandUpdate(9, "/admin role 7 moderator"))
+	if role, _ := store.GetUserRole(ctx, 7); role != session.UserRoleModerator {
+		t.Errorf("expected user 7 to be a moderator, got %q (reply %q)", role, api.LastText())
+	}
+	handler(ctx, api, commandUpdate(9, "/admin role 9 user"))
+	if !strings.Contains(api.LastText(), "change the configuration instead") {
+		t.Errorf("expected configured admins to stay admins, got %q", api.LastText())
+	}
+	handler(ctx, api, commandUpdate(9, "/admin role"))
+	if reply := api.LastText(); !strings.Contains(reply, "9: admin (configured)") || !strings.Contains(reply, "5: moderator") || !strings.Contains(reply, "7: moderator") {
+		t.Errorf("expected every role listed, got %q", reply)
+	}
+	handler(ctx, api, commandUpdate(9, "/admin role 7 owner"))
+	if !strings.Contains(api.LastText(), "Usage") {
+		t.Errorf("expected usage for an unknown role, got %q", api.LastText())
+	}
+}
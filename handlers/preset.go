@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// PresetCallbackPrefix is the callback data of the /preset menu buttons,
+// followed by the session ID, a colon and the preset name; an empty name goes
+// back to the defaults
+const PresetCallbackPrefix = "preset_"
+
+// assistantPreset is a profile changing how the assistant answers in a session
+type assistantPreset struct {
+	Name        string // stored as session.Session.Preset
+	Label       string // button text, translated when shown
+	Prompt      string // system prompt given to the assistant
+	Temperature float64
+}
+
+// assistantPresets are the presets offered by /preset, in menu order
+var assistantPresets = []assistantPreset{
+	{
+		Name:        "formal",
+		Label:       "🎩 Formal",
+		Prompt:      "Answer in a formal, polite and precise tone. Avoid slang, jokes and emoji.",
+		Temperature: 0.3,
+	},
+	{
+		Name:        "casual",
+		Label:       "😎 Casual",
+		Prompt:      "Answer in a relaxed, friendly tone, as if chatting with a friend. Keep answers short.",
+		Temperature: 0.9,
+	},
+	{
+		Name:  "code-assistant",
+		Label: "💻 Code assistant",
+		Prompt: "You are a programming assistant. Answer with working code in fenced code blocks naming their language, " +
+			"followed by a brief explanation. Point out assumptions and edge cases.",
+		Temperature: 0.2,
+	},
+}
+
+// findPreset returns the preset called name, or nil for the defaults and
+// presets no longer offered
+func findPreset(name string) *assistantPreset {
+	for i := range assistantPresets {
+		if assistantPresets[i].Name == name {
+			return &assistantPresets[i]
+		}
+	}
+	return nil
+}
+
+// presetLabel names the preset of sess for the user
+func presetLabel(tr *i18n.Translator, sess *session.Session) string {
+	if preset := findPreset(sess.Preset); preset != nil {
+		return tr.T(preset.Label)
+	}
+	return tr.T("Default")
+}
+
+// presetParams returns the system message the preset of sess adds to a
+// request, and params with the preset's temperature unless the user set one;
+// the message is nil without a preset
+func presetParams(sess *session.Session, params ai.Params) (*ai.Message, ai.Params) {
+	preset := findPreset(sess.Preset)
+	if preset == nil {
+		return nil, params
+	}
+	if params.Temperature == nil {
+		temperature := preset.Temperature
+		params.Temperature = &temperature
+	}
+	return &ai.Message{Role: ai.RoleSystem, Content: preset.Prompt}, params
+}
+
+// PresetCommandHandler handles the /preset command.
+// It shows the preset of the active session with buttons choosing another.
+func PresetCommandHandler(sessionMgr *session.Manager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+
+		activeSession, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetActiveSession(ctx, userID)
+		if errors.Is(err, session.ErrSessionNotFound) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.T("No active session. Send a message to start one, then use /preset."),
+			})
+			return
+		}
+		if err != nil {
+			LogError(ctx, "preset_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            formatPresetMenu(tr, activeSession),
+			ParseMode:       models.ParseModeHTML,
+			ReplyMarkup:     cfg.callbacks().EncodeKeyboard(ctx, buildPresetMenu(tr, activeSession)),
+		})
+	}
+}
+
+// PresetCallbackHandler handles the buttons of the /preset menu. It stores the
+// chosen preset with the session the menu was shown for.
+func PresetCallbackHandler(sessionMgr *session.Manager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		userID := callback.From.ID
+		tr := i18n.FromContext(ctx)
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "preset_callback", err, tr.T("⌛ This menu expired. Send /preset to get a fresh one."))
+			return
+		}
+
+		msg := callback.Message.Message
+		rawID, name, _ := strings.Cut(strings.TrimPrefix(data, PresetCallbackPrefix), ":")
+		sessionID, err := uuid.Parse(rawID)
+		if err != nil || msg == nil || (name != "" && findPreset(name) == nil) {
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+			return
+		}
+
+		sess, err := sessionMgr.SetSessionPreset(ctx, userID, sessionID, name)
+		if err != nil {
+			if !errors.Is(err, session.ErrSessionNotFound) && !errors.Is(err, session.ErrUnauthorized) {
+				LogError(ctx, "preset_callback", userID, err, map[string]interface{}{"session_id": sessionID.String()})
+			}
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: callback.ID,
+				Text:            tr.T("This session is no longer available."),
+				ShowAlert:       true,
+			})
+			return
+		}
+
+		LogInfo(ctx, "preset_callback", userID, "session preset set", map[string]interface{}{
+			"session_id": sessionID.String(),
+			"preset":     name,
+		})
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            tr.Sprintf("✅ Preset: %s", presetLabel(tr, sess)),
+		})
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			Text:        formatPresetMenu(tr, sess),
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: cfg.callbacks().EncodeKeyboard(ctx, buildPresetMenu(tr, sess)),
+		})
+	}
+}
+
+// formatPresetMenu renders the text of the /preset menu of sess as HTML
+func formatPresetMenu(tr *i18n.Translator, sess *session.Session) string {
+	return tr.Sprintf("🎭 Preset of %s: %s", format.Bold(sess.Title), format.Escape(presetLabel(tr, sess))) + "\n" +
+		format.Escape(tr.T("Choose how the assistant answers in this session."))
+}
+
+// buildPresetMenu lists the presets for sess, marking its current one
+func buildPresetMenu(tr *i18n.Translator, sess *session.Session) *models.InlineKeyboardMarkup {
+	button := func(name, label string) []models.InlineKeyboardButton {
+		if name == sess.Preset {
+			label = "✅ " + label
+		}
+		return []models.InlineKeyboardButton{{Text: label, CallbackData: PresetCallbackPrefix + sess.ID.String() + ":" + name}}
+	}
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(assistantPresets)+1)
+	for _, preset := range assistantPresets {
+		rows = append(rows, button(preset.Name, tr.T(preset.Label)))
+	}
+	rows = append(rows, button("", tr.T("Default")))
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
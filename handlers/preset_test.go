@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestPresetChangesAssistantRequests(t *testing.T) {
+	provider := &fakeProvider{replies: []*ai.Message{
+		{Role: ai.RoleAssistant, Content: "Hi!"},
+		{Role: ai.RoleAssistant, Content: "Good day."},
+	}}
+	_, sessionMgr, handler := newAssistantTest(t, provider)
+	cfg := &HandlerConfig{}
+	command := PresetCommandHandler(sessionMgr, cfg)
+	callback := PresetCallbackHandler(sessionMgr, cfg)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	command(ctx, api, commandUpdate(1, "/preset"))
+	if !strings.Contains(api.LastText(), "No active session") {
+		t.Fatalf("expected a no active session notice, got %q", api.LastText())
+	}
+
+	handler(ctx, api, textUpdate(1, "Hello"))
+	if req := provider.requests[0]; req.Temperature != nil || req.Messages[0].Role != ai.RoleUser {
+		t.Errorf("expected no preset by default, got %+v", req)
+	}
+
+	command(ctx, api, commandUpdate(1, "/preset"))
+	if !strings.Contains(api.LastText(), "Default") {
+		t.Errorf("expected the default preset shown, got %q", api.LastText())
+	}
+	markup := api.Sent[len(api.Sent)-1].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	callback(ctx, api, pressButton(t, 1, markup, "Formal"))
+	if answer := api.CallbackAnswers[len(api.CallbackAnswers)-1]; !strings.Contains(answer.Text, "Formal") {
+		t.Errorf("expected the preset confirmed, got %q", answer.Text)
+	}
+	edited := api.EditedTexts[len(api.EditedTexts)-1]
+	if !strings.Contains(keyboardLabels(edited.ReplyMarkup.(*models.InlineKeyboardMarkup)), "✅ 🎩 Formal") {
+		t.Errorf("expected the menu to mark the new preset, got %+v", edited.ReplyMarkup)
+	}
+
+	// Another user cannot change the session's preset
+	callback(ctx, api, pressButton(t, 2, markup, "Casual"))
+	if active, _ := sessionMgr.GetActiveSession(ctx, 1); active.Preset != "formal" {
+		t.Errorf("expected the formal preset stored, got %q", active.Preset)
+	}
+
+	handler(ctx, api, textUpdate(1, "Hello again"))
+	req := provider.requests[1]
+	if req.Temperature == nil || *req.Temperature != 0.3 {
+		t.Errorf("expected the preset's temperature, got %v", req.Temperature)
+	}
+	if first := req.Messages[0]; first.Role != ai.RoleSystem || !strings.Contains(first.Content, "formal") {
+		t.Errorf("expected the preset's prompt first, got %+v", first)
+	}
+}
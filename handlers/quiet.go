@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// quietOffArg is the /quiet argument that disables quiet hours
+const quietOffArg = "off"
+
+// quietUsage explains the /quiet arguments
+const quietUsage = "Use /quiet 22:00-07:00 Europe/Berlin to hold back notifications at night, or /quiet off to receive them any time."
+
+// QuietCommandHandler handles the /quiet command.
+// "/quiet 22:00-07:00 [time zone]" sets quiet hours during which notifications are
+// held back, "/quiet off" disables them and "/quiet" shows the current window.
+func QuietCommandHandler(store session.UserSettingsStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+		reply := func(text string) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+			})
+		}
+
+		userSettings, err := store.GetUserSettings(ctx, userID)
+		if err != nil {
+			LogError(ctx, "quiet_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		args := commandArgs(ctx, update.Message)
+		switch {
+		case len(args) == 0:
+			reply(tr.Sprintf("Quiet hours: %s", formatQuietHours(tr, userSettings)) + "\n" + tr.T(quietUsage))
+			return
+		case len(args) == 1 && strings.EqualFold(args[0], quietOffArg):
+			userSettings.QuietStart, userSettings.QuietEnd = 0, 0
+		case len(args) <= 2:
+			start, end, err := parseQuietWindow(args[0])
+			if err != nil {
+				reply(tr.T(quietUsage))
+				return
+			}
+			if len(args) == 2 {
+				location, err := parseTimezone(args[1])
+				if err != nil {
+					reply(tr.Sprintf("Unknown time zone: %s\nUse a name such as Europe/Berlin or America/New_York.", args[1]))
+					return
+				}
+				userSettings.Timezone = location.String()
+			}
+			userSettings.QuietStart, userSettings.QuietEnd = start, end
+		default:
+			reply(tr.T(quietUsage))
+			return
+		}
+
+		text := tr.T("🔔 Quiet hours are off. Notifications are delivered right away.")
+		if userSettings.HasQuietHours() {
+			text = tr.Sprintf("🌙 Quiet hours set to %s. Notifications in that window are delivered when it ends.", formatQuietHours(tr, userSettings))
+		}
+
+		userSettings.UpdatedAt = time.Now()
+		err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			if err := store.SaveUserSettings(ctx, userSettings); err != nil {
+				return nil, err
+			}
+			return &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+			}, nil
+		})
+		if err != nil {
+			LogError(ctx, "quiet_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "quiet_command", userID, "quiet hours saved", map[string]interface{}{
+			"quiet_start": userSettings.QuietStart,
+			"quiet_end":   userSettings.QuietEnd,
+			"timezone":    userSettings.Timezone,
+		})
+	}
+}
+
+// formatQuietHours renders the quiet hours window and time zone of a user
+func formatQuietHours(tr *i18n.Translator, userSettings *session.UserSettings) string {
+	if !userSettings.HasQuietHours() {
+		return tr.T("off")
+	}
+	return fmt.Sprintf("%s–%s (%s)", session.FormatMinuteOfDay(userSettings.QuietStart),
+		session.FormatMinuteOfDay(userSettings.QuietEnd), userSettings.Location())
+}
+
+// parseQuietWindow parses "HH:MM-HH:MM" into minutes after midnight
+func parseQuietWindow(raw string) (int, int, error) {
+	from, to, ok := strings.Cut(raw, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid quiet hours %q", raw)
+	}
+	start, err := parseMinuteOfDay(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseMinuteOfDay(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("quiet hours %q are empty", raw)
+	}
+	return start, end, nil
+}
+
+// parseMinuteOfDay parses "HH:MM" into minutes after midnight
+func parseMinuteOfDay(raw string) (int, error) {
+	t, err := time.Parse("15:04", raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: %w", raw, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func TestQuietCommandHandler(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_quiet.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+	quiet := QuietCommandHandler(store, &HandlerConfig{})
+
+	quiet(ctx, api, commandUpdate(1, "/quiet"))
+	if !strings.Contains(api.LastText(), "Quiet hours: off") {
+		t.Errorf("expected quiet hours to be off, got %q", api.LastText())
+	}
+
+	quiet(ctx, api, commandUpdate(1, "/quiet 22:00-7:30 Europe/Berlin"))
+	if !strings.Contains(api.LastText(), "22:00–07:30 (Europe/Berlin)") {
+		t.Errorf("expected the new window, got %q", api.LastText())
+	}
+	saved, err := store.GetUserSettings(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserSettings failed: %v", err)
+	}
+	if saved.QuietStart != 22*60 || saved.QuietEnd != 7*60+30 || saved.Timezone != "Europe/Berlin" {
+		t.Errorf("unexpected saved settings %+v", saved)
+	}
+
+	for _, text := range []string{"/quiet 22:00", "/quiet 10:00-10:00", "/quiet 25:00-07:00"} {
+		quiet(ctx, api, commandUpdate(1, text))
+		if !strings.Contains(api.LastText(), "Use /quiet") {
+			t.Errorf("%s: expected usage, got %q", text, api.LastText())
+		}
+	}
+	quiet(ctx, api, commandUpdate(1, "/quiet 22:00-07:00 Mars/Olympus"))
+	if !strings.Contains(api.LastText(), "Unknown time zone") {
+		t.Errorf("expected an unknown time zone error, got %q", api.LastText())
+	}
+
+	quiet(ctx, api, commandUpdate(1, "/quiet off"))
+	if saved, _ := store.GetUserSettings(ctx, 1); saved.HasQuietHours() || saved.Timezone != "Europe/Berlin" {
+		t.Errorf("expected quiet hours off and the time zone kept, got %+v", saved)
+	}
+}
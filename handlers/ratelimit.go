@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/ratelimit"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// RateLimitMiddleware is a bot middleware that drops messages of users sending
+// faster than limiter allows, so one user cannot monopolize workers or AI quota.
+// The user is told to slow down once per throttled stretch; administrators are exempt.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, cfg *HandlerConfig) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if throttleMessage(ctx, b, limiter, cfg, update) {
+				return
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// throttleMessage reports whether update is a message over its sender's rate
+// limit, replying with a warning the first time
+func throttleMessage(ctx context.Context, b TelegramAPI, limiter *ratelimit.Limiter, cfg *HandlerConfig, update *models.Update) bool {
+	message := update.Message
+	if message == nil || message.From == nil || isAdmin(cfg, message.From.ID) {
+		return false
+	}
+
+	decision := limiter.Allow(ctx, message.From.ID)
+	if decision.Allowed {
+		return false
+	}
+	if !decision.Warn {
+		return true
+	}
+
+	seconds := max(int(math.Ceil(decision.RetryAfter.Seconds())), 1)
+	LogInfo(ctx, "rate_limit", message.From.ID, "user throttled", map[string]interface{}{
+		"retry_after_seconds": seconds,
+	})
+
+	tr := i18n.FromContext(ctx)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          message.Chat.ID,
+		MessageThreadID: topicThreadID(message),
+		Text:            tr.Sprintf("🐢 Slow down! You are sending messages too fast. Try again in %d s.", seconds),
+	})
+	return true
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tg-bot-demo/ratelimit"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+)
+
+func TestThrottleMessage(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_ratelimit.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	limiter := ratelimit.New(store, ratelimit.Options{PerMinute: 1, Burst: 1})
+	cfg := &HandlerConfig{AdminUserIDs: []int64{9}}
+	api := testutil.NewFakeTelegram()
+
+	if throttleMessage(ctx, api, limiter, cfg, commandUpdate(1, "hello")) {
+		t.Fatal("first message should pass")
+	}
+	if !throttleMessage(ctx, api, limiter, cfg, commandUpdate(1, "spam")) {
+		t.Fatal("second message should be throttled")
+	}
+	if len(api.Sent) != 1 || !strings.Contains(api.Sent[0].Text, "Slow down") {
+		t.Fatalf("expected one slow down reply, got %d messages", len(api.Sent))
+	}
+	if !throttleMessage(ctx, api, limiter, cfg, commandUpdate(1, "spam")) || len(api.Sent) != 1 {
+		t.Error("expected further messages to be dropped without a reply")
+	}
+
+	for i := 0; i < 3; i++ {
+		if throttleMessage(ctx, api, limiter, cfg, commandUpdate(9, "admin")) {
+			t.Fatal("administrators should not be throttled")
+		}
+	}
+}
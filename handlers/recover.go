@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/reporting"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// RecoverMiddleware keeps a panicking handler from taking down the bot.
+// The panic is logged and reported with its stack trace and the update being handled.
+func RecoverMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		ctx = reporting.WithUpdate(ctx, update)
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			stack := debug.Stack()
+			var userID int64
+			if user := updateSender(update); user != nil {
+				userID = user.ID
+			}
+			err := fmt.Errorf("handler panic: %v", recovered)
+
+			log.Printf("[PANIC] %supdate_id=%d user_id=%d error=%v\n%s",
+				requestIDField(ctx), update.ID, userID, err, stack)
+			reporting.Report(ctx, reporting.Event{
+				Err:       err,
+				Operation: "handler_panic",
+				UserID:    userID,
+				RequestID: correlation.ID(ctx),
+				Details:   map[string]interface{}{"update_type": updateType(update)},
+				Stack:     stack,
+			})
+		}()
+
+		next(ctx, b, update)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tg-bot-demo/reporting"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// recordingReporter keeps reported events in memory
+type recordingReporter struct {
+	events []reporting.Event
+}
+
+func (r *recordingReporter) Report(event reporting.Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) Flush(time.Duration) bool {
+	return true
+}
+
+func TestRecoverMiddlewareReportsPanic(t *testing.T) {
+	recorder := &recordingReporter{}
+	reporting.SetReporter(recorder)
+	defer reporting.SetReporter(nil)
+
+	update := &models.Update{ID: 9, Message: &models.Message{From: &models.User{ID: 123}, Text: "/open"}}
+	handler := RecoverMiddleware(func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		panic("boom")
+	})
+	handler(context.Background(), nil, update)
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("Expected 1 reported event, got %d", len(recorder.events))
+	}
+	event := recorder.events[0]
+	if event.UserID != 123 || event.Operation != "handler_panic" {
+		t.Errorf("Expected panic of user 123, got %+v", event)
+	}
+	if event.Update != update {
+		t.Error("Expected the update to be attached")
+	}
+	if len(event.Stack) == 0 {
+		t.Error("Expected a stack trace")
+	}
+}
+
+func TestLogErrorReports(t *testing.T) {
+	recorder := &recordingReporter{}
+	reporting.SetReporter(recorder)
+	defer reporting.SetReporter(nil)
+
+	LogError(context.Background(), "close_command", 5, context.Canceled, map[string]interface{}{"session_id": "s1"})
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("Expected 1 reported event, got %d", len(recorder.events))
+	}
+	if event := recorder.events[0]; event.Operation != "close_command" || event.UserID != 5 || event.Details["session_id"] != "s1" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
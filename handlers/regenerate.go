@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// RegenerateCallbackPrefix is the callback data of the button asking the
+// assistant for a new reply, followed by the stored message ID of the reply
+const RegenerateCallbackPrefix = "regen_"
+
+// regenerateButton returns the button regenerating the stored reply answer
+func regenerateButton(tr *i18n.Translator, answer *session.Message) []models.InlineKeyboardButton {
+	return []models.InlineKeyboardButton{{Text: tr.T("🔁 Regenerate"), CallbackData: RegenerateCallbackPrefix + answer.ID.String()}}
+}
+
+// RegenerateCallbackHandler handles the regenerate button under assistant
+// replies. It asks the assistant again with the history the reply answered,
+// edits the reply's message with the new answer and revises the stored reply,
+// which keeps both answers as versions. Only the latest message of a session
+// can be regenerated, so the history is the same as the first time.
+func RegenerateCallbackHandler(sessionMgr *session.Manager, messageMgr *session.MessageManager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		userID := callback.From.ID
+		tr := i18n.FromContext(ctx)
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "regenerate_callback", err, tr.T("⌛ This button expired."))
+			return
+		}
+
+		msg := callback.Message.Message
+		id, err := uuid.Parse(strings.TrimPrefix(data, RegenerateCallbackPrefix))
+		if err != nil || msg == nil || cfg.Assistant == nil {
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+			return
+		}
+
+		// Only the user the reply was for may have it regenerated
+		answer, err := messageMgr.GetMessage(ctx, id)
+		if err == nil && (answer.UserID != userID || answer.Role != session.RoleAssistant) {
+			err = session.ErrMessageNotFound
+		}
+		var sess *session.Session
+		var stored []*session.Message
+		if err == nil {
+			sess, err = sessionMgr.GetSession(ctx, userID, answer.SessionID)
+		}
+		if err == nil {
+			stored, err = messageMgr.History(ctx, answer.SessionID, assistantHistoryMessages+1)
+		}
+		if err != nil {
+			if !errors.Is(err, session.ErrMessageNotFound) {
+				LogError(ctx, "regenerate_callback", userID, err, map[string]interface{}{"message_id": id.String()})
+			}
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: callback.ID,
+				Text:            tr.T("This reply is no longer available."),
+				ShowAlert:       true,
+			})
+			return
+		}
+		if len(stored) == 0 || stored[len(stored)-1].ID != answer.ID {
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: callback.ID,
+				Text:            tr.T("Only the latest reply of a session can be regenerated."),
+				ShowAlert:       true,
+			})
+			return
+		}
+
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            tr.T("🔁 Regenerating…"),
+		})
+
+		ctx = WithReplyOrigin(ctx, answer.SessionID, uuid.Nil)
+		reply, model, failure := assistantReply(ctx, userID, msg, sess, stored[:len(stored)-1], cfg, nil)
+		if failure != "" {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          msg.Chat.ID,
+				MessageThreadID: topicThreadID(msg),
+				Text:            failure,
+			})
+			return
+		}
+
+		// A new answer over Telegram's limit continues in new messages
+		regenerated := *answer
+		regenerated.Content = reply
+		parts := format.SplitMarkdown(reply, format.MaxMessageLength)
+		keyboard := assistantKeyboard(ctx, tr, cfg, &regenerated, len(parts))
+		edit := &bot.EditMessageTextParams{
+			ChatID:    msg.Chat.ID,
+			MessageID: msg.ID,
+			Text:      parts[0],
+		}
+		if len(parts) == 1 {
+			edit.ReplyMarkup = keyboard
+		}
+		if _, err := b.EditMessageText(ctx, edit); err != nil {
+			LogError(ctx, "regenerate_callback", userID, err, map[string]interface{}{"message_id": id.String()})
+			SendErrorResponse(ctx, b, msg, err)
+			return
+		}
+		for i := 1; i < len(parts); i++ {
+			params := &bot.SendMessageParams{
+				ChatID:          msg.Chat.ID,
+				MessageThreadID: topicThreadID(msg),
+				Text:            parts[i],
+			}
+			if keyboard != nil && i == len(parts)-1 {
+				params.ReplyMarkup = keyboard
+			}
+			if _, err := b.SendMessage(ctx, params); err != nil {
+				LogError(ctx, "regenerate_callback", userID, err, map[string]interface{}{
+					"message_id": id.String(),
+					"part":       i + 1,
+					"parts":      len(parts),
+				})
+				break
+			}
+		}
+
+		if _, err := messageMgr.ReviseMessage(ctx, answer.ID, reply, time.Now()); err != nil {
+			LogError(ctx, "regenerate_callback", userID, err, map[string]interface{}{"message_id": id.String()})
+			return
+		}
+		if err := sessionMgr.TouchSession(ctx, answer.SessionID, reply); err != nil {
+			LogError(ctx, "regenerate_callback", userID, err, map[string]interface{}{
+				"session_id": answer.SessionID.String(),
+			})
+		}
+
+		LogInfo(ctx, "regenerate_callback", userID, "assistant reply regenerated", map[string]interface{}{
+			"session_id":   answer.SessionID.String(),
+			"message_id":   id.String(),
+			"model":        model,
+			"reply_length": len(reply),
+			"reply_parts":  len(parts),
+		})
+	}
+}
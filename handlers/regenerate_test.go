@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestRegenerateAssistantReply(t *testing.T) {
+	provider := &fakeProvider{replies: []*ai.Message{
+		{Role: ai.RoleAssistant, Content: "First answer"},
+		{Role: ai.RoleAssistant, Content: "Second answer"},
+		{Role: ai.RoleAssistant, Content: "Later answer"},
+	}}
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_regenerate.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	sessionMgr := session.NewManager(store)
+	messageMgr := session.NewMessageManager(store)
+	cfg := &HandlerConfig{AIModels: []string{"small"}, Assistant: ai.NewAssistant(provider, ai.NewRegistry(), 0)}
+	handler := MessageHandler(sessionMgr, messageMgr, cfg)
+	callbacks := RegenerateCallbackHandler(sessionMgr, messageMgr, cfg)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	handler(ctx, api, textUpdate(1, "hello"))
+	markup := api.Sent[len(api.Sent)-1].ReplyMarkup.(*models.InlineKeyboardMarkup)
+
+	callbacks(ctx, api, pressButton(t, 2, markup, "Regenerate"))
+	if len(provider.requests) != 1 || !strings.Contains(api.CallbackAnswers[0].Text, "no longer available") {
+		t.Fatal("expected other users to be unable to regenerate the reply")
+	}
+
+	callbacks(ctx, api, pressButton(t, 1, markup, "Regenerate"))
+	if len(provider.requests) != 2 {
+		t.Fatalf("expected the provider asked again, got %d requests", len(provider.requests))
+	}
+	first, second := provider.requests[0].Messages, provider.requests[1].Messages
+	if len(first) != len(second) || second[len(second)-1].Content != "hello" {
+		t.Errorf("expected the same history, got %+v and %+v", first, second)
+	}
+	if len(api.EditedTexts) != 1 || api.EditedTexts[0].Text != "Second answer" || api.EditedTexts[0].MessageID != 10 {
+		t.Fatalf("expected the reply edited in place, got %+v", api.EditedTexts)
+	}
+	if _, ok := api.EditedTexts[0].ReplyMarkup.(*models.InlineKeyboardMarkup); !ok {
+		t.Error("expected the regenerate button kept under the new reply")
+	}
+
+	active, err := sessionMgr.GetActiveSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	history, err := messageMgr.History(ctx, active.ID, 10)
+	if err != nil || len(history) != 2 || history[1].Content != "Second answer" {
+		t.Fatalf("expected the stored reply revised, got %+v (err=%v)", history, err)
+	}
+	versions, err := messageMgr.Versions(ctx, history[1].ID)
+	if err != nil || len(versions) != 2 || versions[0].Content != "First answer" || versions[1].Content != "Second answer" {
+		t.Errorf("expected both answers versioned, got %+v (err=%v)", versions, err)
+	}
+
+	handler(ctx, api, textUpdate(1, "and then?"))
+	callbacks(ctx, api, pressButton(t, 1, markup, "Regenerate"))
+	if len(provider.requests) != 3 || !strings.Contains(api.CallbackAnswers[len(api.CallbackAnswers)-1].Text, "latest reply") {
+		t.Error("expected only the latest reply to be regenerated")
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// Rename flow identifiers
+const (
+	renameFlow      = "rename"
+	renameStepTitle = "title"
+)
+
+// RenameFlow asks for a new title and renames the session stored in the flow data
+func RenameFlow(sessionMgr *session.Manager) *Flow {
+	return &Flow{
+		Name: renameFlow,
+		Steps: map[string]StepFunc{
+			renameStepTitle: func(ctx context.Context, state *session.ConversationState, text string) (StepResult, error) {
+				sessionID, err := uuid.Parse(state.Data["session_id"])
+				if err != nil {
+					return StepResult{}, fmt.Errorf("invalid session in rename flow: %w", err)
+				}
+
+				sess, err := sessionMgr.RenameSession(ctx, state.UserID, sessionID, text)
+				if errors.Is(err, session.ErrEmptyTitle) {
+					return StepResult{
+						Reply: i18n.FromContext(ctx).T("The title can't be empty. Send a new title, or /cancel to keep the current one."),
+						Next:  renameStepTitle,
+					}, nil
+				}
+				if err != nil {
+					return StepResult{}, err
+				}
+
+				return StepResult{Reply: i18n.FromContext(ctx).Sprintf("✅ Renamed session to: %s", format.Bold(sess.Title))}, nil
+			},
+		},
+	}
+}
+
+// RenameCommandHandler handles the /rename command.
+// "/rename <title>" renames the active session right away; without a title it
+// starts the rename flow that asks for one.
+func RenameCommandHandler(sessionMgr *session.Manager, conversations *Conversations, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		chatID := update.Message.Chat.ID
+		tr := i18n.FromContext(ctx)
+
+		activeSession, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetActiveSession(ctx, userID)
+		if err != nil {
+			if errors.Is(err, session.ErrSessionNotFound) {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:          chatID,
+					MessageThreadID: topicThreadID(update.Message),
+					Text:            tr.T("No active session to rename. Use /sessions to pick one first."),
+				})
+				return
+			}
+			LogError(ctx, "rename_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		if title := strings.Join(commandArgs(ctx, update.Message), " "); strings.TrimSpace(title) != "" {
+			err := cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+				sess, err := sessionMgr.RenameSession(ctx, userID, activeSession.ID, title)
+				if err != nil {
+					return nil, err
+				}
+				return &bot.SendMessageParams{
+					ChatID:          chatID,
+					MessageThreadID: topicThreadID(update.Message),
+					Text:            tr.Sprintf("✅ Renamed session to: %s", format.Bold(sess.Title)),
+					ParseMode:       models.ParseModeHTML,
+				}, nil
+			})
+			if err != nil {
+				LogError(ctx, "rename_command", userID, err, map[string]interface{}{
+					"session_id": activeSession.ID.String(),
+				})
+				SendErrorResponse(ctx, b, update.Message, err)
+				return
+			}
+
+			LogInfo(ctx, "rename_command", userID, "session renamed", map[string]interface{}{
+				"session_id": activeSession.ID.String(),
+			})
+			return
+		}
+
+		err = conversations.Start(ctx, userID, chatID, renameFlow, renameStepTitle, map[string]string{
+			"session_id": activeSession.ID.String(),
+		})
+		if err != nil {
+			LogError(ctx, "rename_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "rename_command", userID, "rename flow started", map[string]interface{}{
+			"session_id": activeSession.ID.String(),
+		})
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          chatID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            tr.Sprintf("✏️ Send the new title for \"%s\", or /cancel to keep it.", format.Escape(activeSession.Title)),
+			ParseMode:       models.ParseModeHTML,
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+	"tg-bot-demo/share"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// shareRevokeArg is the /share argument that revokes the active session's links
+	shareRevokeArg = "revoke"
+
+	// Shared transcripts sent in Telegram keep the latest messages that fit one message
+	maxSharedTranscriptLength = 3500
+	maxSharedMessageLength    = 500
+)
+
+// ShareCommandHandler handles the /share command.
+// "/share" creates a read-only link to the active session; "/share revoke" revokes its links.
+func ShareCommandHandler(sessionMgr *session.Manager, links *share.Links, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+		params := func(text string) *bot.SendMessageParams {
+			return &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+				ParseMode:       models.ParseModeHTML,
+			}
+		}
+		reply := func(text string) {
+			b.SendMessage(ctx, params(text))
+		}
+
+		activeSession, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetActiveSession(ctx, userID)
+		if err != nil {
+			if errors.Is(err, session.ErrSessionNotFound) {
+				reply(tr.T("No active session to share. Use /sessions to pick one first."))
+				return
+			}
+			LogError(ctx, "share_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		args := commandArgs(ctx, update.Message)
+		if len(args) > 0 && strings.EqualFold(args[0], shareRevokeArg) {
+			var revoked int
+			err := cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+				var err error
+				if revoked, err = links.Revoke(ctx, userID, activeSession.ID); err != nil {
+					return nil, err
+				}
+				return params(tr.Sprintf("🔒 Revoked %d share link(s) of %s.", revoked, format.Bold(activeSession.Title))), nil
+			})
+			if err != nil {
+				LogError(ctx, "share_command", userID, err, map[string]interface{}{"session_id": activeSession.ID.String()})
+				SendErrorResponse(ctx, b, update.Message, err)
+				return
+			}
+			LogInfo(ctx, "share_command", userID, "share links revoked", map[string]interface{}{
+				"session_id": activeSession.ID.String(),
+				"revoked":    revoked,
+			})
+			return
+		}
+
+		var link *session.Share
+		err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			var err error
+			if link, err = links.Create(ctx, userID, activeSession.ID); err != nil {
+				return nil, err
+			}
+
+			var text strings.Builder
+			text.WriteString(tr.Sprintf("🔗 Read-only link to %s, valid until %s:", format.Bold(activeSession.Title),
+				tr.FormatTime(link.ExpiresAt, "Jan 2, 2006 15:04")))
+			text.WriteString("\n" + format.Code(links.TelegramLink(link.Token)))
+			if web := links.WebLink(link.Token); web != "" {
+				text.WriteString("\n" + tr.Sprintf("Web: %s", format.Escape(web)))
+			}
+			text.WriteString("\n\n" + tr.T("Anyone with the link can read this session. Use /share revoke to disable its links."))
+			return params(text.String()), nil
+		})
+		if err != nil {
+			LogError(ctx, "share_command", userID, err, map[string]interface{}{"session_id": activeSession.ID.String()})
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+		LogInfo(ctx, "share_command", userID, "share link created", map[string]interface{}{
+			"session_id": activeSession.ID.String(),
+			"expires_at": link.ExpiresAt,
+		})
+	}
+}
+
+// sendSharedTranscript answers "/start share-<token>" with the shared transcript
+func sendSharedTranscript(ctx context.Context, b TelegramAPI, message *models.Message, links *share.Links, token string) {
+	tr := i18n.FromContext(ctx)
+	reply := func(text string) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          message.Chat.ID,
+			MessageThreadID: topicThreadID(message),
+			Text:            text,
+			ParseMode:       models.ParseModeHTML,
+		})
+	}
+
+	transcript, err := links.Open(ctx, token)
+	switch {
+	case errors.Is(err, share.ErrInvalid):
+		reply(tr.T("❌ This share link is not valid."))
+		return
+	case errors.Is(err, share.ErrExpired), errors.Is(err, share.ErrRevoked):
+		reply(tr.T("❌ This share link has expired or was revoked."))
+		return
+	case err != nil:
+		LogError(ctx, "start_command", message.From.ID, err, nil)
+		SendErrorResponse(ctx, b, message, err)
+		return
+	}
+
+	LogInfo(ctx, "start_command", message.From.ID, "shared session opened", map[string]interface{}{
+		"session_id": transcript.Session.ID.String(),
+		"owner_id":   transcript.Share.UserID,
+	})
+	reply(formatSharedTranscript(tr, transcript))
+}
+
+// formatSharedTranscript renders the latest messages of transcript that fit one Telegram message
+func formatSharedTranscript(tr *i18n.Translator, transcript *share.Transcript) string {
+	header := tr.Sprintf("📖 Shared session (read-only): %s", format.Bold(transcript.Session.Title))
+	if len(transcript.Messages) == 0 {
+		return header + "\n\n" + tr.T("This session has no messages yet.")
+	}
+
+	var lines []string
+	length := 0
+	for i := len(transcript.Messages) - 1; i >= 0; i-- {
+		message := transcript.Messages[i]
+		icon := "👤"
+		switch message.Role {
+		case session.RoleAssistant:
+			icon = "🤖"
+		case session.RoleContext:
+			icon = "📎"
+		}
+		line := icon + " " + format.Escape(truncate(message.Content, maxSharedMessageLength))
+		if length+len(line) > maxSharedTranscriptLength {
+			lines = append(lines, "…")
+			break
+		}
+		lines = append(lines, line)
+		length += len(line)
+	}
+
+	var text strings.Builder
+	text.WriteString(header)
+	for i := len(lines) - 1; i >= 0; i-- {
+		text.WriteString("\n\n" + lines[i])
+	}
+	return text.String()
+}
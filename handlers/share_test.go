@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/share"
+	"tg-bot-demo/testutil"
+)
+
+func TestShareCommand(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_share.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	sessionMgr := session.NewManager(store)
+	sess, err := sessionMgr.CreateSession(ctx, 1, "Trip plans")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := store.AppendMessage(ctx, session.NewMessage(sess.ID, 1, session.RoleUser, "Where to <go>?")); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+
+	links := share.New(store, share.Options{Key: "secret", TTL: time.Hour})
+	api := testutil.NewFakeTelegram()
+	ShareCommandHandler(sessionMgr, links, nil)(ctx, api, commandUpdate(1, "/share"))
+
+	reply := api.LastText()
+	start := strings.Index(reply, "/start "+share.StartPayloadPrefix)
+	if start < 0 {
+		t.Fatalf("expected a /start command in %q", reply)
+	}
+	command, _, _ := strings.Cut(reply[start:], "<")
+
+	startHandler := StartCommandHandler(sessionMgr, store, links)
+	startHandler(ctx, api, commandUpdate(2, command))
+	if text := api.LastText(); !strings.Contains(text, "Trip plans") || !strings.Contains(text, "Where to &lt;go&gt;?") {
+		t.Errorf("expected the escaped transcript, got %q", text)
+	}
+	if active, err := sessionMgr.GetActiveSession(ctx, 2); err == nil {
+		t.Errorf("reader should not get the session, got %v", active.ID)
+	}
+
+	ShareCommandHandler(sessionMgr, links, nil)(ctx, api, commandUpdate(1, "/share revoke"))
+	if text := api.LastText(); !strings.Contains(text, "Revoked 1") {
+		t.Errorf("expected one revoked link, got %q", text)
+	}
+	startHandler(ctx, api, commandUpdate(2, command))
+	if text := api.LastText(); !strings.Contains(text, "revoked") {
+		t.Errorf("expected the revoked link to be refused, got %q", text)
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+	"tg-bot-demo/share"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// Deep-link payload kinds of "https://t.me/<bot>?start=<payload>" links
+const (
+	startPayloadNone  = ""
+	startPayloadOpen  = "open"  // open-<sessionID>: switch to one of the user's sessions
+	startPayloadRef   = "ref"   // ref-<code>: record the referral the user came from
+	startPayloadShare = "share" // share-<token>: read a session shared with /share
+)
+
+// ErrInvalidStartPayload is returned for deep-link payloads the bot does not understand
+var ErrInvalidStartPayload = errors.New("invalid start payload")
+
+// startPayloadPattern is the character set Telegram allows in start parameters
+var startPayloadPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// startPayload is a parsed "/start <payload>" argument
+type startPayload struct {
+	kind      string
+	sessionID uuid.UUID // set for startPayloadOpen
+	code      string    // set for startPayloadRef
+	token     string    // set for startPayloadShare
+}
+
+// parseStartPayload parses the argument of "/start". An empty payload is a plain start.
+func parseStartPayload(payload string) (startPayload, error) {
+	if payload == "" {
+		return startPayload{kind: startPayloadNone}, nil
+	}
+	if !startPayloadPattern.MatchString(payload) {
+		return startPayload{}, ErrInvalidStartPayload
+	}
+
+	kind, value, ok := strings.Cut(payload, "-")
+	if !ok || value == "" {
+		return startPayload{}, ErrInvalidStartPayload
+	}
+
+	switch kind {
+	case startPayloadOpen:
+		sessionID, err := uuid.Parse(value)
+		if err != nil {
+			return startPayload{}, ErrInvalidStartPayload
+		}
+		return startPayload{kind: startPayloadOpen, sessionID: sessionID}, nil
+	case startPayloadRef:
+		return startPayload{kind: startPayloadRef, code: value}, nil
+	case startPayloadShare:
+		return startPayload{kind: startPayloadShare, token: value}, nil
+	default:
+		return startPayload{}, ErrInvalidStartPayload
+	}
+}
+
+// StartCommandHandler handles the /start command, including deep-link payloads.
+// "/start open-<sessionID>" switches to that session, "/start share-<token>" shows
+// a shared transcript and "/start ref-<code>" records the referral; anything else
+// gets the plain welcome message.
+func StartCommandHandler(sessionMgr *session.Manager, referrals session.ReferralStore, links *share.Links) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+
+		raw := messageCommand(ctx, update.Message).RawArgs
+		payload, err := parseStartPayload(raw)
+		if err != nil {
+			LogWarning(ctx, "start_command", userID, "ignoring invalid start payload", map[string]interface{}{
+				"payload": raw,
+			})
+		}
+
+		switch payload.kind {
+		case startPayloadOpen:
+			sess, err := sessionMgr.InTopic(MessageTopic(update.Message)).SwitchSession(ctx, userID, payload.sessionID)
+			if err != nil {
+				LogError(ctx, "start_command", userID, err, map[string]interface{}{
+					"session_id": payload.sessionID.String(),
+				})
+				SendErrorResponse(ctx, b, update.Message, err)
+				return
+			}
+
+			LogInfo(ctx, "start_command", userID, "session opened from deep link", map[string]interface{}{
+				"session_id":    sess.ID.String(),
+				"session_title": sess.Title,
+			})
+
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.Sprintf("✅ Switched to session: %s", format.Bold(sess.Title)),
+				ParseMode:       models.ParseModeHTML,
+			})
+			return
+
+		case startPayloadShare:
+			sendSharedTranscript(ctx, b, update.Message, links, payload.token)
+			return
+
+		case startPayloadRef:
+			recorded, err := referrals.RecordReferral(ctx, &session.Referral{
+				UserID:     userID,
+				Code:       payload.code,
+				ReferredAt: time.Now(),
+			})
+			if err != nil {
+				// A lost referral must not keep the user from starting
+				LogError(ctx, "start_command", userID, err, map[string]interface{}{
+					"referral_code": payload.code,
+				})
+			} else {
+				LogInfo(ctx, "start_command", userID, "referral handled", map[string]interface{}{
+					"referral_code": payload.code,
+					"recorded":      recorded,
+				})
+			}
+		}
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            tr.T("👋 Welcome! Just send a message to start a session.\nUse /sessions to see your sessions or /open to start a new one."),
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseStartPayload(t *testing.T) {
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name      string
+		payload   string
+		kind      string
+		sessionID uuid.UUID
+		code      string
+		token     string
+		wantErr   bool
+	}{
+		{name: "empty", payload: "", kind: startPayloadNone},
+		{name: "open session", payload: "open-" + sessionID.String(), kind: startPayloadOpen, sessionID: sessionID},
+		{name: "referral", payload: "ref-spring_2026", kind: startPayloadRef, code: "spring_2026"},
+		{name: "referral with dash", payload: "ref-a-b", kind: startPayloadRef, code: "a-b"},
+		{name: "share", payload: "share-Ab_c-d", kind: startPayloadShare, token: "Ab_c-d"},
+		{name: "open invalid uuid", payload: "open-abc", wantErr: true},
+		{name: "missing value", payload: "ref-", wantErr: true},
+		{name: "no separator", payload: "hello", wantErr: true},
+		{name: "unknown kind", payload: "join-abc", wantErr: true},
+		{name: "disallowed characters", payload: "ref-a b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStartPayload(tt.payload)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidStartPayload) {
+					t.Fatalf("Expected ErrInvalidStartPayload, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.kind != tt.kind || got.sessionID != tt.sessionID || got.code != tt.code || got.token != tt.token {
+				t.Errorf("parseStartPayload(%q) = %+v", tt.payload, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// StatsCommandHandler handles the /stats command.
+// It replies with the user's session, message and file totals and activity highlights.
+func StatsCommandHandler(statsStore session.StatsStore) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+
+		stats, err := statsStore.GetUserStats(ctx, userID)
+		if err != nil {
+			LogError(ctx, "stats_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "stats_command", userID, "statistics sent", map[string]interface{}{
+			"sessions": stats.Sessions,
+			"messages": stats.Messages,
+			"files":    stats.Files,
+		})
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            formatUserStats(i18n.FromContext(ctx), stats),
+			ParseMode:       models.ParseModeHTML,
+		})
+	}
+}
+
+// formatUserStats renders user statistics as HTML
+func formatUserStats(tr *i18n.Translator, stats *session.UserStats) string {
+	if stats.Sessions == 0 && stats.Files == 0 {
+		return tr.T("📊 No statistics yet. Start chatting to create your first session!")
+	}
+
+	lines := []string{
+		tr.T("📊 Your statistics"),
+		tr.Sprintf("Sessions: %d", stats.Sessions),
+		tr.Sprintf("Messages: %d", stats.Messages),
+		tr.Sprintf("Files: %d (%s)", stats.Files, formatBytes(stats.FileBytes)),
+	}
+
+	if stats.OldestSession != nil {
+		lines = append(lines, tr.Sprintf("Oldest session: %s · %s",
+			format.Bold(stats.OldestSession.Title), tr.FormatTime(stats.OldestSession.CreatedAt, "Jan 2, 2006")))
+	}
+	if stats.NewestSession != nil {
+		lines = append(lines, tr.Sprintf("Newest session: %s · %s",
+			format.Bold(stats.NewestSession.Title), tr.FormatTime(stats.NewestSession.CreatedAt, "Jan 2, 2006")))
+	}
+	if stats.BusiestDayMessages > 0 {
+		// BusiestDay is a calendar date rather than an instant, so it is not moved into the user's time zone
+		lines = append(lines, tr.Sprintf("Busiest day: %s (%d messages)",
+			stats.BusiestDay.Format(tr.T("Jan 2, 2006")), stats.BusiestDayMessages))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"tg-bot-demo/session"
+	"time"
+)
+
+func TestFormatUserStats(t *testing.T) {
+	oldest := session.NewSession(1, "First <one>")
+	oldest.CreatedAt = time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	newest := session.NewSession(1, "Latest")
+	newest.CreatedAt = time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	text := formatUserStats(en, &session.UserStats{
+		Sessions:           2,
+		Messages:           7,
+		Files:              1,
+		FileBytes:          512,
+		OldestSession:      oldest,
+		NewestSession:      newest,
+		BusiestDay:         time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+		BusiestDayMessages: 5,
+	})
+
+	for _, want := range []string{
+		"Sessions: 2",
+		"Messages: 7",
+		"Files: 1 (512 B)",
+		"Oldest session: <b>First &lt;one&gt;</b> · Jan 2, 2026",
+		"Newest session: <b>Latest</b> · Mar 4, 2026",
+		"Busiest day: Mar 4, 2026 (5 messages)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestFormatUserStatsEmpty(t *testing.T) {
+	text := formatUserStats(en, &session.UserStats{})
+	if !strings.Contains(text, "No statistics yet") {
+		t.Errorf("Expected empty statistics message, got %q", text)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// recentStickerSetsLimit is the number of sticker sets listed by /stickers
+const recentStickerSetsLimit = 10
+
+// StickersCommandHandler handles the /stickers command.
+// It lists the sticker sets the user sent most recently with buttons to open each set.
+func StickersCommandHandler(fileMgr *session.FileManager) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+
+		LogInfo(ctx, "stickers_command", userID, "user requested sticker sets", nil)
+
+		sets, err := fileMgr.RecentStickerSets(ctx, userID, recentStickerSetsLimit)
+		if err != nil {
+			LogError(ctx, "stickers_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		if len(sets) == 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.T("You haven't sent me any stickers from a sticker set yet."),
+			})
+			return
+		}
+
+		LogInfo(ctx, "stickers_command", userID, "sticker sets sent", map[string]interface{}{
+			"set_count": len(sets),
+		})
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            formatStickerSets(tr, sets),
+			ParseMode:       models.ParseModeHTML,
+			ReplyMarkup:     buildStickerSetsKeyboard(sets),
+		})
+	}
+}
+
+// formatStickerSets lists sticker sets with their sticker counts and emojis as HTML
+func formatStickerSets(tr *i18n.Translator, sets []*session.StickerSetUsage) string {
+	var sb strings.Builder
+	sb.WriteString(tr.T("🎨 Your recent sticker sets:\n"))
+	for i, set := range sets {
+		sb.WriteString(tr.Sprintf("\n%d. %s · %d sticker(s)", i+1, format.Escape(set.SetName), set.Stickers))
+		if set.Type == "custom_emoji" {
+			sb.WriteString(tr.T(" · custom emoji"))
+		}
+		if set.Emojis != "" {
+			sb.WriteString(" " + format.Escape(set.Emojis))
+		}
+	}
+	return sb.String()
+}
+
+// buildStickerSetsKeyboard creates one button per set linking to its t.me page
+func buildStickerSetsKeyboard(sets []*session.StickerSetUsage) *models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(sets))
+	for _, set := range sets {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: set.SetName, URL: stickerSetURL(set)},
+		})
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// stickerSetURL returns the link that opens a sticker or custom emoji set in Telegram
+func stickerSetURL(set *session.StickerSetUsage) string {
+	if set.Type == "custom_emoji" {
+		return "https://t.me/addemoji/" + set.SetName
+	}
+	return "https://t.me/addstickers/" + set.SetName
+}
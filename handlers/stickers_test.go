@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"tg-bot-demo/session"
+)
+
+func TestFormatStickerSets(t *testing.T) {
+	sets := []*session.StickerSetUsage{
+		{SetName: "Cats", Type: "regular", Stickers: 3, Emojis: "😺😹"},
+		{SetName: "Blobs", Type: "custom_emoji", Stickers: 1},
+	}
+
+	text := formatStickerSets(en, sets)
+	if !strings.Contains(text, "1. Cats · 3 sticker(s) 😺😹") {
+		t.Errorf("expected first set line, got %q", text)
+	}
+	if !strings.Contains(text, "2. Blobs · 1 sticker(s) · custom emoji") {
+		t.Errorf("expected custom emoji set line, got %q", text)
+	}
+
+	if text := formatStickerSets(en, []*session.StickerSetUsage{{SetName: "a<b>&c", Stickers: 1}}); !strings.Contains(text, "a&lt;b&gt;&amp;c") {
+		t.Errorf("expected set name to be escaped, got %q", text)
+	}
+
+	keyboard := buildStickerSetsKeyboard(sets)
+	if len(keyboard.InlineKeyboard) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(keyboard.InlineKeyboard))
+	}
+	if url := keyboard.InlineKeyboard[0][0].URL; url != "https://t.me/addstickers/Cats" {
+		t.Errorf("unexpected sticker set URL %q", url)
+	}
+	if url := keyboard.InlineKeyboard[1][0].URL; url != "https://t.me/addemoji/Blobs" {
+		t.Errorf("unexpected custom emoji set URL %q", url)
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// summaryHistoryMessages is the number of stored messages /summary summarizes
+const summaryHistoryMessages = 200
+
+// summaryPrompt instructs the model how to summarize a transcript
+const summaryPrompt = "Summarize the following conversation between a user and an assistant in a few short paragraphs. " +
+	"Keep names, decisions, open questions and facts the user shared. Answer in the language of the conversation."
+
+// summaryContextIntro starts the system message carrying a session's summary to the AI
+const summaryContextIntro = "Summary of the earlier part of this conversation:"
+
+// SummaryCommandHandler handles the /summary command.
+// It asks the AI assistant to summarize the active session's history, sends the
+// summary and stores it on the session; "/summary pin" also pins the message.
+func SummaryCommandHandler(sessionMgr *session.Manager, messageMgr *session.MessageManager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		chatID := update.Message.Chat.ID
+		tr := i18n.FromContext(ctx)
+		reply := func(text string) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          chatID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+			})
+		}
+		fail := func(err error) {
+			LogError(ctx, "summary_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+		}
+
+		if cfg.Assistant == nil {
+			reply(tr.T("The AI assistant is not configured, so sessions cannot be summarized."))
+			return
+		}
+
+		pin := false
+		if args := commandArgs(ctx, update.Message); len(args) > 0 {
+			if len(args) > 1 || !strings.EqualFold(args[0], "pin") {
+				reply(tr.T("Usage: /summary [pin]"))
+				return
+			}
+			pin = true
+		}
+
+		activeSession, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetActiveSession(ctx, userID)
+		if errors.Is(err, session.ErrSessionNotFound) {
+			reply(tr.T("No active session. Send a message to start one, then use /summary."))
+			return
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		stored, err := messageMgr.History(ctx, activeSession.ID, summaryHistoryMessages)
+		if err != nil {
+			fail(err)
+			return
+		}
+		transcript := summaryTranscript(stored)
+		if transcript == "" {
+			reply(tr.T("This session has no messages to summarize yet."))
+			return
+		}
+
+		// The transcript holds text from anyone in the chat, so the model gets no tools to act on it
+		caller := ai.Caller{UserID: userID, ChatID: chatID, Admin: isAdmin(cfg, userID)}
+		params := aiParams(ctx, cfg)
+		params.NoTools = true
+		model := params.Model
+		text, err := cfg.Assistant.ReplyWith(ctx, caller, params, []ai.Message{
+			{Role: ai.RoleSystem, Content: summaryPrompt},
+			{Role: ai.RoleUser, Content: transcript},
+		})
+		if err != nil {
+			LogError(ctx, "summary_command", userID, err, map[string]interface{}{
+				"session_id": activeSession.ID.String(),
+				"model":      model,
+			})
+			reply(tr.T("🤖 The assistant is unavailable right now, please try again later."))
+			return
+		}
+
+		// The summary is sent and stored as the user would see it
+		flagged := &session.FlaggedContent{
+			UserID:    userID,
+			ChatID:    chatID,
+			SessionID: activeSession.ID,
+			Direction: session.DirectionOutbound,
+			Text:      text,
+		}
+		text, matches := filterContent(ctx, cfg, userID, text)
+		flagContent(ctx, cfg, flagged, matches)
+		if cfg.blocksContent(matches) {
+			reply(tr.T("🚫 The assistant's reply was withheld by the content filter."))
+			return
+		}
+
+		sent, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          chatID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            tr.Sprintf("📝 Summary of %s", format.Bold(activeSession.Title)) + "\n\n" + format.Escape(text),
+			ParseMode:       models.ParseModeHTML,
+		})
+		if err != nil {
+			LogError(ctx, "summary_command", userID, err, map[string]interface{}{
+				"session_id": activeSession.ID.String(),
+			})
+			return
+		}
+
+		summary := &session.Summary{
+			SessionID:    activeSession.ID,
+			Text:         text,
+			MessageCount: activeSession.MessageCount,
+			CreatedAt:    time.Now(),
+		}
+		if pin {
+			// Pinning needs admin rights in groups; the summary is kept either way
+			if _, err := b.PinChatMessage(ctx, &bot.PinChatMessageParams{
+				ChatID:              chatID,
+				MessageID:           sent.ID,
+				DisableNotification: true,
+			}); err != nil {
+				LogError(ctx, "summary_command", userID, err, map[string]interface{}{
+					"session_id": activeSession.ID.String(),
+				})
+				reply(tr.T("The summary could not be pinned. In groups the bot needs the right to pin messages."))
+			} else {
+				summary.PinnedMessageID = sent.ID
+			}
+		}
+
+		if cfg.Summaries != nil {
+			if err := cfg.Summaries.SetSummary(ctx, summary); err != nil {
+				LogError(ctx, "summary_command", userID, err, map[string]interface{}{
+					"session_id": activeSession.ID.String(),
+				})
+				return
+			}
+		}
+
+		LogInfo(ctx, "summary_command", userID, "session summarized", map[string]interface{}{
+			"session_id":     activeSession.ID.String(),
+			"model":          model,
+			"messages":       len(stored),
+			"summary_length": len(text),
+			"pinned":         summary.PinnedMessageID != 0,
+		})
+	}
+}
+
+// summaryTranscript renders the user and assistant messages of a session as a
+// transcript for the model, or "" when there are none
+func summaryTranscript(messages []*session.Message) string {
+	var sb strings.Builder
+	for _, message := range messages {
+		if message.Content == "" || (message.Role != session.RoleUser && message.Role != session.RoleAssistant) {
+			continue
+		}
+		sb.WriteString(message.Role + ": " + message.Content + "\n\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// summaryContext returns the system message giving the AI the stored summary
+// of a session, or nil when it has none. It is only given when history was cut
+// to the last limit messages, for the summary to stand in for the older ones.
+// Failures are logged and leave the summary out.
+func summaryContext(ctx context.Context, cfg *HandlerConfig, userID int64, sessionID uuid.UUID, history, limit int) *ai.Message {
+	if cfg.Summaries == nil || history < limit {
+		return nil
+	}
+	summary, err := cfg.Summaries.GetSummary(ctx, sessionID)
+	if errors.Is(err, session.ErrSummaryNotFound) {
+		return nil
+	}
+	if err != nil {
+		LogError(ctx, "assistant", userID, err, map[string]interface{}{"session_id": sessionID.String()})
+		return nil
+	}
+	return &ai.Message{Role: ai.RoleSystem, Content: summaryContextIntro + "\n" + summary.Text}
+}
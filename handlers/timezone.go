@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// timezoneUsage explains the /timezone argument
+const timezoneUsage = "Use /timezone Europe/Berlin to see dates in your time zone, or /timezone UTC to go back to the default."
+
+// TimezoneCommandHandler handles the /timezone command.
+// "/timezone Europe/Berlin" sets the IANA time zone dates are shown in and
+// quiet hours are counted in; "/timezone" shows the current one.
+func TimezoneCommandHandler(store session.UserSettingsStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+		tr := i18n.FromContext(ctx)
+		reply := func(text string) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            text,
+			})
+		}
+
+		userSettings, err := store.GetUserSettings(ctx, userID)
+		if err != nil {
+			LogError(ctx, "timezone_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		args := commandArgs(ctx, update.Message)
+		switch len(args) {
+		case 0:
+			tr = tr.In(userSettings.Location())
+			reply(tr.Sprintf("🕒 Time zone: %s (now %s)", userSettings.Location(), tr.FormatTime(time.Now(), "Jan 2, 2006 15:04")) +
+				"\n" + tr.T(timezoneUsage))
+			return
+		case 1:
+		default:
+			reply(tr.T(timezoneUsage))
+			return
+		}
+
+		location, err := parseTimezone(args[0])
+		if err != nil {
+			reply(tr.Sprintf("Unknown time zone: %s\nUse a name such as Europe/Berlin or America/New_York.", args[0]))
+			return
+		}
+		userSettings.Timezone = location.String()
+		userSettings.UpdatedAt = time.Now()
+		tr = tr.In(location)
+		err = cfg.confirmWrite(ctx, b, userID, func(ctx context.Context) (*bot.SendMessageParams, error) {
+			if err := store.SaveUserSettings(ctx, userSettings); err != nil {
+				return nil, err
+			}
+			return &bot.SendMessageParams{
+				ChatID:          update.Message.Chat.ID,
+				MessageThreadID: topicThreadID(update.Message),
+				Text:            tr.Sprintf("🕒 Time zone set to %s. Your local time is %s.", location, tr.FormatTime(time.Now(), "Jan 2, 2006 15:04")),
+			}, nil
+		})
+		if err != nil {
+			LogError(ctx, "timezone_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "timezone_command", userID, "time zone saved", map[string]interface{}{
+			"timezone": userSettings.Timezone,
+		})
+	}
+}
+
+// parseTimezone loads an IANA time zone name such as "Europe/Berlin".
+// "Local" is rejected because it names the server's zone rather than the user's.
+func parseTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("invalid time zone %q", name)
+	}
+	return time.LoadLocation(name)
+}
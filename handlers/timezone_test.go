@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func TestTimezoneCommandHandler(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_timezone.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+	timezone := TimezoneCommandHandler(store, &HandlerConfig{})
+
+	timezone(ctx, api, commandUpdate(1, "/timezone"))
+	if !strings.Contains(api.LastText(), "Time zone: UTC") {
+		t.Errorf("expected UTC by default, got %q", api.LastText())
+	}
+
+	timezone(ctx, api, commandUpdate(1, "/timezone America/New_York"))
+	if !strings.Contains(api.LastText(), "Time zone set to America/New_York") {
+		t.Errorf("expected the new time zone, got %q", api.LastText())
+	}
+	if saved, _ := store.GetUserSettings(ctx, 1); saved.Timezone != "America/New_York" {
+		t.Errorf("expected the time zone to be saved, got %q", saved.Timezone)
+	}
+
+	for _, text := range []string{"/timezone Local", "/timezone Mars/Olympus"} {
+		timezone(ctx, api, commandUpdate(1, text))
+		if !strings.Contains(api.LastText(), "Unknown time zone") {
+			t.Errorf("%s: expected an unknown time zone error, got %q", text, api.LastText())
+		}
+	}
+	if saved, _ := store.GetUserSettings(ctx, 1); saved.Timezone != "America/New_York" {
+		t.Errorf("expected invalid zones to leave the setting alone, got %q", saved.Timezone)
+	}
+}
+
+func TestUserSettingsMiddlewareSetsTimezone(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_middleware.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	ctx := context.Background()
+	userSettings := session.DefaultUserSettings(1)
+	userSettings.Timezone = "Asia/Tokyo"
+	if err := store.SaveUserSettings(ctx, userSettings); err != nil {
+		t.Fatalf("SaveUserSettings failed: %v", err)
+	}
+
+	var got *i18n.Translator
+	next := UserSettingsMiddleware(store)(func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		got = i18n.FromContext(ctx)
+	})
+	next(i18n.WithTranslator(ctx, i18n.New(i18n.Chinese)), nil, commandUpdate(1, "/stats"))
+
+	if got.Language() != i18n.Chinese || got.Location().String() != "Asia/Tokyo" {
+		t.Fatalf("expected a Chinese translator in Tokyo, got %s in %s", got.Language(), got.Location())
+	}
+	tm := time.Date(2025, 6, 1, 23, 30, 0, 0, time.UTC)
+	if formatted := got.FormatTime(tm, "Jan 2, 2006 15:04"); formatted != "2025年6月2日 08:30" {
+		t.Errorf("unexpected formatted time %q", formatted)
+	}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// MessageTopic returns the forum topic msg was sent in, or the zero Topic
+// outside forum topics. Each topic has its own active session.
+func MessageTopic(msg *models.Message) session.Topic {
+	if threadID := topicThreadID(msg); threadID != 0 {
+		return session.Topic{ChatID: msg.Chat.ID, ThreadID: threadID}
+	}
+	return session.Topic{}
+}
+
+// topicThreadID returns the thread ID replies to msg must carry to stay in its
+// forum topic, or 0. Reply threads outside forums are not topics and return 0.
+func topicThreadID(msg *models.Message) int {
+	if msg == nil || !msg.IsTopicMessage {
+		return 0
+	}
+	return msg.MessageThreadID
+}
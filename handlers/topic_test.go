@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestMessageTopic(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      *models.Message
+		expected session.Topic
+	}{
+		{
+			name:     "forum topic",
+			msg:      &models.Message{Chat: models.Chat{ID: -100}, MessageThreadID: 7, IsTopicMessage: true},
+			expected: session.Topic{ChatID: -100, ThreadID: 7},
+		},
+		{
+			name:     "reply thread outside a forum",
+			msg:      &models.Message{Chat: models.Chat{ID: -100}, MessageThreadID: 7},
+			expected: session.Topic{},
+		},
+		{
+			name:     "private chat",
+			msg:      &models.Message{Chat: models.Chat{ID: 42}},
+			expected: session.Topic{},
+		},
+		{
+			name:     "no message",
+			msg:      nil,
+			expected: session.Topic{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MessageTopic(tt.msg); got != tt.expected {
+				t.Errorf("MessageTopic() = %+v, want %+v", got, tt.expected)
+			}
+			if got := topicThreadID(tt.msg); got != tt.expected.ThreadID {
+				t.Errorf("topicThreadID() = %d, want %d", got, tt.expected.ThreadID)
+			}
+		})
+	}
+}
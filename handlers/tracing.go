@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/tracing"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CorrelationMiddleware restores the request ID and trace of the webhook request
+// that delivered an update, then starts a span for the update
+func CorrelationMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		ctx = correlation.Restore(ctx, update.ID)
+
+		attrs := []attribute.KeyValue{
+			attribute.Int64("telegram.update_id", update.ID),
+			attribute.String("telegram.update_type", updateType(update)),
+			attribute.String("request_id", correlation.ID(ctx)),
+		}
+		if user := updateSender(update); user != nil {
+			attrs = append(attrs, attribute.Int64("telegram.user_id", user.ID))
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, "telegram.update", trace.WithAttributes(attrs...))
+		defer span.End()
+
+		next(ctx, b, update)
+	}
+}
+
+// Traced adapts a handler to the bot library and wraps it in a span named after
+// it. Under DeliveryMiddleware the messages the handler sends are tracked.
+func Traced(name string, next HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		ctx, span := tracing.Tracer().Start(ctx, "handler "+name)
+		defer span.End()
+
+		next(ctx, trackDeliveries(ctx, b, update), update)
+	}
+}
+
+// updateType names the kind of content an update carries
+func updateType(update *models.Update) string {
+	switch {
+	case update.Message != nil:
+		return "message"
+	case update.EditedMessage != nil:
+		return "edited_message"
+	case update.CallbackQuery != nil:
+		return "callback_query"
+	case update.InlineQuery != nil:
+		return "inline_query"
+	case update.ChosenInlineResult != nil:
+		return "chosen_inline_result"
+	case update.BusinessMessage != nil:
+		return "business_message"
+	case update.EditedBusinessMessage != nil:
+		return "edited_business_message"
+	case update.BusinessConnection != nil:
+		return "business_connection"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"tg-bot-demo/ai"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Limits of /translate
+const (
+	maxTranslateLanguageLength = 32
+	maxTranslateTextLength     = 8000
+)
+
+// translatePrompt instructs the model to translate into the language named by %s
+const translatePrompt = "Translate the user's message into %s. Keep the formatting, names and code as they are. " +
+	"Answer with the translation only."
+
+// TranslateCommandHandler handles the /translate command.
+// Sent as a reply, "/translate <language>" asks the AI assistant to translate the
+// replied message and answers it with the translation. Messages without text,
+// such as documents, are looked up in the stored history.
+func TranslateCommandHandler(messageMgr *session.MessageManager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		msg := update.Message
+		userID := msg.From.ID
+		tr := i18n.FromContext(ctx)
+		replied := repliedMessage(msg)
+		reply := func(text string) {
+			params := &bot.SendMessageParams{
+				ChatID:          msg.Chat.ID,
+				MessageThreadID: topicThreadID(msg),
+				Text:            text,
+			}
+			if replied != nil {
+				params.ReplyParameters = &models.ReplyParameters{MessageID: replied.ID, AllowSendingWithoutReply: true}
+			}
+			b.SendMessage(ctx, params)
+		}
+
+		if cfg.Assistant == nil {
+			reply(tr.T("The AI assistant is not configured, so messages cannot be translated."))
+			return
+		}
+
+		language := strings.TrimSpace(messageCommand(ctx, msg).RawArgs)
+		if replied == nil || language == "" || utf8.RuneCountInString(language) > maxTranslateLanguageLength {
+			reply(tr.T("Usage: reply to a message with /translate <language>, e.g. /translate en or /translate French"))
+			return
+		}
+
+		text, err := repliedText(ctx, messageMgr, replied)
+		if err != nil {
+			LogError(ctx, "translate_command", userID, err, nil)
+			SendErrorResponse(ctx, b, msg, err)
+			return
+		}
+		if text == "" {
+			reply(tr.T("The replied message has no text to translate."))
+			return
+		}
+		if utf8.RuneCountInString(text) > maxTranslateTextLength {
+			reply(tr.Sprintf("Messages of up to %d characters can be translated.", maxTranslateTextLength))
+			return
+		}
+
+		// The replied text may be anyone's, so the model gets no tools to act on it
+		caller := ai.Caller{UserID: userID, ChatID: msg.Chat.ID, Admin: isAdmin(cfg, userID)}
+		params := aiParams(ctx, cfg)
+		params.NoTools = true
+		model := params.Model
+		translation, err := cfg.Assistant.ReplyWith(ctx, caller, params, []ai.Message{
+			{Role: ai.RoleSystem, Content: fmt.Sprintf(translatePrompt, i18n.LanguageName(language))},
+			{Role: ai.RoleUser, Content: text},
+		})
+		if err != nil {
+			LogError(ctx, "translate_command", userID, err, map[string]interface{}{
+				"language": language,
+				"model":    model,
+			})
+			reply(tr.T("🤖 The assistant is unavailable right now, please try again later."))
+			return
+		}
+
+		flagged := &session.FlaggedContent{
+			UserID:    userID,
+			ChatID:    msg.Chat.ID,
+			Direction: session.DirectionOutbound,
+			Text:      translation,
+		}
+		translation, matches := filterContent(ctx, cfg, userID, translation)
+		flagContent(ctx, cfg, flagged, matches)
+		if cfg.blocksContent(matches) {
+			reply(tr.T("🚫 The assistant's reply was withheld by the content filter."))
+			return
+		}
+
+		for _, part := range format.SplitMarkdown(translation, format.MaxMessageLength) {
+			reply(part)
+		}
+
+		LogInfo(ctx, "translate_command", userID, "message translated", map[string]interface{}{
+			"language":    language,
+			"model":       model,
+			"text_length": len(text),
+		})
+	}
+}
+
+// repliedMessage returns the message msg replies to, or nil. Messages in forum
+// topics reply to the topic's creation unless they reply to something else.
+func repliedMessage(msg *models.Message) *models.Message {
+	replied := msg.ReplyToMessage
+	if replied == nil || replied.ForumTopicCreated != nil {
+		return nil
+	}
+	return replied
+}
+
+// repliedText returns the text of a replied message: its text or caption, or
+// else what the history stored for it, such as text extracted from a document.
+// It returns "" when there is none.
+func repliedText(ctx context.Context, messageMgr *session.MessageManager, replied *models.Message) (string, error) {
+	if replied.Text != "" {
+		return replied.Text, nil
+	}
+	if replied.Caption != "" {
+		return replied.Caption, nil
+	}
+	stored, err := messageMgr.FindMessage(ctx, replied.Chat.ID, replied.ID)
+	if errors.Is(err, session.ErrMessageNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return stored.Content, nil
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// SettingsCallbackPrefix is the common prefix of all /settings menu callbacks
+const SettingsCallbackPrefix = "settings_"
+
+// Callback data of the /settings menu
+const (
+	settingsMenuCallback          = "settings_menu"
+	settingsLanguageCallback      = "settings_lang"
+	settingsLanguagePrefix        = "settings_lang_" // followed by a language code or languageAutoArg
+	settingsModelCallback         = "settings_model"
+	settingsModelPrefix           = "settings_model_" // followed by an index into HandlerConfig.AIModels
+	settingsAutoDownloadCallback  = "settings_download"
+	settingsNotificationsCallback = "settings_notify"
+	settingsQuietCallback         = "settings_quiet"
+	settingsTimezoneCallback      = "settings_tz"
+	settingsAICallback            = "settings_ai"
+)
+
+// UserSettingsMiddleware is a bot middleware that puts the settings of the
+// update's sender into the context, for session.UserSettingsFromContext.
+// It also sets the time zone of the context's translator to the user's, so it
+// must run after LanguageMiddleware.
+func UserSettingsMiddleware(store session.UserSettingsStore) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if user := updateSender(update); user != nil {
+				userSettings, err := store.GetUserSettings(ctx, user.ID)
+				if err != nil {
+					LogError(ctx, "user_settings", user.ID, err, nil)
+					userSettings = session.DefaultUserSettings(user.ID)
+				}
+				ctx = session.WithUserSettings(ctx, userSettings)
+				ctx = i18n.WithTranslator(ctx, i18n.FromContext(ctx).In(userSettings.Location()))
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// SettingsCommandHandler handles the /settings command.
+// It shows the user's settings as an inline menu of buttons that change them;
+// "/settings ai" shows and changes the AI sampling parameters.
+func SettingsCommandHandler(prefs session.PreferenceStore, store session.UserSettingsStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		user := update.Message.From
+
+		if args := commandArgs(ctx, update.Message); len(args) > 0 && strings.EqualFold(args[0], settingsAIArg) {
+			aiSettingsCommand(ctx, b, update, store, cfg, args[1:])
+			return
+		}
+
+		text, keyboard, err := buildSettingsMenu(ctx, prefs, store, cfg, user)
+		if err != nil {
+			LogError(ctx, "settings_command", user.ID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            text,
+			ReplyMarkup:     cfg.callbacks().EncodeKeyboard(ctx, keyboard),
+		})
+	}
+}
+
+// SettingsCallbackHandler handles button clicks on the /settings menu
+func SettingsCallbackHandler(prefs session.PreferenceStore, store session.UserSettingsStore, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		callback := update.CallbackQuery
+		user := &callback.From
+		tr := i18n.FromContext(ctx)
+
+		data, err := cfg.callbacks().Decode(ctx, callback.Data)
+		if err != nil {
+			rejectCallback(ctx, b, callback, "settings_callback", err, tr.T("⌛ This menu expired. Send /settings to get a fresh one."))
+			return
+		}
+
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+		})
+
+		msg := callback.Message.Message
+		if msg == nil {
+			return
+		}
+
+		var text string
+		var keyboard *models.InlineKeyboardMarkup
+		switch {
+		case data == settingsLanguageCallback:
+			text, keyboard = tr.T("🌐 Choose a language:"), buildLanguageMenu(tr)
+		case data == settingsModelCallback:
+			var userSettings *session.UserSettings
+			if userSettings, err = store.GetUserSettings(ctx, user.ID); err == nil {
+				text, keyboard = tr.T("🤖 Choose an AI model:"), buildModelMenu(tr, cfg, userSettings)
+			}
+		case data == settingsQuietCallback:
+			text, keyboard = tr.T(quietUsage), buildBackMenu(tr)
+		case data == settingsTimezoneCallback:
+			text, keyboard = tr.T(timezoneUsage), buildBackMenu(tr)
+		case data == settingsAICallback:
+			var userSettings *session.UserSettings
+			if userSettings, err = store.GetUserSettings(ctx, user.ID); err == nil {
+				text, keyboard = formatAISettings(tr, cfg.Sampling, userSettings)+"\n\n"+tr.T(aiSettingsUsage), buildBackMenu(tr)
+			}
+		case data == settingsMenuCallback:
+			text, keyboard, err = buildSettingsMenu(ctx, prefs, store, cfg, user)
+		default:
+			if err = applySettingsChoice(ctx, prefs, store, cfg, user.ID, data); err == nil {
+				// A new language applies to the menu right away
+				ctx = i18n.WithTranslator(ctx, i18n.New(userLanguage(ctx, prefs, user)).In(tr.Location()))
+				text, keyboard, err = buildSettingsMenu(ctx, prefs, store, cfg, user)
+			}
+		}
+		if err != nil {
+			LogError(ctx, "settings_callback", user.ID, err, map[string]interface{}{"callback_data": data})
+			return
+		}
+
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			Text:        text,
+			ReplyMarkup: cfg.callbacks().EncodeKeyboard(ctx, keyboard),
+		})
+	}
+}
+
+// applySettingsChoice saves the setting changed by a menu button
+func applySettingsChoice(ctx context.Context, prefs session.PreferenceStore, store session.UserSettingsStore,
+	cfg *HandlerConfig, userID int64, data string) error {
+	if code, ok := strings.CutPrefix(data, settingsLanguagePrefix); ok {
+		language := ""
+		if code != languageAutoArg {
+			normalized, ok := i18n.Normalize(code)
+			if !ok {
+				return fmt.Errorf("unknown language: %q", code)
+			}
+			language = normalized
+		}
+		LogInfo(ctx, "settings_callback", userID, "language preference saved", map[string]interface{}{"language": language})
+		return prefs.SavePreferences(ctx, &session.Preferences{UserID: userID, Language: language, UpdatedAt: time.Now()})
+	}
+
+	userSettings, err := store.GetUserSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case data == settingsAutoDownloadCallback:
+		userSettings.AutoDownload = !userSettings.AutoDownload
+	case data == settingsNotificationsCallback:
+		userSettings.Notifications = !userSettings.Notifications
+	case strings.HasPrefix(data, settingsModelPrefix):
+		index, err := strconv.Atoi(strings.TrimPrefix(data, settingsModelPrefix))
+		if err != nil || index < 0 || index >= len(cfg.AIModels) {
+			return fmt.Errorf("invalid model choice: %q", data)
+		}
+		userSettings.AIModel = cfg.AIModels[index]
+	default:
+		return fmt.Errorf("invalid callback data: %q", data)
+	}
+	userSettings.UpdatedAt = time.Now()
+
+	LogInfo(ctx, "settings_callback", userID, "user settings saved", map[string]interface{}{
+		"ai_model":      userSettings.AIModel,
+		"auto_download": userSettings.AutoDownload,
+		"notifications": userSettings.Notifications,
+	})
+	return store.SaveUserSettings(ctx, userSettings)
+}
+
+// buildSettingsMenu renders the main /settings menu for user
+func buildSettingsMenu(ctx context.Context, prefs session.PreferenceStore, store session.UserSettingsStore,
+	cfg *HandlerConfig, user *models.User) (string, *models.InlineKeyboardMarkup, error) {
+	tr := i18n.FromContext(ctx)
+
+	userSettings, err := store.GetUserSettings(ctx, user.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	language := tr.Sprintf("Auto (%s)", i18n.LanguageName(i18n.New(user.LanguageCode).Language()))
+	if p, err := prefs.GetPreferences(ctx, user.ID); err == nil && p.Language != "" {
+		language = i18n.LanguageName(p.Language)
+	}
+
+	rows := [][]models.InlineKeyboardButton{
+		{{Text: tr.Sprintf("🌐 Language: %s", language), CallbackData: settingsLanguageCallback}},
+	}
+	if len(cfg.AIModels) > 0 {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: tr.Sprintf("🤖 AI model: %s", currentModel(cfg, userSettings)), CallbackData: settingsModelCallback},
+		})
+	}
+	if cfg.Assistant != nil && cfg.Sampling.enabled() {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: tr.Sprintf("🎛 AI parameters: %s", formatAISettingsSummary(tr, userSettings)), CallbackData: settingsAICallback},
+		})
+	}
+	rows = append(rows,
+		[]models.InlineKeyboardButton{{Text: tr.Sprintf("📥 Auto-download: %s", formatOnOff(tr, userSettings.AutoDownload)), CallbackData: settingsAutoDownloadCallback}},
+		[]models.InlineKeyboardButton{{Text: tr.Sprintf("🔔 Notifications: %s", formatOnOff(tr, userSettings.Notifications)), CallbackData: settingsNotificationsCallback}},
+		[]models.InlineKeyboardButton{{Text: tr.Sprintf("🕒 Time zone: %s", userSettings.Location()), CallbackData: settingsTimezoneCallback}},
+		[]models.InlineKeyboardButton{{Text: tr.Sprintf("🌙 Quiet hours: %s", formatQuietHours(tr, userSettings)), CallbackData: settingsQuietCallback}},
+	)
+
+	return tr.T("⚙️ Settings\nTap a setting to change it."), &models.InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+// buildLanguageMenu lists the supported languages and the automatic choice
+func buildLanguageMenu(tr *i18n.Translator) *models.InlineKeyboardMarkup {
+	rows := [][]models.InlineKeyboardButton{
+		{{Text: tr.T("Auto (Telegram app language)"), CallbackData: settingsLanguagePrefix + languageAutoArg}},
+	}
+	for _, code := range i18n.Supported() {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: i18n.LanguageName(code), CallbackData: settingsLanguagePrefix + code},
+		})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: tr.T("« Back"), CallbackData: settingsMenuCallback}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// buildBackMenu is the keyboard of settings that are changed with a command
+func buildBackMenu(tr *i18n.Translator) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: tr.T("« Back"), CallbackData: settingsMenuCallback}},
+	}}
+}
+
+// buildModelMenu lists the configured AI models, marking the user's current one
+func buildModelMenu(tr *i18n.Translator, cfg *HandlerConfig, userSettings *session.UserSettings) *models.InlineKeyboardMarkup {
+	current := currentModel(cfg, userSettings)
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(cfg.AIModels)+1)
+	for i, model := range cfg.AIModels {
+		label := model
+		if model == current {
+			label = "✅ " + model
+		}
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: label, CallbackData: settingsModelPrefix + strconv.Itoa(i)},
+		})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: tr.T("« Back"), CallbackData: settingsMenuCallback}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// currentModel returns the user's AI model, or the default one when unset or no longer offered
+func currentModel(cfg *HandlerConfig, userSettings *session.UserSettings) string {
+	for _, model := range cfg.AIModels {
+		if model == userSettings.AIModel {
+			return model
+		}
+	}
+	if len(cfg.AIModels) == 0 {
+		return ""
+	}
+	return cfg.AIModels[0]
+}
+
+// formatOnOff renders a boolean setting
+func formatOnOff(tr *i18n.Translator, on bool) string {
+	if on {
+		return tr.T("on")
+	}
+	return tr.T("off")
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestSettingsMenu(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_settings_menu.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := &HandlerConfig{
+		AIModels:       []string{"small", "large"},
+		CallbackSigner: NewCallbackSigner("secret", time.Hour),
+	}
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	SettingsCommandHandler(store, store, cfg)(ctx, api, commandUpdate(1, "/settings"))
+	menu := api.Sent[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	labels := keyboardLabels(menu)
+	for _, want := range []string{"Language: Auto (English)", "AI model: small", "Auto-download: on", "Notifications: on"} {
+		if !strings.Contains(labels, want) {
+			t.Errorf("expected %q in the menu, got %q", want, labels)
+		}
+	}
+
+	click := func(data string) string {
+		SettingsCallbackHandler(store, store, cfg)(ctx, api, callbackUpdate(1, cfg.CallbackSigner.Sign(data)))
+		edited := api.EditedTexts[len(api.EditedTexts)-1]
+		return edited.Text + "\n" + keyboardLabels(edited.ReplyMarkup.(*models.InlineKeyboardMarkup))
+	}
+
+	if page := click(settingsModelCallback); !strings.Contains(page, "✅ small") {
+		t.Errorf("expected the default model to be marked, got %q", page)
+	}
+	if page := click(settingsModelPrefix + "1"); !strings.Contains(page, "AI model: large") {
+		t.Errorf("expected the chosen model, got %q", page)
+	}
+	if page := click(settingsAutoDownloadCallback); !strings.Contains(page, "Auto-download: off") {
+		t.Errorf("expected auto-download to be toggled off, got %q", page)
+	}
+	if page := click(settingsLanguagePrefix + "zh"); !strings.Contains(page, "设置") {
+		t.Errorf("expected the menu in the new language, got %q", page)
+	}
+
+	saved, err := store.GetUserSettings(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserSettings failed: %v", err)
+	}
+	if saved.AIModel != "large" || saved.AutoDownload || !saved.Notifications {
+		t.Errorf("unexpected saved settings %+v", saved)
+	}
+	if prefs, err := store.GetPreferences(ctx, 1); err != nil || prefs.Language != "zh" {
+		t.Errorf("expected the language preference to be saved, got %+v, %v", prefs, err)
+	}
+
+	before := len(api.EditedTexts)
+	SettingsCallbackHandler(store, store, cfg)(ctx, api, callbackUpdate(1, settingsNotificationsCallback))
+	if len(api.EditedTexts) != before {
+		t.Error("expected unsigned callback data to be rejected")
+	}
+}
+
+func TestSettingsMenuWithoutModels(t *testing.T) {
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "test_settings_menu.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	_, keyboard, err := buildSettingsMenu(context.Background(), store, store, &HandlerConfig{}, &models.User{ID: 1})
+	if err != nil {
+		t.Fatalf("buildSettingsMenu failed: %v", err)
+	}
+	if labels := keyboardLabels(keyboard); strings.Contains(labels, "AI model") {
+		t.Errorf("expected no model row without configured models, got %q", labels)
+	}
+}
+
+// keyboardLabels joins the button texts of markup, one row per line
+func keyboardLabels(markup *models.InlineKeyboardMarkup) string {
+	var rows []string
+	for _, row := range markup.InlineKeyboard {
+		var labels []string
+		for _, button := range row {
+			labels = append(labels, button.Text)
+		}
+		rows = append(rows, strings.Join(labels, " | "))
+	}
+	return strings.Join(rows, "\n")
+}
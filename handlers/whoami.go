@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"tg-bot-demo/format"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// whoamiInfo holds the diagnostics reported by /whoami
+type whoamiInfo struct {
+	UserID        int64
+	ChatID        int64
+	ActiveSession *session.Session // nil when no session is active
+	SessionCount  int
+	Usage         *session.FileUsage
+	QuotaBytes    int64 // 0 means unlimited
+	Version       string
+}
+
+// WhoamiCommandHandler handles the /whoami command.
+// It replies with the caller's IDs, language, active session, usage and the bot version
+// so users can paste it into support requests and bug reports.
+func WhoamiCommandHandler(sessionMgr *session.Manager, fileMgr *session.FileManager, cfg *HandlerConfig) HandlerFunc {
+	return func(ctx context.Context, b TelegramAPI, update *models.Update) {
+		userID := update.Message.From.ID
+
+		info := whoamiInfo{
+			UserID:     userID,
+			ChatID:     update.Message.Chat.ID,
+			QuotaBytes: cfg.userQuotaBytes(ctx),
+			Version:    cfg.Version,
+		}
+
+		active, err := sessionMgr.InTopic(MessageTopic(update.Message)).GetActiveSession(ctx, userID)
+		if err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+			LogError(ctx, "whoami_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+		info.ActiveSession = active
+
+		if info.SessionCount, err = sessionMgr.CountSessions(ctx, userID); err != nil {
+			LogError(ctx, "whoami_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		if info.Usage, err = fileMgr.Usage(ctx, userID); err != nil {
+			LogError(ctx, "whoami_command", userID, err, nil)
+			SendErrorResponse(ctx, b, update.Message, err)
+			return
+		}
+
+		LogInfo(ctx, "whoami_command", userID, "diagnostics sent", nil)
+
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			MessageThreadID: topicThreadID(update.Message),
+			Text:            formatWhoami(i18n.FromContext(ctx), info),
+			ParseMode:       models.ParseModeHTML,
+		})
+	}
+}
+
+// formatWhoami renders the /whoami diagnostics as HTML
+func formatWhoami(tr *i18n.Translator, info whoamiInfo) string {
+	lines := []string{
+		tr.T("🪪 Who am I"),
+		tr.Sprintf("User ID: %s", format.Code(strconv.FormatInt(info.UserID, 10))),
+		tr.Sprintf("Chat ID: %s", format.Code(strconv.FormatInt(info.ChatID, 10))),
+		tr.Sprintf("Language: %s", format.Escape(i18n.LanguageName(tr.Language())+" ("+tr.Language()+")")),
+	}
+
+	if info.ActiveSession != nil {
+		lines = append(lines, tr.Sprintf("Active session: %s %s",
+			format.Bold(info.ActiveSession.Title), format.Code(info.ActiveSession.ID.String())))
+	} else {
+		lines = append(lines, tr.T("Active session: none"))
+	}
+
+	lines = append(lines, tr.Sprintf("Sessions: %d", info.SessionCount))
+
+	if info.QuotaBytes > 0 {
+		remaining := max(info.QuotaBytes-info.Usage.Bytes, 0)
+		lines = append(lines, tr.Sprintf("Storage: %s used, %s remaining of %s",
+			formatBytes(info.Usage.Bytes), formatBytes(remaining), formatBytes(info.QuotaBytes)))
+	} else {
+		lines = append(lines, tr.Sprintf("Storage: %s used (no quota)", formatBytes(info.Usage.Bytes)))
+	}
+
+	version := info.Version
+	if version == "" {
+		version = "dev"
+	}
+	lines = append(lines, tr.Sprintf("Bot version: %s", format.Code(version)))
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"tg-bot-demo/session"
+)
+
+func TestFormatWhoami(t *testing.T) {
+	active := session.NewSession(1, "Notes <draft>")
+
+	text := formatWhoami(en, whoamiInfo{
+		UserID:        1,
+		ChatID:        -100,
+		ActiveSession: active,
+		SessionCount:  3,
+		Usage:         &session.FileUsage{UserID: 1, Files: 2, Bytes: 1024},
+		QuotaBytes:    4096,
+		Version:       "v1.2.3",
+	})
+
+	for _, want := range []string{
+		"User ID: <code>1</code>",
+		"Chat ID: <code>-100</code>",
+		"Language: English (en)",
+		"<b>Notes &lt;draft&gt;</b> <code>" + active.ID.String() + "</code>",
+		"Sessions: 3",
+		"Storage: 1.0 KB used, 3.0 KB remaining of 4.0 KB",
+		"Bot version: <code>v1.2.3</code>",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestFormatWhoamiWithoutSessionOrQuota(t *testing.T) {
+	text := formatWhoami(en, whoamiInfo{
+		UserID: 1,
+		ChatID: 1,
+		Usage:  &session.FileUsage{Bytes: 10},
+	})
+
+	for _, want := range []string{
+		"Active session: none",
+		"Storage: 10 B used (no quota)",
+		"Bot version: <code>dev</code>",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+}
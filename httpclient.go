@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"tg-bot-demo/config"
+)
+
+// apiRequestTimeout bounds one Bot API call, matching the bot library's default client
+const apiRequestTimeout = time.Minute
+
+// telegramClients are the HTTP clients used to reach Telegram. The configured proxy
+// applies to API calls and file downloads alike.
+type telegramClients struct {
+	api      *http.Client
+	download *http.Client // Timeout is the limit of a whole download
+}
+
+// newTelegramClients builds the Telegram HTTP clients from cfg. Without a configured
+// proxy the standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables still apply.
+func newTelegramClients(cfg *config.Config) (*telegramClients, error) {
+	apiTransport, err := newProxyTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadTransport, err := newDownloadTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &telegramClients{
+		api: &http.Client{Transport: apiTransport, Timeout: apiRequestTimeout},
+		download: &http.Client{
+			Transport: downloadTransport,
+			Timeout:   time.Duration(cfg.DownloadTimeoutSeconds) * time.Second,
+		},
+	}, nil
+}
+
+// newProxyTransport clones the default transport and points it at the configured proxy
+func newProxyTransport(cfg *config.Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy_url: %w", err)
+		}
+		if cfg.ProxyUsername != "" {
+			proxyURL.User = url.UserPassword(cfg.ProxyUsername, cfg.ProxyPassword)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
+}
+
+// newDownloadTransport builds the transport of the download client with the
+// configured connect timeout, idle connection limit and extra CA certificates
+func newDownloadTransport(cfg *config.Config) (*http.Transport, error) {
+	transport, err := newProxyTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	connectTimeout := time.Duration(cfg.DownloadConnectTimeoutSeconds) * time.Second
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.MaxIdleConnsPerHost = cfg.DownloadMaxIdleConns
+
+	if cfg.DownloadCAFile != "" {
+		pem, err := os.ReadFile(cfg.DownloadCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read download_ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("download_ca_file contains no PEM certificates")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return transport, nil
+}
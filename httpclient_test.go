@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tg-bot-demo/config"
+	"tg-bot-demo/storage"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestTelegramClientsUseProxy(t *testing.T) {
+	var proxied []*http.Request
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r)
+		w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+
+	cfg := config.Default()
+	cfg.ProxyURL = proxy.URL
+	cfg.ProxyUsername = "bot"
+	cfg.ProxyPassword = "s3cret"
+
+	clients, err := newTelegramClients(cfg)
+	if err != nil {
+		t.Fatalf("newTelegramClients failed: %v", err)
+	}
+
+	for _, client := range []*http.Client{clients.api, clients.download} {
+		response, err := client.Get("http://api.telegram.invalid/file/bot123/photo.jpg")
+		if err != nil {
+			t.Fatalf("request through proxy failed: %v", err)
+		}
+		response.Body.Close()
+	}
+
+	if len(proxied) != 2 {
+		t.Fatalf("expected both clients to use the proxy, got %d requests", len(proxied))
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("bot:s3cret"))
+	for _, r := range proxied {
+		if r.Host != "api.telegram.invalid" {
+			t.Errorf("expected proxied request for api.telegram.invalid, got host %q", r.Host)
+		}
+		if got := r.Header.Get("Proxy-Authorization"); got != wantAuth {
+			t.Errorf("expected proxy credentials %q, got %q", wantAuth, got)
+		}
+	}
+}
+
+func TestTelegramClientsDownloadSettings(t *testing.T) {
+	cfg := config.Default()
+	cfg.DownloadTimeoutSeconds = 30
+	cfg.DownloadMaxIdleConns = 2
+
+	clients, err := newTelegramClients(cfg)
+	if err != nil {
+		t.Fatalf("newTelegramClients failed: %v", err)
+	}
+	if clients.api.Timeout != apiRequestTimeout {
+		t.Errorf("expected API timeout %v, got %v", apiRequestTimeout, clients.api.Timeout)
+	}
+	if clients.download.Timeout != 30*time.Second {
+		t.Errorf("expected download timeout 30s, got %v", clients.download.Timeout)
+	}
+	transport := clients.download.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 2 || transport.TLSHandshakeTimeout != 10*time.Second {
+		t.Errorf("unexpected download transport: idle=%d tls_timeout=%v", transport.MaxIdleConnsPerHost, transport.TLSHandshakeTimeout)
+	}
+}
+
+func TestTelegramClientsDownloadCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("file"))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.DownloadCAFile = caFile
+	clients, err := newTelegramClients(cfg)
+	if err != nil {
+		t.Fatalf("newTelegramClients failed: %v", err)
+	}
+	response, err := clients.download.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the extra CA to be trusted: %v", err)
+	}
+	response.Body.Close()
+
+	cfg.DownloadCAFile = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := newTelegramClients(cfg); err == nil {
+		t.Error("expected error for a missing CA file")
+	}
+}
+
+func TestDownloadTelegramFile_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	api := &downloadLinkTelegram{FakeTelegram: testutil.NewFakeTelegram(), baseURL: server.URL}
+	api.Files["slow"] = &models.File{FileID: "slow", FilePath: "documents/slow.pdf"}
+	client := &http.Client{Timeout: 200 * time.Millisecond}
+	backend := storage.NewLocalBackend(t.TempDir())
+
+	start := time.Now()
+	if _, err := downloadTelegramFile(context.Background(), api, client, "", backend, "alice/slow", "slow"); err == nil {
+		t.Fatal("expected stuck download to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected download to give up after the timeout, took %v", elapsed)
+	}
+}
+
+// downloadLinkTelegram serves file downloads from a test server
+type downloadLinkTelegram struct {
+	*testutil.FakeTelegram
+	baseURL string
+}
+
+func (d *downloadLinkTelegram) FileDownloadLink(file *models.File) string {
+	return d.baseURL + "/" + file.FilePath
+}
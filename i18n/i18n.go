@@ -0,0 +1,124 @@
+// Package i18n translates user-facing bot strings.
+//
+// Messages are keyed by their English text, gettext style: English needs no
+// catalog and any message missing from a catalog falls back to English.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported languages
+const (
+	English = "en"
+	Chinese = "zh"
+)
+
+// DefaultLanguage is used when the user's language is unknown or unsupported
+const DefaultLanguage = English
+
+// catalogs maps a language code to its translations; nil means identity
+var catalogs = map[string]map[string]string{
+	English: nil,
+	Chinese: zhCatalog,
+}
+
+// languageNames are shown to users when choosing a language
+var languageNames = map[string]string{
+	English: "English",
+	Chinese: "中文",
+}
+
+// Translator translates messages into one language and formats dates in one time zone
+type Translator struct {
+	lang     string
+	catalog  map[string]string
+	location *time.Location
+}
+
+// New returns a translator for lang. Unsupported languages get English.
+func New(lang string) *Translator {
+	code, ok := Normalize(lang)
+	if !ok {
+		code = DefaultLanguage
+	}
+	return &Translator{lang: code, catalog: catalogs[code], location: time.UTC}
+}
+
+// In returns a copy of t that formats dates in loc
+func (t *Translator) In(loc *time.Location) *Translator {
+	in := *t
+	in.location = loc
+	return &in
+}
+
+// Location returns the time zone t formats dates in, UTC unless set with In
+func (t *Translator) Location() *time.Location {
+	return t.location
+}
+
+// Language returns the language code of the translator
+func (t *Translator) Language() string {
+	return t.lang
+}
+
+// T translates msg
+func (t *Translator) T(msg string) string {
+	if translated, ok := t.catalog[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Sprintf translates format and formats it with args
+func (t *Translator) Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(t.T(format), args...)
+}
+
+// FormatTime formats tm in the translator's time zone with layout, an English
+// reference layout such as "Jan 2" that catalogs translate like any message
+func (t *Translator) FormatTime(tm time.Time, layout string) string {
+	return tm.In(t.location).Format(t.T(layout))
+}
+
+// Normalize maps a Telegram language code such as "zh-hans" to a supported language.
+// It reports false when the language is not supported.
+func Normalize(code string) (string, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	_, ok := catalogs[code]
+	return code, ok
+}
+
+// Supported returns the supported language codes in display order
+func Supported() []string {
+	return []string{English, Chinese}
+}
+
+// LanguageName returns the native name of a supported language
+func LanguageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+type contextKey struct{}
+
+// WithTranslator returns a context carrying t
+func WithTranslator(ctx context.Context, t *Translator) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the translator stored in ctx, or an English translator
+func FromContext(ctx context.Context) *Translator {
+	if t, ok := ctx.Value(contextKey{}).(*Translator); ok && t != nil {
+		return t
+	}
+	return New(DefaultLanguage)
+}
@@ -0,0 +1,253 @@
+package i18n
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+		ok       bool
+	}{
+		{"en", English, true},
+		{"EN-us", English, true},
+		{"zh-hans", Chinese, true},
+		{"zh_TW", Chinese, true},
+		{"de", "de", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Normalize(tt.code)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("Normalize(%q) = (%q, %v), want (%q, %v)", tt.code, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	zh := New("zh-hans")
+	if zh.Language() != Chinese {
+		t.Fatalf("expected zh translator, got %q", zh.Language())
+	}
+	if got := zh.Sprintf("✅ Opened new session: %s", "Plan"); got != "✅ 已打开新会话：Plan" {
+		t.Errorf("unexpected translation %q", got)
+	}
+	if got := zh.T("not in any catalog"); got != "not in any catalog" {
+		t.Errorf("expected missing translation to fall back to English, got %q", got)
+	}
+
+	if got := New("fr"); got.Language() != English {
+		t.Errorf("expected unsupported language to fall back to English, got %q", got.Language())
+	}
+	if got := New("en").Sprintf("Page %d/%d", 2, 5); got != "Page 2/5" {
+		t.Errorf("expected English to format the message unchanged, got %q", got)
+	}
+}
+
+func TestTranslatorFormatTime(t *testing.T) {
+	tm := time.Date(2025, 6, 1, 23, 30, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("LoadLocation failed: %v", err)
+	}
+
+	if got := New(English).FormatTime(tm, "Jan 2"); got != "Jun 1" {
+		t.Errorf("expected UTC by default, got %q", got)
+	}
+	if got := New(English).In(tokyo).FormatTime(tm, "Jan 2, 2006 15:04"); got != "Jun 2, 2025 08:30" {
+		t.Errorf("expected the date in Tokyo, got %q", got)
+	}
+	if got := New(Chinese).In(tokyo).FormatTime(tm, "Jan 2"); got != "6月2日" {
+		t.Errorf("expected a Chinese date in Tokyo, got %q", got)
+	}
+
+	en := New(English)
+	if en.In(tokyo); en.Location() != time.UTC {
+		t.Errorf("expected In to leave the original translator unchanged, got %s", en.Location())
+	}
+}
+
+func TestContext(t *testing.T) {
+	if got := FromContext(context.Background()); got.Language() != DefaultLanguage {
+		t.Errorf("expected default translator without context value, got %q", got.Language())
+	}
+
+	ctx := WithTranslator(context.Background(), New(Chinese))
+	if got := FromContext(ctx); got.Language() != Chinese {
+		t.Errorf("expected translator from context, got %q", got.Language())
+	}
+}
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// verbs returns the formatting verbs of a message in order
+func verbs(s string) []string {
+	var result []string
+	for _, verb := range verbPattern.FindAllString(s, -1) {
+		if verb != "%%" {
+			result = append(result, verb[len(verb)-1:])
+		}
+	}
+	return result
+}
+
+func TestCatalogVerbsMatch(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for msg, translated := range catalog {
+			if !slices.Equal(verbs(msg), verbs(translated)) {
+				t.Errorf("%s: verbs of %q do not match %q", lang, translated, msg)
+			}
+		}
+	}
+}
+
+// TestCatalogComplete checks that every message the bot translates has a
+// translation in each catalog. Messages are found by scanning the sources for
+// tr.T / tr.Sprintf calls and ErrorResponse literals.
+func TestCatalogComplete(t *testing.T) {
+	messages := make(map[string]token.Position)
+	for _, dir := range []string{"..", "../handlers"} {
+		collectMessages(t, dir, messages)
+	}
+	if len(messages) == 0 {
+		t.Fatal("no translated messages found in sources")
+	}
+
+	for lang, catalog := range catalogs {
+		if catalog == nil {
+			continue
+		}
+		for msg, pos := range messages {
+			if _, ok := catalog[msg]; !ok {
+				t.Errorf("%s: missing translation for %q (%s)", lang, msg, pos)
+			}
+		}
+	}
+}
+
+// collectMessages adds the translated message literals of the Go files in dir
+func collectMessages(t *testing.T, dir string, messages map[string]token.Position) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatalf("glob %s: %v", dir, err)
+	}
+
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+		parsed = append(parsed, file)
+	}
+
+	// String constants, so tr.T(someConst) is checked too
+	consts := make(map[string]string)
+	for _, file := range parsed {
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.ValueSpec)
+			if !ok {
+				return true
+			}
+			for i, name := range spec.Names {
+				if i < len(spec.Values) {
+					if value, ok := stringLiteral(spec.Values[i]); ok {
+						consts[name.Name] = value
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	add := func(expr ast.Expr) {
+		value, ok := stringLiteral(expr)
+		if !ok {
+			if ident, isIdent := expr.(*ast.Ident); isIdent {
+				value, ok = consts[ident.Name]
+			}
+		}
+		if ok && value != "" {
+			messages[value] = fset.Position(expr.Pos())
+		}
+	}
+
+	for _, file := range parsed {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.CallExpr:
+				if index, ok := translatedArg(node); ok && len(node.Args) > index {
+					add(node.Args[index])
+				}
+			case *ast.CompositeLit:
+				if ident, ok := node.Type.(*ast.Ident); ok && ident.Name == "ErrorResponse" {
+					for _, elt := range node.Elts {
+						if kv, ok := elt.(*ast.KeyValueExpr); ok {
+							if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Message" {
+								add(kv.Value)
+							}
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// translatedArg matches tr.T(...), tr.Sprintf(...), tr.FormatTime(...) and
+// i18n.FromContext(ctx).T(...), returning the index of the translated argument
+func translatedArg(call *ast.CallExpr) (int, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return 0, false
+	}
+	index := 0
+	switch sel.Sel.Name {
+	case "T", "Sprintf":
+	case "FormatTime":
+		index = 1
+	default:
+		return 0, false
+	}
+
+	switch x := sel.X.(type) {
+	case *ast.Ident:
+		return index, x.Name == "tr"
+	case *ast.CallExpr:
+		inner, ok := x.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return 0, false
+		}
+		pkg, ok := inner.X.(*ast.Ident)
+		return index, ok && pkg.Name == "i18n" && inner.Sel.Name == "FromContext"
+	}
+	return 0, false
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
@@ -0,0 +1,350 @@
+package i18n
+
+// zhCatalog holds the Simplified Chinese translations
+var zhCatalog = map[string]string{
+	// Session list and pagination
+	"⬆️ Prev":    "⬆️ 上一页",
+	"⬇️ Next":    "⬇️ 下一页",
+	"⏮ First":    "⏮ 首页",
+	"Last ⏭":     "末页 ⏭",
+	"Page %d/%d": "第 %d/%d 页",
+	"Your sessions — no sessions on this page":     "你的会话 — 本页没有会话",
+	"Your sessions — page %d, showing %d–%d of %d": "你的会话 — 第 %d 页，显示第 %d–%d 个，共 %d 个",
+	"☑️ Select":     "☑️ 多选",
+	"☑️ Select all": "☑️ 全选",
+	"🗄 Archive":     "🗄 归档",
+	"🗑 Delete":      "🗑 删除",
+	"Select sessions on this page, then archive or delete them":      "选择本页的会话，然后归档或删除",
+	"Select at least one session first.":                             "请先至少选择一个会话。",
+	"🗄 Archived %d session(s)":                                       "🗄 已归档 %d 个会话",
+	"🗑 Deleted %d session(s)":                                        "🗑 已删除 %d 个会话",
+	"You don't have any sessions yet. Start chatting to create one!": "你还没有任何会话。发送消息即可创建一个！",
+	"⌛ This menu expired. Send /sessions to get a fresh one.":        "⌛ 此菜单已过期。发送 /sessions 获取新的菜单。",
+
+	// Relative times
+	"just now": "刚刚",
+	"%dm ago":  "%d 分钟前",
+	"%dh ago":  "%d 小时前",
+	"%dd ago":  "%d 天前",
+	"%d msgs":  "%d 条消息",
+	"%d files": "%d 个文件",
+	"Jan 2":    "1月2日",
+
+	// Session commands and buttons
+	"✅ Opened new session: %s":                                          "✅ 已打开新会话：%s",
+	"✅ Switched to session: %s":                                         "✅ 已切换到会话：%s",
+	"✅ Reopened session: %s":                                            "✅ 已重新打开会话：%s",
+	"✅ Renamed session to: %s":                                          "✅ 会话已重命名为：%s",
+	"Message received in session: %s":                                   "消息已收到，所在会话：%s",
+	"No active session to close. Use /open to start one.":               "没有可关闭的活动会话。使用 /open 开始一个。",
+	"✅ Closed session: %s\nYour next message will start a new session.": "✅ 已关闭会话：%s\n你的下一条消息将开始一个新会话。",
+	"↩️ Reopen last session":                                            "↩️ 重新打开上一个会话",
+	"➕ Start new session":                                               "➕ 开始新会话",
+	"🆕 New session":                                                     "🆕 新会话",
+	"📋 Sessions":                                                        "📋 会话列表",
+	"No active session to delete. Use /sessions to pick one first.":     "没有可删除的活动会话。请先使用 /sessions 选择一个。",
+	"🗑 Deleted session: %s":                                             "🗑 已删除会话：%s",
+	"\nDeleted %d attached file(s)":                                     "\n已删除 %d 个附件",
+	", %d could not be removed from storage":                            "，其中 %d 个无法从存储中移除",
+
+	// Conversation flows
+	"merge":                              "合并",
+	"rename":                             "重命名",
+	"Nothing to cancel.":                 "没有需要取消的操作。",
+	"❎ Cancelled the pending %s prompt.": "❎ 已取消待处理的%s操作。",
+	"No active session to rename. Use /sessions to pick one first.":                   "没有可重命名的活动会话。请先使用 /sessions 选择一个。",
+	"✏️ Send the new title for \"%s\", or /cancel to keep it.":                        "✏️ 请发送“%s”的新标题，或发送 /cancel 保留原标题。",
+	"The title can't be empty. Send a new title, or /cancel to keep the current one.": "标题不能为空。请发送新标题，或发送 /cancel 保留当前标题。",
+
+	// Files and stickers
+	"You don't have any files yet. Send me a photo or document to store one!": "你还没有任何文件。发送照片或文档即可保存！",
+	"Your files:":        "你的文件：",
+	"📄 %s":               "📄 %s",
+	"Type: %s":           "类型：%s",
+	"Size: %s":           "大小：%s",
+	"Received: %s":       "接收时间：%s",
+	"MIME type: %s":      "MIME 类型：%s",
+	"🗑 Deleted file: %s": "🗑 已删除文件：%s",
+	"You haven't sent me any stickers from a sticker set yet.": "你还没有发送过来自贴纸包的贴纸。",
+	"🎨 Your recent sticker sets:\n":                            "🎨 你最近使用的贴纸包：\n",
+	"\n%d. %s · %d sticker(s)":                                 "\n%d. %s · %d 个贴纸",
+	" · custom emoji":                                          " · 自定义表情",
+	"OK":                                                       "好的",
+	"OK (album: %d of %d files saved)":                         "好的（相册：已保存 %d/%d 个文件）",
+	"⏸ You sent too many files in a short time. Files are not saved for the next %d minute(s).":           "⏸ 你在短时间内发送了太多文件。接下来 %d 分钟内的文件将不会被保存。",
+	"🚨 User %d sent more than %d files within %d second(s). Their downloads are paused for %d minute(s).": "🚨 用户 %d 发送了超过 %d 个文件（%d 秒内），其下载已暂停 %d 分钟。",
+	"📄 %s: no text found":                              "📄 %s：未找到文本",
+	"📄 %s added to session \"%s\": %d words, %d lines": "📄 %s 已添加到会话“%s”：%d 个词，%d 行",
+	" (truncated)":                                     "（已截断）",
+
+	// Errors
+	"Session not found. It may have been deleted.":                         "未找到会话，它可能已被删除。",
+	"You don't have permission to access this session.":                    "你无权访问此会话。",
+	"File not found. It may have been deleted.":                            "未找到文件，它可能已被删除。",
+	"This command is only available to bot administrators.":                "此命令仅限机器人管理员使用。",
+	"This command is only available to bot moderators and administrators.": "此命令仅限机器人版主和管理员使用。",
+	"An error occurred. Please try again.":                                 "发生错误，请重试。",
+
+	// Forget me
+	"⚠️ This permanently deletes all your sessions, messages, downloaded files and settings. It cannot be undone.\n\nDelete everything?": "⚠️ 这将永久删除你的所有会话、消息、已下载的文件和设置，且无法撤销。\n\n确定全部删除吗？",
+	"🗑 Yes, delete everything": "🗑 是的，全部删除",
+	"Cancel":                   "取消",
+	"Only the user who sent /forgetme can answer this.":                           "只有发送 /forgetme 的用户可以回答。",
+	"Nothing was deleted.":                                                        "未删除任何内容。",
+	"Now reply with the number of the session to merge %s into, or /cancel.":      "现在请回复要将 %s 合并到的会话编号，或发送 /cancel。",
+	"A session can't be merged into itself. Pick a different number, or /cancel.": "会话不能合并到自身。请选择其他编号，或发送 /cancel。",
+	"✅ Merged %s into %s: moved %d messages and %d files.":                        "✅ 已将 %s 合并到 %s：移动了 %d 条消息和 %d 个文件。",
+	"Send a number from 1 to %d, or /cancel.":                                     "请发送 1 到 %d 之间的数字，或发送 /cancel。",
+	"You need at least two sessions to merge.":                                    "至少需要两个会话才能合并。",
+	"🔀 Which session should be merged into another one? Its messages move over and it is deleted. Reply with its number, or /cancel.": "🔀 要将哪个会话合并到另一个会话？它的消息会被移过去，随后它会被删除。请回复其编号，或发送 /cancel。",
+	"No active session to share. Use /sessions to pick one first.":                                                                    "没有可分享的活动会话。请先使用 /sessions 选择一个。",
+	"🔒 Revoked %d share link(s) of %s.":       "🔒 已撤销 %d 个分享链接：%s。",
+	"🔗 Read-only link to %s, valid until %s:": "🔗 %s 的只读链接，有效期至 %s：",
+	"Web: %s": "网页：%s",
+	"Anyone with the link can read this session. Use /share revoke to disable its links.": "任何拥有链接的人都可以阅读此会话。使用 /share revoke 停用其链接。",
+	"❌ This share link is not valid.":                                                     "❌ 此分享链接无效。",
+	"❌ This share link has expired or was revoked.":                                       "❌ 此分享链接已过期或已被撤销。",
+	"📖 Shared session (read-only): %s":                                                    "📖 分享的会话（只读）：%s",
+	"This session has no messages yet.":                                                   "此会话还没有消息。",
+	"🐢 Slow down! You are sending messages too fast. Try again in %d s.":                  "🐢 慢一点！你发送消息太快了，请 %d 秒后再试。",
+	"⏳ You can use %s again in %s.":                                                       "⏳ %s 需要等待 %s 后才能再次使用。",
+	"%d s":                                                                                "%d 秒",
+	"%d min":                                                                              "%d 分钟",
+	"%d min %d s":                                                                         "%d 分 %d 秒",
+	"%d h":                                                                                "%d 小时",
+	"%d h %d min":                                                                         "%d 小时 %d 分钟",
+	"✅ Your data was deleted.":                                                            "✅ 你的数据已删除。",
+	"Sessions: %d\nMessages: %d\nFiles: %d\nOther records: %d":                            "会话：%d\n消息：%d\n文件：%d\n其他记录：%d",
+	"%d file(s) could not be removed from storage":                                        "%d 个文件无法从存储中删除",
+
+	// Admin
+	"Unknown admin command: %s\n\n%s":                   "未知的管理命令：%s\n\n%s",
+	"❌ /admin %s failed: %v":                            "❌ /admin %s 失败：%v",
+	"Available admin commands:\n":                       "可用的管理命令：\n",
+	"Usage: /admin backup now":                          "用法：/admin backup now",
+	"💾 Backup saved to %s (%s)":                         "💾 备份已保存到 %s（%s）",
+	"Usage: /admin purge <user_id>":                     "用法：/admin purge <用户ID>",
+	"🗑 Purged user %d":                                  "🗑 已清除用户 %d 的数据",
+	"Usage: /admin referrals <code>":                    "用法：/admin referrals <代码>",
+	"Runtime settings:":                                 "运行时设置：",
+	"Usage: /admin set <key> <value|default>":           "用法：/admin set <键> <值|default>",
+	"%s = %s (default %s)":                              "%s = %s（默认 %s）",
+	"❌ %v":                                              "❌ %v",
+	"Referral code %s: %d users":                        "推荐码 %s：%d 位用户",
+	"🚧 Cleanup is paused while maintenance mode is on.": "🚧 维护模式开启期间清理已暂停。",
+	"🧹 Cleanup finished\nFiles deleted: %d\nReclaimed: %s\nStored: %s\nFailures: %d":  "🧹 清理完成\n已删除文件：%d\n已回收：%s\n已存储：%s\n失败：%d",
+	"🛠 Maintenance finished\nDatabase size: %s (was %s)\nLog frames checkpointed: %d": "🛠 维护完成\n数据库大小：%s（之前 %s）\n已写回的日志帧：%d",
+	"The write-ahead log is still in use and was not truncated.":                      "预写日志仍在使用中，未被截断。",
+	"Usage: /admin maintenance [on|off]":                                              "用法：/admin maintenance [on|off]",
+	"Usage: /admin transfer <session_id> <user_id>":                                   "用法：/admin transfer <会话ID> <用户ID>",
+	"❌ Session %s not found":                                                          "❌ 未找到会话 %s",
+	"Session %s already belongs to user %d":                                           "会话 %s 已属于用户 %d",
+	"🔀 Session \"%s\" moved from user %d to user %d with %d messages and %d files":    "🔀 会话“%s”已从用户 %d 转移给用户 %d，包含 %d 条消息和 %d 个文件",
+	"%d share links of the previous owner were revoked":                               "原所有者的 %d 个分享链接已撤销",
+	"Usage: /admin audit [action|user_id]":                                            "用法：/admin audit [操作|用户ID]",
+	"No audit entries found":                                                          "没有找到审计记录",
+	"📜 Latest %d audit entries:":                                                      "📜 最近 %d 条审计记录：",
+	"Usage: /admin role [<user_id> [admin|moderator|user]]":                           "用法：/admin role [<用户ID> [admin|moderator|user]]",
+	"👥 Roles:":               "👥 角色：",
+	"%d: admin (configured)": "%d：admin（配置指定）",
+	"User %d is %s":          "用户 %d 的角色是 %s",
+	"User %d is an administrator in admin_user_ids; change the configuration instead": "用户 %d 是 admin_user_ids 中的管理员，请改为修改配置",
+	"✅ User %d is now %s":                              "✅ 用户 %d 现在的角色是 %s",
+	"Usage: /admin sessions <user_id>":                 "用法：/admin sessions <用户ID>",
+	"User %d has no sessions":                          "用户 %d 没有会话",
+	"📋 Sessions of user %d:":                           "📋 用户 %d 的会话：",
+	"…and more":                                        "……还有更多",
+	"Usage: /admin ban <user_id> [--notify] [reason]":  "用法：/admin ban <用户ID> [--notify] [原因]",
+	"User %d is an administrator and cannot be banned": "用户 %d 是管理员，无法封禁",
+	"⛔ User %d is banned":                              "⛔ 用户 %d 已被封禁",
+	"The user could not be notified.":                  "无法通知该用户。",
+	"⛔ You have been banned from this bot.":            "⛔ 你已被禁止使用此机器人。",
+	"Reason: %s":                                       "原因：%s",
+	"No users are banned":                              "没有被封禁的用户",
+	"⛔ Banned users:":                                  "⛔ 已封禁的用户：",
+	"Usage: /admin unban <user_id>":                    "用法：/admin unban <用户ID>",
+	"User %d is not banned":                            "用户 %d 未被封禁",
+	"✅ User %d is no longer banned":                    "✅ 已解除对用户 %d 的封禁",
+	"🚧 Maintenance mode on: users get a maintenance notice and nothing is saved. Turn it off with /admin maintenance off.": "🚧 维护模式已开启：用户会收到维护通知，且不会保存任何内容。使用 /admin maintenance off 关闭。",
+	"✅ Maintenance mode off: the bot answers users again.":                                                                 "✅ 维护模式已关闭：机器人恢复回复用户。",
+	"🚧 The bot is under maintenance and cannot save anything right now. Please try again later.":                           "🚧 机器人正在维护，暂时无法保存任何内容。请稍后再试。",
+	"Usage: /admin integrity [repair]": "用法：/admin integrity [repair]",
+	"✅ No integrity problems found":    "✅ 未发现完整性问题",
+	"🩺 Integrity check\nOrphaned session bindings: %d\nSessions with invalid IDs: %d\nFiles attached to missing sessions: %d": "🩺 完整性检查\n孤立的会话绑定：%d\nID 无效的会话：%d\n关联到不存在会话的文件：%d",
+	"All of them were repaired.":               "已全部修复。",
+	"Run /admin integrity repair to fix them.": "运行 /admin integrity repair 进行修复。",
+
+	// Language
+	"🌐 Language set to %s.":                          "🌐 语言已设置为%s。",
+	"🌐 Language now follows your Telegram app (%s).": "🌐 语言现在跟随你的 Telegram 应用（%s）。",
+	"Current language: %s\nAvailable: %s\nUse /language <code> to switch, or /language auto to follow your Telegram app.": "当前语言：%s\n可用语言：%s\n使用 /language <代码> 切换，或使用 /language auto 跟随你的 Telegram 应用。",
+	"Unknown language: %s\nAvailable: %s": "未知语言：%s\n可用语言：%s",
+
+	// Inline mode
+	"💬 Session: %s\nLast active: %s": "💬 会话：%s\n最近活跃：%s",
+
+	// Deep links
+	"👋 Welcome! Just send a message to start a session.\nUse /sessions to see your sessions or /open to start a new one.": "👋 欢迎！直接发送消息即可开始会话。\n使用 /sessions 查看你的会话，或使用 /open 开始新会话。",
+
+	// Diagnostics
+	"🪪 Who am I":                           "🪪 我是谁",
+	"User ID: %s":                          "用户 ID：%s",
+	"Chat ID: %s":                          "聊天 ID：%s",
+	"Language: %s":                         "语言：%s",
+	"Active session: %s %s":                "当前会话：%s %s",
+	"Active session: none":                 "当前会话：无",
+	"Sessions: %d":                         "会话数：%d",
+	"Storage: %s used, %s remaining of %s": "存储：已用 %s，剩余 %s，共 %s",
+	"Storage: %s used (no quota)":          "存储：已用 %s（无配额）",
+	"Bot version: %s":                      "机器人版本：%s",
+
+	// Statistics
+	"📊 No statistics yet. Start chatting to create your first session!": "📊 暂无统计数据。开始聊天来创建你的第一个会话吧！",
+	"Jan 2, 2006":                   "2006年1月2日",
+	"Jan 2, 2006 15:04":             "2006年1月2日 15:04",
+	"📊 Your statistics":             "📊 你的统计",
+	"Messages: %d":                  "消息数：%d",
+	"Files: %d (%s)":                "文件数：%d（%s）",
+	"Oldest session: %s · %s":       "最早的会话：%s · %s",
+	"Newest session: %s · %s":       "最新的会话：%s · %s",
+	"Busiest day: %s (%d messages)": "最活跃的一天：%s（%d 条消息）",
+	"🔌 The bot was disconnected from your business account.":                                                                                                                             "🔌 机器人已与你的企业账号断开连接。",
+	"💼 The bot is now your business assistant. Each customer chat gets its own session, and customers get a reply right away.":                                                           "💼 机器人现在是你的企业助手。每个客户聊天都有独立的会话，客户会立即收到回复。",
+	"💼 The bot is connected to your business account but may not reply. Customer chats are still recorded in sessions; allow replies in Telegram Business settings to answer customers.": "💼 机器人已连接到你的企业账号，但无权回复。客户聊天仍会记录到会话中；在 Telegram Business 设置中允许回复即可答复客户。",
+	"No channels tracked yet. Enable archive_channel_posts and add the bot to a channel as an administrator.":                                                                            "尚未跟踪任何频道。请启用 archive_channel_posts 并将机器人添加为频道管理员。",
+	"📣 Tracked channels:":         "📣 已跟踪的频道：",
+	"%s · %d · %d posts, last %s": "%s · %d · %d 条帖子，最近 %s",
+	"Usage: /feedback <text>\nTell us what works, what doesn't, or what you'd like to see.": "用法：/feedback <内容>\n告诉我们哪些好用、哪些不好用，或者你希望看到什么。",
+	"Feedback is limited to %d characters. Please shorten it and try again.":                "反馈最多 %d 个字符，请缩短后重试。",
+	"🙏 Thanks for your feedback!":                                                           "🙏 感谢你的反馈！",
+	"⌛ This menu expired. Send /admin feedback to get a fresh one.":                         "⌛ 此菜单已过期。发送 /admin feedback 获取新的菜单。",
+	"✅ Feedback #%d resolved":                                                               "✅ 反馈 #%d 已处理",
+	"Feedback #%d was already resolved":                                                     "反馈 #%d 已被处理",
+	"📭 No open feedback.":                                                                   "📭 没有待处理的反馈。",
+	"📝 Open feedback: %d":                                                                   "📝 待处理的反馈：%d",
+	"✅ Resolve #%d":                                                                         "✅ 处理 #%d",
+	"#%d · user %d · %s":                                                                    "#%d · 用户 %d · %s",
+	"session %s":                                                                            "会话 %s",
+	"⌛ This menu expired. Send /settings to get a fresh one.":                               "⌛ 此菜单已过期。发送 /settings 获取新的菜单。",
+	"🌐 Choose a language:":                                                                  "🌐 选择语言：",
+	"🤖 Choose an AI model:":                                                                 "🤖 选择 AI 模型：",
+	"Auto (%s)":                                                                             "自动（%s）",
+	"🌐 Language: %s":                                                                        "🌐 语言：%s",
+	"🤖 AI model: %s":                                                                        "🤖 AI 模型：%s",
+	"📥 Auto-download: %s":                                                                   "📥 自动下载：%s",
+	"🔔 Notifications: %s":                                                                   "🔔 通知：%s",
+	"⚙️ Settings\nTap a setting to change it.":                                              "⚙️ 设置\n点击某项设置即可修改。",
+	"Auto (Telegram app language)":                                                          "自动（Telegram 应用语言）",
+	"« Back":                                                                                "« 返回",
+	"on":                                                                                    "开",
+	"off":                                                                                   "关",
+	"Quiet hours: %s":                                                                       "免打扰时段：%s",
+	"Use /quiet 22:00-07:00 Europe/Berlin to hold back notifications at night, or /quiet off to receive them any time.": "使用 /quiet 22:00-07:00 Asia/Shanghai 在夜间暂缓通知，或使用 /quiet off 随时接收通知。",
+	"Unknown time zone: %s\nUse a name such as Europe/Berlin or America/New_York.":                                      "未知时区：%s\n请使用 Asia/Shanghai 或 America/New_York 这样的名称。",
+	"🔔 Quiet hours are off. Notifications are delivered right away.":                                                    "🔔 免打扰已关闭，通知将立即送达。",
+	"🌙 Quiet hours set to %s. Notifications in that window are delivered when it ends.":                                 "🌙 免打扰时段已设为 %s。此时段内的通知将在结束后送达。",
+	"🌙 Quiet hours: %s":                             "🌙 免打扰：%s",
+	"🕒 Time zone: %s":                               "🕒 时区：%s",
+	"🕒 Time zone: %s (now %s)":                      "🕒 时区：%s（当前时间 %s）",
+	"🕒 Time zone set to %s. Your local time is %s.": "🕒 时区已设置为 %s。你的当地时间是 %s。",
+	"Use /timezone Europe/Berlin to see dates in your time zone, or /timezone UTC to go back to the default.": "使用 /timezone Asia/Shanghai 按你的时区显示日期，或使用 /timezone UTC 恢复默认。",
+
+	// Session expiry
+	"⏳ %d idle session(s) will be archived in %d hour(s). Press a session to keep it.": "⏳ %d 个闲置会话将在 %d 小时后归档。点按会话即可保留。",
+	"📌 Kept %s. It will not be archived for now.":                                      "📌 已保留 %s，暂时不会被归档。",
+
+	// AI assistant
+	"🤖 The assistant is unavailable right now. Your message is saved, please try again later.": "🤖 助手暂时不可用。你的消息已保存，请稍后再试。",
+	"🚫 Your message was blocked by the content filter.":                                        "🚫 你的消息已被内容过滤器拦截。",
+	"🚫 The assistant's reply was withheld by the content filter.":                              "🚫 助手的回复已被内容过滤器拦截。",
+	"⌛ This menu expired. Send /admin flagged to get a fresh one.":                             "⌛ 此菜单已过期。发送 /admin flagged 获取新的菜单。",
+	"📄 Send code as file":                                    "📄 以文件发送代码",
+	"📄 Send %d code blocks as files":                         "📄 以文件发送 %d 个代码块",
+	"⌛ This button expired.":                                 "⌛ 此按钮已过期。",
+	"This reply is no longer available.":                     "此回复已不可用。",
+	"Only the latest reply of a session can be regenerated.": "只能重新生成会话中的最新回复。",
+	"⏳ Generating a reply…":                                  "⏳ 正在生成回复…",
+	"⏹ Stop":                                                 "⏹ 停止",
+	"⏹ Stopping…":                                            "⏹ 正在停止…",
+	"⏹ Reply stopped.":                                       "⏹ 回复已停止。",
+	"⚠️ The reply could not be delivered. It is saved in the session.": "⚠️ 回复未能送达，已保存在会话中。",
+	"⬇️ The reply was sent as a new message.":                          "⬇️ 回复已作为新消息发送。",
+	"There is no reply in progress to stop.":                           "没有正在生成的回复可以停止。",
+	"🔁 Regenerate":                                                     "🔁 重新生成",
+	"🔁 Regenerating…":                                                  "🔁 正在重新生成…",
+	"✅ Flagged #%d reviewed":                                           "✅ 标记内容 #%d 已审核",
+	"Flagged #%d was already reviewed":                                 "标记内容 #%d 已被审核",
+	"📭 No flagged content to review.":                                  "📭 没有待审核的标记内容。",
+	"🚩 Flagged content to review: %d":                                  "🚩 待审核的标记内容：%d",
+	"✅ Reviewed #%d":                                                   "✅ 已审核 #%d",
+	"user %d":                                                          "用户 %d",
+	"assistant reply to user %d":                                       "助手回复用户 %d",
+	"#%d · %s · %s · %s":                                               "#%d · %s · %s · %s",
+	"Matched: %s":                                                      "匹配：%s",
+
+	// /memory
+	"Usage:\n/memory - show what the active session remembers\n/memory set <key>=<value> - remember a value, e.g. /memory set name=Bob\n/memory delete <key> - forget a value\n/memory clear - forget everything": "用法：\n/memory - 查看当前会话记住的内容\n/memory set <键>=<值> - 记住一个值，例如 /memory set name=Bob\n/memory delete <键> - 忘记一个值\n/memory clear - 忘记全部内容",
+	"No active session. Send a message to start one, then use /memory.":                     "没有活动会话。请先发送一条消息开始会话，再使用 /memory。",
+	"Keys are up to %d letters, digits, '_', '-' or '.'.":                                   "键最多 %d 个字符，只能包含字母、数字、'_'、'-' 或 '.'。",
+	"Values are limited to %d characters.":                                                  "值最多 %d 个字符。",
+	"This session already remembers %d values. Delete one with /memory delete <key> first.": "此会话已记住 %d 个值。请先用 /memory delete <键> 删除一个。",
+	"🧠 Remembered %s":              "🧠 已记住 %s",
+	"Nothing is remembered as %s.": "没有记住名为 %s 的值。",
+	"🗑 Forgot %s":                  "🗑 已忘记 %s",
+	"🗑 Forgot %d values.":          "🗑 已忘记 %d 个值。",
+	"🧠 %s remembers nothing yet.":  "🧠 %s 还没有记住任何内容。",
+	"🧠 Memory of %s (%d/%d):":      "🧠 %s 的记忆（%d/%d）：",
+
+	// /summary
+	"The AI assistant is not configured, so sessions cannot be summarized.": "AI 助手未配置，无法总结会话。",
+	"Usage: /summary [pin]": "用法：/summary [pin]",
+	"No active session. Send a message to start one, then use /summary.": "没有活动会话。请先发送一条消息开始会话，再使用 /summary。",
+	"This session has no messages to summarize yet.":                     "此会话还没有可总结的消息。",
+	"🤖 The assistant is unavailable right now, please try again later.":  "🤖 助手暂时不可用，请稍后再试。",
+	"📝 Summary of %s": "📝 %s 的总结",
+	"The summary could not be pinned. In groups the bot needs the right to pin messages.": "无法置顶总结。在群组中，机器人需要置顶消息的权限。",
+
+	// /translate
+	"The AI assistant is not configured, so messages cannot be translated.":                         "AI 助手未配置，无法翻译消息。",
+	"Usage: reply to a message with /translate <language>, e.g. /translate en or /translate French": "用法：回复一条消息并发送 /translate <语言>，例如 /translate en 或 /translate French",
+	"The replied message has no text to translate.":                                                 "所回复的消息没有可翻译的文本。",
+	"Messages of up to %d characters can be translated.":                                            "只能翻译最多 %d 个字符的消息。",
+
+	// /compose and /send
+	"📝 You are composing already: %d message(s) so far. /send sends them, /compose cancel discards them.":                                               "📝 你已在撰写中：目前有 %d 条消息。/send 发送它们，/compose cancel 丢弃它们。",
+	"📝 Compose mode: your next messages are collected instead of answered. Send /send to send them as one message, or /compose cancel to discard them.": "📝 撰写模式：你接下来的消息会被收集而不会被回答。发送 /send 将它们作为一条消息发送，或发送 /compose cancel 丢弃它们。",
+	"You are not composing a message.":                                                    "你没有在撰写消息。",
+	"🗑 Draft discarded.":                                                                  "🗑 草稿已丢弃。",
+	"Usage: /compose [cancel]":                                                            "用法：/compose [cancel]",
+	"Nothing to send. Start a draft with /compose.":                                       "没有可发送的内容。使用 /compose 开始草稿。",
+	"Your draft was started in another chat; send /send there.":                           "你的草稿是在另一个聊天中开始的；请在那里发送 /send。",
+	"Your draft is empty. Write your messages first, or discard it with /compose cancel.": "你的草稿是空的。请先写消息，或使用 /compose cancel 丢弃它。",
+	"📝 Added to the draft (%d message(s)). /send sends it.":                               "📝 已添加到草稿（%d 条消息）。/send 发送它。",
+	"The draft is full at %d messages. Send it with /send.":                               "草稿已满，共 %d 条消息。使用 /send 发送它。",
+
+	// /preset
+	"Default":          "默认",
+	"🎩 Formal":         "🎩 正式",
+	"😎 Casual":         "😎 随意",
+	"💻 Code assistant": "💻 编程助手",
+	"No active session. Send a message to start one, then use /preset.": "没有活动会话。请先发送一条消息开始会话，再使用 /preset。",
+	"⌛ This menu expired. Send /files to get a fresh one.":              "⌛ 此菜单已过期。发送 /files 获取新菜单。",
+	"⌛ This menu expired. Send /preset to get a fresh one.":             "⌛ 此菜单已过期。发送 /preset 获取新菜单。",
+	"This session is no longer available.":                              "此会话已不可用。",
+	"✅ Preset: %s":                                                      "✅ 预设：%s",
+	"🎭 Preset of %s: %s":                                                "🎭 %s 的预设：%s",
+	"Choose how the assistant answers in this session.":                 "选择助手在此会话中的回答方式。",
+
+	// /settings ai
+	"The AI assistant is not configured, so there are no AI parameters to set.":                                                                                     "AI 助手未配置，没有可设置的 AI 参数。",
+	"Use /settings ai temperature <value> or /settings ai max_tokens <value> to change a parameter, with default instead of a value to use the provider's default.": "使用 /settings ai temperature <值> 或 /settings ai max_tokens <值> 修改参数，用 default 代替值则使用服务商的默认值。",
+	"The temperature must be a number from %s to %s.":                                                                                                               "温度必须是 %s 到 %s 之间的数字。",
+	"Max tokens must be a whole number from %d to %d.":                                                                                                              "最大 token 数必须是 %d 到 %d 之间的整数。",
+	"✅ Saved.":                "✅ 已保存。",
+	"🎛 AI parameters":         "🎛 AI 参数",
+	"Temperature: %s (%s–%s)": "温度：%s（%s–%s）",
+	"Max tokens: %s (%d–%d)":  "最大 token 数：%s（%d–%d）",
+	"default":                 "默认",
+	"🎛 AI parameters: %s":     "🎛 AI 参数：%s",
+	"❌ Invalid button.":       "❌ 无效的按钮。",
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/extract"
+	"tg-bot-demo/format"
+	"tg-bot-demo/handlers"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/retention"
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+// mediaGroupFlushDelay is how long album parts are buffered after the last part arrives
+const mediaGroupFlushDelay = 1500 * time.Millisecond
+
+// extractPreviewLength is the number of characters of extracted text quoted in replies
+const extractPreviewLength = 160
+
+// fileIngestor downloads file media into storage and records it in the file catalog,
+// attached to the sender's active session. Text extracted from documents is added
+// to the session as context.
+type fileIngestor struct {
+	storage    storage.Backend
+	client     *http.Client // downloads files from Telegram
+	localDir   string       // working directory of a local-mode Bot API server, or empty
+	files      *session.FileManager
+	sessions   *session.Manager
+	messages   *session.MessageManager
+	extractors *extract.Pipeline
+	albums     *mediaGroupAggregator
+	flood      *fileFloodGuard // nil disables flood detection
+
+	// archiveChannels attaches channel post media to the channel's session
+	archiveChannels bool
+}
+
+// newFileIngestor creates a file ingestor with an album aggregator
+func newFileIngestor(fileStorage storage.Backend, client *http.Client, fileMgr *session.FileManager, sessionMgr *session.Manager,
+	messageMgr *session.MessageManager, extractors *extract.Pipeline) *fileIngestor {
+	ingestor := &fileIngestor{
+		storage:    fileStorage,
+		client:     client,
+		files:      fileMgr,
+		sessions:   sessionMgr,
+		messages:   messageMgr,
+		extractors: extractors,
+	}
+	ingestor.albums = newMediaGroupAggregator(mediaGroupFlushDelay, ingestor.ingestAlbum)
+	return ingestor
+}
+
+// ingestResult summarizes the files stored from one message
+type ingestResult struct {
+	stored    int
+	extracted []string // one HTML reply line per document added to the session as context
+
+	// context holds the text extracted from documents until it is added to the session
+	context []extractedContext
+}
+
+// extractedContext is the text of a document to add to a session and the reply line describing it
+type extractedContext struct {
+	message *session.Message // nil when the document has no text
+	summary string
+}
+
+// ingest downloads every file in message, adds the text extracted from its
+// documents to the session and reports what was stored.
+// mediaGroupID groups album parts under a shared storage prefix and catalog ID.
+func (i *fileIngestor) ingest(ctx context.Context, b handlers.TelegramAPI, message *models.Message, mediaGroupID string) ingestResult {
+	result := i.download(ctx, b, message, mediaGroupID)
+	result.extracted = i.addContext(ctx, result.context)
+	return result
+}
+
+// download stores every file in message and extracts the text of its documents,
+// leaving it in the result's context for addContext
+func (i *fileIngestor) download(ctx context.Context, b handlers.TelegramAPI, message *models.Message, mediaGroupID string) ingestResult {
+	var result ingestResult
+	targets := collectFileTargets(message)
+	if len(targets) == 0 {
+		return result
+	}
+	if !session.UserSettingsFromContext(ctx).AutoDownload {
+		log.Printf("download skipped: request_id=%s chat_id=%d message_id=%d reason=auto_download_off", correlation.ID(ctx), message.Chat.ID, message.ID)
+		return result
+	}
+	if i.flood != nil && !i.flood.allow(ctx, b, message, len(targets)) {
+		return result
+	}
+
+	username := messageUsername(message)
+	ownerID := messageOwnerID(message)
+	activeSession := i.activeSession(ctx, message)
+	for _, target := range targets {
+		file := session.NewFile(ownerID, target.Kind, target.FileID, "", 0)
+		downloaded, err := downloadTelegramFile(ctx, b, i.client, i.localDir, i.storage, fileStorageKey(username, mediaGroupID, target.FileID, file.ID), target.FileID)
+		if err != nil {
+			log.Printf("download failed: request_id=%s type=%s username=%s file_id=%s err=%v", correlation.ID(ctx), target.Kind, username, target.FileID, err)
+			continue
+		}
+		log.Printf("downloaded: request_id=%s type=%s username=%s file_id=%s bytes=%d path=%s", correlation.ID(ctx), target.Kind, username, target.FileID, downloaded.Size, downloaded.Location)
+
+		file.StorageKey = downloaded.Key
+		file.Size = downloaded.Size
+		file.FileName = target.FileName
+		if file.FileName == "" {
+			file.FileName = path.Base(downloaded.FilePath)
+		}
+		file.MimeType = target.MimeType
+		file.Sticker = target.Sticker
+		file.MediaGroupID = mediaGroupID
+		if activeSession != nil {
+			file.SessionID = activeSession.ID
+		}
+		if err := i.files.RecordFile(ctx, file); err != nil {
+			log.Printf("record file failed: request_id=%s type=%s username=%s file_id=%s err=%v", correlation.ID(ctx), target.Kind, username, target.FileID, err)
+			// Nothing else refers to this object, so keep it from leaking
+			if err := i.storage.Delete(ctx, file.StorageKey); err != nil {
+				log.Printf("delete unrecorded file failed: request_id=%s storage_key=%s err=%v", correlation.ID(ctx), file.StorageKey, err)
+			}
+			continue
+		}
+		retention.TrackStored(downloaded.Size)
+		result.stored++
+
+		if activeSession != nil {
+			if extracted, ok := i.extractContext(ctx, message, activeSession, file); ok {
+				result.context = append(result.context, extracted)
+			}
+		}
+	}
+
+	return result
+}
+
+// extractContext extracts the text of a stored document as a context message of
+// the session, with a short summary for the reply
+func (i *fileIngestor) extractContext(ctx context.Context, message *models.Message, activeSession *session.Session, file *session.File) (extractedContext, bool) {
+	if i.extractors == nil || i.messages == nil || file.Kind != "document" ||
+		!i.extractors.Supports(file.FileName, file.MimeType) {
+		return extractedContext{}, false
+	}
+
+	reader, err := i.storage.Open(ctx, file.StorageKey)
+	if err != nil {
+		log.Printf("extract failed: request_id=%s file_id=%s storage_key=%s err=%v", correlation.ID(ctx), file.ID, file.StorageKey, err)
+		return extractedContext{}, false
+	}
+	defer reader.Close()
+
+	extracted, err := i.extractors.Extract(ctx, file.FileName, file.MimeType, reader)
+	if err != nil {
+		log.Printf("extract failed: request_id=%s file_id=%s file_name=%s err=%v", correlation.ID(ctx), file.ID, file.FileName, err)
+		if errors.Is(err, extract.ErrEmpty) {
+			return extractedContext{summary: i18n.FromContext(ctx).Sprintf("📄 %s: no text found", format.Bold(file.FileName))}, true
+		}
+		return extractedContext{}, false
+	}
+
+	contextMessage := session.NewMessage(activeSession.ID, file.UserID, session.RoleContext,
+		fmt.Sprintf("[%s]\n%s", file.FileName, extracted.Text))
+	contextMessage.FileID = file.ID
+	contextMessage.ChatID = message.Chat.ID
+	contextMessage.TelegramMessageID = message.ID
+
+	log.Printf("extracted: request_id=%s file_id=%s extractor=%s chars=%d truncated=%t session_id=%s",
+		correlation.ID(ctx), file.ID, extracted.Extractor, len(extracted.Text), extracted.Truncated, activeSession.ID)
+	return extractedContext{
+		message: contextMessage,
+		summary: formatExtractionSummary(i18n.FromContext(ctx), file.FileName, activeSession.Title, extracted),
+	}, true
+}
+
+// addContext adds the extracted texts to their sessions, one batch per session,
+// and returns the reply lines of the documents added or without text
+func (i *fileIngestor) addContext(ctx context.Context, extracted []extractedContext) []string {
+	batches := make(map[uuid.UUID][]*session.Message)
+	for _, e := range extracted {
+		if e.message != nil {
+			batches[e.message.SessionID] = append(batches[e.message.SessionID], e.message)
+		}
+	}
+	failed := make(map[uuid.UUID]bool)
+	for sessionID, messages := range batches {
+		if err := i.messages.AddMessages(ctx, sessionID, messages); err != nil {
+			log.Printf("extract failed: request_id=%s session_id=%s messages=%d err=%v", correlation.ID(ctx), sessionID, len(messages), err)
+			failed[sessionID] = true
+		}
+	}
+
+	var summaries []string
+	for _, e := range extracted {
+		if e.message == nil || !failed[e.message.SessionID] {
+			summaries = append(summaries, e.summary)
+		}
+	}
+	return summaries
+}
+
+// formatExtractionSummary describes a document added to a session, with a short preview, as HTML
+func formatExtractionSummary(tr *i18n.Translator, fileName, sessionTitle string, extracted *extract.Result) string {
+	words := len(strings.Fields(extracted.Text))
+	lines := strings.Count(extracted.Text, "\n") + 1
+
+	summary := tr.Sprintf("📄 %s added to session \"%s\": %d words, %d lines", format.Bold(fileName), format.Escape(sessionTitle), words, lines)
+	if extracted.Truncated {
+		summary += tr.T(" (truncated)")
+	}
+
+	preview := strings.Join(strings.Fields(extracted.Text), " ")
+	if runes := []rune(preview); len(runes) > extractPreviewLength {
+		preview = string(runes[:extractPreviewLength]) + "…"
+	}
+	return summary + "\n« " + format.Italic(preview) + " »"
+}
+
+// activeSession returns the sender's active session, creating one if needed.
+// Files sent on behalf of chats have no user session and return nil, except
+// archived channel posts, which go to the channel's session.
+func (i *fileIngestor) activeSession(ctx context.Context, message *models.Message) *session.Session {
+	if i.sessions != nil && i.archiveChannels && message.Chat.Type == models.ChatTypeChannel {
+		activeSession, err := i.sessions.GetOrCreateActiveSession(ctx, message.Chat.ID, message.Chat.Title)
+		if err != nil {
+			log.Printf("attach to session failed: request_id=%s chat_id=%d err=%v", correlation.ID(ctx), message.Chat.ID, err)
+			return nil
+		}
+		return activeSession
+	}
+	if i.sessions == nil || message.From == nil || message.From.IsBot {
+		return nil
+	}
+
+	activeSession, err := i.sessions.InTopic(handlers.MessageTopic(message)).GetOrCreateActiveSession(ctx, message.From.ID, message.Caption)
+	if err != nil {
+		log.Printf("attach to session failed: request_id=%s user_id=%d err=%v", correlation.ID(ctx), message.From.ID, err)
+		return nil
+	}
+	return activeSession
+}
+
+// ingestAlbum downloads all parts of an album and sends one consolidated reply
+func (i *fileIngestor) ingestAlbum(ctx context.Context, b handlers.TelegramAPI, group *mediaGroup) {
+	// Parts are handled concurrently and may arrive out of order
+	sort.Slice(group.parts, func(x, y int) bool { return group.parts[x].ID < group.parts[y].ID })
+
+	stored, total := 0, 0
+	var pending []extractedContext
+	for _, part := range group.parts {
+		total += len(collectFileTargets(part))
+		result := i.download(ctx, b, part, group.id)
+		stored += result.stored
+		pending = append(pending, result.context...)
+	}
+	// The documents of an album are added to the session in one transaction
+	extracted := i.addContext(ctx, pending)
+
+	log.Printf("album stored: request_id=%s media_group_id=%s parts=%d files=%d/%d", correlation.ID(ctx), group.id, len(group.parts), stored, total)
+
+	if group.replyTo == nil {
+		return
+	}
+
+	params := buildOKReply(ctx, group.replyTo)
+	params.Text = i18n.FromContext(ctx).Sprintf("OK (album: %d of %d files saved)", stored, total)
+	if len(extracted) > 0 {
+		params.Text += "\n\n" + strings.Join(extracted, "\n\n")
+		params.ParseMode = models.ParseModeHTML
+	}
+	if _, err := b.SendMessage(ctx, params); err != nil {
+		log.Printf("reply failed: request_id=%s chat_id=%v message_id=%d err=%v", correlation.ID(ctx), group.replyTo.Chat.ID, group.replyTo.ID, err)
+	}
+}
+
+// mediaGroup holds the buffered parts of one album
+type mediaGroup struct {
+	id      string
+	parts   []*models.Message
+	replyTo *models.Message // earliest part that should be acknowledged, if any
+	timer   *time.Timer
+}
+
+// mediaGroupAggregator buffers album parts sharing a MediaGroupID and flushes
+// them together once no new part arrived for delay.
+type mediaGroupAggregator struct {
+	mu     sync.Mutex
+	delay  time.Duration
+	groups map[string]*mediaGroup
+	flush  func(ctx context.Context, b handlers.TelegramAPI, group *mediaGroup)
+}
+
+// newMediaGroupAggregator creates an aggregator that calls flush for each completed album
+func newMediaGroupAggregator(delay time.Duration, flush func(ctx context.Context, b handlers.TelegramAPI, group *mediaGroup)) *mediaGroupAggregator {
+	return &mediaGroupAggregator{
+		delay:  delay,
+		groups: make(map[string]*mediaGroup),
+		flush:  flush,
+	}
+}
+
+// Add buffers an album part. reply marks the part as an incoming user message to acknowledge.
+func (a *mediaGroupAggregator) Add(ctx context.Context, b handlers.TelegramAPI, message *models.Message, reply bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := fmt.Sprintf("%d/%s", message.Chat.ID, message.MediaGroupID)
+	group, ok := a.groups[key]
+	if !ok {
+		group = &mediaGroup{id: message.MediaGroupID}
+		a.groups[key] = group
+		group.timer = time.AfterFunc(a.delay, func() {
+			a.mu.Lock()
+			// A Reset racing with expiry can fire the timer twice; only flush once.
+			if a.groups[key] != group {
+				a.mu.Unlock()
+				return
+			}
+			delete(a.groups, key)
+			a.mu.Unlock()
+			a.flush(ctx, b, group)
+		})
+	} else {
+		group.timer.Reset(a.delay)
+	}
+
+	group.parts = append(group.parts, message)
+	if reply && (group.replyTo == nil || message.ID < group.replyTo.ID) {
+		group.replyTo = message
+	}
+}
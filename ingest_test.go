@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tg-bot-demo/extract"
+	"tg-bot-demo/handlers"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+)
+
+func TestMediaGroupAggregator_FlushesOncePerAlbum(t *testing.T) {
+	var mu sync.Mutex
+	var flushed []*mediaGroup
+	done := make(chan struct{}, 2)
+
+	aggregator := newMediaGroupAggregator(30*time.Millisecond, func(ctx context.Context, b handlers.TelegramAPI, group *mediaGroup) {
+		mu.Lock()
+		flushed = append(flushed, group)
+		mu.Unlock()
+		done <- struct{}{}
+	})
+
+	ctx := context.Background()
+	part := func(id int, chatID int64, groupID string) *models.Message {
+		return &models.Message{ID: id, Chat: models.Chat{ID: chatID}, MediaGroupID: groupID}
+	}
+
+	aggregator.Add(ctx, nil, part(11, 1, "album-a"), true)
+	aggregator.Add(ctx, nil, part(10, 1, "album-a"), true)
+	aggregator.Add(ctx, nil, part(20, 2, "album-b"), false)
+	time.Sleep(10 * time.Millisecond)
+	aggregator.Add(ctx, nil, part(12, 1, "album-a"), true)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for album flush")
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(flushed) != 2 {
+		t.Fatalf("expected 2 flushed albums, got %d", len(flushed))
+	}
+
+	for _, group := range flushed {
+		switch group.id {
+		case "album-a":
+			if len(group.parts) != 3 {
+				t.Errorf("expected 3 parts in album-a, got %d", len(group.parts))
+			}
+			if group.replyTo == nil || group.replyTo.ID != 10 {
+				t.Errorf("expected reply to earliest part 10, got %+v", group.replyTo)
+			}
+		case "album-b":
+			if len(group.parts) != 1 {
+				t.Errorf("expected 1 part in album-b, got %d", len(group.parts))
+			}
+			if group.replyTo != nil {
+				t.Errorf("expected no reply for album-b, got %+v", group.replyTo)
+			}
+		default:
+			t.Errorf("unexpected album %q", group.id)
+		}
+	}
+
+	if len(aggregator.groups) != 0 {
+		t.Errorf("expected no pending albums, got %d", len(aggregator.groups))
+	}
+}
+
+func TestFileStorageKey(t *testing.T) {
+	id := uuid.MustParse("0b0f8f5e-6d0a-4a43-9d55-3f0b6c1f4a10")
+	if key := fileStorageKey("alice", "", "AgAD-1", id); key != "alice/AgAD-1_"+id.String() {
+		t.Errorf("unexpected key %q", key)
+	}
+	if key := fileStorageKey("alice", "1234567890", "AgAD-1", id); key != "alice/1234567890/AgAD-1_"+id.String() {
+		t.Errorf("unexpected album key %q", key)
+	}
+	if key := fileStorageKey("", "../x", "a/b", id); key != "unknown/x/a_b_"+id.String() {
+		t.Errorf("unexpected sanitized key %q", key)
+	}
+	if fileStorageKey("alice", "", "AgAD-1", uuid.New()) == fileStorageKey("alice", "", "AgAD-1", uuid.New()) {
+		t.Error("expected the same file sent twice to get distinct keys")
+	}
+}
+
+func TestFormatExtractionSummary(t *testing.T) {
+	summary := formatExtractionSummary(i18n.New(i18n.English), "notes.txt", "Research", &extract.Result{
+		Extractor: "text",
+		Text:      "first line\nsecond line with more words",
+		Truncated: true,
+	})
+
+	want := "📄 <b>notes.txt</b> added to session \"Research\": 7 words, 2 lines (truncated)\n« <i>first line second line with more words</i> »"
+	if summary != want {
+		t.Errorf("expected %q, got %q", want, summary)
+	}
+
+	long := formatExtractionSummary(i18n.New(i18n.English), "long.txt", "S", &extract.Result{Text: strings.Repeat("a", extractPreviewLength+10)})
+	if !strings.HasSuffix(long, "…</i> »") {
+		t.Errorf("expected long preview to be shortened, got %q", long)
+	}
+
+	escaped := formatExtractionSummary(i18n.New(i18n.English), "<a>&b.txt", "x < y", &extract.Result{Text: "if a<b && c>d"})
+	if !strings.Contains(escaped, "<b>&lt;a&gt;&amp;b.txt</b>") || !strings.Contains(escaped, `"x &lt; y"`) ||
+		!strings.Contains(escaped, "<i>if a&lt;b &amp;&amp; c&gt;d</i>") {
+		t.Errorf("expected user text to be escaped, got %q", escaped)
+	}
+}
+
+func TestCollectFileTargets_StickerMetadata(t *testing.T) {
+	message := &models.Message{
+		Sticker: &models.Sticker{
+			FileID:     "sticker-1",
+			Type:       "regular",
+			Emoji:      "😺",
+			SetName:    "Cats",
+			IsAnimated: true,
+		},
+	}
+
+	targets := collectFileTargets(message)
+	if len(targets) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(targets))
+	}
+	target := targets[0]
+	if target.Kind != "sticker" || target.MimeType != "application/x-tgsticker" {
+		t.Errorf("unexpected sticker target: %+v", target)
+	}
+	if target.Sticker == nil || target.Sticker.SetName != "Cats" || target.Sticker.Emoji != "😺" || !target.Sticker.IsAnimated {
+		t.Errorf("expected sticker metadata, got %+v", target.Sticker)
+	}
+}
+
+func TestDownloadTelegramFile_LocalBotAPIPath(t *testing.T) {
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "bot-api", "documents", "file_0.pdf")
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(localPath, []byte("large document"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	api := testutil.NewFakeTelegram()
+	api.Files["doc-1"] = &models.File{FileID: "doc-1", FilePath: localPath}
+	backend := storage.NewLocalBackend(filepath.Join(dir, "download"))
+
+	if _, err := downloadTelegramFile(context.Background(), api, http.DefaultClient, "", backend, "alice/doc-1", "doc-1"); err == nil {
+		t.Fatal("expected local paths refused without api_local_dir")
+	}
+
+	downloaded, err := downloadTelegramFile(context.Background(), api, http.DefaultClient, filepath.Join(dir, "bot-api"), backend, "alice/doc-1", "doc-1")
+	if err != nil {
+		t.Fatalf("downloadTelegramFile failed: %v", err)
+	}
+	if downloaded.Size != int64(len("large document")) {
+		t.Errorf("expected size %d, got %d", len("large document"), downloaded.Size)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, "download", "alice", "doc-1"))
+	if err != nil || string(stored) != "large document" {
+		t.Errorf("expected stored copy of the local file, got %q err=%v", stored, err)
+	}
+
+	// Paths outside the server's working directory are never read, not even through a symlink
+	secret := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "bot-api", "documents", "file_1.pdf")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{secret, filepath.Join(dir, "bot-api", "..", "secret.txt"), link} {
+		api.Files["doc-2"] = &models.File{FileID: "doc-2", FilePath: path}
+		if _, err := downloadTelegramFile(context.Background(), api, http.DefaultClient, filepath.Join(dir, "bot-api"), backend, "alice/doc-2", "doc-2"); err == nil {
+			t.Errorf("expected %s outside api_local_dir refused", path)
+		}
+	}
+}
+
+func TestFileIngestor_SkipsWhenAutoDownloadOff(t *testing.T) {
+	api := testutil.NewFakeTelegram()
+	api.Files["photo-1"] = &models.File{FileID: "photo-1", FilePath: "photos/file_1.jpg"}
+	ingestor := newFileIngestor(storage.NewLocalBackend(t.TempDir()), http.DefaultClient, nil, nil, nil, nil)
+
+	ctx := session.WithUserSettings(context.Background(), &session.UserSettings{UserID: 1, AutoDownload: false})
+	message := &models.Message{ID: 1, Chat: models.Chat{ID: 1}, From: &models.User{ID: 1},
+		Photo: []models.PhotoSize{{FileID: "photo-1", FileSize: 4}}}
+
+	if result := ingestor.ingest(ctx, api, message, ""); result.stored != 0 {
+		t.Errorf("expected no files stored with auto-download off, got %d", result.stored)
+	}
+}
@@ -28,11 +28,12 @@ func TestIntegration_BotInitializationFlow(t *testing.T) {
 	}
 
 	// Initialize the bot
-	bot, store, err := initializeBot(cfg)
+	app, err := initializeBot(cfg)
 	if err != nil {
 		t.Fatalf("initializeBot failed: %v", err)
 	}
-	defer store.Close()
+	defer app.Close()
+	bot, store := app.bot, app.store
 
 	// Verify bot was created
 	if bot == nil {
@@ -96,11 +97,12 @@ func TestIntegration_MultipleUsers(t *testing.T) {
 		DatabasePath:    dbPath,
 	}
 
-	_, store, err := initializeBot(cfg)
+	app, err := initializeBot(cfg)
 	if err != nil {
 		t.Fatalf("initializeBot failed: %v", err)
 	}
-	defer store.Close()
+	defer app.Close()
+	store := app.store
 
 	ctx := context.Background()
 
@@ -161,11 +163,12 @@ func TestIntegration_SessionPagination(t *testing.T) {
 		DatabasePath:    dbPath,
 	}
 
-	_, store, err := initializeBot(cfg)
+	app, err := initializeBot(cfg)
 	if err != nil {
 		t.Fatalf("initializeBot failed: %v", err)
 	}
-	defer store.Close()
+	defer app.Close()
+	store := app.store
 
 	ctx := context.Background()
 	userID := int64(333)
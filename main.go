@@ -1,8 +1,8 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -10,139 +10,919 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"tg-bot-demo/ai"
+	"tg-bot-demo/backup"
 	"tg-bot-demo/config"
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/dashboard"
+	"tg-bot-demo/events"
+	"tg-bot-demo/extract"
+	"tg-bot-demo/grpcapi"
 	"tg-bot-demo/handlers"
+	"tg-bot-demo/i18n"
+	"tg-bot-demo/maintenance"
+	"tg-bot-demo/moderation"
+	"tg-bot-demo/notify"
+	"tg-bot-demo/outbox"
+	"tg-bot-demo/ratelimit"
+	"tg-bot-demo/reporting"
+	"tg-bot-demo/requestlog"
+	"tg-bot-demo/retention"
 	"tg-bot-demo/session"
+	"tg-bot-demo/settings"
+	"tg-bot-demo/share"
+	"tg-bot-demo/storage"
+	"tg-bot-demo/tracing"
+	"tg-bot-demo/updatequeue"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// initializeBot creates and configures a bot with session management
-func initializeBot(cfg *config.Config) (*bot.Bot, *session.SQLiteStore, error) {
+// version is the bot version, set at build time with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// application bundles the bot with the services it shares with background jobs
+type application struct {
+	bot         *bot.Bot
+	store       *session.SQLiteStore
+	cleaner     *retention.Cleaner
+	maintenance *maintenance.Job
+	backup      *backup.Job
+	limiter     *ratelimit.Limiter   // nil when rate limiting is disabled
+	cooldowns   *ratelimit.Cooldowns // nil when no command has a cooldown
+	floods      *fileFloodGuard      // nil when file flood detection is disabled
+	shares      *share.Links
+	events      *events.Bus // nil when no event endpoints are configured
+	sessionAPI  *grpcapi.Server
+	notifier    *notify.Notifier
+	outbox      *outbox.Outbox
+	expiry      *handlers.SessionExpiry // nil when sessions do not expire
+	updates     *updatequeue.Queue      // webhook updates waiting for the bot's workers
+}
+
+// Close releases resources held by the application
+func (a *application) Close() error {
+	if a.limiter != nil {
+		// Keep throttled users throttled across restarts
+		if err := a.limiter.Flush(context.Background()); err != nil {
+			log.Printf("failed to save rate limit buckets: %v", err)
+		}
+	}
+	if a.events != nil && !a.events.Close(5*time.Second) {
+		log.Printf("events: gave up delivering queued events on shutdown")
+	}
+	return a.store.Close()
+}
+
+// initializeBot creates and configures a bot with session management.
+// Extra options are applied after the defaults, e.g. to redirect API calls.
+func initializeBot(cfg *config.Config, extraOptions ...bot.Option) (*application, error) {
 	// Initialize SQLite store with database path
-	store, err := session.NewSQLiteStore(cfg.DatabasePath)
+	store, err := session.NewSQLiteStoreWithOptions(cfg.DatabasePath, sqliteOptions(cfg))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create session store: %w", err)
+		return nil, fmt.Errorf("failed to create session store: %w", err)
 	}
 
+	// Look for rows pointing at missing sessions, fixing them when configured
+	checkIntegrity(context.Background(), store, cfg.DatabaseRepairOnStart)
+
 	// Create session manager with store
 	sessionMgr := session.NewManager(store)
+	sessionMgr.SetOperationTimeout(time.Duration(cfg.SessionOperationTimeoutMS) * time.Millisecond)
+
+	// Create file catalog manager with store
+	fileMgr := session.NewFileManager(store)
+	messageMgr := session.NewMessageManager(store)
+
+	// Publish session, message and file events to the configured endpoints.
+	// The bus starts delivering once nothing else can fail, so a failed
+	// initialization leaves no delivery goroutine behind.
+	eventBus, err := newEventBus(cfg)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to create event bus: %w", err)
+	}
+	if eventBus != nil {
+		sessionMgr.SetEvents(eventBus)
+		messageMgr.SetEvents(eventBus)
+		fileMgr.SetEvents(eventBus)
+	}
+
+	// Create storage backend for downloaded files
+	fileStorage, err := newStorageBackend(cfg)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to create storage backend: %w", err)
+	}
+
+	// Create runtime settings; /admin set overrides the configured values
+	runtimeSettings := newRuntimeSettings(cfg, store)
+
+	// Create HTTP clients for Telegram, going through the configured proxy
+	clients, err := newTelegramClients(cfg)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	// Create retention cleaner enforcing storage quotas
+	cleaner := retention.NewCleaner(store, fileStorage, retention.Quotas{
+		UserBytes:   cfg.UserQuotaBytes,
+		GlobalBytes: cfg.GlobalQuotaBytes,
+	})
+	cleaner.SetQuotaSource(func(ctx context.Context) retention.Quotas {
+		return retention.Quotas{
+			UserBytes:   runtimeSettings.Int(ctx, settings.UserQuotaBytes),
+			GlobalBytes: runtimeSettings.Int(ctx, settings.GlobalQuotaBytes),
+		}
+	})
+	// Deletes would be refused while the store is read-only for maintenance
+	cleaner.SetPauseSource(store.ReadOnly)
+
+	// Create database maintenance job; /admin maintenance runs it on demand
+	maintenanceJob := maintenance.New(store, session.MaintenanceOptions{IncrementalVacuum: cfg.DatabaseIncrementalVacuum})
+
+	// Create database backup job; /admin backup now runs it on demand
+	backupTarget, err := newBackupTarget(cfg)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to create backup backend: %w", err)
+	}
+	backupJob := backup.New(store, backupTarget)
+
+	// Create read-only share links; tokens are signed with the callback signing key
+	shareLinks := share.New(store, share.Options{
+		Key:         cfg.CallbackSigningKey,
+		TTL:         time.Duration(cfg.ShareTTLHours) * time.Hour,
+		BotUsername: cfg.BotUsername,
+		BaseURL:     cfg.PublicBaseURL,
+	})
+
+	// Create notifier for proactive messages; it defers them during users' quiet hours
+	notifier := notify.New(store)
+
+	// Apply button label overrides before any keyboard is built
+	if err := handlers.SetButtonLabels(cfg.ButtonLabels); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("invalid button_labels: %w", err)
+	}
+
+	// Create the AI assistant; without a provider messages are only confirmed
+	assistant, err := newAssistant(cfg, sessionMgr, store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	// Create the content filter; without rules nothing is filtered
+	contentFilter, err := newContentFilter(cfg)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
 
 	// Create handler config
 	handlerCfg := &handlers.HandlerConfig{
-		SessionsPerPage: cfg.SessionsPerPage,
+		SessionsPerPage:    cfg.SessionsPerPage,
+		AdminUserIDs:       cfg.AdminUserIDs,
+		QuickSwitchButtons: cfg.QuickSwitchButtons,
+		AIModels:           cfg.AIModels,
+		Assistant:          assistant,
+		Memory:             store,
+		Summaries:          store,
+		ContentFilter:      contentFilter,
+		FilterAction:       moderation.Action(cfg.ContentFilterAction),
+		FlaggedContent:     store,
+		Audit:              store,
+		Roles:              store,
+		Outbox:             outbox.New(store),
+		Generations:        handlers.NewGenerations(),
+		CommandRoles:       handlers.MergeCommandRoles(cfg.CommandRoles),
+		UserQuotaBytes:     cfg.UserQuotaBytes,
+		Version:            version,
+		Settings:           runtimeSettings,
+		CallbackSigner: handlers.NewCallbackSigner(cfg.CallbackSigningKey,
+			time.Duration(cfg.CallbackTTLMinutes)*time.Minute),
+		CallbackTokens: store,
+		Sampling: handlers.SamplingLimits{
+			MinTemperature: cfg.AITemperatureMin,
+			MaxTemperature: cfg.AITemperatureMax,
+			MinMaxTokens:   cfg.AIMaxTokensMin,
+			MaxMaxTokens:   cfg.AIMaxTokensMax,
+		},
+	}
+
+	// Create the session expiry job; owners are warned through the notifier
+	var expiry *handlers.SessionExpiry
+	if cfg.SessionTTLDays > 0 {
+		expiry = handlers.NewSessionExpiry(store, store, notifier, handlerCfg,
+			time.Duration(cfg.SessionTTLDays)*24*time.Hour, time.Duration(cfg.SessionExpiryWarningHours)*time.Hour)
+	}
+
+	// Create conversation flows; state lives in the store so flows survive restarts.
+	// Any command other than /cancel aborts a pending flow.
+	conversations := handlers.NewConversations(store, time.Duration(cfg.ConversationTimeoutMinutes)*time.Minute)
+	conversations.Register(handlers.RenameFlow(sessionMgr))
+	conversations.Register(handlers.MergeFlow(sessionMgr, store))
+
+	// Create inbound message rate limiter and callback debouncer
+	middlewares := []bot.Middleware{handlers.CorrelationMiddleware, handlers.RecoverMiddleware, handlers.DeliveryMiddleware(store), handlers.BanMiddleware(store, handlerCfg), handlers.LanguageMiddleware(store),
+		handlers.MaintenanceMiddleware(store, handlerCfg), handlers.UserSettingsMiddleware(store), handlers.PermissionMiddleware(handlerCfg)}
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitMessagesPerMinute > 0 {
+		limiter = ratelimit.New(store, ratelimit.Options{PerMinute: cfg.RateLimitMessagesPerMinute, Burst: cfg.RateLimitBurst})
+		middlewares = append(middlewares, handlers.RateLimitMiddleware(limiter, handlerCfg))
+	}
+
+	// Create per-command cooldowns; Validate already checked the durations
+	var cooldowns *ratelimit.Cooldowns
+	if durations, _ := cfg.CommandCooldownDurations(); len(durations) > 0 {
+		cooldowns = ratelimit.NewCooldowns(durations)
+		middlewares = append(middlewares, handlers.CommandCooldownMiddleware(cooldowns, handlerCfg))
+	}
+	middlewares = append(middlewares, handlers.NewCallbackDebouncer(handlers.DefaultCallbackDebounceWindow).Middleware, conversations.AbortOnCommand)
+
+	// Create file ingestor downloading media of unhandled updates
+	ingestor := newFileIngestor(fileStorage, clients.download, fileMgr, sessionMgr, messageMgr, extract.NewDefaultPipeline())
+	ingestor.localDir = cfg.APILocalDir
+	if cfg.FileFloodMaxFiles > 0 {
+		ingestor.flood = newFileFloodGuard(ratelimit.FloodOptions{
+			MaxFiles: cfg.FileFloodMaxFiles,
+			Window:   time.Duration(cfg.FileFloodWindowSeconds) * time.Second,
+			Pause:    time.Duration(cfg.FileFloodPauseMinutes) * time.Minute,
+		}, cfg.AdminUserIDs, notifier, store, store)
 	}
 
 	// Create bot with handlers
-	tgBot, err := bot.New(
-		cfg.Token,
+	options := []bot.Option{
 		bot.WithSkipGetMe(),
-		bot.WithDefaultHandler(handleUpdate),
-		bot.WithWebhookSecretToken(cfg.SecretToken),
-	)
+		bot.WithDefaultHandler(handlers.Traced("default", updateHandler(ingestor))),
+		bot.WithHTTPClient(apiRequestTimeout, clients.api),
+		bot.WithMiddlewares(middlewares...),
+		// Handlers run on the update queue's workers, which bound their concurrency
+		bot.WithNotAsyncHandlers(),
+	}
+	if len(cfg.AllowedUpdates) > 0 {
+		options = append(options, bot.WithAllowedUpdates(cfg.AllowedUpdates))
+	}
+	if cfg.APIBaseURL != "" {
+		options = append(options, bot.WithServerURL(strings.TrimRight(cfg.APIBaseURL, "/")))
+	}
+	tgBot, err := bot.New(cfg.Token, append(options, extraOptions...)...)
 	if err != nil {
 		store.Close()
-		return nil, nil, fmt.Errorf("failed to create telegram bot: %w", err)
+		return nil, fmt.Errorf("failed to create telegram bot: %w", err)
+	}
+	if eventBus != nil {
+		eventBus.Start()
 	}
 
+	// Create the command dispatcher; it matches commands with arguments and
+	// "/command@botname" addressed to this bot, and parses quoted arguments
+	commands := handlers.NewCommandDispatcher(cfg.BotUsername)
+
+	// Register command handler for /start, including deep-link payloads
+	commands.Handle("/start", handlers.StartCommandHandler(sessionMgr, store, shareLinks))
+
 	// Register command handler for /sessions
-	tgBot.RegisterHandler(bot.HandlerTypeMessageText, "/sessions", bot.MatchTypeExact,
-		handlers.SessionsCommandHandler(sessionMgr, handlerCfg))
+	commands.Handle("/sessions", handlers.SessionsCommandHandler(sessionMgr, handlerCfg))
 
 	// Register command handler for /open
-	tgBot.RegisterHandler(bot.HandlerTypeMessageText, "/open", bot.MatchTypeExact,
-		handlers.OpenCommandHandler(sessionMgr))
+	commands.Handle("/open", handlers.OpenCommandHandler(sessionMgr, handlerCfg))
+
+	// Register /new as an alias of /open
+	commands.Handle("/new", handlers.OpenCommandHandler(sessionMgr, handlerCfg))
 
 	// Register command handler for /close
-	tgBot.RegisterHandler(bot.HandlerTypeMessageText, "/close", bot.MatchTypeExact,
-		handlers.CloseCommandHandler(sessionMgr))
+	commands.Handle("/close", handlers.CloseCommandHandler(sessionMgr, handlerCfg))
+
+	// Register command handler for /files
+	commands.Handle("/files", handlers.FilesCommandHandler(fileMgr, handlerCfg))
+
+	// Register command handler for /stickers
+	commands.Handle("/stickers", handlers.StickersCommandHandler(fileMgr))
+
+	// Register command handler for /delete, optionally followed by "files"
+	commands.Handle("/delete", handlers.DeleteCommandHandler(sessionMgr, fileMgr, fileStorage, handlerCfg))
+
+	// Register command handler for /whoami diagnostics
+	commands.Handle("/whoami", handlers.WhoamiCommandHandler(sessionMgr, fileMgr, handlerCfg))
+
+	// Register command handler for /stats
+	commands.Handle("/stats", handlers.StatsCommandHandler(store))
+
+	// Register command handler for /forgetme; its confirmation buttons are handled below
+	commands.Handle("/forgetme", handlers.ForgetMeCommandHandler())
+
+	// Register command handler for /share, optionally followed by "revoke"
+	commands.Handle("/share", handlers.ShareCommandHandler(sessionMgr, shareLinks, handlerCfg))
 
-	// Register callback query handler
-	tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "", bot.MatchTypePrefix,
-		handlers.CallbackQueryHandler(sessionMgr, handlerCfg))
+	// Register command handler for /settings; its menu buttons are handled below
+	commands.Handle("/settings", handlers.SettingsCommandHandler(store, store, handlerCfg))
+
+	// Register command handler for /quiet
+	commands.Handle("/quiet", handlers.QuietCommandHandler(store, handlerCfg))
+
+	// Register command handler for /timezone
+	commands.Handle("/timezone", handlers.TimezoneCommandHandler(store, handlerCfg))
+
+	// Register command handler for /memory, followed by set, delete or clear
+	commands.Handle("/memory", handlers.MemoryCommandHandler(sessionMgr, store, handlerCfg))
+
+	// Register command handler for /preset, which shows the preset menu of the active session
+	commands.Handle("/preset", handlers.PresetCommandHandler(sessionMgr, handlerCfg))
+
+	// Register command handler for /summary, optionally followed by pin
+	commands.Handle("/summary", handlers.SummaryCommandHandler(sessionMgr, messageMgr, handlerCfg))
+
+	// Register command handler for /translate, sent as a reply to the message to translate
+	commands.Handle("/translate", handlers.TranslateCommandHandler(messageMgr, handlerCfg))
+
+	// Register command handlers for /compose, optionally followed by cancel, and /send
+	messageHandler := handlers.MessageHandler(sessionMgr, messageMgr, handlerCfg)
+	commands.Handle("/compose", handlers.ComposeCommandHandler(store, handlerCfg))
+	commands.Handle("/send", handlers.SendCommandHandler(store, messageHandler))
+
+	// Register command handler for /feedback
+	commands.Handle("/feedback", handlers.FeedbackCommandHandler(sessionMgr, store, handlerCfg))
+
+	// Register command handler for /language, optionally followed by a language code
+	commands.Handle("/language", handlers.LanguageCommandHandler(store, handlerCfg))
+
+	// Register command handler for /merge
+	commands.Handle("/merge", handlers.MergeCommandHandler(sessionMgr, conversations))
+
+	// Register command handlers for /rename, optionally followed by the new title, and /cancel
+	commands.Handle("/rename", handlers.RenameCommandHandler(sessionMgr, conversations, handlerCfg))
+	commands.Handle("/cancel", handlers.CancelCommandHandler(conversations))
+
+	// Register command handler for /admin and its subcommands
+	commands.Handle("/admin", handlers.AdminCommandHandler(handlerCfg, map[string]handlers.AdminCommand{
+		"audit":       handlers.AdminAuditCommand(store),
+		"backup":      handlers.AdminBackupCommand(backupJob),
+		"ban":         handlers.ConfirmedAdminCommand(handlers.AdminBanCommand(store, store, notifier, tgBot, handlerCfg)),
+		"channels":    handlers.AdminChannelsCommand(store),
+		"cleanup":     handlers.AdminCleanupCommand(cleaner),
+		"feedback":    handlers.AdminFeedbackCommand(store, handlerCfg),
+		"flagged":     handlers.AdminFlaggedCommand(store, handlerCfg),
+		"integrity":   handlers.ConfirmedAdminCommand(handlers.AdminIntegrityCommand(store)),
+		"maintenance": handlers.AdminMaintenanceCommand(maintenanceJob, store),
+		"purge":       handlers.ConfirmedAdminCommand(handlers.AdminPurgeCommand(store, fileStorage)),
+		"referrals":   handlers.AdminReferralsCommand(store),
+		"role":        handlers.ConfirmedAdminCommand(handlers.AdminRoleCommand(store, handlerCfg)),
+		"sessions":    handlers.AdminSessionsCommand(sessionMgr, handlerCfg),
+		"set":         handlers.ConfirmedAdminCommand(handlers.AdminSetCommand(runtimeSettings)),
+		"transfer":    handlers.ConfirmedAdminCommand(handlers.AdminTransferCommand(sessionMgr)),
+		"unban":       handlers.ConfirmedAdminCommand(handlers.AdminUnbanCommand(store)),
+	}))
+	tgBot.RegisterHandlerMatchFunc(commands.Match, handlers.Traced("command", commands.Handler()))
+
+	// Handlers of update types missing from allowed_updates are left out, as
+	// Telegram never sends those updates
+	if cfg.UpdateAllowed("callback_query") {
+		// Register callback query handler for the /forgetme confirmation buttons
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.ForgetMeCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("forgetme_callback", handlers.ForgetMeCallbackHandler(store, fileStorage, handlerCfg)))
+
+		// Register callback query handler for the /settings menu
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.SettingsCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("settings_callback", handlers.SettingsCallbackHandler(store, store, handlerCfg)))
+
+		// Register callback query handler for the /admin feedback review buttons
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.FeedbackCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("feedback_callback", handlers.FeedbackCallbackHandler(store, handlerCfg)))
+
+		// Register callback query handler for the /admin flagged review buttons
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.FlaggedCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("flagged_callback", handlers.FlaggedCallbackHandler(store, handlerCfg)))
+
+		// Register callback query handler for the /files keyboard
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.FilesCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("files_callback", handlers.FilesCallbackHandler(fileMgr, fileStorage, handlerCfg)))
+
+		// Register callback query handler for the code files button under assistant replies
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.CodeFilesCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("code_files_callback", handlers.CodeFilesCallbackHandler(messageMgr, handlerCfg)))
+
+		// Register callback query handler for the regenerate button under assistant replies
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.RegenerateCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("regenerate_callback", handlers.RegenerateCallbackHandler(sessionMgr, messageMgr, handlerCfg)))
+
+		// Register callback query handler for the /preset menu
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.PresetCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("preset_callback", handlers.PresetCallbackHandler(sessionMgr, handlerCfg)))
+
+		// Register callback query handler for the stop button under assistant replies in progress
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, handlers.StopGenerationCallbackPrefix, bot.MatchTypePrefix,
+			handlers.Traced("stop_generation_callback", handlers.StopGenerationCallbackHandler(handlerCfg)))
+
+		// Register callback query handler
+		tgBot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "", bot.MatchTypePrefix,
+			handlers.Traced("callback_query", handlers.CallbackQueryHandler(sessionMgr, store, handlerCfg)))
+	}
+
+	// Register inline mode handlers: "@bot <query>" searches sessions,
+	// choosing a result switches to that session (requires inline feedback in @BotFather)
+	if cfg.UpdateAllowed("inline_query") {
+		tgBot.RegisterHandlerMatchFunc(isInlineQuery, handlers.Traced("inline_query", handlers.InlineQueryHandler(sessionMgr)))
+	}
+	if cfg.UpdateAllowed("chosen_inline_result") {
+		tgBot.RegisterHandlerMatchFunc(isChosenInlineResult, handlers.Traced("chosen_inline_result", handlers.ChosenInlineResultHandler(sessionMgr)))
+	}
+
+	// Register handler for replies to a pending conversation step; it must run
+	// before the regular message handler so the reply is not stored as chat.
+	tgBot.RegisterHandlerMatchFunc(conversations.Match, handlers.Traced("conversation", conversations.Handler(handlerCfg)))
+
+	// Register handler for messages of users composing a draft; they are
+	// collected until /send instead of being answered.
+	tgBot.RegisterHandlerMatchFunc(handlers.DraftMatch(store), handlers.Traced("draft_message", handlers.DraftMessageHandler(store)))
 
 	// Register message handler for regular text messages (non-commands)
-	// This will handle messages that don't match other handlers
-	tgBot.RegisterHandler(bot.HandlerTypeMessageText, "", bot.MatchTypePrefix,
-		handlers.MessageHandler(sessionMgr))
+	// This will handle messages that don't match other handlers.
+	// Media messages without text fall through to the default handler for download.
+	tgBot.RegisterHandlerMatchFunc(isTextMessage, handlers.Traced("message", messageHandler))
+
+	// Register handler for edited text messages; edits update the stored message.
+	// Edited media messages fall through to the default handler for download.
+	if cfg.UpdateAllowed("edited_message") {
+		tgBot.RegisterHandlerMatchFunc(isEditedTextMessage, handlers.Traced("edited_message", handlers.EditedMessageHandler(messageMgr, handlerCfg)))
+	}
 
-	return tgBot, store, nil
+	// Register Telegram Business handlers: connection changes are recorded and
+	// each customer chat of a connected account gets its own session.
+	if cfg.UpdateAllowed("business_connection") {
+		tgBot.RegisterHandlerMatchFunc(isBusinessConnection, handlers.Traced("business_connection", handlers.BusinessConnectionHandler(store, notifier)))
+	}
+	if cfg.UpdateAllowed("business_message") {
+		tgBot.RegisterHandlerMatchFunc(isBusinessTextMessage, handlers.Traced("business_message", handlers.BusinessMessageHandler(sessionMgr, messageMgr, store)))
+	}
+
+	// Register channel archive handler; without it channel media is still downloaded
+	// by the default handler but not attached to a session.
+	if cfg.ArchiveChannelPosts {
+		archiver := newChannelArchiver(store, sessionMgr, messageMgr, ingestor)
+		tgBot.RegisterHandlerMatchFunc(isChannelPost, handlers.Traced("channel_post", archiver.handler()))
+	}
+
+	// Start read-only when configured; /admin maintenance off lifts it
+	store.SetReadOnly(cfg.MaintenanceMode)
+
+	updates := updatequeue.New(cfg.UpdateQueueSize, cfg.UpdateWorkers, tgBot.ProcessUpdate)
+	updates.SetExpress(isStopGeneration)
+
+	return &application{
+		bot:         tgBot,
+		store:       store,
+		cleaner:     cleaner,
+		maintenance: maintenanceJob,
+		backup:      backupJob,
+		limiter:     limiter,
+		cooldowns:   cooldowns,
+		floods:      ingestor.flood,
+		shares:      shareLinks,
+		events:      eventBus,
+		sessionAPI:  grpcapi.New(sessionMgr, messageMgr),
+		notifier:    notifier,
+		outbox:      handlerCfg.Outbox,
+		expiry:      expiry,
+		updates:     updates,
+	}, nil
 }
 
-func main() {
-	// Define command-line flags
-	configPath := flag.String("config", "", "Path to config file (optional)")
-	listenAddr := flag.String("listen", "", "HTTP listen address (overrides config)")
-	path := flag.String("path", "", "Webhook path (overrides config)")
-	token := flag.String("token", "", "Telegram bot token (overrides config)")
-	secretToken := flag.String("secret-token", "", "Webhook secret token (overrides config)")
-	defaultStatus := flag.Int("status", 0, "Default HTTP status code (overrides config)")
-	dbPath := flag.String("db", "", "Path to SQLite database file (overrides config)")
-	sessionsPerPage := flag.Int("sessions-per-page", 0, "Sessions per page (overrides config)")
-	flag.Parse()
-
-	// Load configuration
-	cfg, err := config.Load(*configPath)
+// isStopGeneration matches presses of the button stopping an assistant reply,
+// which must not wait behind the reply in their chat's lane
+func isStopGeneration(update *models.Update) bool {
+	return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, handlers.StopGenerationCallbackPrefix)
+}
+
+// isTextMessage matches updates carrying a plain text message
+func isTextMessage(update *models.Update) bool {
+	return update.Message != nil && update.Message.Text != ""
+}
+
+// isInlineQuery matches inline queries
+func isInlineQuery(update *models.Update) bool {
+	return update.InlineQuery != nil
+}
+
+// isChosenInlineResult matches inline result feedback
+func isChosenInlineResult(update *models.Update) bool {
+	return update.ChosenInlineResult != nil
+}
+
+// isEditedTextMessage matches updates carrying an edit of a plain text message
+func isEditedTextMessage(update *models.Update) bool {
+	return update.EditedMessage != nil && update.EditedMessage.Text != ""
+}
+
+// isBusinessConnection matches business account connection changes
+func isBusinessConnection(update *models.Update) bool {
+	return update.BusinessConnection != nil
+}
+
+// isBusinessTextMessage matches plain text messages in a connected business account's chats
+func isBusinessTextMessage(update *models.Update) bool {
+	return update.BusinessMessage != nil && update.BusinessMessage.Text != ""
+}
+
+// deferredDeliveryInterval is how often notifications held back by quiet hours are checked
+const deferredDeliveryInterval = time.Minute
+
+// outboxDeliveryInterval is how often replies waiting in the outbox are retried
+const outboxDeliveryInterval = 5 * time.Second
+
+// updateDrainTimeout bounds how long shutdown waits for queued updates to be handled
+const updateDrainTimeout = 30 * time.Second
+
+// settingsCacheTTL bounds how long runtime settings changed by another instance
+// sharing the database take to apply
+const settingsCacheTTL = time.Minute
+
+// newRuntimeSettings defines the settings /admin set can change, defaulting to cfg
+func newRuntimeSettings(cfg *config.Config, store session.SettingsStore) *settings.Settings {
+	runtimeSettings := settings.New(store, settingsCacheTTL)
+	runtimeSettings.DefineInt(settings.SessionsPerPage, "Sessions and files listed per page", int64(cfg.SessionsPerPage), 1)
+	runtimeSettings.DefineInt(settings.UserQuotaBytes, "Storage quota per user in bytes (0 = unlimited)", cfg.UserQuotaBytes, 0)
+	runtimeSettings.DefineInt(settings.GlobalQuotaBytes, "Storage quota for all users in bytes (0 = unlimited)", cfg.GlobalQuotaBytes, 0)
+	return runtimeSettings
+}
+
+// checkIntegrity runs the startup integrity check and logs what it found. A
+// failed check is logged and does not keep the bot from starting.
+func checkIntegrity(ctx context.Context, store session.IntegrityStore, repair bool) {
+	report, err := store.CheckIntegrity(ctx, repair)
 	if err != nil {
-		log.Fatalf("failed to load configuration: %v", err)
+		log.Printf("database integrity check failed: err=%v", err)
+		return
+	}
+	if report.Problems() == 0 {
+		return
 	}
+	log.Printf("database integrity: orphaned_active_sessions=%d invalid_session_ids=%d dangling_files=%d repaired=%t",
+		report.OrphanedActiveSessions, report.InvalidSessionIDs, report.DanglingFiles, report.Repaired)
+}
 
-	// Override config with command-line flags if provided
-	if *listenAddr != "" {
-		cfg.ListenAddr = *listenAddr
+// newBackupTarget creates the storage backend receiving database snapshots
+func newBackupTarget(cfg *config.Config) (storage.Backend, error) {
+	switch cfg.BackupBackend {
+	case "", "local":
+		backupDir := cfg.BackupDir
+		if backupDir == "" {
+			backupDir = "./data/backups"
+		}
+		return storage.NewLocalBackend(backupDir), nil
+	case "s3":
+		return storage.NewS3Backend(storage.S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			Prefix:          cfg.BackupS3Prefix,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			UsePathStyle:    cfg.S3UsePathStyle,
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown backup backend %q", cfg.BackupBackend)
 	}
-	if *path != "" {
-		cfg.WebhookPath = *path
+}
+
+// eventDeliveryTimeout bounds each delivery of an outgoing event
+const eventDeliveryTimeout = 10 * time.Second
+
+// newEventBus creates the bus delivering outgoing events to the configured
+// webhooks and NATS server, or nil when none is configured
+func newEventBus(cfg *config.Config) (*events.Bus, error) {
+	if len(cfg.EventWebhookURLs) == 0 && cfg.EventNATSURL == "" {
+		return nil, nil
 	}
-	if *token != "" {
-		cfg.Token = *token
+
+	bus := events.NewBus(events.DefaultQueueSize)
+	for _, webhookURL := range cfg.EventWebhookURLs {
+		bus.Subscribe(webhookURL, events.NewWebhookSink(webhookURL, cfg.EventWebhookSecret, eventDeliveryTimeout))
 	}
-	if *secretToken != "" {
-		cfg.SecretToken = *secretToken
+	if cfg.EventNATSURL != "" {
+		sink, err := events.NewNATSSink(cfg.EventNATSURL, cfg.EventNATSSubject, eventDeliveryTimeout)
+		if err != nil {
+			return nil, err
+		}
+		bus.Subscribe("nats", sink)
+	}
+	return bus, nil
+}
+
+// newAssistant creates the AI assistant answering text messages with the
+// built-in session tools, or nil when no AI provider is configured
+func newAssistant(cfg *config.Config, sessionMgr *session.Manager, store session.DeferredMessageStore) (*ai.Assistant, error) {
+	if !cfg.AIEnabled() {
+		return nil, nil
+	}
+
+	tools := ai.NewRegistry()
+	handlers.RegisterSessionTools(tools, sessionMgr, store)
+	if err := tools.SetPermissions(cfg.AIToolPermissions); err != nil {
+		return nil, fmt.Errorf("invalid ai_tool_permissions: %w", err)
+	}
+	return ai.NewAssistant(newAIProvider(cfg), tools, cfg.AIMaxToolRounds), nil
+}
+
+// newContentFilter creates the keyword filter configured in cfg, or nil when
+// it has no rules
+func newContentFilter(cfg *config.Config) (moderation.Filter, error) {
+	if !cfg.ContentFilterEnabled() {
+		return nil, nil
+	}
+	filter, err := moderation.NewKeywordFilter(cfg.ContentFilterKeywords, cfg.ContentFilterPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content filter: %w", err)
+	}
+	return filter, nil
+}
+
+// newAIProvider creates the provider selected in cfg, failing over to the
+// fallback providers
+func newAIProvider(cfg *config.Config) ai.Provider {
+	names := append([]string{cfg.AIProviderName()}, cfg.AIFallbackProviders...)
+	backends := make([]ai.Backend, len(names))
+	for i, name := range names {
+		backends[i] = ai.Backend{Name: name, Provider: newAIBackend(cfg, name)}
+	}
+	return ai.NewFailoverProvider(backends, ai.FailoverOptions{
+		Retries:          cfg.AIRetries,
+		RetryBackoff:     aiRetryBackoff,
+		FailureThreshold: cfg.AICircuitFailureThreshold,
+		OpenDuration:     time.Duration(cfg.AICircuitOpenSeconds) * time.Second,
+	})
+}
+
+// aiRetryBackoff is the wait before retrying a failed AI request, growing with each attempt
+const aiRetryBackoff = 500 * time.Millisecond
+
+// newAIBackend creates the provider called name. Empty base URLs use the
+// provider's default; the provider's model applies when users have no ai_models
+// to choose from.
+func newAIBackend(cfg *config.Config, name string) ai.Provider {
+	timeout := time.Duration(cfg.AITimeoutSeconds) * time.Second
+	baseURL := func(configured, fallback string) string {
+		if configured == "" {
+			return fallback
+		}
+		return configured
+	}
+
+	switch name {
+	case config.AIProviderAnthropic:
+		return ai.NewAnthropicProvider(baseURL(cfg.AnthropicBaseURL, ai.DefaultAnthropicBaseURL),
+			cfg.AnthropicAPIKey, cfg.AnthropicModel, timeout)
+	case config.AIProviderOllama:
+		return ai.NewOllamaProvider(baseURL(cfg.OllamaBaseURL, ai.DefaultOllamaBaseURL), cfg.OllamaModel, timeout)
+	default:
+		return ai.NewOpenAIProvider(baseURL(cfg.OpenAIBaseURL, ai.DefaultOpenAIBaseURL),
+			cfg.OpenAIAPIKey, cfg.OpenAIModel, timeout)
+	}
+}
+
+// sqliteOptions returns the session store tuning from the configuration
+func sqliteOptions(cfg *config.Config) session.SQLiteOptions {
+	return session.SQLiteOptions{
+		MaxOpenConns: cfg.DatabaseMaxOpenConns,
+		BusyTimeout:  time.Duration(cfg.DatabaseBusyTimeoutMS) * time.Millisecond,
+		Synchronous:  cfg.DatabaseSynchronous,
+		CacheSizeKiB: cfg.DatabaseCacheSizeKiB,
+	}
+}
+
+// newStorageBackend selects the file storage backend configured in cfg
+func newStorageBackend(cfg *config.Config) (storage.Backend, error) {
+	switch cfg.StorageBackend {
+	case "", "local":
+		downloadDir := cfg.DownloadDir
+		if downloadDir == "" {
+			downloadDir = "download"
+		}
+		return storage.NewLocalBackend(downloadDir), nil
+	case "s3":
+		return storage.NewS3Backend(storage.S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			Prefix:          cfg.S3Prefix,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			UsePathStyle:    cfg.S3UsePathStyle,
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+// usage lists the subcommands; without one the binary runs the bot
+const usage = `usage: tg-bot-demo [command] [flags]
+
+Commands:
+  serve         run the bot (default)
+  migrate       bring the database schema up to date
+  migrate-data  copy all users' data to another store
+  export        dump everything stored about a user as JSON
+  users         list users with stored data
+  seed          fill the database with demo users, sessions and messages
+  config        check or print the effective configuration
+  replay        feed recorded webhook requests through the handlers again
+  simulate      send fake updates to a running bot or an in-process one
+
+Run "tg-bot-demo <command> -h" for the flags of a command.`
+
+func main() {
+	// Flags without a command, e.g. "tg-bot-demo -config bot.json", run the bot
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
-	if *defaultStatus != 0 {
-		cfg.DefaultStatus = *defaultStatus
+
+	var err error
+	switch command {
+	case "serve":
+		err = runServe(args)
+	case "migrate":
+		err = runMigrate(args, os.Stdout)
+	case "migrate-data":
+		err = runMigrateData(args, os.Stdout)
+	case "export":
+		err = runExport(args, os.Stdout)
+	case "users":
+		err = runUsers(args, os.Stdout)
+	case "seed":
+		err = runSeed(args, os.Stdout)
+	case "config":
+		err = runConfig(args, os.Stdout)
+	case "replay":
+		err = runReplay(args)
+	case "simulate":
+		err = runSimulate(args)
+	case "help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", command, usage)
+		os.Exit(2)
 	}
-	if *dbPath != "" {
-		cfg.DatabasePath = *dbPath
+	if err != nil {
+		log.Fatalf("%s: %v", command, err)
 	}
-	if *sessionsPerPage != 0 {
-		cfg.SessionsPerPage = *sessionsPerPage
+}
+
+// runServe implements the serve subcommand: it runs the bot until the webhook
+// server fails or the process is asked to stop
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+
+	// Load configuration with command-line overrides
+	cfg, err := overrides.load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	// Validate final configuration
 	if err := cfg.Validate(); err != nil {
-		log.Fatalf("invalid configuration: %v", err)
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	// Ensure database directory exists
 	dbDir := filepath.Dir(cfg.DatabasePath)
 	if err := os.MkdirAll(dbDir, 0o755); err != nil {
-		log.Fatalf("failed to create database directory: %v", err)
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	// Set up tracing; spans are only exported when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:       cfg.TracingEndpoint,
+		SampleRatio:    cfg.TracingSampleRatio,
+		ServiceName:    "tg-bot-demo",
+		ServiceVersion: version,
+	})
+	if err != nil {
+		return fmt.Errorf("set up tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Set up error reporting; without a DSN errors are only logged
+	if cfg.SentryDSN != "" {
+		reporter, err := reporting.NewSentryReporter(reporting.SentryConfig{
+			DSN:         cfg.SentryDSN,
+			Environment: cfg.SentryEnvironment,
+			Release:     version,
+		})
+		if err != nil {
+			return fmt.Errorf("set up error reporting: %w", err)
+		}
+		reporting.SetReporter(reporter)
+		defer reporting.Flush(2 * time.Second)
 	}
 
 	// Initialize bot with session management
-	tgBot, store, err := initializeBot(cfg)
+	app, err := initializeBot(cfg)
 	if err != nil {
-		log.Fatalf("initialize bot: %v", err)
+		return fmt.Errorf("initialize bot: %w", err)
 	}
-	defer store.Close()
+	defer app.Close()
+
+	// SIGINT and SIGTERM stop the webhook server and drain the update queue; the
+	// deferred cancel and app.Close then stop the workers and jobs and save state
+	// such as rate limits
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	go tgBot.StartWebhook(ctx)
+	go app.updates.Run(ctx)
+
+	// Point Telegram at this server with the configured update types
+	if cfg.WebhookURL != "" {
+		if err := registerWebhook(ctx, app.bot, cfg); err != nil {
+			return fmt.Errorf("register webhook: %w", err)
+		}
+	}
+
+	// Start storage retention job
+	if cfg.CleanupIntervalMinutes > 0 {
+		app.cleaner.Start(ctx, time.Duration(cfg.CleanupIntervalMinutes)*time.Minute)
+	}
+
+	// Warn about and archive idle sessions on the retention schedule
+	if app.expiry != nil && cfg.CleanupIntervalMinutes > 0 {
+		app.expiry.Start(ctx, app.bot, time.Duration(cfg.CleanupIntervalMinutes)*time.Minute)
+	}
+
+	// Start database maintenance job
+	if cfg.DatabaseMaintenanceIntervalMinutes > 0 {
+		app.maintenance.Start(ctx, time.Duration(cfg.DatabaseMaintenanceIntervalMinutes)*time.Minute)
+	}
+
+	// Deliver notifications deferred during users' quiet hours
+	app.notifier.Start(ctx, app.bot, deferredDeliveryInterval)
+
+	// Retry replies whose delivery failed or was cut short by a restart
+	app.outbox.Start(ctx, app.bot, outboxDeliveryInterval)
+
+	// Drop refilled rate limit buckets from memory
+	if app.limiter != nil {
+		app.limiter.Start(ctx, time.Minute)
+	}
+
+	// Drop finished command cooldowns from memory
+	if app.cooldowns != nil {
+		app.cooldowns.Start(ctx, time.Minute)
+	}
+
+	// Drop users without recent files or a download pause from memory
+	if app.floods != nil {
+		app.floods.detector.Start(ctx, time.Minute)
+	}
+
+	// Start scheduled database backups
+	if cfg.BackupSchedule != "" {
+		schedule, err := backup.ParseSchedule(cfg.BackupSchedule)
+		if err != nil {
+			return fmt.Errorf("invalid backup_schedule: %w", err)
+		}
+		app.backup.Start(ctx, schedule)
+	}
 
-	tgWebhookHandler := tgBot.WebhookHandler()
+	// Serve the session API to other backend services over mutual TLS
+	if cfg.GRPCListenAddr != "" {
+		go func() {
+			log.Fatal(serveGRPC(app.sessionAPI, cfg))
+		}()
+	}
+
+	// Set up the request log; the sink decides where webhook requests are recorded
+	requestLog, err := newRequestLogger(cfg)
+	if err != nil {
+		return fmt.Errorf("set up request log: %w", err)
+	}
+	defer requestLog.Close()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc(cfg.WebhookPath, webhookHandler(tgWebhookHandler, cfg.DefaultStatus))
+	mux.HandleFunc(cfg.WebhookPath, webhookHandler(app.updates, cfg.SecretToken, cfg.DefaultStatus, requestLog))
+	app.shares.Register(mux)
+	if cfg.AdminToken != "" {
+		dashboard.New(app.store, cfg.AdminToken).Register(mux)
+	}
 
 	server := &http.Server{
 		Addr:              cfg.ListenAddr,
@@ -150,12 +930,28 @@ func main() {
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	log.Printf("webhook server started: listen=%s path=%s default_status=%d sessions_per_page=%d",
-		cfg.ListenAddr, cfg.WebhookPath, cfg.DefaultStatus, cfg.SessionsPerPage)
-	log.Fatal(server.ListenAndServe())
+	log.Printf("webhook server started: version=%s listen=%s path=%s tls=%s default_status=%d update_queue_size=%d update_workers=%d sessions_per_page=%d storage=%s request_log=%s dashboard=%t tracing=%t error_reporting=%t",
+		version, cfg.ListenAddr, cfg.WebhookPath, tlsMode(cfg), cfg.DefaultStatus, cfg.UpdateQueueSize, cfg.UpdateWorkers, cfg.SessionsPerPage, cfg.StorageBackend, cfg.RequestLogSink, cfg.AdminToken != "", cfg.TracingEndpoint != "", cfg.SentryDSN != "")
+	if err := serveWebhook(signals, server, cfg); err != nil {
+		return err
+	}
+
+	// No more updates come in; finish the queued ones before the workers are cancelled
+	log.Printf("webhook server stopped, draining update queue: queued=%d", app.updates.Len())
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), updateDrainTimeout)
+	defer cancelDrain()
+	if err := app.updates.Drain(drainCtx); err != nil {
+		log.Printf("update queue not drained, dropping queued updates: queued=%d err=%v", app.updates.Len(), err)
+	}
+	return nil
 }
 
-func webhookHandler(tgHandler http.HandlerFunc, defaultStatus int) http.HandlerFunc {
+// webhookHandler queues the updates Telegram posts for the bot's workers and
+// answers with defaultStatus, or a status requested with ?status=. When the
+// queue is full it answers 429 so Telegram retries the update later. Workers
+// handle updates after the request has been answered, so its cancellation is
+// not carried to them; only the session operation timeout bounds their work.
+func webhookHandler(updates *updatequeue.Queue, secretToken string, defaultStatus int, requestLog *requestlog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
@@ -166,17 +962,72 @@ func webhookHandler(tgHandler http.HandlerFunc, defaultStatus int) http.HandlerF
 		defer r.Body.Close()
 
 		status := resolveStatus(defaultStatus, r.URL.Query().Get("status"))
-		requestID := time.Now().Format("20060102-150405.000000")
-		logRequest(requestID, r, body, status)
+		requestID := correlation.NewID()
+
+		// Start the update's trace and request ID here; handlers run on the queue's
+		// workers and pick both up again through the remembered update ID
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(correlation.WithID(ctx, requestID), "webhook",
+			trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attribute.String("request_id", requestID)))
+		defer span.End()
+		if updateID, ok := parseUpdateID(body); ok {
+			span.SetAttributes(attribute.Int64("telegram.update_id", updateID))
+		}
 
-		r.Body = io.NopCloser(bytes.NewReader(body))
-		tgHandler(newDiscardResponseWriter(), r)
+		var update models.Update
+		switch {
+		case r.Context().Err() != nil:
+			// Telegram gave up on the request and sends the update again
+			log.Printf("webhook: request aborted before the update was queued: request_id=%s", requestID)
+		case secretToken != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Telegram-Bot-Api-Secret-Token")), []byte(secretToken)) != 1:
+			log.Printf("webhook: invalid secret token: request_id=%s", requestID)
+		case json.Unmarshal(body, &update) != nil:
+			log.Printf("webhook: undecodable update: request_id=%s", requestID)
+		default:
+			// Remembered before Offer so a worker that takes the update right
+			// away finds it; forgotten again when it could not be queued
+			correlation.Remember(ctx, update.ID)
+			if !updates.Offer(&update) {
+				correlation.Forget(update.ID)
+				log.Printf("webhook: update queue full, asking Telegram to retry: request_id=%s queued=%d", requestID, updates.Len())
+				status = http.StatusTooManyRequests
+				w.Header().Set("Retry-After", "1")
+			}
+		}
+		logRequest(requestLog, requestID, r, body, status)
 
 		w.WriteHeader(status)
 		_, _ = w.Write([]byte(fmt.Sprintf("status=%d\n", status)))
 	}
 }
 
+// registerWebhook sets the bot's webhook to cfg.WebhookURL with the secret
+// token and allowed updates of cfg. The update types are always listed, as
+// Telegram would otherwise keep those of the previous registration.
+func registerWebhook(ctx context.Context, b *bot.Bot, cfg *config.Config) error {
+	allowed := cfg.AllowedUpdateTypes()
+	if _, err := b.SetWebhook(ctx, &bot.SetWebhookParams{
+		URL:            cfg.WebhookURL,
+		AllowedUpdates: allowed,
+		SecretToken:    cfg.SecretToken,
+	}); err != nil {
+		return err
+	}
+	log.Printf("webhook registered: url=%s allowed_updates=%s", cfg.WebhookURL, strings.Join(allowed, ","))
+	return nil
+}
+
+// parseUpdateID returns the update_id of a webhook body
+func parseUpdateID(body []byte) (int64, bool) {
+	var update struct {
+		UpdateID *int64 `json:"update_id"`
+	}
+	if err := json.Unmarshal(body, &update); err != nil || update.UpdateID == nil {
+		return 0, false
+	}
+	return *update.UpdateID, true
+}
+
 func resolveStatus(defaultStatus int, raw string) int {
 	if raw == "" {
 		return defaultStatus
@@ -188,127 +1039,111 @@ func resolveStatus(defaultStatus int, raw string) int {
 	return parsed
 }
 
-type requestLog struct {
-	RequestID     string         `json:"request_id"`
-	ReceivedAt    string         `json:"received_at"`
-	Method        string         `json:"method"`
-	RequestURI    string         `json:"request_uri"`
-	Proto         string         `json:"proto"`
-	RemoteAddr    string         `json:"remote_addr"`
-	ContentLength int            `json:"content_length"`
-	Headers       []headerRecord `json:"headers"`
-	Body          any            `json:"body"`
-	ResponseCode  int            `json:"response_code"`
-}
+// newRequestLogger selects the request log sink configured in cfg
+func newRequestLogger(cfg *config.Config) (*requestlog.Logger, error) {
+	var sink requestlog.Sink
+	switch cfg.RequestLogSink {
+	case "", "stdout":
+		sink = requestlog.NewWriterSink(os.Stdout)
+	case "file":
+		path := cfg.RequestLogPath
+		if path == "" {
+			path = "./data/requests.jsonl"
+		}
+		fileSink, err := requestlog.NewFileSink(path, cfg.RequestLogMaxFileBytes, cfg.RequestLogMaxFiles)
+		if err != nil {
+			return nil, err
+		}
+		sink = fileSink
+	case "sqlite":
+		path := cfg.RequestLogPath
+		if path == "" {
+			path = "./data/requests.db"
+		}
+		sqliteSink, err := requestlog.NewSQLiteSink(path)
+		if err != nil {
+			return nil, err
+		}
+		sink = sqliteSink
+	case "none":
+		sink = requestlog.DiscardSink{}
+	default:
+		return nil, fmt.Errorf("unknown request log sink %q", cfg.RequestLogSink)
+	}
 
-type headerRecord struct {
-	Name   string   `json:"name"`
-	Values []string `json:"values"`
+	return requestlog.NewLogger(sink, requestlog.Options{
+		SampleRatio:  cfg.RequestLogSampleRatio,
+		MaxBodyBytes: cfg.RequestLogMaxBodyBytes,
+	}), nil
 }
 
-func logRequest(requestID string, r *http.Request, body []byte, status int) {
-	logItem := requestLog{
+// logRequest hands the webhook request to the request log
+func logRequest(requestLog *requestlog.Logger, requestID string, r *http.Request, body []byte, status int) {
+	requestLog.Log(requestlog.Record{
 		RequestID:     requestID,
-		ReceivedAt:    time.Now().Format(time.RFC3339Nano),
+		ReceivedAt:    time.Now(),
 		Method:        r.Method,
 		RequestURI:    r.URL.RequestURI(),
 		Proto:         r.Proto,
 		RemoteAddr:    r.RemoteAddr,
 		ContentLength: len(body),
-		Headers:       collectHeaders(r.Header),
-		Body:          parseBody(body),
+		Headers:       requestlog.CollectHeaders(r.Header),
+		Body:          body,
 		ResponseCode:  status,
-	}
-
-	pretty, err := json.MarshalIndent(logItem, "", "  ")
-	if err != nil {
-		log.Printf("marshal request log error: %v", err)
-		return
-	}
-	fmt.Println(string(pretty))
-}
-
-func collectHeaders(headers http.Header) []headerRecord {
-	keys := make([]string, 0, len(headers))
-	for key := range headers {
-		keys = append(keys, key)
-	}
-	sort.Strings(keys)
-
-	result := make([]headerRecord, 0, len(keys))
-	for _, key := range keys {
-		result = append(result, headerRecord{
-			Name:   key,
-			Values: headers[key],
-		})
-	}
-	return result
+	})
 }
 
-func parseBody(body []byte) any {
-	trimmed := bytes.TrimSpace(body)
-	if len(trimmed) == 0 {
-		return ""
-	}
-
-	var decoded any
-	if err := json.Unmarshal(trimmed, &decoded); err == nil {
-		return decoded
-	}
-
-	return string(body)
+type fileTarget struct {
+	Kind     string
+	FileID   string
+	FileName string
+	MimeType string
+	Sticker  *session.StickerInfo
 }
 
-type discardResponseWriter struct {
-	headers http.Header
+// downloadedFile describes a file written to the storage backend
+type downloadedFile struct {
+	Key      string
+	Location string
+	FilePath string // path reported by getFile, e.g. photos/file_1.jpg
+	Size     int64
 }
 
-func newDiscardResponseWriter() *discardResponseWriter {
-	return &discardResponseWriter{
-		headers: make(http.Header),
+// updateHandler returns the default handler that replies OK and hands file media to ingestor
+func updateHandler(ingestor *fileIngestor) handlers.HandlerFunc {
+	return func(ctx context.Context, b handlers.TelegramAPI, update *models.Update) {
+		handleUpdate(ctx, b, ingestor, update)
 	}
 }
 
-func (d *discardResponseWriter) Header() http.Header {
-	return d.headers
-}
-
-func (d *discardResponseWriter) Write(p []byte) (int, error) {
-	return len(p), nil
-}
-
-func (d *discardResponseWriter) WriteHeader(int) {}
+func handleUpdate(ctx context.Context, b handlers.TelegramAPI, ingestor *fileIngestor, update *models.Update) {
+	incoming := incomingUserMessageFromUpdate(update)
+	message := messageFromUpdate(update)
 
-type fileTarget struct {
-	Kind   string
-	FileID string
-}
+	// Album parts are buffered and acknowledged with a single consolidated reply
+	if message != nil && message.MediaGroupID != "" && len(collectFileTargets(message)) > 0 {
+		ingestor.albums.Add(ctx, b, message, shouldReplyOK(incoming) && incoming == message)
+		return
+	}
 
-func handleUpdate(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if incoming := incomingUserMessageFromUpdate(update); shouldReplyOK(incoming) {
-		if _, err := b.SendMessage(ctx, buildOKReply(incoming)); err != nil {
-			log.Printf("reply failed: chat_id=%v message_id=%d err=%v", incoming.Chat.ID, incoming.ID, err)
+	if shouldReplyOK(incoming) {
+		if _, err := b.SendMessage(ctx, buildOKReply(ctx, incoming)); err != nil {
+			log.Printf("reply failed: request_id=%s chat_id=%v message_id=%d err=%v", correlation.ID(ctx), incoming.Chat.ID, incoming.ID, err)
 		}
 	}
 
-	message := messageFromUpdate(update)
 	if message == nil {
 		return
 	}
 
-	targets := collectFileTargets(message)
-	if len(targets) == 0 {
-		return
-	}
-
-	username := messageUsername(message)
-	for _, target := range targets {
-		outputPath, size, err := downloadTelegramFile(ctx, b, username, target.FileID)
-		if err != nil {
-			log.Printf("download failed: type=%s username=%s file_id=%s err=%v", target.Kind, username, target.FileID, err)
-			continue
+	result := ingestor.ingest(ctx, b, message, "")
+	if len(result.extracted) > 0 && shouldReplyOK(incoming) && incoming == message {
+		params := buildOKReply(ctx, incoming)
+		params.Text = strings.Join(result.extracted, "\n\n")
+		params.ParseMode = models.ParseModeHTML
+		if _, err := b.SendMessage(ctx, params); err != nil {
+			log.Printf("reply failed: request_id=%s chat_id=%v message_id=%d err=%v", correlation.ID(ctx), incoming.Chat.ID, incoming.ID, err)
 		}
-		log.Printf("downloaded: type=%s username=%s file_id=%s bytes=%d path=%s", target.Kind, username, target.FileID, size, outputPath)
 	}
 }
 
@@ -336,10 +1171,10 @@ func shouldReplyOK(message *models.Message) bool {
 	return true
 }
 
-func buildOKReply(message *models.Message) *bot.SendMessageParams {
+func buildOKReply(ctx context.Context, message *models.Message) *bot.SendMessageParams {
 	params := &bot.SendMessageParams{
 		ChatID: message.Chat.ID,
-		Text:   "OK",
+		Text:   i18n.FromContext(ctx).T("OK"),
 		ReplyParameters: &models.ReplyParameters{
 			MessageID:                message.ID,
 			AllowSendingWithoutReply: true,
@@ -351,6 +1186,9 @@ func buildOKReply(message *models.Message) *bot.SendMessageParams {
 	if message.DirectMessagesTopic != nil {
 		params.DirectMessagesTopicID = message.DirectMessagesTopic.TopicID
 	}
+	if message.BusinessConnectionID != "" {
+		params.BusinessConnectionID = message.BusinessConnectionID
+	}
 	return params
 }
 
@@ -377,48 +1215,69 @@ func collectFileTargets(message *models.Message) []fileTarget {
 	targets := make([]fileTarget, 0, 8)
 	seen := make(map[string]struct{})
 
-	add := func(kind, fileID string) {
-		if fileID == "" {
+	add := func(target fileTarget) {
+		if target.FileID == "" {
 			return
 		}
-		if _, ok := seen[fileID]; ok {
+		if _, ok := seen[target.FileID]; ok {
 			return
 		}
-		seen[fileID] = struct{}{}
-		targets = append(targets, fileTarget{
-			Kind:   kind,
-			FileID: fileID,
-		})
+		seen[target.FileID] = struct{}{}
+		targets = append(targets, target)
 	}
 
 	if message.Document != nil {
-		add("document", message.Document.FileID)
+		add(fileTarget{Kind: "document", FileID: message.Document.FileID, FileName: message.Document.FileName, MimeType: message.Document.MimeType})
 	}
 	if message.Animation != nil {
-		add("animation", message.Animation.FileID)
+		add(fileTarget{Kind: "animation", FileID: message.Animation.FileID, FileName: message.Animation.FileName, MimeType: message.Animation.MimeType})
 	}
 	if message.Audio != nil {
-		add("audio", message.Audio.FileID)
+		add(fileTarget{Kind: "audio", FileID: message.Audio.FileID, FileName: message.Audio.FileName, MimeType: message.Audio.MimeType})
 	}
 	if message.Video != nil {
-		add("video", message.Video.FileID)
+		add(fileTarget{Kind: "video", FileID: message.Video.FileID, FileName: message.Video.FileName, MimeType: message.Video.MimeType})
 	}
 	if message.VideoNote != nil {
-		add("video_note", message.VideoNote.FileID)
+		add(fileTarget{Kind: "video_note", FileID: message.VideoNote.FileID})
 	}
 	if message.Voice != nil {
-		add("voice", message.Voice.FileID)
+		add(fileTarget{Kind: "voice", FileID: message.Voice.FileID, MimeType: message.Voice.MimeType})
 	}
 	if message.Sticker != nil {
-		add("sticker", message.Sticker.FileID)
+		add(fileTarget{Kind: "sticker", FileID: message.Sticker.FileID, MimeType: stickerMimeType(message.Sticker), Sticker: stickerInfo(message.Sticker)})
 	}
 	if photo := largestPhoto(message.Photo); photo != nil {
-		add("photo", photo.FileID)
+		add(fileTarget{Kind: "photo", FileID: photo.FileID})
 	}
 
 	return targets
 }
 
+// stickerInfo extracts the catalog metadata of a sticker
+func stickerInfo(sticker *models.Sticker) *session.StickerInfo {
+	return &session.StickerInfo{
+		SetName:       sticker.SetName,
+		Emoji:         sticker.Emoji,
+		Type:          sticker.Type,
+		CustomEmojiID: sticker.CustomEmojiID,
+		IsAnimated:    sticker.IsAnimated,
+		IsVideo:       sticker.IsVideo,
+	}
+}
+
+// stickerMimeType returns the file format of a sticker: TGS for animated, WebM for video, WebP otherwise
+func stickerMimeType(sticker *models.Sticker) string {
+	switch {
+	case sticker.IsAnimated:
+		return "application/x-tgsticker"
+	case sticker.IsVideo:
+		return "video/webm"
+	default:
+		return "image/webp"
+	}
+}
+
 func largestPhoto(photos []models.PhotoSize) *models.PhotoSize {
 	if len(photos) == 0 {
 		return nil
@@ -461,53 +1320,144 @@ func messageUsername(message *models.Message) string {
 	return "unknown"
 }
 
-func downloadTelegramFile(ctx context.Context, b *bot.Bot, username, fileID string) (string, int64, error) {
+// messageOwnerID returns the ID that owns files sent in message: the sender, or the sender chat
+func messageOwnerID(message *models.Message) int64 {
+	if message.From != nil && message.From.ID != 0 {
+		return message.From.ID
+	}
+	if message.SenderChat != nil {
+		return message.SenderChat.ID
+	}
+	return message.Chat.ID
+}
+
+func downloadTelegramFile(ctx context.Context, b handlers.TelegramAPI, client *http.Client, localDir string, fileStorage storage.Backend,
+	key, fileID string) (_ *downloadedFile, err error) {
+	// The deadline also covers getFile and storing the file, so a stuck transfer
+	// cannot hold the handler goroutine forever
+	if client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "download", trace.WithAttributes(attribute.String("telegram.file_id", fileID)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	fileInfo, err := b.GetFile(ctx, &bot.GetFileParams{
 		FileID: fileID,
 	})
 	if err != nil {
-		return "", 0, fmt.Errorf("call getFile: %w", err)
+		return nil, fmt.Errorf("call getFile: %w", err)
 	}
 	if fileInfo.FilePath == "" {
-		return "", 0, fmt.Errorf("empty file_path from getFile")
+		return nil, fmt.Errorf("empty file_path from getFile")
+	}
+
+	content, size, err := openTelegramFile(ctx, b, client, localDir, fileInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	written, err := fileStorage.Put(ctx, key, content, size)
+	if err != nil {
+		return nil, fmt.Errorf("store file: %w", err)
+	}
+
+	return &downloadedFile{
+		Key:      key,
+		Location: fileStorage.Location(key),
+		FilePath: fileInfo.FilePath,
+		Size:     written,
+	}, nil
+}
+
+// openTelegramFile opens the content of a file returned by getFile and returns its size,
+// or -1 when unknown. A Bot API server running with --local returns absolute paths on its
+// own disk instead of download paths; with localDir set to that server's working directory,
+// those are read directly when they lie inside it, so the bot needs access to the
+// directory at the same path. Absolute paths are refused otherwise.
+func openTelegramFile(ctx context.Context, b handlers.TelegramAPI, client *http.Client, localDir string,
+	fileInfo *models.File) (io.ReadCloser, int64, error) {
+	if filepath.IsAbs(fileInfo.FilePath) {
+		path, err := localBotAPIPath(localDir, fileInfo.FilePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("open local Bot API file: %w", err)
+		}
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, 0, fmt.Errorf("stat local Bot API file: %w", err)
+		}
+		if !stat.Mode().IsRegular() {
+			file.Close()
+			return nil, 0, fmt.Errorf("local Bot API file %q is not a regular file", fileInfo.FilePath)
+		}
+		return file, stat.Size(), nil
 	}
 
 	downloadURL := b.FileDownloadLink(fileInfo)
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
 	if err != nil {
-		return "", 0, fmt.Errorf("create download request: %w", err)
+		return nil, 0, fmt.Errorf("create download request: %w", err)
 	}
 
-	response, err := http.DefaultClient.Do(request)
+	response, err := client.Do(request)
 	if err != nil {
-		return "", 0, fmt.Errorf("download file: %w", err)
+		return nil, 0, fmt.Errorf("download file: %w", err)
 	}
-	defer response.Body.Close()
-
 	if response.StatusCode != http.StatusOK {
-		return "", 0, fmt.Errorf("download file status: %d", response.StatusCode)
+		response.Body.Close()
+		return nil, 0, fmt.Errorf("download file status: %d", response.StatusCode)
 	}
 
-	safeUsername := sanitizePathSegment(username, "unknown")
-	safeFileID := sanitizePathSegment(fileID, "file")
-	outputPath := filepath.Join("download", safeUsername, safeFileID)
+	return response.Body, response.ContentLength, nil
+}
 
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
-		return "", 0, fmt.Errorf("create output directory: %w", err)
+// localBotAPIPath resolves path, an absolute path returned by a local-mode Bot API
+// server, and checks that it lies inside localDir, the server's working directory.
+// Symlinks are resolved first so none can lead outside it.
+func localBotAPIPath(localDir, path string) (string, error) {
+	if localDir == "" {
+		return "", fmt.Errorf("Bot API returned the local path %q but api_local_dir is not set", path)
 	}
-
-	file, err := os.Create(outputPath)
+	root, err := filepath.EvalSymlinks(localDir)
 	if err != nil {
-		return "", 0, fmt.Errorf("create output file: %w", err)
+		return "", fmt.Errorf("resolve api_local_dir: %w", err)
 	}
-	defer file.Close()
-
-	written, err := io.Copy(file, response.Body)
+	resolved, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		return "", 0, fmt.Errorf("write output file: %w", err)
+		return "", fmt.Errorf("resolve local Bot API file: %w", err)
 	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("local Bot API file %q is outside api_local_dir", path)
+	}
+	return resolved, nil
+}
 
-	return outputPath, written, nil
+// fileStorageKey builds the storage key {username}/{file_id}_{catalog_id} for a
+// downloaded file, or {username}/{media_group_id}/{file_id}_{catalog_id} for album
+// parts. The catalog ID keeps a file sent twice from sharing one object, so
+// deleting one catalog row never removes the file behind another.
+func fileStorageKey(username, mediaGroupID, fileID string, catalogID uuid.UUID) string {
+	safeUsername := sanitizePathSegment(username, "unknown")
+	safeFileID := sanitizePathSegment(fileID, "file") + "_" + catalogID.String()
+	if mediaGroupID == "" {
+		return safeUsername + "/" + safeFileID
+	}
+	return safeUsername + "/" + sanitizePathSegment(mediaGroupID, "album") + "/" + safeFileID
 }
 
 func sanitizePathSegment(raw, fallback string) string {
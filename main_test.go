@@ -1,11 +1,20 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"tg-bot-demo/config"
+	"tg-bot-demo/requestlog"
+	"tg-bot-demo/updatequeue"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
 )
 
 func TestInitializeBot(t *testing.T) {
@@ -25,11 +34,12 @@ func TestInitializeBot(t *testing.T) {
 	}
 
 	// Initialize the bot
-	bot, store, err := initializeBot(cfg)
+	app, err := initializeBot(cfg)
 	if err != nil {
 		t.Fatalf("initializeBot failed: %v", err)
 	}
-	defer store.Close()
+	defer app.Close()
+	bot, store := app.bot, app.store
 
 	// Verify bot was created
 	if bot == nil {
@@ -62,8 +72,81 @@ func TestInitializeBotWithInvalidDBPath(t *testing.T) {
 	}
 
 	// Initialize the bot - should fail
-	_, _, err := initializeBot(cfg)
+	_, err := initializeBot(cfg)
 	if err == nil {
 		t.Fatal("expected error with invalid database path, got nil")
 	}
 }
+
+func TestParseUpdateID(t *testing.T) {
+	if id, ok := parseUpdateID([]byte(`{"update_id": 123, "message": {}}`)); !ok || id != 123 {
+		t.Errorf("expected update 123, got %d (ok=%v)", id, ok)
+	}
+	if _, ok := parseUpdateID([]byte(`{"message": {}}`)); ok {
+		t.Error("expected no update id without update_id")
+	}
+	if _, ok := parseUpdateID([]byte(`not json`)); ok {
+		t.Error("expected no update id for invalid JSON")
+	}
+}
+
+func TestWebhookHandlerQueueFull(t *testing.T) {
+	queue := updatequeue.New(1, 1, func(context.Context, *models.Update) {})
+	handler := webhookHandler(queue, "secret", 200, requestlog.NewLogger(requestlog.DiscardSink{}, requestlog.Options{}))
+	post := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"update_id": 1}`))
+		r.Header.Set("X-Telegram-Bot-Api-Secret-Token", token)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	if w := post("wrong"); w.Code != 200 || queue.Len() != 0 {
+		t.Fatalf("expected an update with a wrong secret dropped, got status %d and %d queued", w.Code, queue.Len())
+	}
+	if w := post("secret"); w.Code != 200 || queue.Len() != 1 {
+		t.Fatalf("expected the update queued, got status %d and %d queued", w.Code, queue.Len())
+	}
+	w := post("secret")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After for a full queue, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRegisterWebhook(t *testing.T) {
+	var method, allowed, secret string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		method, allowed, secret = filepath.Base(r.URL.Path), r.FormValue("allowed_updates"), r.FormValue("secret_token")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer server.Close()
+
+	b, err := bot.New("123456:test-token", bot.WithSkipGetMe(), bot.WithServerURL(server.URL))
+	if err != nil {
+		t.Fatalf("bot.New failed: %v", err)
+	}
+	cfg := config.Default()
+	cfg.WebhookURL = "https://bot.example.com/webhook"
+	cfg.SecretToken = "secret"
+
+	if err := registerWebhook(context.Background(), b, cfg); err != nil {
+		t.Fatalf("registerWebhook failed: %v", err)
+	}
+	if method != "setWebhook" || secret != "secret" {
+		t.Errorf("expected setWebhook with the secret token, got %s secret=%q", method, secret)
+	}
+	// Without allowed_updates Telegram's defaults are listed explicitly
+	if !strings.Contains(allowed, `"callback_query"`) || strings.Contains(allowed, "message_reaction") {
+		t.Errorf("expected the default update types, got %s", allowed)
+	}
+
+	cfg.AllowedUpdates = []string{"message", "message_reaction"}
+	if err := registerWebhook(context.Background(), b, cfg); err != nil {
+		t.Fatalf("registerWebhook failed: %v", err)
+	}
+	if allowed != `["message","message_reaction"]` {
+		t.Errorf("expected the configured update types, got %s", allowed)
+	}
+}
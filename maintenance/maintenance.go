@@ -0,0 +1,63 @@
+package maintenance
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"tg-bot-demo/session"
+)
+
+// Package maintenance keeps a long-lived SQLite database compact and fast: it
+// checkpoints the write-ahead log, refreshes planner statistics with ANALYZE and
+// optionally returns free pages to the file system.
+
+// Job runs database maintenance on a schedule or on demand
+type Job struct {
+	store   session.MaintenanceStore
+	options session.MaintenanceOptions
+	mu      sync.Mutex
+}
+
+// New creates a maintenance job for store
+func New(store session.MaintenanceStore, options session.MaintenanceOptions) *Job {
+	return &Job{store: store, options: options}
+}
+
+// Start runs the job every interval until ctx is cancelled. Unlike the retention
+// cleaner it waits one interval first, so restarts do not vacuum every time.
+func (j *Job) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := j.Run(ctx); err != nil {
+				log.Printf("database maintenance failed: trigger=scheduled err=%v", err)
+			}
+		}
+	}()
+}
+
+// Run performs one maintenance pass; concurrent runs wait for each other
+func (j *Job) Run(ctx context.Context) (*session.MaintenanceReport, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	started := time.Now()
+	report, err := j.store.Maintain(ctx, j.options)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("database maintenance: bytes_before=%d bytes_after=%d checkpointed_frames=%d checkpoint_busy=%t duration=%s",
+		report.BytesBefore, report.BytesAfter, report.CheckpointedFrames, report.CheckpointBusy, time.Since(started).Round(time.Millisecond))
+	return report, nil
+}
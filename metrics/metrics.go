@@ -0,0 +1,116 @@
+// Package metrics serves the expvar variables the bot publishes in the
+// Prometheus text format, so Prometheus can scrape what /debug/vars shows as JSON.
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType is the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	mu     sync.RWMutex
+	labels = make(map[string]string) // label of the keys of each map, by map name
+)
+
+// labelEscaper escapes label values as the exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// NewMap publishes an expvar map like expvar.NewMap. In Prometheus each key is
+// a series with the key as the value of label, e.g. provider="openai".
+func NewMap(name, label string) *expvar.Map {
+	mu.Lock()
+	labels[name] = label
+	mu.Unlock()
+	return expvar.NewMap(name)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		Write(w)
+	})
+}
+
+// Write writes every numeric expvar variable to w in the Prometheus text
+// format. Names ending in _total are counters and the others gauges. Maps
+// become one series per key; variables that are not numbers, such as the
+// command line and memstats, are left out.
+func Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	expvar.Do(func(kv expvar.KeyValue) {
+		name := metricName(kv.Key)
+		if m, ok := kv.Value.(*expvar.Map); ok {
+			writeType(out, name)
+			label := mapLabel(kv.Key)
+			m.Do(func(entry expvar.KeyValue) {
+				if value, ok := number(entry.Value); ok {
+					out.WriteString(name + "{" + label + `="` + labelEscaper.Replace(entry.Key) + `"} ` + value + "\n")
+				}
+			})
+			return
+		}
+		if value, ok := number(kv.Value); ok {
+			writeType(out, name)
+			out.WriteString(name + " " + value + "\n")
+		}
+	})
+	return out.Flush()
+}
+
+// writeType writes the TYPE line of the metric name
+func writeType(out *bufio.Writer, name string) {
+	kind := "gauge"
+	if strings.HasSuffix(name, "_total") {
+		kind = "counter"
+	}
+	out.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// mapLabel returns the label of the keys of the map name
+func mapLabel(name string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if label, ok := labels[name]; ok {
+		return metricName(label)
+	}
+	return "key"
+}
+
+// number formats the value of v, reporting false when it is not a number
+func number(v expvar.Var) (string, bool) {
+	switch v := v.(type) {
+	case *expvar.Int:
+		return strconv.FormatInt(v.Value(), 10), true
+	case *expvar.Float:
+		return strconv.FormatFloat(v.Value(), 'g', -1, 64), true
+	case expvar.Func:
+		switch value := v.Value().(type) {
+		case int:
+			return strconv.Itoa(value), true
+		case int64:
+			return strconv.FormatInt(value, 10), true
+		case float64:
+			return strconv.FormatFloat(value, 'g', -1, 64), true
+		}
+	}
+	return "", false
+}
+
+// metricName replaces the characters Prometheus does not allow in names with underscores
+func metricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
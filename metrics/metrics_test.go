@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	expvar.NewInt("metrics_test_requests_total").Add(3)
+	expvar.NewFloat("metrics_test_ratio").Set(0.25)
+	requests := NewMap("metrics_test_provider_requests_total", "provider")
+	requests.Add("open\"ai", 2)
+	unlabelled := expvar.NewMap("metrics_test_plain")
+	unlabelled.Add("a", 1)
+	expvar.Publish("metrics_test_utilization", expvar.Func(func() any { return 0.5 }))
+	expvar.Publish("metrics_test_text", expvar.Func(func() any { return "not a number" }))
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("expected the Prometheus text format, got %q", got)
+	}
+	body := recorder.Body.String()
+
+	for _, want := range []string{
+		"# TYPE metrics_test_requests_total counter\nmetrics_test_requests_total 3\n",
+		"# TYPE metrics_test_ratio gauge\nmetrics_test_ratio 0.25\n",
+		"# TYPE metrics_test_provider_requests_total counter\nmetrics_test_provider_requests_total{provider=\"open\\\"ai\"} 2\n",
+		"metrics_test_plain{key=\"a\"} 1\n",
+		"metrics_test_utilization 0.5\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{"metrics_test_text", "cmdline", "memstats"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("expected %s left out of\n%s", unwanted, body)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"tg-bot-demo/session"
+
+	"github.com/google/uuid"
+)
+
+// dataStore is what the migrate-data subcommand reads from and writes to. Any
+// store backend implementing it can take part in a migration.
+type dataStore interface {
+	session.Store
+	session.MessageStore
+	session.FileStore
+	session.UserSettingsStore
+	session.PreferenceStore
+	session.StatsStore
+	session.ActivityStore
+	session.BulkStore
+	session.MemoryStore
+	session.SummaryStore
+	session.DeliveryStore
+	session.BusinessConnectionStore
+	session.SettingsStore
+	session.MigrationStore
+	Close() error
+}
+
+// dataBackends are the store backends migrate-data can open, by name. A new
+// store backend implementing dataStore is registered here to take part in
+// migrations; SQLite is the only one so far.
+var dataBackends = map[string]func(dsn string) (dataStore, error){
+	"sqlite": func(dsn string) (dataStore, error) {
+		if err := os.MkdirAll(filepath.Dir(dsn), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+		return session.NewSQLiteStore(dsn)
+	},
+}
+
+// openDataStore opens a store from a "backend[:dsn]" spec. A sqlite spec
+// without a DSN opens defaultPath, the configured database.
+func openDataStore(spec, defaultPath string) (dataStore, error) {
+	backend, dsn, _ := strings.Cut(spec, ":")
+	open, ok := dataBackends[backend]
+	if !ok {
+		names := make([]string, 0, len(dataBackends))
+		for name := range dataBackends {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unsupported store backend %q; available: %s", backend, strings.Join(names, ", "))
+	}
+	if dsn == "" && backend == "sqlite" {
+		dsn = defaultPath
+	}
+	if dsn == "" {
+		return nil, fmt.Errorf("store backend %q needs a DSN, e.g. %s:DSN", backend, backend)
+	}
+	return open(dsn)
+}
+
+// migrationReport counts what migrateData copied; items already in the target are not counted
+type migrationReport struct {
+	Users, Sessions, Messages, Files int
+}
+
+// runMigrateData implements the migrate-data subcommand: it copies sessions,
+// messages, files and settings of every user and the runtime settings from one
+// store to another
+func runMigrateData(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate-data", flag.ExitOnError)
+	from := flags.String("from", "sqlite", "Store to read, as backend[:dsn]; sqlite without a path is the configured database")
+	to := flags.String("to", "", "Store to write, as backend:dsn, e.g. sqlite:path (required)")
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+	cfg, err := overrides.load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if *to == "" || *to == *from {
+		return errors.New("usage: migrate-data -from backend[:dsn] -to backend:dsn [-config file] [flags]; the stores must differ")
+	}
+	source, err := openDataStore(*from, cfg.DatabasePath)
+	if err != nil {
+		return fmt.Errorf("failed to open source store: %w", err)
+	}
+	defer source.Close()
+	target, err := openDataStore(*to, "")
+	if err != nil {
+		return fmt.Errorf("failed to open target store: %w", err)
+	}
+	defer target.Close()
+
+	report, err := migrateData(context.Background(), source, target, out)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "migrated %d users: %d sessions, %d messages and %d files copied\n",
+		report.Users, report.Sessions, report.Messages, report.Files)
+	return nil
+}
+
+// migrateData copies the runtime settings and every user with stored sessions
+// or messages from source to target, printing a progress line per user to out.
+// Sessions, messages and files already in the target are skipped and the other
+// rows are replaced, so an interrupted migration resumes where it stopped when
+// run again.
+func migrateData(ctx context.Context, source, target dataStore, out io.Writer) (*migrationReport, error) {
+	settings, err := source.ListSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	for _, setting := range settings {
+		if err := target.SaveSetting(ctx, setting); err != nil {
+			return nil, fmt.Errorf("failed to copy setting %s: %w", setting.Key, err)
+		}
+	}
+
+	var userIDs []int64
+	for offset := 0; ; offset += exportPageSize {
+		users, err := source.ListUsers(ctx, offset, exportPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+		for _, user := range users {
+			userIDs = append(userIDs, user.UserID)
+		}
+		if len(users) < exportPageSize {
+			break
+		}
+	}
+	slices.Sort(userIDs)
+
+	report := &migrationReport{}
+	for i, userID := range userIDs {
+		copied, err := migrateUser(ctx, source, target, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate user %d: %w", userID, err)
+		}
+		report.Users++
+		report.Sessions += copied.Sessions
+		report.Messages += copied.Messages
+		report.Files += copied.Files
+		fmt.Fprintf(out, "[%d/%d] user %d: %d sessions, %d messages, %d files\n",
+			i+1, len(userIDs), userID, copied.Sessions, copied.Messages, copied.Files)
+	}
+	return report, nil
+}
+
+// migrateUser copies the settings, preferences, sessions with their messages,
+// active session, topic and business chat bindings, files and sent messages of userID
+func migrateUser(ctx context.Context, source, target dataStore, userID int64) (*migrationReport, error) {
+	copied := &migrationReport{}
+
+	settings, err := source.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := target.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	preferences, err := source.GetPreferences(ctx, userID)
+	if err != nil && !errors.Is(err, session.ErrPreferencesNotFound) {
+		return nil, err
+	}
+	if preferences != nil {
+		if err := target.SavePreferences(ctx, preferences); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, archived := range []bool{false, true} {
+		list := source.ListByUser
+		if archived {
+			list = source.ListArchivedByUser
+		}
+		for offset := 0; ; offset += exportPageSize {
+			sessions, err := list(ctx, userID, offset, exportPageSize)
+			if err != nil {
+				return nil, err
+			}
+			for _, sess := range sessions {
+				if err := migrateSession(ctx, source, target, sess, archived, copied); err != nil {
+					return nil, fmt.Errorf("session %s: %w", sess.ID, err)
+				}
+			}
+			if len(sessions) < exportPageSize {
+				break
+			}
+		}
+	}
+
+	active, err := source.GetActiveSession(ctx, userID)
+	if err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+		return nil, err
+	}
+	if active != nil {
+		if err := target.SetActiveSession(ctx, userID, active.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := migrateBindings(ctx, source, target, userID); err != nil {
+		return nil, err
+	}
+
+	for offset := 0; ; offset += exportPageSize {
+		files, err := source.ListFilesByUser(ctx, userID, offset, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			_, err := target.GetFile(ctx, file.ID)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, session.ErrFileNotFound) {
+				return nil, err
+			}
+			if err := target.CreateFile(ctx, file); err != nil {
+				return nil, fmt.Errorf("file %s: %w", file.ID, err)
+			}
+			copied.Files++
+		}
+		if len(files) < exportPageSize {
+			break
+		}
+	}
+
+	// Sent messages point at the sessions and messages copied above
+	for offset := 0; ; offset += exportPageSize {
+		sent, err := source.ListUserSentMessages(ctx, userID, offset, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range sent {
+			if err := target.RecordSentMessage(ctx, message); err != nil {
+				return nil, err
+			}
+		}
+		if len(sent) < exportPageSize {
+			break
+		}
+	}
+	return copied, nil
+}
+
+// migrateBindings copies the sessions userID has active in forum topics and
+// business chats, with the business connections they belong to
+func migrateBindings(ctx context.Context, source, target dataStore, userID int64) error {
+	topics, err := source.ListTopicBindings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, binding := range topics {
+		if err := target.SetTopicSession(ctx, userID, binding.Topic, binding.SessionID); err != nil {
+			return err
+		}
+	}
+
+	chats, err := source.ListBusinessChatBindings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	connections := make(map[string]bool)
+	for _, binding := range chats {
+		if !connections[binding.Chat.ConnectionID] {
+			connections[binding.Chat.ConnectionID] = true
+			connection, err := source.GetBusinessConnection(ctx, binding.Chat.ConnectionID)
+			if err != nil && !errors.Is(err, session.ErrBusinessConnectionNotFound) {
+				return err
+			}
+			if connection != nil {
+				if err := target.SaveBusinessConnection(ctx, connection); err != nil {
+					return err
+				}
+			}
+		}
+		if err := target.SetBusinessChatSession(ctx, userID, binding.Chat, binding.SessionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateSession copies a session and the messages the target does not have
+// yet with their versions, the session's memory and summary, then restores the
+// session's title, last message and update time, which appending messages changes
+func migrateSession(ctx context.Context, source, target dataStore, sess *session.Session, archived bool, copied *migrationReport) error {
+	_, err := target.Get(ctx, sess.ID)
+	if errors.Is(err, session.ErrSessionNotFound) {
+		created := *sess
+		created.Version = 0
+		if err := target.Create(ctx, &created); err != nil {
+			return err
+		}
+		copied.Sessions++
+	} else if err != nil {
+		return err
+	}
+
+	// Messages are copied oldest first, so the ones in the target are a prefix
+	offset, err := target.CountMessages(ctx, sess.ID)
+	if err != nil {
+		return err
+	}
+	for ; ; offset += exportPageSize {
+		messages, err := source.ListSessionMessages(ctx, sess.ID, offset, exportPageSize)
+		if err != nil {
+			return err
+		}
+		if err := target.AppendMessages(ctx, sess.ID, messages); err != nil {
+			return err
+		}
+		copied.Messages += len(messages)
+		if len(messages) < exportPageSize {
+			break
+		}
+	}
+
+	versions, err := source.ListSessionMessageVersions(ctx, sess.ID)
+	if err != nil {
+		return err
+	}
+	if err := target.ImportMessageVersions(ctx, versions); err != nil {
+		return err
+	}
+	memory, err := source.ListMemory(ctx, sess.ID)
+	if err != nil {
+		return err
+	}
+	for _, entry := range memory {
+		if err := target.SetMemory(ctx, entry); err != nil {
+			return err
+		}
+	}
+	summary, err := source.GetSummary(ctx, sess.ID)
+	if err != nil && !errors.Is(err, session.ErrSummaryNotFound) {
+		return err
+	}
+	if summary != nil {
+		if err := target.SetSummary(ctx, summary); err != nil {
+			return err
+		}
+	}
+
+	migrated, err := target.Get(ctx, sess.ID)
+	if err != nil {
+		return err
+	}
+	migrated.Title, migrated.LastMessage, migrated.UpdatedAt = sess.Title, sess.LastMessage, sess.UpdatedAt
+	if err := target.Update(ctx, migrated); err != nil {
+		return err
+	}
+	if archived {
+		if _, err := target.ArchiveSessions(ctx, sess.UserID, []uuid.UUID{sess.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
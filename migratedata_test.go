@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+
+	"github.com/google/uuid"
+)
+
+func TestMigrateData(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source, err := openDataStore("sqlite", filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatalf("Failed to open source: %v", err)
+	}
+	defer source.Close()
+	if _, err := seedStore(ctx, source.(*session.SQLiteStore), seedOptions{
+		Users: 2, SessionsPerUser: 3, MessagesPerSession: 4, FirstUserID: 100, Seed: 1,
+		Until: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatalf("seedStore failed: %v", err)
+	}
+	sessions, err := source.ListByUser(ctx, 100, 0, 10)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	// Rows only reachable by their keys: revisions, memory, summaries, sent
+	// messages, topic and business chat bindings and runtime settings
+	revised, err := source.ListSessionMessages(ctx, sessions[0].ID, 0, 1)
+	if err != nil {
+		t.Fatalf("ListSessionMessages failed: %v", err)
+	}
+	if _, err := source.ReviseMessage(ctx, revised[0].ID, "revised", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("ReviseMessage failed: %v", err)
+	}
+	if err := source.SetMemory(ctx, &session.MemoryEntry{SessionID: sessions[0].ID, Key: "name", Value: "Bob"}); err != nil {
+		t.Fatalf("SetMemory failed: %v", err)
+	}
+	if err := source.SetSummary(ctx, &session.Summary{SessionID: sessions[0].ID, Text: "Bob's plans"}); err != nil {
+		t.Fatalf("SetSummary failed: %v", err)
+	}
+	sent := &session.SentMessage{ChatID: 100, TelegramMessageID: 7, UserID: 100, SessionID: sessions[0].ID,
+		MessageID: revised[0].ID, SentAt: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}
+	if err := source.RecordSentMessage(ctx, sent); err != nil {
+		t.Fatalf("RecordSentMessage failed: %v", err)
+	}
+	topic := session.Topic{ChatID: -100, ThreadID: 5}
+	if err := source.SetTopicSession(ctx, 100, topic, sessions[1].ID); err != nil {
+		t.Fatalf("SetTopicSession failed: %v", err)
+	}
+	business := session.BusinessChat{ConnectionID: "conn", ChatID: 555}
+	if err := source.SaveBusinessConnection(ctx, &session.BusinessConnection{ID: "conn", UserID: 100, UserChatID: 100,
+		IsEnabled: true}); err != nil {
+		t.Fatalf("SaveBusinessConnection failed: %v", err)
+	}
+	if err := source.SetBusinessChatSession(ctx, 100, business, sessions[1].ID); err != nil {
+		t.Fatalf("SetBusinessChatSession failed: %v", err)
+	}
+	if err := source.SaveSetting(ctx, &session.Setting{Key: "ai_model", Value: "large"}); err != nil {
+		t.Fatalf("SaveSetting failed: %v", err)
+	}
+	if sessions, err = source.ListByUser(ctx, 100, 0, 10); err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+
+	archived := sessions[2]
+	if _, err := source.ArchiveSessions(ctx, 100, []uuid.UUID{archived.ID}); err != nil {
+		t.Fatalf("ArchiveSessions failed: %v", err)
+	}
+	file := session.NewFile(100, "document", "tg-file", "100/report.pdf", 42)
+	file.SessionID = sessions[0].ID
+	if err := source.CreateFile(ctx, file); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	target, err := openDataStore("sqlite:"+filepath.Join(dir, "target.db"), "")
+	if err != nil {
+		t.Fatalf("Failed to open target: %v", err)
+	}
+	defer target.Close()
+
+	// An earlier run stopped halfway through a session
+	partial := *sessions[1]
+	partial.Version = 0
+	if err := target.Create(ctx, &partial); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	head, err := source.ListSessionMessages(ctx, partial.ID, 0, 2)
+	if err != nil {
+		t.Fatalf("ListSessionMessages failed: %v", err)
+	}
+	if err := target.AppendMessages(ctx, partial.ID, head); err != nil {
+		t.Fatalf("AppendMessages failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	report, err := migrateData(ctx, source, target, &out)
+	if err != nil {
+		t.Fatalf("migrateData failed: %v", err)
+	}
+	if report.Users != 2 || report.Sessions != 5 || report.Messages != 22 || report.Files != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if !strings.Contains(out.String(), "[2/2] user 101: 3 sessions, 12 messages, 0 files") {
+		t.Errorf("expected a progress line per user, got %q", out.String())
+	}
+
+	for _, sess := range sessions {
+		migrated, err := target.Get(ctx, sess.ID)
+		if err != nil {
+			t.Fatalf("session %s was not migrated: %v", sess.ID, err)
+		}
+		if migrated.Title != sess.Title || !migrated.UpdatedAt.Equal(sess.UpdatedAt) || migrated.MessageCount != 4 {
+			t.Errorf("expected %+v, got %+v", sess, migrated)
+		}
+	}
+	if listed, _ := target.CountByUser(ctx, 100); listed != 2 {
+		t.Errorf("expected the archived session to stay archived, got %d listed", listed)
+	}
+	if active, err := target.GetActiveSession(ctx, 100); err != nil || active.ID != sessions[0].ID {
+		t.Errorf("expected the active session migrated, got %v err=%v", active, err)
+	}
+	if _, err := target.GetFile(ctx, file.ID); err != nil {
+		t.Errorf("expected the file migrated: %v", err)
+	}
+	if versions, err := target.ListMessageVersions(ctx, revised[0].ID); err != nil || len(versions) != 2 || versions[1].Content != "revised" {
+		t.Errorf("expected the message versions migrated, got %+v err=%v", versions, err)
+	}
+	if memory, err := target.ListMemory(ctx, sessions[0].ID); err != nil || len(memory) != 1 || memory[0].Value != "Bob" {
+		t.Errorf("expected the session memory migrated, got %+v err=%v", memory, err)
+	}
+	if summary, err := target.GetSummary(ctx, sessions[0].ID); err != nil || summary.Text != "Bob's plans" {
+		t.Errorf("expected the summary migrated, got %+v err=%v", summary, err)
+	}
+	if got, err := target.GetSentMessage(ctx, 100, 7); err != nil || got.MessageID != revised[0].ID {
+		t.Errorf("expected the sent message migrated, got %+v err=%v", got, err)
+	}
+	if bound, err := target.GetTopicSession(ctx, 100, topic); err != nil || bound.ID != sessions[1].ID {
+		t.Errorf("expected the topic binding migrated, got %+v err=%v", bound, err)
+	}
+	if bound, err := target.GetBusinessChatSession(ctx, 100, business); err != nil || bound.ID != sessions[1].ID {
+		t.Errorf("expected the business chat binding migrated, got %+v err=%v", bound, err)
+	}
+	if connection, err := target.GetBusinessConnection(ctx, "conn"); err != nil || !connection.IsEnabled {
+		t.Errorf("expected the business connection migrated, got %+v err=%v", connection, err)
+	}
+	if settings, err := target.ListSettings(ctx); err != nil || len(settings) != 1 || settings[0].Value != "large" {
+		t.Errorf("expected the runtime settings migrated, got %+v err=%v", settings, err)
+	}
+
+	// Running again copies nothing
+	report, err = migrateData(ctx, source, target, &out)
+	if err != nil {
+		t.Fatalf("second migrateData failed: %v", err)
+	}
+	if report.Sessions != 0 || report.Messages != 0 || report.Files != 0 {
+		t.Errorf("expected nothing copied twice, got %+v", report)
+	}
+}
+
+func TestOpenDataStore_UnknownBackend(t *testing.T) {
+	_, err := openDataStore("nosuchdb:dsn", "")
+	if err == nil || !strings.Contains(err.Error(), `unsupported store backend "nosuchdb"; available: sqlite`) {
+		t.Errorf("expected the available backends listed, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Package moderation checks text against content rules. A Filter reports the
+// parts of a text that match; what happens to matching text is decided by the
+// Action configured by the caller.
+
+// Action says what happens to text a filter matched
+type Action string
+
+const (
+	ActionBlock  Action = "block"  // the text is dropped
+	ActionRedact Action = "redact" // the matches are masked and the rest goes through
+	ActionFlag   Action = "flag"   // the text goes through unchanged
+)
+
+// ParseAction converts a configured action name
+func ParseAction(name string) (Action, error) {
+	switch action := Action(name); action {
+	case ActionBlock, ActionRedact, ActionFlag:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown action %q (want block, redact or flag)", name)
+	}
+}
+
+// Match is a part of a text that broke a rule
+type Match struct {
+	Rule  string // the keyword or pattern that matched
+	Start int    // byte offsets into the text
+	End   int
+}
+
+// Filter finds content that breaks the rules
+type Filter interface {
+	// Check returns the matches in text, ordered by position; none means the text is clean
+	Check(ctx context.Context, text string) ([]Match, error)
+}
+
+// redactionMask replaces each redacted match
+const redactionMask = "***"
+
+// Redact masks the matches in text. Overlapping matches are masked once.
+func Redact(text string, matches []Match) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		if match.End <= last {
+			continue
+		}
+		// A match overlapping the previous one extends its mask
+		if match.Start >= last {
+			b.WriteString(text[last:match.Start])
+			b.WriteString(redactionMask)
+		}
+		last = match.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Rules returns the distinct rules of matches, in order of appearance
+func Rules(matches []Match) []string {
+	seen := make(map[string]bool, len(matches))
+	var rules []string
+	for _, match := range matches {
+		if !seen[match.Rule] {
+			seen[match.Rule] = true
+			rules = append(rules, match.Rule)
+		}
+	}
+	return rules
+}
+
+// KeywordFilter matches keywords as whole words, ignoring case, and regular
+// expressions as written
+type KeywordFilter struct {
+	rules []rule
+}
+
+type rule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// NewKeywordFilter creates a filter for keywords and patterns. Keywords are
+// literal; patterns use Go regular expression syntax, e.g. "(?i)free\s+crypto".
+func NewKeywordFilter(keywords, patterns []string) (*KeywordFilter, error) {
+	f := &KeywordFilter{}
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" {
+			return nil, fmt.Errorf("empty keyword")
+		}
+		// \b only applies next to word characters, so keywords starting or
+		// ending with punctuation still match
+		expr := regexp.QuoteMeta(keyword)
+		if isWordByte(keyword[0]) {
+			expr = `\b` + expr
+		}
+		if isWordByte(keyword[len(keyword)-1]) {
+			expr += `\b`
+		}
+		f.rules = append(f.rules, rule{name: keyword, re: regexp.MustCompile("(?i)" + expr)})
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		f.rules = append(f.rules, rule{name: pattern, re: re})
+	}
+	return f, nil
+}
+
+// Check returns the matches of every rule in text
+func (f *KeywordFilter) Check(_ context.Context, text string) ([]Match, error) {
+	var matches []Match
+	for _, r := range f.rules {
+		for _, loc := range r.re.FindAllStringIndex(text, -1) {
+			if loc[1] > loc[0] {
+				matches = append(matches, Match{Rule: r.name, Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Start < matches[j].Start
+	})
+	return matches, nil
+}
+
+// isWordByte reports whether c is an ASCII word character as \b sees it
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
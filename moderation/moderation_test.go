@@ -0,0 +1,69 @@
+package moderation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestKeywordFilterCheck(t *testing.T) {
+	filter, err := NewKeywordFilter([]string{"darn", "c++"}, []string{`\d{4}-\d{4}-\d{4}-\d{4}`})
+	if err != nil {
+		t.Fatalf("NewKeywordFilter failed: %v", err)
+	}
+
+	tests := []struct {
+		text  string
+		rules []string
+	}{
+		{"Darn it, my card is 1234-5678-9012-3456", []string{"darn", `\d{4}-\d{4}-\d{4}-\d{4}`}},
+		{"I love C++ and darn", []string{"c++", "darn"}},
+		{"darning socks is fine", nil},
+		{"nothing to see", nil},
+	}
+	for _, tt := range tests {
+		matches, err := filter.Check(context.Background(), tt.text)
+		if err != nil {
+			t.Fatalf("Check(%q) failed: %v", tt.text, err)
+		}
+		if rules := Rules(matches); !reflect.DeepEqual(rules, tt.rules) {
+			t.Errorf("Check(%q) matched %q, want %q", tt.text, rules, tt.rules)
+		}
+	}
+}
+
+func TestKeywordFilterInvalidPattern(t *testing.T) {
+	if _, err := NewKeywordFilter(nil, []string{"(unclosed"}); err == nil {
+		t.Error("expected an invalid pattern to fail")
+	}
+	if _, err := NewKeywordFilter([]string{" "}, nil); err == nil {
+		t.Error("expected an empty keyword to fail")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		text    string
+		matches []Match
+		want    string
+	}{
+		{"darn it", []Match{{Start: 0, End: 4}}, "*** it"},
+		{"a darn darn", []Match{{Start: 2, End: 6}, {Start: 7, End: 11}}, "a *** ***"},
+		{"overlapping", []Match{{Start: 0, End: 4}, {Start: 2, End: 7}}, "***ping"},
+		{"clean", nil, "clean"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.text, tt.matches); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	if action, err := ParseAction("redact"); err != nil || action != ActionRedact {
+		t.Errorf("ParseAction(redact) = %q, %v", action, err)
+	}
+	if _, err := ParseAction("delete"); err == nil {
+		t.Error("expected an unknown action to fail")
+	}
+}
@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Package notify delivers proactive messages, the ones the bot sends without
+// being asked, such as reminders and notices. Users who turned notifications off
+// do not get them; during a user's quiet hours they are queued and delivered
+// once the quiet hours end.
+
+// deliveryBatchSize is the number of queued messages delivered per query
+const deliveryBatchSize = 50
+
+// Store is the persistence the notifier needs
+type Store interface {
+	session.UserSettingsStore
+	session.DeferredMessageStore
+}
+
+// Sender sends Telegram messages; handlers.TelegramAPI and *bot.Bot implement it
+type Sender interface {
+	SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error)
+}
+
+// Outcome is what happened to a notification
+type Outcome int
+
+const (
+	Sent       Outcome = iota // delivered now
+	Deferred                  // queued until the user's quiet hours end
+	Suppressed                // dropped because the user turned notifications off
+)
+
+// Notifier sends proactive messages according to each user's notification settings
+type Notifier struct {
+	store Store
+	now   func() time.Time
+}
+
+// New creates a notifier
+func New(store Store) *Notifier {
+	return &Notifier{store: store, now: time.Now}
+}
+
+// Send delivers text to userID in chatID now, queues it until the user's quiet
+// hours end, or drops it if the user turned notifications off
+func (n *Notifier) Send(ctx context.Context, b Sender, userID, chatID int64, text, parseMode string) (Outcome, error) {
+	return n.SendKeyboard(ctx, b, userID, chatID, text, parseMode, nil)
+}
+
+// SendKeyboard is Send for a message with an inline keyboard. Queued messages keep
+// the keyboard, so its buttons should stay valid until the quiet hours end.
+func (n *Notifier) SendKeyboard(ctx context.Context, b Sender, userID, chatID int64, text, parseMode string,
+	keyboard *models.InlineKeyboardMarkup) (Outcome, error) {
+	settings, err := n.store.GetUserSettings(ctx, userID)
+	if err != nil {
+		return Sent, err
+	}
+	if !settings.Notifications {
+		return Suppressed, nil
+	}
+
+	now := n.now()
+	if until, quiet := settings.QuietUntil(now); quiet {
+		message := &session.DeferredMessage{
+			UserID:    userID,
+			ChatID:    chatID,
+			Text:      text,
+			ParseMode: parseMode,
+			DeliverAt: until,
+			CreatedAt: now,
+		}
+		if keyboard != nil {
+			encoded, err := json.Marshal(keyboard)
+			if err != nil {
+				return Deferred, fmt.Errorf("failed to encode keyboard: %w", err)
+			}
+			message.ReplyMarkup = string(encoded)
+		}
+		return Deferred, n.store.DeferMessage(ctx, message)
+	}
+
+	params := &bot.SendMessageParams{ChatID: chatID, Text: text, ParseMode: models.ParseMode(parseMode)}
+	if keyboard != nil {
+		params.ReplyMarkup = keyboard
+	}
+	_, err = b.SendMessage(ctx, params)
+	return Sent, err
+}
+
+// Start delivers due messages every interval until ctx is cancelled
+func (n *Notifier) Start(ctx context.Context, b Sender, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if delivered, err := n.Flush(ctx, b); err != nil {
+				log.Printf("deferred delivery failed: delivered=%d err=%v", delivered, err)
+			} else if delivered > 0 {
+				log.Printf("deferred delivery: delivered=%d", delivered)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Flush delivers every queued message that is due and reports how many were sent.
+// A message that fails to send stays queued and is retried on the next flush.
+func (n *Notifier) Flush(ctx context.Context, b Sender) (int, error) {
+	delivered := 0
+	var failures []error
+	for {
+		due, err := n.store.ListDueMessages(ctx, n.now(), deliveryBatchSize)
+		if err != nil {
+			return delivered, err
+		}
+
+		progressed := false
+		for _, message := range due {
+			params := &bot.SendMessageParams{
+				ChatID:    message.ChatID,
+				Text:      message.Text,
+				ParseMode: models.ParseMode(message.ParseMode),
+			}
+			if message.ReplyMarkup != "" {
+				var keyboard models.InlineKeyboardMarkup
+				if err := json.Unmarshal([]byte(message.ReplyMarkup), &keyboard); err != nil {
+					failures = append(failures, fmt.Errorf("message %d: invalid keyboard: %w", message.ID, err))
+					continue
+				}
+				params.ReplyMarkup = &keyboard
+			}
+			_, err := b.SendMessage(ctx, params)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("message %d: %w", message.ID, err))
+				continue
+			}
+			if err := n.store.DeleteDeferredMessage(ctx, message.ID); err != nil {
+				return delivered, err
+			}
+			delivered++
+			progressed = true
+		}
+
+		// Stop when the queue is drained or only failing messages are left
+		if len(due) < deliveryBatchSize || !progressed {
+			return delivered, errors.Join(failures...)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func newTestNotifier(t *testing.T, now *time.Time) (*Notifier, *session.SQLiteStore) {
+	t.Helper()
+
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "notify.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	notifier := New(store)
+	notifier.now = func() time.Time { return *now }
+	return notifier, store
+}
+
+func TestNotifierSend(t *testing.T) {
+	now := time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC)
+	notifier, store := newTestNotifier(t, &now)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	quiet := session.DefaultUserSettings(1)
+	quiet.QuietStart, quiet.QuietEnd = 22*60, 7*60
+	muted := session.DefaultUserSettings(2)
+	muted.Notifications = false
+	for _, userSettings := range []*session.UserSettings{quiet, muted} {
+		if err := store.SaveUserSettings(ctx, userSettings); err != nil {
+			t.Fatalf("SaveUserSettings failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		userID int64
+		want   Outcome
+	}{
+		{1, Deferred},
+		{2, Suppressed},
+		{3, Sent},
+	}
+	for _, tt := range tests {
+		outcome, err := notifier.Send(ctx, api, tt.userID, tt.userID, "hello", "")
+		if err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if outcome != tt.want {
+			t.Errorf("user %d: expected outcome %d, got %d", tt.userID, tt.want, outcome)
+		}
+	}
+	if len(api.Sent) != 1 || api.Sent[0].ChatID != int64(3) {
+		t.Fatalf("expected only user 3 to get the message now, got %d messages", len(api.Sent))
+	}
+
+	if delivered, err := notifier.Flush(ctx, api); err != nil || delivered != 0 {
+		t.Fatalf("expected nothing due during quiet hours, got %d, %v", delivered, err)
+	}
+
+	now = time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC)
+	if delivered, err := notifier.Flush(ctx, api); err != nil || delivered != 1 {
+		t.Fatalf("expected the deferred message to be delivered, got %d, %v", delivered, err)
+	}
+	if last := api.Sent[len(api.Sent)-1]; last.ChatID != int64(1) || last.Text != "hello" {
+		t.Errorf("expected the deferred message for user 1, got %+v", last)
+	}
+	if delivered, _ := notifier.Flush(ctx, api); delivered != 0 {
+		t.Errorf("expected a delivered message to leave the queue, got %d deliveries", delivered)
+	}
+}
+
+func TestNotifierFlushKeepsFailedMessages(t *testing.T) {
+	now := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+	notifier, store := newTestNotifier(t, &now)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	if err := store.DeferMessage(ctx, &session.DeferredMessage{UserID: 1, ChatID: 1, Text: "hello", DeliverAt: now, CreatedAt: now}); err != nil {
+		t.Fatalf("DeferMessage failed: %v", err)
+	}
+
+	api.Err = errors.New("telegram unavailable")
+	if delivered, err := notifier.Flush(ctx, api); err == nil || delivered != 0 {
+		t.Fatalf("expected the failure to be reported, got %d, %v", delivered, err)
+	}
+
+	api.Err = nil
+	if delivered, err := notifier.Flush(ctx, api); err != nil || delivered != 1 {
+		t.Errorf("expected the message to be retried, got %d, %v", delivered, err)
+	}
+}
+
+func TestNotifierDeferredKeyboard(t *testing.T) {
+	now := time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC)
+	notifier, store := newTestNotifier(t, &now)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	quiet := session.DefaultUserSettings(1)
+	quiet.QuietStart, quiet.QuietEnd = 22*60, 7*60
+	if err := store.SaveUserSettings(ctx, quiet); err != nil {
+		t.Fatalf("SaveUserSettings failed: %v", err)
+	}
+
+	keyboard := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: "Keep", CallbackData: "keep_s_1"}},
+	}}
+	if outcome, err := notifier.SendKeyboard(ctx, api, 1, 1, "hello", "", keyboard); err != nil || outcome != Deferred {
+		t.Fatalf("expected the message to be deferred, got %d, %v", outcome, err)
+	}
+
+	now = time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC)
+	if delivered, err := notifier.Flush(ctx, api); err != nil || delivered != 1 {
+		t.Fatalf("expected the deferred message to be delivered, got %d, %v", delivered, err)
+	}
+	markup, ok := api.Sent[0].ReplyMarkup.(*models.InlineKeyboardMarkup)
+	if !ok || markup.InlineKeyboard[0][0].CallbackData != "keep_s_1" {
+		t.Errorf("expected the keyboard to be delivered, got %#v", api.Sent[0].ReplyMarkup)
+	}
+}
@@ -0,0 +1,350 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	"tg-bot-demo/session"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Package outbox delivers replies reliably. A reply is written to the outbox
+// table before it is sent and removed once Telegram accepted it, so a reply
+// whose delivery fails, or is cut short by a crash, is retried with backoff.
+// Delivery is at least once: a crash right after a send repeats the reply.
+
+// Delivery tuning
+const (
+	deliveryBatchSize = 50
+	maxAttempts       = 8                // attempts before a message is dropped
+	firstRetryDelay   = 5 * time.Second  // doubled after each failed attempt
+	maxRetryDelay     = 10 * time.Minute // upper bound of the retry delay
+
+	// claimDelay keeps a message that is being sent right away out of Flush,
+	// so the two do not deliver it twice; after a crash Flush sends it then
+	claimDelay = 30 * time.Second
+)
+
+// Metrics published under /debug/vars
+var (
+	delivered = expvar.NewInt("outbox_delivered_total")
+	retried   = expvar.NewInt("outbox_retries_total")
+	dropped   = expvar.NewInt("outbox_dropped_total")
+)
+
+// Sender sends Telegram messages; handlers.TelegramAPI and *bot.Bot implement it
+type Sender interface {
+	SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error)
+}
+
+// Outbox queues replies in the store and delivers them with retries
+type Outbox struct {
+	store session.OutboxStore
+	now   func() time.Time
+}
+
+// New creates an outbox
+func New(store session.OutboxStore) *Outbox {
+	return &Outbox{store: store, now: time.Now}
+}
+
+// Send stores the reply to userID described by params in the outbox and sends
+// it right away. A reply that fails to send stays queued for Flush, and the
+// error is returned for logging. When the reply cannot be stored it is sent
+// without the outbox.
+func (o *Outbox) Send(ctx context.Context, b Sender, userID int64, params *bot.SendMessageParams) error {
+	message, err := newOutboxMessage(userID, params, o.now())
+	if err == nil {
+		err = o.store.EnqueueOutbox(ctx, message)
+	}
+	if err != nil {
+		log.Printf("outbox enqueue failed, sending directly: user_id=%d err=%v", userID, err)
+		_, err := b.SendMessage(ctx, params)
+		return err
+	}
+
+	return o.deliver(ctx, b, message, params)
+}
+
+// Write stores a change and returns the reply confirming it, or nil for no reply
+type Write func(ctx context.Context) (*bot.SendMessageParams, error)
+
+// SendAfter runs write and stores the reply it returns in the outbox in the
+// same transaction, so the reply is queued exactly when the change commits and
+// a crash between the two cannot lose it. The reply is then sent right away;
+// one that fails to send stays queued for Flush and the failure is logged.
+// write's error is returned, and nothing is stored or sent after it.
+func (o *Outbox) SendAfter(ctx context.Context, b Sender, userID int64, write Write) error {
+	var params *bot.SendMessageParams
+	var message *session.OutboxMessage
+	err := o.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		params, err = write(ctx)
+		if err != nil || params == nil {
+			return err
+		}
+
+		queued, err := newOutboxMessage(userID, params, o.now())
+		if err != nil {
+			// Not storable, but the change stands: the reply is sent directly
+			log.Printf("outbox enqueue failed, sending directly: user_id=%d err=%v", userID, err)
+			return nil
+		}
+		if err := o.store.EnqueueOutbox(ctx, queued); err != nil {
+			return err
+		}
+		message = queued
+		return nil
+	})
+	if err != nil || params == nil {
+		return err
+	}
+
+	if message == nil {
+		if _, err := b.SendMessage(ctx, params); err != nil {
+			log.Printf("outbox direct send failed: user_id=%d err=%v", userID, err)
+		}
+		return nil
+	}
+	if err := o.deliver(ctx, b, message, params); err != nil {
+		log.Printf("outbox delivery failed: id=%d user_id=%d err=%v", message.ID, userID, err)
+	}
+	return nil
+}
+
+// newOutboxMessage converts params to an outbox message claimed for an immediate
+// send. The whole request is kept, so a retry sends the same message.
+func newOutboxMessage(userID int64, params *bot.SendMessageParams, now time.Time) (*session.OutboxMessage, error) {
+	chatID, ok := params.ChatID.(int64)
+	if !ok {
+		return nil, fmt.Errorf("outbox needs a numeric chat ID, got %T", params.ChatID)
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	message := &session.OutboxMessage{
+		UserID:        userID,
+		ChatID:        chatID,
+		ThreadID:      params.MessageThreadID,
+		Text:          params.Text,
+		ParseMode:     string(params.ParseMode),
+		Params:        string(encoded),
+		NextAttemptAt: now.Add(claimDelay),
+		CreatedAt:     now,
+	}
+	if params.ReplyMarkup != nil {
+		if message.ReplyMarkupType, err = markupType(params.ReplyMarkup); err != nil {
+			return nil, err
+		}
+		keyboard, err := json.Marshal(params.ReplyMarkup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode keyboard: %w", err)
+		}
+		message.ReplyMarkup = string(keyboard)
+	}
+	return message, nil
+}
+
+// Kinds of keyboards, stored next to their JSON to decode them again
+const (
+	inlineKeyboard      = "inline_keyboard"
+	replyKeyboard       = "reply_keyboard"
+	replyKeyboardRemove = "reply_keyboard_remove"
+	forceReply          = "force_reply"
+)
+
+// markupType returns the kind of keyboard markup is
+func markupType(markup models.ReplyMarkup) (string, error) {
+	switch markup.(type) {
+	case *models.InlineKeyboardMarkup, models.InlineKeyboardMarkup:
+		return inlineKeyboard, nil
+	case *models.ReplyKeyboardMarkup, models.ReplyKeyboardMarkup:
+		return replyKeyboard, nil
+	case *models.ReplyKeyboardRemove, models.ReplyKeyboardRemove:
+		return replyKeyboardRemove, nil
+	case *models.ForceReply, models.ForceReply:
+		return forceReply, nil
+	default:
+		return "", fmt.Errorf("outbox cannot store a keyboard of type %T", markup)
+	}
+}
+
+// decodeMarkup decodes a keyboard of the given kind; an empty kind is an
+// inline keyboard, which rows queued before kinds were stored hold
+func decodeMarkup(kind string, encoded []byte) (models.ReplyMarkup, error) {
+	var markup models.ReplyMarkup
+	switch kind {
+	case inlineKeyboard, "":
+		markup = &models.InlineKeyboardMarkup{}
+	case replyKeyboard:
+		markup = &models.ReplyKeyboardMarkup{}
+	case replyKeyboardRemove:
+		markup = &models.ReplyKeyboardRemove{}
+	case forceReply:
+		markup = &models.ForceReply{}
+	default:
+		return nil, fmt.Errorf("unknown keyboard type %q", kind)
+	}
+	if err := json.Unmarshal(encoded, markup); err != nil {
+		return nil, err
+	}
+	return markup, nil
+}
+
+// storedParams decodes a stored request; its keyboard is decoded separately
+// because its Go type is not in the JSON
+type storedParams struct {
+	bot.SendMessageParams
+	ReplyMarkup json.RawMessage `json:"reply_markup,omitempty"`
+}
+
+// messageParams rebuilds the request of a queued message
+func messageParams(message *session.OutboxMessage) (*bot.SendMessageParams, error) {
+	if message.Params == "" {
+		// Queued before whole requests were stored
+		params := &bot.SendMessageParams{
+			ChatID:          message.ChatID,
+			MessageThreadID: message.ThreadID,
+			Text:            message.Text,
+			ParseMode:       models.ParseMode(message.ParseMode),
+		}
+		if message.ReplyMarkup != "" {
+			markup, err := decodeMarkup(message.ReplyMarkupType, []byte(message.ReplyMarkup))
+			if err != nil {
+				return nil, fmt.Errorf("invalid keyboard: %w", err)
+			}
+			params.ReplyMarkup = markup
+		}
+		return params, nil
+	}
+
+	var stored storedParams
+	if err := json.Unmarshal([]byte(message.Params), &stored); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	params := stored.SendMessageParams
+	// JSON numbers decode as float64; the chat ID is kept exactly in its own column
+	params.ChatID = message.ChatID
+	if len(stored.ReplyMarkup) > 0 {
+		markup, err := decodeMarkup(message.ReplyMarkupType, stored.ReplyMarkup)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keyboard: %w", err)
+		}
+		params.ReplyMarkup = markup
+	}
+	return &params, nil
+}
+
+// deliver sends message and removes it from the outbox, or records the failed
+// attempt. Messages Telegram refuses for good, e.g. because the user blocked
+// the bot, and messages out of attempts are dropped.
+func (o *Outbox) deliver(ctx context.Context, b Sender, message *session.OutboxMessage, params *bot.SendMessageParams) error {
+	_, sendErr := b.SendMessage(ctx, params)
+	if sendErr == nil {
+		delivered.Add(1)
+		return o.store.DeleteOutbox(ctx, message.ID)
+	}
+
+	attempts := message.Attempts + 1
+	if permanent(sendErr) || attempts >= maxAttempts {
+		dropped.Add(1)
+		log.Printf("outbox message dropped: id=%d user_id=%d chat_id=%d attempts=%d err=%v",
+			message.ID, message.UserID, message.ChatID, attempts, sendErr)
+		if err := o.store.DeleteOutbox(ctx, message.ID); err != nil {
+			return err
+		}
+		return sendErr
+	}
+
+	retried.Add(1)
+	next := o.now().Add(retryDelay(attempts, sendErr))
+	if err := o.store.RescheduleOutbox(ctx, message.ID, attempts, next, sendErr.Error()); err != nil {
+		return errors.Join(sendErr, err)
+	}
+	return sendErr
+}
+
+// permanent reports whether retrying err cannot succeed
+func permanent(err error) bool {
+	return errors.Is(err, bot.ErrorForbidden) || errors.Is(err, bot.ErrorBadRequest)
+}
+
+// retryDelay returns how long to wait after the given number of failed
+// attempts, honoring the wait Telegram asks for when throttling
+func retryDelay(attempts int, err error) time.Duration {
+	var tooMany *bot.TooManyRequestsError
+	if errors.As(err, &tooMany) && tooMany.RetryAfter > 0 {
+		return time.Duration(tooMany.RetryAfter) * time.Second
+	}
+	delay := firstRetryDelay << (attempts - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// Start delivers due messages every interval until ctx is cancelled
+func (o *Outbox) Start(ctx context.Context, b Sender, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if sent, err := o.Flush(ctx, b); err != nil {
+				log.Printf("outbox delivery failed: delivered=%d err=%v", sent, err)
+			} else if sent > 0 {
+				log.Printf("outbox delivery: delivered=%d", sent)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Flush delivers every due message and reports how many were sent. Messages
+// that fail again are rescheduled with a longer delay.
+func (o *Outbox) Flush(ctx context.Context, b Sender) (int, error) {
+	sent := 0
+	var failures []error
+	for {
+		due, err := o.store.ListDueOutbox(ctx, o.now(), deliveryBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		progressed := false
+		for _, message := range due {
+			params, err := messageParams(message)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("message %d: %w", message.ID, err))
+				if err := o.store.DeleteOutbox(ctx, message.ID); err != nil {
+					return sent, err
+				}
+				continue
+			}
+			if err := o.deliver(ctx, b, message, params); err != nil {
+				failures = append(failures, fmt.Errorf("message %d: %w", message.ID, err))
+				continue
+			}
+			sent++
+			progressed = true
+		}
+
+		// Stop when the outbox is drained or only failing messages are left
+		if len(due) < deliveryBatchSize || !progressed {
+			return sent, errors.Join(failures...)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/testutil"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func newTestOutbox(t *testing.T, now *time.Time) (*Outbox, *session.SQLiteStore) {
+	t.Helper()
+
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "outbox.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	outbox := New(store)
+	outbox.now = func() time.Time { return *now }
+	return outbox, store
+}
+
+func pending(t *testing.T, store *session.SQLiteStore) []*session.OutboxMessage {
+	t.Helper()
+	messages, err := store.ListDueOutbox(context.Background(), time.Now().Add(24*time.Hour), 100)
+	if err != nil {
+		t.Fatalf("ListDueOutbox failed: %v", err)
+	}
+	return messages
+}
+
+func TestOutboxSendDelivers(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	outbox, store := newTestOutbox(t, &now)
+	api := testutil.NewFakeTelegram()
+
+	keyboard := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{{Text: "Sessions", CallbackData: "sessions"}}}}
+	err := outbox.Send(context.Background(), api, 1, &bot.SendMessageParams{ChatID: int64(1), Text: "saved", ReplyMarkup: keyboard})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(api.Sent) != 1 || api.Sent[0].Text != "saved" {
+		t.Fatalf("expected the reply sent right away, got %+v", api.Sent)
+	}
+	if left := pending(t, store); len(left) != 0 {
+		t.Errorf("expected the delivered reply removed from the outbox, got %+v", left)
+	}
+}
+
+func TestOutboxRetriesFailedReplies(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	outbox, store := newTestOutbox(t, &now)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	keyboard := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{{Text: "Sessions", CallbackData: "sessions"}}}}
+	api.Err = errors.New("connection reset")
+	params := &bot.SendMessageParams{ChatID: int64(1), MessageThreadID: 7, Text: "saved", ParseMode: models.ParseModeHTML, ReplyMarkup: keyboard}
+	if err := outbox.Send(ctx, api, 1, params); err == nil {
+		t.Fatal("expected the failed send reported")
+	}
+	left := pending(t, store)
+	if len(left) != 1 || left[0].Attempts != 1 || left[0].LastError != "connection reset" ||
+		!left[0].NextAttemptAt.Equal(now.Add(firstRetryDelay)) {
+		t.Fatalf("expected the reply rescheduled, got %+v", left)
+	}
+
+	// Not due yet
+	api.Err = nil
+	if sent, err := outbox.Flush(ctx, api); sent != 0 || err != nil {
+		t.Fatalf("expected nothing due, got sent=%d err=%v", sent, err)
+	}
+
+	now = now.Add(firstRetryDelay)
+	if sent, err := outbox.Flush(ctx, api); sent != 1 || err != nil {
+		t.Fatalf("expected the reply delivered on retry, got sent=%d err=%v", sent, err)
+	}
+	retried := api.Sent[0]
+	if retried.ChatID != int64(1) || retried.MessageThreadID != 7 || retried.ParseMode != models.ParseModeHTML ||
+		retried.ReplyMarkup.(*models.InlineKeyboardMarkup).InlineKeyboard[0][0].CallbackData != "sessions" {
+		t.Errorf("expected the reply restored as queued, got %+v", retried)
+	}
+	if left := pending(t, store); len(left) != 0 {
+		t.Errorf("expected the outbox drained, got %+v", left)
+	}
+}
+
+func TestOutboxRetriesKeepTheWholeRequest(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	outbox, _ := newTestOutbox(t, &now)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	disabled := true
+	requests := []*bot.SendMessageParams{
+		{
+			ChatID:               int64(-1001234567890),
+			Text:                 "pick one",
+			BusinessConnectionID: "biz",
+			ReplyParameters:      &models.ReplyParameters{MessageID: 42},
+			LinkPreviewOptions:   &models.LinkPreviewOptions{IsDisabled: &disabled},
+			ReplyMarkup: &models.ReplyKeyboardMarkup{
+				Keyboard:       [][]models.KeyboardButton{{{Text: "Yes"}, {Text: "No"}}},
+				ResizeKeyboard: true,
+			},
+		},
+		{ChatID: int64(1), Text: "name?", ReplyMarkup: &models.ForceReply{ForceReply: true, InputFieldPlaceholder: "name"}},
+		{ChatID: int64(1), Text: "done", ReplyMarkup: &models.ReplyKeyboardRemove{RemoveKeyboard: true}},
+	}
+	api.Err = errors.New("connection reset")
+	for _, params := range requests {
+		if err := outbox.Send(ctx, api, 1, params); err == nil {
+			t.Fatal("expected the failed send reported")
+		}
+	}
+
+	api.Err = nil
+	now = now.Add(firstRetryDelay)
+	if sent, err := outbox.Flush(ctx, api); sent != len(requests) || err != nil {
+		t.Fatalf("expected every reply delivered on retry, got sent=%d err=%v", sent, err)
+	}
+	byText := map[string]*bot.SendMessageParams{}
+	for _, params := range api.Sent {
+		byText[params.Text] = params
+	}
+
+	retried := byText["pick one"]
+	if retried == nil || retried.ChatID != int64(-1001234567890) || retried.BusinessConnectionID != "biz" ||
+		retried.ReplyParameters == nil || retried.ReplyParameters.MessageID != 42 ||
+		retried.LinkPreviewOptions == nil || retried.LinkPreviewOptions.IsDisabled == nil || !*retried.LinkPreviewOptions.IsDisabled {
+		t.Fatalf("expected the reply restored as queued, got %+v", retried)
+	}
+	keyboard, ok := retried.ReplyMarkup.(*models.ReplyKeyboardMarkup)
+	if !ok || !keyboard.ResizeKeyboard || keyboard.Keyboard[0][1].Text != "No" {
+		t.Errorf("expected the reply keyboard restored, got %#v", retried.ReplyMarkup)
+	}
+	if force, ok := byText["name?"].ReplyMarkup.(*models.ForceReply); !ok || !force.ForceReply || force.InputFieldPlaceholder != "name" {
+		t.Errorf("expected ForceReply restored, got %#v", byText["name?"].ReplyMarkup)
+	}
+	if remove, ok := byText["done"].ReplyMarkup.(*models.ReplyKeyboardRemove); !ok || !remove.RemoveKeyboard {
+		t.Errorf("expected ReplyKeyboardRemove restored, got %#v", byText["done"].ReplyMarkup)
+	}
+}
+
+func TestOutboxDropsUndeliverableReplies(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	outbox, store := newTestOutbox(t, &now)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	api.Err = fmt.Errorf("%w, bot was blocked by the user", bot.ErrorForbidden)
+	outbox.Send(ctx, api, 1, &bot.SendMessageParams{ChatID: int64(1), Text: "blocked"})
+	if left := pending(t, store); len(left) != 0 {
+		t.Errorf("expected a reply to a user who blocked the bot dropped, got %+v", left)
+	}
+
+	api.Err = errors.New("timeout")
+	outbox.Send(ctx, api, 2, &bot.SendMessageParams{ChatID: int64(2), Text: "flaky"})
+	for attempt := 2; attempt <= maxAttempts; attempt++ {
+		now = now.Add(maxRetryDelay)
+		outbox.Flush(ctx, api)
+	}
+	if left := pending(t, store); len(left) != 0 {
+		t.Errorf("expected the reply dropped after %d attempts, got %+v", maxAttempts, left)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		err      error
+		want     time.Duration
+	}{
+		{1, errors.New("timeout"), 5 * time.Second},
+		{3, errors.New("timeout"), 20 * time.Second},
+		{20, errors.New("timeout"), maxRetryDelay},
+		{1, &bot.TooManyRequestsError{Message: "too many requests", RetryAfter: 42}, 42 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts, tt.err); got != tt.want {
+			t.Errorf("retryDelay(%d, %v) = %v, want %v", tt.attempts, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxSurvivesCrashBeforeSend(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	outbox, store := newTestOutbox(t, &now)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	// A reply stored before the process stopped is claimed for claimDelay, then delivered
+	message, err := newOutboxMessage(1, &bot.SendMessageParams{ChatID: int64(1), Text: "saved"}, now)
+	if err != nil {
+		t.Fatalf("newOutboxMessage failed: %v", err)
+	}
+	if err := store.EnqueueOutbox(ctx, message); err != nil {
+		t.Fatalf("EnqueueOutbox failed: %v", err)
+	}
+	if sent, _ := outbox.Flush(ctx, api); sent != 0 {
+		t.Fatalf("expected the claimed reply left alone, sent %d", sent)
+	}
+	now = now.Add(claimDelay)
+	if sent, err := outbox.Flush(ctx, api); sent != 1 || err != nil || api.Sent[0].Text != "saved" {
+		t.Errorf("expected the reply delivered after the claim expired, got sent=%d err=%v", sent, err)
+	}
+}
+
+func TestOutboxSendAfterQueuesWithTheWrite(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	outbox, store := newTestOutbox(t, &now)
+	api := testutil.NewFakeTelegram()
+	api.Err = errors.New("connection reset")
+	ctx := context.Background()
+
+	sess := session.NewSession(1, "Saved")
+	err := outbox.SendAfter(ctx, api, 1, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		if err := store.Create(ctx, sess); err != nil {
+			return nil, err
+		}
+		return &bot.SendMessageParams{ChatID: int64(1), Text: "saved"}, nil
+	})
+	if err != nil {
+		t.Fatalf("SendAfter failed: %v", err)
+	}
+	if _, err := store.Get(ctx, sess.ID); err != nil {
+		t.Errorf("expected the write committed: %v", err)
+	}
+	if left := pending(t, store); len(left) != 1 || left[0].Text != "saved" {
+		t.Errorf("expected the failed reply queued, got %+v", left)
+	}
+}
+
+func TestOutboxSendAfterRollsBackFailedWrites(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	outbox, store := newTestOutbox(t, &now)
+	api := testutil.NewFakeTelegram()
+	ctx := context.Background()
+
+	sess := session.NewSession(1, "Lost")
+	writeErr := errors.New("later write failed")
+	err := outbox.SendAfter(ctx, api, 1, func(ctx context.Context) (*bot.SendMessageParams, error) {
+		if err := store.Create(ctx, sess); err != nil {
+			return nil, err
+		}
+		return nil, writeErr
+	})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("expected the write error, got %v", err)
+	}
+	if _, err := store.Get(ctx, sess.ID); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("expected the write rolled back, got %v", err)
+	}
+	if len(api.Sent) != 0 || len(pending(t, store)) != 0 {
+		t.Errorf("expected no reply after a failed write, sent %+v", api.Sent)
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Metrics published under /debug/vars
+var cooldownRejected = expvar.NewInt("ratelimit_commands_on_cooldown_total")
+
+// cooldownKey identifies one user's uses of one command
+type cooldownKey struct {
+	userID  int64
+	command string
+}
+
+// Cooldowns enforces a minimum interval between two uses of a command by the
+// same user. Last uses live in memory only, so a restart clears all cooldowns.
+type Cooldowns struct {
+	durations map[string]time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	lastUsed map[cooldownKey]time.Time
+}
+
+// NewCooldowns creates cooldowns for the commands in durations, e.g. "/export"
+func NewCooldowns(durations map[string]time.Duration) *Cooldowns {
+	return &Cooldowns{
+		durations: durations,
+		now:       time.Now,
+		lastUsed:  make(map[cooldownKey]time.Time),
+	}
+}
+
+// Allow records a use of command by userID. When the command is still cooling
+// down from the previous use it returns false and the time left; rejected uses
+// do not restart the cooldown.
+func (c *Cooldowns) Allow(userID int64, command string) (bool, time.Duration) {
+	duration, ok := c.durations[command]
+	if !ok {
+		return true, 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	key := cooldownKey{userID: userID, command: command}
+	if last, ok := c.lastUsed[key]; ok {
+		if wait := last.Add(duration).Sub(now); wait > 0 {
+			cooldownRejected.Add(1)
+			return false, wait
+		}
+	}
+	c.lastUsed[key] = now
+	return true, 0
+}
+
+// Start drops finished cooldowns every interval until ctx is cancelled
+func (c *Cooldowns) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Sweep()
+			}
+		}
+	}()
+}
+
+// Sweep drops the uses whose cooldown has passed; they are the same as no use
+func (c *Cooldowns) Sweep() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	evicted := 0
+	for key, last := range c.lastUsed {
+		if !now.Before(last.Add(c.durations[key.command])) {
+			delete(c.lastUsed, key)
+			evicted++
+		}
+	}
+	return evicted
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldowns_Allow(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	cooldowns := NewCooldowns(map[string]time.Duration{"/export": 5 * time.Minute})
+	cooldowns.now = func() time.Time { return now }
+
+	if ok, _ := cooldowns.Allow(1, "/export"); !ok {
+		t.Fatal("expected the first use to be allowed")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if ok, wait := cooldowns.Allow(1, "/export"); ok || wait != 3*time.Minute {
+		t.Errorf("expected a 3m wait, got ok=%v wait=%v", ok, wait)
+	}
+	if ok, _ := cooldowns.Allow(2, "/export"); !ok {
+		t.Error("other users should not be on cooldown")
+	}
+	if ok, _ := cooldowns.Allow(1, "/stats"); !ok {
+		t.Error("commands without a cooldown should always be allowed")
+	}
+
+	// The rejected use did not restart the cooldown
+	now = now.Add(3 * time.Minute)
+	if ok, _ := cooldowns.Allow(1, "/export"); !ok {
+		t.Error("expected the command to be allowed once the cooldown passed")
+	}
+}
+
+func TestCooldowns_Sweep(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	cooldowns := NewCooldowns(map[string]time.Duration{"/export": 5 * time.Minute, "/stats": time.Minute})
+	cooldowns.now = func() time.Time { return now }
+
+	cooldowns.Allow(1, "/export")
+	cooldowns.Allow(1, "/stats")
+
+	now = now.Add(time.Minute)
+	if evicted := cooldowns.Sweep(); evicted != 1 {
+		t.Errorf("expected the finished /stats cooldown to be evicted, got %d", evicted)
+	}
+	if ok, _ := cooldowns.Allow(1, "/export"); ok {
+		t.Error("expected /export to still be cooling down after a sweep")
+	}
+}
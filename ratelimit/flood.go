@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Metrics published under /debug/vars
+var (
+	floodsDetected = expvar.NewInt("ratelimit_file_floods_total")
+	floodSkipped   = expvar.NewInt("ratelimit_files_skipped_total")
+)
+
+// FloodOptions configures a FloodDetector
+type FloodOptions struct {
+	MaxFiles int           // files a user may send within Window; more pause their downloads
+	Window   time.Duration // span in which files are counted
+	Pause    time.Duration // how long downloads stay paused after a flood
+}
+
+// FloodDecision is the outcome of FloodDetector.Record
+type FloodDecision struct {
+	Allowed     bool      // the files may be downloaded
+	Tripped     bool      // these files started a pause; true only once per pause
+	PausedUntil time.Time // end of the pause when not allowed
+}
+
+// floodState is one user's recent files and pause
+type floodState struct {
+	sent        []time.Time // when each file in the window arrived, oldest first
+	pausedUntil time.Time
+}
+
+// FloodDetector notices users sending many files in a short window and pauses
+// their downloads for a while. State lives in memory only, so a restart lifts
+// all pauses.
+type FloodDetector struct {
+	options FloodOptions
+	now     func() time.Time
+
+	mu    sync.Mutex
+	users map[int64]*floodState
+}
+
+// NewFloodDetector creates a flood detector
+func NewFloodDetector(options FloodOptions) *FloodDetector {
+	return &FloodDetector{
+		options: options,
+		now:     time.Now,
+		users:   make(map[int64]*floodState),
+	}
+}
+
+// Record counts files sent by userID now. Files sent while a pause lasts are
+// not counted, so the pause is not extended by a user who keeps sending.
+func (d *FloodDetector) Record(userID int64, files int) FloodDecision {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	state, ok := d.users[userID]
+	if !ok {
+		state = &floodState{}
+		d.users[userID] = state
+	}
+	if now.Before(state.pausedUntil) {
+		floodSkipped.Add(int64(files))
+		return FloodDecision{PausedUntil: state.pausedUntil}
+	}
+
+	state.sent = dropBefore(state.sent, now.Add(-d.options.Window))
+	for range files {
+		state.sent = append(state.sent, now)
+	}
+	if len(state.sent) <= d.options.MaxFiles {
+		return FloodDecision{Allowed: true}
+	}
+
+	state.sent = nil
+	state.pausedUntil = now.Add(d.options.Pause)
+	floodsDetected.Add(1)
+	floodSkipped.Add(int64(files))
+	return FloodDecision{Tripped: true, PausedUntil: state.pausedUntil}
+}
+
+// dropBefore removes the times before cutoff from the sorted times
+func dropBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// Start drops idle users every interval until ctx is cancelled
+func (d *FloodDetector) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.Sweep()
+			}
+		}
+	}()
+}
+
+// Sweep drops users with no files in the window and no pause; they are the
+// same as users who sent nothing
+func (d *FloodDetector) Sweep() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	evicted := 0
+	for userID, state := range d.users {
+		state.sent = dropBefore(state.sent, now.Add(-d.options.Window))
+		if len(state.sent) == 0 && !now.Before(state.pausedUntil) {
+			delete(d.users, userID)
+			evicted++
+		}
+	}
+	return evicted
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFloodDetector_Record(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	detector := NewFloodDetector(FloodOptions{MaxFiles: 5, Window: time.Minute, Pause: 10 * time.Minute})
+	detector.now = func() time.Time { return now }
+
+	if decision := detector.Record(1, 3); !decision.Allowed {
+		t.Fatalf("expected files under the limit to be allowed, got %+v", decision)
+	}
+
+	// Files older than the window no longer count
+	now = now.Add(61 * time.Second)
+	if decision := detector.Record(1, 5); !decision.Allowed {
+		t.Fatalf("expected the earlier files to have left the window, got %+v", decision)
+	}
+
+	now = now.Add(10 * time.Second)
+	decision := detector.Record(1, 1)
+	if decision.Allowed || !decision.Tripped || !decision.PausedUntil.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("expected the sixth file to start a pause, got %+v", decision)
+	}
+	if other := detector.Record(2, 5); !other.Allowed {
+		t.Errorf("other users should not be paused, got %+v", other)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if decision := detector.Record(1, 1); decision.Allowed || decision.Tripped {
+		t.Errorf("expected files during the pause to be skipped without a new trip, got %+v", decision)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if decision := detector.Record(1, 5); !decision.Allowed {
+		t.Errorf("expected downloads to resume after the pause, got %+v", decision)
+	}
+}
+
+func TestFloodDetector_Sweep(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	detector := NewFloodDetector(FloodOptions{MaxFiles: 1, Window: time.Minute, Pause: 10 * time.Minute})
+	detector.now = func() time.Time { return now }
+
+	detector.Record(1, 1)
+	detector.Record(2, 2)
+
+	now = now.Add(2 * time.Minute)
+	if evicted := detector.Sweep(); evicted != 1 {
+		t.Errorf("expected only the idle user evicted, got %d", evicted)
+	}
+	if decision := detector.Record(2, 1); decision.Allowed {
+		t.Error("expected the pause to survive a sweep")
+	}
+}
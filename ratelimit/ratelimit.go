@@ -0,0 +1,195 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"tg-bot-demo/session"
+)
+
+// Package ratelimit throttles inbound messages per user with token buckets.
+// Buckets live in memory; users beyond the in-memory capacity are limited
+// through the store instead, and buckets that are not full are saved on
+// shutdown so a restart does not hand spammers a fresh allowance.
+//
+// Cooldowns additionally space out uses of expensive commands per user.
+
+// DefaultMaxBuckets is the number of users tracked in memory when Options.MaxBuckets is 0
+const DefaultMaxBuckets = 10000
+
+// Metrics published under /debug/vars
+var throttled = expvar.NewInt("ratelimit_messages_throttled_total")
+
+// Options configures a Limiter
+type Options struct {
+	PerMinute  int // tokens added per minute, i.e. the sustained message rate
+	Burst      int // bucket size, i.e. messages accepted back to back; at least 1
+	MaxBuckets int // users tracked in memory before falling back to the store
+}
+
+// Decision is the outcome of Allow
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration // until the next message is accepted; zero when allowed
+	// Warn is set on the first rejected message since the user's last accepted
+	// one, so the user is told to slow down once instead of on every message
+	Warn bool
+}
+
+// Limiter is a per-user token bucket rate limiter
+type Limiter struct {
+	store      session.RateLimitStore
+	rate       float64 // tokens per second
+	burst      float64
+	maxBuckets int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets map[int64]*session.RateLimitBucket
+}
+
+// New creates a limiter saving buckets in store
+func New(store session.RateLimitStore, options Options) *Limiter {
+	burst := max(options.Burst, 1)
+	maxBuckets := options.MaxBuckets
+	if maxBuckets <= 0 {
+		maxBuckets = DefaultMaxBuckets
+	}
+	return &Limiter{
+		store:      store,
+		rate:       float64(options.PerMinute) / 60,
+		burst:      float64(burst),
+		maxBuckets: maxBuckets,
+		now:        time.Now,
+		buckets:    make(map[int64]*session.RateLimitBucket),
+	}
+}
+
+// Allow takes a token from the bucket of userID
+func (l *Limiter) Allow(ctx context.Context, userID int64) Decision {
+	l.mu.Lock()
+	bucket, ok := l.buckets[userID]
+	if ok {
+		defer l.mu.Unlock()
+		return l.take(bucket)
+	}
+	l.mu.Unlock()
+
+	// Not in memory: start from the saved bucket, if any
+	bucket = l.load(ctx, userID)
+
+	l.mu.Lock()
+	if existing, ok := l.buckets[userID]; ok {
+		// Another message of the user got here first
+		defer l.mu.Unlock()
+		return l.take(existing)
+	}
+	if len(l.buckets) < l.maxBuckets {
+		l.buckets[userID] = bucket
+		defer l.mu.Unlock()
+		return l.take(bucket)
+	}
+	l.mu.Unlock()
+
+	// Memory is full: limit this user through the store
+	decision := l.take(bucket)
+	if err := l.store.SaveRateLimitBuckets(ctx, []*session.RateLimitBucket{bucket}); err != nil {
+		log.Printf("rate limit: failed to save bucket: user_id=%d err=%v", userID, err)
+	}
+	return decision
+}
+
+// load returns the saved bucket of userID, or a full one
+func (l *Limiter) load(ctx context.Context, userID int64) *session.RateLimitBucket {
+	bucket, err := l.store.GetRateLimitBucket(ctx, userID)
+	if err == nil {
+		return bucket
+	}
+	if !errors.Is(err, session.ErrRateLimitBucketNotFound) {
+		// Fail open: a broken store must not silence every user
+		log.Printf("rate limit: failed to load bucket: user_id=%d err=%v", userID, err)
+	}
+	return &session.RateLimitBucket{UserID: userID, Tokens: l.burst, UpdatedAt: l.now()}
+}
+
+// take refills bucket for the time passed and takes one token if there is one
+func (l *Limiter) take(bucket *session.RateLimitBucket) Decision {
+	l.refill(bucket)
+
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		bucket.Warned = false
+		return Decision{Allowed: true}
+	}
+
+	throttled.Add(1)
+	decision := Decision{Warn: !bucket.Warned}
+	bucket.Warned = true
+	if l.rate > 0 {
+		decision.RetryAfter = time.Duration(math.Ceil((1 - bucket.Tokens) / l.rate * float64(time.Second)))
+	}
+	return decision
+}
+
+// refill adds the tokens earned since bucket was last updated
+func (l *Limiter) refill(bucket *session.RateLimitBucket) {
+	now := l.now()
+	if elapsed := now.Sub(bucket.UpdatedAt).Seconds(); elapsed > 0 {
+		bucket.Tokens = math.Min(l.burst, bucket.Tokens+elapsed*l.rate)
+	}
+	bucket.UpdatedAt = now
+}
+
+// Start evicts full buckets from memory every interval until ctx is cancelled
+func (l *Limiter) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.Sweep()
+			}
+		}
+	}()
+}
+
+// Sweep drops buckets that have refilled completely; they are the same as no bucket
+func (l *Limiter) Sweep() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	evicted := 0
+	for userID, bucket := range l.buckets {
+		l.refill(bucket)
+		if bucket.Tokens >= l.burst {
+			delete(l.buckets, userID)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Flush saves the buckets that have not refilled yet, e.g. before shutdown
+func (l *Limiter) Flush(ctx context.Context) error {
+	l.mu.Lock()
+	pending := make([]*session.RateLimitBucket, 0, len(l.buckets))
+	for _, bucket := range l.buckets {
+		l.refill(bucket)
+		if bucket.Tokens < l.burst {
+			saved := *bucket
+			pending = append(pending, &saved)
+		}
+	}
+	l.mu.Unlock()
+
+	return l.store.SaveRateLimitBuckets(ctx, pending)
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+)
+
+func newTestLimiter(t *testing.T, options Options) (*Limiter, *session.SQLiteStore, *time.Time) {
+	t.Helper()
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	limiter := New(store, options)
+	limiter.now = func() time.Time { return now }
+	return limiter, store, &now
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	limiter, _, now := newTestLimiter(t, Options{PerMinute: 6, Burst: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if decision := limiter.Allow(ctx, 1); !decision.Allowed {
+			t.Fatalf("message %d should be allowed within the burst", i+1)
+		}
+	}
+
+	decision := limiter.Allow(ctx, 1)
+	if decision.Allowed || !decision.Warn || decision.RetryAfter != 10*time.Second {
+		t.Errorf("expected first rejection with warning and 10s wait, got %+v", decision)
+	}
+	if decision := limiter.Allow(ctx, 1); decision.Allowed || decision.Warn {
+		t.Errorf("expected silent rejection, got %+v", decision)
+	}
+	if decision := limiter.Allow(ctx, 2); !decision.Allowed {
+		t.Error("other users should not be throttled")
+	}
+
+	*now = now.Add(10 * time.Second)
+	if decision := limiter.Allow(ctx, 1); !decision.Allowed {
+		t.Errorf("expected a refilled token after 10s, got %+v", decision)
+	}
+	if decision := limiter.Allow(ctx, 1); decision.Allowed || !decision.Warn {
+		t.Errorf("expected a new warning after an accepted message, got %+v", decision)
+	}
+}
+
+func TestLimiter_FlushSurvivesRestart(t *testing.T) {
+	limiter, store, now := newTestLimiter(t, Options{PerMinute: 6, Burst: 1})
+	ctx := context.Background()
+
+	limiter.Allow(ctx, 1)
+	limiter.Allow(ctx, 3)
+	*now = now.Add(time.Minute)
+	limiter.Allow(ctx, 3) // takes the refilled token again
+	if err := limiter.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if _, err := store.GetRateLimitBucket(ctx, 1); err != session.ErrRateLimitBucketNotFound {
+		t.Errorf("expected refilled bucket of user 1 not to be saved, got %v", err)
+	}
+
+	restarted := New(store, Options{PerMinute: 6, Burst: 1})
+	restarted.now = limiter.now
+	if decision := restarted.Allow(ctx, 3); decision.Allowed {
+		t.Error("expected throttling to survive a restart")
+	}
+}
+
+func TestLimiter_StoreFallback(t *testing.T) {
+	limiter, store, _ := newTestLimiter(t, Options{PerMinute: 6, Burst: 1, MaxBuckets: 1})
+	ctx := context.Background()
+
+	limiter.Allow(ctx, 1)
+	if decision := limiter.Allow(ctx, 2); !decision.Allowed {
+		t.Fatal("first message of user 2 should be allowed")
+	}
+	if _, err := store.GetRateLimitBucket(ctx, 2); err != nil {
+		t.Fatalf("expected user 2 to be limited through the store, got %v", err)
+	}
+	if decision := limiter.Allow(ctx, 2); decision.Allowed || !decision.Warn {
+		t.Errorf("expected user 2 to be throttled through the store, got %+v", decision)
+	}
+	if decision := limiter.Allow(ctx, 2); decision.Warn {
+		t.Error("expected the warning state to be saved")
+	}
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	limiter, _, now := newTestLimiter(t, Options{PerMinute: 60, Burst: 5})
+	ctx := context.Background()
+
+	limiter.Allow(ctx, 1)
+	if evicted := limiter.Sweep(); evicted != 0 {
+		t.Errorf("expected a draining bucket to stay, evicted %d", evicted)
+	}
+	*now = now.Add(time.Second)
+	if evicted := limiter.Sweep(); evicted != 1 {
+		t.Errorf("expected the refilled bucket to be evicted, evicted %d", evicted)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tg-bot-demo/config"
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/requestlog"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// runReplay implements the replay subcommand: recorded webhook requests are read
+// from the request log and fed through the handler pipeline again, one at a time.
+// Unless -send is given, Telegram API calls go to a local stub that prints them.
+func runReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	from := flags.String("from", "", "Request log to replay: a file log or a .db SQLite log (default: the configured sink)")
+	requestID := flags.String("request-id", "", "Replay only the request with this request ID")
+	limit := flags.Int("limit", 0, "Replay only the last N requests (0 = all)")
+	send := flags.Bool("send", false, "Send API calls to Telegram instead of printing them")
+	flags.Parse(args)
+
+	cfg, err := overrides.load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	records, err := loadRecordedRequests(cfg, *from)
+	if err != nil {
+		return err
+	}
+	records = selectRecords(records, *requestID, *limit)
+	if len(records) == 0 {
+		return errors.New("no recorded requests to replay")
+	}
+
+	options := []bot.Option{bot.WithNotAsyncHandlers()}
+	if !*send {
+		stub, err := startStubAPI()
+		if err != nil {
+			return err
+		}
+		defer stub.Close()
+		options = append(options, bot.WithServerURL(stub.URL))
+	}
+
+	app, err := initializeBot(cfg, options...)
+	if err != nil {
+		return fmt.Errorf("initialize bot: %w", err)
+	}
+	defer app.Close()
+
+	replayed := replayRecords(context.Background(), app.bot, records)
+	log.Printf("replay finished: replayed=%d skipped=%d", replayed, len(records)-replayed)
+	return nil
+}
+
+// loadRecordedRequests reads the request log at from, or the configured request log
+func loadRecordedRequests(cfg *config.Config, from string) ([]requestlog.Record, error) {
+	sink := cfg.RequestLogSink
+	if from == "" {
+		from = cfg.RequestLogPath
+		switch sink {
+		case "file":
+			if from == "" {
+				from = "./data/requests.jsonl"
+			}
+		case "sqlite":
+			if from == "" {
+				from = "./data/requests.db"
+			}
+		default:
+			return nil, fmt.Errorf("request log sink %q cannot be read back; pass -from", sink)
+		}
+	} else if strings.HasSuffix(from, ".db") {
+		sink = "sqlite"
+	} else {
+		sink = "file"
+	}
+
+	if sink == "sqlite" {
+		return requestlog.ReadSQLite(from)
+	}
+	return requestlog.ReadFile(from)
+}
+
+// selectRecords keeps the record with requestID, or the last limit records
+func selectRecords(records []requestlog.Record, requestID string, limit int) []requestlog.Record {
+	if requestID != "" {
+		var selected []requestlog.Record
+		for _, record := range records {
+			if record.RequestID == requestID {
+				selected = append(selected, record)
+			}
+		}
+		return selected
+	}
+	if limit > 0 && len(records) > limit {
+		return records[len(records)-limit:]
+	}
+	return records
+}
+
+// replayRecords processes the update of each record under its original request ID
+// and returns how many were replayed
+func replayRecords(ctx context.Context, b *bot.Bot, records []requestlog.Record) int {
+	replayed := 0
+	albums := false
+	for _, record := range records {
+		if record.BodyTruncated {
+			log.Printf("replay skipped: request_id=%s reason=body truncated", record.RequestID)
+			continue
+		}
+
+		var update models.Update
+		if err := json.Unmarshal(record.Body, &update); err != nil {
+			log.Printf("replay skipped: request_id=%s reason=not an update err=%v", record.RequestID, err)
+			continue
+		}
+
+		log.Printf("replaying: request_id=%s update_id=%d received_at=%s",
+			record.RequestID, update.ID, record.ReceivedAt.Format(time.RFC3339))
+		updateCtx := correlation.WithID(ctx, record.RequestID)
+		correlation.Remember(updateCtx, update.ID)
+		b.ProcessUpdate(updateCtx, &update)
+		replayed++
+
+		if message := messageFromUpdate(&update); message != nil && message.MediaGroupID != "" {
+			albums = true
+		}
+	}
+
+	// Album parts are flushed after a delay; give the last album time to complete
+	if albums {
+		time.Sleep(2 * mediaGroupFlushDelay)
+	}
+	return replayed
+}
+
+// stubTrueMethods are API methods whose result is a plain true
+var stubTrueMethods = map[string]bool{
+	"answerCallbackQuery": true,
+	"answerInlineQuery":   true,
+	"deleteMessage":       true,
+	"deleteMessages":      true,
+	"sendChatAction":      true,
+	"setMessageReaction":  true,
+}
+
+// stubAPI is a local stand-in for the Telegram Bot API that prints every call
+type stubAPI struct {
+	URL    string
+	server *http.Server
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// startStubAPI starts a stub API server on a random local port
+func startStubAPI() (*stubAPI, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("start stub API: %w", err)
+	}
+
+	stub := &stubAPI{URL: "http://" + listener.Addr().String()}
+	stub.server = &http.Server{Handler: stub, ReadHeaderTimeout: 5 * time.Second}
+	go stub.server.Serve(listener)
+	return stub, nil
+}
+
+// ServeHTTP prints the API call and answers it with a minimal successful result
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := path.Base(r.URL.Path)
+	r.ParseMultipartForm(1 << 20)
+
+	fields := make([]string, 0, len(r.Form))
+	for key, values := range r.Form {
+		fields = append(fields, key+"="+strings.Join(values, ","))
+	}
+	sort.Strings(fields)
+	log.Printf("api call: method=%s %s", method, strings.Join(fields, " "))
+
+	s.mu.Lock()
+	s.calls = append(s.calls, method)
+	s.mu.Unlock()
+
+	result := `{"message_id":1,"date":0,"chat":{"id":0,"type":"private"}}`
+	if stubTrueMethods[method] {
+		result = "true"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"ok":true,"result":%s}`, result)
+}
+
+// Calls returns the names of the API methods called so far
+func (s *stubAPI) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// Close stops the stub server
+func (s *stubAPI) Close() error {
+	return s.server.Close()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"tg-bot-demo/config"
+	"tg-bot-demo/requestlog"
+
+	"github.com/go-telegram/bot"
+)
+
+func TestReplayRecordsThroughStubAPI(t *testing.T) {
+	stub, err := startStubAPI()
+	if err != nil {
+		t.Fatalf("startStubAPI failed: %v", err)
+	}
+	defer stub.Close()
+
+	cfg := config.Default()
+	cfg.Token = "123456:test-token"
+	cfg.DatabasePath = filepath.Join(t.TempDir(), "sessions.db")
+	app, err := initializeBot(cfg, bot.WithNotAsyncHandlers(), bot.WithServerURL(stub.URL))
+	if err != nil {
+		t.Fatalf("initializeBot failed: %v", err)
+	}
+	defer app.Close()
+
+	records := []requestlog.Record{
+		{RequestID: "req-1", Body: []byte(`{"update_id":1,"message":{"message_id":10,"from":{"id":42,"first_name":"A"},"chat":{"id":42,"type":"private"},"date":1,"text":"hello"}}`)},
+		{RequestID: "req-2", Body: []byte(`{"update_id":2`), BodyTruncated: true},
+		{RequestID: "req-3", Body: []byte("not json")},
+	}
+
+	if replayed := replayRecords(context.Background(), app.bot, records); replayed != 1 {
+		t.Errorf("Expected 1 replayed record, got %d", replayed)
+	}
+
+	sessions, err := app.store.ListByUser(context.Background(), 42, 0, 10)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Errorf("Expected the replayed message to create a session, got %d", len(sessions))
+	}
+	if calls := stub.Calls(); len(calls) == 0 || calls[0] != "sendMessage" {
+		t.Errorf("Expected the reply to reach the stub API, got %v", calls)
+	}
+}
+
+func TestSelectRecords(t *testing.T) {
+	records := []requestlog.Record{{RequestID: "a"}, {RequestID: "b"}, {RequestID: "c"}}
+
+	if got := selectRecords(records, "b", 0); len(got) != 1 || got[0].RequestID != "b" {
+		t.Errorf("Expected record b, got %+v", got)
+	}
+	if got := selectRecords(records, "", 2); len(got) != 2 || got[0].RequestID != "b" {
+		t.Errorf("Expected the last 2 records, got %+v", got)
+	}
+	if got := selectRecords(records, "", 0); len(got) != 3 {
+		t.Errorf("Expected all records, got %d", len(got))
+	}
+}
@@ -0,0 +1,84 @@
+package reporting
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// Package reporting forwards errors and recovered panics to an external error
+// tracker such as Sentry. Without a configured reporter every call is a no-op.
+
+// Event describes one reported error
+type Event struct {
+	Err       error
+	Operation string
+	UserID    int64
+	RequestID string
+	Details   map[string]interface{}
+	Update    *models.Update // update being handled, attached as a breadcrumb; may be nil
+	Stack     []byte         // stack trace of a recovered panic; nil for ordinary errors
+}
+
+// Reporter delivers events to an error tracker
+type Reporter interface {
+	// Report sends event; it must not block the caller for long
+	Report(event Event)
+
+	// Flush waits up to timeout for queued events to be delivered
+	Flush(timeout time.Duration) bool
+}
+
+var (
+	mu       sync.RWMutex
+	reporter Reporter
+)
+
+// SetReporter installs r as the process-wide reporter; nil disables reporting
+func SetReporter(r Reporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	reporter = r
+}
+
+// Report sends event to the installed reporter, filling in the update carried by ctx
+func Report(ctx context.Context, event Event) {
+	mu.RLock()
+	r := reporter
+	mu.RUnlock()
+
+	if r == nil {
+		return
+	}
+	if event.Update == nil {
+		event.Update = UpdateFromContext(ctx)
+	}
+	r.Report(event)
+}
+
+// Flush waits up to timeout for the installed reporter to deliver queued events
+func Flush(timeout time.Duration) bool {
+	mu.RLock()
+	r := reporter
+	mu.RUnlock()
+
+	if r == nil {
+		return true
+	}
+	return r.Flush(timeout)
+}
+
+type updateKey struct{}
+
+// WithUpdate returns a context carrying the update being handled
+func WithUpdate(ctx context.Context, update *models.Update) context.Context {
+	return context.WithValue(ctx, updateKey{}, update)
+}
+
+// UpdateFromContext returns the update carried by ctx, or nil
+func UpdateFromContext(ctx context.Context) *models.Update {
+	update, _ := ctx.Value(updateKey{}).(*models.Update)
+	return update
+}
@@ -0,0 +1,67 @@
+package reporting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// recordingReporter keeps reported events in memory
+type recordingReporter struct {
+	events []Event
+}
+
+func (r *recordingReporter) Report(event Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) Flush(time.Duration) bool {
+	return true
+}
+
+func TestReportWithoutReporter(t *testing.T) {
+	SetReporter(nil)
+	Report(context.Background(), Event{Err: errors.New("boom")})
+	if !Flush(time.Second) {
+		t.Error("Expected Flush without reporter to succeed")
+	}
+}
+
+func TestReportAttachesContextUpdate(t *testing.T) {
+	recorder := &recordingReporter{}
+	SetReporter(recorder)
+	defer SetReporter(nil)
+
+	update := &models.Update{ID: 42}
+	Report(WithUpdate(context.Background(), update), Event{Err: errors.New("boom"), Operation: "open_command"})
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(recorder.events))
+	}
+	if recorder.events[0].Update != update {
+		t.Errorf("Expected update from context, got %+v", recorder.events[0].Update)
+	}
+}
+
+func TestUpdateBreadcrumbTruncatesPayload(t *testing.T) {
+	update := &models.Update{ID: 7, Message: &models.Message{Text: strings.Repeat("x", 2*maxUpdateBreadcrumbBytes)}}
+
+	breadcrumb := updateBreadcrumb(Event{Update: update})
+	if breadcrumb == nil {
+		t.Fatal("Expected a breadcrumb")
+	}
+	if len(breadcrumb.Message) != maxUpdateBreadcrumbBytes+3 {
+		t.Errorf("Expected payload truncated to %d bytes, got %d", maxUpdateBreadcrumbBytes+3, len(breadcrumb.Message))
+	}
+	if breadcrumb.Data["update_id"] != int64(7) {
+		t.Errorf("Expected update_id 7, got %v", breadcrumb.Data["update_id"])
+	}
+
+	if updateBreadcrumb(Event{}) != nil {
+		t.Error("Expected no breadcrumb without an update")
+	}
+}
@@ -0,0 +1,92 @@
+package reporting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// maxUpdateBreadcrumbBytes limits the update payload attached to an event
+const maxUpdateBreadcrumbBytes = 8 * 1024
+
+// SentryConfig holds Sentry settings
+type SentryConfig struct {
+	DSN         string
+	Environment string
+	Release     string
+}
+
+// SentryReporter reports events to Sentry
+type SentryReporter struct {
+	client *sentry.Client
+}
+
+// NewSentryReporter creates a reporter sending to the Sentry project of cfg.DSN
+func NewSentryReporter(cfg SentryConfig) (*SentryReporter, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         cfg.DSN,
+		Environment: cfg.Environment,
+		Release:     cfg.Release,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sentry client: %w", err)
+	}
+	return &SentryReporter{client: client}, nil
+}
+
+// Report sends event to Sentry with the user, operation and update as context
+func (r *SentryReporter) Report(event Event) {
+	scope := sentry.NewScope()
+	if event.UserID != 0 {
+		scope.SetUser(sentry.User{ID: strconv.FormatInt(event.UserID, 10)})
+	}
+	if event.Operation != "" {
+		scope.SetTag("operation", event.Operation)
+	}
+	if event.RequestID != "" {
+		scope.SetTag("request_id", event.RequestID)
+	}
+	if len(event.Details) > 0 {
+		scope.SetContext("details", sentry.Context(event.Details))
+	}
+	if event.Stack != nil {
+		scope.SetTag("panic", "true")
+		scope.SetContext("panic", sentry.Context{"stack": string(event.Stack)})
+	}
+	if breadcrumb := updateBreadcrumb(event); breadcrumb != nil {
+		scope.AddBreadcrumb(breadcrumb, 1)
+	}
+
+	sentry.NewHub(r.client, scope).CaptureException(event.Err)
+}
+
+// Flush waits up to timeout for queued events to be delivered
+func (r *SentryReporter) Flush(timeout time.Duration) bool {
+	return r.client.Flush(timeout)
+}
+
+// updateBreadcrumb records the update being handled, truncated to a sane size
+func updateBreadcrumb(event Event) *sentry.Breadcrumb {
+	if event.Update == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(event.Update)
+	if err != nil {
+		return nil
+	}
+	if len(payload) > maxUpdateBreadcrumbBytes {
+		payload = append(payload[:maxUpdateBreadcrumbBytes], "..."...)
+	}
+
+	return &sentry.Breadcrumb{
+		Type:     "default",
+		Category: "telegram.update",
+		Message:  string(payload),
+		Data:     map[string]interface{}{"update_id": event.Update.ID},
+		Level:    sentry.LevelInfo,
+	}
+}
@@ -0,0 +1,130 @@
+package requestlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink appends records as JSON lines to a file and rotates it by size.
+// Rotated files are named path.1 (newest) to path.N (oldest).
+type FileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileSink opens path for appending. The file is rotated once it would grow
+// beyond maxBytes (0 disables rotation), keeping at most maxBackups old files.
+func NewFileSink(path string, maxBytes int64, maxBackups int) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create request log directory: %w", err)
+	}
+
+	sink := &FileSink{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// Write appends record as one JSON line
+func (s *FileSink) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal request log: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write request log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the current file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// open opens the current file for appending
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open request log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat request log: %w", err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups by one and starts a new current file
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("close request log: %w", err)
+	}
+
+	if s.maxBackups > 0 {
+		os.Remove(backupPath(s.path, s.maxBackups))
+		for i := s.maxBackups - 1; i >= 1; i-- {
+			os.Rename(backupPath(s.path, i), backupPath(s.path, i+1))
+		}
+		if err := os.Rename(s.path, backupPath(s.path, 1)); err != nil {
+			return fmt.Errorf("rotate request log: %w", err)
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return fmt.Errorf("rotate request log: %w", err)
+	}
+
+	return s.open()
+}
+
+// backupPath returns the name of the n-th rotated file
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// ReadFile reads the records of a request log file. It accepts the JSON lines
+// written by FileSink as well as a saved dump of the indented stdout sink.
+func ReadFile(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request log: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var record Record
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("failed to parse request log record %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,83 @@
+package requestlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSinkRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "requests.jsonl")
+	sink, err := NewFileSink(path, 200, 2)
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	defer sink.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := sink.Write(Record{RequestID: "req", Body: []byte(`{"update_id":1}`)}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("Expected %s to stay within 200 bytes, got %d", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Expected at most 2 backups")
+	}
+}
+
+func TestFileSinkWritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	sink, err := NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	sink.Write(Record{RequestID: "a"})
+	sink.Write(Record{RequestID: "b"})
+	sink.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Errorf("Expected 2 JSON lines, got %d", lines)
+	}
+
+	records, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if len(records) != 2 || records[0].RequestID != "a" || records[1].RequestID != "b" {
+		t.Errorf("Expected records a and b, got %+v", records)
+	}
+}
+
+func TestReadFileAcceptsStdoutDump(t *testing.T) {
+	var dump bytes.Buffer
+	sink := NewWriterSink(&dump)
+	sink.Write(Record{RequestID: "a", Body: []byte(`{"update_id":1}`)})
+	sink.Write(Record{RequestID: "b", Body: []byte(`{"update_id":2}`)})
+
+	path := filepath.Join(t.TempDir(), "stdout.log")
+	if err := os.WriteFile(path, dump.Bytes(), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	records, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if len(records) != 2 || string(records[1].Body) != `{"update_id":2}` {
+		t.Errorf("Expected both records with their bodies, got %+v", records)
+	}
+}
@@ -0,0 +1,180 @@
+package requestlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Package requestlog records incoming webhook requests. Records go to a pluggable
+// sink (stdout, rotating files or SQLite); the Logger in front of it samples
+// requests and truncates large bodies so busy bots do not flood their logs.
+
+// Record describes one webhook request
+type Record struct {
+	RequestID     string    `json:"request_id"`
+	ReceivedAt    time.Time `json:"received_at"`
+	Method        string    `json:"method"`
+	RequestURI    string    `json:"request_uri"`
+	Proto         string    `json:"proto"`
+	RemoteAddr    string    `json:"remote_addr"`
+	ContentLength int       `json:"content_length"`
+	Headers       []Header  `json:"headers"`
+	Body          []byte    `json:"-"` // raw request body, possibly truncated
+	BodyTruncated bool      `json:"body_truncated,omitempty"`
+	ResponseCode  int       `json:"response_code"`
+}
+
+// Header is one request header with all its values
+type Header struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// recordJSON is the JSON form of a Record; the body is embedded as JSON when it parses
+type recordJSON struct {
+	RequestID     string          `json:"request_id"`
+	ReceivedAt    string          `json:"received_at"`
+	Method        string          `json:"method"`
+	RequestURI    string          `json:"request_uri"`
+	Proto         string          `json:"proto"`
+	RemoteAddr    string          `json:"remote_addr"`
+	ContentLength int             `json:"content_length"`
+	Headers       []Header        `json:"headers"`
+	Body          json.RawMessage `json:"body"`
+	BodyTruncated bool            `json:"body_truncated,omitempty"`
+	ResponseCode  int             `json:"response_code"`
+}
+
+// MarshalJSON encodes the record with its body inlined as JSON, or as a string otherwise
+func (r Record) MarshalJSON() ([]byte, error) {
+	body := bytes.TrimSpace(r.Body)
+	if len(body) == 0 || !json.Valid(body) {
+		encoded, err := json.Marshal(string(r.Body))
+		if err != nil {
+			return nil, err
+		}
+		body = encoded
+	}
+
+	return json.Marshal(recordJSON{
+		RequestID:     r.RequestID,
+		ReceivedAt:    r.ReceivedAt.Format(time.RFC3339Nano),
+		Method:        r.Method,
+		RequestURI:    r.RequestURI,
+		Proto:         r.Proto,
+		RemoteAddr:    r.RemoteAddr,
+		ContentLength: r.ContentLength,
+		Headers:       r.Headers,
+		Body:          body,
+		BodyTruncated: r.BodyTruncated,
+		ResponseCode:  r.ResponseCode,
+	})
+}
+
+// UnmarshalJSON decodes a record written by MarshalJSON
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var decoded recordJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	// JSON bodies come back compacted, which undoes the indentation of the stdout sink
+	var body []byte
+	var text string
+	if err := json.Unmarshal(decoded.Body, &text); err == nil {
+		body = []byte(text)
+	} else {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, decoded.Body); err != nil {
+			return err
+		}
+		body = compact.Bytes()
+	}
+	receivedAt, _ := time.Parse(time.RFC3339Nano, decoded.ReceivedAt)
+
+	*r = Record{
+		RequestID:     decoded.RequestID,
+		ReceivedAt:    receivedAt,
+		Method:        decoded.Method,
+		RequestURI:    decoded.RequestURI,
+		Proto:         decoded.Proto,
+		RemoteAddr:    decoded.RemoteAddr,
+		ContentLength: decoded.ContentLength,
+		Headers:       decoded.Headers,
+		Body:          body,
+		BodyTruncated: decoded.BodyTruncated,
+		ResponseCode:  decoded.ResponseCode,
+	}
+	return nil
+}
+
+// CollectHeaders returns the headers sorted by name
+func CollectHeaders(headers http.Header) []Header {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]Header, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, Header{
+			Name:   key,
+			Values: headers[key],
+		})
+	}
+	return result
+}
+
+// Sink persists request records
+type Sink interface {
+	// Write stores one record
+	Write(record Record) error
+
+	// Close flushes and releases the sink
+	Close() error
+}
+
+// Options limits what reaches the sink
+type Options struct {
+	SampleRatio  float64 // fraction of requests recorded, 0 to 1
+	MaxBodyBytes int     // bodies are truncated to this many bytes; 0 means unlimited
+}
+
+// Logger samples and truncates records before handing them to a sink
+type Logger struct {
+	sink    Sink
+	options Options
+	sample  func() float64
+}
+
+// NewLogger creates a logger writing to sink
+func NewLogger(sink Sink, options Options) *Logger {
+	return &Logger{sink: sink, options: options, sample: rand.Float64}
+}
+
+// Log writes record to the sink unless it is sampled out. Sink errors are logged, not returned,
+// so a broken request log never fails a webhook.
+func (l *Logger) Log(record Record) {
+	if l.options.SampleRatio < 1 && l.sample() >= l.options.SampleRatio {
+		return
+	}
+	if l.options.MaxBodyBytes > 0 && len(record.Body) > l.options.MaxBodyBytes {
+		record.Body = record.Body[:l.options.MaxBodyBytes]
+		record.BodyTruncated = true
+	}
+
+	if err := l.sink.Write(record); err != nil {
+		log.Printf("request log write error: request_id=%s err=%v", record.RequestID, err)
+	}
+}
+
+// Close closes the sink
+func (l *Logger) Close() error {
+	return l.sink.Close()
+}
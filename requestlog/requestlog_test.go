@@ -0,0 +1,119 @@
+package requestlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memorySink keeps written records in memory
+type memorySink struct {
+	records []Record
+}
+
+func (s *memorySink) Write(record Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+func TestRecordJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "json body", body: `{"update_id":123,"message":{"text":"hi"}}`},
+		{name: "text body", body: "not json"},
+		{name: "empty body", body: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := Record{
+				RequestID:  "req-1",
+				ReceivedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				Method:     "POST",
+				Headers:    []Header{{Name: "Content-Type", Values: []string{"application/json"}}},
+				Body:       []byte(tt.body),
+			}
+
+			encoded, err := json.Marshal(record)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var decoded Record
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+
+			if string(decoded.Body) != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, decoded.Body)
+			}
+			if !decoded.ReceivedAt.Equal(record.ReceivedAt) || decoded.RequestID != "req-1" {
+				t.Errorf("Unexpected decoded record %+v", decoded)
+			}
+		})
+	}
+}
+
+func TestRecordJSONInlinesBody(t *testing.T) {
+	encoded, err := json.Marshal(Record{Body: []byte(`{"update_id":1}`)})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"body":{"update_id":1}`) {
+		t.Errorf("Expected body inlined as JSON, got %s", encoded)
+	}
+}
+
+func TestLoggerTruncatesBody(t *testing.T) {
+	sink := &memorySink{}
+	logger := NewLogger(sink, Options{SampleRatio: 1, MaxBodyBytes: 4})
+
+	logger.Log(Record{Body: []byte("0123456789")})
+	logger.Log(Record{Body: []byte("0123")})
+
+	if len(sink.records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(sink.records))
+	}
+	if string(sink.records[0].Body) != "0123" || !sink.records[0].BodyTruncated {
+		t.Errorf("Expected truncated body, got %q (truncated=%t)", sink.records[0].Body, sink.records[0].BodyTruncated)
+	}
+	if sink.records[1].BodyTruncated {
+		t.Error("Expected body within the limit to be kept")
+	}
+}
+
+func TestLoggerSamples(t *testing.T) {
+	sink := &memorySink{}
+	logger := NewLogger(sink, Options{SampleRatio: 0.5})
+
+	samples := []float64{0.1, 0.7, 0.49, 0.5}
+	logger.sample = func() float64 {
+		next := samples[0]
+		samples = samples[1:]
+		return next
+	}
+	for i := 0; i < 4; i++ {
+		logger.Log(Record{})
+	}
+
+	if len(sink.records) != 2 {
+		t.Errorf("Expected 2 sampled records, got %d", len(sink.records))
+	}
+}
+
+func TestWriterSinkPrettyPrints(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriterSink(&buf).Write(Record{RequestID: "req-1", Body: []byte(`{"update_id":1}`)}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "\n  \"request_id\": \"req-1\"") {
+		t.Errorf("Expected 2-space indented JSON, got %s", buf.String())
+	}
+}
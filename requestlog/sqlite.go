@@ -0,0 +1,134 @@
+package requestlog
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteSink stores records in the request_logs table of a SQLite database.
+// It uses its own database file so request logging never contends with the session store.
+type SQLiteSink struct {
+	db *sql.DB
+}
+
+// NewSQLiteSink opens or creates the database at dbPath
+func NewSQLiteSink(dbPath string) (*SQLiteSink, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create request log directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request log database: %w", err)
+	}
+
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS request_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		received_at DATETIME NOT NULL,
+		method TEXT NOT NULL,
+		request_uri TEXT NOT NULL,
+		proto TEXT NOT NULL,
+		remote_addr TEXT NOT NULL,
+		content_length INTEGER NOT NULL,
+		headers TEXT NOT NULL,
+		body BLOB NOT NULL,
+		body_truncated INTEGER NOT NULL DEFAULT 0,
+		response_code INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_request_logs_request_id
+		ON request_logs(request_id);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize request log schema: %w", err)
+	}
+
+	return &SQLiteSink{db: db}, nil
+}
+
+// Write inserts record
+func (s *SQLiteSink) Write(record Record) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return fmt.Errorf("marshal request headers: %w", err)
+	}
+
+	query := `
+		INSERT INTO request_logs (request_id, received_at, method, request_uri, proto, remote_addr,
+			content_length, headers, body, body_truncated, response_code)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	body := record.Body
+	if body == nil {
+		body = []byte{}
+	}
+	_, err = s.db.Exec(query, record.RequestID, record.ReceivedAt, record.Method, record.RequestURI,
+		record.Proto, record.RemoteAddr, record.ContentLength, string(headers), body,
+		record.BodyTruncated, record.ResponseCode)
+	if err != nil {
+		return fmt.Errorf("insert request log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
+
+// ReadSQLite reads the records stored by SQLiteSink in dbPath, oldest first
+func ReadSQLite(dbPath string) ([]Record, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open request log database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request log database: %w", err)
+	}
+	defer db.Close()
+
+	query := `
+		SELECT request_id, received_at, method, request_uri, proto, remote_addr,
+			content_length, headers, body, body_truncated, response_code
+		FROM request_logs
+		ORDER BY id
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request logs: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var record Record
+		var headers string
+		if err := rows.Scan(&record.RequestID, &record.ReceivedAt, &record.Method, &record.RequestURI,
+			&record.Proto, &record.RemoteAddr, &record.ContentLength, &headers, &record.Body,
+			&record.BodyTruncated, &record.ResponseCode); err != nil {
+			return nil, fmt.Errorf("failed to scan request log: %w", err)
+		}
+		if err := json.Unmarshal([]byte(headers), &record.Headers); err != nil {
+			return nil, fmt.Errorf("failed to parse request headers: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating request logs: %w", err)
+	}
+	return records, nil
+}
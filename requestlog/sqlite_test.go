@@ -0,0 +1,44 @@
+package requestlog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteSinkWrite(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "requests.db")
+	sink, err := NewSQLiteSink(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteSink failed: %v", err)
+	}
+	defer sink.Close()
+
+	record := Record{
+		RequestID:    "req-1",
+		ReceivedAt:   time.Now(),
+		Method:       "POST",
+		RequestURI:   "/webhook",
+		Headers:      []Header{{Name: "Content-Type", Values: []string{"application/json"}}},
+		Body:         []byte(`{"update_id":1}`),
+		ResponseCode: 200,
+	}
+	if err := sink.Write(record); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	records, err := ReadSQLite(dbPath)
+	if err != nil {
+		t.Fatalf("ReadSQLite failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	got := records[0]
+	if string(got.Body) != `{"update_id":1}` || got.ResponseCode != 200 || got.RequestURI != "/webhook" {
+		t.Errorf("Unexpected stored record %+v", got)
+	}
+	if len(got.Headers) != 1 || got.Headers[0].Name != "Content-Type" {
+		t.Errorf("Expected headers to round-trip, got %+v", got.Headers)
+	}
+}
@@ -0,0 +1,50 @@
+package requestlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// WriterSink pretty-prints records as indented JSON, one after another
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink creates a sink printing to w, typically os.Stdout
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write prints record with 2-space indentation
+func (s *WriterSink) Write(record Record) error {
+	pretty, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal request log: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = fmt.Fprintln(s.w, string(pretty))
+	return err
+}
+
+// Close does nothing; the writer belongs to the caller
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// DiscardSink drops every record
+type DiscardSink struct{}
+
+// Write drops record
+func (DiscardSink) Write(Record) error {
+	return nil
+}
+
+// Close does nothing
+func (DiscardSink) Close() error {
+	return nil
+}
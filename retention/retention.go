@@ -0,0 +1,220 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+)
+
+// Package retention enforces storage quotas for downloaded files.
+// When a user or the whole bot exceeds its quota, the oldest files are removed
+// from the file catalog and the storage backend until usage is back under the limit.
+
+const cleanupBatchSize = 50
+
+// Metrics published under /debug/vars
+var (
+	bytesStored    = expvar.NewInt("storage_bytes_stored")
+	bytesReclaimed = expvar.NewInt("storage_bytes_reclaimed_total")
+	filesReclaimed = expvar.NewInt("storage_files_reclaimed_total")
+)
+
+// TrackStored adds newly downloaded bytes to the stored bytes gauge
+func TrackStored(size int64) {
+	bytesStored.Add(size)
+}
+
+// TrackDeleted removes the bytes of files deleted outside a cleanup run, such
+// as by /delete or /forgetme, from the stored bytes gauge
+func TrackDeleted(size int64) {
+	bytesStored.Add(-size)
+}
+
+// ErrPaused is returned by Run while the cleaner is paused
+var ErrPaused = errors.New("retention cleanup is paused")
+
+// Quotas holds storage limits in bytes. Zero means unlimited.
+type Quotas struct {
+	UserBytes   int64
+	GlobalBytes int64
+}
+
+// Report summarizes one cleanup run
+type Report struct {
+	FilesDeleted   int
+	BytesReclaimed int64
+	BytesStored    int64
+	Failures       int
+}
+
+// Cleaner deletes the oldest files when quotas are exceeded
+type Cleaner struct {
+	catalog session.FileStore
+	storage storage.Backend
+	quotas  func(ctx context.Context) Quotas
+	paused  func() bool
+	mu      sync.Mutex
+}
+
+// NewCleaner creates a new retention cleaner
+func NewCleaner(catalog session.FileStore, fileStorage storage.Backend, quotas Quotas) *Cleaner {
+	return &Cleaner{
+		catalog: catalog,
+		storage: fileStorage,
+		quotas:  func(context.Context) Quotas { return quotas },
+		paused:  func() bool { return false },
+	}
+}
+
+// SetQuotaSource makes every run read its quotas from source instead of the fixed
+// quotas given to NewCleaner, so they can be changed while the bot is running
+func (c *Cleaner) SetQuotaSource(source func(ctx context.Context) Quotas) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.quotas = source
+}
+
+// SetPauseSource makes every run check paused first and delete nothing while
+// it reports true, e.g. while the store is read-only for maintenance
+func (c *Cleaner) SetPauseSource(paused func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = paused
+}
+
+// Start runs the cleaner every interval until ctx is cancelled
+func (c *Cleaner) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			c.runAndLog(ctx, "scheduled")
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (c *Cleaner) runAndLog(ctx context.Context, trigger string) {
+	report, err := c.Run(ctx)
+	if errors.Is(err, ErrPaused) {
+		return
+	}
+	if err != nil {
+		log.Printf("retention cleanup failed: trigger=%s err=%v", trigger, err)
+		return
+	}
+	if report.FilesDeleted > 0 || report.Failures > 0 {
+		log.Printf("retention cleanup: trigger=%s files_deleted=%d bytes_reclaimed=%d bytes_stored=%d failures=%d",
+			trigger, report.FilesDeleted, report.BytesReclaimed, report.BytesStored, report.Failures)
+	}
+}
+
+// Run enforces per-user quotas first and then the global quota. It returns
+// ErrPaused without deleting anything while the cleaner is paused.
+func (c *Cleaner) Run(ctx context.Context) (*Report, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused() {
+		return nil, ErrPaused
+	}
+
+	usages, err := c.catalog.ListFileUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file usage: %w", err)
+	}
+
+	quotas := c.quotas(ctx)
+	report := &Report{}
+	for _, usage := range usages {
+		report.BytesStored += usage.Bytes
+	}
+
+	if quotas.UserBytes > 0 {
+		for _, usage := range usages {
+			excess := usage.Bytes - quotas.UserBytes
+			if excess <= 0 {
+				continue
+			}
+			userID := usage.UserID
+			err := c.reclaim(ctx, excess, report, func(offset int) ([]*session.File, error) {
+				return c.catalog.ListOldestFilesByUser(ctx, userID, offset, cleanupBatchSize)
+			})
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if quotas.GlobalBytes > 0 && report.BytesStored > quotas.GlobalBytes {
+		err := c.reclaim(ctx, report.BytesStored-quotas.GlobalBytes, report, func(offset int) ([]*session.File, error) {
+			return c.catalog.ListOldestFiles(ctx, offset, cleanupBatchSize)
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	bytesStored.Set(report.BytesStored)
+	return report, nil
+}
+
+// reclaim deletes files returned by list, oldest first, until excess bytes are freed.
+// Files that cannot be deleted are skipped by advancing the list offset.
+func (c *Cleaner) reclaim(ctx context.Context, excess int64, report *Report, list func(offset int) ([]*session.File, error)) error {
+	skipped := 0
+	for excess > 0 {
+		files, err := list(skipped)
+		if err != nil {
+			return fmt.Errorf("failed to list oldest files: %w", err)
+		}
+		if len(files) == 0 {
+			return nil
+		}
+
+		for _, file := range files {
+			if excess <= 0 {
+				return nil
+			}
+			if err := c.deleteFile(ctx, file); err != nil {
+				log.Printf("retention delete failed: file_id=%s storage_key=%s err=%v", file.ID, file.StorageKey, err)
+				report.Failures++
+				skipped++
+				continue
+			}
+			excess -= file.Size
+			report.FilesDeleted++
+			report.BytesReclaimed += file.Size
+			report.BytesStored -= file.Size
+			bytesReclaimed.Add(file.Size)
+			filesReclaimed.Add(1)
+		}
+	}
+	return nil
+}
+
+// deleteFile removes the catalog entry and then the stored object. An object
+// that cannot be deleted is left behind as an orphan no file refers to; the
+// catalog is what quotas and users see.
+func (c *Cleaner) deleteFile(ctx context.Context, file *session.File) error {
+	if err := c.catalog.DeleteFile(ctx, file.ID); err != nil && !errors.Is(err, session.ErrFileNotFound) {
+		return fmt.Errorf("delete catalog entry: %w", err)
+	}
+	if err := c.storage.Delete(ctx, file.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("retention left an orphaned object: file_id=%s storage_key=%s err=%v", file.ID, file.StorageKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+	"tg-bot-demo/storage"
+)
+
+func newTestCleaner(t *testing.T, quotas Quotas) (*Cleaner, *session.SQLiteStore, *storage.LocalBackend) {
+	t.Helper()
+
+	dir := t.TempDir()
+	store, err := session.NewSQLiteStore(filepath.Join(dir, "retention.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	backend := storage.NewLocalBackend(filepath.Join(dir, "download"))
+	return NewCleaner(store, backend, quotas), store, backend
+}
+
+// addFile stores size bytes for userID and records them in the catalog with the given age
+func addFile(t *testing.T, store *session.SQLiteStore, backend *storage.LocalBackend, userID int64, name string, size int, age time.Duration) *session.File {
+	t.Helper()
+	ctx := context.Background()
+
+	key := fmt.Sprintf("user_%d/%s", userID, name)
+	if _, err := backend.Put(ctx, key, strings.NewReader(strings.Repeat("x", size)), int64(size)); err != nil {
+		t.Fatalf("Failed to store file: %v", err)
+	}
+
+	file := session.NewFile(userID, "document", name, key, int64(size))
+	file.CreatedAt = time.Now().Add(-age)
+	if err := store.CreateFile(ctx, file); err != nil {
+		t.Fatalf("Failed to record file: %v", err)
+	}
+	return file
+}
+
+func TestCleaner_UserQuota(t *testing.T) {
+	cleaner, store, backend := newTestCleaner(t, Quotas{UserBytes: 250})
+	ctx := context.Background()
+
+	oldest := addFile(t, store, backend, 1, "a", 100, 3*time.Hour)
+	middle := addFile(t, store, backend, 1, "b", 100, 2*time.Hour)
+	newest := addFile(t, store, backend, 1, "c", 100, time.Hour)
+	other := addFile(t, store, backend, 2, "d", 200, 4*time.Hour)
+
+	report, err := cleaner.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.FilesDeleted != 1 || report.BytesReclaimed != 100 {
+		t.Errorf("expected 1 file / 100 bytes reclaimed, got %d / %d", report.FilesDeleted, report.BytesReclaimed)
+	}
+	if report.BytesStored != 400 {
+		t.Errorf("expected 400 bytes stored after cleanup, got %d", report.BytesStored)
+	}
+
+	if _, err := store.GetFile(ctx, oldest.ID); err != session.ErrFileNotFound {
+		t.Errorf("expected oldest file to be deleted, got %v", err)
+	}
+	if _, err := backend.Open(ctx, oldest.StorageKey); err != storage.ErrNotFound {
+		t.Errorf("expected oldest object to be deleted, got %v", err)
+	}
+	for _, kept := range []*session.File{middle, newest, other} {
+		if _, err := store.GetFile(ctx, kept.ID); err != nil {
+			t.Errorf("expected file %s to be kept, got %v", kept.TelegramFileID, err)
+		}
+	}
+}
+
+func TestCleaner_GlobalQuota(t *testing.T) {
+	cleaner, store, backend := newTestCleaner(t, Quotas{GlobalBytes: 150})
+	ctx := context.Background()
+
+	first := addFile(t, store, backend, 1, "a", 100, 3*time.Hour)
+	second := addFile(t, store, backend, 2, "b", 100, 2*time.Hour)
+	third := addFile(t, store, backend, 1, "c", 100, time.Hour)
+
+	report, err := cleaner.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.FilesDeleted != 2 || report.BytesStored != 100 {
+		t.Errorf("expected 2 files deleted and 100 bytes stored, got %d / %d", report.FilesDeleted, report.BytesStored)
+	}
+	for _, deleted := range []*session.File{first, second} {
+		if _, err := store.GetFile(ctx, deleted.ID); err != session.ErrFileNotFound {
+			t.Errorf("expected file %s to be deleted, got %v", deleted.TelegramFileID, err)
+		}
+	}
+	if _, err := store.GetFile(ctx, third.ID); err != nil {
+		t.Errorf("expected newest file to be kept, got %v", err)
+	}
+}
+
+func TestCleaner_NoQuotas(t *testing.T) {
+	cleaner, store, backend := newTestCleaner(t, Quotas{})
+
+	addFile(t, store, backend, 1, "a", 100, time.Hour)
+
+	report, err := cleaner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.FilesDeleted != 0 || report.BytesStored != 100 {
+		t.Errorf("expected nothing deleted and 100 bytes stored, got %+v", report)
+	}
+}
+
+func TestCleaner_MissingObjectStillRemovesCatalogEntry(t *testing.T) {
+	cleaner, store, backend := newTestCleaner(t, Quotas{UserBytes: 50})
+	ctx := context.Background()
+
+	file := addFile(t, store, backend, 1, "a", 100, time.Hour)
+	if err := backend.Delete(ctx, file.StorageKey); err != nil {
+		t.Fatalf("Failed to delete object: %v", err)
+	}
+
+	report, err := cleaner.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.FilesDeleted != 1 || report.Failures != 0 {
+		t.Errorf("expected catalog entry to be removed without failures, got %+v", report)
+	}
+}
+
+// failingDeletes is a storage backend refusing to delete objects
+type failingDeletes struct {
+	*storage.LocalBackend
+}
+
+func (failingDeletes) Delete(context.Context, string) error {
+	return errors.New("permission denied")
+}
+
+func TestCleaner_UndeletableObjectIsOrphaned(t *testing.T) {
+	_, store, backend := newTestCleaner(t, Quotas{})
+	cleaner := NewCleaner(store, failingDeletes{backend}, Quotas{UserBytes: 50})
+	ctx := context.Background()
+
+	file := addFile(t, store, backend, 1, "a", 100, time.Hour)
+
+	report, err := cleaner.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.FilesDeleted != 1 || report.Failures != 0 || report.BytesStored != 0 {
+		t.Errorf("expected the file reclaimed despite the object left behind, got %+v", report)
+	}
+	if _, err := store.GetFile(ctx, file.ID); err != session.ErrFileNotFound {
+		t.Errorf("expected the catalog entry deleted, got %v", err)
+	}
+}
+
+func TestCleaner_PausedInReadOnlyMode(t *testing.T) {
+	cleaner, store, backend := newTestCleaner(t, Quotas{UserBytes: 50, GlobalBytes: 50})
+	cleaner.SetPauseSource(store.ReadOnly)
+	ctx := context.Background()
+
+	file := addFile(t, store, backend, 1, "a", 100, time.Hour)
+	store.SetReadOnly(true)
+
+	if _, err := cleaner.Run(ctx); !errors.Is(err, ErrPaused) {
+		t.Fatalf("expected the cleanup paused, got %v", err)
+	}
+	cleaner.runAndLog(ctx, "scheduled")
+	if _, err := store.GetFile(ctx, file.ID); err != nil {
+		t.Errorf("expected the catalog entry kept, got %v", err)
+	}
+	if _, err := backend.Open(ctx, file.StorageKey); err != nil {
+		t.Errorf("expected the object kept, got %v", err)
+	}
+
+	// Leaving maintenance mode resumes cleanups
+	store.SetReadOnly(false)
+	if report, err := cleaner.Run(ctx); err != nil || report.FilesDeleted != 1 {
+		t.Errorf("expected the file reclaimed after maintenance, got %+v err=%v", report, err)
+	}
+}
+
+func TestCleaner_QuotaSource(t *testing.T) {
+	cleaner, store, backend := newTestCleaner(t, Quotas{})
+	ctx := context.Background()
+
+	addFile(t, store, backend, 1, "a", 100, 2*time.Hour)
+	addFile(t, store, backend, 1, "b", 100, time.Hour)
+
+	report, err := cleaner.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.FilesDeleted != 0 {
+		t.Fatalf("expected no deletions without quotas, got %d", report.FilesDeleted)
+	}
+
+	cleaner.SetQuotaSource(func(context.Context) Quotas { return Quotas{UserBytes: 150} })
+	report, err = cleaner.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.FilesDeleted != 1 || report.BytesReclaimed != 100 {
+		t.Errorf("expected the oldest file reclaimed, got %+v", report)
+	}
+}
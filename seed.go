@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"tg-bot-demo/session"
+
+	"github.com/google/uuid"
+)
+
+// Demo content the seed subcommand builds sessions and messages from. Titles
+// share words so /search and the dashboard filter find several sessions.
+var (
+	seedTopics = []string{
+		"Trip to Lisbon", "Weekly groceries", "Birthday party ideas", "Learning Go",
+		"Garden planning", "Job interview prep", "Book club notes", "Home office setup",
+		"Marathon training", "Recipe for ramen", "Budget review", "Photo editing tips",
+	}
+	seedUserLines = []string{
+		"Can you help me with %s?", "What should I do first about %s?", "Any tips on %s?",
+		"Let's continue with %s.", "Summarize what we said about %s.", "I changed my mind about %s.",
+	}
+	seedAssistantLines = []string{
+		"Sure, here is a plan for %s.", "Start small with %s and review it in a week.",
+		"Here are three ideas for %s.", "Noted, I updated the list for %s.",
+	}
+)
+
+// seedOptions controls how much demo data the seed subcommand writes
+type seedOptions struct {
+	Users              int
+	SessionsPerUser    int
+	MessagesPerSession int
+	FirstUserID        int64
+	Seed               uint64
+	Until              time.Time // timestamps spread over the 30 days before this
+}
+
+// seedReport counts what seedStore wrote
+type seedReport struct {
+	Users, Sessions, Messages int
+}
+
+// runSeed implements the seed subcommand: it fills the store with demo users,
+// sessions and messages. The same -seed always produces the same IDs and content.
+func runSeed(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	users := flags.Int("users", 10, "Number of users to create")
+	sessions := flags.Int("sessions", 15, "Sessions per user")
+	messages := flags.Int("messages", 8, "Messages per session")
+	firstUserID := flags.Int64("first-user-id", 900000001, "User ID of the first demo user; the others follow")
+	seed := flags.Uint64("seed", 1, "Random seed; the same seed produces the same data")
+	store, err := loadStoreCommand(flags, registerConfigFlags(flags), args)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *users < 1 || *sessions < 1 || *messages < 1 {
+		return errors.New("usage: seed [-users N] [-sessions N] [-messages N] [-seed N] [-config file] [flags]; counts must be at least 1")
+	}
+
+	report, err := seedStore(context.Background(), store, seedOptions{
+		Users:              *users,
+		SessionsPerUser:    *sessions,
+		MessagesPerSession: *messages,
+		FirstUserID:        *firstUserID,
+		Seed:               *seed,
+		Until:              time.Now().UTC().Truncate(24 * time.Hour),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "seeded %d users, %d sessions and %d messages (user IDs %d-%d)\n",
+		report.Users, report.Sessions, report.Messages, *firstUserID, *firstUserID+int64(*users)-1)
+	return nil
+}
+
+// seedStore writes the demo data, one transaction per user. Each user's newest
+// session becomes the active one.
+func seedStore(ctx context.Context, store *session.SQLiteStore, options seedOptions) (*seedReport, error) {
+	var key [32]byte
+	for i := range 8 {
+		key[i] = byte(options.Seed >> (8 * i))
+	}
+	source := rand.NewChaCha8(key)
+	random := rand.New(source)
+
+	report := &seedReport{}
+	span := 30 * 24 * time.Hour
+	for u := range options.Users {
+		userID := options.FirstUserID + int64(u)
+
+		// Sessions start at random points of the span, oldest first
+		starts := make([]time.Duration, options.SessionsPerUser)
+		for i := range starts {
+			starts[i] = time.Duration(random.Int64N(int64(span)))
+		}
+		slices.Sort(starts)
+
+		err := store.WithTx(ctx, func(tx session.Store) error {
+			messageStore, ok := tx.(session.MessageStore)
+			if !ok {
+				return errors.New("store does not support messages")
+			}
+
+			var newest uuid.UUID
+			for _, start := range starts {
+				topic := seedTopics[random.IntN(len(seedTopics))]
+				at := options.Until.Add(start - span)
+
+				sessionID, err := uuid.NewRandomFromReader(source)
+				if err != nil {
+					return err
+				}
+				sess := session.NewSession(userID, topic)
+				sess.ID, sess.CreatedAt, sess.UpdatedAt = sessionID, at, at
+
+				messages := make([]*session.Message, options.MessagesPerSession)
+				for i := range messages {
+					role, lines := session.RoleUser, seedUserLines
+					if i%2 == 1 {
+						role, lines = session.RoleAssistant, seedAssistantLines
+					}
+					messageID, err := uuid.NewRandomFromReader(source)
+					if err != nil {
+						return err
+					}
+					message := session.NewMessage(sess.ID, userID, role,
+						fmt.Sprintf(lines[random.IntN(len(lines))], strings.ToLower(topic)))
+					message.ID = messageID
+					at = at.Add(time.Duration(1+random.IntN(30)) * time.Minute)
+					message.CreatedAt = at
+					messages[i] = message
+				}
+				sess.LastMessage = messages[len(messages)-1].Content
+
+				if err := tx.Create(ctx, sess); err != nil {
+					return err
+				}
+				if err := messageStore.AppendMessages(ctx, sess.ID, messages); err != nil {
+					return err
+				}
+				newest = sess.ID
+				report.Sessions++
+				report.Messages += len(messages)
+			}
+			return tx.SetActiveSession(ctx, userID, newest)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to seed user %d: %w", userID, err)
+		}
+		report.Users++
+	}
+	return report, nil
+}
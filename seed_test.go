@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+)
+
+func TestSeedStore_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	options := seedOptions{
+		Users:              3,
+		SessionsPerUser:    4,
+		MessagesPerSession: 5,
+		FirstUserID:        100,
+		Seed:               7,
+		Until:              time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	seeded := func(seed uint64) []*session.Session {
+		store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "seed.db"))
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		defer store.Close()
+
+		options.Seed = seed
+		report, err := seedStore(ctx, store, options)
+		if err != nil {
+			t.Fatalf("seedStore failed: %v", err)
+		}
+		if report.Users != 3 || report.Sessions != 12 || report.Messages != 60 {
+			t.Errorf("unexpected report %+v", report)
+		}
+
+		sessions, err := store.ListByUser(ctx, 101, 0, 10)
+		if err != nil {
+			t.Fatalf("ListByUser failed: %v", err)
+		}
+		if len(sessions) != 4 {
+			t.Fatalf("expected 4 sessions, got %d", len(sessions))
+		}
+		active, err := store.GetActiveSession(ctx, 101)
+		if err != nil || active.ID != sessions[0].ID {
+			t.Errorf("expected the newest session to be active, got %v err=%v", active, err)
+		}
+		if count, _ := store.CountMessages(ctx, sessions[0].ID); count != 5 {
+			t.Errorf("expected 5 messages, got %d", count)
+		}
+		return sessions
+	}
+
+	first, second, other := seeded(7), seeded(7), seeded(8)
+	for i := range first {
+		if first[i].ID != second[i].ID || first[i].Title != second[i].Title || !first[i].UpdatedAt.Equal(second[i].UpdatedAt) {
+			t.Errorf("expected the same seed to produce the same session, got %+v and %+v", first[i], second[i])
+		}
+	}
+	if first[0].ID == other[0].ID {
+		t.Error("expected another seed to produce other sessions")
+	}
+}
+
+func TestRunSeed(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "demo", "sessions.db")
+
+	var out bytes.Buffer
+	if err := runSeed([]string{"-db", dbPath, "-users", "2", "-sessions", "3", "-messages", "2", "-first-user-id", "500"}, &out); err != nil {
+		t.Fatalf("runSeed failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "seeded 2 users, 6 sessions and 12 messages (user IDs 500-501)") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	if err := runSeed([]string{"-db", dbPath, "-users", "0"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for zero users")
+	}
+}
@@ -0,0 +1,29 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PendingSession is a session with user messages the assistant has not replied to
+type PendingSession struct {
+	*Session
+	// WaitingSince is when the oldest unanswered user message was sent
+	WaitingSince time.Time `json:"waiting_since"`
+}
+
+// ActivityStore defines cross-user queries used by the admin dashboard
+type ActivityStore interface {
+	// ListRecentSessions returns sessions of all users whose title or last message
+	// contains query, most recently updated first. An empty query matches every session.
+	ListRecentSessions(ctx context.Context, query string, offset, limit int) ([]*Session, error)
+
+	// ListPendingSessions returns unarchived sessions with unanswered user messages
+	// sent before waitingBefore, the longest waiting first
+	ListPendingSessions(ctx context.Context, waitingBefore time.Time, offset, limit int) ([]*PendingSession, error)
+
+	// ListSessionMessages returns a page of a session's messages, oldest first
+	ListSessionMessages(ctx context.Context, sessionID uuid.UUID, offset, limit int) ([]*Message, error)
+}
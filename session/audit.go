@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Actions recorded in the audit log. /admin subcommands are recorded as
+// AuditAdminPrefix followed by the subcommand, e.g. "admin.purge".
+const (
+	AuditSessionDelete = "session.delete" // a user deleted sessions
+	AuditUserPurge     = "user.purge"     // a user deleted all of their data with /forgetme
+	AuditFileFlood     = "file.flood"     // a user sent too many files and their downloads were paused
+	AuditAdminPrefix   = "admin."
+)
+
+// AuditEntry records a privileged or destructive action. The payload itself is
+// not kept, only its hash, so the log holds no message text yet shows whether
+// two entries acted on the same input.
+type AuditEntry struct {
+	ID          int64     `json:"id"`
+	ActorID     int64     `json:"actor_id"` // the user who acted
+	Action      string    `json:"action"`
+	Target      string    `json:"target"` // what was acted on, e.g. a session or user ID
+	PayloadHash string    `json:"payload_hash"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewAuditEntry creates an audit entry for an action taken now, hashing payload
+func NewAuditEntry(actorID int64, action, target, payload string) *AuditEntry {
+	return &AuditEntry{
+		ActorID:     actorID,
+		Action:      action,
+		Target:      target,
+		PayloadHash: HashAuditPayload(payload),
+		CreatedAt:   time.Now(),
+	}
+}
+
+// HashAuditPayload returns the hex SHA-256 of payload, or "" for an empty payload
+func HashAuditPayload(payload string) string {
+	if payload == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditStore defines the interface for the audit log. Entries are never
+// changed or deleted, not even when the actor's data is purged.
+type AuditStore interface {
+	// RecordAudit appends an entry to the audit log and sets its ID
+	RecordAudit(ctx context.Context, entry *AuditEntry) error
+
+	// ListAudit returns audit entries, newest first. A non-empty action lists
+	// only entries of that action; a non-zero actorID only that user's entries.
+	ListAudit(ctx context.Context, action string, actorID int64, offset, limit int) ([]*AuditEntry, error)
+}
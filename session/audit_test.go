@@ -0,0 +1,52 @@
+package session
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSQLiteStore_Audit(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	entries := []*AuditEntry{
+		NewAuditEntry(1, AuditSessionDelete, "s-1", "/delete"),
+		NewAuditEntry(9, AuditAdminPrefix+"purge", "1", "/admin purge 1"),
+		NewAuditEntry(1, AuditUserPurge, "1", ""),
+	}
+	for _, entry := range entries {
+		if err := store.RecordAudit(ctx, entry); err != nil {
+			t.Fatalf("RecordAudit failed: %v", err)
+		}
+		if entry.ID == 0 {
+			t.Fatal("expected RecordAudit to set the entry ID")
+		}
+	}
+
+	all, err := store.ListAudit(ctx, "", 0, 0, 10)
+	if err != nil {
+		t.Fatalf("ListAudit failed: %v", err)
+	}
+	if len(all) != 3 || all[0].Action != AuditUserPurge || all[2].Action != AuditSessionDelete {
+		t.Fatalf("expected all entries newest first, got %+v", all)
+	}
+	if all[2].PayloadHash != HashAuditPayload("/delete") || all[0].PayloadHash != "" {
+		t.Errorf("unexpected payload hashes: %q, %q", all[2].PayloadHash, all[0].PayloadHash)
+	}
+
+	byAction, err := store.ListAudit(ctx, AuditAdminPrefix+"purge", 0, 0, 10)
+	if err != nil {
+		t.Fatalf("ListAudit failed: %v", err)
+	}
+	if len(byAction) != 1 || byAction[0].ActorID != 9 || byAction[0].Target != "1" {
+		t.Errorf("expected only the admin purge, got %+v", byAction)
+	}
+
+	byActor, err := store.ListAudit(ctx, "", 1, 1, 10)
+	if err != nil {
+		t.Fatalf("ListAudit failed: %v", err)
+	}
+	if len(byActor) != 1 || byActor[0].Action != AuditSessionDelete {
+		t.Errorf("expected the second page of user 1's entries, got %+v", byActor)
+	}
+}
@@ -0,0 +1,10 @@
+package session
+
+import "context"
+
+// BackupStore defines the interface for taking database snapshots
+type BackupStore interface {
+	// BackupTo writes a consistent copy of the database to a new file at path
+	// while the store stays in use
+	BackupTo(ctx context.Context, path string) error
+}
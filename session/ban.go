@@ -0,0 +1,36 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MaxBanReasonLength is the longest reason kept for a ban, in characters
+const MaxBanReasonLength = 200
+
+// Ban keeps a user from using the bot
+type Ban struct {
+	UserID   int64     `json:"user_id"`
+	Reason   string    `json:"reason,omitempty"`
+	BannedBy int64     `json:"banned_by"` // the administrator who banned the user
+	BannedAt time.Time `json:"banned_at"`
+}
+
+// ErrBanNotFound is returned when a user is not banned
+var ErrBanNotFound = fmt.Errorf("ban not found")
+
+// BanStore defines the interface for the ban list
+type BanStore interface {
+	// BanUser adds a ban, replacing the reason of an existing one
+	BanUser(ctx context.Context, ban *Ban) error
+
+	// UnbanUser lifts the ban of userID. It returns ErrBanNotFound when the user is not banned.
+	UnbanUser(ctx context.Context, userID int64) error
+
+	// GetBan returns the ban of userID, or ErrBanNotFound
+	GetBan(ctx context.Context, userID int64) (*Ban, error)
+
+	// ListBans returns bans, the most recent first
+	ListBans(ctx context.Context, offset, limit int) ([]*Ban, error)
+}
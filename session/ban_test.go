@@ -0,0 +1,53 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_Bans(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if _, err := store.GetBan(ctx, 1); err != ErrBanNotFound {
+		t.Fatalf("expected ErrBanNotFound, got %v", err)
+	}
+
+	now := time.Now()
+	if err := store.BanUser(ctx, &Ban{UserID: 1, Reason: "spam", BannedBy: 9, BannedAt: now.Add(-time.Hour)}); err != nil {
+		t.Fatalf("BanUser failed: %v", err)
+	}
+	if err := store.BanUser(ctx, &Ban{UserID: 2, BannedBy: 9, BannedAt: now}); err != nil {
+		t.Fatalf("BanUser failed: %v", err)
+	}
+	if err := store.BanUser(ctx, &Ban{UserID: 1, Reason: "abuse", BannedBy: 8, BannedAt: now.Add(-time.Minute)}); err != nil {
+		t.Fatalf("BanUser of a banned user failed: %v", err)
+	}
+
+	ban, err := store.GetBan(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetBan failed: %v", err)
+	}
+	if ban.Reason != "abuse" || ban.BannedBy != 8 {
+		t.Errorf("expected the ban replaced, got %+v", ban)
+	}
+
+	bans, err := store.ListBans(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ListBans failed: %v", err)
+	}
+	if len(bans) != 2 || bans[0].UserID != 2 || bans[1].UserID != 1 {
+		t.Errorf("expected the most recent ban first, got %+v", bans)
+	}
+
+	if err := store.UnbanUser(ctx, 1); err != nil {
+		t.Fatalf("UnbanUser failed: %v", err)
+	}
+	if err := store.UnbanUser(ctx, 1); err != ErrBanNotFound {
+		t.Errorf("expected ErrBanNotFound for a user no longer banned, got %v", err)
+	}
+	if _, err := store.GetBan(ctx, 1); err != ErrBanNotFound {
+		t.Errorf("expected the ban lifted, got %v", err)
+	}
+}
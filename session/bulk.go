@@ -0,0 +1,24 @@
+package session
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// BulkStore defines the interface for operations on several sessions at once.
+// Each call runs in one transaction: either every session is changed or none is.
+type BulkStore interface {
+	// ArchiveSessions hides sessions of userID from the session list. Chats and
+	// topics bound to them are unbound. It returns the number of sessions archived;
+	// sessions that no longer exist are skipped.
+	ArchiveSessions(ctx context.Context, userID int64, ids []uuid.UUID) (int, error)
+
+	// DeleteSessions deletes sessions of userID with their messages. Their files
+	// are kept but unlinked, like /delete does. It returns the number of sessions
+	// deleted; sessions that no longer exist are skipped.
+	DeleteSessions(ctx context.Context, userID int64, ids []uuid.UUID) (int, error)
+
+	// ListArchivedByUser returns the archived sessions of a user with pagination
+	ListArchivedByUser(ctx context.Context, userID int64, offset, limit int) ([]*Session, error)
+}
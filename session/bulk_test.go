@@ -0,0 +1,114 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteStore_ArchiveSessions(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	manager := NewManager(store)
+
+	kept, err := manager.CreateSession(ctx, 1, "kept")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	first, err := manager.CreateSession(ctx, 1, "first")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	second, err := manager.CreateSession(ctx, 1, "second")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	count, err := store.ArchiveSessions(ctx, 1, []uuid.UUID{first.ID, second.ID, uuid.New()})
+	if err != nil {
+		t.Fatalf("ArchiveSessions failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 archived sessions, got %d", count)
+	}
+
+	sessions, err := store.ListByUser(ctx, 1, 0, 10)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != kept.ID {
+		t.Errorf("expected only the kept session to be listed, got %d sessions", len(sessions))
+	}
+	if total, err := store.CountByUser(ctx, 1); err != nil || total != 1 {
+		t.Errorf("expected 1 session counted, got %d, %v", total, err)
+	}
+	archived, err := store.ListArchivedByUser(ctx, 1, 0, 10)
+	if err != nil {
+		t.Fatalf("ListArchivedByUser failed: %v", err)
+	}
+	if len(archived) != 2 {
+		t.Errorf("expected 2 archived sessions listed, got %d", len(archived))
+	}
+
+	// The second session was active; archiving it leaves no active session
+	if _, err := store.GetActiveSession(ctx, 1); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected no active session, got %v", err)
+	}
+
+	// Opening an archived session restores it
+	if _, err := manager.SwitchSession(ctx, 1, first.ID); err != nil {
+		t.Fatalf("SwitchSession failed: %v", err)
+	}
+	if total, err := store.CountByUser(ctx, 1); err != nil || total != 2 {
+		t.Errorf("expected the opened session to be listed again, got %d, %v", total, err)
+	}
+}
+
+func TestSQLiteStore_DeleteSessions(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	manager := NewManager(store)
+
+	own, err := manager.CreateSession(ctx, 1, "own")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	other, err := manager.CreateSession(ctx, 2, "other")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	file := NewFile(1, "document", "a.txt", "user_1/a.txt", 4)
+	file.SessionID = own.ID
+	if err := store.CreateFile(ctx, file); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	// A session of another user fails the whole batch
+	if _, err := store.DeleteSessions(ctx, 1, []uuid.UUID{own.ID, other.ID}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	if _, err := store.Get(ctx, own.ID); err != nil {
+		t.Fatalf("expected the batch to roll back, got %v", err)
+	}
+
+	count, err := store.DeleteSessions(ctx, 1, []uuid.UUID{own.ID})
+	if err != nil {
+		t.Fatalf("DeleteSessions failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 deleted session, got %d", count)
+	}
+	if _, err := store.Get(ctx, own.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected the session to be deleted, got %v", err)
+	}
+
+	kept, err := store.GetFile(ctx, file.ID)
+	if err != nil {
+		t.Fatalf("GetFile failed: %v", err)
+	}
+	if kept.SessionID != uuid.Nil {
+		t.Errorf("expected the file to be unlinked, got session %v", kept.SessionID)
+	}
+}
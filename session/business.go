@@ -0,0 +1,69 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BusinessConnection is a Telegram Business account that connected the bot as
+// its chat assistant. Customers write to the account; the bot answers on its behalf.
+type BusinessConnection struct {
+	ID         string    `json:"id"`
+	UserID     int64     `json:"user_id"`      // the business account owner
+	UserChatID int64     `json:"user_chat_id"` // private chat of the owner with the bot
+	CanReply   bool      `json:"can_reply"`    // the bot may send messages in the account's chats
+	IsEnabled  bool      `json:"is_enabled"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// BusinessChat identifies one customer chat of a business connection
+type BusinessChat struct {
+	ConnectionID string `json:"connection_id"`
+	ChatID       int64  `json:"chat_id"`
+}
+
+// ErrBusinessConnectionNotFound is returned when no business connection matches an ID
+var ErrBusinessConnectionNotFound = fmt.Errorf("business connection not found")
+
+// BusinessConnectionStore defines the interface for business connection persistence
+type BusinessConnectionStore interface {
+	// SaveBusinessConnection creates or replaces a business connection
+	SaveBusinessConnection(ctx context.Context, connection *BusinessConnection) error
+
+	// GetBusinessConnection returns the business connection with id
+	GetBusinessConnection(ctx context.Context, id string) (*BusinessConnection, error)
+}
+
+// InBusinessChat returns a manager whose active session is bound to one chat of
+// a business connection, so every customer conversation keeps its own session.
+// Sessions belong to the business account owner.
+func (m *Manager) InBusinessChat(chat BusinessChat) *Manager {
+	return m.withStore(&businessBindingStore{Store: m.store, chat: chat})
+}
+
+// businessBindingStore redirects active session bindings to a business chat
+type businessBindingStore struct {
+	Store
+	chat BusinessChat
+}
+
+func (s *businessBindingStore) GetActiveSession(ctx context.Context, userID int64) (*Session, error) {
+	return s.Store.GetBusinessChatSession(ctx, userID, s.chat)
+}
+
+func (s *businessBindingStore) SetActiveSession(ctx context.Context, userID int64, sessionID uuid.UUID) error {
+	return s.Store.SetBusinessChatSession(ctx, userID, s.chat, sessionID)
+}
+
+func (s *businessBindingStore) ClearActiveSession(ctx context.Context, userID int64) error {
+	return s.Store.ClearBusinessChatSession(ctx, userID, s.chat)
+}
+
+func (s *businessBindingStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return s.Store.WithTx(ctx, func(tx Store) error {
+		return fn(&businessBindingStore{Store: tx, chat: s.chat})
+	})
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_BusinessConnections(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if _, err := store.GetBusinessConnection(ctx, "conn-1"); !errors.Is(err, ErrBusinessConnectionNotFound) {
+		t.Fatalf("expected ErrBusinessConnectionNotFound, got %v", err)
+	}
+
+	connection := &BusinessConnection{ID: "conn-1", UserID: 42, UserChatID: 42, CanReply: true, IsEnabled: true, UpdatedAt: time.Now()}
+	if err := store.SaveBusinessConnection(ctx, connection); err != nil {
+		t.Fatalf("SaveBusinessConnection failed: %v", err)
+	}
+	connection.IsEnabled = false
+	if err := store.SaveBusinessConnection(ctx, connection); err != nil {
+		t.Fatalf("SaveBusinessConnection (update) failed: %v", err)
+	}
+
+	got, err := store.GetBusinessConnection(ctx, "conn-1")
+	if err != nil {
+		t.Fatalf("GetBusinessConnection failed: %v", err)
+	}
+	if got.UserID != 42 || !got.CanReply || got.IsEnabled {
+		t.Errorf("unexpected connection %+v", got)
+	}
+}
+
+func TestManager_InBusinessChat(t *testing.T) {
+	store := newTestStore(t)
+	mgr := NewManager(store)
+	ctx := context.Background()
+	ownerID := int64(42)
+
+	private, err := mgr.CreateSession(ctx, ownerID, "private chat")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	alice := mgr.InBusinessChat(BusinessChat{ConnectionID: "conn-1", ChatID: 1001})
+	bob := mgr.InBusinessChat(BusinessChat{ConnectionID: "conn-1", ChatID: 1002})
+
+	aliceSession, err := alice.GetOrCreateActiveSession(ctx, ownerID, "Do you ship to Lisbon?")
+	if err != nil {
+		t.Fatalf("GetOrCreateActiveSession (alice) failed: %v", err)
+	}
+	bobSession, err := bob.GetOrCreateActiveSession(ctx, ownerID, "Opening hours?")
+	if err != nil {
+		t.Fatalf("GetOrCreateActiveSession (bob) failed: %v", err)
+	}
+	if aliceSession.ID == bobSession.ID || aliceSession.ID == private.ID {
+		t.Fatal("expected every business chat to get its own session")
+	}
+	if aliceSession.UserID != ownerID {
+		t.Errorf("expected business sessions to belong to the owner, got user %d", aliceSession.UserID)
+	}
+
+	again, err := alice.GetOrCreateActiveSession(ctx, ownerID, "ignored")
+	if err != nil || again.ID != aliceSession.ID {
+		t.Errorf("expected alice's session %s, got %v (err: %v)", aliceSession.ID, again, err)
+	}
+	if active, err := mgr.GetActiveSession(ctx, ownerID); err != nil || active.ID != private.ID {
+		t.Errorf("expected the private binding to stay on %s, got %v (err: %v)", private.ID, active, err)
+	}
+
+	if _, closed, err := alice.CloseActiveSession(ctx, ownerID); err != nil || !closed {
+		t.Fatalf("CloseActiveSession failed: closed=%v err=%v", closed, err)
+	}
+	if _, err := alice.GetActiveSession(ctx, ownerID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected alice's chat to have no session, got %v", err)
+	}
+	if got, err := bob.GetActiveSession(ctx, ownerID); err != nil || got.ID != bobSession.ID {
+		t.Errorf("expected bob's session to be unaffected, got %v (err: %v)", got, err)
+	}
+
+	// Deleting the session removes its binding
+	if err := store.Delete(ctx, bobSession.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := bob.GetActiveSession(ctx, ownerID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected the binding to be removed with the session, got %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrCallbackTokenNotFound is returned when a callback token is unknown or expired
+var ErrCallbackTokenNotFound = fmt.Errorf("callback token not found")
+
+// CallbackTokenStore keeps callback data that does not fit into Telegram's
+// 64-byte callback_data limit, under short tokens sent in its place
+type CallbackTokenStore interface {
+	// CreateCallbackToken stores data under token until expiresAt. Tokens that
+	// expired before createdAt are removed.
+	CreateCallbackToken(ctx context.Context, token, data string, createdAt, expiresAt time.Time) error
+
+	// GetCallbackToken returns the data stored under token, or
+	// ErrCallbackTokenNotFound when the token is unknown or expired at now
+	GetCallbackToken(ctx context.Context, token string, now time.Time) (string, error)
+}
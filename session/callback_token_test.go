@@ -0,0 +1,41 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_CallbackTokens(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := store.CreateCallbackToken(ctx, "old", "sel_t_old", now.Add(-2*time.Hour), now.Add(-time.Hour)); err != nil {
+		t.Fatalf("CreateCallbackToken failed: %v", err)
+	}
+	if err := store.CreateCallbackToken(ctx, "fresh", "sel_t_fresh", now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("CreateCallbackToken failed: %v", err)
+	}
+
+	data, err := store.GetCallbackToken(ctx, "fresh", now)
+	if err != nil {
+		t.Fatalf("GetCallbackToken failed: %v", err)
+	}
+	if data != "sel_t_fresh" {
+		t.Errorf("expected sel_t_fresh, got %q", data)
+	}
+	if _, err := store.GetCallbackToken(ctx, "fresh", now.Add(2*time.Hour)); !errors.Is(err, ErrCallbackTokenNotFound) {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+
+	// Creating the fresh token removed the expired one
+	var count int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM callback_tokens`).Scan(&count); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 stored token, got %d", count)
+	}
+}
@@ -0,0 +1,27 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Channel is a Telegram channel whose posts are archived. Its sessions are owned
+// by the channel's chat ID, which never collides with a user ID.
+type Channel struct {
+	ChatID      int64     `json:"chat_id"`
+	Title       string    `json:"title"`
+	Username    string    `json:"username,omitempty"`
+	Posts       int       `json:"posts"`
+	FirstPostAt time.Time `json:"first_post_at"`
+	LastPostAt  time.Time `json:"last_post_at"`
+}
+
+// ChannelStore defines the interface for tracked channel persistence
+type ChannelStore interface {
+	// TrackChannelPost records a post in channel, creating the channel on its first
+	// post and updating its title and username from later ones
+	TrackChannelPost(ctx context.Context, channel *Channel, postedAt time.Time) error
+
+	// ListChannels returns tracked channels, most recently active first
+	ListChannels(ctx context.Context) ([]*Channel, error)
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_TrackChannelPost(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	first := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	if err := store.TrackChannelPost(ctx, &Channel{ChatID: -1001, Title: "Old title"}, first); err != nil {
+		t.Fatalf("TrackChannelPost failed: %v", err)
+	}
+	if err := store.TrackChannelPost(ctx, &Channel{ChatID: -1002, Title: "Quiet"}, first.Add(time.Hour)); err != nil {
+		t.Fatalf("TrackChannelPost failed: %v", err)
+	}
+	if err := store.TrackChannelPost(ctx, &Channel{ChatID: -1001, Title: "News", Username: "news"}, first.Add(2*time.Hour)); err != nil {
+		t.Fatalf("TrackChannelPost failed: %v", err)
+	}
+
+	channels, err := store.ListChannels(ctx)
+	if err != nil {
+		t.Fatalf("ListChannels failed: %v", err)
+	}
+	if len(channels) != 2 {
+		t.Fatalf("Expected 2 channels, got %d", len(channels))
+	}
+
+	news := channels[0]
+	if news.ChatID != -1001 || news.Title != "News" || news.Username != "news" || news.Posts != 2 {
+		t.Errorf("Expected the updated channel first, got %+v", news)
+	}
+	if !news.FirstPostAt.Equal(first) || !news.LastPostAt.Equal(first.Add(2*time.Hour)) {
+		t.Errorf("Unexpected post times %v - %v", news.FirstPostAt, news.LastPostAt)
+	}
+	if channels[1].ChatID != -1002 {
+		t.Errorf("Expected the quiet channel last, got %d", channels[1].ChatID)
+	}
+}
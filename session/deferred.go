@@ -0,0 +1,31 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// DeferredMessage is a proactive message held back until a user's quiet hours end
+type DeferredMessage struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	ChatID    int64  `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+	// ReplyMarkup is the JSON-encoded inline keyboard sent with the message, if any
+	ReplyMarkup string    `json:"reply_markup,omitempty"`
+	DeliverAt   time.Time `json:"deliver_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DeferredMessageStore defines the interface for deferred message persistence
+type DeferredMessageStore interface {
+	// DeferMessage queues a message and sets its ID
+	DeferMessage(ctx context.Context, message *DeferredMessage) error
+
+	// ListDueMessages returns up to limit queued messages to deliver at or before now, oldest first
+	ListDueMessages(ctx context.Context, now time.Time, limit int) ([]*DeferredMessage, error)
+
+	// DeleteDeferredMessage removes a delivered message from the queue
+	DeleteDeferredMessage(ctx context.Context, id int64) error
+}
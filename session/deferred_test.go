@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_DeferredMessages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := &DeferredMessage{UserID: 1, ChatID: 1, Text: "later", DeliverAt: now.Add(time.Hour), CreatedAt: now}
+	due := &DeferredMessage{UserID: 2, ChatID: 2, Text: "due", ParseMode: "HTML", DeliverAt: now.Add(-time.Minute), CreatedAt: now}
+	for _, message := range []*DeferredMessage{later, due} {
+		if err := store.DeferMessage(ctx, message); err != nil {
+			t.Fatalf("DeferMessage failed: %v", err)
+		}
+	}
+
+	messages, err := store.ListDueMessages(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("ListDueMessages failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != due.ID || messages[0].ParseMode != "HTML" {
+		t.Fatalf("Expected only the due message, got %+v", messages)
+	}
+
+	if err := store.DeleteDeferredMessage(ctx, due.ID); err != nil {
+		t.Fatalf("DeleteDeferredMessage failed: %v", err)
+	}
+	messages, err = store.ListDueMessages(ctx, now.Add(2*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListDueMessages failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != later.ID {
+		t.Errorf("Expected the later message once due, got %+v", messages)
+	}
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SentMessage is a message the bot sent, linked to what it answered so that
+// it can be edited or deleted later, e.g. to correct an assistant reply
+type SentMessage struct {
+	ChatID            int64     `json:"chat_id"`
+	TelegramMessageID int       `json:"telegram_message_id"`
+	UserID            int64     `json:"user_id"`              // user the reply went to
+	SessionID         uuid.UUID `json:"session_id,omitempty"` // uuid.Nil when the reply belongs to no session
+	MessageID         uuid.UUID `json:"message_id,omitempty"` // stored message answered, uuid.Nil if none
+	// ReplyToMessageID is the Telegram ID of the message answered, 0 if none
+	ReplyToMessageID int       `json:"reply_to_message_id,omitempty"`
+	SentAt           time.Time `json:"sent_at"`
+}
+
+// ErrSentMessageNotFound is returned when a sent message is not tracked
+var ErrSentMessageNotFound = fmt.Errorf("sent message not found")
+
+// DeliveryStore defines the interface for tracking sent messages
+type DeliveryStore interface {
+	// RecordSentMessage tracks a sent message
+	RecordSentMessage(ctx context.Context, sent *SentMessage) error
+
+	// GetSentMessage returns a sent message by its Telegram IDs, or ErrSentMessageNotFound
+	GetSentMessage(ctx context.Context, chatID int64, telegramMessageID int) (*SentMessage, error)
+
+	// ListReplies returns the messages sent in answer to a stored message, in the order they were sent
+	ListReplies(ctx context.Context, messageID uuid.UUID) ([]*SentMessage, error)
+
+	// ListSessionSentMessages returns up to limit messages sent in a session, the most recent first
+	ListSessionSentMessages(ctx context.Context, sessionID uuid.UUID, limit int) ([]*SentMessage, error)
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteStore_SentMessages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	mgr := NewManager(store)
+	messageMgr := NewMessageManager(store)
+
+	sess, err := mgr.CreateSession(ctx, 1, "replies")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	question := NewMessage(sess.ID, 1, RoleUser, "question")
+	if err := messageMgr.AddMessage(ctx, question); err != nil {
+		t.Fatalf("Failed to add message: %v", err)
+	}
+
+	if _, err := store.GetSentMessage(ctx, 1, 100); !errors.Is(err, ErrSentMessageNotFound) {
+		t.Fatalf("Expected ErrSentMessageNotFound, got %v", err)
+	}
+
+	base := time.Now()
+	sent := []*SentMessage{
+		{ChatID: 1, TelegramMessageID: 101, UserID: 1, SessionID: sess.ID, MessageID: question.ID, ReplyToMessageID: 100, SentAt: base},
+		{ChatID: 1, TelegramMessageID: 102, UserID: 1, SessionID: sess.ID, MessageID: question.ID, ReplyToMessageID: 100, SentAt: base.Add(time.Second)},
+		{ChatID: 1, TelegramMessageID: 103, UserID: 1, SessionID: sess.ID, SentAt: base.Add(2 * time.Second)},
+		{ChatID: 1, TelegramMessageID: 104, UserID: 1, SentAt: base.Add(3 * time.Second)},
+	}
+	for _, s := range sent {
+		if err := store.RecordSentMessage(ctx, s); err != nil {
+			t.Fatalf("RecordSentMessage failed: %v", err)
+		}
+	}
+
+	got, err := store.GetSentMessage(ctx, 1, 101)
+	if err != nil {
+		t.Fatalf("GetSentMessage failed: %v", err)
+	}
+	if got.SessionID != sess.ID || got.MessageID != question.ID || got.ReplyToMessageID != 100 {
+		t.Errorf("Expected the reply linked to its question, got %+v", got)
+	}
+	got, err = store.GetSentMessage(ctx, 1, 104)
+	if err != nil || got.SessionID != uuid.Nil || got.MessageID != uuid.Nil {
+		t.Errorf("Expected a reply outside sessions, got %+v (err=%v)", got, err)
+	}
+
+	replies, err := store.ListReplies(ctx, question.ID)
+	if err != nil {
+		t.Fatalf("ListReplies failed: %v", err)
+	}
+	if len(replies) != 2 || replies[0].TelegramMessageID != 101 || replies[1].TelegramMessageID != 102 {
+		t.Errorf("Expected both parts in send order, got %+v", replies)
+	}
+
+	inSession, err := store.ListSessionSentMessages(ctx, sess.ID, 2)
+	if err != nil {
+		t.Fatalf("ListSessionSentMessages failed: %v", err)
+	}
+	if len(inSession) != 2 || inSession[0].TelegramMessageID != 103 || inSession[1].TelegramMessageID != 102 {
+		t.Errorf("Expected the latest session replies first, got %+v", inSession)
+	}
+
+	if _, err := mgr.DeleteSession(ctx, 1, sess.ID); err != nil {
+		t.Fatalf("DeleteSession failed: %v", err)
+	}
+	if _, err := store.GetSentMessage(ctx, 1, 101); !errors.Is(err, ErrSentMessageNotFound) {
+		t.Errorf("Expected replies deleted with their session, got %v", err)
+	}
+	if _, err := store.GetSentMessage(ctx, 1, 104); err != nil {
+		t.Errorf("Expected replies outside sessions kept, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxDraftParts is the most messages one draft collects
+const MaxDraftParts = 50
+
+// Draft is a prompt a user assembles from several messages with /compose and
+// sends to the assistant at once with /send. Each user has at most one draft,
+// collecting the messages of the chat it was started in.
+type Draft struct {
+	UserID    int64     `json:"user_id"`
+	ChatID    int64     `json:"chat_id"`
+	Parts     []string  `json:"parts"` // collected messages, oldest first
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Text returns the collected messages as one prompt, separated by blank lines
+func (d *Draft) Text() string {
+	return strings.Join(d.Parts, "\n\n")
+}
+
+// Draft errors
+var (
+	ErrDraftNotFound = fmt.Errorf("draft not found")
+	ErrDraftExists   = fmt.Errorf("draft already started")
+	ErrDraftFull     = fmt.Errorf("draft is full")
+)
+
+// DraftStore defines the interface for draft persistence
+type DraftStore interface {
+	// StartDraft creates an empty draft. It returns ErrDraftExists when the user has one.
+	StartDraft(ctx context.Context, draft *Draft) error
+
+	// AppendDraftPart adds a message to the user's draft and returns how many it
+	// has. It returns ErrDraftNotFound without a draft and ErrDraftFull when it
+	// has MaxDraftParts messages already.
+	AppendDraftPart(ctx context.Context, userID int64, text string, now time.Time) (int, error)
+
+	// GetDraft returns the user's draft with its messages, or ErrDraftNotFound
+	GetDraft(ctx context.Context, userID int64) (*Draft, error)
+
+	// DeleteDraft removes the user's draft. It returns ErrDraftNotFound when there is none.
+	DeleteDraft(ctx context.Context, userID int64) error
+}
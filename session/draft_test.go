@@ -0,0 +1,65 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_Drafts(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, err := store.AppendDraftPart(ctx, 1, "lost", now); !errors.Is(err, ErrDraftNotFound) {
+		t.Fatalf("expected ErrDraftNotFound without a draft, got %v", err)
+	}
+	if err := store.StartDraft(ctx, &Draft{UserID: 1, ChatID: 10, StartedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("StartDraft failed: %v", err)
+	}
+	if err := store.StartDraft(ctx, &Draft{UserID: 1, ChatID: 20, StartedAt: now, UpdatedAt: now}); !errors.Is(err, ErrDraftExists) {
+		t.Fatalf("expected ErrDraftExists for a second draft, got %v", err)
+	}
+
+	for i, text := range []string{"Context:", "the logs", "Question?"} {
+		count, err := store.AppendDraftPart(ctx, 1, text, now.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("AppendDraftPart failed: %v", err)
+		}
+		if count != i+1 {
+			t.Errorf("expected %d parts, got %d", i+1, count)
+		}
+	}
+
+	draft, err := store.GetDraft(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetDraft failed: %v", err)
+	}
+	if draft.ChatID != 10 || len(draft.Parts) != 3 || draft.Text() != "Context:\n\nthe logs\n\nQuestion?" {
+		t.Errorf("expected the parts in order, got %+v", draft)
+	}
+
+	if err := store.DeleteDraft(ctx, 1); err != nil {
+		t.Fatalf("DeleteDraft failed: %v", err)
+	}
+	if _, err := store.GetDraft(ctx, 1); !errors.Is(err, ErrDraftNotFound) {
+		t.Errorf("expected the draft deleted, got %v", err)
+	}
+	if err := store.DeleteDraft(ctx, 1); !errors.Is(err, ErrDraftNotFound) {
+		t.Errorf("expected ErrDraftNotFound deleting twice, got %v", err)
+	}
+
+	// A new draft starts empty
+	if err := store.StartDraft(ctx, &Draft{UserID: 1, ChatID: 10, StartedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("StartDraft failed: %v", err)
+	}
+	for i := 0; i < MaxDraftParts; i++ {
+		if _, err := store.AppendDraftPart(ctx, 1, "part", now); err != nil {
+			t.Fatalf("AppendDraftPart %d failed: %v", i, err)
+		}
+	}
+	if _, err := store.AppendDraftPart(ctx, 1, "one too many", now); !errors.Is(err, ErrDraftFull) {
+		t.Errorf("expected ErrDraftFull, got %v", err)
+	}
+}
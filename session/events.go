@@ -0,0 +1,24 @@
+package session
+
+import (
+	"context"
+
+	"tg-bot-demo/events"
+
+	"github.com/google/uuid"
+)
+
+// publish hands event to publisher when one is set
+func publish(ctx context.Context, publisher events.Publisher, event events.Event) {
+	if publisher != nil {
+		publisher.Publish(ctx, event)
+	}
+}
+
+// sessionIDString formats id for an event, leaving uuid.Nil empty
+func sessionIDString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
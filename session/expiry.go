@@ -0,0 +1,25 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpiryStore defines the interface for archiving sessions that stayed idle for
+// too long. Owners are warned first; any activity after the warning, such as a
+// new message or pressing "keep", moves updated_at past it and cancels it.
+type ExpiryStore interface {
+	// ListSessionsToWarn returns up to limit unarchived sessions last updated
+	// before idleBefore whose owners were not warned since, ordered by owner
+	ListSessionsToWarn(ctx context.Context, idleBefore time.Time, limit int) ([]*Session, error)
+
+	// MarkExpiryWarned records that the owners of sessions were warned at warnedAt
+	MarkExpiryWarned(ctx context.Context, ids []uuid.UUID, warnedAt time.Time) error
+
+	// ArchiveExpiredSessions archives the sessions last updated before idleBefore
+	// whose owners were warned at or before warnedBefore, and returns how many
+	// were archived
+	ArchiveExpiredSessions(ctx context.Context, idleBefore, warnedBefore time.Time) (int, error)
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteStore_SessionExpiry(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	manager := NewManager(store)
+
+	idle, err := manager.CreateSession(ctx, 1, "idle")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	kept, err := manager.CreateSession(ctx, 1, "kept")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	// Both sessions are idle as seen from a week later
+	later := time.Now().Add(7 * 24 * time.Hour)
+	toWarn, err := store.ListSessionsToWarn(ctx, later, 10)
+	if err != nil {
+		t.Fatalf("ListSessionsToWarn failed: %v", err)
+	}
+	if len(toWarn) != 2 {
+		t.Fatalf("expected 2 sessions to warn about, got %d", len(toWarn))
+	}
+
+	warnedAt := time.Now()
+	if err := store.MarkExpiryWarned(ctx, []uuid.UUID{idle.ID, kept.ID}, warnedAt); err != nil {
+		t.Fatalf("MarkExpiryWarned failed: %v", err)
+	}
+	if toWarn, _ := store.ListSessionsToWarn(ctx, later, 10); len(toWarn) != 0 {
+		t.Errorf("expected warned sessions to be skipped, got %d", len(toWarn))
+	}
+
+	// Keeping a session cancels its warning
+	if _, err := manager.KeepSession(ctx, 1, kept.ID); err != nil {
+		t.Fatalf("KeepSession failed: %v", err)
+	}
+
+	// Nothing is archived before the warning lead time has passed
+	if archived, err := store.ArchiveExpiredSessions(ctx, later, warnedAt.Add(-time.Hour)); err != nil || archived != 0 {
+		t.Fatalf("expected nothing archived yet, got %d, %v", archived, err)
+	}
+
+	archived, err := store.ArchiveExpiredSessions(ctx, later, later)
+	if err != nil {
+		t.Fatalf("ArchiveExpiredSessions failed: %v", err)
+	}
+	if archived != 1 {
+		t.Errorf("expected 1 archived session, got %d", archived)
+	}
+	sessions, err := store.ListByUser(ctx, 1, 0, 10)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != kept.ID {
+		t.Errorf("expected only the kept session to be listed, got %d sessions", len(sessions))
+	}
+
+	// The kept session is warned about again once it is idle again
+	if toWarn, _ := store.ListSessionsToWarn(ctx, later, 10); len(toWarn) != 1 || toWarn[0].ID != kept.ID {
+		t.Errorf("expected the kept session to be warned about again, got %d sessions", len(toWarn))
+	}
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Feedback is a note a user sent with /feedback
+type Feedback struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	SessionID    uuid.UUID `json:"session_id"`              // active session when sent, uuid.Nil if none
+	SessionTitle string    `json:"session_title,omitempty"` // filled by ListOpenFeedback while the session exists
+	Text         string    `json:"text"`
+	CreatedAt    time.Time `json:"created_at"`
+	ResolvedAt   time.Time `json:"resolved_at,omitempty"` // zero while open
+	ResolvedBy   int64     `json:"resolved_by,omitempty"`
+}
+
+// ErrFeedbackNotFound is returned when no open feedback matches an ID
+var ErrFeedbackNotFound = fmt.Errorf("feedback not found")
+
+// FeedbackStore defines the interface for feedback persistence
+type FeedbackStore interface {
+	// AddFeedback stores feedback and sets its ID
+	AddFeedback(ctx context.Context, feedback *Feedback) error
+
+	// ListOpenFeedback returns unresolved feedback, oldest first
+	ListOpenFeedback(ctx context.Context, offset, limit int) ([]*Feedback, error)
+
+	// CountOpenFeedback returns the number of unresolved feedback rows
+	CountOpenFeedback(ctx context.Context) (int, error)
+
+	// ResolveFeedback marks open feedback as resolved by an administrator.
+	// It returns ErrFeedbackNotFound when id is unknown or already resolved.
+	ResolveFeedback(ctx context.Context, id, resolvedBy int64, resolvedAt time.Time) error
+}
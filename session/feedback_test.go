@@ -0,0 +1,52 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_Feedback(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	linked := NewSession(1, "Linked")
+	if err := store.Create(ctx, linked); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	first := &Feedback{UserID: 1, SessionID: linked.ID, Text: "first", CreatedAt: time.Now()}
+	second := &Feedback{UserID: 2, Text: "second", CreatedAt: time.Now()}
+	for _, feedback := range []*Feedback{first, second} {
+		if err := store.AddFeedback(ctx, feedback); err != nil {
+			t.Fatalf("AddFeedback failed: %v", err)
+		}
+	}
+	if first.ID == 0 || second.ID <= first.ID {
+		t.Fatalf("Expected increasing IDs, got %d and %d", first.ID, second.ID)
+	}
+
+	open, err := store.ListOpenFeedback(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ListOpenFeedback failed: %v", err)
+	}
+	if len(open) != 2 || open[0].SessionTitle != "Linked" || open[0].SessionID != linked.ID || open[1].SessionTitle != "" {
+		t.Fatalf("Unexpected open feedback %+v %+v", open[0], open[1])
+	}
+
+	if err := store.ResolveFeedback(ctx, first.ID, 99, time.Now()); err != nil {
+		t.Fatalf("ResolveFeedback failed: %v", err)
+	}
+	if err := store.ResolveFeedback(ctx, first.ID, 99, time.Now()); !errors.Is(err, ErrFeedbackNotFound) {
+		t.Errorf("Expected ErrFeedbackNotFound resolving twice, got %v", err)
+	}
+
+	count, err := store.CountOpenFeedback(ctx)
+	if err != nil {
+		t.Fatalf("CountOpenFeedback failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 open feedback, got %d", count)
+	}
+}
@@ -0,0 +1,242 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tg-bot-demo/events"
+
+	"github.com/google/uuid"
+)
+
+// File represents a Telegram file downloaded into the storage backend
+type File struct {
+	ID             uuid.UUID    `json:"id"`
+	UserID         int64        `json:"user_id"`
+	SessionID      uuid.UUID    `json:"session_id"` // uuid.Nil when not attached to a session
+	Kind           string       `json:"kind"`
+	TelegramFileID string       `json:"telegram_file_id"`
+	FileName       string       `json:"file_name"`
+	MimeType       string       `json:"mime_type"`
+	MediaGroupID   string       `json:"media_group_id,omitempty"`
+	Sticker        *StickerInfo `json:"sticker,omitempty"` // set only for stickers
+	StorageKey     string       `json:"storage_key"`
+	Size           int64        `json:"size"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// StickerInfo holds the sticker metadata recorded alongside a sticker file
+type StickerInfo struct {
+	SetName       string `json:"set_name,omitempty"`
+	Emoji         string `json:"emoji,omitempty"`
+	Type          string `json:"type"` // regular, mask or custom_emoji
+	CustomEmojiID string `json:"custom_emoji_id,omitempty"`
+	IsAnimated    bool   `json:"is_animated"`
+	IsVideo       bool   `json:"is_video"`
+}
+
+// StickerSetUsage summarizes the stickers a user sent from one set
+type StickerSetUsage struct {
+	SetName  string `json:"set_name"`
+	Type     string `json:"type"`
+	Stickers int    `json:"stickers"`
+	Emojis   string `json:"emojis"`
+}
+
+// NewFile creates a new catalog entry with generated UUID
+func NewFile(userID int64, kind, telegramFileID, storageKey string, size int64) *File {
+	return &File{
+		ID:             uuid.New(),
+		UserID:         userID,
+		Kind:           kind,
+		TelegramFileID: telegramFileID,
+		StorageKey:     storageKey,
+		Size:           size,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// FileStore defines the interface for the downloaded file catalog
+type FileStore interface {
+	// CreateFile stores a new catalog entry
+	CreateFile(ctx context.Context, file *File) error
+
+	// GetFile retrieves a catalog entry by ID
+	GetFile(ctx context.Context, id uuid.UUID) (*File, error)
+
+	// DeleteFile removes a catalog entry
+	DeleteFile(ctx context.Context, id uuid.UUID) error
+
+	// ListFilesByUser returns files for a specific user with pagination, newest first
+	ListFilesByUser(ctx context.Context, userID int64, offset, limit int) ([]*File, error)
+
+	// CountFilesByUser returns total number of files for a user
+	CountFilesByUser(ctx context.Context, userID int64) (int, error)
+
+	// ListFilesBySession returns files attached to a session, oldest first
+	ListFilesBySession(ctx context.Context, sessionID uuid.UUID) ([]*File, error)
+
+	// DetachSessionFiles clears the session link of all files attached to a session
+	DetachSessionFiles(ctx context.Context, sessionID uuid.UUID) error
+
+	// ListRecentStickerSets returns the sticker sets a user sent, most recently used first
+	ListRecentStickerSets(ctx context.Context, userID int64, limit int) ([]*StickerSetUsage, error)
+
+	// GetFileUsage returns the number and total size of files stored for a user
+	GetFileUsage(ctx context.Context, userID int64) (*FileUsage, error)
+
+	// ListFileUsage returns file usage for every user that has stored files
+	ListFileUsage(ctx context.Context) ([]*FileUsage, error)
+
+	// ListOldestFiles returns files of all users, oldest first
+	ListOldestFiles(ctx context.Context, offset, limit int) ([]*File, error)
+
+	// ListOldestFilesByUser returns files of one user, oldest first
+	ListOldestFilesByUser(ctx context.Context, userID int64, offset, limit int) ([]*File, error)
+}
+
+// ErrFileNotFound is returned when a catalog entry does not exist
+var ErrFileNotFound = fmt.Errorf("file not found")
+
+// FileManager handles file catalog business logic
+type FileManager struct {
+	store  FileStore
+	events events.Publisher // nil publishes nothing
+}
+
+// NewFileManager creates a new file manager
+func NewFileManager(store FileStore) *FileManager {
+	return &FileManager{store: store}
+}
+
+// SetEvents publishes file downloaded events to publisher
+func (m *FileManager) SetEvents(publisher events.Publisher) {
+	m.events = publisher
+}
+
+// RecordFile adds a downloaded file to the catalog
+func (m *FileManager) RecordFile(ctx context.Context, file *File) error {
+	if err := m.store.CreateFile(ctx, file); err != nil {
+		return fmt.Errorf("failed to record file: %w", err)
+	}
+
+	publish(ctx, m.events, events.Event{
+		Type:      events.FileDownloaded,
+		UserID:    file.UserID,
+		SessionID: sessionIDString(file.SessionID),
+		Data: map[string]any{
+			"file_id":   file.ID.String(),
+			"kind":      file.Kind,
+			"file_name": file.FileName,
+			"mime_type": file.MimeType,
+			"size":      file.Size,
+		},
+	})
+	return nil
+}
+
+// ListFiles retrieves paginated files for a user
+func (m *FileManager) ListFiles(ctx context.Context, userID int64, offset, limit int) ([]*File, bool, error) {
+	files, err := m.store.ListFilesByUser(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	total, err := m.store.CountFilesByUser(ctx, userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to count files: %w", err)
+	}
+
+	hasMore := offset+limit < total
+	return files, hasMore, nil
+}
+
+// GetFile returns a file owned by userID
+func (m *FileManager) GetFile(ctx context.Context, userID int64, fileID uuid.UUID) (*File, error) {
+	file, err := m.store.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	if file.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	return file, nil
+}
+
+// DeleteFile removes a file owned by userID from the catalog.
+// Removing the stored object is left to the caller.
+func (m *FileManager) DeleteFile(ctx context.Context, userID int64, fileID uuid.UUID) (*File, error) {
+	file, err := m.GetFile(ctx, userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.store.DeleteFile(ctx, fileID); err != nil {
+		return nil, fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return file, nil
+}
+
+// ListSessionFiles returns the attachments of a session
+func (m *FileManager) ListSessionFiles(ctx context.Context, sessionID uuid.UUID) ([]*File, error) {
+	files, err := m.store.ListFilesBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session files: %w", err)
+	}
+	return files, nil
+}
+
+// DeleteSessionFiles removes the catalog entries of a session's attachments and returns them,
+// so the caller can delete the stored objects.
+func (m *FileManager) DeleteSessionFiles(ctx context.Context, sessionID uuid.UUID) ([]*File, error) {
+	files, err := m.ListSessionFiles(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if err := m.store.DeleteFile(ctx, file.ID); err != nil && !errors.Is(err, ErrFileNotFound) {
+			return nil, fmt.Errorf("failed to delete file: %w", err)
+		}
+	}
+
+	return files, nil
+}
+
+// DetachSessionFiles keeps a session's attachments but unlinks them from the session
+func (m *FileManager) DetachSessionFiles(ctx context.Context, sessionID uuid.UUID) error {
+	if err := m.store.DetachSessionFiles(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to detach session files: %w", err)
+	}
+	return nil
+}
+
+// Usage returns how many files a user stored and their total size
+func (m *FileManager) Usage(ctx context.Context, userID int64) (*FileUsage, error) {
+	usage, err := m.store.GetFileUsage(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file usage: %w", err)
+	}
+	return usage, nil
+}
+
+// RecentStickerSets returns the sticker sets a user sent most recently
+func (m *FileManager) RecentStickerSets(ctx context.Context, userID int64, limit int) ([]*StickerSetUsage, error) {
+	sets, err := m.store.ListRecentStickerSets(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sticker sets: %w", err)
+	}
+	return sets, nil
+}
+
+// FileUsage summarizes the files stored for one user
+type FileUsage struct {
+	UserID int64 `json:"user_id"`
+	Files  int   `json:"files"`
+	Bytes  int64 `json:"bytes"`
+}
@@ -0,0 +1,256 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_files.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func TestSQLiteStore_FileCatalog(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	userID := int64(12345)
+
+	for i := 0; i < 5; i++ {
+		file := NewFile(userID, "photo", "tg-file-"+string(rune('A'+i)), "alice/file", int64(100*i))
+		file.CreatedAt = time.Now().Add(time.Duration(i) * time.Second)
+		if err := store.CreateFile(ctx, file); err != nil {
+			t.Fatalf("Failed to create file %d: %v", i, err)
+		}
+	}
+	if err := store.CreateFile(ctx, NewFile(999, "document", "other", "bob/file", 1)); err != nil {
+		t.Fatalf("Failed to create other user's file: %v", err)
+	}
+
+	count, err := store.CountFilesByUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to count files: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("Expected 5 files, got %d", count)
+	}
+
+	files, err := store.ListFilesByUser(ctx, userID, 0, 3)
+	if err != nil {
+		t.Fatalf("Failed to list files: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %d", len(files))
+	}
+	if files[0].TelegramFileID != "tg-file-E" {
+		t.Errorf("Expected newest file first, got %s", files[0].TelegramFileID)
+	}
+
+	retrieved, err := store.GetFile(ctx, files[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get file: %v", err)
+	}
+	if retrieved.Size != 400 || retrieved.Kind != "photo" {
+		t.Errorf("Unexpected file data: %+v", retrieved)
+	}
+
+	if err := store.DeleteFile(ctx, files[0].ID); err != nil {
+		t.Fatalf("Failed to delete file: %v", err)
+	}
+	if _, err := store.GetFile(ctx, files[0].ID); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("Expected ErrFileNotFound, got %v", err)
+	}
+	if err := store.DeleteFile(ctx, files[0].ID); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("Expected ErrFileNotFound on second delete, got %v", err)
+	}
+}
+
+func TestFileManager_Ownership(t *testing.T) {
+	store := newTestStore(t)
+	manager := NewFileManager(store)
+	ctx := context.Background()
+
+	file := NewFile(111, "document", "tg-file", "alice/tg-file", 42)
+	if err := manager.RecordFile(ctx, file); err != nil {
+		t.Fatalf("RecordFile failed: %v", err)
+	}
+
+	if _, err := manager.GetFile(ctx, 222, file.ID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized for other user, got %v", err)
+	}
+	if _, err := manager.DeleteFile(ctx, 222, file.ID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized deleting other user's file, got %v", err)
+	}
+
+	files, hasNext, err := manager.ListFiles(ctx, 111, 0, 1)
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 1 || hasNext {
+		t.Errorf("Expected 1 file without next page, got %d hasNext=%v", len(files), hasNext)
+	}
+
+	deleted, err := manager.DeleteFile(ctx, 111, file.ID)
+	if err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if deleted.StorageKey != "alice/tg-file" {
+		t.Errorf("Expected deleted file to carry storage key, got %q", deleted.StorageKey)
+	}
+}
+
+func TestFileManager_SessionAttachments(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	mgr := NewManager(store)
+	fileMgr := NewFileManager(store)
+	userID := int64(12345)
+
+	sess, err := mgr.CreateSession(ctx, userID, "with files")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		file := NewFile(userID, "document", name, "alice/"+name, 10)
+		file.SessionID = sess.ID
+		if err := fileMgr.RecordFile(ctx, file); err != nil {
+			t.Fatalf("Failed to record file: %v", err)
+		}
+	}
+	loose := NewFile(userID, "document", "loose", "alice/loose", 10)
+	if err := fileMgr.RecordFile(ctx, loose); err != nil {
+		t.Fatalf("Failed to record file: %v", err)
+	}
+
+	attached, err := fileMgr.ListSessionFiles(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to list session files: %v", err)
+	}
+	if len(attached) != 2 || attached[0].SessionID != sess.ID {
+		t.Fatalf("Expected 2 attached files, got %+v", attached)
+	}
+
+	retrieved, err := store.GetFile(ctx, loose.ID)
+	if err != nil {
+		t.Fatalf("Failed to get file: %v", err)
+	}
+	if retrieved.SessionID != uuid.Nil {
+		t.Errorf("Expected unattached file to have no session, got %s", retrieved.SessionID)
+	}
+
+	// Detaching keeps the files in the catalog
+	if err := fileMgr.DetachSessionFiles(ctx, sess.ID); err != nil {
+		t.Fatalf("Failed to detach files: %v", err)
+	}
+	attached, _ = fileMgr.ListSessionFiles(ctx, sess.ID)
+	if len(attached) != 0 {
+		t.Errorf("Expected no attached files after detach, got %d", len(attached))
+	}
+	if count, _ := store.CountFilesByUser(ctx, userID); count != 3 {
+		t.Errorf("Expected 3 files kept after detach, got %d", count)
+	}
+
+	// Deleting removes only the session's files
+	file := NewFile(userID, "document", "c", "alice/c", 10)
+	file.SessionID = sess.ID
+	if err := fileMgr.RecordFile(ctx, file); err != nil {
+		t.Fatalf("Failed to record file: %v", err)
+	}
+	deleted, err := fileMgr.DeleteSessionFiles(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to delete session files: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != file.ID {
+		t.Errorf("Expected the attached file to be deleted, got %+v", deleted)
+	}
+	if count, _ := store.CountFilesByUser(ctx, userID); count != 3 {
+		t.Errorf("Expected 3 files left, got %d", count)
+	}
+}
+
+func TestManager_DeleteSession(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	mgr := NewManager(store)
+
+	sess, err := mgr.CreateSession(ctx, 1, "to delete")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if _, err := mgr.DeleteSession(ctx, 2, sess.ID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized for another user, got %v", err)
+	}
+
+	if _, err := mgr.DeleteSession(ctx, 1, sess.ID); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if _, err := mgr.GetActiveSession(ctx, 1); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected no active session after delete, got %v", err)
+	}
+}
+
+func TestSQLiteStore_StickerSets(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	userID := int64(12345)
+	base := time.Now()
+
+	stickers := []struct {
+		set   string
+		emoji string
+		typ   string
+	}{
+		{"Cats", "😺", "regular"},
+		{"Dogs", "🐶", "regular"},
+		{"Cats", "😹", "regular"},
+		{"Cats", "😺", "regular"},
+		{"", "🙂", "regular"},
+		{"Blobs", "", "custom_emoji"},
+	}
+	for i, s := range stickers {
+		file := NewFile(userID, "sticker", fmt.Sprintf("sticker-%d", i), "alice/sticker", 10)
+		file.Sticker = &StickerInfo{SetName: s.set, Emoji: s.emoji, Type: s.typ, IsVideo: s.set == "Dogs"}
+		file.CreatedAt = base.Add(time.Duration(i) * time.Second)
+		if err := store.CreateFile(ctx, file); err != nil {
+			t.Fatalf("Failed to create sticker %d: %v", i, err)
+		}
+	}
+
+	files, err := store.ListFilesByUser(ctx, userID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to list files: %v", err)
+	}
+	dogs := files[len(files)-2]
+	if dogs.Sticker == nil || dogs.Sticker.SetName != "Dogs" || !dogs.Sticker.IsVideo || dogs.Sticker.Emoji != "🐶" {
+		t.Errorf("Expected sticker metadata to round-trip, got %+v", dogs.Sticker)
+	}
+
+	sets, err := store.ListRecentStickerSets(ctx, userID, 10)
+	if err != nil {
+		t.Fatalf("Failed to list sticker sets: %v", err)
+	}
+	if len(sets) != 3 {
+		t.Fatalf("Expected 3 sticker sets, got %d", len(sets))
+	}
+	if sets[0].SetName != "Blobs" || sets[0].Type != "custom_emoji" {
+		t.Errorf("Expected most recent set first, got %+v", sets[0])
+	}
+	if sets[1].SetName != "Cats" || sets[1].Stickers != 3 || !strings.Contains(sets[1].Emojis, "😹") {
+		t.Errorf("Unexpected Cats set usage: %+v", sets[1])
+	}
+}
@@ -0,0 +1,35 @@
+package session
+
+import "context"
+
+// IntegrityReport lists the inconsistencies an integrity check found. Rows like
+// these are left behind by databases written before foreign keys were enforced,
+// or edited by hand.
+type IntegrityReport struct {
+	// OrphanedActiveSessions are active, topic and business chat session
+	// bindings pointing at sessions that no longer exist
+	OrphanedActiveSessions int
+
+	// InvalidSessionIDs are sessions whose ID is not a UUID; they cannot be loaded
+	InvalidSessionIDs int
+
+	// DanglingFiles are catalog entries attached to sessions that no longer exist
+	DanglingFiles int
+
+	// Repaired is set when the inconsistencies were fixed
+	Repaired bool
+}
+
+// Problems returns the number of inconsistencies found
+func (r *IntegrityReport) Problems() int {
+	return r.OrphanedActiveSessions + r.InvalidSessionIDs + r.DanglingFiles
+}
+
+// IntegrityStore defines the interface for checking and repairing references between sessions and other rows
+type IntegrityStore interface {
+	// CheckIntegrity looks for orphaned session bindings, sessions with invalid
+	// IDs and files attached to missing sessions. With repair it also fixes them
+	// in one transaction: bindings are removed, invalid sessions are deleted
+	// with their messages and files are detached, keeping them in the catalog.
+	CheckIntegrity(ctx context.Context, repair bool) (*IntegrityReport, error)
+}
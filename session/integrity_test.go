@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteStore_CheckIntegrity(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	healthy := NewSession(1, "healthy")
+	if err := store.Create(ctx, healthy); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := store.SetActiveSession(ctx, 1, healthy.ID); err != nil {
+		t.Fatalf("SetActiveSession failed: %v", err)
+	}
+	attached := NewFile(1, "photo", "tg-1", "1/photo.jpg", 10)
+	attached.SessionID = healthy.ID
+	if err := store.CreateFile(ctx, attached); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	// Write what an old database without foreign keys could contain
+	conn, err := store.db.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn failed: %v", err)
+	}
+	missing := uuid.New().String()
+	now := time.Now()
+	for _, statement := range []struct {
+		query string
+		args  []any
+	}{
+		{`PRAGMA foreign_keys = OFF`, nil},
+		{`INSERT INTO active_sessions (user_id, session_id) VALUES (2, ?)`, []any{missing}},
+		{`INSERT INTO topic_sessions (chat_id, thread_id, user_id, session_id) VALUES (-100, 5, 2, ?)`, []any{missing}},
+		{`INSERT INTO sessions (id, user_id, title, created_at, updated_at, last_message) VALUES ('not-a-uuid', 3, 'broken', ?, ?, '')`, []any{now, now}},
+		{`INSERT INTO messages (id, session_id, user_id, role, content, created_at) VALUES (?, 'not-a-uuid', 3, 'user', 'hi', ?)`, []any{uuid.New().String(), now}},
+		{`INSERT INTO files (id, user_id, session_id, kind, telegram_file_id, storage_key, size, created_at) VALUES (?, 2, ?, 'photo', 'tg-2', '2/photo.jpg', 10, ?)`, []any{uuid.New().String(), missing, now}},
+		{`PRAGMA foreign_keys = ON`, nil},
+	} {
+		if _, err := conn.ExecContext(ctx, statement.query, statement.args...); err != nil {
+			t.Fatalf("%s failed: %v", statement.query, err)
+		}
+	}
+	conn.Close()
+
+	report, err := store.CheckIntegrity(ctx, false)
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	want := IntegrityReport{OrphanedActiveSessions: 2, InvalidSessionIDs: 1, DanglingFiles: 1}
+	if *report != want {
+		t.Errorf("expected %+v, got %+v", want, *report)
+	}
+
+	// Checking changes nothing
+	if report, err := store.CheckIntegrity(ctx, false); err != nil || report.Problems() != 4 {
+		t.Fatalf("expected the problems to remain after a check, got %+v err=%v", report, err)
+	}
+
+	report, err = store.CheckIntegrity(ctx, true)
+	if err != nil {
+		t.Fatalf("CheckIntegrity with repair failed: %v", err)
+	}
+	want.Repaired = true
+	if *report != want {
+		t.Errorf("expected %+v, got %+v", want, *report)
+	}
+
+	report, err = store.CheckIntegrity(ctx, false)
+	if err != nil || report.Problems() != 0 {
+		t.Errorf("expected no problems after the repair, got %+v err=%v", report, err)
+	}
+	if active, err := store.GetActiveSession(ctx, 1); err != nil || active.ID != healthy.ID {
+		t.Errorf("expected the healthy binding kept, got %v err=%v", active, err)
+	}
+	if file, err := store.GetFile(ctx, attached.ID); err != nil || file.SessionID != healthy.ID {
+		t.Errorf("expected the attached file kept, got %v err=%v", file, err)
+	}
+	var orphanedMessages int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE session_id = 'not-a-uuid'`).Scan(&orphanedMessages); err != nil || orphanedMessages != 0 {
+		t.Errorf("expected the messages of the invalid session deleted, got %d err=%v", orphanedMessages, err)
+	}
+}
@@ -0,0 +1,25 @@
+package session
+
+import "context"
+
+// MaintenanceOptions selects the optional steps of a maintenance run
+type MaintenanceOptions struct {
+	// IncrementalVacuum returns free pages to the file system. The first run with it
+	// switches the database to incremental auto-vacuum, which takes a full VACUUM.
+	IncrementalVacuum bool
+}
+
+// MaintenanceReport summarizes one maintenance run
+type MaintenanceReport struct {
+	BytesBefore        int64 // database file size before the run
+	BytesAfter         int64 // database file size after the run
+	CheckpointedFrames int   // write-ahead log frames copied into the database
+	CheckpointBusy     bool  // a reader kept the checkpoint from truncating the log
+}
+
+// MaintenanceStore defines the interface for database housekeeping
+type MaintenanceStore interface {
+	// Maintain refreshes query planner statistics, optionally vacuums free pages
+	// and checkpoints the write-ahead log
+	Maintain(ctx context.Context, options MaintenanceOptions) (*MaintenanceReport, error)
+}
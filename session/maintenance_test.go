@@ -0,0 +1,56 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteStore_Maintain(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	// Grow the database, then free most of it
+	session := NewSession(1, "big")
+	if err := store.Create(ctx, session); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		if err := store.AppendMessage(ctx, NewMessage(session.ID, 1, RoleUser, strings.Repeat("x", 4096))); err != nil {
+			t.Fatalf("AppendMessage failed: %v", err)
+		}
+	}
+	if err := store.Delete(ctx, session.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	report, err := store.Maintain(ctx, MaintenanceOptions{})
+	if err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	if report.BytesAfter != report.BytesBefore {
+		t.Errorf("expected no shrinking without vacuum, got %d -> %d", report.BytesBefore, report.BytesAfter)
+	}
+
+	// The first incremental run rebuilds the database, dropping the free pages
+	report, err = store.Maintain(ctx, MaintenanceOptions{IncrementalVacuum: true})
+	if err != nil {
+		t.Fatalf("Maintain with vacuum failed: %v", err)
+	}
+	if report.BytesAfter >= report.BytesBefore {
+		t.Errorf("expected vacuum to shrink the database, got %d -> %d", report.BytesBefore, report.BytesAfter)
+	}
+
+	var mode int
+	if err := store.db.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&mode); err != nil {
+		t.Fatalf("failed to read auto_vacuum: %v", err)
+	}
+	if mode != autoVacuumIncremental {
+		t.Errorf("expected incremental auto_vacuum, got mode %d", mode)
+	}
+
+	// Later runs release free pages incrementally
+	if _, err := store.Maintain(ctx, MaintenanceOptions{IncrementalVacuum: true}); err != nil {
+		t.Fatalf("second Maintain with vacuum failed: %v", err)
+	}
+}
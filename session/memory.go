@@ -0,0 +1,47 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limits of a session's memory
+const (
+	MaxMemoryEntries     = 20  // entries per session
+	MaxMemoryKeyLength   = 32  // characters
+	MaxMemoryValueLength = 500 // characters
+)
+
+// MemoryEntry is a value remembered for a session with /memory and given to the AI as context
+type MemoryEntry struct {
+	SessionID uuid.UUID `json:"session_id"`
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Memory errors
+var (
+	ErrMemoryNotFound = fmt.Errorf("memory entry not found")
+	ErrMemoryFull     = fmt.Errorf("session memory is full")
+)
+
+// MemoryStore defines the interface for session memory persistence
+type MemoryStore interface {
+	// SetMemory stores or replaces the value of key in a session's memory. It
+	// returns ErrMemoryFull when key is new and the session has MaxMemoryEntries.
+	SetMemory(ctx context.Context, entry *MemoryEntry) error
+
+	// ListMemory returns a session's memory ordered by key
+	ListMemory(ctx context.Context, sessionID uuid.UUID) ([]*MemoryEntry, error)
+
+	// DeleteMemory removes key from a session's memory.
+	// It returns ErrMemoryNotFound when the key is not set.
+	DeleteMemory(ctx context.Context, sessionID uuid.UUID, key string) error
+
+	// ClearMemory removes a session's whole memory and returns the number of entries removed
+	ClearMemory(ctx context.Context, sessionID uuid.UUID) (int, error)
+}
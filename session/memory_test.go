@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteStore_Memory(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sess := NewSession(1, "Trip")
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	set := func(key, value string) error {
+		return store.SetMemory(ctx, &MemoryEntry{SessionID: sess.ID, Key: key, Value: value, UpdatedAt: time.Now()})
+	}
+	if err := set("name", "Bob"); err != nil {
+		t.Fatalf("SetMemory failed: %v", err)
+	}
+	if err := set("city", "Lisbon"); err != nil {
+		t.Fatalf("SetMemory failed: %v", err)
+	}
+	if err := set("name", "Alice"); err != nil {
+		t.Fatalf("SetMemory replacing a key failed: %v", err)
+	}
+
+	entries, err := store.ListMemory(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("ListMemory failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Key != "city" || entries[1].Value != "Alice" {
+		t.Fatalf("expected city and the replaced name, got %+v %+v", entries[0], entries[1])
+	}
+
+	if err := store.DeleteMemory(ctx, sess.ID, "city"); err != nil {
+		t.Fatalf("DeleteMemory failed: %v", err)
+	}
+	if err := store.DeleteMemory(ctx, sess.ID, "city"); !errors.Is(err, ErrMemoryNotFound) {
+		t.Errorf("expected ErrMemoryNotFound deleting twice, got %v", err)
+	}
+
+	err = store.SetMemory(ctx, &MemoryEntry{SessionID: uuid.New(), Key: "name", Value: "Bob", UpdatedAt: time.Now()})
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound for an unknown session, got %v", err)
+	}
+
+	// Memory goes with its session
+	if err := store.Delete(ctx, sess.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if entries, _ := store.ListMemory(ctx, sess.ID); len(entries) != 0 {
+		t.Errorf("expected memory deleted with the session, got %d entries", len(entries))
+	}
+}
+
+func TestSQLiteStore_MemoryLimit(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sess := NewSession(1, "Full")
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for i := 0; i < MaxMemoryEntries; i++ {
+		entry := &MemoryEntry{SessionID: sess.ID, Key: fmt.Sprintf("key%d", i), Value: "v", UpdatedAt: time.Now()}
+		if err := store.SetMemory(ctx, entry); err != nil {
+			t.Fatalf("SetMemory %d failed: %v", i, err)
+		}
+	}
+
+	if err := store.SetMemory(ctx, &MemoryEntry{SessionID: sess.ID, Key: "one_more", Value: "v", UpdatedAt: time.Now()}); !errors.Is(err, ErrMemoryFull) {
+		t.Errorf("expected ErrMemoryFull, got %v", err)
+	}
+	if err := store.SetMemory(ctx, &MemoryEntry{SessionID: sess.ID, Key: "key0", Value: "new", UpdatedAt: time.Now()}); err != nil {
+		t.Errorf("expected replacing a key of a full memory to work, got %v", err)
+	}
+
+	cleared, err := store.ClearMemory(ctx, sess.ID)
+	if err != nil || cleared != MaxMemoryEntries {
+		t.Errorf("expected %d entries cleared, got %d (err=%v)", MaxMemoryEntries, cleared, err)
+	}
+}
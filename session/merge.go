@@ -0,0 +1,25 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MergeReport summarizes a merge of two sessions
+type MergeReport struct {
+	Target   *Session // the merged session
+	Messages int      // messages moved from the source
+	Files    int      // files moved from the source
+}
+
+// ErrSameSession is returned when a session is merged into itself
+var ErrSameSession = fmt.Errorf("cannot merge a session into itself")
+
+// MergeStore defines the interface for merging sessions
+type MergeStore interface {
+	// MergeSessions moves the messages and files of source into target and deletes
+	// source, in one transaction. Both sessions must belong to userID.
+	MergeSessions(ctx context.Context, userID int64, sourceID, targetID uuid.UUID) (*MergeReport, error)
+}
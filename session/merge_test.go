@@ -0,0 +1,72 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_MergeSessions(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_merge.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	manager := NewManager(store)
+	target, err := manager.CreateSession(ctx, 1, "target")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	source, err := manager.CreateSession(ctx, 1, "source")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	start := time.Now()
+	for i, sess := range []*Session{target, source, target} {
+		message := NewMessage(sess.ID, 1, RoleUser, sess.Title)
+		message.CreatedAt = start.Add(time.Duration(i) * time.Second)
+		if err := store.AppendMessage(ctx, message); err != nil {
+			t.Fatalf("AppendMessage failed: %v", err)
+		}
+	}
+	file := NewFile(1, "document", "a.txt", "user_1/a.txt", 4)
+	file.SessionID = source.ID
+	if err := store.CreateFile(ctx, file); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	if _, err := store.MergeSessions(ctx, 2, source.ID, target.ID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for another user, got %v", err)
+	}
+	if _, err := store.MergeSessions(ctx, 1, source.ID, source.ID); !errors.Is(err, ErrSameSession) {
+		t.Errorf("expected ErrSameSession, got %v", err)
+	}
+
+	report, err := store.MergeSessions(ctx, 1, source.ID, target.ID)
+	if err != nil {
+		t.Fatalf("MergeSessions failed: %v", err)
+	}
+	if report.Messages != 1 || report.Files != 1 || report.Target.ID != target.ID {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if _, err := store.Get(ctx, source.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected source to be deleted, got %v", err)
+	}
+	messages, err := store.ListMessages(ctx, target.ID, 10)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 3 || messages[1].Content != "source" {
+		t.Errorf("expected messages interleaved chronologically, got %d", len(messages))
+	}
+	// The source was active; the user continues in the target
+	if active, err := store.GetActiveSession(ctx, 1); err != nil || active.ID != target.ID {
+		t.Errorf("expected target to become active, got %v, %v", active, err)
+	}
+}
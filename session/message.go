@@ -0,0 +1,210 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tg-bot-demo/events"
+
+	"github.com/google/uuid"
+)
+
+// Message roles
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleContext   = "context" // text extracted from an attachment, supplied to the AI as background
+)
+
+// Message represents one entry in a session's conversation history
+type Message struct {
+	ID                uuid.UUID  `json:"id"`
+	SessionID         uuid.UUID  `json:"session_id"`
+	UserID            int64      `json:"user_id"`
+	Role              string     `json:"role"`
+	Content           string     `json:"content"`
+	FileID            uuid.UUID  `json:"file_id"` // source file for RoleContext messages, uuid.Nil otherwise
+	ChatID            int64      `json:"chat_id"`
+	TelegramMessageID int        `json:"telegram_message_id"`
+	CreatedAt         time.Time  `json:"created_at"`
+	EditedAt          *time.Time `json:"edited_at,omitempty"`
+	// RepliedAt is when the assistant replied to a user message; the store sets
+	// it on every unanswered user message of the session when a reply is stored
+	RepliedAt *time.Time `json:"replied_at,omitempty"`
+}
+
+// MessageVersion is one content a message had. Messages whose content was
+// revised, e.g. a regenerated assistant reply, keep every version.
+type MessageVersion struct {
+	MessageID uuid.UUID `json:"message_id"`
+	Version   int       `json:"version"` // 1 for the original content
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrMessageNotFound is returned when no stored message matches
+var ErrMessageNotFound = fmt.Errorf("message not found")
+
+// NewMessage creates a new message with generated UUID
+func NewMessage(sessionID uuid.UUID, userID int64, role, content string) *Message {
+	return &Message{
+		ID:        uuid.New(),
+		SessionID: sessionID,
+		UserID:    userID,
+		Role:      role,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+}
+
+// MessageStore defines the interface for conversation history persistence
+type MessageStore interface {
+	// AppendMessage stores a message and marks its session as updated
+	AppendMessage(ctx context.Context, message *Message) error
+
+	// AppendMessages stores messages of the session sessionID in one transaction
+	// and marks the session as updated. Either all messages are stored or none.
+	AppendMessages(ctx context.Context, sessionID uuid.UUID, messages []*Message) error
+
+	// GetMessage returns the message with id, or ErrMessageNotFound
+	GetMessage(ctx context.Context, id uuid.UUID) (*Message, error)
+
+	// FindMessage returns the latest message stored for the Telegram message
+	// chatID/telegramMessageID, or ErrMessageNotFound
+	FindMessage(ctx context.Context, chatID int64, telegramMessageID int) (*Message, error)
+
+	// ListMessages returns the latest limit messages of a session, oldest first
+	ListMessages(ctx context.Context, sessionID uuid.UUID, limit int) ([]*Message, error)
+
+	// UpdateMessageContent replaces the content of the user message sent as chatID/telegramMessageID,
+	// marks its session as updated and returns the updated message
+	UpdateMessageContent(ctx context.Context, chatID int64, telegramMessageID int, content string, editedAt time.Time) (*Message, error)
+
+	// ReviseMessage replaces the content of the message with id, keeping the
+	// earlier content as a version, marks its session as updated and returns the revised message
+	ReviseMessage(ctx context.Context, id uuid.UUID, content string, revisedAt time.Time) (*Message, error)
+
+	// ListMessageVersions returns the versions of the message with id, oldest
+	// first; messages never revised have none
+	ListMessageVersions(ctx context.Context, id uuid.UUID) ([]*MessageVersion, error)
+
+	// CountMessages returns the number of messages in a session
+	CountMessages(ctx context.Context, sessionID uuid.UUID) (int, error)
+}
+
+// MessageManager handles conversation history business logic
+type MessageManager struct {
+	store  MessageStore
+	events events.Publisher // nil publishes nothing
+}
+
+// NewMessageManager creates a new message manager
+func NewMessageManager(store MessageStore) *MessageManager {
+	return &MessageManager{store: store}
+}
+
+// SetEvents publishes message stored events to publisher
+func (m *MessageManager) SetEvents(publisher events.Publisher) {
+	m.events = publisher
+}
+
+// AddMessage appends a message to its session's history
+func (m *MessageManager) AddMessage(ctx context.Context, message *Message) error {
+	if err := m.store.AppendMessage(ctx, message); err != nil {
+		return fmt.Errorf("failed to add message: %w", err)
+	}
+
+	publish(ctx, m.events, events.Event{
+		Type:      events.MessageStored,
+		UserID:    message.UserID,
+		SessionID: message.SessionID.String(),
+		Data: map[string]any{
+			"message_id": message.ID.String(),
+			"role":       message.Role,
+			"content":    message.Content,
+		},
+	})
+	return nil
+}
+
+// AddMessages appends several messages to the history of a session at once,
+// such as the parts of an album or imported conversations
+func (m *MessageManager) AddMessages(ctx context.Context, sessionID uuid.UUID, messages []*Message) error {
+	if err := m.store.AppendMessages(ctx, sessionID, messages); err != nil {
+		return fmt.Errorf("failed to add messages: %w", err)
+	}
+
+	for _, message := range messages {
+		publish(ctx, m.events, events.Event{
+			Type:      events.MessageStored,
+			UserID:    message.UserID,
+			SessionID: message.SessionID.String(),
+			Data: map[string]any{
+				"message_id": message.ID.String(),
+				"role":       message.Role,
+				"content":    message.Content,
+			},
+		})
+	}
+	return nil
+}
+
+// History returns the latest limit messages of a session, oldest first
+func (m *MessageManager) History(ctx context.Context, sessionID uuid.UUID, limit int) ([]*Message, error) {
+	messages, err := m.store.ListMessages(ctx, sessionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	return messages, nil
+}
+
+// GetMessage returns a stored message by ID.
+// It returns ErrMessageNotFound when the message does not exist.
+func (m *MessageManager) GetMessage(ctx context.Context, id uuid.UUID) (*Message, error) {
+	message, err := m.store.GetMessage(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	return message, nil
+}
+
+// FindMessage returns the latest message stored for a Telegram message.
+// It returns ErrMessageNotFound when the message was never stored.
+func (m *MessageManager) FindMessage(ctx context.Context, chatID int64, telegramMessageID int) (*Message, error) {
+	message, err := m.store.FindMessage(ctx, chatID, telegramMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message: %w", err)
+	}
+	return message, nil
+}
+
+// EditMessage applies an edit of a Telegram message to the stored user message.
+// It returns ErrMessageNotFound when the message was never stored.
+func (m *MessageManager) EditMessage(ctx context.Context, chatID int64, telegramMessageID int, content string, editedAt time.Time) (*Message, error) {
+	message, err := m.store.UpdateMessageContent(ctx, chatID, telegramMessageID, content, editedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+	return message, nil
+}
+
+// ReviseMessage replaces the content of a stored message, such as a
+// regenerated assistant reply. Both contents are kept as versions.
+// It returns ErrMessageNotFound when the message does not exist.
+func (m *MessageManager) ReviseMessage(ctx context.Context, id uuid.UUID, content string, revisedAt time.Time) (*Message, error) {
+	message, err := m.store.ReviseMessage(ctx, id, content, revisedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revise message: %w", err)
+	}
+	return message, nil
+}
+
+// Versions returns the contents a message had, oldest first
+func (m *MessageManager) Versions(ctx context.Context, id uuid.UUID) ([]*MessageVersion, error) {
+	versions, err := m.store.ListMessageVersions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message versions: %w", err)
+	}
+	return versions, nil
+}
@@ -0,0 +1,287 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteStore_Messages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	mgr := NewManager(store)
+	messageMgr := NewMessageManager(store)
+
+	sess, err := mgr.CreateSession(ctx, 1, "history")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		message := NewMessage(sess.ID, 1, RoleUser, fmt.Sprintf("message %d", i))
+		message.CreatedAt = base.Add(time.Duration(i) * time.Second)
+		if err := messageMgr.AddMessage(ctx, message); err != nil {
+			t.Fatalf("Failed to add message %d: %v", i, err)
+		}
+	}
+
+	fileID := uuid.New()
+	contextMessage := NewMessage(sess.ID, 1, RoleContext, "[notes.txt]\nhello")
+	contextMessage.FileID = fileID
+	contextMessage.CreatedAt = base.Add(10 * time.Second)
+	if err := messageMgr.AddMessage(ctx, contextMessage); err != nil {
+		t.Fatalf("Failed to add context message: %v", err)
+	}
+
+	history, err := messageMgr.History(ctx, sess.ID, 3)
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(history))
+	}
+	if history[0].Content != "message 3" || history[2].Role != RoleContext || history[2].FileID != fileID {
+		t.Errorf("Expected latest messages oldest first, got %q, %q", history[0].Content, history[2].Content)
+	}
+
+	got, err := messageMgr.GetMessage(ctx, contextMessage.ID)
+	if err != nil || got.Content != contextMessage.Content || got.SessionID != sess.ID {
+		t.Errorf("Expected the context message by ID, got %+v (err=%v)", got, err)
+	}
+	if _, err := messageMgr.GetMessage(ctx, uuid.New()); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound for an unknown ID, got %v", err)
+	}
+
+	count, err := store.CountMessages(ctx, sess.ID)
+	if err != nil || count != 6 {
+		t.Errorf("Expected 6 messages, got %d (err=%v)", count, err)
+	}
+
+	updated, err := store.Get(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if !updated.UpdatedAt.Equal(contextMessage.CreatedAt) {
+		t.Errorf("Expected session updated_at to follow the latest message, got %v", updated.UpdatedAt)
+	}
+
+	if err := store.AppendMessage(ctx, NewMessage(uuid.New(), 1, RoleUser, "orphan")); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound for unknown session, got %v", err)
+	}
+
+	// Messages are removed with their session
+	if _, err := mgr.DeleteSession(ctx, 1, sess.ID); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if count, _ := store.CountMessages(ctx, sess.ID); count != 0 {
+		t.Errorf("Expected messages to be deleted with the session, got %d", count)
+	}
+}
+
+func TestSQLiteStore_UpdateMessageContent(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	mgr := NewManager(store)
+	messageMgr := NewMessageManager(store)
+
+	sess, err := mgr.CreateSession(ctx, 1, "edits")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	original := NewMessage(sess.ID, 1, RoleUser, "helo")
+	original.ChatID = 100
+	original.TelegramMessageID = 7
+	if err := messageMgr.AddMessage(ctx, original); err != nil {
+		t.Fatalf("Failed to add message: %v", err)
+	}
+
+	// A context message extracted from the same Telegram message must not be edited
+	extracted := NewMessage(sess.ID, 1, RoleContext, "[doc.txt]\ntext")
+	extracted.ChatID = 100
+	extracted.TelegramMessageID = 7
+	if err := messageMgr.AddMessage(ctx, extracted); err != nil {
+		t.Fatalf("Failed to add context message: %v", err)
+	}
+
+	editedAt := time.Now().Add(time.Minute)
+	edited, err := messageMgr.EditMessage(ctx, 100, 7, "hello", editedAt)
+	if err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if edited.ID != original.ID || edited.Content != "hello" || edited.EditedAt == nil {
+		t.Errorf("Unexpected edited message: %+v", edited)
+	}
+
+	history, err := messageMgr.History(ctx, sess.ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(history) != 2 || history[0].Content != "hello" || history[1].Content != "[doc.txt]\ntext" {
+		t.Errorf("Expected only the user message to change, got %q / %q", history[0].Content, history[1].Content)
+	}
+	if history[0].EditedAt == nil || !history[0].EditedAt.Equal(editedAt) {
+		t.Errorf("Expected edited_at to be stored, got %v", history[0].EditedAt)
+	}
+
+	updated, err := store.Get(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if !updated.UpdatedAt.Equal(editedAt) {
+		t.Errorf("Expected session to be marked updated, got %v", updated.UpdatedAt)
+	}
+
+	if _, err := messageMgr.EditMessage(ctx, 100, 8, "unknown", editedAt); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+	if _, err := messageMgr.EditMessage(ctx, 101, 7, "other chat", editedAt); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound for another chat, got %v", err)
+	}
+}
+
+func TestSQLiteStore_FindMessage(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	messageMgr := NewMessageManager(store)
+
+	sess, err := NewManager(store).CreateSession(ctx, 1, "lookups")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	for _, message := range []*Message{
+		NewMessage(sess.ID, 1, RoleUser, "caption"),
+		NewMessage(sess.ID, 1, RoleContext, "[doc.txt]\ntext"),
+	} {
+		message.ChatID = 100
+		message.TelegramMessageID = 7
+		if err := messageMgr.AddMessage(ctx, message); err != nil {
+			t.Fatalf("Failed to add message: %v", err)
+		}
+	}
+
+	found, err := messageMgr.FindMessage(ctx, 100, 7)
+	if err != nil {
+		t.Fatalf("Failed to find message: %v", err)
+	}
+	if found.Role != RoleContext || found.Content != "[doc.txt]\ntext" {
+		t.Errorf("Expected the latest message stored for the Telegram message, got %+v", found)
+	}
+
+	if _, err := messageMgr.FindMessage(ctx, 101, 7); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound for another chat, got %v", err)
+	}
+}
+
+func TestSQLiteStore_AppendMessages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	messageMgr := NewMessageManager(store)
+
+	sess, err := NewManager(store).CreateSession(ctx, 1, "album")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// More than one INSERT worth of rows
+	messages := make([]*Message, appendMessagesBatchSize+3)
+	base := time.Now().Add(time.Hour)
+	for i := range messages {
+		messages[i] = NewMessage(sess.ID, 1, RoleContext, fmt.Sprintf("part %d", i))
+		messages[i].CreatedAt = base.Add(time.Duration(i) * time.Second)
+	}
+	if err := messageMgr.AddMessages(ctx, sess.ID, messages); err != nil {
+		t.Fatalf("Failed to add messages: %v", err)
+	}
+
+	count, err := store.CountMessages(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to count messages: %v", err)
+	}
+	if count != len(messages) {
+		t.Errorf("Expected %d messages, got %d", len(messages), count)
+	}
+	history, err := messageMgr.History(ctx, sess.ID, 2)
+	if err != nil {
+		t.Fatalf("Failed to list messages: %v", err)
+	}
+	if len(history) != 2 || history[1].Content != messages[len(messages)-1].Content {
+		t.Errorf("Expected the last part last in the history, got %+v", history)
+	}
+	updated, err := store.Get(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if !updated.UpdatedAt.Equal(messages[len(messages)-1].CreatedAt) {
+		t.Errorf("Expected the session updated at the last message, got %v", updated.UpdatedAt)
+	}
+
+	// A message of another session stores nothing
+	mixed := []*Message{NewMessage(sess.ID, 1, RoleUser, "ok"), NewMessage(uuid.New(), 1, RoleUser, "stray")}
+	if err := messageMgr.AddMessages(ctx, sess.ID, mixed); err == nil {
+		t.Error("Expected an error for a message of another session")
+	}
+	if err := messageMgr.AddMessages(ctx, uuid.New(), nil); err != nil {
+		t.Errorf("Expected no messages to be a no-op, got %v", err)
+	}
+	orphan := NewMessage(uuid.New(), 1, RoleUser, "orphan")
+	if err := messageMgr.AddMessages(ctx, orphan.SessionID, []*Message{orphan}); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+	if count, _ := store.CountMessages(ctx, sess.ID); count != len(messages) {
+		t.Errorf("Expected the failed batches to store nothing, got %d messages", count-len(messages))
+	}
+}
+
+func TestMessageManager_ReviseMessage(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	mgr := NewManager(store)
+	messageMgr := NewMessageManager(store)
+
+	sess, err := mgr.CreateSession(ctx, 1, "regenerate")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	answer := NewMessage(sess.ID, 1, RoleAssistant, "first answer")
+	if err := messageMgr.AddMessage(ctx, answer); err != nil {
+		t.Fatalf("Failed to add message: %v", err)
+	}
+
+	if versions, err := messageMgr.Versions(ctx, answer.ID); err != nil || len(versions) != 0 {
+		t.Fatalf("Expected no versions before a revision, got %d (err=%v)", len(versions), err)
+	}
+
+	revisedAt := time.Now().Add(time.Minute)
+	for _, content := range []string{"second answer", "third answer"} {
+		if _, err := messageMgr.ReviseMessage(ctx, answer.ID, content, revisedAt); err != nil {
+			t.Fatalf("ReviseMessage failed: %v", err)
+		}
+	}
+
+	got, err := messageMgr.GetMessage(ctx, answer.ID)
+	if err != nil {
+		t.Fatalf("GetMessage failed: %v", err)
+	}
+	if got.Content != "third answer" || got.EditedAt == nil {
+		t.Errorf("Expected the latest revision stored, got %+v", got)
+	}
+
+	versions, err := messageMgr.Versions(ctx, answer.ID)
+	if err != nil {
+		t.Fatalf("Versions failed: %v", err)
+	}
+	if len(versions) != 3 || versions[0].Content != "first answer" || versions[2].Content != "third answer" || versions[2].Version != 3 {
+		t.Errorf("Expected every content kept in order, got %+v", versions)
+	}
+
+	if _, err := messageMgr.ReviseMessage(ctx, uuid.New(), "x", revisedAt); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound for an unknown message, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// TopicBinding is the session a user has active in a forum topic
+type TopicBinding struct {
+	UserID    int64     `json:"user_id"`
+	Topic     Topic     `json:"topic"`
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// BusinessChatBinding is the session a business account has active in one of its chats
+type BusinessChatBinding struct {
+	UserID    int64        `json:"user_id"`
+	Chat      BusinessChat `json:"chat"`
+	SessionID uuid.UUID    `json:"session_id"`
+}
+
+// MigrationStore defines the interface for reading and restoring the rows a
+// data migration copies that the other stores only reach by their keys
+type MigrationStore interface {
+	// ListTopicBindings returns the forum topic bindings of a user
+	ListTopicBindings(ctx context.Context, userID int64) ([]*TopicBinding, error)
+
+	// ListBusinessChatBindings returns the business chat bindings of a user
+	ListBusinessChatBindings(ctx context.Context, userID int64) ([]*BusinessChatBinding, error)
+
+	// ListUserSentMessages returns the messages sent to a user with pagination, oldest first
+	ListUserSentMessages(ctx context.Context, userID int64, offset, limit int) ([]*SentMessage, error)
+
+	// ListSessionMessageVersions returns the versions of the messages of a
+	// session, ordered by message and version
+	ListSessionMessageVersions(ctx context.Context, sessionID uuid.UUID) ([]*MessageVersion, error)
+
+	// ImportMessageVersions stores versions as they are, in one transaction.
+	// Versions already stored are skipped.
+	ImportMessageVersions(ctx context.Context, versions []*MessageVersion) error
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Directions of moderated content
+const (
+	DirectionInbound  = "inbound"  // a message from a user
+	DirectionOutbound = "outbound" // an assistant reply
+)
+
+// FlaggedContent is text the content filter matched, kept for administrators to review
+type FlaggedContent struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	ChatID       int64     `json:"chat_id"`
+	SessionID    uuid.UUID `json:"session_id"`              // uuid.Nil for messages blocked before reaching a session
+	SessionTitle string    `json:"session_title,omitempty"` // filled by ListOpenFlaggedContent while the session exists
+	Direction    string    `json:"direction"`               // DirectionInbound or DirectionOutbound
+	Action       string    `json:"action"`                  // what the filter did: block, redact or flag
+	Rules        []string  `json:"rules"`                   // the keywords and patterns that matched
+	Text         string    `json:"text"`                    // the original, unredacted text
+	CreatedAt    time.Time `json:"created_at"`
+	ReviewedAt   time.Time `json:"reviewed_at,omitempty"` // zero while open
+	ReviewedBy   int64     `json:"reviewed_by,omitempty"`
+}
+
+// ErrFlaggedContentNotFound is returned when no open flagged content matches an ID
+var ErrFlaggedContentNotFound = fmt.Errorf("flagged content not found")
+
+// FlaggedContentStore defines the interface for the content review queue
+type FlaggedContentStore interface {
+	// AddFlaggedContent stores flagged content and sets its ID
+	AddFlaggedContent(ctx context.Context, content *FlaggedContent) error
+
+	// ListOpenFlaggedContent returns unreviewed flagged content, oldest first
+	ListOpenFlaggedContent(ctx context.Context, offset, limit int) ([]*FlaggedContent, error)
+
+	// CountOpenFlaggedContent returns the number of unreviewed flagged content rows
+	CountOpenFlaggedContent(ctx context.Context) (int, error)
+
+	// ReviewFlaggedContent marks open flagged content as reviewed by an administrator.
+	// It returns ErrFlaggedContentNotFound when id is unknown or already reviewed.
+	ReviewFlaggedContent(ctx context.Context, id, reviewedBy int64, reviewedAt time.Time) error
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_FlaggedContent(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	linked := NewSession(1, "Linked")
+	if err := store.Create(ctx, linked); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	redacted := &FlaggedContent{UserID: 1, ChatID: 10, SessionID: linked.ID, Direction: DirectionOutbound,
+		Action: "redact", Rules: []string{"darn", `\d{16}`}, Text: "darn", CreatedAt: time.Now()}
+	blocked := &FlaggedContent{UserID: 2, ChatID: 20, Direction: DirectionInbound, Action: "block",
+		Rules: []string{"darn"}, Text: "darn it", CreatedAt: time.Now()}
+	for _, content := range []*FlaggedContent{redacted, blocked} {
+		if err := store.AddFlaggedContent(ctx, content); err != nil {
+			t.Fatalf("AddFlaggedContent failed: %v", err)
+		}
+	}
+
+	open, err := store.ListOpenFlaggedContent(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ListOpenFlaggedContent failed: %v", err)
+	}
+	if len(open) != 2 || open[0].SessionTitle != "Linked" || open[1].SessionTitle != "" || open[1].ChatID != 20 {
+		t.Fatalf("Unexpected open flagged content %+v %+v", open[0], open[1])
+	}
+	if !reflect.DeepEqual(open[0].Rules, redacted.Rules) || open[0].Direction != DirectionOutbound {
+		t.Errorf("Expected rules %q outbound, got %q %s", redacted.Rules, open[0].Rules, open[0].Direction)
+	}
+
+	if err := store.ReviewFlaggedContent(ctx, blocked.ID, 99, time.Now()); err != nil {
+		t.Fatalf("ReviewFlaggedContent failed: %v", err)
+	}
+	if err := store.ReviewFlaggedContent(ctx, blocked.ID, 99, time.Now()); !errors.Is(err, ErrFlaggedContentNotFound) {
+		t.Errorf("Expected ErrFlaggedContentNotFound reviewing twice, got %v", err)
+	}
+
+	count, err := store.CountOpenFlaggedContent(ctx)
+	if err != nil {
+		t.Fatalf("CountOpenFlaggedContent failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 open flagged content, got %d", count)
+	}
+}
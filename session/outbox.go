@@ -0,0 +1,49 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxMessage is a reply waiting to be delivered to a user. Replies are
+// written to the outbox before they are sent, so one whose delivery is cut
+// short by a crash or a failed request is retried instead of lost.
+type OutboxMessage struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	ChatID    int64  `json:"chat_id"`
+	ThreadID  int    `json:"thread_id,omitempty"` // forum topic of the reply, if any
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+	// ReplyMarkup is the JSON-encoded keyboard sent with the message, if any,
+	// and ReplyMarkupType names its kind, e.g. "inline_keyboard"; older rows
+	// without a type hold an inline keyboard
+	ReplyMarkup     string `json:"reply_markup,omitempty"`
+	ReplyMarkupType string `json:"reply_markup_type,omitempty"`
+	// Params is the whole JSON-encoded sendMessage request, so a retry sends the
+	// same message; older rows without it are rebuilt from the fields above
+	Params        string    `json:"params,omitempty"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// OutboxStore defines the interface for outbox persistence
+type OutboxStore interface {
+	// EnqueueOutbox adds a message to the outbox and sets its ID
+	EnqueueOutbox(ctx context.Context, message *OutboxMessage) error
+
+	// ListDueOutbox returns up to limit messages whose next attempt is at or before now, oldest first
+	ListDueOutbox(ctx context.Context, now time.Time, limit int) ([]*OutboxMessage, error)
+
+	// RescheduleOutbox records a failed attempt and when to try again
+	RescheduleOutbox(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error
+
+	// DeleteOutbox removes a delivered or abandoned message from the outbox
+	DeleteOutbox(ctx context.Context, id int64) error
+
+	// InTx runs fn in a transaction that every store call made with fn's context
+	// joins, so a reply is queued together with the write it confirms
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,26 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Preferences holds per-user settings chosen with bot commands
+type Preferences struct {
+	UserID    int64     `json:"user_id"`
+	Language  string    `json:"language"` // empty follows the Telegram client language
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ErrPreferencesNotFound is returned when a user has not changed any setting
+var ErrPreferencesNotFound = fmt.Errorf("preferences not found")
+
+// PreferenceStore defines the interface for user preference persistence
+type PreferenceStore interface {
+	// GetPreferences returns the preferences of a user
+	GetPreferences(ctx context.Context, userID int64) (*Preferences, error)
+
+	// SavePreferences creates or replaces the preferences of a user
+	SavePreferences(ctx context.Context, prefs *Preferences) error
+}
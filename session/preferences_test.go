@@ -0,0 +1,39 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_Preferences(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if _, err := store.GetPreferences(ctx, 1); !errors.Is(err, ErrPreferencesNotFound) {
+		t.Fatalf("expected ErrPreferencesNotFound, got %v", err)
+	}
+
+	if err := store.SavePreferences(ctx, &Preferences{UserID: 1, Language: "zh", UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("SavePreferences failed: %v", err)
+	}
+	prefs, err := store.GetPreferences(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetPreferences failed: %v", err)
+	}
+	if prefs.Language != "zh" {
+		t.Errorf("expected language zh, got %q", prefs.Language)
+	}
+
+	if err := store.SavePreferences(ctx, &Preferences{UserID: 1, Language: "", UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("SavePreferences (replace) failed: %v", err)
+	}
+	prefs, err = store.GetPreferences(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetPreferences failed: %v", err)
+	}
+	if prefs.Language != "" {
+		t.Errorf("expected language override to be cleared, got %q", prefs.Language)
+	}
+}
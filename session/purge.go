@@ -0,0 +1,24 @@
+package session
+
+import "context"
+
+// PurgeReport counts the rows removed when a user's data is purged
+type PurgeReport struct {
+	Sessions int
+	Messages int
+	Files    int
+	Other    int // session bindings, preferences, referral, pending conversation and statistics
+
+	// StorageKeys are the stored objects of the deleted files; the caller removes
+	// them from the storage backend
+	StorageKeys []string
+
+	// Bytes is the total size of the deleted files
+	Bytes int64
+}
+
+// PurgeStore defines the interface for erasing everything stored about a user
+type PurgeStore interface {
+	// PurgeUser deletes all rows belonging to userID in one transaction
+	PurgeUser(ctx context.Context, userID int64) (*PurgeReport, error)
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_PurgeUser(t *testing.T) {
+	store := newTestStore(t)
+	manager := NewManager(store)
+	ctx := context.Background()
+
+	for _, userID := range []int64{1, 2} {
+		sess, err := manager.CreateSession(ctx, userID, "hello")
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		if err := store.AppendMessage(ctx, NewMessage(sess.ID, userID, RoleUser, "hi")); err != nil {
+			t.Fatalf("AppendMessage failed: %v", err)
+		}
+		if err := store.CreateFile(ctx, NewFile(userID, "document", "a.txt", "key", 1)); err != nil {
+			t.Fatalf("CreateFile failed: %v", err)
+		}
+		if err := store.SavePreferences(ctx, &Preferences{UserID: userID, Language: "en", UpdatedAt: time.Now()}); err != nil {
+			t.Fatalf("SavePreferences failed: %v", err)
+		}
+	}
+
+	report, err := store.PurgeUser(ctx, 1)
+	if err != nil {
+		t.Fatalf("PurgeUser failed: %v", err)
+	}
+	if report.Sessions != 1 || report.Messages != 1 || report.Files != 1 || len(report.StorageKeys) != 1 || report.Bytes != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	// active session, preference and statistics rows
+	if report.Other != 3 {
+		t.Errorf("expected 3 other records, got %d", report.Other)
+	}
+
+	if count, _ := store.CountByUser(ctx, 2); count != 1 {
+		t.Errorf("expected the other user to keep their session, got %d", count)
+	}
+	if again, err := store.PurgeUser(ctx, 1); err != nil || again.Sessions+again.Messages+again.Files+again.Other != 0 {
+		t.Errorf("expected nothing left to purge, got %+v, %v", again, err)
+	}
+}
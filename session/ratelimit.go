@@ -0,0 +1,28 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RateLimitBucket is the persisted state of a user's inbound message token bucket
+type RateLimitBucket struct {
+	UserID    int64     `json:"user_id"`
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"` // when Tokens was last computed
+	Warned    bool      `json:"warned"`     // the user was told to slow down since their last accepted message
+}
+
+// ErrRateLimitBucketNotFound is returned when no bucket was saved for a user
+var ErrRateLimitBucketNotFound = fmt.Errorf("rate limit bucket not found")
+
+// RateLimitStore defines the interface for persisting rate limit buckets, so
+// throttling survives restarts and buckets evicted from memory
+type RateLimitStore interface {
+	// GetRateLimitBucket returns the saved bucket of a user
+	GetRateLimitBucket(ctx context.Context, userID int64) (*RateLimitBucket, error)
+
+	// SaveRateLimitBuckets creates or replaces buckets in one transaction
+	SaveRateLimitBuckets(ctx context.Context, buckets []*RateLimitBucket) error
+}
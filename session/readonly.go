@@ -0,0 +1,16 @@
+package session
+
+import "errors"
+
+// ErrReadOnly is returned by writes while the store is read-only
+var ErrReadOnly = errors.New("store is read-only for maintenance")
+
+// ReadOnlyStore defines the interface for refusing writes during maintenance,
+// such as migrations and backups
+type ReadOnlyStore interface {
+	// SetReadOnly makes every later write fail with ErrReadOnly, or allows writes again
+	SetReadOnly(readOnly bool)
+
+	// ReadOnly reports whether writes are refused
+	ReadOnly() bool
+}
@@ -0,0 +1,23 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Referral records the deep-link referral code a user first started the bot with
+type Referral struct {
+	UserID     int64     `json:"user_id"`
+	Code       string    `json:"code"`
+	ReferredAt time.Time `json:"referred_at"`
+}
+
+// ReferralStore defines the interface for referral persistence
+type ReferralStore interface {
+	// RecordReferral stores the referral of a user unless one is already recorded.
+	// It reports whether the referral was newly recorded.
+	RecordReferral(ctx context.Context, referral *Referral) (bool, error)
+
+	// CountReferrals returns the number of users referred with code
+	CountReferrals(ctx context.Context, code string) (int, error)
+}
@@ -0,0 +1,49 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_RecordReferral(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	recorded, err := store.RecordReferral(ctx, &Referral{UserID: 1, Code: "friends", ReferredAt: time.Now()})
+	if err != nil {
+		t.Fatalf("RecordReferral failed: %v", err)
+	}
+	if !recorded {
+		t.Error("Expected first referral to be recorded")
+	}
+
+	// The first referral of a user wins
+	recorded, err = store.RecordReferral(ctx, &Referral{UserID: 1, Code: "other", ReferredAt: time.Now()})
+	if err != nil {
+		t.Fatalf("RecordReferral failed: %v", err)
+	}
+	if recorded {
+		t.Error("Expected second referral of the same user to be ignored")
+	}
+
+	if _, err := store.RecordReferral(ctx, &Referral{UserID: 2, Code: "friends", ReferredAt: time.Now()}); err != nil {
+		t.Fatalf("RecordReferral failed: %v", err)
+	}
+
+	count, err := store.CountReferrals(ctx, "friends")
+	if err != nil {
+		t.Fatalf("CountReferrals failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 referrals for 'friends', got %d", count)
+	}
+
+	count, err = store.CountReferrals(ctx, "other")
+	if err != nil {
+		t.Fatalf("CountReferrals failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected 0 referrals for 'other', got %d", count)
+	}
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// UserRole is what a user may do with the bot. Each role includes the
+// permissions of the roles below it: admin > moderator > user.
+type UserRole string
+
+// User roles, from most to least privileged
+const (
+	UserRoleAdmin     UserRole = "admin"
+	UserRoleModerator UserRole = "moderator"
+	UserRoleUser      UserRole = "user"
+)
+
+// userRoleRanks orders the roles; a higher rank includes the lower ones
+var userRoleRanks = map[UserRole]int{
+	UserRoleUser:      0,
+	UserRoleModerator: 1,
+	UserRoleAdmin:     2,
+}
+
+// ErrInvalidRole is returned for a role name other than admin, moderator or user
+var ErrInvalidRole = fmt.Errorf("role must be admin, moderator or user")
+
+// ParseUserRole returns the role named s
+func ParseUserRole(s string) (UserRole, error) {
+	role := UserRole(s)
+	if _, ok := userRoleRanks[role]; !ok {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// Includes reports whether r grants at least the permissions of other
+func (r UserRole) Includes(other UserRole) bool {
+	return userRoleRanks[r] >= userRoleRanks[other]
+}
+
+// RoleAssignment is a role given to a user
+type RoleAssignment struct {
+	UserID    int64     `json:"user_id"`
+	Role      UserRole  `json:"role"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RoleStore defines the interface for role persistence. Users without an
+// assignment have UserRoleUser. Assignments survive a purge of the user's
+// data, as they were given by an administrator.
+type RoleStore interface {
+	// GetUserRole returns the role of userID, UserRoleUser when none is assigned
+	GetUserRole(ctx context.Context, userID int64) (UserRole, error)
+
+	// SetUserRole assigns role to userID; UserRoleUser removes the assignment
+	SetUserRole(ctx context.Context, userID int64, role UserRole) error
+
+	// ListUserRoles returns every assignment ordered by user ID
+	ListUserRoles(ctx context.Context) ([]*RoleAssignment, error)
+}
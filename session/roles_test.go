@@ -0,0 +1,55 @@
+package session
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUserRoleIncludes(t *testing.T) {
+	if !UserRoleAdmin.Includes(UserRoleModerator) || !UserRoleModerator.Includes(UserRoleUser) || !UserRoleUser.Includes(UserRoleUser) {
+		t.Error("expected each role to include the roles below it")
+	}
+	if UserRoleModerator.Includes(UserRoleAdmin) || UserRoleUser.Includes(UserRoleModerator) {
+		t.Error("expected no role to include the roles above it")
+	}
+	if _, err := ParseUserRole("owner"); err != ErrInvalidRole {
+		t.Errorf("expected ErrInvalidRole, got %v", err)
+	}
+}
+
+func TestSQLiteStore_UserRoles(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if role, err := store.GetUserRole(ctx, 1); err != nil || role != UserRoleUser {
+		t.Fatalf("expected user without assignment, got %q err=%v", role, err)
+	}
+
+	if err := store.SetUserRole(ctx, 2, UserRoleAdmin); err != nil {
+		t.Fatalf("SetUserRole failed: %v", err)
+	}
+	if err := store.SetUserRole(ctx, 1, UserRoleModerator); err != nil {
+		t.Fatalf("SetUserRole failed: %v", err)
+	}
+	if role, err := store.GetUserRole(ctx, 1); err != nil || role != UserRoleModerator {
+		t.Errorf("expected moderator, got %q err=%v", role, err)
+	}
+
+	assignments, err := store.ListUserRoles(ctx)
+	if err != nil {
+		t.Fatalf("ListUserRoles failed: %v", err)
+	}
+	if len(assignments) != 2 || assignments[0].UserID != 1 || assignments[1].Role != UserRoleAdmin {
+		t.Errorf("unexpected assignments %+v", assignments)
+	}
+
+	if err := store.SetUserRole(ctx, 1, UserRoleUser); err != nil {
+		t.Fatalf("SetUserRole failed: %v", err)
+	}
+	if assignments, _ := store.ListUserRoles(ctx); len(assignments) != 1 {
+		t.Errorf("expected the user role to remove the assignment, got %+v", assignments)
+	}
+	if err := store.SetUserRole(ctx, 1, "owner"); err != ErrInvalidRole {
+		t.Errorf("expected ErrInvalidRole, got %v", err)
+	}
+}
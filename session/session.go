@@ -3,11 +3,14 @@ package session
 import (
 	"context"
 	"errors"
+	"expvar"
 	"fmt"
 	"strings"
 	"time"
 	"unicode/utf8"
 
+	"tg-bot-demo/events"
+
 	"github.com/google/uuid"
 )
 
@@ -23,6 +26,20 @@ type Session struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	LastMessage string    `json:"last_message"`
+	Version     int       `json:"version"` // bumped on every write, see Store.Update
+
+	// MessageCount and FileCount are maintained by the store as messages and
+	// files are added to or removed from the session
+	MessageCount int `json:"message_count"`
+	FileCount    int `json:"file_count"`
+
+	// UnreadCount is the number of user messages without an assistant reply yet,
+	// also maintained by the store
+	UnreadCount int `json:"unread_count"`
+
+	// Preset names the assistant preset of the session, such as "formal"; empty
+	// uses the assistant's defaults
+	Preset string `json:"preset"`
 }
 
 // NewSession creates a new session with generated UUID
@@ -77,18 +94,29 @@ type Store interface {
 	// Get retrieves a session by ID
 	Get(ctx context.Context, id uuid.UUID) (*Session, error)
 
-	// Update modifies an existing session
+	// Update modifies an existing session. It returns ErrConflict when the session
+	// changed since it was read, i.e. its version no longer matches.
 	Update(ctx context.Context, session *Session) error
 
+	// TouchSession records lastMessage as the latest message of a session and sets
+	// its updated_at to now. It returns ErrSessionNotFound for an unknown session.
+	TouchSession(ctx context.Context, id uuid.UUID, lastMessage string) error
+
 	// Delete removes a session
 	Delete(ctx context.Context, id uuid.UUID) error
 
-	// ListByUser returns sessions for a specific user with pagination
+	// ListByUser returns sessions for a specific user with pagination, without archived sessions
 	ListByUser(ctx context.Context, userID int64, offset, limit int) ([]*Session, error)
 
-	// CountByUser returns total number of sessions for a user
+	// SearchByUser returns a user's sessions whose title or last message contains query
+	SearchByUser(ctx context.Context, userID int64, query string, offset, limit int) ([]*Session, error)
+
+	// CountByUser returns total number of sessions for a user, without archived sessions
 	CountByUser(ctx context.Context, userID int64) (int, error)
 
+	// Unarchive lists an archived session again; other sessions are left as they are
+	Unarchive(ctx context.Context, id uuid.UUID) error
+
 	// GetActiveSession returns the current active session for a user
 	GetActiveSession(ctx context.Context, userID int64) (*Session, error)
 
@@ -97,26 +125,107 @@ type Store interface {
 
 	// ClearActiveSession removes the active session binding for a user
 	ClearActiveSession(ctx context.Context, userID int64) error
+
+	// GetTopicSession returns the active session of a user in a forum topic
+	GetTopicSession(ctx context.Context, userID int64, topic Topic) (*Session, error)
+
+	// SetTopicSession sets the active session of a user in a forum topic
+	SetTopicSession(ctx context.Context, userID int64, topic Topic, sessionID uuid.UUID) error
+
+	// ClearTopicSession removes the active session binding of a user in a forum topic
+	ClearTopicSession(ctx context.Context, userID int64, topic Topic) error
+
+	// GetBusinessChatSession returns the active session of a business account in one of its chats
+	GetBusinessChatSession(ctx context.Context, userID int64, chat BusinessChat) (*Session, error)
+
+	// SetBusinessChatSession sets the active session of a business account in one of its chats
+	SetBusinessChatSession(ctx context.Context, userID int64, chat BusinessChat, sessionID uuid.UUID) error
+
+	// ClearBusinessChatSession removes the active session binding of a business account in one of its chats
+	ClearBusinessChatSession(ctx context.Context, userID int64, chat BusinessChat) error
+
+	// TransferSession gives a session with its messages and files to toUserID and
+	// unbinds it from the chats of its previous owner, in one transaction
+	TransferSession(ctx context.Context, id uuid.UUID, toUserID int64) (*TransferReport, error)
+
+	// WithTx runs fn in a transaction: every call on tx commits together, or none
+	// does when fn returns an error
+	WithTx(ctx context.Context, fn func(tx Store) error) error
 }
 
 // Error types
 var (
 	ErrSessionNotFound = fmt.Errorf("session not found")
 	ErrUnauthorized    = fmt.Errorf("unauthorized access to session")
+	ErrEmptyTitle      = fmt.Errorf("session title must not be empty")
+	ErrConflict        = fmt.Errorf("session was modified concurrently")
 )
 
+// errOperationTimeout is the cause of contexts whose operation timeout ran out
+var errOperationTimeout = errors.New("session operation timed out")
+
+// Metrics published under /debug/vars
+var operationsTimedOut = expvar.NewInt("session_operations_timed_out_total")
+
+// maxUpdateAttempts bounds how often an update is retried after ErrConflict
+const maxUpdateAttempts = 3
+
 // Manager handles session business logic
 type Manager struct {
-	store Store
+	store  Store
+	events events.Publisher // nil publishes nothing
+
+	// locks is shared with the managers of topics and business chats, so
+	// GetOrCreateActiveSession runs one at a time per user across all of them
+	locks *userLocks
+
+	timeout time.Duration // bounds each operation; 0 leaves them unbounded
 }
 
 // NewManager creates a new session manager
 func NewManager(store Store) *Manager {
-	return &Manager{store: store}
+	return &Manager{store: store, locks: newUserLocks()}
+}
+
+// SetEvents publishes session created and closed events to publisher
+func (m *Manager) SetEvents(publisher events.Publisher) {
+	m.events = publisher
+}
+
+// SetOperationTimeout bounds each operation of the manager, store queries
+// included, by timeout. Operations running out of time fail with
+// context.DeadlineExceeded and are counted in session_operations_timed_out_total.
+func (m *Manager) SetOperationTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
+// withTimeout bounds ctx by the operation timeout. The returned function must
+// be called when the operation is done.
+func (m *Manager) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.timeout <= 0 {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, m.timeout, errOperationTimeout)
+	return ctx, func() {
+		if context.Cause(ctx) == errOperationTimeout {
+			operationsTimedOut.Add(1)
+		}
+		cancel()
+	}
+}
+
+// withStore returns a copy of the manager working on store
+func (m *Manager) withStore(store Store) *Manager {
+	copied := *m
+	copied.store = store
+	return &copied
 }
 
 // ListSessions retrieves paginated sessions for a user
 func (m *Manager) ListSessions(ctx context.Context, userID int64, offset, limit int) ([]*Session, bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
 	sessions, err := m.store.ListByUser(ctx, userID, offset, limit)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to list sessions: %w", err)
@@ -132,21 +241,74 @@ func (m *Manager) ListSessions(ctx context.Context, userID int64, offset, limit
 	return sessions, hasMore, nil
 }
 
-// SwitchSession changes the active session for a user
-func (m *Manager) SwitchSession(ctx context.Context, userID int64, sessionID uuid.UUID) (*Session, error) {
-	// Verify ownership
-	session, err := m.store.Get(ctx, sessionID)
+// SearchSessions returns a page of the user's sessions matching query.
+// An empty query lists all sessions. hasMore reports whether another page may follow.
+func (m *Manager) SearchSessions(ctx context.Context, userID int64, query string, offset, limit int) ([]*Session, bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query = strings.TrimSpace(query)
+
+	var sessions []*Session
+	var err error
+	if query == "" {
+		sessions, err = m.store.ListByUser(ctx, userID, offset, limit+1)
+	} else {
+		sessions, err = m.store.SearchByUser(ctx, userID, query, offset, limit+1)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get session: %w", err)
+		return nil, false, fmt.Errorf("failed to search sessions: %w", err)
 	}
 
-	if session.UserID != userID {
-		return nil, ErrUnauthorized
+	if len(sessions) > limit {
+		return sessions[:limit], true, nil
 	}
+	return sessions, false, nil
+}
 
-	// Set as active
-	if err := m.store.SetActiveSession(ctx, userID, sessionID); err != nil {
-		return nil, fmt.Errorf("failed to set active session: %w", err)
+// CountSessions returns the total number of sessions for a user
+func (m *Manager) CountSessions(ctx context.Context, userID int64) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	total, err := m.store.CountByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	return total, nil
+}
+
+// SwitchSession changes the active session for a user
+func (m *Manager) SwitchSession(ctx context.Context, userID int64, sessionID uuid.UUID) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var session *Session
+	err := m.store.WithTx(ctx, func(tx Store) error {
+		// Verify ownership
+		var err error
+		session, err = tx.Get(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+
+		if session.UserID != userID {
+			return ErrUnauthorized
+		}
+
+		// Opening an archived session, e.g. from search results, restores it
+		if err := tx.Unarchive(ctx, sessionID); err != nil {
+			return fmt.Errorf("failed to unarchive session: %w", err)
+		}
+
+		// Set as active
+		if err := tx.SetActiveSession(ctx, userID, sessionID); err != nil {
+			return fmt.Errorf("failed to set active session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return session, nil
@@ -154,45 +316,304 @@ func (m *Manager) SwitchSession(ctx context.Context, userID int64, sessionID uui
 
 // CreateSession creates a new session from a user message
 func (m *Manager) CreateSession(ctx context.Context, userID int64, message string) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
 	session := NewSession(userID, message)
 
-	if err := m.store.Create(ctx, session); err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
-	}
+	err := m.store.WithTx(ctx, func(tx Store) error {
+		if err := tx.Create(ctx, session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
 
-	// Set as active session
-	if err := m.store.SetActiveSession(ctx, userID, session.ID); err != nil {
-		return nil, fmt.Errorf("failed to set active session: %w", err)
+		// Set as active session
+		if err := tx.SetActiveSession(ctx, userID, session.ID); err != nil {
+			return fmt.Errorf("failed to set active session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	publish(ctx, m.events, events.Event{
+		Type:      events.SessionCreated,
+		UserID:    userID,
+		SessionID: session.ID.String(),
+		Data:      map[string]any{"title": session.Title},
+	})
 	return session, nil
 }
 
-// GetOrCreateActiveSession returns the active session or creates a new one
+// GetOrCreateActiveSession returns the active session or creates a new one.
+// Concurrent calls for a user, such as for a burst of messages, create at most
+// one session: they run one at a time, and the lookup and creation share a
+// transaction.
 func (m *Manager) GetOrCreateActiveSession(ctx context.Context, userID int64, message string) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	// Most calls find the session without taking the lock
 	session, err := m.store.GetActiveSession(ctx, userID)
 	if err == nil {
 		return session, nil
 	}
+	if !errors.Is(err, ErrSessionNotFound) {
+		return nil, fmt.Errorf("failed to get active session: %w", err)
+	}
+
+	unlock := m.locks.lock(userID)
+	defer unlock()
+
+	created := false
+	err = m.store.WithTx(ctx, func(tx Store) error {
+		active, err := tx.GetActiveSession(ctx, userID)
+		if err == nil {
+			session = active
+			return nil
+		}
+		if !errors.Is(err, ErrSessionNotFound) {
+			return fmt.Errorf("failed to get active session: %w", err)
+		}
+
+		session = NewSession(userID, message)
+		if err := tx.Create(ctx, session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		if err := tx.SetActiveSession(ctx, userID, session.ID); err != nil {
+			return fmt.Errorf("failed to set active session: %w", err)
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		publish(ctx, m.events, events.Event{
+			Type:      events.SessionCreated,
+			UserID:    userID,
+			SessionID: session.ID.String(),
+			Data:      map[string]any{"title": session.Title},
+		})
+	}
+	return session, nil
+}
+
+// ReopenLastSession activates the user's most recently updated session.
+// It returns ErrSessionNotFound when the user has no sessions.
+func (m *Manager) ReopenLastSession(ctx context.Context, userID int64) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	sessions, err := m.store.ListByUser(ctx, userID, 0, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		return nil, ErrSessionNotFound
+	}
 
-	// No active session, create new one
-	return m.CreateSession(ctx, userID, message)
+	if err := m.store.SetActiveSession(ctx, userID, sessions[0].ID); err != nil {
+		return nil, fmt.Errorf("failed to set active session: %w", err)
+	}
+
+	return sessions[0], nil
+}
+
+// RenameSession changes the title of a session owned by userID.
+// A write racing with the rename, such as an incoming message, causes a retry.
+func (m *Manager) RenameSession(ctx context.Context, userID int64, sessionID uuid.UUID, title string) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, ErrEmptyTitle
+	}
+
+	var session *Session
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		session, err = m.store.Get(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+
+		if session.UserID != userID {
+			return nil, ErrUnauthorized
+		}
+
+		session.Title = generateTitle(title)
+		session.UpdatedAt = time.Now()
+		err = m.store.Update(ctx, session)
+		if !errors.Is(err, ErrConflict) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename session: %w", err)
+	}
+
+	return session, nil
+}
+
+// SetSessionPreset sets the assistant preset of a session owned by userID; an
+// empty preset goes back to the defaults. The session keeps its place in the
+// session list. A racing write causes a retry, as with RenameSession.
+func (m *Manager) SetSessionPreset(ctx context.Context, userID int64, sessionID uuid.UUID, preset string) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var session *Session
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		session, err = m.store.Get(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+
+		if session.UserID != userID {
+			return nil, ErrUnauthorized
+		}
+
+		session.Preset = preset
+		err = m.store.Update(ctx, session)
+		if !errors.Is(err, ErrConflict) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set session preset: %w", err)
+	}
+
+	return session, nil
+}
+
+// TouchSession updates the preview and activity time of a session after a new
+// message was stored in it, so the session list shows it with its latest message
+func (m *Manager) TouchSession(ctx context.Context, sessionID uuid.UUID, lastMessage string) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	if err := m.store.TouchSession(ctx, sessionID, lastMessage); err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// KeepSession marks a session of userID as active now without changing its
+// preview, which cancels a pending expiry warning
+func (m *Manager) KeepSession(ctx context.Context, userID int64, sessionID uuid.UUID) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var session *Session
+	err := m.store.WithTx(ctx, func(tx Store) error {
+		var err error
+		session, err = tx.Get(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+
+		if session.UserID != userID {
+			return ErrUnauthorized
+		}
+
+		if err := tx.TouchSession(ctx, sessionID, session.LastMessage); err != nil {
+			return fmt.Errorf("failed to keep session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// GetActiveSession returns the active session for a user, or ErrSessionNotFound
+func (m *Manager) GetActiveSession(ctx context.Context, userID int64) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	session, err := m.store.GetActiveSession(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active session: %w", err)
+	}
+	return session, nil
+}
+
+// GetSession returns a session owned by userID
+func (m *Manager) GetSession(ctx context.Context, userID int64, sessionID uuid.UUID) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	session, err := m.store.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	return session, nil
+}
+
+// DeleteSession removes a session owned by userID.
+// Its active binding is removed with it; attachments are handled by FileManager.
+func (m *Manager) DeleteSession(ctx context.Context, userID int64, sessionID uuid.UUID) (*Session, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	session, err := m.store.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	if err := m.store.Delete(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return session, nil
 }
 
 // CloseActiveSession removes the active session binding for a user.
 // It does not delete the session itself.
 func (m *Manager) CloseActiveSession(ctx context.Context, userID int64) (*Session, bool, error) {
-	activeSession, err := m.store.GetActiveSession(ctx, userID)
-	if err != nil {
-		if errors.Is(err, ErrSessionNotFound) {
-			return nil, false, nil
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var activeSession *Session
+	err := m.store.WithTx(ctx, func(tx Store) error {
+		var err error
+		activeSession, err = tx.GetActiveSession(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get active session: %w", err)
 		}
-		return nil, false, fmt.Errorf("failed to get active session: %w", err)
-	}
 
-	if err := m.store.ClearActiveSession(ctx, userID); err != nil {
-		return nil, false, fmt.Errorf("failed to clear active session: %w", err)
+		if err := tx.ClearActiveSession(ctx, userID); err != nil {
+			return fmt.Errorf("failed to clear active session: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
 	}
 
+	publish(ctx, m.events, events.Event{
+		Type:      events.SessionClosed,
+		UserID:    userID,
+		SessionID: activeSession.ID.String(),
+	})
 	return activeSession, true, nil
 }
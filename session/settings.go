@@ -0,0 +1,25 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Setting is a runtime override of a configuration value, changed with /admin set
+type Setting struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SettingsStore defines the interface for runtime setting persistence
+type SettingsStore interface {
+	// ListSettings returns every stored setting ordered by key
+	ListSettings(ctx context.Context) ([]*Setting, error)
+
+	// SaveSetting creates or replaces a setting
+	SaveSetting(ctx context.Context, setting *Setting) error
+
+	// DeleteSetting removes a setting; deleting a missing key is not an error
+	DeleteSetting(ctx context.Context, key string) error
+}
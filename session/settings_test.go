@@ -0,0 +1,55 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_Settings(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	settings, err := store.ListSettings(ctx)
+	if err != nil {
+		t.Fatalf("ListSettings failed: %v", err)
+	}
+	if len(settings) != 0 {
+		t.Fatalf("expected no settings, got %d", len(settings))
+	}
+
+	for _, setting := range []*Setting{
+		{Key: "sessions_per_page", Value: "10", UpdatedAt: time.Now()},
+		{Key: "default_model", Value: "small", UpdatedAt: time.Now()},
+		{Key: "sessions_per_page", Value: "12", UpdatedAt: time.Now()},
+	} {
+		if err := store.SaveSetting(ctx, setting); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+	}
+
+	settings, err = store.ListSettings(ctx)
+	if err != nil {
+		t.Fatalf("ListSettings failed: %v", err)
+	}
+	if len(settings) != 2 {
+		t.Fatalf("expected 2 settings, got %d", len(settings))
+	}
+	if settings[0].Key != "default_model" || settings[1].Key != "sessions_per_page" || settings[1].Value != "12" {
+		t.Errorf("unexpected settings: %+v %+v", settings[0], settings[1])
+	}
+
+	if err := store.DeleteSetting(ctx, "sessions_per_page"); err != nil {
+		t.Fatalf("DeleteSetting failed: %v", err)
+	}
+	if err := store.DeleteSetting(ctx, "missing"); err != nil {
+		t.Fatalf("DeleteSetting of a missing key failed: %v", err)
+	}
+	settings, err = store.ListSettings(ctx)
+	if err != nil {
+		t.Fatalf("ListSettings failed: %v", err)
+	}
+	if len(settings) != 1 || settings[0].Key != "default_model" {
+		t.Errorf("expected only default_model to remain, got %+v", settings)
+	}
+}
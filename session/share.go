@@ -0,0 +1,35 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Share is a read-only link to a session transcript
+type Share struct {
+	Token     string     `json:"token"`
+	SessionID uuid.UUID  `json:"session_id"`
+	UserID    int64      `json:"user_id"` // owner of the session who created the link
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ErrShareNotFound is returned when no share link matches a token
+var ErrShareNotFound = fmt.Errorf("share not found")
+
+// ShareStore defines the interface for share link persistence
+type ShareStore interface {
+	// CreateShare stores a new share link
+	CreateShare(ctx context.Context, share *Share) error
+
+	// GetShare returns the share link with token
+	GetShare(ctx context.Context, token string) (*Share, error)
+
+	// RevokeShares revokes the unrevoked links a user created for a session and
+	// returns how many were revoked
+	RevokeShares(ctx context.Context, userID int64, sessionID uuid.UUID, revokedAt time.Time) (int, error)
+}
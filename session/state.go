@@ -0,0 +1,39 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ConversationState is the position of a user in a multi-step conversation flow
+type ConversationState struct {
+	UserID    int64             `json:"user_id"`
+	ChatID    int64             `json:"chat_id"`
+	Flow      string            `json:"flow"`
+	Step      string            `json:"step"`
+	Data      map[string]string `json:"data"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Expired reports whether the state timed out at now
+func (s *ConversationState) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && now.After(s.ExpiresAt)
+}
+
+// ErrStateNotFound is returned when a user is not in a conversation flow
+var ErrStateNotFound = fmt.Errorf("conversation state not found")
+
+// StateStore defines the interface for conversation state persistence.
+// Each user has at most one state.
+type StateStore interface {
+	// GetState returns the conversation state of a user
+	GetState(ctx context.Context, userID int64) (*ConversationState, error)
+
+	// SaveState creates or replaces the conversation state of a user
+	SaveState(ctx context.Context, state *ConversationState) error
+
+	// ClearState removes the conversation state of a user
+	ClearState(ctx context.Context, userID int64) error
+}
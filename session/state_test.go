@@ -0,0 +1,131 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_ConversationState(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	userID := int64(12345)
+
+	if _, err := store.GetState(ctx, userID); !errors.Is(err, ErrStateNotFound) {
+		t.Fatalf("expected ErrStateNotFound, got %v", err)
+	}
+
+	now := time.Now()
+	state := &ConversationState{
+		UserID:    userID,
+		ChatID:    67890,
+		Flow:      "rename",
+		Step:      "title",
+		Data:      map[string]string{"session_id": "abc"},
+		ExpiresAt: now.Add(10 * time.Minute),
+		UpdatedAt: now,
+	}
+	if err := store.SaveState(ctx, state); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+
+	got, err := store.GetState(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetState failed: %v", err)
+	}
+	if got.Flow != "rename" || got.Step != "title" || got.ChatID != 67890 {
+		t.Errorf("unexpected state: %+v", got)
+	}
+	if got.Data["session_id"] != "abc" {
+		t.Errorf("expected data to round-trip, got %v", got.Data)
+	}
+	if got.Expired(now) || !got.Expired(now.Add(11*time.Minute)) {
+		t.Errorf("unexpected expiry for ExpiresAt %v", got.ExpiresAt)
+	}
+
+	// Saving again replaces the state
+	state.Step = "confirm"
+	state.Data = nil
+	state.ExpiresAt = time.Time{}
+	if err := store.SaveState(ctx, state); err != nil {
+		t.Fatalf("SaveState (replace) failed: %v", err)
+	}
+	got, err = store.GetState(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetState failed: %v", err)
+	}
+	if got.Step != "confirm" || len(got.Data) != 0 {
+		t.Errorf("expected replaced state, got %+v", got)
+	}
+	if got.Expired(now.Add(24 * time.Hour)) {
+		t.Errorf("expected state without expiry never to expire, got ExpiresAt %v", got.ExpiresAt)
+	}
+
+	if err := store.ClearState(ctx, userID); err != nil {
+		t.Fatalf("ClearState failed: %v", err)
+	}
+	if _, err := store.GetState(ctx, userID); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("expected ErrStateNotFound after clear, got %v", err)
+	}
+}
+
+func TestManager_RenameSession(t *testing.T) {
+	store := newTestStore(t)
+	mgr := NewManager(store)
+	ctx := context.Background()
+
+	sess, err := mgr.CreateSession(ctx, 1, "original")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	renamed, err := mgr.RenameSession(ctx, 1, sess.ID, "  Trip planning  ")
+	if err != nil {
+		t.Fatalf("RenameSession failed: %v", err)
+	}
+	if renamed.Title != "Trip planning" {
+		t.Errorf("expected trimmed title, got %q", renamed.Title)
+	}
+
+	if _, err := mgr.RenameSession(ctx, 1, sess.ID, "   "); !errors.Is(err, ErrEmptyTitle) {
+		t.Errorf("expected ErrEmptyTitle, got %v", err)
+	}
+	if _, err := mgr.RenameSession(ctx, 2, sess.ID, "stolen"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestManager_SetSessionPreset(t *testing.T) {
+	mgr := NewManager(newTestStore(t))
+	ctx := context.Background()
+
+	sess, err := mgr.CreateSession(ctx, 1, "Trip")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	if _, err := mgr.SetSessionPreset(ctx, 1, sess.ID, "formal"); err != nil {
+		t.Fatalf("SetSessionPreset failed: %v", err)
+	}
+	stored, err := mgr.GetSession(ctx, 1, sess.ID)
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if stored.Preset != "formal" || !stored.UpdatedAt.Equal(sess.UpdatedAt) {
+		t.Errorf("expected the preset stored without touching the session, got %+v", stored)
+	}
+	if active, _ := mgr.GetActiveSession(ctx, 1); active == nil || active.Preset != "formal" {
+		t.Errorf("expected the active session to carry the preset, got %+v", active)
+	}
+
+	if _, err := mgr.SetSessionPreset(ctx, 2, sess.ID, "casual"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+	if _, err := mgr.SetSessionPreset(ctx, 1, sess.ID, ""); err != nil {
+		t.Fatalf("SetSessionPreset failed: %v", err)
+	}
+	if stored, _ := mgr.GetSession(ctx, 1, sess.ID); stored.Preset != "" {
+		t.Errorf("expected the preset cleared, got %q", stored.Preset)
+	}
+}
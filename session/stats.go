@@ -0,0 +1,32 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// UserStats aggregates a user's activity across sessions, messages and files
+type UserStats struct {
+	UserID    int64 `json:"user_id"`
+	Sessions  int   `json:"sessions"`
+	Messages  int   `json:"messages"`
+	Files     int   `json:"files"`
+	FileBytes int64 `json:"file_bytes"`
+	// LastActive is when the user last created a session or message; zero when never
+	LastActive    time.Time `json:"last_active"`
+	OldestSession *Session  `json:"oldest_session,omitempty"` // nil when the user has no sessions
+	NewestSession *Session  `json:"newest_session,omitempty"`
+	// BusiestDay is the date with the most messages; zero when the user has no messages
+	BusiestDay         time.Time `json:"busiest_day"`
+	BusiestDayMessages int       `json:"busiest_day_messages"`
+}
+
+// StatsStore defines the interface for per-user aggregate queries
+type StatsStore interface {
+	// GetUserStats returns aggregate statistics for a user
+	GetUserStats(ctx context.Context, userID int64) (*UserStats, error)
+
+	// ListUsers returns a page of users with stored sessions or messages, most recently
+	// active first. Only UserID, Sessions, Messages, Files, FileBytes and LastActive are set.
+	ListUsers(ctx context.Context, offset, limit int) ([]*UserStats, error)
+}
@@ -0,0 +1,432 @@
+package session
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_GetUserStats(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	empty, err := store.GetUserStats(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+	if empty.Sessions != 0 || empty.OldestSession != nil || empty.BusiestDayMessages != 0 || !empty.BusiestDay.IsZero() {
+		t.Errorf("Expected empty stats, got %+v", empty)
+	}
+
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)
+
+	oldest := NewSession(1, "first")
+	oldest.CreatedAt, oldest.UpdatedAt = day1, day1
+	newest := NewSession(1, "second")
+	newest.CreatedAt, newest.UpdatedAt = day2, day2
+	for _, s := range []*Session{newest, oldest, NewSession(2, "other user")} {
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+
+	messageTimes := []time.Time{day1, day2, day2.Add(time.Hour), day2.Add(2 * time.Hour)}
+	for _, at := range messageTimes {
+		message := NewMessage(oldest.ID, 1, RoleUser, "hi")
+		message.CreatedAt = at
+		if err := store.AppendMessage(ctx, message); err != nil {
+			t.Fatalf("Failed to append message: %v", err)
+		}
+	}
+
+	if err := store.CreateFile(ctx, NewFile(1, "document", "tg-file", "key", 2048)); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	stats, err := store.GetUserStats(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+
+	if stats.Sessions != 2 || stats.Messages != 4 || stats.Files != 1 || stats.FileBytes != 2048 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	if stats.OldestSession == nil || stats.OldestSession.ID != oldest.ID {
+		t.Errorf("Expected oldest session %s, got %+v", oldest.ID, stats.OldestSession)
+	}
+	if stats.NewestSession == nil || stats.NewestSession.ID != newest.ID {
+		t.Errorf("Expected newest session %s, got %+v", newest.ID, stats.NewestSession)
+	}
+	if !stats.BusiestDay.Equal(time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)) || stats.BusiestDayMessages != 3 {
+		t.Errorf("Expected busiest day 2026-03-05 with 3 messages, got %s with %d",
+			stats.BusiestDay.Format(time.DateOnly), stats.BusiestDayMessages)
+	}
+}
+
+func TestSQLiteStore_UserStatsCounters(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	at := time.Date(2026, 4, 2, 9, 30, 0, 0, time.UTC)
+	kept := NewSession(1, "kept")
+	dropped := NewSession(1, "dropped")
+	for _, s := range []*Session{kept, dropped} {
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+	for _, sess := range []*Session{kept, dropped, dropped} {
+		message := NewMessage(sess.ID, 1, RoleUser, "hi")
+		message.CreatedAt = at
+		if err := store.AppendMessage(ctx, message); err != nil {
+			t.Fatalf("Failed to append message: %v", err)
+		}
+	}
+
+	stats, err := store.GetUserStats(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+	if stats.Sessions != 2 || stats.Messages != 3 || !stats.LastActive.Equal(at) {
+		t.Errorf("Unexpected counters before delete: %+v", stats)
+	}
+
+	// Deleting a session removes its messages from the counters too
+	if err := store.Delete(ctx, dropped.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	stats, err = store.GetUserStats(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+	if stats.Sessions != 1 || stats.Messages != 1 {
+		t.Errorf("Unexpected counters after delete: %+v", stats)
+	}
+	if count, err := store.CountByUser(ctx, 1); err != nil || count != 1 {
+		t.Errorf("Expected CountByUser 1, got %d err=%v", count, err)
+	}
+}
+
+func TestSQLiteStore_SessionCounters(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	target := NewSession(1, "target")
+	source := NewSession(1, "source")
+	for _, s := range []*Session{target, source} {
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+	for _, sess := range []*Session{target, source, source} {
+		if err := store.AppendMessage(ctx, NewMessage(sess.ID, 1, RoleUser, "hi")); err != nil {
+			t.Fatalf("Failed to append message: %v", err)
+		}
+	}
+	file := NewFile(1, "document", "a.txt", "user_1/a.txt", 4)
+	file.SessionID = source.ID
+	if err := store.CreateFile(ctx, file); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	got, err := store.Get(ctx, source.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.MessageCount != 2 || got.FileCount != 1 {
+		t.Errorf("Unexpected counters before merge: %d messages, %d files", got.MessageCount, got.FileCount)
+	}
+
+	// Merging moves the counts along with the rows
+	if _, err := store.MergeSessions(ctx, 1, source.ID, target.ID); err != nil {
+		t.Fatalf("MergeSessions failed: %v", err)
+	}
+	got, err = store.Get(ctx, target.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.MessageCount != 3 || got.FileCount != 1 {
+		t.Errorf("Unexpected counters after merge: %d messages, %d files", got.MessageCount, got.FileCount)
+	}
+
+	if err := store.DetachSessionFiles(ctx, target.ID); err != nil {
+		t.Fatalf("DetachSessionFiles failed: %v", err)
+	}
+	if got, err := store.Get(ctx, target.ID); err != nil || got.FileCount != 0 {
+		t.Errorf("Expected no files after detaching, got %+v err=%v", got, err)
+	}
+}
+
+func TestSQLiteStore_SessionCountersBackfill(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "counters.db")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	sess := NewSession(7, "before upgrade")
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := store.AppendMessage(ctx, NewMessage(sess.ID, 7, RoleUser, "hi")); err != nil {
+		t.Fatalf("Failed to append message: %v", err)
+	}
+
+	// Simulate a database created before the counter columns existed
+	for _, stmt := range []string{
+		`DROP TRIGGER session_counter_message_insert`,
+		`DROP TRIGGER session_counter_message_delete`,
+		`DROP TRIGGER session_counter_message_move`,
+		`DROP TRIGGER session_counter_file_insert`,
+		`DROP TRIGGER session_counter_file_delete`,
+		`DROP TRIGGER session_counter_file_move`,
+		`ALTER TABLE sessions DROP COLUMN message_count`,
+		`ALTER TABLE sessions DROP COLUMN file_count`,
+	} {
+		if _, err := store.db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to downgrade schema: %v", err)
+		}
+	}
+	store.Close()
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	got, err := store.Get(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.MessageCount != 1 {
+		t.Errorf("Expected a backfilled message count of 1, got %d", got.MessageCount)
+	}
+}
+
+func TestSQLiteStore_UserStatsBackfill(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "backfill.db")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	sess := NewSession(7, "before upgrade")
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := store.AppendMessage(ctx, NewMessage(sess.ID, 7, RoleUser, "hi")); err != nil {
+		t.Fatalf("Failed to append message: %v", err)
+	}
+
+	// Simulate a database created before user_stats existed
+	if _, err := store.db.Exec(`DELETE FROM user_stats`); err != nil {
+		t.Fatalf("Failed to clear user stats: %v", err)
+	}
+	store.Close()
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	stats, err := store.GetUserStats(ctx, 7)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+	if stats.Sessions != 1 || stats.Messages != 1 || stats.LastActive.IsZero() {
+		t.Errorf("Expected backfilled counters, got %+v", stats)
+	}
+}
+
+func TestSQLiteStore_ListUsers(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	earlier := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	later := earlier.Add(24 * time.Hour)
+	for _, s := range []struct {
+		userID int64
+		at     time.Time
+	}{{1, earlier}, {2, earlier}, {2, later}} {
+		sess := NewSession(s.userID, "hello")
+		sess.CreatedAt, sess.UpdatedAt = s.at, s.at
+		if err := store.Create(ctx, sess); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+	if err := store.CreateFile(ctx, NewFile(1, "document", "tg-file", "key", 2048)); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	users, err := store.ListUsers(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(users))
+	}
+	if users[0].UserID != 2 || users[0].Sessions != 2 || !users[0].LastActive.Equal(later) {
+		t.Errorf("Expected the most recently active user first, got %+v", users[0])
+	}
+	if users[1].UserID != 1 || users[1].Files != 1 || users[1].FileBytes != 2048 {
+		t.Errorf("Expected file usage of user 1, got %+v", users[1])
+	}
+
+	page, err := store.ListUsers(ctx, 1, 10)
+	if err != nil || len(page) != 1 || page[0].UserID != 1 {
+		t.Errorf("Expected the second page to hold user 1, got %v err=%v", page, err)
+	}
+
+	if _, err := store.PurgeUser(ctx, 2); err != nil {
+		t.Fatalf("PurgeUser failed: %v", err)
+	}
+	if users, _ := store.ListUsers(ctx, 0, 10); len(users) != 1 {
+		t.Errorf("Expected purged users to be left out, got %d users", len(users))
+	}
+}
+
+func TestSQLiteStore_UnreadCounters(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour)
+	waiting := NewSession(1, "waiting")
+	answered := NewSession(2, "answered")
+	for _, s := range []*Session{waiting, answered} {
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+	appendAt := func(sess *Session, role string, at time.Time) *Message {
+		message := NewMessage(sess.ID, sess.UserID, role, "hi")
+		message.CreatedAt = at
+		if err := store.AppendMessage(ctx, message); err != nil {
+			t.Fatalf("Failed to append message: %v", err)
+		}
+		return message
+	}
+
+	appendAt(waiting, RoleUser, start)
+	appendAt(waiting, RoleUser, start.Add(time.Minute))
+	appendAt(answered, RoleUser, start)
+	reply := appendAt(answered, RoleAssistant, start.Add(2*time.Minute))
+	appendAt(answered, RoleUser, start.Add(50*time.Minute))
+
+	if got, err := store.Get(ctx, waiting.ID); err != nil || got.UnreadCount != 2 {
+		t.Errorf("Expected 2 unread messages, got %+v err=%v", got, err)
+	}
+	if got, err := store.Get(ctx, answered.ID); err != nil || got.UnreadCount != 1 {
+		t.Errorf("Expected 1 unread message after the reply, got %+v err=%v", got, err)
+	}
+
+	messages, err := store.ListSessionMessages(ctx, answered.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("ListSessionMessages failed: %v", err)
+	}
+	if messages[0].RepliedAt == nil || !messages[0].RepliedAt.Equal(reply.CreatedAt) {
+		t.Errorf("Expected the first message to be replied at %s, got %v", reply.CreatedAt, messages[0].RepliedAt)
+	}
+	if messages[1].RepliedAt != nil || messages[2].RepliedAt != nil {
+		t.Errorf("Expected no read receipt on the reply and the latest message, got %+v", messages)
+	}
+
+	pending, err := store.ListPendingSessions(ctx, time.Now(), 0, 10)
+	if err != nil {
+		t.Fatalf("ListPendingSessions failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].ID != waiting.ID || !pending[0].WaitingSince.Equal(start) {
+		t.Fatalf("Expected the longest waiting session first, got %+v", pending)
+	}
+	if !pending[1].WaitingSince.Equal(start.Add(50 * time.Minute)) {
+		t.Errorf("Expected the answered session to wait since its latest message, got %s", pending[1].WaitingSince)
+	}
+
+	// Only sessions waiting since before the cut-off are listed
+	pending, err = store.ListPendingSessions(ctx, start.Add(30*time.Minute), 0, 10)
+	if err != nil || len(pending) != 1 || pending[0].ID != waiting.ID {
+		t.Errorf("Expected only the stuck session, got %+v err=%v", pending, err)
+	}
+
+	// Merging moves unread messages along with the rows
+	other := NewSession(1, "other")
+	if err := store.Create(ctx, other); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	appendAt(other, RoleUser, start.Add(3*time.Minute))
+	if _, err := store.MergeSessions(ctx, 1, other.ID, waiting.ID); err != nil {
+		t.Fatalf("MergeSessions failed: %v", err)
+	}
+	if got, err := store.Get(ctx, waiting.ID); err != nil || got.UnreadCount != 3 {
+		t.Errorf("Expected 3 unread messages after merge, got %+v err=%v", got, err)
+	}
+
+	appendAt(waiting, RoleAssistant, time.Now())
+	if got, err := store.Get(ctx, waiting.ID); err != nil || got.UnreadCount != 0 {
+		t.Errorf("Expected no unread messages after the reply, got %+v err=%v", got, err)
+	}
+	pending, err = store.ListPendingSessions(ctx, time.Now(), 0, 10)
+	if err != nil || len(pending) != 1 || pending[0].ID != answered.ID {
+		t.Errorf("Expected only the answered session to wait, got %+v err=%v", pending, err)
+	}
+}
+
+func TestSQLiteStore_UnreadBackfill(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "unread.db")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	sess := NewSession(7, "before upgrade")
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	start := time.Now().Add(-time.Hour)
+	for i, role := range []string{RoleUser, RoleAssistant, RoleUser} {
+		message := NewMessage(sess.ID, 7, role, "hi")
+		message.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if err := store.AppendMessage(ctx, message); err != nil {
+			t.Fatalf("Failed to append message: %v", err)
+		}
+	}
+
+	// Simulate a database created before read receipts existed
+	for _, stmt := range []string{
+		`DROP TRIGGER session_unread_insert`,
+		`DROP TRIGGER session_unread_reply`,
+		`DROP TRIGGER session_unread_delete`,
+		`DROP TRIGGER session_unread_move`,
+		`ALTER TABLE messages DROP COLUMN replied_at`,
+		`ALTER TABLE sessions DROP COLUMN unread_count`,
+	} {
+		if _, err := store.db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to downgrade schema: %v", err)
+		}
+	}
+	store.Close()
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	got, err := store.Get(ctx, sess.ID)
+	if err != nil || got.UnreadCount != 1 {
+		t.Errorf("Expected 1 unread message after backfill, got %+v err=%v", got, err)
+	}
+	messages, err := store.ListSessionMessages(ctx, sess.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("ListSessionMessages failed: %v", err)
+	}
+	if messages[0].RepliedAt == nil || !messages[0].RepliedAt.Equal(messages[1].CreatedAt) || messages[2].RepliedAt != nil {
+		t.Errorf("Unexpected read receipts after backfill: %+v", messages)
+	}
+}
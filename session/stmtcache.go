@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// maxCachedStatements bounds the statement cache; further statements run unprepared
+const maxCachedStatements = 128
+
+// statement is a query bound to a connection, prepared or not
+type statement interface {
+	ExecContext(ctx context.Context, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, args ...any) *sql.Row
+}
+
+// stmtCache prepares each distinct query once and reuses it for the life of the store
+type stmtCache struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func newStmtCache(db *sql.DB) *stmtCache {
+	return &stmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// lookup returns the prepared statement for query, or nil when it was not prepared yet.
+// A nil cache has no statements.
+func (c *stmtCache) lookup(query string) *sql.Stmt {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stmts[query]
+}
+
+// prepare returns the prepared statement for query, preparing it on first use.
+// It returns nil when the cache is full or preparing fails; running the query
+// unprepared then reports the error.
+func (c *stmtCache) prepare(ctx context.Context, query string) *sql.Stmt {
+	if c == nil {
+		return nil
+	}
+	if stmt := c.lookup(query); stmt != nil {
+		return stmt
+	}
+
+	c.mu.Lock()
+	full := len(c.stmts) >= maxCachedStatements
+	c.mu.Unlock()
+	if full {
+		return nil
+	}
+
+	// Prepared without holding the lock: it waits for a pool connection
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.stmts[query]; ok {
+		stmt.Close()
+		return existing
+	}
+	c.stmts[query] = stmt
+	return stmt
+}
+
+// Close closes every prepared statement
+func (c *stmtCache) Close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for query, stmt := range c.stmts {
+		stmt.Close()
+		delete(c.stmts, query)
+	}
+}
+
+// unprepared runs a query directly on a connection or transaction
+type unprepared struct {
+	conn interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+		QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	}
+	query string
+}
+
+func (u unprepared) ExecContext(ctx context.Context, args ...any) (sql.Result, error) {
+	return u.conn.ExecContext(ctx, u.query, args...)
+}
+
+func (u unprepared) QueryContext(ctx context.Context, args ...any) (*sql.Rows, error) {
+	return u.conn.QueryContext(ctx, u.query, args...)
+}
+
+func (u unprepared) QueryRowContext(ctx context.Context, args ...any) *sql.Row {
+	return u.conn.QueryRowContext(ctx, u.query, args...)
+}
@@ -0,0 +1,123 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// BenchmarkSQLiteStore_ConcurrentHandlers runs the store calls of a message handler
+// from parallel goroutines: look up the active session, append the message and
+// load the recent history. "baseline" is SQLite's defaults without prepared
+// statements; "tuned" is DefaultSQLiteOptions.
+//
+//	go test ./session -run '^$' -bench ConcurrentHandlers -cpu 8
+func BenchmarkSQLiteStore_ConcurrentHandlers(b *testing.B) {
+	variants := []struct {
+		name     string
+		options  SQLiteOptions
+		prepared bool
+	}{
+		// A busy timeout is needed for parallel writers to work at all
+		{"baseline", SQLiteOptions{BusyTimeout: 5 * time.Second}, false},
+		{"tuned", DefaultSQLiteOptions(), true},
+	}
+
+	for _, variant := range variants {
+		b.Run(variant.name, func(b *testing.B) {
+			store, err := NewSQLiteStoreWithOptions(filepath.Join(b.TempDir(), "bench.db"), variant.options)
+			if err != nil {
+				b.Fatalf("Failed to create store: %v", err)
+			}
+			defer store.Close()
+			if !variant.prepared {
+				store.db.stmts.Close()
+				store.db.stmts = nil
+			}
+
+			ctx := context.Background()
+			manager := NewManager(store)
+			const users = 32
+			for userID := int64(1); userID <= users; userID++ {
+				if _, err := manager.CreateSession(ctx, userID, "hello"); err != nil {
+					b.Fatalf("CreateSession failed: %v", err)
+				}
+			}
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					userID := next.Add(1)%users + 1
+					active, err := manager.GetActiveSession(ctx, userID)
+					if err != nil {
+						b.Errorf("GetActiveSession failed: %v", err)
+						return
+					}
+					if err := store.AppendMessage(ctx, NewMessage(active.ID, userID, RoleUser, "message")); err != nil {
+						b.Errorf("AppendMessage failed: %v", err)
+						return
+					}
+					if _, err := store.ListMessages(ctx, active.ID, 20); err != nil {
+						b.Errorf("ListMessages failed: %v", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkSQLiteStore_AppendMessages stores a batch of messages, such as an
+// imported conversation, with one AppendMessage call per message versus one
+// AppendMessages call.
+//
+//	go test ./session -run '^$' -bench AppendMessages
+func BenchmarkSQLiteStore_AppendMessages(b *testing.B) {
+	for _, size := range []int{10, 100, 1000} {
+		for _, batched := range []bool{false, true} {
+			name := fmt.Sprintf("per-row/%d", size)
+			if batched {
+				name = fmt.Sprintf("batch/%d", size)
+			}
+			b.Run(name, func(b *testing.B) {
+				store, err := NewSQLiteStore(filepath.Join(b.TempDir(), "bench.db"))
+				if err != nil {
+					b.Fatalf("Failed to create store: %v", err)
+				}
+				defer store.Close()
+
+				ctx := context.Background()
+				sess, err := NewManager(store).CreateSession(ctx, 1, "import")
+				if err != nil {
+					b.Fatalf("CreateSession failed: %v", err)
+				}
+
+				b.ResetTimer()
+				for range b.N {
+					b.StopTimer()
+					messages := make([]*Message, size)
+					for i := range messages {
+						messages[i] = NewMessage(sess.ID, 1, RoleUser, "message")
+					}
+					b.StartTimer()
+
+					if batched {
+						if err := store.AppendMessages(ctx, sess.ID, messages); err != nil {
+							b.Fatalf("AppendMessages failed: %v", err)
+						}
+						continue
+					}
+					for _, message := range messages {
+						if err := store.AppendMessage(ctx, message); err != nil {
+							b.Fatalf("AppendMessage failed: %v", err)
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
@@ -11,27 +15,74 @@ import (
 
 // SQLiteStore implements Store interface using SQLite
 type SQLiteStore struct {
-	db *sql.DB
+	db tracedDB
+
+	// migrations lists the columns added when the store was opened, as "table.column"
+	migrations []string
+}
+
+// SQLiteOptions tunes the connection pool and the PRAGMAs applied to every connection
+type SQLiteOptions struct {
+	MaxOpenConns int           // 0 means unlimited
+	BusyTimeout  time.Duration // how long a statement waits for a locked database
+	Synchronous  string        // PRAGMA synchronous: OFF, NORMAL, FULL or EXTRA; empty keeps SQLite's default
+	CacheSizeKiB int           // PRAGMA cache_size in KiB; 0 keeps SQLite's default
+}
+
+// DefaultSQLiteOptions returns options suited to a bot serving concurrent handlers
+func DefaultSQLiteOptions() SQLiteOptions {
+	return SQLiteOptions{
+		MaxOpenConns: 4,
+		BusyTimeout:  5 * time.Second,
+		Synchronous:  "NORMAL",
+	}
 }
 
-// NewSQLiteStore creates a new SQLite store
+// dsn returns the data source name opening dbPath with options. PRAGMAs in the DSN
+// run on every new connection, unlike statements executed once on the pool.
+// Transactions begin IMMEDIATE so a read followed by a write never fails to upgrade its lock.
+func (o SQLiteOptions) dsn(dbPath string) string {
+	pragmas := []string{"journal_mode(WAL)", "foreign_keys(1)"}
+	if o.BusyTimeout > 0 {
+		pragmas = append(pragmas, fmt.Sprintf("busy_timeout(%d)", o.BusyTimeout.Milliseconds()))
+	}
+	if o.Synchronous != "" {
+		pragmas = append(pragmas, fmt.Sprintf("synchronous(%s)", strings.ToUpper(o.Synchronous)))
+	}
+	if o.CacheSizeKiB > 0 {
+		// A negative cache_size is in KiB rather than pages
+		pragmas = append(pragmas, fmt.Sprintf("cache_size(-%d)", o.CacheSizeKiB))
+	}
+
+	query := url.Values{"_pragma": pragmas, "_txlock": {"immediate"}}
+	return dbPath + "?" + query.Encode()
+}
+
+// NewSQLiteStore creates a new SQLite store with DefaultSQLiteOptions
 func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	return NewSQLiteStoreWithOptions(dbPath, DefaultSQLiteOptions())
+}
+
+// NewSQLiteStoreWithOptions creates a new SQLite store tuned by options
+func NewSQLiteStoreWithOptions(dbPath string, options SQLiteOptions) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", options.dsn(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Enable WAL mode for better concurrency
-	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
-		return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
+	// Idle connections are kept so their prepared statements survive
+	db.SetMaxOpenConns(options.MaxOpenConns)
+	if options.MaxOpenConns > 0 {
+		db.SetMaxIdleConns(options.MaxOpenConns)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys=ON"); err != nil {
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	// Connect now so a bad path or PRAGMA fails here rather than on first use
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	store := &SQLiteStore{db: db}
+	store := &SQLiteStore{db: tracedDB{DB: db, stmts: newStmtCache(db), readOnly: new(atomic.Bool)}}
 
 	// Initialize schema
 	if err := store.initSchema(); err != nil {
@@ -50,7 +101,14 @@ func (s *SQLiteStore) initSchema() error {
 		title TEXT NOT NULL,
 		created_at DATETIME NOT NULL,
 		updated_at DATETIME NOT NULL,
-		last_message TEXT NOT NULL
+		last_message TEXT NOT NULL,
+		version INTEGER NOT NULL DEFAULT 0,
+		archived_at DATETIME,
+		message_count INTEGER NOT NULL DEFAULT 0,
+		file_count INTEGER NOT NULL DEFAULT 0,
+		expiry_warned_at DATETIME,
+		unread_count INTEGER NOT NULL DEFAULT 0,
+		preset TEXT NOT NULL DEFAULT ''
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_sessions_user_updated 
@@ -64,22 +122,519 @@ func (s *SQLiteStore) initSchema() error {
 
 	CREATE INDEX IF NOT EXISTS idx_active_sessions_user 
 		ON active_sessions(user_id);
-	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	CREATE TABLE IF NOT EXISTS topic_sessions (
+		chat_id INTEGER NOT NULL,
+		thread_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		session_id TEXT NOT NULL,
+		PRIMARY KEY (chat_id, thread_id, user_id),
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS files (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		session_id TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		telegram_file_id TEXT NOT NULL,
+		file_name TEXT NOT NULL DEFAULT '',
+		mime_type TEXT NOT NULL DEFAULT '',
+		media_group_id TEXT NOT NULL DEFAULT '',
+		sticker_set_name TEXT NOT NULL DEFAULT '',
+		sticker_emoji TEXT NOT NULL DEFAULT '',
+		sticker_type TEXT NOT NULL DEFAULT '',
+		sticker_custom_emoji_id TEXT NOT NULL DEFAULT '',
+		sticker_animated INTEGER NOT NULL DEFAULT 0,
+		sticker_video INTEGER NOT NULL DEFAULT 0,
+		storage_key TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_files_user_created
+		ON files(user_id, created_at DESC);
+
+	CREATE TABLE IF NOT EXISTS messages (
+		id TEXT PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		file_id TEXT NOT NULL DEFAULT '',
+		chat_id INTEGER NOT NULL DEFAULT 0,
+		telegram_message_id INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		edited_at DATETIME,
+		replied_at DATETIME,
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_messages_session_created
+		ON messages(session_id, created_at);
+
+	CREATE INDEX IF NOT EXISTS idx_messages_telegram
+		ON messages(chat_id, telegram_message_id);
+
+	CREATE TABLE IF NOT EXISTS conversation_states (
+		user_id INTEGER PRIMARY KEY,
+		chat_id INTEGER NOT NULL,
+		flow TEXT NOT NULL,
+		step TEXT NOT NULL,
+		data TEXT NOT NULL DEFAULT '{}',
+		expires_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS user_preferences (
+		user_id INTEGER PRIMARY KEY,
+		language TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS referrals (
+		user_id INTEGER PRIMARY KEY,
+		code TEXT NOT NULL,
+		referred_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_referrals_code
+		ON referrals(code);
+
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS user_stats (
+		user_id INTEGER PRIMARY KEY,
+		sessions INTEGER NOT NULL DEFAULT 0,
+		messages INTEGER NOT NULL DEFAULT 0,
+		last_active DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS rate_limits (
+		user_id INTEGER PRIMARY KEY,
+		tokens REAL NOT NULL,
+		updated_at DATETIME NOT NULL,
+		warned BOOLEAN NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS session_shares (
+		token TEXT PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		revoked_at DATETIME,
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_session_shares_session
+		ON session_shares(session_id);
+
+	CREATE TABLE IF NOT EXISTS business_connections (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		user_chat_id INTEGER NOT NULL,
+		can_reply BOOLEAN NOT NULL DEFAULT 0,
+		is_enabled BOOLEAN NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS business_chat_sessions (
+		connection_id TEXT NOT NULL,
+		chat_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		session_id TEXT NOT NULL,
+		PRIMARY KEY (connection_id, chat_id),
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS channels (
+		chat_id INTEGER PRIMARY KEY,
+		title TEXT NOT NULL,
+		username TEXT NOT NULL DEFAULT '',
+		posts INTEGER NOT NULL DEFAULT 0,
+		first_post_at DATETIME NOT NULL,
+		last_post_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS feedback (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		session_id TEXT NOT NULL DEFAULT '',
+		text TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		resolved_at DATETIME,
+		resolved_by INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_feedback_open
+		ON feedback(resolved_at, id);
+
+	CREATE TABLE IF NOT EXISTS session_memory (
+		session_id TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (session_id, key),
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS session_summaries (
+		session_id TEXT PRIMARY KEY,
+		text TEXT NOT NULL,
+		message_count INTEGER NOT NULL DEFAULT 0,
+		pinned_message_id INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS flagged_content (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		chat_id INTEGER NOT NULL,
+		session_id TEXT NOT NULL DEFAULT '',
+		direction TEXT NOT NULL,
+		action TEXT NOT NULL,
+		rules TEXT NOT NULL DEFAULT '',
+		text TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		reviewed_at DATETIME,
+		reviewed_by INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_flagged_content_open
+		ON flagged_content(reviewed_at, id);
+
+	CREATE TABLE IF NOT EXISTS user_settings (
+		user_id INTEGER PRIMARY KEY,
+		ai_model TEXT NOT NULL DEFAULT '',
+		auto_download BOOLEAN NOT NULL DEFAULT 1,
+		notifications BOOLEAN NOT NULL DEFAULT 1,
+		timezone TEXT NOT NULL DEFAULT '',
+		quiet_start INTEGER NOT NULL DEFAULT 0,
+		quiet_end INTEGER NOT NULL DEFAULT 0,
+		temperature REAL,
+		max_tokens INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS deferred_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		chat_id INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		parse_mode TEXT NOT NULL DEFAULT '',
+		reply_markup TEXT NOT NULL DEFAULT '',
+		deliver_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_deferred_messages_deliver_at
+		ON deferred_messages(deliver_at);
+
+	CREATE TABLE IF NOT EXISTS callback_tokens (
+		token TEXT PRIMARY KEY,
+		data TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_callback_tokens_expires
+		ON callback_tokens(expires_at);
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		target TEXT NOT NULL DEFAULT '',
+		payload_hash TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_log_action
+		ON audit_log(action, id);
+
+	CREATE TABLE IF NOT EXISTS user_roles (
+		user_id INTEGER PRIMARY KEY,
+		role TEXT NOT NULL CHECK (role IN ('admin', 'moderator')),
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		chat_id INTEGER NOT NULL,
+		thread_id INTEGER NOT NULL DEFAULT 0,
+		text TEXT NOT NULL,
+		parse_mode TEXT NOT NULL DEFAULT '',
+		reply_markup TEXT NOT NULL DEFAULT '',
+		reply_markup_type TEXT NOT NULL DEFAULT '',
+		params TEXT NOT NULL DEFAULT '',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_outbox_next_attempt
+		ON outbox(next_attempt_at);
+
+	CREATE TABLE IF NOT EXISTS banned_users (
+		user_id INTEGER PRIMARY KEY,
+		reason TEXT NOT NULL DEFAULT '',
+		banned_by INTEGER NOT NULL,
+		banned_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS sent_messages (
+		chat_id INTEGER NOT NULL,
+		telegram_message_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		session_id TEXT,
+		message_id TEXT,
+		reply_to_message_id INTEGER NOT NULL DEFAULT 0,
+		sent_at DATETIME NOT NULL,
+		PRIMARY KEY (chat_id, telegram_message_id),
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE,
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sent_messages_session
+		ON sent_messages(session_id, sent_at);
+
+	CREATE INDEX IF NOT EXISTS idx_sent_messages_message
+		ON sent_messages(message_id);
+
+	CREATE TABLE IF NOT EXISTS message_versions (
+		message_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (message_id, version),
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS drafts (
+		user_id INTEGER PRIMARY KEY,
+		chat_id INTEGER NOT NULL,
+		started_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS draft_parts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES drafts(user_id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_draft_parts_user
+		ON draft_parts(user_id, id);
+	` + userStatsTriggers
+
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	if err := s.migrateSchema(); err != nil {
+		return err
+	}
+
+	// Indexes on migrated columns are created after the columns exist
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_files_session ON files(session_id)`); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_sessions_user_archived
+		ON sessions(user_id, updated_at DESC) WHERE archived_at IS NOT NULL`); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_sessions_idle
+		ON sessions(updated_at) WHERE archived_at IS NULL`); err != nil {
+		return err
+	}
+
+	// Likewise the session counters, whose triggers refer to migrated columns
+	if _, err := s.db.Exec(sessionCounterTriggers + unreadTriggers); err != nil {
+		return err
+	}
+	if err := s.backfillSessionCounters(); err != nil {
+		return err
+	}
+	if err := s.backfillUnread(); err != nil {
+		return err
+	}
+
+	return s.backfillUserStats()
+}
+
+// migrateSchema adds columns introduced after a table was first created
+func (s *SQLiteStore) migrateSchema() error {
+	migrations := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"files", "media_group_id", "TEXT NOT NULL DEFAULT ''"},
+		{"files", "session_id", "TEXT NOT NULL DEFAULT ''"},
+		{"files", "sticker_set_name", "TEXT NOT NULL DEFAULT ''"},
+		{"files", "sticker_emoji", "TEXT NOT NULL DEFAULT ''"},
+		{"files", "sticker_type", "TEXT NOT NULL DEFAULT ''"},
+		{"files", "sticker_custom_emoji_id", "TEXT NOT NULL DEFAULT ''"},
+		{"files", "sticker_animated", "INTEGER NOT NULL DEFAULT 0"},
+		{"files", "sticker_video", "INTEGER NOT NULL DEFAULT 0"},
+		{"messages", "edited_at", "DATETIME"},
+		{"messages", "replied_at", "DATETIME"},
+		{"sessions", "version", "INTEGER NOT NULL DEFAULT 0"},
+		{"sessions", "archived_at", "DATETIME"},
+		{"sessions", "message_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"sessions", "file_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"sessions", "expiry_warned_at", "DATETIME"},
+		{"sessions", "unread_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"sessions", "preset", "TEXT NOT NULL DEFAULT ''"},
+		{"user_settings", "timezone", "TEXT NOT NULL DEFAULT ''"},
+		{"user_settings", "quiet_start", "INTEGER NOT NULL DEFAULT 0"},
+		{"user_settings", "quiet_end", "INTEGER NOT NULL DEFAULT 0"},
+		{"user_settings", "temperature", "REAL"},
+		{"user_settings", "max_tokens", "INTEGER NOT NULL DEFAULT 0"},
+		{"deferred_messages", "reply_markup", "TEXT NOT NULL DEFAULT ''"},
+		{"outbox", "reply_markup_type", "TEXT NOT NULL DEFAULT ''"},
+		{"outbox", "params", "TEXT NOT NULL DEFAULT ''"},
+	}
+
+	for _, m := range migrations {
+		added, err := s.addColumnIfMissing(m.table, m.column, m.definition)
+		if err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", m.table, m.column, err)
+		}
+		if added {
+			s.migrations = append(s.migrations, m.table+"."+m.column)
+		}
+	}
+
+	return nil
+}
+
+// AppliedMigrations returns the columns added to an older database when the store
+// was opened, as "table.column". It is empty when the schema was already current.
+func (s *SQLiteStore) AppliedMigrations() []string {
+	return s.migrations
+}
+
+// addColumnIfMissing runs ALTER TABLE ADD COLUMN unless the column already exists
+// and reports whether it was added
+func (s *SQLiteStore) addColumnIfMissing(table, column, definition string) (bool, error) {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, err
+		}
+		if name == column {
+			return false, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // Close closes the database connection
 func (s *SQLiteStore) Close() error {
+	s.db.stmts.Close()
 	return s.db.Close()
 }
 
+// WithTx runs fn against a store bound to a single transaction, committing when
+// fn succeeds and rolling back otherwise. Calls nested in fn join the outer transaction.
+func (s *SQLiteStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return s.withTx(ctx, func(tx *SQLiteStore) error { return fn(tx) })
+}
+
+// InTx runs fn in a transaction bound to the context fn is given. Every call
+// made on this store with that context, or one derived from it, joins the
+// transaction, including calls made through a Manager, so a write and what
+// depends on it, e.g. the outbox reply confirming it, commit together or not at all.
+func (s *SQLiteStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.withTx(ctx, func(tx *SQLiteStore) error {
+		return fn(context.WithValue(ctx, txContextKey{}, boundTx{db: tx.db.DB, tx: tx.db.tx}))
+	})
+}
+
+// txContextKey is the context key of the transaction bound by InTx
+type txContextKey struct{}
+
+// boundTx is a transaction bound to a context, with the database it belongs to
+type boundTx struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// contextTx returns the transaction InTx bound to ctx for db, or nil
+func contextTx(ctx context.Context, db *sql.DB) *sql.Tx {
+	bound, ok := ctx.Value(txContextKey{}).(boundTx)
+	if !ok || bound.db != db {
+		return nil
+	}
+	return bound.tx
+}
+
+// withTx is WithTx for the store's own methods, which need the concrete store
+func (s *SQLiteStore) withTx(ctx context.Context, fn func(tx *SQLiteStore) error) error {
+	if s.db.tx != nil {
+		return fn(s)
+	}
+	if tx := contextTx(ctx, s.db.DB); tx != nil {
+		return fn(s.boundTo(tx))
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txStore := s.boundTo(tx)
+	if err := fn(txStore); err != nil {
+		return err
+	}
+	if err := txStore.db.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// boundTo returns a store running its statements in tx
+func (s *SQLiteStore) boundTo(tx *sql.Tx) *SQLiteStore {
+	return &SQLiteStore{db: tracedDB{DB: s.db.DB, tx: tx, stmts: s.db.stmts, readOnly: s.db.readOnly}}
+}
+
 // Create stores a new session
 func (s *SQLiteStore) Create(ctx context.Context, session *Session) error {
 	query := `
-		INSERT INTO sessions (id, user_id, title, created_at, updated_at, last_message)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO sessions (id, user_id, title, created_at, updated_at, last_message, version, preset)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := s.db.ExecContext(ctx, query,
@@ -89,6 +644,8 @@ func (s *SQLiteStore) Create(ctx context.Context, session *Session) error {
 		session.CreatedAt,
 		session.UpdatedAt,
 		session.LastMessage,
+		session.Version,
+		session.Preset,
 	)
 
 	if err != nil {
@@ -101,23 +658,12 @@ func (s *SQLiteStore) Create(ctx context.Context, session *Session) error {
 // Get retrieves a session by ID
 func (s *SQLiteStore) Get(ctx context.Context, id uuid.UUID) (*Session, error) {
 	query := `
-		SELECT id, user_id, title, created_at, updated_at, last_message
+		SELECT ` + sessionColumns + `
 		FROM sessions
 		WHERE id = ?
 	`
 
-	var session Session
-	var idStr string
-
-	err := s.db.QueryRowContext(ctx, query, id.String()).Scan(
-		&idStr,
-		&session.UserID,
-		&session.Title,
-		&session.CreatedAt,
-		&session.UpdatedAt,
-		&session.LastMessage,
-	)
-
+	session, err := scanSession(s.db.QueryRowContext(ctx, query, id.String()))
 	if err == sql.ErrNoRows {
 		return nil, ErrSessionNotFound
 	}
@@ -125,27 +671,26 @@ func (s *SQLiteStore) Get(ctx context.Context, id uuid.UUID) (*Session, error) {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
-	session.ID, err = uuid.Parse(idStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse session ID: %w", err)
-	}
-
-	return &session, nil
+	return session, nil
 }
 
-// Update modifies an existing session
+// Update modifies an existing session. The update only applies when the stored
+// version still matches session.Version; otherwise another write got there first
+// and ErrConflict is returned. On success session.Version is advanced.
 func (s *SQLiteStore) Update(ctx context.Context, session *Session) error {
 	query := `
 		UPDATE sessions
-		SET title = ?, updated_at = ?, last_message = ?
-		WHERE id = ?
+		SET title = ?, updated_at = ?, last_message = ?, preset = ?, version = version + 1
+		WHERE id = ? AND version = ?
 	`
 
 	result, err := s.db.ExecContext(ctx, query,
 		session.Title,
 		session.UpdatedAt,
 		session.LastMessage,
+		session.Preset,
 		session.ID.String(),
+		session.Version,
 	)
 
 	if err != nil {
@@ -157,6 +702,41 @@ func (s *SQLiteStore) Update(ctx context.Context, session *Session) error {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
+	if rows == 0 {
+		var exists int
+		err := s.db.QueryRowContext(ctx, `SELECT 1 FROM sessions WHERE id = ?`, session.ID.String()).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return ErrSessionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check session: %w", err)
+		}
+		return ErrConflict
+	}
+
+	session.Version++
+	return nil
+}
+
+// TouchSession sets the last message and updated_at of a session. Like Update it
+// bumps the version, so a concurrent read-modify-write retries on top of it.
+func (s *SQLiteStore) TouchSession(ctx context.Context, id uuid.UUID, lastMessage string) error {
+	query := `
+		UPDATE sessions
+		SET last_message = ?, updated_at = ?, version = version + 1
+		WHERE id = ?
+	`
+
+	result, err := s.db.ExecContext(ctx, query, lastMessage, time.Now(), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
 	if rows == 0 {
 		return ErrSessionNotFound
 	}
@@ -185,12 +765,13 @@ func (s *SQLiteStore) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// ListByUser returns sessions for a specific user with pagination
+// ListByUser returns sessions for a specific user with pagination; archived
+// sessions are left out
 func (s *SQLiteStore) ListByUser(ctx context.Context, userID int64, offset, limit int) ([]*Session, error) {
 	query := `
-		SELECT id, user_id, title, created_at, updated_at, last_message
+		SELECT ` + sessionColumns + `
 		FROM sessions
-		WHERE user_id = ?
+		WHERE user_id = ? AND archived_at IS NULL
 		ORDER BY updated_at DESC
 		LIMIT ? OFFSET ?
 	`
@@ -201,31 +782,77 @@ func (s *SQLiteStore) ListByUser(ctx context.Context, userID int64, offset, limi
 	}
 	defer rows.Close()
 
+	return scanSessions(rows)
+}
+
+// SearchByUser returns a user's sessions whose title or last message contains
+// query (case-insensitive), most recently updated first
+func (s *SQLiteStore) SearchByUser(ctx context.Context, userID int64, query string, offset, limit int) ([]*Session, error) {
+	sqlQuery := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = ?
+			AND (title LIKE ? ESCAPE '\' OR last_message LIKE ? ESCAPE '\')
+		ORDER BY updated_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	rows, err := s.db.QueryContext(ctx, sqlQuery, userID, pattern, pattern, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// likeEscaper escapes LIKE wildcards so user input matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// sessionColumns are the sessions columns read by scanSession, in order
+const sessionColumns = `id, user_id, title, created_at, updated_at, last_message, version, message_count, file_count, unread_count, preset`
+
+// scanSession reads a sessions row selected as sessionColumns into a Session
+func scanSession(scanner interface{ Scan(...any) error }) (*Session, error) {
+	var session Session
+	var idStr string
+
+	err := scanner.Scan(
+		&idStr,
+		&session.UserID,
+		&session.Title,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+		&session.LastMessage,
+		&session.Version,
+		&session.MessageCount,
+		&session.FileCount,
+		&session.UnreadCount,
+		&session.Preset,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	session.ID, err = uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session ID: %w", err)
+	}
+
+	return &session, nil
+}
+
+// scanSessions reads session rows selected as sessionColumns
+func scanSessions(rows *sql.Rows) ([]*Session, error) {
 	var sessions []*Session
 
 	for rows.Next() {
-		var session Session
-		var idStr string
-
-		err := rows.Scan(
-			&idStr,
-			&session.UserID,
-			&session.Title,
-			&session.CreatedAt,
-			&session.UpdatedAt,
-			&session.LastMessage,
-		)
-
+		session, err := scanSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
-
-		session.ID, err = uuid.Parse(idStr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse session ID: %w", err)
-		}
-
-		sessions = append(sessions, &session)
+		sessions = append(sessions, session)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -235,12 +862,16 @@ func (s *SQLiteStore) ListByUser(ctx context.Context, userID int64, offset, limi
 	return sessions, nil
 }
 
-// CountByUser returns total number of sessions for a user
+// CountByUser returns total number of sessions for a user, not counting archived ones
 func (s *SQLiteStore) CountByUser(ctx context.Context, userID int64) (int, error) {
-	query := `SELECT COUNT(*) FROM sessions WHERE user_id = ?`
+	// user_stats counts every session; the few archived ones are subtracted
+	query := `
+		SELECT COALESCE((SELECT sessions FROM user_stats WHERE user_id = ?), 0)
+			- (SELECT COUNT(*) FROM sessions WHERE user_id = ? AND archived_at IS NOT NULL)
+	`
 
 	var count int
-	err := s.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	err := s.db.QueryRowContext(ctx, query, userID, userID).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count sessions: %w", err)
 	}
@@ -251,24 +882,13 @@ func (s *SQLiteStore) CountByUser(ctx context.Context, userID int64) (int, error
 // GetActiveSession returns the current active session for a user
 func (s *SQLiteStore) GetActiveSession(ctx context.Context, userID int64) (*Session, error) {
 	query := `
-		SELECT s.id, s.user_id, s.title, s.created_at, s.updated_at, s.last_message
+		SELECT s.id, s.user_id, s.title, s.created_at, s.updated_at, s.last_message, s.version, s.message_count, s.file_count, s.unread_count, s.preset
 		FROM sessions s
 		INNER JOIN active_sessions a ON s.id = a.session_id
 		WHERE a.user_id = ?
 	`
 
-	var session Session
-	var idStr string
-
-	err := s.db.QueryRowContext(ctx, query, userID).Scan(
-		&idStr,
-		&session.UserID,
-		&session.Title,
-		&session.CreatedAt,
-		&session.UpdatedAt,
-		&session.LastMessage,
-	)
-
+	session, err := scanSession(s.db.QueryRowContext(ctx, query, userID))
 	if err == sql.ErrNoRows {
 		return nil, ErrSessionNotFound
 	}
@@ -276,12 +896,7 @@ func (s *SQLiteStore) GetActiveSession(ctx context.Context, userID int64) (*Sess
 		return nil, fmt.Errorf("failed to get active session: %w", err)
 	}
 
-	session.ID, err = uuid.Parse(idStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse session ID: %w", err)
-	}
-
-	return &session, nil
+	return session, nil
 }
 
 // SetActiveSession sets the active session for a user
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListRecentSessions returns sessions of all users matching query, most recently updated first
+func (s *SQLiteStore) ListRecentSessions(ctx context.Context, query string, offset, limit int) ([]*Session, error) {
+	sqlQuery := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE title LIKE ? ESCAPE '\' OR last_message LIKE ? ESCAPE '\'
+		ORDER BY updated_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	rows, err := s.db.QueryContext(ctx, sqlQuery, pattern, pattern, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// ListPendingSessions returns unarchived sessions with unanswered user messages
+// sent before waitingBefore, the longest waiting first
+func (s *SQLiteStore) ListPendingSessions(ctx context.Context, waitingBefore time.Time, offset, limit int) ([]*PendingSession, error) {
+	query := `
+		SELECT s.id, s.user_id, s.title, s.created_at, s.updated_at, s.last_message, s.version, s.message_count, s.file_count, s.unread_count, s.preset,
+			m.created_at
+		FROM sessions s
+		INNER JOIN messages m ON m.rowid = (
+			SELECT rowid FROM messages
+			WHERE session_id = s.id AND role = 'user' AND replied_at IS NULL
+			ORDER BY created_at, rowid
+			LIMIT 1
+		)
+		WHERE s.unread_count > 0 AND s.archived_at IS NULL AND m.created_at < ?
+		ORDER BY m.created_at
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, waitingBefore, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*PendingSession
+	for rows.Next() {
+		var p PendingSession
+		p.Session, err = scanSession(pendingScanner{rows: rows, waitingSince: &p.WaitingSince})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pending session: %w", err)
+		}
+		pending = append(pending, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending sessions: %w", err)
+	}
+
+	return pending, nil
+}
+
+// pendingScanner scans the session columns read by scanSession followed by the
+// time the session has been waiting since
+type pendingScanner struct {
+	rows         *sql.Rows
+	waitingSince *time.Time
+}
+
+func (p pendingScanner) Scan(dest ...any) error {
+	return p.rows.Scan(append(dest, p.waitingSince)...)
+}
+
+// ListSessionMessages returns a page of a session's messages, oldest first
+func (s *SQLiteStore) ListSessionMessages(ctx context.Context, sessionID uuid.UUID, offset, limit int) ([]*Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE session_id = ?
+		ORDER BY created_at ASC, rowid ASC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, sessionID.String(), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// RecordAudit appends an entry to the audit log and sets its ID
+func (s *SQLiteStore) RecordAudit(ctx context.Context, entry *AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target, payload_hash, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.ExecContext(ctx, query, entry.ActorID, entry.Action, entry.Target, entry.PayloadHash, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	entry.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get audit entry ID: %w", err)
+	}
+	return nil
+}
+
+// ListAudit returns audit entries, newest first, optionally of one action or actor
+func (s *SQLiteStore) ListAudit(ctx context.Context, action string, actorID int64, offset, limit int) ([]*AuditEntry, error) {
+	query := `
+		SELECT id, actor_id, action, target, payload_hash, created_at
+		FROM audit_log
+		WHERE (? = '' OR action = ?) AND (? = 0 OR actor_id = ?)
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, action, action, actorID, actorID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.Action, &entry.Target, &entry.PayloadHash, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,17 @@
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// BackupTo writes a consistent, compacted copy of the database to a new file at
+// path with VACUUM INTO. Writers may continue meanwhile; the copy reflects the
+// moment the statement started. It only reads the database, so it also runs
+// while the store is read-only.
+func (s *SQLiteStore) BackupTo(ctx context.Context, path string) error {
+	if _, err := s.db.exec(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// BanUser adds a ban, replacing the reason of an existing one
+func (s *SQLiteStore) BanUser(ctx context.Context, ban *Ban) error {
+	query := `
+		INSERT INTO banned_users (user_id, reason, banned_by, banned_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			reason = excluded.reason, banned_by = excluded.banned_by, banned_at = excluded.banned_at
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, ban.UserID, ban.Reason, ban.BannedBy, ban.BannedAt); err != nil {
+		return fmt.Errorf("failed to ban user: %w", err)
+	}
+	return nil
+}
+
+// UnbanUser lifts the ban of userID
+func (s *SQLiteStore) UnbanUser(ctx context.Context, userID int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM banned_users WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unban user: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check unban: %w", err)
+	}
+	if rows == 0 {
+		return ErrBanNotFound
+	}
+	return nil
+}
+
+// GetBan returns the ban of userID
+func (s *SQLiteStore) GetBan(ctx context.Context, userID int64) (*Ban, error) {
+	query := `SELECT user_id, reason, banned_by, banned_at FROM banned_users WHERE user_id = ?`
+
+	var ban Ban
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&ban.UserID, &ban.Reason, &ban.BannedBy, &ban.BannedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ban: %w", err)
+	}
+	return &ban, nil
+}
+
+// ListBans returns bans, the most recent first
+func (s *SQLiteStore) ListBans(ctx context.Context, offset, limit int) ([]*Ban, error) {
+	query := `
+		SELECT user_id, reason, banned_by, banned_at
+		FROM banned_users
+		ORDER BY banned_at DESC, user_id
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []*Ban
+	for rows.Next() {
+		var ban Ban
+		if err := rows.Scan(&ban.UserID, &ban.Reason, &ban.BannedBy, &ban.BannedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ban: %w", err)
+		}
+		bans = append(bans, &ban)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	return bans, nil
+}
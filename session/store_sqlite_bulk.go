@@ -0,0 +1,112 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ArchiveSessions archives sessions of userID in one transaction and unbinds
+// the chats, topics and business chats that had them open
+func (s *SQLiteStore) ArchiveSessions(ctx context.Context, userID int64, ids []uuid.UUID) (int, error) {
+	archivedAt := time.Now()
+	return s.bulkUpdate(ctx, userID, ids, func(tx *SQLiteStore, id uuid.UUID) error {
+		return tx.archiveSession(ctx, id, archivedAt)
+	})
+}
+
+// archiveSession unbinds a session from its chats, topics and business chats and
+// archives it; callers run it inside a transaction
+func (s *SQLiteStore) archiveSession(ctx context.Context, id uuid.UUID, archivedAt time.Time) error {
+	queries := []string{
+		`DELETE FROM active_sessions WHERE session_id = ?`,
+		`DELETE FROM topic_sessions WHERE session_id = ?`,
+		`DELETE FROM business_chat_sessions WHERE session_id = ?`,
+	}
+	for _, query := range queries {
+		if _, err := s.db.ExecContext(ctx, query, id.String()); err != nil {
+			return fmt.Errorf("failed to unbind session: %w", err)
+		}
+	}
+
+	query := `UPDATE sessions SET archived_at = ?, version = version + 1 WHERE id = ? AND archived_at IS NULL`
+	if _, err := s.db.ExecContext(ctx, query, archivedAt, id.String()); err != nil {
+		return fmt.Errorf("failed to archive session: %w", err)
+	}
+	return nil
+}
+
+// DeleteSessions deletes sessions of userID in one transaction. Their files
+// stay in the catalog without a session.
+func (s *SQLiteStore) DeleteSessions(ctx context.Context, userID int64, ids []uuid.UUID) (int, error) {
+	return s.bulkUpdate(ctx, userID, ids, func(tx *SQLiteStore, id uuid.UUID) error {
+		if err := tx.DetachSessionFiles(ctx, id); err != nil {
+			return err
+		}
+		return tx.Delete(ctx, id)
+	})
+}
+
+// bulkUpdate applies update to every session in ids inside one transaction,
+// after checking that each belongs to userID. Missing sessions are skipped.
+func (s *SQLiteStore) bulkUpdate(ctx context.Context, userID int64, ids []uuid.UUID, update func(tx *SQLiteStore, id uuid.UUID) error) (int, error) {
+	var count int
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		count = 0
+		for _, id := range ids {
+			sess, err := tx.Get(ctx, id)
+			if errors.Is(err, ErrSessionNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if sess.UserID != userID {
+				return ErrUnauthorized
+			}
+
+			if err := update(tx, id); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Unarchive lists an archived session again
+func (s *SQLiteStore) Unarchive(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE sessions SET archived_at = NULL, version = version + 1 WHERE id = ? AND archived_at IS NOT NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, id.String()); err != nil {
+		return fmt.Errorf("failed to unarchive session: %w", err)
+	}
+
+	return nil
+}
+
+// ListArchivedByUser returns the archived sessions of a user, most recently updated first
+func (s *SQLiteStore) ListArchivedByUser(ctx context.Context, userID int64, offset, limit int) ([]*Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = ? AND archived_at IS NOT NULL
+		ORDER BY updated_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SaveBusinessConnection creates or replaces a business connection
+func (s *SQLiteStore) SaveBusinessConnection(ctx context.Context, connection *BusinessConnection) error {
+	query := `
+		INSERT INTO business_connections (id, user_id, user_chat_id, can_reply, is_enabled, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			user_id = excluded.user_id,
+			user_chat_id = excluded.user_chat_id,
+			can_reply = excluded.can_reply,
+			is_enabled = excluded.is_enabled,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.ExecContext(ctx, query, connection.ID, connection.UserID, connection.UserChatID,
+		connection.CanReply, connection.IsEnabled, connection.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save business connection: %w", err)
+	}
+
+	return nil
+}
+
+// GetBusinessConnection returns the business connection with id
+func (s *SQLiteStore) GetBusinessConnection(ctx context.Context, id string) (*BusinessConnection, error) {
+	query := `
+		SELECT id, user_id, user_chat_id, can_reply, is_enabled, updated_at
+		FROM business_connections
+		WHERE id = ?
+	`
+
+	var connection BusinessConnection
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&connection.ID,
+		&connection.UserID,
+		&connection.UserChatID,
+		&connection.CanReply,
+		&connection.IsEnabled,
+		&connection.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrBusinessConnectionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get business connection: %w", err)
+	}
+
+	return &connection, nil
+}
+
+// GetBusinessChatSession returns the active session of a business account in one of its chats
+func (s *SQLiteStore) GetBusinessChatSession(ctx context.Context, userID int64, chat BusinessChat) (*Session, error) {
+	query := `
+		SELECT s.id, s.user_id, s.title, s.created_at, s.updated_at, s.last_message, s.version, s.message_count, s.file_count, s.unread_count, s.preset
+		FROM sessions s
+		INNER JOIN business_chat_sessions b ON s.id = b.session_id
+		WHERE b.connection_id = ? AND b.chat_id = ? AND b.user_id = ?
+	`
+
+	session, err := scanSession(s.db.QueryRowContext(ctx, query, chat.ConnectionID, chat.ChatID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get business chat session: %w", err)
+	}
+
+	return session, nil
+}
+
+// SetBusinessChatSession sets the active session of a business account in one of its chats
+func (s *SQLiteStore) SetBusinessChatSession(ctx context.Context, userID int64, chat BusinessChat, sessionID uuid.UUID) error {
+	query := `
+		INSERT INTO business_chat_sessions (connection_id, chat_id, user_id, session_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(connection_id, chat_id) DO UPDATE SET
+			user_id = excluded.user_id,
+			session_id = excluded.session_id
+	`
+
+	_, err := s.db.ExecContext(ctx, query, chat.ConnectionID, chat.ChatID, userID, sessionID.String())
+	if err != nil {
+		return fmt.Errorf("failed to set business chat session: %w", err)
+	}
+
+	return nil
+}
+
+// ClearBusinessChatSession removes the active session binding of a business account in one of its chats
+func (s *SQLiteStore) ClearBusinessChatSession(ctx context.Context, userID int64, chat BusinessChat) error {
+	query := `DELETE FROM business_chat_sessions WHERE connection_id = ? AND chat_id = ? AND user_id = ?`
+
+	if _, err := s.db.ExecContext(ctx, query, chat.ConnectionID, chat.ChatID, userID); err != nil {
+		return fmt.Errorf("failed to clear business chat session: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CreateCallbackToken stores data under token until expiresAt and removes
+// tokens that expired before createdAt
+func (s *SQLiteStore) CreateCallbackToken(ctx context.Context, token, data string, createdAt, expiresAt time.Time) error {
+	return s.withTx(ctx, func(tx *SQLiteStore) error {
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM callback_tokens WHERE expires_at < ?`, createdAt.UTC()); err != nil {
+			return fmt.Errorf("failed to remove expired callback tokens: %w", err)
+		}
+
+		query := `
+			INSERT INTO callback_tokens (token, data, created_at, expires_at)
+			VALUES (?, ?, ?, ?)
+		`
+		if _, err := tx.db.ExecContext(ctx, query, token, data, createdAt, expiresAt.UTC()); err != nil {
+			return fmt.Errorf("failed to create callback token: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetCallbackToken returns the data stored under token unless it expired at now
+func (s *SQLiteStore) GetCallbackToken(ctx context.Context, token string, now time.Time) (string, error) {
+	query := `
+		SELECT data
+		FROM callback_tokens
+		WHERE token = ? AND expires_at >= ?
+	`
+
+	var data string
+	err := s.db.QueryRowContext(ctx, query, token, now.UTC()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return "", ErrCallbackTokenNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get callback token: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TrackChannelPost records a post in channel
+func (s *SQLiteStore) TrackChannelPost(ctx context.Context, channel *Channel, postedAt time.Time) error {
+	query := `
+		INSERT INTO channels (chat_id, title, username, posts, first_post_at, last_post_at)
+		VALUES (?, ?, ?, 1, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			title = excluded.title,
+			username = excluded.username,
+			posts = posts + 1,
+			last_post_at = MAX(last_post_at, excluded.last_post_at)
+	`
+
+	_, err := s.db.ExecContext(ctx, query, channel.ChatID, channel.Title, channel.Username, postedAt, postedAt)
+	if err != nil {
+		return fmt.Errorf("failed to track channel post: %w", err)
+	}
+
+	return nil
+}
+
+// ListChannels returns tracked channels, most recently active first
+func (s *SQLiteStore) ListChannels(ctx context.Context) ([]*Channel, error) {
+	query := `
+		SELECT chat_id, title, username, posts, first_post_at, last_post_at
+		FROM channels
+		ORDER BY last_post_at DESC, chat_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channels: %w", err)
+	}
+	defer rows.Close()
+
+	var channels []*Channel
+	for rows.Next() {
+		var channel Channel
+		if err := rows.Scan(&channel.ChatID, &channel.Title, &channel.Username, &channel.Posts,
+			&channel.FirstPostAt, &channel.LastPostAt); err != nil {
+			return nil, fmt.Errorf("failed to scan channel: %w", err)
+		}
+		channels = append(channels, &channel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list channels: %w", err)
+	}
+
+	return channels, nil
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeferMessage queues a message and sets its ID
+func (s *SQLiteStore) DeferMessage(ctx context.Context, message *DeferredMessage) error {
+	query := `
+		INSERT INTO deferred_messages (user_id, chat_id, text, parse_mode, reply_markup, deliver_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.ExecContext(ctx, query, message.UserID, message.ChatID, message.Text, message.ParseMode,
+		message.ReplyMarkup, message.DeliverAt.UTC(), message.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to defer message: %w", err)
+	}
+
+	message.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get deferred message ID: %w", err)
+	}
+
+	return nil
+}
+
+// ListDueMessages returns up to limit queued messages to deliver at or before now, oldest first
+func (s *SQLiteStore) ListDueMessages(ctx context.Context, now time.Time, limit int) ([]*DeferredMessage, error) {
+	query := `
+		SELECT id, user_id, chat_id, text, parse_mode, reply_markup, deliver_at, created_at
+		FROM deferred_messages
+		WHERE deliver_at <= ?
+		ORDER BY deliver_at, id
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deferred messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*DeferredMessage
+	for rows.Next() {
+		var message DeferredMessage
+		if err := rows.Scan(&message.ID, &message.UserID, &message.ChatID, &message.Text, &message.ParseMode,
+			&message.ReplyMarkup, &message.DeliverAt, &message.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deferred message: %w", err)
+		}
+		messages = append(messages, &message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list deferred messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// DeleteDeferredMessage removes a delivered message from the queue
+func (s *SQLiteStore) DeleteDeferredMessage(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM deferred_messages WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete deferred message: %w", err)
+	}
+	return nil
+}
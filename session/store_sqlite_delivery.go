@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const sentMessageColumns = `chat_id, telegram_message_id, user_id, session_id, message_id, reply_to_message_id, sent_at`
+
+// RecordSentMessage tracks a sent message; tracking it again replaces the earlier record
+func (s *SQLiteStore) RecordSentMessage(ctx context.Context, sent *SentMessage) error {
+	query := `
+		INSERT OR REPLACE INTO sent_messages (` + sentMessageColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, sent.ChatID, sent.TelegramMessageID, sent.UserID,
+		nullUUID(sent.SessionID), nullUUID(sent.MessageID), sent.ReplyToMessageID, sent.SentAt); err != nil {
+		return fmt.Errorf("failed to record sent message: %w", err)
+	}
+	return nil
+}
+
+// GetSentMessage returns a sent message by its Telegram IDs
+func (s *SQLiteStore) GetSentMessage(ctx context.Context, chatID int64, telegramMessageID int) (*SentMessage, error) {
+	query := `SELECT ` + sentMessageColumns + ` FROM sent_messages WHERE chat_id = ? AND telegram_message_id = ?`
+
+	sent, err := scanSentMessage(s.db.QueryRowContext(ctx, query, chatID, telegramMessageID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSentMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sent message: %w", err)
+	}
+	return sent, nil
+}
+
+// ListReplies returns the messages sent in answer to a stored message, in the order they were sent
+func (s *SQLiteStore) ListReplies(ctx context.Context, messageID uuid.UUID) ([]*SentMessage, error) {
+	query := `
+		SELECT ` + sentMessageColumns + `
+		FROM sent_messages
+		WHERE message_id = ?
+		ORDER BY sent_at, telegram_message_id
+	`
+	return s.listSentMessages(ctx, query, messageID.String())
+}
+
+// ListSessionSentMessages returns up to limit messages sent in a session, the most recent first
+func (s *SQLiteStore) ListSessionSentMessages(ctx context.Context, sessionID uuid.UUID, limit int) ([]*SentMessage, error) {
+	query := `
+		SELECT ` + sentMessageColumns + `
+		FROM sent_messages
+		WHERE session_id = ?
+		ORDER BY sent_at DESC, telegram_message_id DESC
+		LIMIT ?
+	`
+	return s.listSentMessages(ctx, query, sessionID.String(), limit)
+}
+
+// listSentMessages runs a query selecting sentMessageColumns
+func (s *SQLiteStore) listSentMessages(ctx context.Context, query string, args ...any) ([]*SentMessage, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sent messages: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*SentMessage
+	for rows.Next() {
+		sent, err := scanSentMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sent message: %w", err)
+		}
+		list = append(list, sent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sent messages: %w", err)
+	}
+	return list, nil
+}
+
+// scanSentMessage reads a row of sentMessageColumns
+func scanSentMessage(scanner interface{ Scan(...any) error }) (*SentMessage, error) {
+	var sent SentMessage
+	var sessionID, messageID sql.NullString
+	if err := scanner.Scan(&sent.ChatID, &sent.TelegramMessageID, &sent.UserID, &sessionID, &messageID,
+		&sent.ReplyToMessageID, &sent.SentAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if sessionID.Valid {
+		if sent.SessionID, err = uuid.Parse(sessionID.String); err != nil {
+			return nil, fmt.Errorf("invalid session ID: %w", err)
+		}
+	}
+	if messageID.Valid {
+		if sent.MessageID, err = uuid.Parse(messageID.String); err != nil {
+			return nil, fmt.Errorf("invalid message ID: %w", err)
+		}
+	}
+	return &sent, nil
+}
+
+// nullUUID stores uuid.Nil as NULL, which foreign keys accept
+func nullUUID(id uuid.UUID) sql.NullString {
+	if id == uuid.Nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: id.String(), Valid: true}
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StartDraft creates an empty draft
+func (s *SQLiteStore) StartDraft(ctx context.Context, draft *Draft) error {
+	query := `
+		INSERT INTO drafts (user_id, chat_id, started_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO NOTHING
+	`
+
+	result, err := s.db.ExecContext(ctx, query, draft.UserID, draft.ChatID, draft.StartedAt, draft.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to start draft: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check draft: %w", err)
+	}
+	if rows == 0 {
+		return ErrDraftExists
+	}
+	return nil
+}
+
+// AppendDraftPart adds a message to the user's draft and returns how many it has
+func (s *SQLiteStore) AppendDraftPart(ctx context.Context, userID int64, text string, now time.Time) (int, error) {
+	var count int
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		result, err := tx.db.ExecContext(ctx, `UPDATE drafts SET updated_at = ? WHERE user_id = ?`, now, userID)
+		if err != nil {
+			return fmt.Errorf("failed to update draft: %w", err)
+		}
+		if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+			return ErrDraftNotFound
+		}
+
+		if err := tx.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM draft_parts WHERE user_id = ?`, userID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count draft parts: %w", err)
+		}
+		if count >= MaxDraftParts {
+			return ErrDraftFull
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `INSERT INTO draft_parts (user_id, text, created_at) VALUES (?, ?, ?)`,
+			userID, text, now); err != nil {
+			return fmt.Errorf("failed to append draft part: %w", err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetDraft returns the user's draft with its messages
+func (s *SQLiteStore) GetDraft(ctx context.Context, userID int64) (*Draft, error) {
+	draft := Draft{UserID: userID}
+	err := s.db.QueryRowContext(ctx, `SELECT chat_id, started_at, updated_at FROM drafts WHERE user_id = ?`, userID).
+		Scan(&draft.ChatID, &draft.StartedAt, &draft.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT text FROM draft_parts WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list draft parts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, fmt.Errorf("failed to scan draft part: %w", err)
+		}
+		draft.Parts = append(draft.Parts, text)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list draft parts: %w", err)
+	}
+	return &draft, nil
+}
+
+// DeleteDraft removes the user's draft; its messages go with it
+func (s *SQLiteStore) DeleteDraft(ctx context.Context, userID int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM drafts WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check draft deletion: %w", err)
+	}
+	if rows == 0 {
+		return ErrDraftNotFound
+	}
+	return nil
+}
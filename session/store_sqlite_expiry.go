@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListSessionsToWarn returns up to limit unarchived sessions last updated before
+// idleBefore whose owners were not warned since, ordered by owner
+func (s *SQLiteStore) ListSessionsToWarn(ctx context.Context, idleBefore time.Time, limit int) ([]*Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE archived_at IS NULL AND updated_at < ?
+			AND (expiry_warned_at IS NULL OR expiry_warned_at < updated_at)
+		ORDER BY user_id, updated_at
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, idleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions to warn: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// MarkExpiryWarned records that the owners of sessions were warned at warnedAt
+func (s *SQLiteStore) MarkExpiryWarned(ctx context.Context, ids []uuid.UUID, warnedAt time.Time) error {
+	return s.withTx(ctx, func(tx *SQLiteStore) error {
+		for _, id := range ids {
+			if _, err := tx.db.ExecContext(ctx, `UPDATE sessions SET expiry_warned_at = ? WHERE id = ?`, warnedAt, id.String()); err != nil {
+				return fmt.Errorf("failed to mark expiry warning: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ArchiveExpiredSessions archives the sessions last updated before idleBefore
+// whose owners were warned at or before warnedBefore, in one transaction
+func (s *SQLiteStore) ArchiveExpiredSessions(ctx context.Context, idleBefore, warnedBefore time.Time) (int, error) {
+	query := `
+		SELECT id
+		FROM sessions
+		WHERE archived_at IS NULL AND updated_at < ?
+			AND expiry_warned_at >= updated_at AND expiry_warned_at <= ?
+	`
+
+	var count int
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		rows, err := tx.db.QueryContext(ctx, query, idleBefore, warnedBefore)
+		if err != nil {
+			return fmt.Errorf("failed to list expired sessions: %w", err)
+		}
+		var ids []uuid.UUID
+		for rows.Next() {
+			var idStr string
+			if err := rows.Scan(&idStr); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan expired session: %w", err)
+			}
+			id, err := uuid.Parse(idStr)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to parse session ID: %w", err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to list expired sessions: %w", err)
+		}
+
+		archivedAt := time.Now()
+		for _, id := range ids {
+			if err := tx.archiveSession(ctx, id, archivedAt); err != nil {
+				return err
+			}
+		}
+		count = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
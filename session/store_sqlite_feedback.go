@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AddFeedback stores feedback and sets its ID
+func (s *SQLiteStore) AddFeedback(ctx context.Context, feedback *Feedback) error {
+	query := `
+		INSERT INTO feedback (user_id, session_id, text, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := s.db.ExecContext(ctx, query, feedback.UserID, nullableUUID(feedback.SessionID), feedback.Text, feedback.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add feedback: %w", err)
+	}
+
+	feedback.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get feedback ID: %w", err)
+	}
+
+	return nil
+}
+
+// ListOpenFeedback returns unresolved feedback, oldest first
+func (s *SQLiteStore) ListOpenFeedback(ctx context.Context, offset, limit int) ([]*Feedback, error) {
+	query := `
+		SELECT f.id, f.user_id, f.session_id, COALESCE(s.title, ''), f.text, f.created_at
+		FROM feedback f
+		LEFT JOIN sessions s ON s.id = f.session_id
+		WHERE f.resolved_at IS NULL
+		ORDER BY f.id
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*Feedback
+	for rows.Next() {
+		var feedback Feedback
+		var sessionIDStr string
+		if err := rows.Scan(&feedback.ID, &feedback.UserID, &sessionIDStr, &feedback.SessionTitle,
+			&feedback.Text, &feedback.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feedback: %w", err)
+		}
+		if sessionIDStr != "" {
+			feedback.SessionID, err = uuid.Parse(sessionIDStr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse session ID: %w", err)
+			}
+		}
+		list = append(list, &feedback)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list feedback: %w", err)
+	}
+
+	return list, nil
+}
+
+// CountOpenFeedback returns the number of unresolved feedback rows
+func (s *SQLiteStore) CountOpenFeedback(ctx context.Context) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM feedback WHERE resolved_at IS NULL`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count feedback: %w", err)
+	}
+	return count, nil
+}
+
+// ResolveFeedback marks open feedback as resolved by an administrator
+func (s *SQLiteStore) ResolveFeedback(ctx context.Context, id, resolvedBy int64, resolvedAt time.Time) error {
+	query := `UPDATE feedback SET resolved_at = ?, resolved_by = ? WHERE id = ? AND resolved_at IS NULL`
+
+	result, err := s.db.ExecContext(ctx, query, resolvedAt, resolvedBy, id)
+	if err != nil {
+		return fmt.Errorf("failed to resolve feedback: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrFeedbackNotFound
+	}
+
+	return nil
+}
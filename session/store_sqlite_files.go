@@ -0,0 +1,319 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const fileColumns = `id, user_id, session_id, kind, telegram_file_id, file_name, mime_type, media_group_id,
+	sticker_set_name, sticker_emoji, sticker_type, sticker_custom_emoji_id, sticker_animated, sticker_video,
+	storage_key, size, created_at`
+
+// scanFile reads a files row into a File
+func scanFile(scanner interface{ Scan(...any) error }) (*File, error) {
+	var file File
+	var idStr, sessionIDStr string
+	var sticker StickerInfo
+
+	err := scanner.Scan(
+		&idStr,
+		&file.UserID,
+		&sessionIDStr,
+		&file.Kind,
+		&file.TelegramFileID,
+		&file.FileName,
+		&file.MimeType,
+		&file.MediaGroupID,
+		&sticker.SetName,
+		&sticker.Emoji,
+		&sticker.Type,
+		&sticker.CustomEmojiID,
+		&sticker.IsAnimated,
+		&sticker.IsVideo,
+		&file.StorageKey,
+		&file.Size,
+		&file.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	file.ID, err = uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file ID: %w", err)
+	}
+
+	if sessionIDStr != "" {
+		file.SessionID, err = uuid.Parse(sessionIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse file session ID: %w", err)
+		}
+	}
+
+	if file.Kind == "sticker" {
+		file.Sticker = &sticker
+	}
+
+	return &file, nil
+}
+
+// CreateFile stores a new catalog entry
+func (s *SQLiteStore) CreateFile(ctx context.Context, file *File) error {
+	query := `
+		INSERT INTO files (` + fileColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var sticker StickerInfo
+	if file.Sticker != nil {
+		sticker = *file.Sticker
+	}
+
+	_, err := s.db.ExecContext(ctx, query,
+		file.ID.String(),
+		file.UserID,
+		nullableUUID(file.SessionID),
+		file.Kind,
+		file.TelegramFileID,
+		file.FileName,
+		file.MimeType,
+		file.MediaGroupID,
+		sticker.SetName,
+		sticker.Emoji,
+		sticker.Type,
+		sticker.CustomEmojiID,
+		sticker.IsAnimated,
+		sticker.IsVideo,
+		file.StorageKey,
+		file.Size,
+		file.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	return nil
+}
+
+// GetFile retrieves a catalog entry by ID
+func (s *SQLiteStore) GetFile(ctx context.Context, id uuid.UUID) (*File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files WHERE id = ?`
+
+	file, err := scanFile(s.db.QueryRowContext(ctx, query, id.String()))
+	if err == sql.ErrNoRows {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	return file, nil
+}
+
+// DeleteFile removes a catalog entry
+func (s *SQLiteStore) DeleteFile(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM files WHERE id = ?`
+
+	result, err := s.db.ExecContext(ctx, query, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrFileNotFound
+	}
+
+	return nil
+}
+
+// ListFilesByUser returns files for a specific user with pagination, newest first
+func (s *SQLiteStore) ListFilesByUser(ctx context.Context, userID int64, offset, limit int) ([]*File, error) {
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	return s.queryFiles(ctx, query, userID, limit, offset)
+}
+
+// ListFilesBySession returns files attached to a session, oldest first
+func (s *SQLiteStore) ListFilesBySession(ctx context.Context, sessionID uuid.UUID) ([]*File, error) {
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE session_id = ?
+		ORDER BY created_at ASC
+	`
+
+	return s.queryFiles(ctx, query, sessionID.String())
+}
+
+// DetachSessionFiles clears the session link of all files attached to a session
+func (s *SQLiteStore) DetachSessionFiles(ctx context.Context, sessionID uuid.UUID) error {
+	query := `UPDATE files SET session_id = '' WHERE session_id = ?`
+
+	if _, err := s.db.ExecContext(ctx, query, sessionID.String()); err != nil {
+		return fmt.Errorf("failed to detach session files: %w", err)
+	}
+
+	return nil
+}
+
+// ListRecentStickerSets returns the sticker sets a user sent, most recently used first
+func (s *SQLiteStore) ListRecentStickerSets(ctx context.Context, userID int64, limit int) ([]*StickerSetUsage, error) {
+	query := `
+		SELECT sticker_set_name, MAX(sticker_type), COUNT(*), GROUP_CONCAT(DISTINCT sticker_emoji)
+		FROM files
+		WHERE user_id = ? AND kind = 'sticker' AND sticker_set_name != ''
+		GROUP BY sticker_set_name
+		ORDER BY MAX(created_at) DESC
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sticker sets: %w", err)
+	}
+	defer rows.Close()
+
+	var sets []*StickerSetUsage
+	for rows.Next() {
+		var set StickerSetUsage
+		var emojis sql.NullString
+		if err := rows.Scan(&set.SetName, &set.Type, &set.Stickers, &emojis); err != nil {
+			return nil, fmt.Errorf("failed to scan sticker set: %w", err)
+		}
+		set.Emojis = strings.ReplaceAll(emojis.String, ",", "")
+		sets = append(sets, &set)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sticker sets: %w", err)
+	}
+
+	return sets, nil
+}
+
+// ListOldestFiles returns files of all users, oldest first
+func (s *SQLiteStore) ListOldestFiles(ctx context.Context, offset, limit int) ([]*File, error) {
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		ORDER BY created_at ASC
+		LIMIT ? OFFSET ?
+	`
+
+	return s.queryFiles(ctx, query, limit, offset)
+}
+
+// ListOldestFilesByUser returns files of one user, oldest first
+func (s *SQLiteStore) ListOldestFilesByUser(ctx context.Context, userID int64, offset, limit int) ([]*File, error) {
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+		LIMIT ? OFFSET ?
+	`
+
+	return s.queryFiles(ctx, query, userID, limit, offset)
+}
+
+// queryFiles runs a files query and scans every row
+func (s *SQLiteStore) queryFiles(ctx context.Context, query string, args ...any) ([]*File, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []*File
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file: %w", err)
+		}
+		files = append(files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating files: %w", err)
+	}
+
+	return files, nil
+}
+
+// CountFilesByUser returns total number of files for a user
+func (s *SQLiteStore) CountFilesByUser(ctx context.Context, userID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM files WHERE user_id = ?`
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count files: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetFileUsage returns the number and total size of files stored for a user
+func (s *SQLiteStore) GetFileUsage(ctx context.Context, userID int64) (*FileUsage, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(size), 0) FROM files WHERE user_id = ?`
+
+	usage := &FileUsage{UserID: userID}
+	if err := s.db.QueryRowContext(ctx, query, userID).Scan(&usage.Files, &usage.Bytes); err != nil {
+		return nil, fmt.Errorf("failed to get file usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// ListFileUsage returns file usage for every user that has stored files
+func (s *SQLiteStore) ListFileUsage(ctx context.Context) ([]*FileUsage, error) {
+	query := `
+		SELECT user_id, COUNT(*), SUM(size)
+		FROM files
+		GROUP BY user_id
+		ORDER BY SUM(size) DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usages []*FileUsage
+	for rows.Next() {
+		var usage FileUsage
+		if err := rows.Scan(&usage.UserID, &usage.Files, &usage.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan file usage: %w", err)
+		}
+		usages = append(usages, &usage)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating file usage: %w", err)
+	}
+
+	return usages, nil
+}
+
+// nullableUUID stores uuid.Nil as an empty string
+func nullableUUID(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// sessionBindingTables are the tables binding a chat or user to a session
+var sessionBindingTables = []string{"active_sessions", "topic_sessions", "business_chat_sessions"}
+
+// CheckIntegrity looks for orphaned session bindings, sessions with invalid
+// IDs and files attached to missing sessions, and fixes them with repair
+func (s *SQLiteStore) CheckIntegrity(ctx context.Context, repair bool) (*IntegrityReport, error) {
+	report := &IntegrityReport{}
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		*report = IntegrityReport{}
+
+		invalid, err := tx.invalidSessionIDs(ctx)
+		if err != nil {
+			return err
+		}
+		report.InvalidSessionIDs = len(invalid)
+		if repair {
+			// Their files are detached below along with other dangling files
+			for _, id := range invalid {
+				if _, err := tx.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id); err != nil {
+					return fmt.Errorf("failed to delete invalid session: %w", err)
+				}
+			}
+		}
+
+		for _, table := range sessionBindingTables {
+			where := `session_id NOT IN (SELECT id FROM sessions)`
+			count, err := tx.countOrDelete(ctx, table, where, repair)
+			if err != nil {
+				return err
+			}
+			report.OrphanedActiveSessions += count
+		}
+
+		var dangling int
+		where := `session_id != '' AND session_id NOT IN (SELECT id FROM sessions)`
+		if err := tx.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM files WHERE `+where).Scan(&dangling); err != nil {
+			return fmt.Errorf("failed to count dangling files: %w", err)
+		}
+		report.DanglingFiles = dangling
+		if repair && dangling > 0 {
+			if _, err := tx.db.ExecContext(ctx, `UPDATE files SET session_id = '' WHERE `+where); err != nil {
+				return fmt.Errorf("failed to detach dangling files: %w", err)
+			}
+		}
+
+		report.Repaired = repair && report.Problems() > 0
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// invalidSessionIDs returns the IDs of sessions that do not parse as UUIDs
+func (s *SQLiteStore) invalidSessionIDs(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM sessions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var invalid []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan session ID: %w", err)
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			invalid = append(invalid, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list session IDs: %w", err)
+	}
+	return invalid, nil
+}
+
+// countOrDelete counts the rows of table matching where, deleting them with remove
+func (s *SQLiteStore) countOrDelete(ctx context.Context, table, where string, remove bool) (int, error) {
+	if !remove {
+		var count int
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE `+where).Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed to count orphaned %s: %w", table, err)
+		}
+		return count, nil
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE `+where)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete orphaned %s: %w", table, err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(count), nil
+}
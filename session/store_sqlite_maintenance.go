@@ -0,0 +1,93 @@
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// autoVacuumIncremental is the PRAGMA auto_vacuum value of incremental mode
+const autoVacuumIncremental = 2
+
+// Maintain refreshes query planner statistics, optionally vacuums free pages
+// and checkpoints the write-ahead log
+func (s *SQLiteStore) Maintain(ctx context.Context, options MaintenanceOptions) (*MaintenanceReport, error) {
+	report := &MaintenanceReport{}
+
+	var err error
+	if report.BytesBefore, err = s.databaseSize(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `ANALYZE`); err != nil {
+		return nil, fmt.Errorf("failed to analyze database: %w", err)
+	}
+
+	if options.IncrementalVacuum {
+		if err := s.incrementalVacuum(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Last, so the log written by the steps above is folded in as well
+	var busy, logFrames int
+	if err := s.db.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &report.CheckpointedFrames); err != nil {
+		return nil, fmt.Errorf("failed to checkpoint write-ahead log: %w", err)
+	}
+	report.CheckpointBusy = busy != 0
+
+	if report.BytesAfter, err = s.databaseSize(ctx); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// incrementalVacuum releases all free pages, switching the database to incremental
+// auto-vacuum first when needed
+func (s *SQLiteStore) incrementalVacuum(ctx context.Context) error {
+	var mode int
+	if err := s.db.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&mode); err != nil {
+		return fmt.Errorf("failed to read auto_vacuum mode: %w", err)
+	}
+
+	if mode != autoVacuumIncremental {
+		// The new mode only takes effect once the same connection rebuilds the database
+		conn, err := s.db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get connection: %w", err)
+		}
+		defer conn.Close()
+
+		if _, err := conn.ExecContext(ctx, `PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+			return fmt.Errorf("failed to enable incremental auto_vacuum: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, `VACUUM`); err != nil {
+			return fmt.Errorf("failed to vacuum database: %w", err)
+		}
+		return nil
+	}
+
+	// incremental_vacuum frees pages as its result rows are stepped through
+	rows, err := s.db.QueryContext(ctx, `PRAGMA incremental_vacuum`)
+	if err != nil {
+		return fmt.Errorf("failed to vacuum free pages: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to vacuum free pages: %w", err)
+	}
+	return nil
+}
+
+// databaseSize returns the size of the main database file in bytes
+func (s *SQLiteStore) databaseSize(ctx context.Context) (int64, error) {
+	var pageCount, pageSize int64
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pageCount * pageSize, nil
+}
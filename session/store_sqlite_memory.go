@@ -0,0 +1,97 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SetMemory stores or replaces the value of key in a session's memory
+func (s *SQLiteStore) SetMemory(ctx context.Context, entry *MemoryEntry) error {
+	return s.withTx(ctx, func(tx *SQLiteStore) error {
+		// Replacing a key never fills the memory
+		var exists bool
+		var count int
+		err := tx.db.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM sessions WHERE id = ?),
+				(SELECT COUNT(*) FROM session_memory WHERE session_id = ? AND key != ?)
+		`, entry.SessionID.String(), entry.SessionID.String(), entry.Key).Scan(&exists, &count)
+		if err != nil {
+			return fmt.Errorf("failed to count memory entries: %w", err)
+		}
+		if !exists {
+			return ErrSessionNotFound
+		}
+		if count >= MaxMemoryEntries {
+			return ErrMemoryFull
+		}
+
+		query := `
+			INSERT INTO session_memory (session_id, key, value, updated_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (session_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+		`
+		if _, err := tx.db.ExecContext(ctx, query, entry.SessionID.String(), entry.Key, entry.Value, entry.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to set memory: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListMemory returns a session's memory ordered by key
+func (s *SQLiteStore) ListMemory(ctx context.Context, sessionID uuid.UUID) ([]*MemoryEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT key, value, updated_at FROM session_memory WHERE session_id = ? ORDER BY key
+	`, sessionID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memory: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*MemoryEntry
+	for rows.Next() {
+		entry := &MemoryEntry{SessionID: sessionID}
+		if err := rows.Scan(&entry.Key, &entry.Value, &entry.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan memory entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list memory: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteMemory removes key from a session's memory
+func (s *SQLiteStore) DeleteMemory(ctx context.Context, sessionID uuid.UUID, key string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM session_memory WHERE session_id = ? AND key = ?`, sessionID.String(), key)
+	if err != nil {
+		return fmt.Errorf("failed to delete memory: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrMemoryNotFound
+	}
+
+	return nil
+}
+
+// ClearMemory removes a session's whole memory
+func (s *SQLiteStore) ClearMemory(ctx context.Context, sessionID uuid.UUID) (int, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM session_memory WHERE session_id = ?`, sessionID.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear memory: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MergeSessions moves the messages and files of source into target and deletes
+// source, in one transaction. Messages keep their timestamps, so the target's
+// history reads chronologically with both sessions interleaved.
+func (s *SQLiteStore) MergeSessions(ctx context.Context, userID int64, sourceID, targetID uuid.UUID) (*MergeReport, error) {
+	if sourceID == targetID {
+		return nil, ErrSameSession
+	}
+
+	report := &MergeReport{}
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		source, err := tx.Get(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+		target, err := tx.Get(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		if source.UserID != userID || target.UserID != userID {
+			return ErrUnauthorized
+		}
+
+		move := func(count *int, query string) error {
+			result, err := tx.db.ExecContext(ctx, query, targetID.String(), sourceID.String())
+			if err != nil {
+				return fmt.Errorf("failed to merge sessions: %w", err)
+			}
+			rows, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to merge sessions: %w", err)
+			}
+			if count != nil {
+				*count = int(rows)
+			}
+			return nil
+		}
+
+		if err := move(&report.Messages, `UPDATE messages SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		if err := move(&report.Files, `UPDATE files SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		// Chats and topics on the source continue in the target
+		if err := move(nil, `UPDATE active_sessions SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		if err := move(nil, `UPDATE topic_sessions SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		if err := move(nil, `UPDATE business_chat_sessions SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		if err := move(nil, `UPDATE feedback SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		if err := move(nil, `UPDATE flagged_content SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		if err := move(nil, `UPDATE sent_messages SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		// Memory keys set on both keep the target's value
+		if err := move(nil, `UPDATE OR IGNORE session_memory SET session_id = ? WHERE session_id = ?`); err != nil {
+			return err
+		}
+		// The target's summary no longer covers its history; the source's goes with it
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM session_summaries WHERE session_id = ?`, targetID.String()); err != nil {
+			return fmt.Errorf("failed to merge sessions: %w", err)
+		}
+
+		if source.UpdatedAt.After(target.UpdatedAt) {
+			target.UpdatedAt = source.UpdatedAt
+			target.LastMessage = source.LastMessage
+		}
+		if err := tx.Update(ctx, target); err != nil {
+			return err
+		}
+		if err := tx.Delete(ctx, sourceID); err != nil {
+			return err
+		}
+
+		report.Target = target
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,353 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const messageColumns = `id, session_id, user_id, role, content, file_id, chat_id, telegram_message_id, created_at, edited_at, replied_at`
+
+// scanMessage reads a messages row into a Message
+func scanMessage(scanner interface{ Scan(...any) error }) (*Message, error) {
+	var message Message
+	var idStr, sessionIDStr, fileIDStr string
+	var editedAt, repliedAt sql.NullTime
+
+	err := scanner.Scan(
+		&idStr,
+		&sessionIDStr,
+		&message.UserID,
+		&message.Role,
+		&message.Content,
+		&fileIDStr,
+		&message.ChatID,
+		&message.TelegramMessageID,
+		&message.CreatedAt,
+		&editedAt,
+		&repliedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+	}
+	if repliedAt.Valid {
+		message.RepliedAt = &repliedAt.Time
+	}
+
+	if message.ID, err = uuid.Parse(idStr); err != nil {
+		return nil, fmt.Errorf("failed to parse message ID: %w", err)
+	}
+	if message.SessionID, err = uuid.Parse(sessionIDStr); err != nil {
+		return nil, fmt.Errorf("failed to parse message session ID: %w", err)
+	}
+	if fileIDStr != "" {
+		if message.FileID, err = uuid.Parse(fileIDStr); err != nil {
+			return nil, fmt.Errorf("failed to parse message file ID: %w", err)
+		}
+	}
+
+	return &message, nil
+}
+
+// AppendMessage stores a message and marks its session as updated
+func (s *SQLiteStore) AppendMessage(ctx context.Context, message *Message) error {
+	return s.withTx(ctx, func(tx *SQLiteStore) error {
+		result, err := tx.db.ExecContext(ctx, `UPDATE sessions SET updated_at = ?, version = version + 1 WHERE id = ?`,
+			message.CreatedAt, message.SessionID.String())
+		if err != nil {
+			return fmt.Errorf("failed to touch session: %w", err)
+		}
+		if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+			return ErrSessionNotFound
+		}
+
+		query := `
+			INSERT INTO messages (` + messageColumns + `)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`
+
+		_, err = tx.db.ExecContext(ctx, query,
+			message.ID.String(),
+			message.SessionID.String(),
+			message.UserID,
+			message.Role,
+			message.Content,
+			nullableUUID(message.FileID),
+			message.ChatID,
+			message.TelegramMessageID,
+			message.CreatedAt,
+			message.EditedAt,
+			message.RepliedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append message: %w", err)
+		}
+		return nil
+	})
+}
+
+// appendMessagesBatchSize is the number of rows per INSERT in AppendMessages,
+// well below SQLite's limit of 32766 bound parameters per statement
+const appendMessagesBatchSize = 500
+
+// AppendMessages stores messages of a session in one transaction, with one
+// multi-row INSERT per appendMessagesBatchSize messages, and marks the session
+// as updated once
+func (s *SQLiteStore) AppendMessages(ctx context.Context, sessionID uuid.UUID, messages []*Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	var updatedAt time.Time
+	for _, message := range messages {
+		if message.SessionID != sessionID {
+			return fmt.Errorf("message %s belongs to session %s, not %s", message.ID, message.SessionID, sessionID)
+		}
+		if message.CreatedAt.After(updatedAt) {
+			updatedAt = message.CreatedAt
+		}
+	}
+
+	return s.withTx(ctx, func(tx *SQLiteStore) error {
+		result, err := tx.db.ExecContext(ctx, `UPDATE sessions SET updated_at = ?, version = version + 1 WHERE id = ?`,
+			updatedAt, sessionID.String())
+		if err != nil {
+			return fmt.Errorf("failed to touch session: %w", err)
+		}
+		if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+			return ErrSessionNotFound
+		}
+
+		for start := 0; start < len(messages); start += appendMessagesBatchSize {
+			batch := messages[start:min(start+appendMessagesBatchSize, len(messages))]
+			rows := make([]string, len(batch))
+			args := make([]any, 0, len(batch)*11)
+			for i, message := range batch {
+				rows[i] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+				args = append(args,
+					message.ID.String(),
+					message.SessionID.String(),
+					message.UserID,
+					message.Role,
+					message.Content,
+					nullableUUID(message.FileID),
+					message.ChatID,
+					message.TelegramMessageID,
+					message.CreatedAt,
+					message.EditedAt,
+					message.RepliedAt,
+				)
+			}
+
+			query := `INSERT INTO messages (` + messageColumns + `) VALUES ` + strings.Join(rows, ", ")
+			if _, err := tx.db.ExecContext(ctx, query, args...); err != nil {
+				return fmt.Errorf("failed to append messages: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetMessage returns the message with id, or ErrMessageNotFound
+func (s *SQLiteStore) GetMessage(ctx context.Context, id uuid.UUID) (*Message, error) {
+	query := `SELECT ` + messageColumns + ` FROM messages WHERE id = ?`
+
+	message, err := scanMessage(s.db.QueryRowContext(ctx, query, id.String()))
+	if err == sql.ErrNoRows {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	return message, nil
+}
+
+// FindMessage returns the latest message stored for the Telegram message
+// chatID/telegramMessageID, or ErrMessageNotFound
+func (s *SQLiteStore) FindMessage(ctx context.Context, chatID int64, telegramMessageID int) (*Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE chat_id = ? AND telegram_message_id = ?
+		ORDER BY created_at DESC, rowid DESC
+		LIMIT 1
+	`
+
+	message, err := scanMessage(s.db.QueryRowContext(ctx, query, chatID, telegramMessageID))
+	if err == sql.ErrNoRows {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message: %w", err)
+	}
+	return message, nil
+}
+
+// ListMessages returns the latest limit messages of a session, oldest first
+func (s *SQLiteStore) ListMessages(ctx context.Context, sessionID uuid.UUID, limit int) ([]*Message, error) {
+	query := `
+		SELECT ` + messageColumns + ` FROM (
+			SELECT ` + messageColumns + `, rowid AS seq
+			FROM messages
+			WHERE session_id = ?
+			ORDER BY created_at DESC, seq DESC
+			LIMIT ?
+		)
+		ORDER BY created_at ASC, seq ASC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, sessionID.String(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// UpdateMessageContent replaces the content of the user message sent as chatID/telegramMessageID,
+// marks its session as updated and returns the updated message
+func (s *SQLiteStore) UpdateMessageContent(ctx context.Context, chatID int64, telegramMessageID int, content string, editedAt time.Time) (*Message, error) {
+	var message *Message
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		query := `
+			SELECT ` + messageColumns + `
+			FROM messages
+			WHERE chat_id = ? AND telegram_message_id = ? AND role = ?
+			ORDER BY created_at DESC
+			LIMIT 1
+		`
+
+		var err error
+		message, err = scanMessage(tx.db.QueryRowContext(ctx, query, chatID, telegramMessageID, RoleUser))
+		if err == sql.ErrNoRows {
+			return ErrMessageNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find message: %w", err)
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `UPDATE messages SET content = ?, edited_at = ? WHERE id = ?`,
+			content, editedAt, message.ID.String()); err != nil {
+			return fmt.Errorf("failed to update message: %w", err)
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `UPDATE sessions SET updated_at = ?, version = version + 1 WHERE id = ?`,
+			editedAt, message.SessionID.String()); err != nil {
+			return fmt.Errorf("failed to touch session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	message.Content = content
+	message.EditedAt = &editedAt
+	return message, nil
+}
+
+// ReviseMessage replaces the content of the message with id. The first
+// revision also keeps the original content, as version 1.
+func (s *SQLiteStore) ReviseMessage(ctx context.Context, id uuid.UUID, content string, revisedAt time.Time) (*Message, error) {
+	var message *Message
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		var err error
+		message, err = tx.GetMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		var latest int
+		if err := tx.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM message_versions WHERE message_id = ?`,
+			id.String()).Scan(&latest); err != nil {
+			return fmt.Errorf("failed to get message version: %w", err)
+		}
+		insert := `INSERT INTO message_versions (message_id, version, content, created_at) VALUES (?, ?, ?, ?)`
+		if latest == 0 {
+			if _, err := tx.db.ExecContext(ctx, insert, id.String(), 1, message.Content, message.CreatedAt); err != nil {
+				return fmt.Errorf("failed to store message version: %w", err)
+			}
+			latest = 1
+		}
+		if _, err := tx.db.ExecContext(ctx, insert, id.String(), latest+1, content, revisedAt); err != nil {
+			return fmt.Errorf("failed to store message version: %w", err)
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `UPDATE messages SET content = ?, edited_at = ? WHERE id = ?`,
+			content, revisedAt, id.String()); err != nil {
+			return fmt.Errorf("failed to update message: %w", err)
+		}
+		if _, err := tx.db.ExecContext(ctx, `UPDATE sessions SET updated_at = ?, version = version + 1 WHERE id = ?`,
+			revisedAt, message.SessionID.String()); err != nil {
+			return fmt.Errorf("failed to touch session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	message.Content = content
+	message.EditedAt = &revisedAt
+	return message, nil
+}
+
+// ListMessageVersions returns the versions of the message with id, oldest first
+func (s *SQLiteStore) ListMessageVersions(ctx context.Context, id uuid.UUID) ([]*MessageVersion, error) {
+	query := `
+		SELECT version, content, created_at
+		FROM message_versions
+		WHERE message_id = ?
+		ORDER BY version
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*MessageVersion
+	for rows.Next() {
+		version := MessageVersion{MessageID: id}
+		if err := rows.Scan(&version.Version, &version.Content, &version.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message version: %w", err)
+		}
+		versions = append(versions, &version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list message versions: %w", err)
+	}
+	return versions, nil
+}
+
+// CountMessages returns the number of messages in a session
+func (s *SQLiteStore) CountMessages(ctx context.Context, sessionID uuid.UUID) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE session_id = ?`, sessionID.String()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ListTopicBindings returns the forum topic bindings of a user
+func (s *SQLiteStore) ListTopicBindings(ctx context.Context, userID int64) ([]*TopicBinding, error) {
+	query := `
+		SELECT chat_id, thread_id, session_id
+		FROM topic_sessions
+		WHERE user_id = ?
+		ORDER BY chat_id, thread_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topic bindings: %w", err)
+	}
+	defer rows.Close()
+
+	var bindings []*TopicBinding
+	for rows.Next() {
+		binding := TopicBinding{UserID: userID}
+		var sessionID string
+		if err := rows.Scan(&binding.Topic.ChatID, &binding.Topic.ThreadID, &sessionID); err != nil {
+			return nil, fmt.Errorf("failed to scan topic binding: %w", err)
+		}
+		if binding.SessionID, err = uuid.Parse(sessionID); err != nil {
+			return nil, fmt.Errorf("invalid session ID: %w", err)
+		}
+		bindings = append(bindings, &binding)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list topic bindings: %w", err)
+	}
+	return bindings, nil
+}
+
+// ListBusinessChatBindings returns the business chat bindings of a user
+func (s *SQLiteStore) ListBusinessChatBindings(ctx context.Context, userID int64) ([]*BusinessChatBinding, error) {
+	query := `
+		SELECT connection_id, chat_id, session_id
+		FROM business_chat_sessions
+		WHERE user_id = ?
+		ORDER BY connection_id, chat_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list business chat bindings: %w", err)
+	}
+	defer rows.Close()
+
+	var bindings []*BusinessChatBinding
+	for rows.Next() {
+		binding := BusinessChatBinding{UserID: userID}
+		var sessionID string
+		if err := rows.Scan(&binding.Chat.ConnectionID, &binding.Chat.ChatID, &sessionID); err != nil {
+			return nil, fmt.Errorf("failed to scan business chat binding: %w", err)
+		}
+		if binding.SessionID, err = uuid.Parse(sessionID); err != nil {
+			return nil, fmt.Errorf("invalid session ID: %w", err)
+		}
+		bindings = append(bindings, &binding)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list business chat bindings: %w", err)
+	}
+	return bindings, nil
+}
+
+// ListUserSentMessages returns the messages sent to a user with pagination, oldest first
+func (s *SQLiteStore) ListUserSentMessages(ctx context.Context, userID int64, offset, limit int) ([]*SentMessage, error) {
+	query := `
+		SELECT ` + sentMessageColumns + `
+		FROM sent_messages
+		WHERE user_id = ?
+		ORDER BY sent_at, chat_id, telegram_message_id
+		LIMIT ? OFFSET ?
+	`
+	return s.listSentMessages(ctx, query, userID, limit, offset)
+}
+
+// ListSessionMessageVersions returns the versions of the messages of a session,
+// ordered by message and version
+func (s *SQLiteStore) ListSessionMessageVersions(ctx context.Context, sessionID uuid.UUID) ([]*MessageVersion, error) {
+	query := `
+		SELECT v.message_id, v.version, v.content, v.created_at
+		FROM message_versions v
+		INNER JOIN messages m ON m.id = v.message_id
+		WHERE m.session_id = ?
+		ORDER BY v.message_id, v.version
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, sessionID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*MessageVersion
+	for rows.Next() {
+		var version MessageVersion
+		var messageID string
+		if err := rows.Scan(&messageID, &version.Version, &version.Content, &version.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message version: %w", err)
+		}
+		if version.MessageID, err = uuid.Parse(messageID); err != nil {
+			return nil, fmt.Errorf("invalid message ID: %w", err)
+		}
+		versions = append(versions, &version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list message versions: %w", err)
+	}
+	return versions, nil
+}
+
+// ImportMessageVersions stores versions as they are, skipping those already stored
+func (s *SQLiteStore) ImportMessageVersions(ctx context.Context, versions []*MessageVersion) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	return s.withTx(ctx, func(tx *SQLiteStore) error {
+		query := `
+			INSERT OR IGNORE INTO message_versions (message_id, version, content, created_at)
+			VALUES (?, ?, ?, ?)
+		`
+		for _, version := range versions {
+			if _, err := tx.db.ExecContext(ctx, query, version.MessageID.String(), version.Version,
+				version.Content, version.CreatedAt); err != nil {
+				return fmt.Errorf("failed to import message version: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AddFlaggedContent stores flagged content and sets its ID
+func (s *SQLiteStore) AddFlaggedContent(ctx context.Context, content *FlaggedContent) error {
+	query := `
+		INSERT INTO flagged_content (user_id, chat_id, session_id, direction, action, rules, text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.ExecContext(ctx, query, content.UserID, content.ChatID, nullableUUID(content.SessionID),
+		content.Direction, content.Action, strings.Join(content.Rules, "\n"), content.Text, content.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add flagged content: %w", err)
+	}
+
+	content.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get flagged content ID: %w", err)
+	}
+
+	return nil
+}
+
+// ListOpenFlaggedContent returns unreviewed flagged content, oldest first
+func (s *SQLiteStore) ListOpenFlaggedContent(ctx context.Context, offset, limit int) ([]*FlaggedContent, error) {
+	query := `
+		SELECT f.id, f.user_id, f.chat_id, f.session_id, COALESCE(s.title, ''), f.direction, f.action,
+			f.rules, f.text, f.created_at
+		FROM flagged_content f
+		LEFT JOIN sessions s ON s.id = f.session_id
+		WHERE f.reviewed_at IS NULL
+		ORDER BY f.id
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flagged content: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*FlaggedContent
+	for rows.Next() {
+		var content FlaggedContent
+		var sessionIDStr, rules string
+		if err := rows.Scan(&content.ID, &content.UserID, &content.ChatID, &sessionIDStr, &content.SessionTitle,
+			&content.Direction, &content.Action, &rules, &content.Text, &content.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan flagged content: %w", err)
+		}
+		if sessionIDStr != "" {
+			content.SessionID, err = uuid.Parse(sessionIDStr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse session ID: %w", err)
+			}
+		}
+		if rules != "" {
+			content.Rules = strings.Split(rules, "\n")
+		}
+		list = append(list, &content)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list flagged content: %w", err)
+	}
+
+	return list, nil
+}
+
+// CountOpenFlaggedContent returns the number of unreviewed flagged content rows
+func (s *SQLiteStore) CountOpenFlaggedContent(ctx context.Context) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM flagged_content WHERE reviewed_at IS NULL`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count flagged content: %w", err)
+	}
+	return count, nil
+}
+
+// ReviewFlaggedContent marks open flagged content as reviewed by an administrator
+func (s *SQLiteStore) ReviewFlaggedContent(ctx context.Context, id, reviewedBy int64, reviewedAt time.Time) error {
+	query := `UPDATE flagged_content SET reviewed_at = ?, reviewed_by = ? WHERE id = ? AND reviewed_at IS NULL`
+
+	result, err := s.db.ExecContext(ctx, query, reviewedAt, reviewedBy, id)
+	if err != nil {
+		return fmt.Errorf("failed to review flagged content: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrFlaggedContentNotFound
+	}
+
+	return nil
+}
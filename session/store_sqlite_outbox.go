@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EnqueueOutbox adds a message to the outbox and sets its ID
+func (s *SQLiteStore) EnqueueOutbox(ctx context.Context, message *OutboxMessage) error {
+	query := `
+		INSERT INTO outbox (user_id, chat_id, thread_id, text, parse_mode, reply_markup, reply_markup_type, params,
+			attempts, last_error, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.ExecContext(ctx, query, message.UserID, message.ChatID, message.ThreadID, message.Text,
+		message.ParseMode, message.ReplyMarkup, message.ReplyMarkupType, message.Params, message.Attempts, message.LastError, message.NextAttemptAt.UTC(), message.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbox message: %w", err)
+	}
+
+	message.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get outbox message ID: %w", err)
+	}
+	return nil
+}
+
+// ListDueOutbox returns up to limit messages whose next attempt is at or before now, oldest first
+func (s *SQLiteStore) ListDueOutbox(ctx context.Context, now time.Time, limit int) ([]*OutboxMessage, error) {
+	query := `
+		SELECT id, user_id, chat_id, thread_id, text, parse_mode, reply_markup, reply_markup_type, params,
+			attempts, last_error, next_attempt_at, created_at
+		FROM outbox
+		WHERE next_attempt_at <= ?
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*OutboxMessage
+	for rows.Next() {
+		var message OutboxMessage
+		if err := rows.Scan(&message.ID, &message.UserID, &message.ChatID, &message.ThreadID, &message.Text,
+			&message.ParseMode, &message.ReplyMarkup, &message.ReplyMarkupType, &message.Params, &message.Attempts, &message.LastError,
+			&message.NextAttemptAt, &message.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		messages = append(messages, &message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list outbox: %w", err)
+	}
+	return messages, nil
+}
+
+// RescheduleOutbox records a failed attempt and when to try again
+func (s *SQLiteStore) RescheduleOutbox(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error {
+	query := `UPDATE outbox SET attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?`
+	if _, err := s.db.ExecContext(ctx, query, attempts, next.UTC(), lastError, id); err != nil {
+		return fmt.Errorf("failed to reschedule outbox message: %w", err)
+	}
+	return nil
+}
+
+// DeleteOutbox removes a delivered or abandoned message from the outbox
+func (s *SQLiteStore) DeleteOutbox(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM outbox WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete outbox message: %w", err)
+	}
+	return nil
+}
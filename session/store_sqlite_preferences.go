@@ -0,0 +1,48 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// GetPreferences returns the preferences of a user
+func (s *SQLiteStore) GetPreferences(ctx context.Context, userID int64) (*Preferences, error) {
+	query := `
+		SELECT user_id, language, updated_at
+		FROM user_preferences
+		WHERE user_id = ?
+	`
+
+	var prefs Preferences
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&prefs.UserID,
+		&prefs.Language,
+		&prefs.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrPreferencesNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	return &prefs, nil
+}
+
+// SavePreferences creates or replaces the preferences of a user
+func (s *SQLiteStore) SavePreferences(ctx context.Context, prefs *Preferences) error {
+	query := `
+		INSERT INTO user_preferences (user_id, language, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			language = excluded.language,
+			updated_at = excluded.updated_at
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, prefs.UserID, prefs.Language, prefs.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	return nil
+}
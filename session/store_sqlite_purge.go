@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// purgeOtherTables hold at most a few rows per user keyed by user_id
+var purgeOtherTables = []string{
+	"active_sessions",
+	"topic_sessions",
+	"conversation_states",
+	"user_preferences",
+	"user_settings",
+	"deferred_messages",
+	"referrals",
+	"user_stats",
+	"rate_limits",
+	"session_shares",
+	"business_connections",
+	"business_chat_sessions",
+	"feedback",
+	"flagged_content",
+	"outbox",
+	"sent_messages",
+	"draft_parts",
+	"drafts",
+}
+
+// PurgeUser deletes all rows belonging to userID in one transaction
+func (s *SQLiteStore) PurgeUser(ctx context.Context, userID int64) (*PurgeReport, error) {
+	report := &PurgeReport{}
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		rows, err := tx.db.QueryContext(ctx, `SELECT storage_key, size FROM files WHERE user_id = ?`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+		for rows.Next() {
+			var key string
+			var size int64
+			if err := rows.Scan(&key, &size); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan file: %w", err)
+			}
+			report.StorageKeys = append(report.StorageKeys, key)
+			report.Bytes += size
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+
+		purge := func(count *int, query string, args ...any) error {
+			result, err := tx.db.ExecContext(ctx, query, args...)
+			if err != nil {
+				return fmt.Errorf("failed to purge user data: %w", err)
+			}
+			n, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			*count += int(n)
+			return nil
+		}
+
+		// Sessions go last so rows removed by their cascade are counted where they belong
+		if err := purge(&report.Messages, `DELETE FROM messages
+			WHERE user_id = ? OR session_id IN (SELECT id FROM sessions WHERE user_id = ?)`, userID, userID); err != nil {
+			return err
+		}
+		if err := purge(&report.Files, `DELETE FROM files WHERE user_id = ?`, userID); err != nil {
+			return err
+		}
+		for _, table := range purgeOtherTables {
+			if err := purge(&report.Other, `DELETE FROM `+table+` WHERE user_id = ?`, userID); err != nil {
+				return err
+			}
+		}
+		// An archived channel is tracked by the chat ID that owns its sessions
+		if err := purge(&report.Other, `DELETE FROM channels WHERE chat_id = ?`, userID); err != nil {
+			return err
+		}
+		if err := purge(&report.Sessions, `DELETE FROM sessions WHERE user_id = ?`, userID); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,52 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// GetRateLimitBucket returns the saved bucket of a user
+func (s *SQLiteStore) GetRateLimitBucket(ctx context.Context, userID int64) (*RateLimitBucket, error) {
+	query := `
+		SELECT user_id, tokens, updated_at, warned
+		FROM rate_limits
+		WHERE user_id = ?
+	`
+
+	var bucket RateLimitBucket
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&bucket.UserID, &bucket.Tokens, &bucket.UpdatedAt, &bucket.Warned)
+	if err == sql.ErrNoRows {
+		return nil, ErrRateLimitBucketNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate limit bucket: %w", err)
+	}
+
+	return &bucket, nil
+}
+
+// SaveRateLimitBuckets creates or replaces buckets in one transaction
+func (s *SQLiteStore) SaveRateLimitBuckets(ctx context.Context, buckets []*RateLimitBucket) error {
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO rate_limits (user_id, tokens, updated_at, warned)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			tokens = excluded.tokens,
+			updated_at = excluded.updated_at,
+			warned = excluded.warned
+	`
+
+	return s.withTx(ctx, func(tx *SQLiteStore) error {
+		for _, bucket := range buckets {
+			if _, err := tx.db.ExecContext(ctx, query, bucket.UserID, bucket.Tokens, bucket.UpdatedAt, bucket.Warned); err != nil {
+				return fmt.Errorf("failed to save rate limit bucket: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,12 @@
+package session
+
+// SetReadOnly makes every later write fail with ErrReadOnly, or allows writes
+// again. Reads and backups are not affected.
+func (s *SQLiteStore) SetReadOnly(readOnly bool) {
+	s.db.readOnly.Store(readOnly)
+}
+
+// ReadOnly reports whether writes are refused
+func (s *SQLiteStore) ReadOnly() bool {
+	return s.db.readOnly.Load()
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// RecordReferral stores the referral of a user unless one is already recorded
+func (s *SQLiteStore) RecordReferral(ctx context.Context, referral *Referral) (bool, error) {
+	query := `
+		INSERT INTO referrals (user_id, code, referred_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO NOTHING
+	`
+
+	result, err := s.db.ExecContext(ctx, query, referral.UserID, referral.Code, referral.ReferredAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record referral: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check referral: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// CountReferrals returns the number of users referred with code
+func (s *SQLiteStore) CountReferrals(ctx context.Context, code string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM referrals WHERE code = ?`, code).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count referrals: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetUserRole returns the role of userID, UserRoleUser when none is assigned
+func (s *SQLiteStore) GetUserRole(ctx context.Context, userID int64) (UserRole, error) {
+	var role UserRole
+	err := s.db.QueryRowContext(ctx, `SELECT role FROM user_roles WHERE user_id = ?`, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return UserRoleUser, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	return role, nil
+}
+
+// SetUserRole assigns role to userID; UserRoleUser removes the assignment
+func (s *SQLiteStore) SetUserRole(ctx context.Context, userID int64, role UserRole) error {
+	if _, err := ParseUserRole(string(role)); err != nil {
+		return err
+	}
+
+	if role == UserRoleUser {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM user_roles WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("failed to remove user role: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+		INSERT INTO user_roles (user_id, role, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET role = excluded.role, updated_at = excluded.updated_at
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, role, time.Now()); err != nil {
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+	return nil
+}
+
+// ListUserRoles returns every assignment ordered by user ID
+func (s *SQLiteStore) ListUserRoles(ctx context.Context) ([]*RoleAssignment, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id, role, updated_at FROM user_roles ORDER BY user_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user roles: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []*RoleAssignment
+	for rows.Next() {
+		var assignment RoleAssignment
+		if err := rows.Scan(&assignment.UserID, &assignment.Role, &assignment.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user role: %w", err)
+		}
+		assignments = append(assignments, &assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list user roles: %w", err)
+	}
+	return assignments, nil
+}
@@ -0,0 +1,54 @@
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListSettings returns every stored setting ordered by key
+func (s *SQLiteStore) ListSettings(ctx context.Context) ([]*Setting, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key, value, updated_at FROM settings ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []*Setting
+	for rows.Next() {
+		var setting Setting
+		if err := rows.Scan(&setting.Key, &setting.Value, &setting.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		settings = append(settings, &setting)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// SaveSetting creates or replaces a setting
+func (s *SQLiteStore) SaveSetting(ctx context.Context, setting *Setting) error {
+	query := `
+		INSERT INTO settings (key, value, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			value = excluded.value,
+			updated_at = excluded.updated_at
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, setting.Key, setting.Value, setting.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save setting: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSetting removes a setting; deleting a missing key is not an error
+func (s *SQLiteStore) DeleteSetting(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM settings WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("failed to delete setting: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateShare stores a new share link
+func (s *SQLiteStore) CreateShare(ctx context.Context, share *Share) error {
+	query := `
+		INSERT INTO session_shares (token, session_id, user_id, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.ExecContext(ctx, query, share.Token, share.SessionID.String(), share.UserID, share.CreatedAt, share.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create share: %w", err)
+	}
+	return nil
+}
+
+// GetShare returns the share link with token
+func (s *SQLiteStore) GetShare(ctx context.Context, token string) (*Share, error) {
+	query := `
+		SELECT token, session_id, user_id, created_at, expires_at, revoked_at
+		FROM session_shares
+		WHERE token = ?
+	`
+
+	var share Share
+	var sessionID string
+	var revokedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, query, token).Scan(
+		&share.Token,
+		&sessionID,
+		&share.UserID,
+		&share.CreatedAt,
+		&share.ExpiresAt,
+		&revokedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share: %w", err)
+	}
+
+	if share.SessionID, err = uuid.Parse(sessionID); err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", err)
+	}
+	if revokedAt.Valid {
+		share.RevokedAt = &revokedAt.Time
+	}
+	return &share, nil
+}
+
+// RevokeShares revokes the unrevoked links a user created for a session and
+// returns how many were revoked
+func (s *SQLiteStore) RevokeShares(ctx context.Context, userID int64, sessionID uuid.UUID, revokedAt time.Time) (int, error) {
+	query := `
+		UPDATE session_shares SET revoked_at = ?
+		WHERE user_id = ? AND session_id = ? AND revoked_at IS NULL
+	`
+
+	result, err := s.db.ExecContext(ctx, query, revokedAt, userID, sessionID.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke shares: %w", err)
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke shares: %w", err)
+	}
+	return int(revoked), nil
+}
@@ -0,0 +1,84 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// GetState returns the conversation state of a user
+func (s *SQLiteStore) GetState(ctx context.Context, userID int64) (*ConversationState, error) {
+	query := `
+		SELECT user_id, chat_id, flow, step, data, expires_at, updated_at
+		FROM conversation_states
+		WHERE user_id = ?
+	`
+
+	var state ConversationState
+	var data string
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&state.UserID,
+		&state.ChatID,
+		&state.Flow,
+		&state.Step,
+		&data,
+		&state.ExpiresAt,
+		&state.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrStateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation state: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(data), &state.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation state data: %w", err)
+	}
+
+	return &state, nil
+}
+
+// SaveState creates or replaces the conversation state of a user
+func (s *SQLiteStore) SaveState(ctx context.Context, state *ConversationState) error {
+	data, err := json.Marshal(state.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation state data: %w", err)
+	}
+
+	query := `
+		INSERT INTO conversation_states (user_id, chat_id, flow, step, data, expires_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			chat_id = excluded.chat_id,
+			flow = excluded.flow,
+			step = excluded.step,
+			data = excluded.data,
+			expires_at = excluded.expires_at,
+			updated_at = excluded.updated_at
+	`
+
+	_, err = s.db.ExecContext(ctx, query,
+		state.UserID,
+		state.ChatID,
+		state.Flow,
+		state.Step,
+		string(data),
+		state.ExpiresAt,
+		state.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save conversation state: %w", err)
+	}
+
+	return nil
+}
+
+// ClearState removes the conversation state of a user
+func (s *SQLiteStore) ClearState(ctx context.Context, userID int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM conversation_states WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to clear conversation state: %w", err)
+	}
+	return nil
+}
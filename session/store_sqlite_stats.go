@@ -0,0 +1,310 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// userStatsTriggers keep the per-user counters in user_stats up to date. They run
+// inside the transaction of the statement that changed sessions or messages, including
+// messages removed by ON DELETE CASCADE, so the counters cannot drift from the rows.
+const userStatsTriggers = `
+	CREATE TRIGGER IF NOT EXISTS user_stats_session_insert AFTER INSERT ON sessions
+	BEGIN
+		INSERT INTO user_stats (user_id, sessions, last_active) VALUES (NEW.user_id, 1, NEW.updated_at)
+		ON CONFLICT(user_id) DO UPDATE SET sessions = sessions + 1, last_active = excluded.last_active;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS user_stats_session_delete AFTER DELETE ON sessions
+	BEGIN
+		UPDATE user_stats SET sessions = sessions - 1 WHERE user_id = OLD.user_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS user_stats_session_transfer AFTER UPDATE OF user_id ON sessions
+	WHEN OLD.user_id != NEW.user_id
+	BEGIN
+		UPDATE user_stats SET sessions = sessions - 1 WHERE user_id = OLD.user_id;
+		INSERT INTO user_stats (user_id, sessions, last_active) VALUES (NEW.user_id, 1, NEW.updated_at)
+		ON CONFLICT(user_id) DO UPDATE SET sessions = sessions + 1, last_active = MAX(COALESCE(last_active, ''), excluded.last_active);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS user_stats_message_insert AFTER INSERT ON messages
+	BEGIN
+		INSERT INTO user_stats (user_id, messages, last_active) VALUES (NEW.user_id, 1, NEW.created_at)
+		ON CONFLICT(user_id) DO UPDATE SET messages = messages + 1, last_active = excluded.last_active;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS user_stats_message_delete AFTER DELETE ON messages
+	BEGIN
+		UPDATE user_stats SET messages = messages - 1 WHERE user_id = OLD.user_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS user_stats_message_transfer AFTER UPDATE OF user_id ON messages
+	WHEN OLD.user_id != NEW.user_id
+	BEGIN
+		UPDATE user_stats SET messages = messages - 1 WHERE user_id = OLD.user_id;
+		INSERT INTO user_stats (user_id, messages, last_active) VALUES (NEW.user_id, 1, NEW.created_at)
+		ON CONFLICT(user_id) DO UPDATE SET messages = messages + 1, last_active = MAX(COALESCE(last_active, ''), excluded.last_active);
+	END;
+`
+
+// sessionCounterTriggers keep sessions.message_count and sessions.file_count up
+// to date, the same way userStatsTriggers do for user_stats. Updates cover
+// messages and files moved between sessions by a merge, and files unlinked
+// from a deleted session.
+const sessionCounterTriggers = `
+	CREATE TRIGGER IF NOT EXISTS session_counter_message_insert AFTER INSERT ON messages
+	BEGIN
+		UPDATE sessions SET message_count = message_count + 1 WHERE id = NEW.session_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS session_counter_message_delete AFTER DELETE ON messages
+	BEGIN
+		UPDATE sessions SET message_count = message_count - 1 WHERE id = OLD.session_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS session_counter_message_move AFTER UPDATE OF session_id ON messages
+	WHEN OLD.session_id != NEW.session_id
+	BEGIN
+		UPDATE sessions SET message_count = message_count - 1 WHERE id = OLD.session_id;
+		UPDATE sessions SET message_count = message_count + 1 WHERE id = NEW.session_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS session_counter_file_insert AFTER INSERT ON files
+	BEGIN
+		UPDATE sessions SET file_count = file_count + 1 WHERE id = NEW.session_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS session_counter_file_delete AFTER DELETE ON files
+	BEGIN
+		UPDATE sessions SET file_count = file_count - 1 WHERE id = OLD.session_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS session_counter_file_move AFTER UPDATE OF session_id ON files
+	WHEN OLD.session_id != NEW.session_id
+	BEGIN
+		UPDATE sessions SET file_count = file_count - 1 WHERE id = OLD.session_id;
+		UPDATE sessions SET file_count = file_count + 1 WHERE id = NEW.session_id;
+	END;
+`
+
+// unreadTriggers keep read receipts and sessions.unread_count up to date. An
+// assistant message marks every unanswered user message of its session as
+// replied to; user messages count as unread until then.
+const unreadTriggers = `
+	CREATE TRIGGER IF NOT EXISTS session_unread_insert AFTER INSERT ON messages
+	WHEN NEW.role = 'user' AND NEW.replied_at IS NULL
+	BEGIN
+		UPDATE sessions SET unread_count = unread_count + 1 WHERE id = NEW.session_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS session_unread_reply AFTER INSERT ON messages
+	WHEN NEW.role = 'assistant'
+	BEGIN
+		UPDATE messages SET replied_at = NEW.created_at
+			WHERE session_id = NEW.session_id AND role = 'user' AND replied_at IS NULL;
+		UPDATE sessions SET unread_count = 0 WHERE id = NEW.session_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS session_unread_delete AFTER DELETE ON messages
+	WHEN OLD.role = 'user' AND OLD.replied_at IS NULL
+	BEGIN
+		UPDATE sessions SET unread_count = unread_count - 1 WHERE id = OLD.session_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS session_unread_move AFTER UPDATE OF session_id ON messages
+	WHEN OLD.session_id != NEW.session_id AND NEW.role = 'user' AND NEW.replied_at IS NULL
+	BEGIN
+		UPDATE sessions SET unread_count = unread_count - 1 WHERE id = OLD.session_id;
+		UPDATE sessions SET unread_count = unread_count + 1 WHERE id = NEW.session_id;
+	END;
+`
+
+// backfillSessionCounters counts the messages and files of existing sessions
+// once, when the counter columns were just added to an older database
+func (s *SQLiteStore) backfillSessionCounters() error {
+	if !slices.Contains(s.migrations, "sessions.message_count") {
+		return nil
+	}
+
+	query := `
+		UPDATE sessions SET
+			message_count = (SELECT COUNT(*) FROM messages WHERE messages.session_id = sessions.id),
+			file_count = (SELECT COUNT(*) FROM files WHERE files.session_id = sessions.id)
+	`
+
+	if _, err := s.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to backfill session counters: %w", err)
+	}
+	return nil
+}
+
+// backfillUnread derives read receipts and unread counts from the existing
+// messages once, when the columns were just added to an older database: a user
+// message counts as replied to by the first assistant message after it
+func (s *SQLiteStore) backfillUnread() error {
+	if !slices.Contains(s.migrations, "messages.replied_at") {
+		return nil
+	}
+
+	queries := []string{`
+		UPDATE messages SET replied_at = (
+			SELECT MIN(reply.created_at) FROM messages reply
+			WHERE reply.session_id = messages.session_id AND reply.role = 'assistant'
+				AND reply.created_at >= messages.created_at
+		)
+		WHERE role = 'user'
+	`, `
+		UPDATE sessions SET unread_count = (
+			SELECT COUNT(*) FROM messages
+			WHERE messages.session_id = sessions.id AND role = 'user' AND replied_at IS NULL
+		)
+	`}
+	for _, query := range queries {
+		if _, err := s.db.Exec(query); err != nil {
+			return fmt.Errorf("failed to backfill unread counts: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillUserStats fills user_stats from existing rows when it is still empty,
+// i.e. the first time a database created before the table is opened
+func (s *SQLiteStore) backfillUserStats() error {
+	query := `
+		INSERT INTO user_stats (user_id, sessions, messages, last_active)
+		SELECT user_id, SUM(sessions), SUM(messages), MAX(last_active)
+		FROM (
+			SELECT user_id, COUNT(*) AS sessions, 0 AS messages, MAX(updated_at) AS last_active
+			FROM sessions GROUP BY user_id
+			UNION ALL
+			SELECT user_id, 0, COUNT(*), MAX(created_at)
+			FROM messages GROUP BY user_id
+		)
+		GROUP BY user_id
+		HAVING NOT EXISTS (SELECT 1 FROM user_stats)
+	`
+
+	if _, err := s.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to backfill user stats: %w", err)
+	}
+	return nil
+}
+
+// GetUserStats returns aggregate statistics for a user
+func (s *SQLiteStore) GetUserStats(ctx context.Context, userID int64) (*UserStats, error) {
+	stats := &UserStats{UserID: userID}
+
+	var lastActive sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT sessions, messages, last_active FROM user_stats WHERE user_id = ?`, userID).
+		Scan(&stats.Sessions, &stats.Messages, &lastActive)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	stats.LastActive = lastActive.Time
+
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(size), 0) FROM files WHERE user_id = ?`, userID).
+		Scan(&stats.Files, &stats.FileBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file usage: %w", err)
+	}
+
+	if stats.OldestSession, err = s.firstSessionByCreated(ctx, userID, "ASC"); err != nil {
+		return nil, err
+	}
+	if stats.NewestSession, err = s.firstSessionByCreated(ctx, userID, "DESC"); err != nil {
+		return nil, err
+	}
+
+	// Timestamps are stored as text starting with the date they were written on
+	query := `
+		SELECT substr(created_at, 1, 10) AS day, COUNT(*)
+		FROM messages
+		WHERE user_id = ?
+		GROUP BY day
+		ORDER BY COUNT(*) DESC, day DESC
+		LIMIT 1
+	`
+
+	var day string
+	err = s.db.QueryRowContext(ctx, query, userID).Scan(&day, &stats.BusiestDayMessages)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get busiest day: %w", err)
+	}
+	if err == nil {
+		if stats.BusiestDay, err = time.Parse(time.DateOnly, day); err != nil {
+			return nil, fmt.Errorf("failed to parse busiest day: %w", err)
+		}
+	}
+
+	return stats, nil
+}
+
+// ListUsers returns a page of users with stored sessions or messages, most recently active first
+func (s *SQLiteStore) ListUsers(ctx context.Context, offset, limit int) ([]*UserStats, error) {
+	query := `
+		SELECT u.user_id, u.sessions, u.messages, u.last_active,
+			COALESCE(f.files, 0), COALESCE(f.bytes, 0)
+		FROM user_stats u
+		LEFT JOIN (
+			SELECT user_id, COUNT(*) AS files, SUM(size) AS bytes
+			FROM files GROUP BY user_id
+		) f ON f.user_id = u.user_id
+		WHERE u.sessions > 0 OR u.messages > 0
+		ORDER BY u.last_active DESC, u.user_id
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*UserStats
+	for rows.Next() {
+		stats := &UserStats{}
+		var lastActive sql.NullTime
+		if err := rows.Scan(&stats.UserID, &stats.Sessions, &stats.Messages, &lastActive, &stats.Files, &stats.FileBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		stats.LastActive = lastActive.Time
+		users = append(users, stats)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, nil
+}
+
+// firstSessionByCreated returns the user's first session in creation order
+// ("ASC" for the oldest, "DESC" for the newest), or nil when there is none
+func (s *SQLiteStore) firstSessionByCreated(ctx context.Context, userID int64, order string) (*Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = ?
+		ORDER BY created_at ` + order + `
+		LIMIT 1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session by creation time: %w", err)
+	}
+	defer rows.Close()
+
+	sessions, err := scanSessions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	return sessions[0], nil
+}
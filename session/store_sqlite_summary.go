@@ -0,0 +1,53 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SetSummary stores the summary of a session, replacing the previous one
+func (s *SQLiteStore) SetSummary(ctx context.Context, summary *Summary) error {
+	query := `
+		INSERT INTO session_summaries (session_id, text, message_count, pinned_message_id, created_at)
+		SELECT id, ?, ?, ?, ? FROM sessions WHERE id = ?
+		ON CONFLICT (session_id) DO UPDATE SET
+			text = excluded.text,
+			message_count = excluded.message_count,
+			pinned_message_id = excluded.pinned_message_id,
+			created_at = excluded.created_at
+	`
+	result, err := s.db.ExecContext(ctx, query, summary.Text, summary.MessageCount, summary.PinnedMessageID,
+		summary.CreatedAt, summary.SessionID.String())
+	if err != nil {
+		return fmt.Errorf("failed to set summary: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// GetSummary returns the summary of a session
+func (s *SQLiteStore) GetSummary(ctx context.Context, sessionID uuid.UUID) (*Summary, error) {
+	summary := &Summary{SessionID: sessionID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT text, message_count, pinned_message_id, created_at FROM session_summaries WHERE session_id = ?
+	`, sessionID.String()).Scan(&summary.Text, &summary.MessageCount, &summary.PinnedMessageID, &summary.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSummaryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summary: %w", err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// GetTopicSession returns the active session of a user in a forum topic
+func (s *SQLiteStore) GetTopicSession(ctx context.Context, userID int64, topic Topic) (*Session, error) {
+	query := `
+		SELECT s.id, s.user_id, s.title, s.created_at, s.updated_at, s.last_message, s.version, s.message_count, s.file_count, s.unread_count, s.preset
+		FROM sessions s
+		INNER JOIN topic_sessions t ON s.id = t.session_id
+		WHERE t.chat_id = ? AND t.thread_id = ? AND t.user_id = ?
+	`
+
+	session, err := scanSession(s.db.QueryRowContext(ctx, query, topic.ChatID, topic.ThreadID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic session: %w", err)
+	}
+
+	return session, nil
+}
+
+// SetTopicSession sets the active session of a user in a forum topic
+func (s *SQLiteStore) SetTopicSession(ctx context.Context, userID int64, topic Topic, sessionID uuid.UUID) error {
+	query := `
+		INSERT INTO topic_sessions (chat_id, thread_id, user_id, session_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(chat_id, thread_id, user_id) DO UPDATE SET session_id = excluded.session_id
+	`
+
+	_, err := s.db.ExecContext(ctx, query, topic.ChatID, topic.ThreadID, userID, sessionID.String())
+	if err != nil {
+		return fmt.Errorf("failed to set topic session: %w", err)
+	}
+
+	return nil
+}
+
+// ClearTopicSession removes the active session binding of a user in a forum topic
+func (s *SQLiteStore) ClearTopicSession(ctx context.Context, userID int64, topic Topic) error {
+	query := `DELETE FROM topic_sessions WHERE chat_id = ? AND thread_id = ? AND user_id = ?`
+
+	if _, err := s.db.ExecContext(ctx, query, topic.ChatID, topic.ThreadID, userID); err != nil {
+		return fmt.Errorf("failed to clear topic session: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransferSession gives a session with its messages and files to toUserID and
+// unbinds it from the chats, topics and business chats of its previous owner
+// and revokes the share links they made, in one transaction. Triggers move the counts in user_stats along.
+func (s *SQLiteStore) TransferSession(ctx context.Context, id uuid.UUID, toUserID int64) (*TransferReport, error) {
+	report := &TransferReport{}
+	err := s.withTx(ctx, func(tx *SQLiteStore) error {
+		sess, err := tx.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if sess.UserID == toUserID {
+			return ErrSameOwner
+		}
+		report.FromUserID = sess.UserID
+
+		if _, err := tx.db.ExecContext(ctx, `UPDATE sessions SET user_id = ?, version = version + 1 WHERE id = ?`,
+			toUserID, id.String()); err != nil {
+			return fmt.Errorf("failed to transfer session: %w", err)
+		}
+
+		move := func(count *int, query string) error {
+			result, err := tx.db.ExecContext(ctx, query, toUserID, id.String(), report.FromUserID)
+			if err != nil {
+				return fmt.Errorf("failed to transfer session: %w", err)
+			}
+			rows, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to transfer session: %w", err)
+			}
+			*count = int(rows)
+			return nil
+		}
+		// Assistant replies are stored under the user they answered
+		if err := move(&report.Messages, `UPDATE messages SET user_id = ? WHERE session_id = ? AND user_id = ?`); err != nil {
+			return err
+		}
+		if err := move(&report.Files, `UPDATE files SET user_id = ? WHERE session_id = ? AND user_id = ?`); err != nil {
+			return err
+		}
+
+		for _, table := range sessionBindingTables {
+			if _, err := tx.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE session_id = ?`, id.String()); err != nil {
+				return fmt.Errorf("failed to unbind session: %w", err)
+			}
+		}
+
+		// Links the previous owner handed out must not keep showing what is now someone else's
+		result, err := tx.db.ExecContext(ctx, `UPDATE session_shares SET revoked_at = ? WHERE session_id = ? AND revoked_at IS NULL`,
+			time.Now(), id.String())
+		if err != nil {
+			return fmt.Errorf("failed to revoke shares: %w", err)
+		}
+		revoked, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to revoke shares: %w", err)
+		}
+		report.Shares = int(revoked)
+
+		report.Session, err = tx.Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
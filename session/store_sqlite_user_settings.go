@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// GetUserSettings returns the settings of a user, or the defaults if they never changed them
+func (s *SQLiteStore) GetUserSettings(ctx context.Context, userID int64) (*UserSettings, error) {
+	query := `
+		SELECT user_id, ai_model, auto_download, notifications, timezone, quiet_start, quiet_end, temperature, max_tokens, updated_at
+		FROM user_settings
+		WHERE user_id = ?
+	`
+
+	var settings UserSettings
+	var temperature sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&settings.UserID,
+		&settings.AIModel,
+		&settings.AutoDownload,
+		&settings.Notifications,
+		&settings.Timezone,
+		&settings.QuietStart,
+		&settings.QuietEnd,
+		&temperature,
+		&settings.MaxTokens,
+		&settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return DefaultUserSettings(userID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	if temperature.Valid {
+		settings.Temperature = &temperature.Float64
+	}
+
+	return &settings, nil
+}
+
+// SaveUserSettings creates or replaces the settings of a user
+func (s *SQLiteStore) SaveUserSettings(ctx context.Context, settings *UserSettings) error {
+	query := `
+		INSERT INTO user_settings (user_id, ai_model, auto_download, notifications, timezone, quiet_start, quiet_end,
+			temperature, max_tokens, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			ai_model = excluded.ai_model,
+			auto_download = excluded.auto_download,
+			notifications = excluded.notifications,
+			timezone = excluded.timezone,
+			quiet_start = excluded.quiet_start,
+			quiet_end = excluded.quiet_end,
+			temperature = excluded.temperature,
+			max_tokens = excluded.max_tokens,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.ExecContext(ctx, query, settings.UserID, settings.AIModel, settings.AutoDownload,
+		settings.Notifications, settings.Timezone, settings.QuietStart, settings.QuietEnd, settings.Temperature,
+		settings.MaxTokens, settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+
+	return nil
+}
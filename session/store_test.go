@@ -2,9 +2,14 @@ package session
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -72,6 +77,82 @@ func TestSQLiteStore_BasicOperations(t *testing.T) {
 	}
 }
 
+func TestSQLiteStore_UpdateConflict(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	session := NewSession(12345, "Hello, world!")
+	if err := store.Create(ctx, session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	first, _ := store.Get(ctx, session.ID)
+	second, _ := store.Get(ctx, session.ID)
+
+	first.Title = "First"
+	if err := store.Update(ctx, first); err != nil {
+		t.Fatalf("Failed to update session: %v", err)
+	}
+	if first.Version != 1 {
+		t.Errorf("expected version 1 after update, got %d", first.Version)
+	}
+
+	second.Title = "Second"
+	if err := store.Update(ctx, second); err != ErrConflict {
+		t.Fatalf("expected ErrConflict for a stale update, got %v", err)
+	}
+
+	// An incoming message also invalidates earlier reads
+	if err := store.AppendMessage(ctx, NewMessage(session.ID, 12345, RoleUser, "hi")); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+	first.Title = "Again"
+	if err := store.Update(ctx, first); err != ErrConflict {
+		t.Errorf("expected ErrConflict after a message, got %v", err)
+	}
+
+	stored, _ := store.Get(ctx, session.ID)
+	if stored.Title != "First" {
+		t.Errorf("expected the first update to survive, got %q", stored.Title)
+	}
+
+	missing := NewSession(12345, "missing")
+	if err := store.Update(ctx, missing); err != ErrSessionNotFound {
+		t.Errorf("expected ErrSessionNotFound for a missing session, got %v", err)
+	}
+}
+
+func TestSQLiteStore_TouchSession(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	session := NewSession(12345, "first")
+	session.UpdatedAt = time.Now().Add(-time.Hour)
+	if err := store.Create(ctx, session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if err := store.TouchSession(ctx, session.ID, "latest"); err != nil {
+		t.Fatalf("TouchSession failed: %v", err)
+	}
+	stored, err := store.Get(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if stored.LastMessage != "latest" || !stored.UpdatedAt.After(session.UpdatedAt) {
+		t.Errorf("expected the preview and activity time to be updated, got %q at %v", stored.LastMessage, stored.UpdatedAt)
+	}
+
+	// Earlier reads are stale now, like after any other write
+	if err := store.Update(ctx, session); err != ErrConflict {
+		t.Errorf("expected ErrConflict after a touch, got %v", err)
+	}
+
+	if err := store.TouchSession(ctx, NewSession(12345, "missing").ID, "x"); err != ErrSessionNotFound {
+		t.Errorf("expected ErrSessionNotFound for a missing session, got %v", err)
+	}
+}
+
 func TestSQLiteStore_ListByUser(t *testing.T) {
 	dbPath := "test_sessions_list.db"
 	defer os.Remove(dbPath)
@@ -497,6 +578,64 @@ func TestManager_CreateSession(t *testing.T) {
 	}
 }
 
+// failingBindStore fails every SetActiveSession, including inside transactions
+type failingBindStore struct {
+	Store
+}
+
+func (s *failingBindStore) SetActiveSession(ctx context.Context, userID int64, sessionID uuid.UUID) error {
+	return fmt.Errorf("binding failed")
+}
+
+func (s *failingBindStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return s.Store.WithTx(ctx, func(tx Store) error {
+		return fn(&failingBindStore{Store: tx})
+	})
+}
+
+func TestManager_CreateSessionRollsBack(t *testing.T) {
+	store := newTestStore(t)
+	manager := NewManager(&failingBindStore{Store: store})
+	ctx := context.Background()
+
+	if _, err := manager.CreateSession(ctx, 123, "Test message"); err == nil {
+		t.Fatal("expected CreateSession to fail")
+	}
+
+	count, err := store.CountByUser(ctx, 123)
+	if err != nil {
+		t.Fatalf("CountByUser failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected the session insert to be rolled back, got %d sessions", count)
+	}
+}
+
+func TestSQLiteStore_WithTxNested(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	session := NewSession(123, "Nested")
+
+	err := store.WithTx(ctx, func(tx Store) error {
+		if err := tx.Create(ctx, session); err != nil {
+			return err
+		}
+		// AppendMessage opens its own transaction, which must join the outer one
+		message := NewMessage(session.ID, 123, RoleUser, "hello")
+		if err := tx.(*SQLiteStore).AppendMessage(ctx, message); err != nil {
+			return err
+		}
+		return fmt.Errorf("abort")
+	})
+	if err == nil || err.Error() != "abort" {
+		t.Fatalf("expected the abort error, got %v", err)
+	}
+
+	if _, err := store.Get(ctx, session.ID); err != ErrSessionNotFound {
+		t.Errorf("expected the session to be rolled back, got %v", err)
+	}
+}
+
 func TestManager_GetOrCreateActiveSession(t *testing.T) {
 	dbPath := "test_manager_get_or_create.db"
 	defer os.Remove(dbPath)
@@ -535,6 +674,108 @@ func TestManager_GetOrCreateActiveSession(t *testing.T) {
 	}
 }
 
+// slowLookupStore delays active session lookups, widening the window in which
+// concurrent callers all find no session
+type slowLookupStore struct {
+	Store
+}
+
+func (s slowLookupStore) GetActiveSession(ctx context.Context, userID int64) (*Session, error) {
+	session, err := s.Store.GetActiveSession(ctx, userID)
+	time.Sleep(5 * time.Millisecond)
+	return session, err
+}
+
+func (s slowLookupStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return s.Store.WithTx(ctx, func(tx Store) error { return fn(slowLookupStore{tx}) })
+}
+
+func TestManager_GetOrCreateActiveSessionConcurrent(t *testing.T) {
+	store := newTestStore(t)
+	manager := NewManager(slowLookupStore{store})
+	ctx := context.Background()
+
+	const burst = 20
+	ids := make(chan uuid.UUID, burst)
+	errs := make(chan error, burst)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < burst; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			session, err := manager.GetOrCreateActiveSession(ctx, 123, fmt.Sprintf("Message %d", i))
+			if err != nil {
+				errs <- err
+				return
+			}
+			ids <- session.ID
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(ids)
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("GetOrCreateActiveSession failed: %v", err)
+	}
+	var first uuid.UUID
+	for id := range ids {
+		if first == uuid.Nil {
+			first = id
+		} else if id != first {
+			t.Fatalf("expected every call to get the same session, got %s and %s", first, id)
+		}
+	}
+
+	sessions, err := store.ListByUser(ctx, 123, 0, burst)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Errorf("expected one session created for the burst, got %d", len(sessions))
+	}
+}
+
+// blockingLookupStore blocks active session lookups until their context ends
+type blockingLookupStore struct {
+	Store
+}
+
+func (s blockingLookupStore) GetActiveSession(ctx context.Context, userID int64) (*Session, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s blockingLookupStore) GetTopicSession(ctx context.Context, userID int64, topic Topic) (*Session, error) {
+	return s.GetActiveSession(ctx, userID)
+}
+
+func TestManager_OperationTimeout(t *testing.T) {
+	manager := NewManager(blockingLookupStore{newTestStore(t)})
+	manager.SetOperationTimeout(10 * time.Millisecond)
+
+	before := operationsTimedOut.Value()
+	if _, err := manager.InTopic(Topic{ChatID: -100, ThreadID: 5}).GetActiveSession(context.Background(), 123); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the topic manager's lookup to time out, got %v", err)
+	}
+	if got := operationsTimedOut.Value() - before; got != 1 {
+		t.Errorf("expected 1 timed out operation counted, got %d", got)
+	}
+
+	// Cancellation by the caller is not a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := manager.GetActiveSession(ctx, 123); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the caller's cancellation, got %v", err)
+	}
+	if got := operationsTimedOut.Value() - before; got != 1 {
+		t.Errorf("expected the cancellation not counted, got %d timed out operations", got)
+	}
+}
+
 func TestManager_CloseActiveSession(t *testing.T) {
 	dbPath := "test_manager_close_active.db"
 	defer os.Remove(dbPath)
@@ -586,3 +827,169 @@ func TestManager_CloseActiveSession(t *testing.T) {
 		t.Fatalf("Expected no active session after close, got %v", err)
 	}
 }
+
+func TestManager_ReopenLastSession(t *testing.T) {
+	dbPath := "test_manager_reopen_last.db"
+	defer os.Remove(dbPath)
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	manager := NewManager(store)
+	ctx := context.Background()
+	userID := int64(123)
+
+	if _, err := manager.ReopenLastSession(ctx, userID); err != ErrSessionNotFound {
+		t.Fatalf("Expected ErrSessionNotFound without sessions, got %v", err)
+	}
+
+	older, err := manager.CreateSession(ctx, userID, "Older")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	latest, err := manager.CreateSession(ctx, userID, "Latest")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if older.ID == latest.ID {
+		t.Fatal("Expected distinct sessions")
+	}
+
+	if _, _, err := manager.CloseActiveSession(ctx, userID); err != nil {
+		t.Fatalf("CloseActiveSession failed: %v", err)
+	}
+
+	reopened, err := manager.ReopenLastSession(ctx, userID)
+	if err != nil {
+		t.Fatalf("ReopenLastSession failed: %v", err)
+	}
+	if reopened.ID != latest.ID {
+		t.Fatalf("Expected latest session %v to be reopened, got %v", latest.ID, reopened.ID)
+	}
+
+	active, err := store.GetActiveSession(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetActiveSession failed: %v", err)
+	}
+	if active.ID != latest.ID {
+		t.Fatalf("Expected reopened session to be active, got %v", active.ID)
+	}
+}
+
+func TestSQLiteStore_SearchByUser(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, msg := range []string{"Trip to Paris", "Grocery list", "paris hotels", "100% done", "other_user"} {
+		if err := store.Create(ctx, NewSession(1, msg)); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+	if err := store.Create(ctx, NewSession(2, "Paris for someone else")); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	sessions, err := store.SearchByUser(ctx, 1, "paris", 0, 10)
+	if err != nil {
+		t.Fatalf("SearchByUser failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 matches for 'paris', got %d", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.UserID != 1 {
+			t.Errorf("Search returned session of user %d", s.UserID)
+		}
+	}
+
+	// LIKE wildcards in the query match literally
+	sessions, err = store.SearchByUser(ctx, 1, "%", 0, 10)
+	if err != nil {
+		t.Fatalf("SearchByUser failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Title != "100% done" {
+		t.Errorf("Expected only '100%% done' to match '%%', got %d sessions", len(sessions))
+	}
+
+	sessions, err = store.SearchByUser(ctx, 1, "_", 0, 10)
+	if err != nil {
+		t.Fatalf("SearchByUser failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Title != "other_user" {
+		t.Errorf("Expected only 'other_user' to match '_', got %d sessions", len(sessions))
+	}
+}
+
+func TestManager_SearchSessions(t *testing.T) {
+	store := newTestStore(t)
+	manager := NewManager(store)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := store.Create(ctx, NewSession(1, fmt.Sprintf("Note %d", i))); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+
+	sessions, hasMore, err := manager.SearchSessions(ctx, 1, "note", 0, 3)
+	if err != nil {
+		t.Fatalf("SearchSessions failed: %v", err)
+	}
+	if len(sessions) != 3 || !hasMore {
+		t.Errorf("Expected 3 sessions with more, got %d (hasMore=%v)", len(sessions), hasMore)
+	}
+
+	// An empty query lists everything
+	sessions, hasMore, err = manager.SearchSessions(ctx, 1, "  ", 3, 3)
+	if err != nil {
+		t.Fatalf("SearchSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || hasMore {
+		t.Errorf("Expected last 2 sessions, got %d (hasMore=%v)", len(sessions), hasMore)
+	}
+}
+
+func TestSQLiteStore_AppliedMigrations(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// A sessions table from before optimistic locking added the version column
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE sessions (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		last_message TEXT NOT NULL
+	)`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	want := []string{"sessions.version", "sessions.archived_at", "sessions.message_count", "sessions.file_count", "sessions.expiry_warned_at", "sessions.unread_count", "sessions.preset"}
+	if got := store.AppliedMigrations(); !slices.Equal(got, want) {
+		t.Errorf("Expected %v to be added, got %v", want, got)
+	}
+	store.Close()
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	if got := store.AppliedMigrations(); len(got) != 0 {
+		t.Errorf("Expected no migrations on a current schema, got %v", got)
+	}
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Summary is the AI-written summary of a session, made with /summary. It stands
+// in for the messages it covers once they no longer fit the assistant's history.
+type Summary struct {
+	SessionID uuid.UUID `json:"session_id"`
+	Text      string    `json:"text"`
+
+	// MessageCount is the number of messages the session had when summarized
+	MessageCount int `json:"message_count"`
+
+	// PinnedMessageID is the Telegram message showing the summary when it was pinned, or 0
+	PinnedMessageID int       `json:"pinned_message_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ErrSummaryNotFound is returned when a session has no summary
+var ErrSummaryNotFound = fmt.Errorf("summary not found")
+
+// SummaryStore defines the interface for session summary persistence
+type SummaryStore interface {
+	// SetSummary stores the summary of a session, replacing the previous one.
+	// It returns ErrSessionNotFound for an unknown session.
+	SetSummary(ctx context.Context, summary *Summary) error
+
+	// GetSummary returns the summary of a session.
+	// It returns ErrSummaryNotFound when the session has none.
+	GetSummary(ctx context.Context, sessionID uuid.UUID) (*Summary, error)
+}
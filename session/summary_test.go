@@ -0,0 +1,48 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteStore_Summary(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sess := NewSession(1, "Trip")
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.GetSummary(ctx, sess.ID); !errors.Is(err, ErrSummaryNotFound) {
+		t.Fatalf("expected ErrSummaryNotFound, got %v", err)
+	}
+
+	if err := store.SetSummary(ctx, &Summary{SessionID: sess.ID, Text: "Planning", MessageCount: 4, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SetSummary failed: %v", err)
+	}
+	if err := store.SetSummary(ctx, &Summary{SessionID: sess.ID, Text: "Booked", MessageCount: 9, PinnedMessageID: 42, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SetSummary replacing the summary failed: %v", err)
+	}
+	summary, err := store.GetSummary(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("GetSummary failed: %v", err)
+	}
+	if summary.Text != "Booked" || summary.MessageCount != 9 || summary.PinnedMessageID != 42 {
+		t.Errorf("expected the replaced summary, got %+v", summary)
+	}
+
+	if err := store.SetSummary(ctx, &Summary{SessionID: uuid.New(), Text: "x", CreatedAt: time.Now()}); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound for an unknown session, got %v", err)
+	}
+
+	if err := store.Delete(ctx, sess.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.GetSummary(ctx, sess.ID); !errors.Is(err, ErrSummaryNotFound) {
+		t.Errorf("expected the summary deleted with its session, got %v", err)
+	}
+}
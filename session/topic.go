@@ -0,0 +1,53 @@
+package session
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Topic identifies a forum topic of a supergroup.
+// The zero Topic stands for chats without topics.
+type Topic struct {
+	ChatID   int64 `json:"chat_id"`
+	ThreadID int   `json:"thread_id"`
+}
+
+// IsZero reports whether t is the zero Topic
+func (t Topic) IsZero() bool {
+	return t.ThreadID == 0
+}
+
+// InTopic returns a manager whose active session is bound to a forum topic
+// instead of the user's private binding, so each topic keeps its own session.
+// The zero Topic returns m itself.
+func (m *Manager) InTopic(topic Topic) *Manager {
+	if topic.IsZero() {
+		return m
+	}
+	return m.withStore(&topicBindingStore{Store: m.store, topic: topic})
+}
+
+// topicBindingStore redirects active session bindings to a forum topic
+type topicBindingStore struct {
+	Store
+	topic Topic
+}
+
+func (s *topicBindingStore) GetActiveSession(ctx context.Context, userID int64) (*Session, error) {
+	return s.Store.GetTopicSession(ctx, userID, s.topic)
+}
+
+func (s *topicBindingStore) SetActiveSession(ctx context.Context, userID int64, sessionID uuid.UUID) error {
+	return s.Store.SetTopicSession(ctx, userID, s.topic, sessionID)
+}
+
+func (s *topicBindingStore) ClearActiveSession(ctx context.Context, userID int64) error {
+	return s.Store.ClearTopicSession(ctx, userID, s.topic)
+}
+
+func (s *topicBindingStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return s.Store.WithTx(ctx, func(tx Store) error {
+		return fn(&topicBindingStore{Store: tx, topic: s.topic})
+	})
+}
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestManager_InTopic(t *testing.T) {
+	store := newTestStore(t)
+	mgr := NewManager(store)
+	ctx := context.Background()
+	userID := int64(12345)
+
+	if mgr.InTopic(Topic{}) != mgr {
+		t.Error("expected the zero topic to return the manager itself")
+	}
+
+	private, err := mgr.CreateSession(ctx, userID, "private chat")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	topicA := mgr.InTopic(Topic{ChatID: -100, ThreadID: 7})
+	topicB := mgr.InTopic(Topic{ChatID: -100, ThreadID: 8})
+
+	sessA, err := topicA.GetOrCreateActiveSession(ctx, userID, "topic A")
+	if err != nil {
+		t.Fatalf("GetOrCreateActiveSession (topic A) failed: %v", err)
+	}
+	if sessA.ID == private.ID {
+		t.Fatal("expected the topic to get its own session")
+	}
+
+	sessB, err := topicB.GetOrCreateActiveSession(ctx, userID, "topic B")
+	if err != nil {
+		t.Fatalf("GetOrCreateActiveSession (topic B) failed: %v", err)
+	}
+	if sessB.ID == sessA.ID {
+		t.Fatal("expected each topic to get its own session")
+	}
+
+	// Topic bindings are stable and leave the private binding alone
+	again, err := topicA.GetOrCreateActiveSession(ctx, userID, "ignored")
+	if err != nil || again.ID != sessA.ID {
+		t.Errorf("expected topic A session %s, got %v (err: %v)", sessA.ID, again, err)
+	}
+	active, err := mgr.GetActiveSession(ctx, userID)
+	if err != nil || active.ID != private.ID {
+		t.Errorf("expected private session %s, got %v (err: %v)", private.ID, active, err)
+	}
+
+	// Closing a topic session only clears that topic
+	if _, closed, err := topicA.CloseActiveSession(ctx, userID); err != nil || !closed {
+		t.Fatalf("CloseActiveSession (topic A) failed: closed=%v err=%v", closed, err)
+	}
+	if _, err := topicA.GetActiveSession(ctx, userID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected topic A to have no session, got %v", err)
+	}
+	if got, err := topicB.GetActiveSession(ctx, userID); err != nil || got.ID != sessB.ID {
+		t.Errorf("expected topic B session to remain, got %v (err: %v)", got, err)
+	}
+
+	// Deleting a session removes its topic binding
+	if _, err := mgr.DeleteSession(ctx, userID, sessB.ID); err != nil {
+		t.Fatalf("DeleteSession failed: %v", err)
+	}
+	if _, err := topicB.GetActiveSession(ctx, userID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected topic B binding to be removed with its session, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"tg-bot-demo/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("tg-bot-demo/session")
+
+// Metrics published under /debug/vars and /metrics; the maps are keyed by the
+// store method issuing the statements, e.g. "SQLiteStore.ListByUser"
+var (
+	storeStatements       = metrics.NewMap("store_statements_total", "method")
+	storeStatementSeconds = metrics.NewMap("store_statement_seconds_total", "method")
+)
+
+// tracedDB wraps the database handle so every statement issued by a store method
+// is recorded as a span named after that method, e.g. "SQLiteStore.ListByUser".
+// When tx is set, statements run inside that transaction instead. Statements are
+// prepared once through stmts when it is set. While readOnly is set, ExecContext
+// refuses to run statements.
+type tracedDB struct {
+	*sql.DB
+	tx       *sql.Tx
+	stmts    *stmtCache
+	readOnly *atomic.Bool
+}
+
+// statement returns query bound to the transaction or the pool, prepared when possible.
+// Without a transaction of its own, the one InTx bound to ctx is used.
+func (db tracedDB) statement(ctx context.Context, query string) statement {
+	tx := db.tx
+	if tx == nil {
+		tx = contextTx(ctx, db.DB)
+	}
+	if tx != nil {
+		// Preparing needs a pool connection, which a transaction must not wait for
+		if stmt := db.stmts.lookup(query); stmt != nil {
+			return tx.StmtContext(ctx, stmt)
+		}
+		return unprepared{conn: tx, query: query}
+	}
+	if stmt := db.stmts.prepare(ctx, query); stmt != nil {
+		return stmt
+	}
+	return unprepared{conn: db.DB, query: query}
+}
+
+// ExecContext executes a statement inside a span. It returns ErrReadOnly while
+// the store is read-only.
+func (db tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if db.readOnly.Load() {
+		return nil, ErrReadOnly
+	}
+	return db.exec(ctx, query, args...)
+}
+
+// exec executes a statement inside a span, even while the store is read-only
+func (db tracedDB) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startStatementSpan(ctx, query)
+	result, err := db.statement(ctx, query).ExecContext(ctx, args...)
+	endStatementSpan(span, err)
+	return result, err
+}
+
+// QueryContext runs a query inside a span covering its execution
+func (db tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startStatementSpan(ctx, query)
+	rows, err := db.statement(ctx, query).QueryContext(ctx, args...)
+	endStatementSpan(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs a single-row query inside a span; scan errors are reported by the caller
+func (db tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := startStatementSpan(ctx, query)
+	row := db.statement(ctx, query).QueryRowContext(ctx, args...)
+	endStatementSpan(span, row.Err())
+	return row
+}
+
+// BeginTx starts a transaction inside a span
+func (db tracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	ctx, span := startStatementSpan(ctx, "BEGIN")
+	tx, err := db.DB.BeginTx(ctx, opts)
+	endStatementSpan(span, err)
+	return tx, err
+}
+
+// Commit commits the transaction inside a span
+func (db tracedDB) Commit(ctx context.Context) error {
+	_, span := startStatementSpan(ctx, "COMMIT")
+	err := db.tx.Commit()
+	endStatementSpan(span, err)
+	return err
+}
+
+// statementSpan is the span of a statement with what its metrics need
+type statementSpan struct {
+	trace.Span
+	method string
+	start  time.Time
+}
+
+func startStatementSpan(ctx context.Context, query string) (context.Context, statementSpan) {
+	method := storeMethodName()
+	ctx, span := tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.statement", strings.Join(strings.Fields(query), " ")),
+	))
+	return ctx, statementSpan{Span: span, method: method, start: time.Now()}
+}
+
+func endStatementSpan(span statementSpan, err error) {
+	storeStatements.Add(span.method, 1)
+	storeStatementSeconds.AddFloat(span.method, time.Since(span.start).Seconds())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// storeMethodName returns the name of the SQLiteStore method issuing the statement
+func storeMethodName() string {
+	pcs := make([]uintptr, 8)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if _, method, ok := strings.Cut(frame.Function, "(*SQLiteStore)."); ok {
+			return "SQLiteStore." + method
+		}
+		if !more {
+			return "SQLiteStore"
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStoreStatementSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	store := newTestStore(t)
+	if _, err := store.ListByUser(context.Background(), 1, 0, 10); err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+
+	var found bool
+	for _, span := range recorder.Ended() {
+		if span.Name() == "SQLiteStore.ListByUser" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a SQLiteStore.ListByUser span, got %d other spans", len(recorder.Ended()))
+	}
+}
@@ -0,0 +1,35 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TransferReport summarizes a session handed to another user
+type TransferReport struct {
+	Session    *Session // the session with its new owner
+	FromUserID int64    // the previous owner
+	Messages   int      // messages moved to the new owner
+	Files      int      // files moved to the new owner
+	Shares     int      // share links of the previous owner revoked
+}
+
+// ErrSameOwner is returned when a session is transferred to the user who owns it
+var ErrSameOwner = fmt.Errorf("session already belongs to this user")
+
+// TransferSession gives a session to toUserID, e.g. for a user who moved to
+// another Telegram account. The messages and files the previous owner stored in
+// it move along, it is no longer active in the previous owner's chats and the
+// links they shared it with stop working.
+func (m *Manager) TransferSession(ctx context.Context, sessionID uuid.UUID, toUserID int64) (*TransferReport, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	report, err := m.store.TransferSession(ctx, sessionID, toUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer session: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestManager_TransferSession(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	manager := NewManager(store)
+
+	sess, err := manager.CreateSession(ctx, 1, "moving")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	kept, err := manager.CreateSession(ctx, 1, "staying")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := store.SetActiveSession(ctx, 1, sess.ID); err != nil {
+		t.Fatalf("SetActiveSession failed: %v", err)
+	}
+	for _, message := range []*Message{
+		NewMessage(sess.ID, 1, RoleUser, "hello"),
+		NewMessage(sess.ID, 1, RoleAssistant, "hi"),
+		NewMessage(kept.ID, 1, RoleUser, "other"),
+	} {
+		if err := store.AppendMessage(ctx, message); err != nil {
+			t.Fatalf("AppendMessage failed: %v", err)
+		}
+	}
+	file := NewFile(1, "photo", "tg-1", "1/photo.jpg", 10)
+	file.SessionID = sess.ID
+	if err := store.CreateFile(ctx, file); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+
+	share := &Share{Token: "tok", SessionID: sess.ID, UserID: 1, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := store.CreateShare(ctx, share); err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+
+	report, err := manager.TransferSession(ctx, sess.ID, 2)
+	if err != nil {
+		t.Fatalf("TransferSession failed: %v", err)
+	}
+	if report.Session.UserID != 2 || report.FromUserID != 1 || report.Messages != 2 || report.Files != 1 || report.Shares != 1 {
+		t.Errorf("unexpected report %+v, session %+v", report, report.Session)
+	}
+
+	if _, err := store.GetActiveSession(ctx, 1); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected the session no longer active for the previous owner, got %v", err)
+	}
+	if revoked, err := store.GetShare(ctx, "tok"); err != nil || revoked.RevokedAt == nil {
+		t.Errorf("expected the previous owner's share link revoked, got %+v err=%v", revoked, err)
+	}
+	if moved, err := store.GetFile(ctx, file.ID); err != nil || moved.UserID != 2 {
+		t.Errorf("expected the file moved to the new owner, got %+v err=%v", moved, err)
+	}
+	for userID, want := range map[int64][2]int{1: {1, 1}, 2: {1, 2}} {
+		stats, err := store.GetUserStats(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Sessions != want[0] || stats.Messages != want[1] {
+			t.Errorf("user %d: expected %d sessions and %d messages, got %d and %d",
+				userID, want[0], want[1], stats.Sessions, stats.Messages)
+		}
+	}
+
+	if _, err := manager.TransferSession(ctx, sess.ID, 2); !errors.Is(err, ErrSameOwner) {
+		t.Errorf("expected ErrSameOwner, got %v", err)
+	}
+	if _, err := manager.TransferSession(ctx, uuid.New(), 2); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+	_ "time/tzdata" // user time zones must resolve on hosts without a zoneinfo database
+)
+
+// UserSettings holds the choices a user makes in the /settings menu.
+// The interface language is kept with the other Preferences.
+type UserSettings struct {
+	UserID        int64     `json:"user_id"`
+	AIModel       string    `json:"ai_model"` // empty uses the bot's default model
+	AutoDownload  bool      `json:"auto_download"`
+	Notifications bool      `json:"notifications"`
+	Timezone      string    `json:"timezone"`    // IANA name; empty means UTC
+	QuietStart    int       `json:"quiet_start"` // start of quiet hours in minutes after local midnight
+	QuietEnd      int       `json:"quiet_end"`   // end of quiet hours; equal to QuietStart when disabled
+	Temperature   *float64  `json:"temperature"` // AI sampling temperature; nil uses the provider's default
+	MaxTokens     int       `json:"max_tokens"`  // longest AI answer in tokens; 0 uses the provider's default
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Location returns the user's time zone, or UTC when unset or unknown
+func (s *UserSettings) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// HasQuietHours reports whether the user set a quiet hours window
+func (s *UserSettings) HasQuietHours() bool {
+	return s.QuietStart != s.QuietEnd
+}
+
+// QuietUntil reports whether t falls in the user's quiet hours and, if so,
+// when they end. Windows may wrap around midnight, e.g. 22:00-07:00.
+func (s *UserSettings) QuietUntil(t time.Time) (time.Time, bool) {
+	if !s.HasQuietHours() {
+		return time.Time{}, false
+	}
+
+	local := t.In(s.Location())
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	minute := local.Hour()*60 + local.Minute()
+
+	switch {
+	case s.QuietStart < s.QuietEnd && minute >= s.QuietStart && minute < s.QuietEnd:
+		return atMinute(midnight, s.QuietEnd), true
+	case s.QuietStart > s.QuietEnd && minute >= s.QuietStart:
+		return atMinute(midnight.AddDate(0, 0, 1), s.QuietEnd), true
+	case s.QuietStart > s.QuietEnd && minute < s.QuietEnd:
+		return atMinute(midnight, s.QuietEnd), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// atMinute returns the wall clock time minute minutes after midnight
+func atMinute(midnight time.Time, minute int) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(), minute/60, minute%60, 0, 0, midnight.Location())
+}
+
+// FormatMinuteOfDay renders minutes after midnight as "HH:MM"
+func FormatMinuteOfDay(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// DefaultUserSettings returns the settings of a user who never changed them
+func DefaultUserSettings(userID int64) *UserSettings {
+	return &UserSettings{
+		UserID:        userID,
+		AutoDownload:  true,
+		Notifications: true,
+	}
+}
+
+// UserSettingsStore defines the interface for user settings persistence
+type UserSettingsStore interface {
+	// GetUserSettings returns the settings of a user, or the defaults if they never changed them
+	GetUserSettings(ctx context.Context, userID int64) (*UserSettings, error)
+
+	// SaveUserSettings creates or replaces the settings of a user
+	SaveUserSettings(ctx context.Context, settings *UserSettings) error
+}
+
+type userSettingsKey struct{}
+
+// WithUserSettings returns a context carrying the settings of the user an update comes from
+func WithUserSettings(ctx context.Context, settings *UserSettings) context.Context {
+	return context.WithValue(ctx, userSettingsKey{}, settings)
+}
+
+// UserSettingsFromContext returns the settings stored by WithUserSettings, or the defaults
+func UserSettingsFromContext(ctx context.Context) *UserSettings {
+	if settings, ok := ctx.Value(userSettingsKey{}).(*UserSettings); ok {
+		return settings
+	}
+	return DefaultUserSettings(0)
+}
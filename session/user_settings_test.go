@@ -0,0 +1,94 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore_UserSettings(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	settings, err := store.GetUserSettings(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserSettings failed: %v", err)
+	}
+	if settings.UserID != 1 || !settings.AutoDownload || !settings.Notifications || settings.AIModel != "" {
+		t.Errorf("Expected defaults, got %+v", settings)
+	}
+
+	settings.AIModel = "large"
+	settings.AutoDownload = false
+	settings.UpdatedAt = time.Now()
+	if err := store.SaveUserSettings(ctx, settings); err != nil {
+		t.Fatalf("SaveUserSettings failed: %v", err)
+	}
+
+	saved, err := store.GetUserSettings(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserSettings failed: %v", err)
+	}
+	if saved.AIModel != "large" || saved.AutoDownload || !saved.Notifications {
+		t.Errorf("Expected saved settings, got %+v", saved)
+	}
+	if saved.Temperature != nil || saved.MaxTokens != 0 {
+		t.Errorf("Expected default sampling parameters, got %v and %d", saved.Temperature, saved.MaxTokens)
+	}
+
+	temperature := 0.7
+	saved.Temperature, saved.MaxTokens = &temperature, 512
+	if err := store.SaveUserSettings(ctx, saved); err != nil {
+		t.Fatalf("SaveUserSettings failed: %v", err)
+	}
+	saved, err = store.GetUserSettings(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetUserSettings failed: %v", err)
+	}
+	if saved.Temperature == nil || *saved.Temperature != 0.7 || saved.MaxTokens != 512 {
+		t.Errorf("Expected saved sampling parameters, got %v and %d", saved.Temperature, saved.MaxTokens)
+	}
+}
+
+func TestUserSettingsFromContext(t *testing.T) {
+	if settings := UserSettingsFromContext(context.Background()); !settings.AutoDownload || !settings.Notifications {
+		t.Errorf("Expected defaults without settings in context, got %+v", settings)
+	}
+
+	ctx := WithUserSettings(context.Background(), &UserSettings{UserID: 1, Notifications: true})
+	if settings := UserSettingsFromContext(ctx); settings.UserID != 1 || settings.AutoDownload {
+		t.Errorf("Expected the settings from context, got %+v", settings)
+	}
+}
+
+func TestUserSettings_QuietUntil(t *testing.T) {
+	overnight := &UserSettings{Timezone: "Europe/Berlin", QuietStart: 22 * 60, QuietEnd: 7 * 60}
+	berlin := overnight.Location()
+
+	tests := []struct {
+		name  string
+		at    time.Time
+		quiet bool
+		until time.Time
+	}{
+		{"before", time.Date(2025, 6, 1, 21, 59, 0, 0, berlin), false, time.Time{}},
+		{"evening", time.Date(2025, 6, 1, 23, 30, 0, 0, berlin), true, time.Date(2025, 6, 2, 7, 0, 0, 0, berlin)},
+		{"morning", time.Date(2025, 6, 2, 6, 0, 0, 0, berlin), true, time.Date(2025, 6, 2, 7, 0, 0, 0, berlin)},
+		{"end", time.Date(2025, 6, 2, 7, 0, 0, 0, berlin), false, time.Time{}},
+		{"utc instant", time.Date(2025, 6, 1, 21, 0, 0, 0, time.UTC), true, time.Date(2025, 6, 2, 7, 0, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		until, quiet := overnight.QuietUntil(tt.at)
+		if quiet != tt.quiet || !until.Equal(tt.until) {
+			t.Errorf("%s: QuietUntil = (%v, %v), want (%v, %v)", tt.name, until, quiet, tt.until, tt.quiet)
+		}
+	}
+
+	daytime := &UserSettings{QuietStart: 9 * 60, QuietEnd: 17 * 60}
+	if until, quiet := daytime.QuietUntil(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)); !quiet || until.Hour() != 17 {
+		t.Errorf("Expected daytime window until 17:00 UTC, got (%v, %v)", until, quiet)
+	}
+	if _, quiet := DefaultUserSettings(1).QuietUntil(time.Now()); quiet {
+		t.Error("Expected no quiet hours by default")
+	}
+}
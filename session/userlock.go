@@ -0,0 +1,44 @@
+package session
+
+import "sync"
+
+// userLocks serializes work on the sessions of one user within the process.
+// Entries exist only while someone holds or waits for the lock.
+type userLocks struct {
+	mu    sync.Mutex
+	locks map[int64]*userLock
+}
+
+// userLock is the lock of one user with the number of goroutines holding or waiting for it
+type userLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newUserLocks() *userLocks {
+	return &userLocks{locks: make(map[int64]*userLock)}
+}
+
+// lock locks userID and returns the function unlocking it
+func (l *userLocks) lock(userID int64) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[userID]
+	if !ok {
+		lock = &userLock{}
+		l.locks[userID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, userID)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,226 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"tg-bot-demo/session"
+)
+
+// Package settings holds tunables that administrators can change at runtime with
+// /admin set. Each setting is defined with its configured value as the default;
+// overrides are stored in the database and cached in memory. The cache is dropped
+// on every change and reloaded after a TTL, so overrides made by another instance
+// sharing the database are picked up too.
+
+// Keys of the settings defined by the bot
+const (
+	SessionsPerPage  = "sessions_per_page"
+	UserQuotaBytes   = "user_quota_bytes"
+	GlobalQuotaBytes = "global_quota_bytes"
+)
+
+// Errors returned by Set for values an administrator got wrong
+var (
+	ErrUnknownSetting = errors.New("unknown setting")
+	ErrInvalidValue   = errors.New("invalid value")
+)
+
+// Definition describes one setting
+type Definition struct {
+	Key         string
+	Description string
+	Default     string
+	Validate    func(value string) error // nil accepts any value
+}
+
+// Entry is the effective value of a setting
+type Entry struct {
+	Definition
+	Value      string
+	Overridden bool // the value comes from the database rather than the config
+}
+
+// Settings resolves settings from database overrides and configured defaults
+type Settings struct {
+	store session.SettingsStore
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu          sync.Mutex
+	definitions map[string]Definition
+	overrides   map[string]string // nil when the cache must be reloaded
+	loadedAt    time.Time
+}
+
+// New creates settings backed by store. Cached overrides are reloaded after ttl.
+func New(store session.SettingsStore, ttl time.Duration) *Settings {
+	return &Settings{
+		store:       store,
+		ttl:         ttl,
+		now:         time.Now,
+		definitions: make(map[string]Definition),
+	}
+}
+
+// Define registers a setting; defining a key again replaces it
+func (s *Settings) Define(definition Definition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.definitions[definition.Key] = definition
+}
+
+// DefineInt registers an integer setting that must be at least min
+func (s *Settings) DefineInt(key, description string, defaultValue, min int64) {
+	s.Define(Definition{
+		Key:         key,
+		Description: description,
+		Default:     strconv.FormatInt(defaultValue, 10),
+		Validate: func(value string) error {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%q is not an integer", value)
+			}
+			if n < min {
+				return fmt.Errorf("must be at least %d, got %d", min, n)
+			}
+			return nil
+		},
+	})
+}
+
+// String returns the effective value of key, or "" for unknown keys
+func (s *Settings) String(ctx context.Context, key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	definition, ok := s.definitions[key]
+	if !ok {
+		return ""
+	}
+	if value, ok := s.loadLocked(ctx)[key]; ok {
+		return value
+	}
+	return definition.Default
+}
+
+// Int returns the effective value of key as an integer. An override that no longer
+// parses falls back to the default.
+func (s *Settings) Int(ctx context.Context, key string) int64 {
+	n, err := strconv.ParseInt(s.String(ctx, key), 10, 64)
+	if err != nil {
+		s.mu.Lock()
+		n, _ = strconv.ParseInt(s.definitions[key].Default, 10, 64)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Set validates and stores an override of key
+func (s *Settings) Set(ctx context.Context, key, value string) error {
+	definition, err := s.definition(key)
+	if err != nil {
+		return err
+	}
+	if definition.Validate != nil {
+		if err := definition.Validate(value); err != nil {
+			return fmt.Errorf("%w for %s: %v", ErrInvalidValue, key, err)
+		}
+	}
+
+	err = s.store.SaveSetting(ctx, &session.Setting{Key: key, Value: value, UpdatedAt: s.now()})
+	s.Invalidate()
+	return err
+}
+
+// Reset removes the override of key so the configured default applies again
+func (s *Settings) Reset(ctx context.Context, key string) error {
+	if _, err := s.definition(key); err != nil {
+		return err
+	}
+
+	err := s.store.DeleteSetting(ctx, key)
+	s.Invalidate()
+	return err
+}
+
+// Get returns the effective value of one setting
+func (s *Settings) Get(ctx context.Context, key string) (Entry, error) {
+	definition, err := s.definition(key)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entryLocked(ctx, definition), nil
+}
+
+// List returns the effective value of every setting ordered by key
+func (s *Settings) List(ctx context.Context) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, 0, len(s.definitions))
+	for _, definition := range s.definitions {
+		entries = append(entries, s.entryLocked(ctx, definition))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// Invalidate drops the cached overrides so the next read reloads them
+func (s *Settings) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides = nil
+}
+
+func (s *Settings) definition(key string) (Definition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	definition, ok := s.definitions[key]
+	if !ok {
+		return Definition{}, fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+	}
+	return definition, nil
+}
+
+func (s *Settings) entryLocked(ctx context.Context, definition Definition) Entry {
+	entry := Entry{Definition: definition, Value: definition.Default}
+	if value, ok := s.loadLocked(ctx)[definition.Key]; ok {
+		entry.Value = value
+		entry.Overridden = true
+	}
+	return entry
+}
+
+// loadLocked returns the cached overrides, reloading them when invalidated or expired.
+// When the store fails the previous overrides are kept so a database hiccup does not
+// silently revert every setting to its default.
+func (s *Settings) loadLocked(ctx context.Context) map[string]string {
+	if s.overrides != nil && s.now().Sub(s.loadedAt) < s.ttl {
+		return s.overrides
+	}
+
+	stored, err := s.store.ListSettings(ctx)
+	if err != nil {
+		log.Printf("settings load failed: err=%v", err)
+		return s.overrides
+	}
+
+	overrides := make(map[string]string, len(stored))
+	for _, setting := range stored {
+		overrides[setting.Key] = setting.Value
+	}
+	s.overrides = overrides
+	s.loadedAt = s.now()
+	return overrides
+}
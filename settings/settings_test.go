@@ -0,0 +1,151 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+)
+
+// memoryStore is an in-memory SettingsStore counting reloads
+type memoryStore struct {
+	values map[string]string
+	lists  int
+	err    error
+}
+
+func (m *memoryStore) ListSettings(ctx context.Context) ([]*session.Setting, error) {
+	m.lists++
+	if m.err != nil {
+		return nil, m.err
+	}
+	var settings []*session.Setting
+	for key, value := range m.values {
+		settings = append(settings, &session.Setting{Key: key, Value: value})
+	}
+	return settings, nil
+}
+
+func (m *memoryStore) SaveSetting(ctx context.Context, setting *session.Setting) error {
+	m.values[setting.Key] = setting.Value
+	return nil
+}
+
+func (m *memoryStore) DeleteSetting(ctx context.Context, key string) error {
+	delete(m.values, key)
+	return nil
+}
+
+func newTestSettings(store *memoryStore) (*Settings, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := New(store, time.Minute)
+	s.now = func() time.Time { return now }
+	s.DefineInt(SessionsPerPage, "Sessions per page", 6, 1)
+	s.Define(Definition{Key: "greeting", Default: "hi"})
+	return s, &now
+}
+
+func TestSettingsDefaultsAndOverrides(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{values: map[string]string{}}
+	s, _ := newTestSettings(store)
+
+	if got := s.Int(ctx, SessionsPerPage); got != 6 {
+		t.Errorf("expected default 6, got %d", got)
+	}
+	if got := s.String(ctx, "unknown"); got != "" {
+		t.Errorf("expected empty value for unknown key, got %q", got)
+	}
+
+	if err := s.Set(ctx, SessionsPerPage, "10"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := s.Int(ctx, SessionsPerPage); got != 10 {
+		t.Errorf("expected override 10, got %d", got)
+	}
+	entry, err := s.Get(ctx, SessionsPerPage)
+	if err != nil || !entry.Overridden || entry.Default != "6" {
+		t.Errorf("unexpected entry %+v err=%v", entry, err)
+	}
+
+	if err := s.Reset(ctx, SessionsPerPage); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if got := s.Int(ctx, SessionsPerPage); got != 6 {
+		t.Errorf("expected default after reset, got %d", got)
+	}
+}
+
+func TestSettingsValidation(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{values: map[string]string{}}
+	s, _ := newTestSettings(store)
+
+	if err := s.Set(ctx, SessionsPerPage, "0"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("expected ErrInvalidValue for value below minimum, got %v", err)
+	}
+	if err := s.Set(ctx, SessionsPerPage, "many"); err == nil {
+		t.Error("expected error for non-integer value")
+	}
+	if err := s.Set(ctx, "missing", "1"); !errors.Is(err, ErrUnknownSetting) {
+		t.Errorf("expected ErrUnknownSetting, got %v", err)
+	}
+	if len(store.values) != 0 {
+		t.Errorf("invalid values must not be stored, got %v", store.values)
+	}
+
+	// An override that no longer parses falls back to the default
+	store.values[SessionsPerPage] = "broken"
+	s.Invalidate()
+	if got := s.Int(ctx, SessionsPerPage); got != 6 {
+		t.Errorf("expected default for unparsable override, got %d", got)
+	}
+}
+
+func TestSettingsCache(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{values: map[string]string{}}
+	s, now := newTestSettings(store)
+
+	s.String(ctx, "greeting")
+	s.String(ctx, "greeting")
+	if store.lists != 1 {
+		t.Fatalf("expected one load while cached, got %d", store.lists)
+	}
+
+	// Changes made elsewhere show up once the TTL expires
+	store.values["greeting"] = "hello"
+	if got := s.String(ctx, "greeting"); got != "hi" {
+		t.Errorf("expected cached value, got %q", got)
+	}
+	*now = now.Add(2 * time.Minute)
+	if got := s.String(ctx, "greeting"); got != "hello" {
+		t.Errorf("expected reloaded value, got %q", got)
+	}
+
+	// A failing store keeps the last known overrides
+	store.err = errors.New("database is locked")
+	*now = now.Add(2 * time.Minute)
+	if got := s.String(ctx, "greeting"); got != "hello" {
+		t.Errorf("expected last known value on load failure, got %q", got)
+	}
+}
+
+func TestSettingsList(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{values: map[string]string{"greeting": "hey"}}
+	s, _ := newTestSettings(store)
+
+	entries := s.List(ctx)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Key != "greeting" || entries[0].Value != "hey" || !entries[0].Overridden {
+		t.Errorf("unexpected first entry %+v", entries[0])
+	}
+	if entries[1].Key != SessionsPerPage || entries[1].Value != "6" || entries[1].Overridden {
+		t.Errorf("unexpected second entry %+v", entries[1])
+	}
+}
@@ -0,0 +1,178 @@
+package share
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"tg-bot-demo/session"
+
+	"github.com/google/uuid"
+)
+
+// Package share issues read-only links to session transcripts. A link carries a
+// random token, signed when a key is configured so forged tokens are rejected
+// before touching the database; expiry and revocation are kept in the store.
+
+const (
+	tokenIDBytes        = 12 // random part of a token
+	tokenSignatureBytes = 8  // truncated HMAC, like signed callback data
+
+	// StartPayloadPrefix prefixes tokens in "https://t.me/<bot>?start=" deep links
+	StartPayloadPrefix = "share-"
+
+	// DefaultTTL is how long links work when Options.TTL is 0
+	DefaultTTL = 7 * 24 * time.Hour
+
+	// maxTranscriptMessages is how many of the latest messages a shared transcript shows
+	maxTranscriptMessages = 200
+)
+
+// Link errors
+var (
+	ErrInvalid = errors.New("invalid share link")
+	ErrExpired = errors.New("share link expired")
+	ErrRevoked = errors.New("share link revoked")
+)
+
+// Store is the data share links read and write
+type Store interface {
+	session.ShareStore
+	session.Store
+	session.MessageStore
+}
+
+// Options configures share links
+type Options struct {
+	Key         string        // HMAC key signing tokens; empty issues unsigned tokens
+	TTL         time.Duration // how long a link works; 0 uses DefaultTTL
+	BotUsername string        // for t.me deep links; empty falls back to a /start command
+	BaseURL     string        // public URL of the web viewer; empty disables web links
+}
+
+// Transcript is a shared session with its latest messages
+type Transcript struct {
+	Share    *session.Share
+	Session  *session.Session
+	Messages []*session.Message
+}
+
+// Links creates, resolves and revokes share links
+type Links struct {
+	store   Store
+	options Options
+	now     func() time.Time
+}
+
+// New creates share links backed by store
+func New(store Store, options Options) *Links {
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+	return &Links{store: store, options: options, now: time.Now}
+}
+
+// Create issues a link to sessionID owned by userID
+func (l *Links) Create(ctx context.Context, userID int64, sessionID uuid.UUID) (*session.Share, error) {
+	id := make([]byte, tokenIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(id)
+
+	now := l.now()
+	share := &session.Share{
+		Token:     token + l.signature(token),
+		SessionID: sessionID,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(l.options.TTL),
+	}
+	if err := l.store.CreateShare(ctx, share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// Revoke revokes every link userID created for sessionID and returns how many were revoked
+func (l *Links) Revoke(ctx context.Context, userID int64, sessionID uuid.UUID) (int, error) {
+	return l.store.RevokeShares(ctx, userID, sessionID, l.now())
+}
+
+// Open verifies token and returns the shared transcript
+func (l *Links) Open(ctx context.Context, token string) (*Transcript, error) {
+	if !l.verify(token) {
+		return nil, ErrInvalid
+	}
+
+	share, err := l.store.GetShare(ctx, token)
+	if errors.Is(err, session.ErrShareNotFound) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if share.RevokedAt != nil {
+		return nil, ErrRevoked
+	}
+	if !l.now().Before(share.ExpiresAt) {
+		return nil, ErrExpired
+	}
+
+	sess, err := l.store.Get(ctx, share.SessionID)
+	if errors.Is(err, session.ErrSessionNotFound) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	messages, err := l.store.ListMessages(ctx, share.SessionID, maxTranscriptMessages)
+	if err != nil {
+		return nil, err
+	}
+	return &Transcript{Share: share, Session: sess, Messages: messages}, nil
+}
+
+// TelegramLink returns how another user opens token in Telegram: a t.me deep
+// link, or the /start command to send when the bot username is unknown
+func (l *Links) TelegramLink(token string) string {
+	if l.options.BotUsername == "" {
+		return "/start " + StartPayloadPrefix + token
+	}
+	return "https://t.me/" + strings.TrimPrefix(l.options.BotUsername, "@") + "?start=" + StartPayloadPrefix + token
+}
+
+// WebLink returns the web viewer URL of token, or "" when no base URL is configured
+func (l *Links) WebLink(token string) string {
+	if l.options.BaseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(l.options.BaseURL, "/") + "/share/" + url.PathEscape(token)
+}
+
+// verify checks the signature of token
+func (l *Links) verify(token string) bool {
+	idLength := base64.RawURLEncoding.EncodedLen(tokenIDBytes)
+	if len(token) < idLength {
+		return false
+	}
+	id, signature := token[:idLength], token[idLength:]
+	return hmac.Equal([]byte(signature), []byte(l.signature(id)))
+}
+
+// signature returns the signature of a token ID, or "" without a key
+func (l *Links) signature(id string) string {
+	if l.options.Key == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(l.options.Key))
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:tokenSignatureBytes])
+}
@@ -0,0 +1,103 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-bot-demo/session"
+)
+
+func newTestLinks(t *testing.T) (*Links, *session.Session) {
+	t.Helper()
+	store, err := session.NewSQLiteStore(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	ctx := context.Background()
+	sess := session.NewSession(1, "Shared")
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := store.AppendMessage(ctx, session.NewMessage(sess.ID, 1, session.RoleUser, "<script>hi</script>")); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+	return New(store, Options{Key: "secret", TTL: time.Hour, BaseURL: "https://bot.example.com/"}), sess
+}
+
+func TestLinks_Open(t *testing.T) {
+	links, sess := newTestLinks(t)
+	ctx := context.Background()
+
+	link, err := links.Create(ctx, 1, sess.ID)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	transcript, err := links.Open(ctx, link.Token)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if transcript.Session.ID != sess.ID || len(transcript.Messages) != 1 {
+		t.Errorf("unexpected transcript %+v", transcript)
+	}
+
+	forged := link.Token[:len(link.Token)-1] + "x"
+	if strings.HasSuffix(link.Token, "x") {
+		forged = link.Token[:len(link.Token)-1] + "y"
+	}
+	if _, err := links.Open(ctx, forged); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a forged token, got %v", err)
+	}
+
+	links.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := links.Open(ctx, link.Token); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+	links.now = time.Now
+
+	if revoked, err := links.Revoke(ctx, 1, sess.ID); err != nil || revoked != 1 {
+		t.Fatalf("Revoke = %d, %v", revoked, err)
+	}
+	if _, err := links.Open(ctx, link.Token); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected ErrRevoked, got %v", err)
+	}
+}
+
+func TestLinks_Viewer(t *testing.T) {
+	links, sess := newTestLinks(t)
+	link, err := links.Create(context.Background(), 1, sess.ID)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	links.Register(mux)
+
+	webLink := links.WebLink(link.Token)
+	if webLink != "https://bot.example.com/share/"+link.Token {
+		t.Errorf("unexpected web link %q", webLink)
+	}
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/share/"+link.Token, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", recorder.Code)
+	}
+	body := recorder.Body.String()
+	if !strings.Contains(body, "&lt;script&gt;hi&lt;/script&gt;") || strings.Contains(body, "<script>") {
+		t.Errorf("expected escaped message content, got %s", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/share/unknown", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown token, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,44 @@
+package share
+
+import (
+	_ "embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+)
+
+//go:embed transcript.html
+var transcriptHTML string
+
+var transcriptTemplate = template.Must(template.New("transcript").Parse(transcriptHTML))
+
+// Register adds the web viewer of shared transcripts under /share/ to mux.
+// The token in the URL is the only credential.
+func (l *Links) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /share/{token}", l.handleTranscript)
+}
+
+func (l *Links) handleTranscript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	transcript, err := l.Open(r.Context(), r.PathValue("token"))
+	switch {
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, "share link not found", http.StatusNotFound)
+		return
+	case errors.Is(err, ErrExpired), errors.Is(err, ErrRevoked):
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	case err != nil:
+		log.Printf("share: open transcript error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := transcriptTemplate.Execute(w, transcript); err != nil {
+		log.Printf("share: render transcript error: %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tg-bot-demo/config"
+	"tg-bot-demo/correlation"
+	"tg-bot-demo/requestlog"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// simulateStep describes one synthetic update. Exactly one of Text, Callback,
+// Photo or Update is set; sender fields override the command-line defaults.
+type simulateStep struct {
+	Text     string          `json:"text,omitempty"`     // message text; a leading /word is marked as a command
+	Callback string          `json:"callback,omitempty"` // callback query data
+	Photo    string          `json:"photo,omitempty"`    // file ID of a photo message
+	Caption  string          `json:"caption,omitempty"`  // photo caption
+	Update   json.RawMessage `json:"update,omitempty"`   // raw update sent as is
+	UserID   int64           `json:"user_id,omitempty"`
+	ChatID   int64           `json:"chat_id,omitempty"`
+	Username string          `json:"username,omitempty"`
+	Language string          `json:"language,omitempty"`
+	DelayMS  int             `json:"delay_ms,omitempty"` // pause before this step
+}
+
+// simulateSender holds the default sender of synthetic updates
+type simulateSender struct {
+	UserID   int64
+	ChatID   int64
+	Username string
+	Language string
+}
+
+// runSimulate implements the simulate subcommand: synthetic updates are built from
+// flags or a JSON file of steps and posted to the webhook, or with -local handled
+// in-process against a stub Telegram API so no bot token is needed.
+func runSimulate(args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	webhookURL := flags.String("url", "http://localhost:3000/webhook", "Webhook URL to post updates to")
+	secretToken := flags.String("secret-token", os.Getenv("TELEGRAM_SECRET_TOKEN"), "Webhook secret token")
+	local := flags.Bool("local", false, "Handle updates in-process with a stub Telegram API instead of posting them")
+	dbPath := flags.String("db", "./data/simulate.db", "Path to SQLite database file for -local")
+	file := flags.String("file", "", "JSON file with an array of steps")
+	text := flags.String("text", "", "Send a text message or command, e.g. \"/sessions\"")
+	callback := flags.String("callback", "", "Send a callback query with this data")
+	photo := flags.String("photo", "", "Send a photo message with this file ID")
+	caption := flags.String("caption", "", "Caption of the -photo message")
+	userID := flags.Int64("user-id", 1000, "Sender user ID")
+	chatID := flags.Int64("chat-id", 0, "Chat ID (default: the user ID, i.e. a private chat)")
+	username := flags.String("username", "simulator", "Sender username")
+	language := flags.String("language", "en", "Sender language code")
+	flags.Parse(args)
+
+	steps, err := simulateSteps(*file, simulateStep{Text: *text, Callback: *callback, Photo: *photo, Caption: *caption})
+	if err != nil {
+		return err
+	}
+
+	sender := simulateSender{UserID: *userID, ChatID: *chatID, Username: *username, Language: *language}
+	updates := make([][]byte, 0, len(steps))
+	baseID := time.Now().Unix()
+	for i, step := range steps {
+		body, err := buildSimulatedUpdate(step, sender, baseID+int64(i))
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		updates = append(updates, body)
+	}
+
+	deliver := func(body []byte) error { return postUpdate(*webhookURL, *secretToken, body) }
+	if *local {
+		deliverLocal, closeLocal, err := newLocalDelivery(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer closeLocal()
+		deliver = deliverLocal
+	}
+
+	for i, body := range updates {
+		if steps[i].DelayMS > 0 {
+			time.Sleep(time.Duration(steps[i].DelayMS) * time.Millisecond)
+		}
+		log.Printf("simulate: step=%d update=%s", i+1, body)
+		if err := deliver(body); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// simulateSteps returns the steps of file, or the single step given by flags
+func simulateSteps(file string, flagStep simulateStep) ([]simulateStep, error) {
+	if file == "" {
+		if flagStep.Text == "" && flagStep.Callback == "" && flagStep.Photo == "" {
+			return nil, errors.New("nothing to send: use -text, -callback, -photo or -file")
+		}
+		return []simulateStep{flagStep}, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read steps file: %w", err)
+	}
+	var steps []simulateStep
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, fmt.Errorf("failed to parse steps file: %w", err)
+	}
+	return steps, nil
+}
+
+// buildSimulatedUpdate renders step as the JSON body of update updateID
+func buildSimulatedUpdate(step simulateStep, sender simulateSender, updateID int64) ([]byte, error) {
+	if len(step.Update) > 0 {
+		return step.Update, nil
+	}
+
+	if step.UserID != 0 {
+		sender.UserID = step.UserID
+	}
+	if step.ChatID != 0 {
+		sender.ChatID = step.ChatID
+	}
+	if step.Username != "" {
+		sender.Username = step.Username
+	}
+	if step.Language != "" {
+		sender.Language = step.Language
+	}
+	if sender.ChatID == 0 {
+		sender.ChatID = sender.UserID
+	}
+
+	from := models.User{
+		ID:           sender.UserID,
+		FirstName:    sender.Username,
+		Username:     sender.Username,
+		LanguageCode: sender.Language,
+	}
+	chatType := models.ChatTypePrivate
+	if sender.ChatID != sender.UserID {
+		chatType = models.ChatTypeSupergroup
+	}
+	message := &models.Message{
+		ID:   int(updateID % 1_000_000),
+		From: &from,
+		Chat: models.Chat{ID: sender.ChatID, Type: chatType},
+		Date: int(time.Now().Unix()),
+	}
+
+	update := models.Update{ID: updateID}
+	switch {
+	case step.Callback != "":
+		update.CallbackQuery = &models.CallbackQuery{
+			ID:   fmt.Sprintf("simulated-%d", updateID),
+			From: from,
+			Message: models.MaybeInaccessibleMessage{
+				Type:    models.MaybeInaccessibleMessageTypeMessage,
+				Message: message,
+			},
+			ChatInstance: fmt.Sprintf("%d", sender.ChatID),
+			Data:         step.Callback,
+		}
+	case step.Photo != "":
+		message.Photo = []models.PhotoSize{{FileID: step.Photo, FileUniqueID: step.Photo, Width: 1280, Height: 720}}
+		message.Caption = step.Caption
+		update.Message = message
+	case step.Text != "":
+		message.Text = step.Text
+		if strings.HasPrefix(step.Text, "/") {
+			command, _, _ := strings.Cut(step.Text, " ")
+			message.Entities = []models.MessageEntity{{Type: models.MessageEntityTypeBotCommand, Length: len(command)}}
+		}
+		update.Message = message
+	default:
+		return nil, errors.New("step needs text, callback, photo or update")
+	}
+
+	return json.Marshal(&update)
+}
+
+// postUpdate posts body to the webhook and prints the response
+func postUpdate(webhookURL, secretToken string, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if secretToken != "" {
+		request.Header.Set("X-Telegram-Bot-Api-Secret-Token", secretToken)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("post update: %w", err)
+	}
+	defer response.Body.Close()
+
+	reply, _ := io.ReadAll(response.Body)
+	log.Printf("simulate: webhook responded status=%d body=%s", response.StatusCode, strings.TrimSpace(string(reply)))
+	return nil
+}
+
+// newLocalDelivery starts an in-process bot talking to a stub API and returns a
+// function handing update bodies to it
+func newLocalDelivery(dbPath string) (func([]byte) error, func(), error) {
+	stub, err := startStubAPI()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := config.Default()
+	cfg.Token = "simulate:local"
+	cfg.DatabasePath = dbPath
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		stub.Close()
+		return nil, nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	app, err := initializeBot(cfg, bot.WithNotAsyncHandlers(), bot.WithServerURL(stub.URL))
+	if err != nil {
+		stub.Close()
+		return nil, nil, fmt.Errorf("initialize bot: %w", err)
+	}
+
+	deliver := func(body []byte) error {
+		replayRecords(context.Background(), app.bot, []requestlog.Record{{RequestID: correlation.NewID(), Body: body}})
+		return nil
+	}
+	closeAll := func() {
+		app.Close()
+		stub.Close()
+	}
+	return deliver, closeAll, nil
+}
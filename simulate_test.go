@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestBuildSimulatedUpdate(t *testing.T) {
+	sender := simulateSender{UserID: 7, Username: "dev", Language: "en"}
+
+	tests := []struct {
+		name  string
+		step  simulateStep
+		check func(t *testing.T, update models.Update)
+	}{
+		{
+			name: "command",
+			step: simulateStep{Text: "/delete files"},
+			check: func(t *testing.T, update models.Update) {
+				if update.Message == nil || update.Message.Text != "/delete files" {
+					t.Fatalf("Expected command message, got %+v", update.Message)
+				}
+				if len(update.Message.Entities) != 1 || update.Message.Entities[0].Length != len("/delete") {
+					t.Errorf("Expected bot_command entity for /delete, got %+v", update.Message.Entities)
+				}
+				if update.Message.Chat.ID != 7 || update.Message.Chat.Type != models.ChatTypePrivate {
+					t.Errorf("Expected private chat with the user, got %+v", update.Message.Chat)
+				}
+			},
+		},
+		{
+			name: "callback",
+			step: simulateStep{Callback: "page:2", UserID: 9},
+			check: func(t *testing.T, update models.Update) {
+				if update.CallbackQuery == nil || update.CallbackQuery.Data != "page:2" || update.CallbackQuery.From.ID != 9 {
+					t.Fatalf("Expected callback from user 9, got %+v", update.CallbackQuery)
+				}
+				if update.CallbackQuery.Message.Message == nil {
+					t.Error("Expected callback to carry its message")
+				}
+			},
+		},
+		{
+			name: "photo",
+			step: simulateStep{Photo: "file-1", Caption: "look"},
+			check: func(t *testing.T, update models.Update) {
+				if update.Message == nil || len(update.Message.Photo) != 1 || update.Message.Photo[0].FileID != "file-1" {
+					t.Fatalf("Expected photo message, got %+v", update.Message)
+				}
+				if update.Message.Caption != "look" {
+					t.Errorf("Expected caption, got %q", update.Message.Caption)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := buildSimulatedUpdate(tt.step, sender, 100)
+			if err != nil {
+				t.Fatalf("buildSimulatedUpdate failed: %v", err)
+			}
+			var update models.Update
+			if err := json.Unmarshal(body, &update); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if update.ID != 100 {
+				t.Errorf("Expected update ID 100, got %d", update.ID)
+			}
+			tt.check(t, update)
+		})
+	}
+
+	if _, err := buildSimulatedUpdate(simulateStep{}, sender, 1); err == nil {
+		t.Error("Expected an error for an empty step")
+	}
+}
+
+func TestPostUpdateSendsSecretToken(t *testing.T) {
+	var gotSecret, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSecret = r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer server.Close()
+
+	if err := postUpdate(server.URL, "secret", []byte(`{"update_id":1}`)); err != nil {
+		t.Fatalf("postUpdate failed: %v", err)
+	}
+	if gotSecret != "secret" || gotBody != `{"update_id":1}` {
+		t.Errorf("Unexpected request: secret=%q body=%q", gotSecret, gotBody)
+	}
+}
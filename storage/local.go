@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBackend implements Backend by writing files below a root directory
+type LocalBackend struct {
+	root string
+}
+
+// NewLocalBackend creates a new local-disk backend rooted at dir
+func NewLocalBackend(dir string) *LocalBackend {
+	return &LocalBackend{root: dir}
+}
+
+// path resolves key to a file path inside the root directory
+func (l *LocalBackend) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." ||
+		strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(l.root, cleaned), nil
+}
+
+// Put writes r to the file identified by key
+func (l *LocalBackend) Put(ctx context.Context, key string, r io.Reader, size int64) (int64, error) {
+	outputPath, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return 0, fmt.Errorf("create output directory: %w", err)
+	}
+
+	// Write to a temporary file next to the final path so a failed copy
+	// never leaves a truncated file under key
+	file, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".tmp-*")
+	if err != nil {
+		return 0, fmt.Errorf("create output file: %w", err)
+	}
+	tmpPath := file.Name()
+
+	written, err := io.Copy(file, r)
+	if err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("write output file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("close output file: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("rename output file: %w", err)
+	}
+
+	return written, nil
+}
+
+// Open opens the file identified by key for reading
+func (l *LocalBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	filePath, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+
+	return file, nil
+}
+
+// Delete removes the file identified by key
+func (l *LocalBackend) Delete(ctx context.Context, key string) error {
+	filePath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete file: %w", err)
+	}
+
+	return nil
+}
+
+// Location returns the file path of key
+func (l *LocalBackend) Location(key string) string {
+	filePath, err := l.path(key)
+	if err != nil {
+		return key
+	}
+	return filePath
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalBackend_PutOpenDelete(t *testing.T) {
+	root := t.TempDir()
+	backend := NewLocalBackend(root)
+	ctx := context.Background()
+
+	written, err := backend.Put(ctx, "alice/file_1", strings.NewReader("hello"), 5)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if written != 5 {
+		t.Errorf("expected 5 bytes written, got %d", written)
+	}
+
+	expectedPath := filepath.Join(root, "alice", "file_1")
+	if backend.Location("alice/file_1") != expectedPath {
+		t.Errorf("expected location %q, got %q", expectedPath, backend.Location("alice/file_1"))
+	}
+	if _, err := os.Stat(expectedPath); err != nil {
+		t.Fatalf("expected file on disk: %v", err)
+	}
+
+	reader, err := backend.Open(ctx, "alice/file_1")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	content, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(content) != "hello" {
+		t.Errorf("expected content 'hello', got %q", content)
+	}
+
+	if err := backend.Delete(ctx, "alice/file_1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := backend.Open(ctx, "alice/file_1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := backend.Delete(ctx, "alice/file_1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+func TestLocalBackend_RejectsEscapingKeys(t *testing.T) {
+	backend := NewLocalBackend(t.TempDir())
+	ctx := context.Background()
+
+	for _, key := range []string{"", ".", "..", "../outside", "/etc/passwd", "a/../../b"} {
+		if _, err := backend.Put(ctx, key, strings.NewReader("x"), 1); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestLocalBackend_PutFailureLeavesNoFile(t *testing.T) {
+	root := t.TempDir()
+	backend := NewLocalBackend(root)
+	ctx := context.Background()
+
+	reader := io.MultiReader(strings.NewReader("partial"), failingReader{})
+	if _, err := backend.Put(ctx, "alice/file_1", reader, 100); err == nil {
+		t.Fatal("expected Put to fail")
+	}
+
+	if _, err := backend.Open(ctx, "alice/file_1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after failed Put, got %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "alice"))
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no leftover files, got %d", len(entries))
+	}
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service         = "s3"
+	s3SigningAlgo     = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3EmptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Options holds settings for an S3-compatible backend
+type S3Options struct {
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UsePathStyle    bool // required by MinIO and most self-hosted services
+}
+
+// S3Backend implements Backend on top of the S3 REST API using Signature V4
+type S3Backend struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Backend creates a new S3-compatible backend
+func NewS3Backend(opts S3Options, client *http.Client) (*S3Backend, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", opts.Region)
+	}
+	opts.Prefix = strings.Trim(opts.Prefix, "/")
+
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid s3 endpoint scheme: %q", endpoint.Scheme)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3Backend{
+		opts:     opts,
+		endpoint: endpoint,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// objectKey prepends the configured prefix to key
+func (s *S3Backend) objectKey(key string) string {
+	if s.opts.Prefix == "" {
+		return key
+	}
+	return s.opts.Prefix + "/" + key
+}
+
+// objectURL builds the request URL for key honoring path-style addressing
+func (s *S3Backend) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	objectPath := "/" + s.objectKey(key)
+	if s.opts.UsePathStyle {
+		objectPath = "/" + s.opts.Bucket + objectPath
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+	}
+	basePath := strings.TrimSuffix(u.Path, "/")
+	u.Path = basePath + objectPath
+	u.RawPath = s3EscapePath(basePath + objectPath)
+	return &u
+}
+
+// Put uploads r as the object identified by key
+func (s *S3Backend) Put(ctx context.Context, key string, r io.Reader, size int64) (int64, error) {
+	if key == "" {
+		return 0, ErrInvalidKey
+	}
+
+	// S3 requires a Content-Length on PUT; spool unknown-length bodies to disk first.
+	if size < 0 {
+		spool, err := os.CreateTemp("", "tg-bot-upload-*")
+		if err != nil {
+			return 0, fmt.Errorf("create spool file: %w", err)
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+
+		size, err = io.Copy(spool, r)
+		if err != nil {
+			return 0, fmt.Errorf("spool upload: %w", err)
+		}
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("rewind spool file: %w", err)
+		}
+		r = spool
+	}
+
+	counter := &countingReader{r: r}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), counter)
+	if err != nil {
+		return 0, fmt.Errorf("create upload request: %w", err)
+	}
+	request.ContentLength = size
+	if size == 0 {
+		request.Body = http.NoBody
+	}
+	s.sign(request, s3UnsignedPayload)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("upload object: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("upload object status: %d: %s", response.StatusCode, readErrorBody(response.Body))
+	}
+
+	return counter.n, nil
+}
+
+// Open downloads the object identified by key
+func (s *S3Backend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create get request: %w", err)
+	}
+	s.sign(request, s3EmptyPayload)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("get object: %w", err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return response.Body, nil
+	case http.StatusNotFound:
+		response.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer response.Body.Close()
+		return nil, fmt.Errorf("get object status: %d: %s", response.StatusCode, readErrorBody(response.Body))
+	}
+}
+
+// Delete removes the object identified by key
+func (s *S3Backend) Delete(ctx context.Context, key string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("create delete request: %w", err)
+	}
+	s.sign(request, s3EmptyPayload)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("delete object: %w", err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return fmt.Errorf("delete object status: %d: %s", response.StatusCode, readErrorBody(response.Body))
+	}
+}
+
+// Location returns the s3:// URI of key
+func (s *S3Backend) Location(key string) string {
+	return fmt.Sprintf("s3://%s/%s", s.opts.Bucket, s.objectKey(key))
+}
+
+// sign adds AWS Signature Version 4 headers to request
+func (s *S3Backend) sign(request *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + request.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.Query().Encode(),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{shortDate, s.opts.Region, s3Service, "aws4_request"}, "/")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3SigningAlgo,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), shortDate)
+	signingKey = hmacSHA256(signingKey, s.opts.Region)
+	signingKey = hmacSHA256(signingKey, s3Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgo, s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath URI-encodes every path segment as required by Signature V4
+func s3EscapePath(p string) string {
+	var sb strings.Builder
+	for i := 0; i < len(p); i++ {
+		ch := p[i]
+		if ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || ch == '/' {
+			sb.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", ch)
+	}
+	return sb.String()
+}
+
+func readErrorBody(r io.Reader) string {
+	body, _ := io.ReadAll(io.LimitReader(r, 512))
+	return strings.TrimSpace(string(body))
+}
+
+// countingReader counts bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
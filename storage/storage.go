@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Package storage provides backends for persisting files downloaded from Telegram.
+// It includes a local-disk implementation and an S3-compatible implementation
+// that streams objects to AWS S3, MinIO or any other service speaking the S3 API.
+
+// Backend defines the interface for file persistence
+type Backend interface {
+	// Put streams r into the object identified by key and returns the number of bytes written.
+	// size is the expected content length, or -1 when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64) (int64, error)
+
+	// Open returns a reader for a stored object
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes a stored object
+	Delete(ctx context.Context, key string) error
+
+	// Location returns a human-readable location of an object (path or URL) for logging
+	Location(key string) string
+}
+
+// Error types
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
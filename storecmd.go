@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"tg-bot-demo/config"
+	"tg-bot-demo/session"
+)
+
+// exportPageSize is how many sessions, messages or files the export reads at a time
+const exportPageSize = 500
+
+// openStore opens the configured database, creating its directory and bringing the
+// schema up to date
+func openStore(cfg *config.Config) (*session.SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(cfg.DatabasePath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	store, err := session.NewSQLiteStoreWithOptions(cfg.DatabasePath, sqliteOptions(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session store: %w", err)
+	}
+	return store, nil
+}
+
+// loadStoreCommand parses the config flags of a store subcommand and opens the store.
+// The bot token is not needed, so the configuration is not validated.
+func loadStoreCommand(flags *flag.FlagSet, overrides *configFlags, args []string) (*session.SQLiteStore, error) {
+	flags.Parse(args)
+	cfg, err := overrides.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return openStore(cfg)
+}
+
+// runMigrate implements the migrate subcommand: it opens the database, which
+// creates missing tables and columns, and reports what changed
+func runMigrate(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	store, err := loadStoreCommand(flags, registerConfigFlags(flags), args)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	applied := store.AppliedMigrations()
+	if len(applied) == 0 {
+		fmt.Fprintln(out, "database schema is up to date")
+		return nil
+	}
+	for _, column := range applied {
+		fmt.Fprintf(out, "added column %s\n", column)
+	}
+	fmt.Fprintf(out, "applied %d migration(s)\n", len(applied))
+	return nil
+}
+
+// userExport is everything stored about a user, as written by the export subcommand
+type userExport struct {
+	UserID      int64                 `json:"user_id"`
+	ExportedAt  time.Time             `json:"exported_at"`
+	Stats       *session.UserStats    `json:"stats"`
+	Preferences *session.Preferences  `json:"preferences,omitempty"`
+	Settings    *session.UserSettings `json:"settings"`
+	Sessions    []exportedSession     `json:"sessions"`
+	Files       []*session.File       `json:"files"`
+}
+
+// exportedSession is a session with all of its messages, oldest first
+type exportedSession struct {
+	*session.Session
+	Messages []*session.Message `json:"messages"`
+	Archived bool               `json:"archived,omitempty"`
+}
+
+// runExport implements the export subcommand: it writes everything stored about
+// one user as JSON to out, or to the -o file
+func runExport(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	userID := flags.Int64("user", 0, "Telegram user ID to export (required)")
+	output := flags.String("o", "", "Write the export to this file instead of stdout")
+	store, err := loadStoreCommand(flags, registerConfigFlags(flags), args)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *userID == 0 {
+		return errors.New("usage: export -user ID [-o file] [-config file] [flags]")
+	}
+
+	export, err := exportUser(context.Background(), store, *userID)
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		file, err := os.OpenFile(*output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// exportUser collects the sessions, messages, files and preferences of userID
+func exportUser(ctx context.Context, store *session.SQLiteStore, userID int64) (*userExport, error) {
+	stats, err := store.GetUserStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	export := &userExport{UserID: userID, ExportedAt: time.Now().UTC(), Stats: stats, Sessions: []exportedSession{}, Files: []*session.File{}}
+
+	preferences, err := store.GetPreferences(ctx, userID)
+	if err != nil && !errors.Is(err, session.ErrPreferencesNotFound) {
+		return nil, err
+	}
+	export.Preferences = preferences
+
+	export.Settings, err = store.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := exportSessions(ctx, store, export, store.ListByUser, false); err != nil {
+		return nil, err
+	}
+	if err := exportSessions(ctx, store, export, store.ListArchivedByUser, true); err != nil {
+		return nil, err
+	}
+
+	for offset := 0; ; offset += exportPageSize {
+		files, err := store.ListFilesByUser(ctx, userID, offset, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		export.Files = append(export.Files, files...)
+		if len(files) < exportPageSize {
+			break
+		}
+	}
+
+	export.localize(export.Settings.Location())
+	return export, nil
+}
+
+// exportSessions appends the sessions returned by list to export, with their messages
+func exportSessions(ctx context.Context, store *session.SQLiteStore, export *userExport,
+	list func(ctx context.Context, userID int64, offset, limit int) ([]*session.Session, error), archived bool) error {
+	for offset := 0; ; offset += exportPageSize {
+		sessions, err := list(ctx, export.UserID, offset, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, sess := range sessions {
+			exported := exportedSession{Session: sess, Messages: []*session.Message{}, Archived: archived}
+			for messageOffset := 0; ; messageOffset += exportPageSize {
+				messages, err := store.ListSessionMessages(ctx, sess.ID, messageOffset, exportPageSize)
+				if err != nil {
+					return err
+				}
+				exported.Messages = append(exported.Messages, messages...)
+				if len(messages) < exportPageSize {
+					break
+				}
+			}
+			export.Sessions = append(export.Sessions, exported)
+		}
+		if len(sessions) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// localize moves the timestamps of the export into the user's time zone, so
+// they read as the user's local time while still naming the same instants
+func (e *userExport) localize(loc *time.Location) {
+	e.ExportedAt = e.ExportedAt.In(loc)
+	e.Stats.LastActive = e.Stats.LastActive.In(loc)
+	sessions := []*session.Session{e.Stats.OldestSession, e.Stats.NewestSession}
+	for _, exported := range e.Sessions {
+		sessions = append(sessions, exported.Session)
+		for _, message := range exported.Messages {
+			message.CreatedAt = message.CreatedAt.In(loc)
+			if message.EditedAt != nil {
+				editedAt := message.EditedAt.In(loc)
+				message.EditedAt = &editedAt
+			}
+		}
+	}
+	for _, sess := range sessions {
+		if sess != nil {
+			sess.CreatedAt = sess.CreatedAt.In(loc)
+			sess.UpdatedAt = sess.UpdatedAt.In(loc)
+		}
+	}
+	for _, file := range e.Files {
+		file.CreatedAt = file.CreatedAt.In(loc)
+	}
+}
+
+// runUsers implements the users subcommand. "users list" prints users with stored
+// data, most recently active first.
+func runUsers(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "list" {
+		return errors.New("usage: users list [-limit N] [-offset N] [-config file] [flags]")
+	}
+
+	flags := flag.NewFlagSet("users list", flag.ExitOnError)
+	limit := flags.Int("limit", 50, "Maximum number of users to list")
+	offset := flags.Int("offset", 0, "Number of users to skip")
+	store, err := loadStoreCommand(flags, registerConfigFlags(flags), args[1:])
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	users, err := store.ListUsers(context.Background(), *offset, *limit)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "USER ID\tSESSIONS\tMESSAGES\tFILES\tFILE BYTES\tLAST ACTIVE")
+	for _, user := range users {
+		lastActive := "-"
+		if !user.LastActive.IsZero() {
+			lastActive = user.LastActive.UTC().Format(time.DateTime)
+		}
+		fmt.Fprintf(table, "%d\t%d\t%d\t%d\t%d\t%s\n", user.UserID, user.Sessions, user.Messages, user.Files, user.FileBytes, lastActive)
+	}
+	return table.Flush()
+}
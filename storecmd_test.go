@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tg-bot-demo/session"
+)
+
+// newCommandStore creates a database for the store subcommands with one user's data
+func newCommandStore(t *testing.T) (string, *session.Session) {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "data", "sessions.db")
+	t.Setenv("DATABASE_PATH", dbPath)
+
+	cfg, err := flagsFor(t).load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	store, err := openStore(cfg)
+	if err != nil {
+		t.Fatalf("openStore failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	sessionMgr := session.NewManager(store)
+	sess, err := sessionMgr.CreateSession(ctx, 42, "Plan the trip")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := session.NewMessageManager(store).AddMessage(ctx, session.NewMessage(sess.ID, 42, session.RoleUser, "Plan the trip")); err != nil {
+		t.Fatalf("AddMessage failed: %v", err)
+	}
+	if _, err := sessionMgr.CreateSession(ctx, 7, "Other user"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	return dbPath, sess
+}
+
+// flagsFor returns config flags parsed from no arguments
+func flagsFor(t *testing.T) *configFlags {
+	t.Helper()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Parse(nil)
+	return overrides
+}
+
+func TestRunMigrate(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "new", "sessions.db")
+
+	var out bytes.Buffer
+	if err := runMigrate([]string{"-db", dbPath}, &out); err != nil {
+		t.Fatalf("runMigrate failed: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "database schema is up to date" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Errorf("expected the database to be created: %v", err)
+	}
+}
+
+func TestRunExport(t *testing.T) {
+	_, sess := newCommandStore(t)
+
+	var out bytes.Buffer
+	if err := runExport([]string{"--user", "42"}, &out); err != nil {
+		t.Fatalf("runExport failed: %v", err)
+	}
+
+	var export userExport
+	if err := json.Unmarshal(out.Bytes(), &export); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if export.UserID != 42 || len(export.Sessions) != 1 || export.Sessions[0].ID != sess.ID {
+		t.Fatalf("expected the user's one session, got %+v", export)
+	}
+	if messages := export.Sessions[0].Messages; len(messages) != 1 || messages[0].Content != "Plan the trip" {
+		t.Errorf("expected the session's message, got %+v", messages)
+	}
+	if export.Stats.Sessions != 1 || export.Stats.Messages != 1 {
+		t.Errorf("unexpected stats %+v", export.Stats)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "export.json")
+	if err := runExport([]string{"-user", "42", "-o", outputPath}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runExport to file failed: %v", err)
+	}
+	if written, err := os.ReadFile(outputPath); err != nil || !json.Valid(written) {
+		t.Errorf("expected JSON in %s, err=%v", outputPath, err)
+	}
+
+	if err := runExport(nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected a usage error without -user")
+	}
+}
+
+func TestExportUserInTimezone(t *testing.T) {
+	dbPath, sess := newCommandStore(t)
+	store, err := session.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	userSettings := session.DefaultUserSettings(42)
+	userSettings.Timezone = "Asia/Tokyo"
+	if err := store.SaveUserSettings(ctx, userSettings); err != nil {
+		t.Fatalf("SaveUserSettings failed: %v", err)
+	}
+
+	export, err := exportUser(ctx, store, 42)
+	if err != nil {
+		t.Fatalf("exportUser failed: %v", err)
+	}
+	if zone := export.ExportedAt.Location().String(); zone != "Asia/Tokyo" {
+		t.Errorf("expected the export time in the user's zone, got %s", zone)
+	}
+	exported := export.Sessions[0]
+	if !exported.CreatedAt.Equal(sess.CreatedAt) || exported.CreatedAt.Location().String() != "Asia/Tokyo" {
+		t.Errorf("expected the session creation time in Tokyo, got %v", exported.CreatedAt)
+	}
+	if encoded, _ := json.Marshal(exported.Messages[0]); !strings.Contains(string(encoded), "+09:00") {
+		t.Errorf("expected message times with the Tokyo offset, got %s", encoded)
+	}
+}
+
+func TestRunUsersList(t *testing.T) {
+	newCommandStore(t)
+
+	var out bytes.Buffer
+	if err := runUsers([]string{"list"}, &out); err != nil {
+		t.Fatalf("runUsers failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "USER ID") {
+		t.Fatalf("expected a header and two users, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "7" || fields[1] != "1" {
+		t.Errorf("expected the most recently active user first, got %q", lines[1])
+	}
+
+	out.Reset()
+	if err := runUsers([]string{"list", "-limit", "1", "-offset", "1"}, &out); err != nil {
+		t.Fatalf("runUsers failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || strings.Fields(lines[1])[0] != "42" {
+		t.Errorf("expected user 42 on the second page, got:\n%s", out.String())
+	}
+
+	if err := runUsers(nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected a usage error without an action")
+	}
+}
@@ -0,0 +1,211 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Package testutil provides test doubles shared by the bot's tests.
+
+// FakeTelegram is an in-memory Telegram Bot API that records every call.
+// It implements handlers.TelegramAPI. Sent messages get increasing message IDs
+// and are returned as if Telegram had accepted them.
+type FakeTelegram struct {
+	mu sync.Mutex
+
+	Sent            []*bot.SendMessageParams
+	Documents       []SentDocument
+	EditedTexts     []*bot.EditMessageTextParams
+	EditedMarkups   []*bot.EditMessageReplyMarkupParams
+	CallbackAnswers []*bot.AnswerCallbackQueryParams
+	InlineAnswers   []*bot.AnswerInlineQueryParams
+	Pinned          []*bot.PinChatMessageParams
+
+	// Files maps file IDs to the files returned by GetFile
+	Files map[string]*models.File
+
+	// BusinessConnections maps connection IDs to the connections returned by GetBusinessConnection
+	BusinessConnections map[string]*models.BusinessConnection
+
+	// Err, when set, is returned by every call
+	Err error
+
+	nextMessageID int
+}
+
+// SentDocument is a document upload with its content read into memory
+type SentDocument struct {
+	Params  *bot.SendDocumentParams
+	Content []byte
+}
+
+// NewFakeTelegram creates an empty fake
+func NewFakeTelegram() *FakeTelegram {
+	return &FakeTelegram{Files: make(map[string]*models.File), BusinessConnections: make(map[string]*models.BusinessConnection)}
+}
+
+// SendMessage records params
+func (f *FakeTelegram) SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.Sent = append(f.Sent, params)
+	return f.message(params.ChatID, params.Text), nil
+}
+
+// SendDocument records params and reads uploaded content
+func (f *FakeTelegram) SendDocument(ctx context.Context, params *bot.SendDocumentParams) (*models.Message, error) {
+	var content []byte
+	if upload, ok := params.Document.(*models.InputFileUpload); ok && upload.Data != nil {
+		data, err := io.ReadAll(upload.Data)
+		if err != nil {
+			return nil, err
+		}
+		content = data
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.Documents = append(f.Documents, SentDocument{Params: params, Content: content})
+	return f.message(params.ChatID, params.Caption), nil
+}
+
+// EditMessageText records params
+func (f *FakeTelegram) EditMessageText(ctx context.Context, params *bot.EditMessageTextParams) (*models.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.EditedTexts = append(f.EditedTexts, params)
+	return &models.Message{ID: params.MessageID, Text: params.Text}, nil
+}
+
+// EditMessageReplyMarkup records params
+func (f *FakeTelegram) EditMessageReplyMarkup(ctx context.Context, params *bot.EditMessageReplyMarkupParams) (*models.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.EditedMarkups = append(f.EditedMarkups, params)
+	return &models.Message{ID: params.MessageID}, nil
+}
+
+// AnswerCallbackQuery records params
+func (f *FakeTelegram) AnswerCallbackQuery(ctx context.Context, params *bot.AnswerCallbackQueryParams) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return false, f.Err
+	}
+	f.CallbackAnswers = append(f.CallbackAnswers, params)
+	return true, nil
+}
+
+// PinChatMessage records params
+func (f *FakeTelegram) PinChatMessage(ctx context.Context, params *bot.PinChatMessageParams) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return false, f.Err
+	}
+	f.Pinned = append(f.Pinned, params)
+	return true, nil
+}
+
+// AnswerInlineQuery records params
+func (f *FakeTelegram) AnswerInlineQuery(ctx context.Context, params *bot.AnswerInlineQueryParams) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return false, f.Err
+	}
+	f.InlineAnswers = append(f.InlineAnswers, params)
+	return true, nil
+}
+
+// GetFile returns the file registered in Files
+func (f *FakeTelegram) GetFile(ctx context.Context, params *bot.GetFileParams) (*models.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	file, ok := f.Files[params.FileID]
+	if !ok {
+		return nil, fmt.Errorf("bad request, Bad Request: invalid file_id")
+	}
+	return file, nil
+}
+
+// GetBusinessConnection returns the connection registered in BusinessConnections
+func (f *FakeTelegram) GetBusinessConnection(ctx context.Context, params *bot.GetBusinessConnectionParams) (*models.BusinessConnection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	id, _ := params.BusinessConnectionID.(string)
+	connection, ok := f.BusinessConnections[id]
+	if !ok {
+		return nil, fmt.Errorf("bad request, Bad Request: business connection not found")
+	}
+	return connection, nil
+}
+
+// FileDownloadLink returns a fake download URL
+func (f *FakeTelegram) FileDownloadLink(file *models.File) string {
+	return "https://api.telegram.org/file/bot-fake/" + file.FilePath
+}
+
+// LastText returns the text of the last sent message, or ""
+func (f *FakeTelegram) LastText() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.Sent) == 0 {
+		return ""
+	}
+	return f.Sent[len(f.Sent)-1].Text
+}
+
+// Reset forgets all recorded calls
+func (f *FakeTelegram) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Sent = nil
+	f.Documents = nil
+	f.EditedTexts = nil
+	f.EditedMarkups = nil
+	f.CallbackAnswers = nil
+	f.InlineAnswers = nil
+	f.Pinned = nil
+}
+
+// message builds the message Telegram would return for a send to chatID
+func (f *FakeTelegram) message(chatID any, text string) *models.Message {
+	f.nextMessageID++
+	id, _ := chatID.(int64)
+	return &models.Message{ID: f.nextMessageID, Chat: models.Chat{ID: id}, Text: text}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"tg-bot-demo/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsMode names how the webhook server terminates TLS, for the startup log
+func tlsMode(cfg *config.Config) string {
+	switch {
+	case cfg.TLSCertFile != "":
+		return "files"
+	case len(cfg.TLSAutocertDomains) > 0:
+		return "autocert"
+	default:
+		return "off"
+	}
+}
+
+// webhookTLS returns the TLS config of the webhook server, or nil when TLS is off.
+// With autocert the returned handler answers ACME HTTP-01 challenges and redirects
+// everything else to HTTPS; it is nil otherwise.
+func webhookTLS(cfg *config.Config) (*tls.Config, http.Handler, error) {
+	switch tlsMode(cfg) {
+	case "files":
+		certificate, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load TLS certificate: %w", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}, nil, nil
+	case "autocert":
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+			Email:      cfg.TLSAutocertEmail,
+		}
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, manager.HTTPHandler(nil), nil
+	default:
+		return nil, nil, nil
+	}
+}
+
+// webhookShutdownTimeout bounds how long a stopping server waits for requests in flight
+const webhookShutdownTimeout = 10 * time.Second
+
+// serveWebhook runs server, over HTTPS when TLS is configured, until it fails or
+// ctx is done. Then it stops accepting requests, waits up to webhookShutdownTimeout
+// for those in flight and returns nil.
+func serveWebhook(ctx context.Context, server *http.Server, cfg *config.Config) error {
+	tlsConfig, challengeHandler, err := webhookTLS(cfg)
+	if err != nil {
+		return err
+	}
+
+	serve := server.ListenAndServe
+	var challengeServer *http.Server
+	if tlsConfig != nil {
+		if challengeHandler != nil && cfg.TLSAutocertHTTPAddr != "" {
+			challengeServer = &http.Server{
+				Addr:              cfg.TLSAutocertHTTPAddr,
+				Handler:           challengeHandler,
+				ReadHeaderTimeout: 5 * time.Second,
+			}
+			go func() {
+				if err := challengeServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Printf("acme challenge server stopped: addr=%s err=%v", cfg.TLSAutocertHTTPAddr, err)
+				}
+			}()
+		}
+
+		server.TLSConfig = tlsConfig
+		serve = func() error { return server.ListenAndServeTLS("", "") }
+	}
+
+	failed := make(chan error, 1)
+	go func() { failed <- serve() }()
+	select {
+	case err := <-failed:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+	defer cancel()
+	if challengeServer != nil {
+		challengeServer.Shutdown(shutdownCtx)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shut down webhook server: %w", err)
+	}
+	return nil
+}
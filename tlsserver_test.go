@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"tg-bot-demo/config"
+)
+
+func TestWebhookTLSOff(t *testing.T) {
+	tlsConfig, handler, err := webhookTLS(config.Default())
+	if err != nil || tlsConfig != nil || handler != nil {
+		t.Errorf("expected TLS off by default, got config=%v handler=%v err=%v", tlsConfig, handler, err)
+	}
+}
+
+func TestServeWebhookStopsWhenDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	stopped := make(chan error, 1)
+	go func() { stopped <- serveWebhook(ctx, server, config.Default()) }()
+
+	cancel()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the server to stop")
+	}
+}
+
+func TestWebhookTLSFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeSelfSignedCertificate(t, certFile, keyFile)
+
+	cfg := config.Default()
+	cfg.TLSCertFile = certFile
+	cfg.TLSKeyFile = keyFile
+	tlsConfig, handler, err := webhookTLS(cfg)
+	if err != nil {
+		t.Fatalf("webhookTLS failed: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 || handler != nil {
+		t.Errorf("expected one certificate and no challenge handler, got %d certificates", len(tlsConfig.Certificates))
+	}
+
+	cfg.TLSKeyFile = filepath.Join(dir, "missing.pem")
+	if _, _, err := webhookTLS(cfg); err == nil {
+		t.Error("expected error for a missing key file")
+	}
+}
+
+func TestWebhookTLSAutocert(t *testing.T) {
+	cfg := config.Default()
+	cfg.TLSAutocertDomains = []string{"bot.example.com"}
+	cfg.TLSAutocertCacheDir = t.TempDir()
+
+	tlsConfig, handler, err := webhookTLS(cfg)
+	if err != nil {
+		t.Fatalf("webhookTLS failed: %v", err)
+	}
+	if tlsConfig.GetCertificate == nil || !slices.Contains(tlsConfig.NextProtos, "acme-tls/1") {
+		t.Errorf("expected an ACME TLS config, got %+v", tlsConfig)
+	}
+	if handler == nil {
+		t.Error("expected an HTTP-01 challenge handler")
+	}
+	if tlsMode(cfg) != "autocert" {
+		t.Errorf("expected autocert mode, got %s", tlsMode(cfg))
+	}
+}
+
+// writeSelfSignedCertificate writes a certificate for bot.example.com and its key as PEM
+func writeSelfSignedCertificate(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bot.example.com"},
+		DNSNames:     []string{"bot.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
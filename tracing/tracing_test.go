@@ -0,0 +1,16 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown failed: %v", err)
+	}
+}
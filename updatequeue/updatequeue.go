@@ -0,0 +1,249 @@
+package updatequeue
+
+import (
+	"context"
+	"expvar"
+	"sync"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// Package updatequeue buffers webhook updates for a fixed number of workers.
+// Updates are partitioned by chat: each worker owns a lane, and every update of
+// a chat goes to the same lane, so a chat's updates are handled one at a time
+// and in order while different chats are handled in parallel. This keeps, say,
+// two quick messages from both creating an active session.
+//
+// Offer never blocks: when a lane is full the update is refused, so the
+// webhook can answer Telegram with a retryable status instead of piling up
+// goroutines. Queue depth, utilization and busy workers are published under
+// /debug/vars for tuning the queue size and worker count.
+//
+// On shutdown the webhook server stops accepting updates first; Drain then has
+// the workers handle what is still queued before their context is cancelled.
+//
+// Updates matched by SetExpress skip the lanes and go to an express lane with
+// a worker of its own, so a quick update, such as a button stopping a reply,
+// is not held up behind a slow update of its chat.
+
+// Defaults used when New is given 0
+const (
+	DefaultSize    = 256
+	DefaultWorkers = 8
+)
+
+// Metrics published under /debug/vars
+var (
+	depth     = expvar.NewInt("update_queue_depth")
+	capacity  = expvar.NewInt("update_queue_capacity")
+	workers   = expvar.NewInt("update_queue_workers")
+	busy      = expvar.NewInt("update_queue_busy_workers")
+	rejected  = expvar.NewInt("update_queue_rejected_total")
+	processed = expvar.NewInt("update_queue_processed_total")
+)
+
+func init() {
+	expvar.Publish("update_queue_utilization", expvar.Func(func() any {
+		if c := capacity.Value(); c > 0 {
+			return float64(depth.Value()) / float64(c)
+		}
+		return 0.0
+	}))
+}
+
+// ProcessFunc handles one update
+type ProcessFunc func(ctx context.Context, update *models.Update)
+
+// Queue is a bounded queue of updates handled by a pool of workers, one lane per worker
+type Queue struct {
+	lanes   []chan *models.Update
+	process ProcessFunc
+
+	express   chan *models.Update
+	isExpress func(update *models.Update) bool // nil sends every update to the lanes
+
+	draining  chan struct{} // closed by Drain
+	drainOnce sync.Once
+	stopped   chan struct{} // closed when Run returns
+}
+
+// New creates a queue holding up to size updates for workerCount goroutines
+// running process. Zero uses DefaultSize and DefaultWorkers. The size is split
+// evenly between the workers' lanes, each holding at least one update.
+func New(size, workerCount int, process ProcessFunc) *Queue {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	if workerCount <= 0 {
+		workerCount = DefaultWorkers
+	}
+	laneSize := max((size+workerCount-1)/workerCount, 1)
+
+	q := &Queue{
+		lanes:    make([]chan *models.Update, workerCount),
+		process:  process,
+		draining: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	for i := range q.lanes {
+		q.lanes[i] = make(chan *models.Update, laneSize)
+	}
+	q.express = make(chan *models.Update, laneSize)
+	capacity.Set(int64(q.Cap()))
+	return q
+}
+
+// SetExpress sends the updates match accepts to the express lane, which gives
+// up their order within the chat. It must be called before Offer and Run.
+func (q *Queue) SetExpress(match func(update *models.Update) bool) {
+	q.isExpress = match
+}
+
+// Offer queues update in the lane of its chat and reports whether there was room for it
+func (q *Queue) Offer(update *models.Update) bool {
+	lane := q.lanes[q.lane(update)]
+	if q.isExpress != nil && q.isExpress(update) {
+		lane = q.express
+	}
+	select {
+	case lane <- update:
+		depth.Add(1)
+		return true
+	default:
+		rejected.Add(1)
+		return false
+	}
+}
+
+// lane returns the index of the lane handling update
+func (q *Queue) lane(update *models.Update) int {
+	key := uint64(PartitionKey(update))
+	return int(key % uint64(len(q.lanes)))
+}
+
+// Len returns the number of queued updates
+func (q *Queue) Len() int {
+	n := len(q.express)
+	for _, lane := range q.lanes {
+		n += len(lane)
+	}
+	return n
+}
+
+// Cap returns the number of updates the lanes hold, besides the express lane
+func (q *Queue) Cap() int {
+	return len(q.lanes) * cap(q.lanes[0])
+}
+
+// Run handles queued updates with a worker per lane, and one for the express
+// lane when SetExpress was called, until ctx is done or Drain emptied the lanes.
+// Updates still queued when ctx is done are dropped. Run must be called once.
+func (q *Queue) Run(ctx context.Context) {
+	defer close(q.stopped)
+	lanes := q.lanes
+	if q.isExpress != nil {
+		lanes = append(lanes[:len(lanes):len(lanes)], q.express)
+	}
+	workers.Add(int64(len(lanes)))
+	defer workers.Add(-int64(len(lanes)))
+
+	var wg sync.WaitGroup
+	wg.Add(len(lanes))
+	for _, lane := range lanes {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case update := <-lane:
+					q.handle(ctx, update)
+				case <-q.draining:
+					// Each lane has one worker, so its length only shrinks from here
+					for len(lane) > 0 && ctx.Err() == nil {
+						q.handle(ctx, <-lane)
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// handle processes one update taken from a lane
+func (q *Queue) handle(ctx context.Context, update *models.Update) {
+	depth.Add(-1)
+	busy.Add(1)
+	q.process(ctx, update)
+	busy.Add(-1)
+	processed.Add(1)
+}
+
+// Drain has the workers handle the updates still queued and stop, and waits
+// until they have or ctx is done. Nothing may be offered any more: the webhook
+// server must stop accepting updates before the queue is drained.
+func (q *Queue) Drain(ctx context.Context) error {
+	q.drainOnce.Do(func() { close(q.draining) })
+	select {
+	case <-q.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PartitionKey returns the ID updates are serialized by: the chat of the
+// update, or the user for updates outside a chat such as inline queries. A
+// user's private chat has the user's ID, so both share a lane. Updates with
+// neither are spread by their update ID.
+func PartitionKey(update *models.Update) int64 {
+	if chat := updateChat(update); chat != nil && chat.ID != 0 {
+		return chat.ID
+	}
+	switch {
+	case update.InlineQuery != nil && update.InlineQuery.From != nil:
+		return update.InlineQuery.From.ID
+	case update.ChosenInlineResult != nil:
+		return update.ChosenInlineResult.From.ID
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID
+	case update.BusinessConnection != nil:
+		return update.BusinessConnection.UserChatID
+	}
+	return update.ID
+}
+
+// updateChat returns the chat update happened in, or nil
+func updateChat(update *models.Update) *models.Chat {
+	switch {
+	case update.Message != nil:
+		return &update.Message.Chat
+	case update.EditedMessage != nil:
+		return &update.EditedMessage.Chat
+	case update.ChannelPost != nil:
+		return &update.ChannelPost.Chat
+	case update.EditedChannelPost != nil:
+		return &update.EditedChannelPost.Chat
+	case update.BusinessMessage != nil:
+		return &update.BusinessMessage.Chat
+	case update.EditedBusinessMessage != nil:
+		return &update.EditedBusinessMessage.Chat
+	case update.MessageReaction != nil:
+		return &update.MessageReaction.Chat
+	case update.CallbackQuery != nil:
+		if message := update.CallbackQuery.Message.Message; message != nil {
+			return &message.Chat
+		}
+		if message := update.CallbackQuery.Message.InaccessibleMessage; message != nil {
+			return &message.Chat
+		}
+	case update.MyChatMember != nil:
+		return &update.MyChatMember.Chat
+	case update.ChatMember != nil:
+		return &update.ChatMember.Chat
+	case update.ChatJoinRequest != nil:
+		return &update.ChatJoinRequest.Chat
+	}
+	return nil
+}
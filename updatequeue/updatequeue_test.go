@@ -0,0 +1,246 @@
+package updatequeue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestQueueRefusesUpdatesWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan int64, 3)
+	q := New(2, 1, func(_ context.Context, update *models.Update) {
+		<-release
+		handled <- update.ID
+	})
+
+	for id := int64(1); id <= 2; id++ {
+		if !q.Offer(&models.Update{ID: id}) {
+			t.Fatalf("expected update %d queued", id)
+		}
+	}
+	before := rejected.Value()
+	if q.Offer(&models.Update{ID: 3}) {
+		t.Fatal("expected a full queue to refuse the update")
+	}
+	if rejected.Value() != before+1 {
+		t.Errorf("expected the refusal counted, got %d", rejected.Value()-before)
+	}
+	if q.Len() != 2 || q.Cap() != 2 {
+		t.Errorf("expected 2 of 2 queued, got %d of %d", q.Len(), q.Cap())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	close(release)
+	for want := int64(1); want <= 2; want++ {
+		select {
+		case id := <-handled:
+			if id != want {
+				t.Errorf("expected update %d handled, got %d", want, id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("update %d was not handled", want)
+		}
+	}
+	if !q.Offer(&models.Update{ID: 4}) {
+		t.Error("expected room once the workers drained the queue")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestQueueSerializesUpdatesOfAChat(t *testing.T) {
+	var mu sync.Mutex
+	running := map[int64]bool{}
+	var overlaps, parallel, handled int
+	var order []int64
+	q := New(64, 4, func(_ context.Context, update *models.Update) {
+		chatID := update.Message.Chat.ID
+		mu.Lock()
+		if running[chatID] {
+			overlaps++
+		}
+		if len(running) > 0 {
+			parallel++
+		}
+		running[chatID] = true
+		if chatID == 1 {
+			order = append(order, update.ID)
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		delete(running, chatID)
+		handled++
+		mu.Unlock()
+	})
+
+	// Chats 1 and 2 land in different lanes of the 4
+	for id := int64(1); id <= 5; id++ {
+		for _, chatID := range []int64{1, 2} {
+			if !q.Offer(&models.Update{ID: id, Message: &models.Message{Chat: models.Chat{ID: chatID}}}) {
+				t.Fatalf("expected update %d of chat %d queued", id, chatID)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+	deadline := time.Now().Add(2 * time.Second)
+	for func() bool { mu.Lock(); defer mu.Unlock(); return handled < 10 }() {
+		if time.Now().After(deadline) {
+			t.Fatal("updates were not handled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if overlaps != 0 {
+		t.Errorf("expected the updates of a chat handled one at a time, got %d overlaps", overlaps)
+	}
+	if parallel == 0 {
+		t.Error("expected different chats handled in parallel")
+	}
+	if len(order) != 5 {
+		t.Fatalf("expected 5 updates of chat 1, got %v", order)
+	}
+	for i, id := range order {
+		if id != int64(i+1) {
+			t.Fatalf("expected the updates of a chat handled in order, got %v", order)
+		}
+	}
+}
+
+func TestPartitionKey(t *testing.T) {
+	user := &models.User{ID: 42}
+	tests := []struct {
+		name   string
+		update *models.Update
+		want   int64
+	}{
+		{"message", &models.Update{ID: 1, Message: &models.Message{Chat: models.Chat{ID: -100}}}, -100},
+		{"callback", &models.Update{ID: 1, CallbackQuery: &models.CallbackQuery{From: *user,
+			Message: models.MaybeInaccessibleMessage{Message: &models.Message{Chat: models.Chat{ID: -100}}}}}, -100},
+		{"inline query", &models.Update{ID: 1, InlineQuery: &models.InlineQuery{From: user}}, 42},
+		{"no chat or user", &models.Update{ID: 7}, 7},
+	}
+	for _, tt := range tests {
+		if got := PartitionKey(tt.update); got != tt.want {
+			t.Errorf("%s: expected key %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestQueueExpressLaneSkipsBusyChat(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan int64, 2)
+	q := New(4, 1, func(_ context.Context, update *models.Update) {
+		if update.ID == 1 {
+			<-release
+		}
+		handled <- update.ID
+	})
+	q.SetExpress(func(update *models.Update) bool { return update.CallbackQuery != nil })
+
+	chat := models.Chat{ID: 1}
+	if !q.Offer(&models.Update{ID: 1, Message: &models.Message{Chat: chat}}) {
+		t.Fatal("expected the message queued")
+	}
+	stop := &models.Update{ID: 2, CallbackQuery: &models.CallbackQuery{
+		Message: models.MaybeInaccessibleMessage{Message: &models.Message{Chat: chat}},
+	}}
+	if !q.Offer(stop) {
+		t.Fatal("expected the callback queued")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+	select {
+	case id := <-handled:
+		if id != 2 {
+			t.Fatalf("expected the express update first, got %d", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the express update waited for the busy chat")
+	}
+	close(release)
+	select {
+	case id := <-handled:
+		if id != 1 {
+			t.Errorf("expected the message handled after, got %d", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the message was not handled")
+	}
+}
+
+func TestQueueDrainHandlesQueuedUpdates(t *testing.T) {
+	var mu sync.Mutex
+	var handled []int64
+	q := New(8, 2, func(ctx context.Context, update *models.Update) {
+		if ctx.Err() != nil {
+			t.Errorf("expected update %d handled before the workers were cancelled", update.ID)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, update.ID)
+	})
+	for id := int64(1); id <= 6; id++ {
+		if !q.Offer(&models.Update{ID: id, Message: &models.Message{Chat: models.Chat{ID: id % 2}}}) {
+			t.Fatalf("expected update %d queued", id)
+		}
+	}
+
+	// Draining before the workers run still hands every queued update to them
+	drained := make(chan error, 1)
+	go func() { drained <- q.Drain(context.Background()) }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("Drain failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the queue drained")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 6 || q.Len() != 0 {
+		t.Errorf("expected all 6 updates handled, got %v with %d queued", handled, q.Len())
+	}
+}
+
+func TestQueueDrainGivesUp(t *testing.T) {
+	release := make(chan struct{})
+	q := New(1, 1, func(context.Context, *models.Update) { <-release })
+	defer close(release)
+	q.Offer(&models.Update{ID: 1})
+	go q.Run(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Drain(ctx); err == nil {
+		t.Error("expected Drain to give up on a stuck update")
+	}
+}